@@ -31,6 +31,10 @@ func main() {
 	rootCmd.AddCommand(cmdRPCConsumer)
 	// Add RPC Provider Command
 	rootCmd.AddCommand(cmdRPCProvider)
+	// estimate the rewards of a running provider
+	rootCmd.AddCommand(rpcprovider.CreateRewardsEstimateCobraCommand())
+	// export the paid rewards of a running provider
+	rootCmd.AddCommand(rpcprovider.CreateRewardsExportCobraCommand())
 
 	testCmd := &cobra.Command{
 		Use:   "test",
@@ -39,6 +43,7 @@ func main() {
 	rootCmd.AddCommand(testCmd)
 	testCmd.AddCommand(rpcconsumer.CreateTestRPCConsumerCobraCommand())
 	testCmd.AddCommand(rpcprovider.CreateTestRPCProviderCobraCommand())
+	testCmd.AddCommand(rpcprovider.CreateSoakTestRPCProviderCobraCommand())
 	if err := svrcmd.Execute(rootCmd, app.DefaultNodeHome); err != nil {
 		switch e := err.(type) {
 		case server.ErrorCode:
@@ -0,0 +1,153 @@
+package chainlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+const latestBlockParam = "latest"
+
+// jsonrpc methods whose last parameter is a block that defaults to "latest" when omitted, value is the number of parameters including the block
+var defaultLatestBlockParams = map[string]int{
+	"eth_getBalance":          2,
+	"eth_getCode":             2,
+	"eth_getTransactionCount": 2,
+	"eth_call":                2,
+	"eth_getStorageAt":        3,
+}
+
+// CanonicalRelayRequest returns a copy of the request normalized with CanonicalizeRequestData and without the salt, so semantically
+// identical requests hash the same when used as a cache key. the request itself isn't modified, it is signed
+func CanonicalRelayRequest(request *pairingtypes.RelayRequest) *pairingtypes.RelayRequest {
+	if request == nil || request.RelayData == nil {
+		return request
+	}
+	canonicalData := *request.RelayData
+	canonicalData.Salt = nil
+	canonicalData.Data = CanonicalizeRequestData(canonicalData.ApiInterface, canonicalData.Data)
+	canonicalData.ApiUrl = canonicalizeApiUrl(canonicalData.ApiInterface, canonicalData.ApiUrl)
+	canonicalRequest := *request
+	canonicalRequest.RelayData = &canonicalData
+	return &canonicalRequest
+}
+
+// CanonicalizeRequestData sorts json object keys, lower cases hex strings and fills omitted default parameters.
+// data that isn't json (grpc, rest GET) is returned as is
+func CanonicalizeRequestData(apiInterface string, data []byte) []byte {
+	parsed, ok := parseCanonicalJSON(data)
+	if !ok {
+		return data
+	}
+	if apiInterface == spectypes.APIInterfaceJsonRPC {
+		if batch, isBatch := parsed.([]interface{}); isBatch {
+			for _, message := range batch {
+				fillDefaultParams(message)
+			}
+		} else {
+			fillDefaultParams(parsed)
+		}
+	}
+	return marshalCanonicalJSON(parsed, data)
+}
+
+// CanonicalizeReplyData sorts json object keys and lower cases hex strings, so replies that only differ in encoding compare equal
+func CanonicalizeReplyData(data []byte) []byte {
+	parsed, ok := parseCanonicalJSON(data)
+	if !ok {
+		return data
+	}
+	return marshalCanonicalJSON(parsed, data)
+}
+
+func parseCanonicalJSON(data []byte) (interface{}, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber() // keeps numbers as they were sent instead of going through float64
+	var parsed interface{}
+	if err := decoder.Decode(&parsed); err != nil || decoder.More() {
+		return nil, false
+	}
+	return normalizeJSONValue(parsed), true
+}
+
+// encoding/json sorts map keys, html escaping is disabled so strings are kept as sent
+func marshalCanonicalJSON(parsed interface{}, original []byte) []byte {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(parsed); err != nil {
+		return original
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func normalizeJSONValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range typed {
+			typed[key] = normalizeJSONValue(inner)
+		}
+	case []interface{}:
+		for idx, inner := range typed {
+			typed[idx] = normalizeJSONValue(inner)
+		}
+	case string:
+		if isHexString(typed) {
+			return strings.ToLower(typed)
+		}
+	}
+	return value
+}
+
+func isHexString(value string) bool {
+	if len(value) < 3 || (value[:2] != "0x" && value[:2] != "0X") {
+		return false
+	}
+	for _, char := range value[2:] {
+		if !(char >= '0' && char <= '9') && !(char >= 'a' && char <= 'f') && !(char >= 'A' && char <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func fillDefaultParams(message interface{}) {
+	jsonrpcMessage, ok := message.(map[string]interface{})
+	if !ok {
+		return
+	}
+	method, ok := jsonrpcMessage["method"].(string)
+	if !ok {
+		return
+	}
+	paramsCount, ok := defaultLatestBlockParams[method]
+	if !ok {
+		return
+	}
+	params, ok := jsonrpcMessage["params"].([]interface{})
+	if !ok || len(params) != paramsCount-1 {
+		return
+	}
+	jsonrpcMessage["params"] = append(params, latestBlockParam)
+}
+
+// rest and tendermint uri requests carry their parameters in the url query, encoding it again sorts the keys
+func canonicalizeApiUrl(apiInterface string, apiUrl string) string {
+	if apiInterface != spectypes.APIInterfaceRest && apiInterface != spectypes.APIInterfaceTendermintRPC {
+		return apiUrl
+	}
+	parsedUrl, err := url.Parse(apiUrl)
+	if err != nil || parsedUrl.RawQuery == "" {
+		return apiUrl
+	}
+	parsedUrl.RawQuery = parsedUrl.Query().Encode()
+	return parsedUrl.String()
+}
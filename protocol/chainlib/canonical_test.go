@@ -0,0 +1,115 @@
+package chainlib
+
+import (
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeRequestData(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name         string
+		apiInterface string
+		data1        string
+		data2        string
+		equal        bool
+	}{
+		{
+			name:         "key order",
+			apiInterface: spectypes.APIInterfaceJsonRPC,
+			data1:        `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`,
+			data2:        `{"params":[],"method":"eth_blockNumber","id":1,"jsonrpc":"2.0"}`,
+			equal:        true,
+		},
+		{
+			name:         "hex case",
+			apiInterface: spectypes.APIInterfaceJsonRPC,
+			data1:        `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0xABC",false]}`,
+			data2:        `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0xabc",false]}`,
+			equal:        true,
+		},
+		{
+			name:         "default block param",
+			apiInterface: spectypes.APIInterfaceJsonRPC,
+			data1:        `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x5A0b54D5dc17e0AadC383d2db43B0a0D3E029c4c"]}`,
+			data2:        `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x5a0b54d5dc17e0aadc383d2db43b0a0d3e029c4c","latest"]}`,
+			equal:        true,
+		},
+		{
+			name:         "batch",
+			apiInterface: spectypes.APIInterfaceJsonRPC,
+			data1:        `[{"id":1,"method":"eth_getCode","params":["0x01"]},{"method":"eth_chainId","id":2}]`,
+			data2:        `[{"method":"eth_getCode","id":1,"params":["0x01","latest"]},{"id":2,"method":"eth_chainId"}]`,
+			equal:        true,
+		},
+		{
+			name:         "different block",
+			apiInterface: spectypes.APIInterfaceJsonRPC,
+			data1:        `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x01","0x10"]}`,
+			data2:        `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x01","latest"]}`,
+			equal:        false,
+		},
+		{
+			name:         "large numbers",
+			apiInterface: spectypes.APIInterfaceTendermintRPC,
+			data1:        `{"jsonrpc":"2.0","id":1,"method":"block","params":{"height":18446744073709551615}}`,
+			data2:        `{"jsonrpc":"2.0","id":1,"method":"block","params":{"height":18446744073709551614}}`,
+			equal:        false,
+		},
+		{
+			name:         "not hex",
+			apiInterface: spectypes.APIInterfaceTendermintRPC,
+			data1:        `{"jsonrpc":"2.0","id":1,"method":"abci_query","params":{"path":"0xZZ"}}`,
+			data2:        `{"jsonrpc":"2.0","id":1,"method":"abci_query","params":{"path":"0xzz"}}`,
+			equal:        false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			canonical1 := CanonicalizeRequestData(testCase.apiInterface, []byte(testCase.data1))
+			canonical2 := CanonicalizeRequestData(testCase.apiInterface, []byte(testCase.data2))
+			if testCase.equal {
+				require.Equal(t, string(canonical1), string(canonical2))
+			} else {
+				require.NotEqual(t, string(canonical1), string(canonical2))
+			}
+		})
+	}
+}
+
+func TestCanonicalizeNonJSON(t *testing.T) {
+	t.Parallel()
+	data := []byte{0x0a, 0x03, 0x7b, 0x22}
+	require.Equal(t, data, CanonicalizeRequestData(spectypes.APIInterfaceGrpc, data))
+	require.Equal(t, data, CanonicalizeReplyData(data))
+	require.Empty(t, CanonicalizeRequestData(spectypes.APIInterfaceRest, nil))
+}
+
+func TestCanonicalRelayRequest(t *testing.T) {
+	t.Parallel()
+	request := &pairingtypes.RelayRequest{
+		RelaySession: &pairingtypes.RelaySession{SessionId: 1},
+		RelayData: &pairingtypes.RelayPrivateData{
+			ApiInterface: spectypes.APIInterfaceRest,
+			ApiUrl:       "/cosmos/tx/v1beta1/txs?events=a&pagination.limit=10&order_by=1",
+			Data:         []byte(`{"b":1,"a":"0xFF"}`),
+			Salt:         []byte{1, 2, 3},
+		},
+	}
+	canonical := CanonicalRelayRequest(request)
+	require.Equal(t, "/cosmos/tx/v1beta1/txs?events=a&order_by=1&pagination.limit=10", canonical.RelayData.ApiUrl)
+	require.Equal(t, `{"a":"0xff","b":1}`, string(canonical.RelayData.Data))
+	require.Nil(t, canonical.RelayData.Salt)
+	require.Equal(t, request.RelaySession, canonical.RelaySession)
+	// the original request is signed and must not change
+	require.Equal(t, "/cosmos/tx/v1beta1/txs?events=a&pagination.limit=10&order_by=1", request.RelayData.ApiUrl)
+	require.Equal(t, `{"b":1,"a":"0xFF"}`, string(request.RelayData.Data))
+	require.Equal(t, []byte{1, 2, 3}, request.RelayData.Salt)
+}
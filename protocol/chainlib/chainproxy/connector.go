@@ -7,6 +7,7 @@ package chainproxy
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"log"
 	"strconv"
@@ -18,6 +19,7 @@ import (
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/utils"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -33,13 +35,19 @@ type Connector struct {
 	freeClients []*rpcclient.Client
 	usedClients int64
 	nodeUrl     common.NodeUrl
+	tlsConfig   *tls.Config // nil unless the node url configures tls
 }
 
 func NewConnector(ctx context.Context, nConns uint, nodeUrl common.NodeUrl) (*Connector, error) {
 	NumberOfParallelConnections = nConns // set number of parallel connections requested by user (or default.)
+	tlsConfig, err := nodeUrl.ClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 	connector := &Connector{
 		freeClients: make([]*rpcclient.Client, 0, nConns),
 		nodeUrl:     nodeUrl,
+		tlsConfig:   tlsConfig,
 	}
 
 	rpcClient, err := connector.createConnection(ctx, nodeUrl, connector.numberOfFreeClients())
@@ -102,7 +110,7 @@ func (connector *Connector) createConnection(ctx context.Context, nodeUrl common
 		}
 		nctx, cancel := nodeUrl.LowerContextTimeout(ctx, common.AverageWorldLatency*2)
 		// add auth path
-		rpcClient, err = rpcclient.DialContext(nctx, nodeUrl.AuthConfig.AddAuthPath(nodeUrl.Url))
+		rpcClient, err = rpcclient.DialContextWithTLS(nctx, nodeUrl.AuthConfig.AddAuthPath(nodeUrl.Url), connector.tlsConfig)
 		if err != nil {
 			utils.LavaFormatWarning("Could not connect to the node, retrying", err, []utils.Attribute{
 				{Key: "Current Number Of Connections", Value: currentNumberOfConnections},
@@ -153,7 +161,7 @@ func (connector *Connector) increaseNumberOfClients(ctx context.Context, numberO
 	var err error
 	for connectionAttempt := 0; connectionAttempt < MaximumNumberOfParallelConnectionsAttempts; connectionAttempt++ {
 		nctx, cancel := connector.nodeUrl.LowerContextTimeout(ctx, common.AverageWorldLatency*2)
-		rpcClient, err = rpcclient.DialContext(nctx, connector.nodeUrl.Url)
+		rpcClient, err = rpcclient.DialContextWithTLS(nctx, connector.nodeUrl.Url, connector.tlsConfig)
 		if err != nil {
 			utils.LavaFormatDebug(
 				"could no increase number of connections to the node jsonrpc connector, retrying",
@@ -222,13 +230,22 @@ type GRPCConnector struct {
 	freeClients []*grpc.ClientConn
 	usedClients int64
 	nodeUrl     common.NodeUrl
+	credentials credentials.TransportCredentials // tls if the node url configures it, plaintext otherwise
 }
 
 func NewGRPCConnector(ctx context.Context, nConns uint, nodeUrl common.NodeUrl) (*GRPCConnector, error) {
 	NumberOfParallelConnections = nConns // set number of parallel connections requested by user (or default.)
+	tlsConfig, err := nodeUrl.ClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 	connector := &GRPCConnector{
 		freeClients: make([]*grpc.ClientConn, 0, nConns),
 		nodeUrl:     nodeUrl,
+		credentials: insecure.NewCredentials(),
+	}
+	if tlsConfig != nil {
+		connector.credentials = credentials.NewTLS(tlsConfig)
 	}
 
 	rpcClient, err := connector.createConnection(ctx, nodeUrl.Url, connector.numberOfFreeClients())
@@ -247,7 +264,7 @@ func (connector *GRPCConnector) increaseNumberOfClients(ctx context.Context, num
 	var err error
 	for connectionAttempt := 0; connectionAttempt < MaximumNumberOfParallelConnectionsAttempts; connectionAttempt++ {
 		nctx, cancel := connector.nodeUrl.LowerContextTimeout(ctx, common.AverageWorldLatency*2)
-		grpcClient, err = grpc.DialContext(nctx, connector.nodeUrl.Url, grpc.WithBlock(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		grpcClient, err = grpc.DialContext(nctx, connector.nodeUrl.Url, grpc.WithBlock(), grpc.WithTransportCredentials(connector.credentials))
 		if err != nil {
 			utils.LavaFormatDebug("increaseNumberOfClients, Could not connect to the node, retrying", []utils.Attribute{{Key: "err", Value: err.Error()}, {Key: "Number Of Attempts", Value: connectionAttempt}, {Key: "nodeUrl", Value: connector.nodeUrl.Url}}...)
 			cancel()
@@ -384,7 +401,7 @@ func (connector *GRPCConnector) createConnection(ctx context.Context, addr strin
 			return nil, ctx.Err()
 		}
 		nctx, cancel := connector.nodeUrl.LowerContextTimeout(ctx, common.AverageWorldLatency*2)
-		rpcClient, err = grpc.DialContext(nctx, addr, grpc.WithBlock(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		rpcClient, err = grpc.DialContext(nctx, addr, grpc.WithBlock(), grpc.WithTransportCredentials(connector.credentials))
 		if err != nil {
 			utils.LavaFormatWarning("Could not connect to the node, retrying", err, []utils.Attribute{{
 				Key: "Current Number Of Connections", Value: currentNumberOfConnections,
@@ -18,9 +18,11 @@ package rpcclient
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -28,6 +30,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
 )
 
 var (
@@ -186,6 +189,33 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 	}
 }
 
+// DialContextWithTLS is DialContext with tlsConfig used for https and wss urls, a nil tlsConfig dials just like DialContext.
+func DialContextWithTLS(ctx context.Context, rawurl string, tlsConfig *tls.Config) (*Client, error) {
+	if tlsConfig == nil {
+		return DialContext(ctx, rawurl)
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "https":
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		return DialHTTPWithClient(rawurl, &http.Client{Transport: transport})
+	case "wss":
+		dialer := websocket.Dialer{
+			ReadBufferSize:  wsReadBuffer,
+			WriteBufferSize: wsWriteBuffer,
+			WriteBufferPool: wsBufferPool,
+			TLSClientConfig: tlsConfig,
+		}
+		return DialWebsocketWithDialer(ctx, rawurl, "", dialer)
+	default:
+		return DialContext(ctx, rawurl)
+	}
+}
+
 // ClientFromContext retrieves the client from the context, if any. This can be used to perform
 // 'reverse calls' in a handler method.
 func ClientFromContext(ctx context.Context) (*Client, bool) {
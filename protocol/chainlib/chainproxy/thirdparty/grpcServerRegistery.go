@@ -18,8 +18,8 @@ import (
 	"google.golang.org/grpc"
 )
 
-func RegisterServer(chain string, cb func(ctx context.Context, method string, reqBody []byte) ([]byte, error)) (*grpc.Server, http.Server, error) {
-	s := grpc.NewServer()
+func RegisterServer(chain string, cb func(ctx context.Context, method string, reqBody []byte) ([]byte, error), opts ...grpc.ServerOption) (*grpc.Server, http.Server, error) {
+	s := grpc.NewServer(opts...)
 	wrappedServer := grpcweb.WrapServer(s)
 	handler := func(resp http.ResponseWriter, req *http.Request) {
 		// Set CORS headers
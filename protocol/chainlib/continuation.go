@@ -0,0 +1,162 @@
+package chainlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"google.golang.org/grpc/metadata"
+)
+
+// heavy range queries can be served in parts: a consumer that supports it sends ContinuationSupportedHeader, a provider with a range
+// limit then serves the first part of the range and returns a token for the rest in ContinuationTokenHeader. the consumer sends the same
+// request again with the token to get the next part. a token is the first block of the part it refers to, so any provider can continue it
+const (
+	ContinuationSupportedHeader = "lava-continuation-supported"
+	ContinuationTokenHeader     = "lava-continuation-token"  // on requests the part to serve, on replies the next part
+	ContinuationServedHeader    = "lava-continuation-served" // on replies the token of the part that was served
+	MaxContinuationParts        = 100                        // a consumer stops fetching parts after this many
+)
+
+type continuationTokenKey struct{}
+
+// WithContinuationToken marks the relays sent with ctx as a request for the part the token refers to
+func WithContinuationToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, continuationTokenKey{}, token)
+}
+
+func ContinuationTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(continuationTokenKey{}).(string)
+	return token
+}
+
+// AppendContinuationMetadata adds the consumer's continuation headers to an outgoing relay
+func AppendContinuationMetadata(ctx context.Context) context.Context {
+	ctx = metadata.AppendToOutgoingContext(ctx, ContinuationSupportedHeader, "true")
+	if token := ContinuationTokenFromContext(ctx); token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, ContinuationTokenHeader, token)
+	}
+	return ctx
+}
+
+// GetContinuationRequest reads the continuation headers of an incoming relay
+func GetContinuationRequest(ctx context.Context) (supported bool, token string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false, ""
+	}
+	if values := md.Get(ContinuationSupportedHeader); len(values) == 0 || values[0] != "true" {
+		return false, ""
+	}
+	if values := md.Get(ContinuationTokenHeader); len(values) > 0 {
+		token = values[0]
+	}
+	return true, token
+}
+
+// GetContinuationReply reads the continuation headers of a relay reply
+func GetContinuationReply(header metadata.MD) (servedToken string, nextToken string) {
+	if values := header.Get(ContinuationServedHeader); len(values) > 0 {
+		servedToken = values[0]
+	}
+	if values := header.Get(ContinuationTokenHeader); len(values) > 0 {
+		nextToken = values[0]
+	}
+	return servedToken, nextToken
+}
+
+func encodeContinuationToken(block int64) string {
+	return "0x" + strconv.FormatInt(block, 16)
+}
+
+func parseHexBlock(value interface{}) (int64, bool) {
+	hexValue, ok := value.(string)
+	if !ok || !strings.HasPrefix(hexValue, "0x") {
+		return 0, false
+	}
+	block, err := strconv.ParseInt(hexValue[2:], 16, 64)
+	return block, err == nil && block >= 0
+}
+
+// SplitRangeRequest returns the request data of the part of a range query the token refers to, limited to maxBlocks blocks.
+// partialData is nil when the request isn't split: it isn't a supported range query, or it has no token and its range fits.
+// only jsonrpc eth_getLogs with explicit block numbers is supported
+func SplitRangeRequest(apiInterface string, data []byte, token string, maxBlocks uint64) (partialData []byte, servedToken string, nextToken string, err error) {
+	if apiInterface != spectypes.APIInterfaceJsonRPC || maxBlocks == 0 {
+		return nil, "", "", nil
+	}
+	message := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if decoder.Decode(&message) != nil || message["method"] != "eth_getLogs" {
+		return nil, "", "", nil
+	}
+	params, ok := message["params"].([]interface{})
+	if !ok || len(params) != 1 {
+		return nil, "", "", nil
+	}
+	filter, ok := params[0].(map[string]interface{})
+	if !ok {
+		return nil, "", "", nil
+	}
+	fromBlock, okFrom := parseHexBlock(filter["fromBlock"])
+	toBlock, okTo := parseHexBlock(filter["toBlock"])
+	if !okFrom || !okTo || toBlock < fromBlock {
+		return nil, "", "", nil
+	}
+	start := fromBlock
+	if token != "" {
+		start, ok = parseHexBlock(token)
+		if !ok || start < fromBlock || start > toBlock {
+			return nil, "", "", utils.LavaFormatWarning("invalid continuation token", nil, utils.Attribute{Key: "token", Value: token}, utils.Attribute{Key: "fromBlock", Value: fromBlock}, utils.Attribute{Key: "toBlock", Value: toBlock})
+		}
+	} else if uint64(toBlock-fromBlock+1) <= maxBlocks {
+		return nil, "", "", nil
+	}
+	end := start + int64(maxBlocks) - 1
+	if end >= toBlock {
+		end = toBlock
+	} else {
+		nextToken = encodeContinuationToken(end + 1)
+	}
+	filter["fromBlock"] = encodeContinuationToken(start)
+	filter["toBlock"] = encodeContinuationToken(end)
+	partialData, err = json.Marshal(message)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return partialData, encodeContinuationToken(start), nextToken, nil
+}
+
+// MergePartialReplies appends the results of a part to the results merged so far, the other reply fields are kept from merged
+func MergePartialReplies(merged []byte, part []byte) ([]byte, error) {
+	mergedReply := map[string]json.RawMessage{}
+	if err := json.Unmarshal(merged, &mergedReply); err != nil {
+		return nil, err
+	}
+	partReply := map[string]json.RawMessage{}
+	if err := json.Unmarshal(part, &partReply); err != nil {
+		return nil, err
+	}
+	if partError, ok := partReply["error"]; ok && string(partError) != "null" {
+		return nil, utils.LavaFormatWarning("partial reply returned an error", nil, utils.Attribute{Key: "error", Value: string(partError)})
+	}
+	mergedResults := []json.RawMessage{}
+	if err := json.Unmarshal(mergedReply["result"], &mergedResults); err != nil {
+		return nil, err
+	}
+	partResults := []json.RawMessage{}
+	if err := json.Unmarshal(partReply["result"], &partResults); err != nil {
+		return nil, err
+	}
+	results, err := json.Marshal(append(mergedResults, partResults...))
+	if err != nil {
+		return nil, err
+	}
+	mergedReply["result"] = results
+	return json.Marshal(mergedReply)
+}
@@ -0,0 +1,88 @@
+package chainlib
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSplitRangeRequest(t *testing.T) {
+	t.Parallel()
+	data := []byte(`{"jsonrpc":"2.0","id":7,"method":"eth_getLogs","params":[{"fromBlock":"0x10","toBlock":"0x2d","address":"0xabc"}]}`) // 30 blocks
+	maxBlocks := uint64(10)
+
+	parts := []string{}
+	token := ""
+	for {
+		partialData, servedToken, nextToken, err := SplitRangeRequest(spectypes.APIInterfaceJsonRPC, data, token, maxBlocks)
+		require.NoError(t, err)
+		require.NotNil(t, partialData)
+		if token != "" {
+			require.Equal(t, token, servedToken)
+		}
+		message := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(partialData, &message))
+		require.EqualValues(t, 7, message["id"])
+		filter := message["params"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "0xabc", filter["address"])
+		parts = append(parts, filter["fromBlock"].(string)+"-"+filter["toBlock"].(string))
+		if nextToken == "" {
+			break
+		}
+		token = nextToken
+	}
+	require.Equal(t, []string{"0x10-0x19", "0x1a-0x23", "0x24-0x2d"}, parts)
+
+	// fits in a single part
+	partialData, _, _, err := SplitRangeRequest(spectypes.APIInterfaceJsonRPC, data, "", 30)
+	require.NoError(t, err)
+	require.Nil(t, partialData)
+	// token out of the requested range
+	_, _, _, err = SplitRangeRequest(spectypes.APIInterfaceJsonRPC, data, "0x2e", maxBlocks)
+	require.Error(t, err)
+	// not a range query
+	partialData, _, _, err = SplitRangeRequest(spectypes.APIInterfaceJsonRPC, []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`), "", maxBlocks)
+	require.NoError(t, err)
+	require.Nil(t, partialData)
+	// block tags can't be split
+	partialData, _, _, err = SplitRangeRequest(spectypes.APIInterfaceJsonRPC, []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x1","toBlock":"latest"}]}`), "", maxBlocks)
+	require.NoError(t, err)
+	require.Nil(t, partialData)
+}
+
+func TestMergePartialReplies(t *testing.T) {
+	t.Parallel()
+	merged, err := MergePartialReplies([]byte(`{"jsonrpc":"2.0","id":7,"result":[{"blockNumber":"0x10"}]}`), []byte(`{"jsonrpc":"2.0","id":7,"result":[{"blockNumber":"0x1a"},{"blockNumber":"0x1b"}]}`))
+	require.NoError(t, err)
+	reply := struct {
+		ID     int                 `json:"id"`
+		Result []map[string]string `json:"result"`
+	}{}
+	require.NoError(t, json.Unmarshal(merged, &reply))
+	require.Equal(t, 7, reply.ID)
+	require.Equal(t, []map[string]string{{"blockNumber": "0x10"}, {"blockNumber": "0x1a"}, {"blockNumber": "0x1b"}}, reply.Result)
+
+	_, err = MergePartialReplies(merged, []byte(`{"jsonrpc":"2.0","id":7,"error":{"code":-32000,"message":"limit exceeded"}}`))
+	require.Error(t, err)
+}
+
+func TestContinuationMetadata(t *testing.T) {
+	t.Parallel()
+	ctx := AppendContinuationMetadata(WithContinuationToken(context.Background(), "0x1a"))
+	outgoing, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+	supported, token := GetContinuationRequest(metadata.NewIncomingContext(context.Background(), outgoing))
+	require.True(t, supported)
+	require.Equal(t, "0x1a", token)
+
+	supported, _ = GetContinuationRequest(context.Background())
+	require.False(t, supported)
+
+	servedToken, nextToken := GetContinuationReply(metadata.Pairs(ContinuationServedHeader, "0x1a", ContinuationTokenHeader, "0x24"))
+	require.Equal(t, "0x1a", servedToken)
+	require.Equal(t, "0x24", nextToken)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -34,9 +35,10 @@ import (
 )
 
 type GrpcChainParser struct {
-	spec       spectypes.Spec
-	rwLock     sync.RWMutex
-	serverApis map[string]spectypes.ServiceApi
+	spec        spectypes.Spec
+	rwLock      sync.RWMutex
+	serverApis  map[string]spectypes.ServiceApi
+	descriptors *GrpcDescriptors
 	BaseChainParser
 }
 
@@ -151,6 +153,53 @@ func (apip *GrpcChainParser) SetSpec(spec spectypes.Spec) {
 	apip.BaseChainParser.SetTaggedApis(taggedApis)
 }
 
+// SetDescriptors sets the descriptors of services that aren't compiled in, for serving them over reflection and relaying them
+func (apip *GrpcChainParser) SetDescriptors(descriptors *GrpcDescriptors) {
+	// Guard that the GrpcChainParser instance exists
+	if apip == nil {
+		return
+	}
+
+	apip.rwLock.Lock()
+	defer apip.rwLock.Unlock()
+	apip.descriptors = descriptors
+}
+
+func (apip *GrpcChainParser) getDescriptors() *GrpcDescriptors {
+	// Guard that the GrpcChainParser instance exists
+	if apip == nil {
+		return nil
+	}
+
+	apip.rwLock.RLock()
+	defer apip.rwLock.RUnlock()
+	return apip.descriptors
+}
+
+// GetServices returns the sorted names of the grpc services with enabled apis in the spec
+func (apip *GrpcChainParser) GetServices() []string {
+	// Guard that the GrpcChainParser instance exists
+	if apip == nil {
+		return nil
+	}
+
+	apip.rwLock.RLock()
+	defer apip.rwLock.RUnlock()
+	servicesSet := map[string]struct{}{}
+	for name, api := range apip.serverApis {
+		svc, _ := rpcInterfaceMessages.ParseSymbol(name)
+		if api.Enabled && svc != "" {
+			servicesSet[svc] = struct{}{}
+		}
+	}
+	services := make([]string, 0, len(servicesSet))
+	for svc := range servicesSet {
+		services = append(services, svc)
+	}
+	sort.Strings(services)
+	return services
+}
+
 // DataReliabilityParams returns data reliability params from spec (spec.enabled and spec.dataReliabilityThreshold)
 func (apip *GrpcChainParser) DataReliabilityParams() (enabled bool, dataReliabilityThreshold uint32) {
 	// Guard that the GrpcChainParser instance exists
@@ -189,14 +238,16 @@ type GrpcChainListener struct {
 	endpoint    *lavasession.RPCEndpoint
 	relaySender RelaySender
 	logger      *common.RPCConsumerLogs
+	chainParser *GrpcChainParser
 }
 
-func NewGrpcChainListener(ctx context.Context, listenEndpoint *lavasession.RPCEndpoint, relaySender RelaySender, rpcConsumerLogs *common.RPCConsumerLogs) (chainListener *GrpcChainListener) {
+func NewGrpcChainListener(ctx context.Context, listenEndpoint *lavasession.RPCEndpoint, relaySender RelaySender, rpcConsumerLogs *common.RPCConsumerLogs, chainParser *GrpcChainParser) (chainListener *GrpcChainListener) {
 	// Create a new instance of GrpcChainListener
 	chainListener = &GrpcChainListener{
 		listenEndpoint,
 		relaySender,
 		rpcConsumerLogs,
+		chainParser,
 	}
 
 	return chainListener
@@ -232,7 +283,9 @@ func (apil *GrpcChainListener) Serve(ctx context.Context) {
 		return relayReply.Data, nil
 	}
 
-	_, httpServer, err := thirdparty.RegisterServer(apil.endpoint.ChainID, sendRelayCallback)
+	// reflection describes the services of the spec, like the node does
+	specReflection := &grpcSpecReflection{chainParser: apil.chainParser}
+	_, httpServer, err := thirdparty.RegisterServer(apil.endpoint.ChainID, sendRelayCallback, grpc.StreamInterceptor(specReflection.streamInterceptor), grpc.UnknownServiceHandler(specReflection.unknownServiceHandler(sendRelayCallback)))
 	if err != nil {
 		utils.LavaFormatFatal("provider failure RegisterServer", err, utils.Attribute{Key: "listenAddr", Value: apil.endpoint.NetworkAddress})
 	}
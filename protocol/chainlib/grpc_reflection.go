@@ -0,0 +1,246 @@
+package chainlib
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	"github.com/lavanet/lava/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionpbo "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	GrpcDescriptorSetFlagName = "grpc-descriptor-set"
+	grpcReflectionPackage     = "grpc.reflection."
+	grpcReflectionInfoMethod  = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// GrpcDescriptors holds the descriptors of grpc services that aren't compiled into the consumer, so they can be served over reflection and relayed
+type GrpcDescriptors struct {
+	files map[string]*desc.FileDescriptor
+}
+
+func NewGrpcDescriptors(fileDescriptorSet *descriptor.FileDescriptorSet) (*GrpcDescriptors, error) {
+	files, err := desc.CreateFileDescriptorsFromSet(fileDescriptorSet)
+	if err != nil {
+		return nil, err
+	}
+	return &GrpcDescriptors{files: files}, nil
+}
+
+// LoadGrpcDescriptors reads a FileDescriptorSet file created with protoc --descriptor_set_out --include_imports, returns nil when path is empty
+func LoadGrpcDescriptors(path string) (*GrpcDescriptors, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed reading grpc descriptor set", err, utils.Attribute{Key: "path", Value: path})
+	}
+	fileDescriptorSet := &descriptor.FileDescriptorSet{}
+	err = proto.Unmarshal(data, fileDescriptorSet)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed parsing grpc descriptor set", err, utils.Attribute{Key: "path", Value: path})
+	}
+	grpcDescriptors, err := NewGrpcDescriptors(fileDescriptorSet)
+	if err != nil {
+		return nil, utils.LavaFormatError("invalid grpc descriptor set", err, utils.Attribute{Key: "path", Value: path})
+	}
+	utils.LavaFormatInfo("loaded grpc descriptor set", utils.Attribute{Key: "path", Value: path}, utils.Attribute{Key: "files", Value: len(grpcDescriptors.files)})
+	return grpcDescriptors, nil
+}
+
+func (gd *GrpcDescriptors) hasService(service string) bool {
+	if gd == nil {
+		return false
+	}
+	_, ok := gd.findSymbol(service).(*desc.ServiceDescriptor)
+	return ok
+}
+
+func (gd *GrpcDescriptors) findFile(name string) *desc.FileDescriptor {
+	if gd == nil {
+		return nil
+	}
+	return gd.files[name]
+}
+
+func (gd *GrpcDescriptors) findSymbol(symbol string) desc.Descriptor {
+	if gd == nil {
+		return nil
+	}
+	for _, file := range gd.files {
+		if descriptor := file.FindSymbol(symbol); descriptor != nil {
+			return descriptor
+		}
+	}
+	return nil
+}
+
+// findMethod returns the descriptor of a method path, e.g. /cosmos.bank.v1beta1.Query/AllBalances
+func (gd *GrpcDescriptors) findMethod(fullMethod string) *desc.MethodDescriptor {
+	svc, methodName := rpcInterfaceMessages.ParseSymbol(strings.TrimPrefix(fullMethod, "/"))
+	serviceDescriptor, ok := gd.findSymbol(svc).(*desc.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return serviceDescriptor.FindMethodByName(methodName)
+}
+
+// encodes the file and its transitive dependencies the way reflection responses carry them
+func encodeFileWithDependencies(file *desc.FileDescriptor) ([][]byte, error) {
+	encoded := [][]byte{}
+	sent := map[string]struct{}{}
+	var encode func(file *desc.FileDescriptor) error
+	encode = func(file *desc.FileDescriptor) error {
+		if _, ok := sent[file.GetName()]; ok {
+			return nil
+		}
+		sent[file.GetName()] = struct{}{}
+		data, err := proto.Marshal(file.AsFileDescriptorProto())
+		if err != nil {
+			return err
+		}
+		encoded = append(encoded, data)
+		for _, dependency := range file.GetDependencies() {
+			err = encode(dependency)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return encoded, encode(file)
+}
+
+// grpcSpecReflection makes the consumer grpc server look like the node: reflection lists the services of the spec,
+// and services that aren't compiled in are described and relayed using the descriptors set on the chain parser
+type grpcSpecReflection struct {
+	chainParser *GrpcChainParser
+}
+
+func (gsr *grpcSpecReflection) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if info.FullMethod != grpcReflectionInfoMethod || gsr.chainParser == nil {
+		return handler(srv, stream)
+	}
+	return handler(srv, &specReflectionStream{ServerStream: stream, reflection: gsr})
+}
+
+// unknownServiceHandler relays methods of the spec that aren't compiled in, the request is parsed with the loaded descriptors
+func (gsr *grpcSpecReflection) unknownServiceHandler(sendRelay func(ctx context.Context, method string, reqBody []byte) ([]byte, error)) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		methodDescriptor := gsr.chainParser.getDescriptors().findMethod(fullMethod)
+		if methodDescriptor == nil {
+			return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+		}
+		if methodDescriptor.IsClientStreaming() || methodDescriptor.IsServerStreaming() {
+			return status.Errorf(codes.Unimplemented, "streaming method %s isn't supported", fullMethod)
+		}
+		request := dynamic.NewMessage(methodDescriptor.GetInputType())
+		err := stream.RecvMsg(request)
+		if err != nil {
+			return err
+		}
+		reqBody, err := request.MarshalJSONPB(&jsonpb.Marshaler{OrigName: true})
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed marshaling request of %s: %s", fullMethod, err)
+		}
+		reply, err := sendRelay(stream.Context(), strings.TrimPrefix(fullMethod, "/"), reqBody)
+		if err != nil {
+			return err
+		}
+		response := dynamic.NewMessage(methodDescriptor.GetOutputType())
+		err = response.Unmarshal(reply)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed parsing reply of %s: %s", fullMethod, err)
+		}
+		return stream.SendMsg(response)
+	}
+}
+
+// lists the spec services the server can describe, and the reflection service itself
+func (gsr *grpcSpecReflection) listServices(registered []*reflectionpbo.ServiceResponse) []*reflectionpbo.ServiceResponse {
+	services := map[string]struct{}{}
+	for _, serviceResponse := range registered {
+		if strings.HasPrefix(serviceResponse.Name, grpcReflectionPackage) {
+			services[serviceResponse.Name] = struct{}{}
+		}
+	}
+	for _, service := range gsr.chainParser.GetServices() {
+		services[service] = struct{}{}
+	}
+	descriptors := gsr.chainParser.getDescriptors()
+	serviceResponses := []*reflectionpbo.ServiceResponse{}
+	for _, serviceResponse := range registered {
+		if _, ok := services[serviceResponse.Name]; ok {
+			serviceResponses = append(serviceResponses, serviceResponse)
+			delete(services, serviceResponse.Name)
+		}
+	}
+	for service := range services {
+		if descriptors.hasService(service) {
+			serviceResponses = append(serviceResponses, &reflectionpbo.ServiceResponse{Name: service})
+		}
+	}
+	sort.Slice(serviceResponses, func(i, j int) bool { return serviceResponses[i].Name < serviceResponses[j].Name })
+	return serviceResponses
+}
+
+// answers from the loaded descriptors what the compiled in descriptors don't have
+func (gsr *grpcSpecReflection) describe(request *reflectionpbo.ServerReflectionRequest) *reflectionpbo.FileDescriptorResponse {
+	descriptors := gsr.chainParser.getDescriptors()
+	var file *desc.FileDescriptor
+	switch messageRequest := request.GetMessageRequest().(type) {
+	case *reflectionpbo.ServerReflectionRequest_FileByFilename:
+		file = descriptors.findFile(messageRequest.FileByFilename)
+	case *reflectionpbo.ServerReflectionRequest_FileContainingSymbol:
+		if descriptor := descriptors.findSymbol(messageRequest.FileContainingSymbol); descriptor != nil {
+			file = descriptor.GetFile()
+		}
+	}
+	if file == nil {
+		return nil
+	}
+	encoded, err := encodeFileWithDependencies(file)
+	if err != nil {
+		utils.LavaFormatWarning("failed encoding grpc descriptor for reflection", err, utils.Attribute{Key: "file", Value: file.GetName()})
+		return nil
+	}
+	return &reflectionpbo.FileDescriptorResponse{FileDescriptorProto: encoded}
+}
+
+type specReflectionStream struct {
+	grpc.ServerStream
+	reflection *grpcSpecReflection
+}
+
+func (srs *specReflectionStream) SendMsg(m interface{}) error {
+	response, ok := m.(*reflectionpbo.ServerReflectionResponse)
+	if !ok {
+		return srs.ServerStream.SendMsg(m)
+	}
+	switch messageResponse := response.MessageResponse.(type) {
+	case *reflectionpbo.ServerReflectionResponse_ListServicesResponse:
+		if len(srs.reflection.chainParser.GetServices()) > 0 {
+			messageResponse.ListServicesResponse.Service = srs.reflection.listServices(messageResponse.ListServicesResponse.Service)
+		}
+	case *reflectionpbo.ServerReflectionResponse_ErrorResponse:
+		if messageResponse.ErrorResponse.ErrorCode == int32(codes.NotFound) {
+			if fileDescriptorResponse := srs.reflection.describe(response.OriginalRequest); fileDescriptorResponse != nil {
+				response.MessageResponse = &reflectionpbo.ServerReflectionResponse_FileDescriptorResponse{FileDescriptorResponse: fileDescriptorResponse}
+			}
+		}
+	}
+	return srs.ServerStream.SendMsg(response)
+}
@@ -0,0 +1,107 @@
+package chainlib
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/thirdparty"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpbo "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// a service that isn't compiled into the consumer
+func echoDescriptorSet() *descriptor.FileDescriptorSet {
+	textField := &descriptor.FieldDescriptorProto{
+		Name:     proto.String("text"),
+		JsonName: proto.String("text"),
+		Number:   proto.Int32(1),
+		Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+	}
+	return &descriptor.FileDescriptorSet{File: []*descriptor.FileDescriptorProto{{
+		Name:    proto.String("lavatest/echo.proto"),
+		Package: proto.String("lavatest"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("EchoRequest"), Field: []*descriptor.FieldDescriptorProto{textField}},
+			{Name: proto.String("EchoResponse"), Field: []*descriptor.FieldDescriptorProto{textField}},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name: proto.String("Echo"),
+			Method: []*descriptor.MethodDescriptorProto{
+				{Name: proto.String("Echo"), InputType: proto.String(".lavatest.EchoRequest"), OutputType: proto.String(".lavatest.EchoResponse")},
+			},
+		}},
+	}}}
+}
+
+func TestGrpcSpecReflection(t *testing.T) {
+	grpcDescriptors, err := NewGrpcDescriptors(echoDescriptorSet())
+	require.NoError(t, err)
+	apip := &GrpcChainParser{serverApis: map[string]spectypes.ServiceApi{
+		"lavatest.Echo/Echo":                        {Name: "lavatest.Echo/Echo", Enabled: true},
+		"cosmos.bank.v1beta1.Query/AllBalances":     {Name: "cosmos.bank.v1beta1.Query/AllBalances", Enabled: true},
+		"cosmos.staking.v1beta1.Query/Validators":   {Name: "cosmos.staking.v1beta1.Query/Validators", Enabled: false},
+		"lavatest.Missing/Missing":                  {Name: "lavatest.Missing/Missing", Enabled: true},
+		"cosmos.base.tendermint.v1beta1.Service/Ok": {Name: "cosmos.base.tendermint.v1beta1.Service/Ok", Enabled: true},
+	}}
+	apip.SetDescriptors(grpcDescriptors)
+	require.Equal(t, []string{"cosmos.bank.v1beta1.Query", "cosmos.base.tendermint.v1beta1.Service", "lavatest.Echo", "lavatest.Missing"}, apip.GetServices())
+
+	relayedMethods := []string{}
+	sendRelay := func(ctx context.Context, method string, reqBody []byte) ([]byte, error) {
+		relayedMethods = append(relayedMethods, method)
+		require.JSONEq(t, `{"text":"hello"}`, string(reqBody))
+		reply := dynamic.NewMessage(grpcDescriptors.findMethod(method).GetOutputType())
+		reply.SetFieldByName("text", "hello back")
+		return reply.Marshal()
+	}
+	specReflection := &grpcSpecReflection{chainParser: apip}
+	server, _, err := thirdparty.RegisterServer("LAV1", sendRelay, grpc.StreamInterceptor(specReflection.streamInterceptor), grpc.UnknownServiceHandler(specReflection.unknownServiceHandler(sendRelay)))
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// only spec services the server can describe are listed
+	reflectionClient := grpcreflect.NewClient(ctx, reflectionpbo.NewServerReflectionClient(conn))
+	defer reflectionClient.Reset()
+	services, err := reflectionClient.ListServices()
+	require.NoError(t, err)
+	require.Equal(t, []string{"cosmos.bank.v1beta1.Query", "cosmos.base.tendermint.v1beta1.Service", "grpc.reflection.v1alpha.ServerReflection", "lavatest.Echo"}, services)
+
+	// compiled in services are described as before, and the others from the descriptor set
+	_, err = reflectionClient.ResolveService("cosmos.bank.v1beta1.Query")
+	require.NoError(t, err)
+	echoService, err := reflectionClient.ResolveService("lavatest.Echo")
+	require.NoError(t, err)
+	_, err = reflectionClient.FileByFilename("lavatest/echo.proto")
+	require.NoError(t, err)
+	_, err = reflectionClient.ResolveService("lavatest.Missing")
+	require.Error(t, err)
+
+	// a client built from reflection can call the service through the consumer
+	echoMethod := echoService.FindMethodByName("Echo")
+	request := dynamic.NewMessage(echoMethod.GetInputType())
+	request.SetFieldByName("text", "hello")
+	response, err := grpcdynamic.NewStub(conn).InvokeRpc(ctx, echoMethod, request)
+	require.NoError(t, err)
+	responseMessage, err := dynamic.AsDynamicMessage(response)
+	require.NoError(t, err)
+	require.Equal(t, "hello back", responseMessage.GetFieldByName("text"))
+	require.Equal(t, []string{"lavatest.Echo/Echo"}, relayedMethods)
+}
@@ -0,0 +1,190 @@
+package chainlib
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+const (
+	MethodNotFoundErrorCode      = -32601 // json-rpc error code for a method the node doesn't have
+	DefaultMethodAvailabilityTTL = 10 * time.Minute
+	rpcModulesMethod             = "rpc_modules"
+	rpcModulesProbeTimeout       = 5 * time.Second
+)
+
+type nodeMethods struct {
+	modules     map[string]struct{}  // namespaces returned by rpc_modules, nil if the node wasn't probed
+	unsupported map[string]time.Time // methods the node replied method not found to, and when
+}
+
+// MethodAvailabilityCache remembers which rpc methods a node doesn't have, so relays for them are rejected without querying the node.
+// it is keyed by the node urls and shared by all the chains of the provider, a node serving several namespaces is probed once.
+// methods are learned from the rpc_modules probe and from method not found replies, which are forgotten after ttl in case the node is upgraded
+type MethodAvailabilityCache struct {
+	lock  sync.RWMutex
+	ttl   time.Duration
+	nodes map[string]*nodeMethods
+}
+
+func NewMethodAvailabilityCache(ttl time.Duration) *MethodAvailabilityCache {
+	if ttl == 0 {
+		ttl = DefaultMethodAvailabilityTTL
+	}
+	return &MethodAvailabilityCache{ttl: ttl, nodes: map[string]*nodeMethods{}}
+}
+
+// must be called with the lock locked
+func (mac *MethodAvailabilityCache) nodeUnsafe(nodeKey string) *nodeMethods {
+	node, ok := mac.nodes[nodeKey]
+	if !ok {
+		node = &nodeMethods{unsupported: map[string]time.Time{}}
+		mac.nodes[nodeKey] = node
+	}
+	return node
+}
+
+func (mac *MethodAvailabilityCache) SetModules(nodeKey string, modules []string) {
+	if mac == nil {
+		return
+	}
+	mac.lock.Lock()
+	defer mac.lock.Unlock()
+	node := mac.nodeUnsafe(nodeKey)
+	node.modules = make(map[string]struct{}, len(modules))
+	for _, module := range modules {
+		node.modules[module] = struct{}{}
+	}
+}
+
+// ObserveReply learns from a json-rpc node reply to method, a method not found error marks it unsupported and any other reply supported
+func (mac *MethodAvailabilityCache) ObserveReply(nodeKey string, apiInterface string, method string, reply []byte) {
+	if mac == nil || (apiInterface != spectypes.APIInterfaceJsonRPC && apiInterface != spectypes.APIInterfaceTendermintRPC) {
+		return
+	}
+	message := struct {
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}{}
+	if json.Unmarshal(reply, &message) != nil {
+		return
+	}
+	notFound := message.Error != nil && message.Error.Code == MethodNotFoundErrorCode
+	mac.lock.Lock()
+	defer mac.lock.Unlock()
+	if notFound {
+		mac.nodeUnsafe(nodeKey).unsupported[method] = time.Now()
+		return
+	}
+	if node, ok := mac.nodes[nodeKey]; ok {
+		delete(node.unsupported, method)
+	}
+}
+
+// IsSupported returns false if the node is known not to have the method, unknown methods are assumed supported
+func (mac *MethodAvailabilityCache) IsSupported(nodeKey string, method string) bool {
+	if mac == nil {
+		return true
+	}
+	mac.lock.RLock()
+	defer mac.lock.RUnlock()
+	node, ok := mac.nodes[nodeKey]
+	if !ok {
+		return true
+	}
+	if seen, ok := node.unsupported[method]; ok && time.Since(seen) < mac.ttl {
+		return false
+	}
+	if node.modules != nil {
+		// json-rpc methods are namespaced as namespace_method
+		if namespace, _, found := strings.Cut(method, "_"); found {
+			_, ok := node.modules[namespace]
+			return ok
+		}
+	}
+	return true
+}
+
+// GetAvailability returns the probed namespaces and the methods the node replied method not found to, both sorted
+func (mac *MethodAvailabilityCache) GetAvailability(nodeKey string) (modules []string, unsupported []string) {
+	if mac == nil {
+		return nil, nil
+	}
+	mac.lock.RLock()
+	defer mac.lock.RUnlock()
+	node, ok := mac.nodes[nodeKey]
+	if !ok {
+		return nil, nil
+	}
+	for module := range node.modules {
+		modules = append(modules, module)
+	}
+	for method, seen := range node.unsupported {
+		if time.Since(seen) < mac.ttl {
+			unsupported = append(unsupported, method)
+		}
+	}
+	sort.Strings(modules)
+	sort.Strings(unsupported)
+	return modules, unsupported
+}
+
+// ProbeModules asks a json-rpc node for its namespaces with rpc_modules, which doesn't change the node's state.
+// nodes that are already probed and specs without rpc_modules are skipped
+func (mac *MethodAvailabilityCache) ProbeModules(ctx context.Context, nodeKey string, apiInterface string, chainParser ChainParser, chainProxy ChainProxy) error {
+	if mac == nil || apiInterface != spectypes.APIInterfaceJsonRPC {
+		return nil
+	}
+	mac.lock.RLock()
+	node, ok := mac.nodes[nodeKey]
+	probed := ok && node.modules != nil
+	mac.lock.RUnlock()
+	if probed {
+		return nil
+	}
+	chainMessage, err := chainParser.ParseMsg("", []byte(`{"jsonrpc":"2.0","id":1,"method":"`+rpcModulesMethod+`","params":[]}`), "")
+	if err != nil {
+		// the spec doesn't have rpc_modules, methods are learned from replies only
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, rpcModulesProbeTimeout)
+	defer cancel()
+	reply, _, _, err := chainProxy.SendNodeMsg(ctx, nil, chainMessage)
+	if err != nil {
+		return utils.LavaFormatWarning("failed probing node rpc modules", err, utils.Attribute{Key: "node", Value: nodeKey})
+	}
+	modules, err := ParseRPCModulesReply(reply.Data)
+	if err != nil {
+		return utils.LavaFormatWarning("failed parsing node rpc modules", err, utils.Attribute{Key: "node", Value: nodeKey}, utils.Attribute{Key: "reply", Value: string(reply.Data)})
+	}
+	mac.SetModules(nodeKey, modules)
+	utils.LavaFormatInfo("probed node rpc modules", utils.Attribute{Key: "node", Value: nodeKey}, utils.Attribute{Key: "modules", Value: modules})
+	return nil
+}
+
+// ParseRPCModulesReply returns the namespaces of an rpc_modules reply, whose result maps each namespace to its version
+func ParseRPCModulesReply(reply []byte) ([]string, error) {
+	message := struct {
+		Result map[string]string `json:"result"`
+	}{}
+	err := json.Unmarshal(reply, &message)
+	if err != nil {
+		return nil, err
+	}
+	if message.Result == nil {
+		return nil, utils.LavaFormatWarning("rpc_modules reply has no result", nil)
+	}
+	modules := make([]string, 0, len(message.Result))
+	for module := range message.Result {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
@@ -0,0 +1,57 @@
+package chainlib
+
+import (
+	"testing"
+	"time"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodAvailabilityCache(t *testing.T) {
+	t.Parallel()
+	methodNotFound := []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method debug_traceTransaction does not exist/is not available"}}`)
+	cache := NewMethodAvailabilityCache(time.Minute)
+	require.True(t, cache.IsSupported("node", "debug_traceTransaction"))
+
+	cache.ObserveReply("node", spectypes.APIInterfaceJsonRPC, "debug_traceTransaction", methodNotFound)
+	require.False(t, cache.IsSupported("node", "debug_traceTransaction"))
+	// other nodes are not affected
+	require.True(t, cache.IsSupported("other", "debug_traceTransaction"))
+	// other errors don't mean the method is missing
+	cache.ObserveReply("node", spectypes.APIInterfaceJsonRPC, "eth_call", []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"execution reverted"}}`))
+	require.True(t, cache.IsSupported("node", "eth_call"))
+	// rest replies aren't json-rpc errors
+	cache.ObserveReply("node", spectypes.APIInterfaceRest, "/status", methodNotFound)
+	require.True(t, cache.IsSupported("node", "/status"))
+
+	// the probed namespaces decide for methods that weren't seen yet
+	modules, err := ParseRPCModulesReply([]byte(`{"jsonrpc":"2.0","id":1,"result":{"eth":"1.0","net":"1.0","web3":"1.0"}}`))
+	require.NoError(t, err)
+	cache.SetModules("node", modules)
+	require.True(t, cache.IsSupported("node", "eth_getBalance"))
+	require.False(t, cache.IsSupported("node", "trace_block"))
+	require.True(t, cache.IsSupported("node", "status")) // not namespaced
+
+	nodeModules, unsupported := cache.GetAvailability("node")
+	require.Equal(t, []string{"eth", "net", "web3"}, nodeModules)
+	require.Equal(t, []string{"debug_traceTransaction"}, unsupported)
+
+	// a successful reply after an upgrade
+	cache.ObserveReply("node", spectypes.APIInterfaceJsonRPC, "debug_traceTransaction", []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	_, unsupported = cache.GetAvailability("node")
+	require.Empty(t, unsupported)
+
+	_, err = ParseRPCModulesReply([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601}}`))
+	require.Error(t, err)
+	var nilCache *MethodAvailabilityCache
+	require.True(t, nilCache.IsSupported("node", "trace_block"))
+}
+
+func TestMethodAvailabilityExpires(t *testing.T) {
+	t.Parallel()
+	cache := NewMethodAvailabilityCache(10 * time.Millisecond)
+	cache.ObserveReply("node", spectypes.APIInterfaceTendermintRPC, "unsafe_flush_mempool", []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`))
+	require.False(t, cache.IsSupported("node", "unsafe_flush_mempool"))
+	require.Eventually(t, func() bool { return cache.IsSupported("node", "unsafe_flush_mempool") }, time.Second, 5*time.Millisecond)
+}
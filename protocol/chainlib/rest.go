@@ -312,6 +312,7 @@ func (apil *RestChainListener) Serve(ctx context.Context) {
 
 type RestChainProxy struct {
 	BaseChainProxy
+	httpTransport http.RoundTripper // nil unless the node url configures tls
 }
 
 func NewRestChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint *lavasession.RPCProviderEndpoint, averageBlockTime time.Duration) (ChainProxy, error) {
@@ -320,8 +321,13 @@ func NewRestChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint *la
 	}
 	nodeUrl := rpcProviderEndpoint.NodeUrls[0]
 	nodeUrl.Url = strings.TrimSuffix(rpcProviderEndpoint.NodeUrls[0].Url, "/")
+	httpTransport, err := nodeUrl.HTTPTransport()
+	if err != nil {
+		return nil, err
+	}
 	rcp := &RestChainProxy{
 		BaseChainProxy: BaseChainProxy{averageBlockTime: averageBlockTime, NodeUrl: rpcProviderEndpoint.NodeUrls[0]},
+		httpTransport:  httpTransport,
 	}
 	return rcp, nil
 }
@@ -331,7 +337,8 @@ func (rcp *RestChainProxy) SendNodeMsg(ctx context.Context, ch chan interface{},
 		return nil, "", nil, utils.LavaFormatError("Subscribe is not allowed on rest", nil)
 	}
 	httpClient := http.Client{
-		Timeout: LocalNodeTimePerCu(chainMessage.GetServiceApi().ComputeUnits),
+		Timeout:   LocalNodeTimePerCu(chainMessage.GetServiceApi().ComputeUnits),
+		Transport: rcp.httpTransport,
 	}
 
 	rpcInputMessage := chainMessage.GetRPCMessage()
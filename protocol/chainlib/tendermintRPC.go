@@ -426,6 +426,7 @@ type tendermintRpcChainProxy struct {
 	JrpcChainProxy
 	httpNodeUrl   common.NodeUrl
 	httpConnector *chainproxy.Connector
+	httpTransport http.RoundTripper // nil unless the http node url configures tls
 }
 
 func NewtendermintRpcChainProxy(ctx context.Context, nConns uint, rpcProviderEndpoint *lavasession.RPCProviderEndpoint, averageBlockTime time.Duration) (ChainProxy, error) {
@@ -433,10 +434,15 @@ func NewtendermintRpcChainProxy(ctx context.Context, nConns uint, rpcProviderEnd
 		return nil, utils.LavaFormatError("rpcProviderEndpoint.NodeUrl list is empty missing node url", nil, utils.Attribute{Key: "chainID", Value: rpcProviderEndpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: rpcProviderEndpoint.ApiInterface})
 	}
 	websocketUrl, httpUrl := verifyTendermintEndpoint(rpcProviderEndpoint.NodeUrls)
+	httpTransport, err := httpUrl.HTTPTransport()
+	if err != nil {
+		return nil, err
+	}
 	cp := &tendermintRpcChainProxy{
 		JrpcChainProxy: JrpcChainProxy{BaseChainProxy: BaseChainProxy{averageBlockTime: averageBlockTime, NodeUrl: websocketUrl}, conn: map[string]*chainproxy.Connector{}},
 		httpNodeUrl:    httpUrl,
 		httpConnector:  nil,
+		httpTransport:  httpTransport,
 	}
 	cp.addHttpConnector(ctx, nConns, httpUrl)
 	return cp, cp.start(ctx, nConns, websocketUrl, nil)
@@ -478,7 +484,8 @@ func (cp *tendermintRpcChainProxy) SendURI(ctx context.Context, nodeMessage *rpc
 
 	// create a new http client with a timeout set by the getTimePerCu function
 	httpClient := http.Client{
-		Timeout: LocalNodeTimePerCu(chainMessage.GetServiceApi().ComputeUnits),
+		Timeout:   LocalNodeTimePerCu(chainMessage.GetServiceApi().ComputeUnits),
+		Transport: cp.httpTransport,
 	}
 
 	// construct the url by concatenating the node url with the path variable
@@ -0,0 +1,136 @@
+package chaintracker
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"io"
+)
+
+const (
+	DefaultBlockBodyMemoryLimit = 64 * 1024 * 1024
+)
+
+// BlockDataFetcher is implemented by chain fetchers that get the whole block when fetching its hash, the raw reply can then be retained
+type BlockDataFetcher interface {
+	FetchBlockDataByNum(ctx context.Context, blockNum int64) (hash string, data []byte, err error)
+}
+
+// BlockBodyRetentionConfig enables keeping the raw replies of recent blocks compressed in memory, so they can be served without the node
+type BlockBodyRetentionConfig struct {
+	Blocks      uint64 // how many of the latest blocks to retain, bounded by BlocksToSave
+	MemoryLimit uint64 // max compressed bytes, the oldest blocks are dropped first
+}
+
+func (bbrc *BlockBodyRetentionConfig) validate() error {
+	if bbrc.Blocks == 0 {
+		return InvalidConfigBlockBodyRetention
+	}
+	if bbrc.MemoryLimit == 0 {
+		bbrc.MemoryLimit = DefaultBlockBodyMemoryLimit
+	}
+	return nil
+}
+
+type blockBody struct {
+	hash       string
+	compressed []byte
+}
+
+// fetches the hash, and the block body when retention is enabled and supported by the fetcher
+func (cs *ChainTracker) fetchBlockHashAndBodyByNum(ctx context.Context, blockNum int64) (string, error) {
+	dataFetcher, ok := cs.chainFetcher.(BlockDataFetcher)
+	if cs.blockBodyRetention == nil || !ok {
+		return cs.chainFetcher.FetchBlockHashByNum(ctx, blockNum)
+	}
+	hash, data, err := dataFetcher.FetchBlockDataByNum(ctx, blockNum)
+	if err != nil {
+		return "", err
+	}
+	cs.storeBlockBody(blockNum, hash, data)
+	return hash, nil
+}
+
+func (cs *ChainTracker) storeBlockBody(blockNum int64, hash string, data []byte) {
+	if blockNum <= cs.GetLatestBlockNum()-int64(cs.blockBodyRetention.Blocks) {
+		return
+	}
+	cs.blockBodyMu.RLock()
+	existing, ok := cs.blockBodies[blockNum]
+	cs.blockBodyMu.RUnlock()
+	if ok && existing.hash == hash {
+		// the latest block is fetched on every poll, it only needs to be compressed once
+		return
+	}
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestSpeed)
+	if err != nil {
+		return
+	}
+	if _, err = writer.Write(data); err != nil {
+		return
+	}
+	if err = writer.Close(); err != nil {
+		return
+	}
+	cs.blockBodyMu.Lock()
+	defer cs.blockBodyMu.Unlock()
+	if existing, ok := cs.blockBodies[blockNum]; ok {
+		cs.blockBodiesBytes -= uint64(len(existing.compressed))
+	}
+	cs.blockBodies[blockNum] = &blockBody{hash: hash, compressed: compressed.Bytes()}
+	cs.blockBodiesBytes += uint64(compressed.Len())
+	cs.enforceBlockBodyMemoryLimitUnsafe()
+}
+
+// drops bodies that left the retention window or were replaced by a fork, blockQueueMu must be locked
+func (cs *ChainTracker) pruneBlockBodiesUnsafe(latestBlock int64) {
+	if cs.blockBodyRetention == nil {
+		return
+	}
+	cs.blockBodyMu.Lock()
+	defer cs.blockBodyMu.Unlock()
+	for blockNum, body := range cs.blockBodies {
+		queued, inQueue := cs.blocksQueue.Get(blockNum)
+		if blockNum <= latestBlock-int64(cs.blockBodyRetention.Blocks) || !inQueue || queued.Hash != body.hash {
+			cs.blockBodiesBytes -= uint64(len(body.compressed))
+			delete(cs.blockBodies, blockNum)
+		}
+	}
+	cs.enforceBlockBodyMemoryLimitUnsafe()
+}
+
+func (cs *ChainTracker) enforceBlockBodyMemoryLimitUnsafe() {
+	for cs.blockBodiesBytes > cs.blockBodyRetention.MemoryLimit && len(cs.blockBodies) > 0 {
+		oldest := int64(-1)
+		for blockNum := range cs.blockBodies {
+			if oldest == -1 || blockNum < oldest {
+				oldest = blockNum
+			}
+		}
+		cs.blockBodiesBytes -= uint64(len(cs.blockBodies[oldest].compressed))
+		delete(cs.blockBodies, oldest)
+	}
+}
+
+// GetBlockBody returns the raw reply the node returned for the block and its hash, ok is false if the block isn't retained
+func (cs *ChainTracker) GetBlockBody(blockNum int64) (hash string, data []byte, ok bool) {
+	cs.blockBodyMu.RLock()
+	body, ok := cs.blockBodies[blockNum]
+	cs.blockBodyMu.RUnlock()
+	if !ok {
+		return "", nil, false
+	}
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(body.compressed)))
+	if err != nil {
+		return "", nil, false
+	}
+	return body.hash, data, true
+}
+
+// GetBlockBodyStats returns how many block bodies are retained and their compressed size
+func (cs *ChainTracker) GetBlockBodyStats() (blocks int, compressedBytes uint64) {
+	cs.blockBodyMu.RLock()
+	defer cs.blockBodyMu.RUnlock()
+	return len(cs.blockBodies), cs.blockBodiesBytes
+}
@@ -0,0 +1,84 @@
+package chaintracker_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBlockDataFetcher struct {
+	*MockChainFetcher
+}
+
+func (mbdf *mockBlockDataFetcher) FetchBlockDataByNum(ctx context.Context, blockNum int64) (string, []byte, error) {
+	hash, err := mbdf.FetchBlockHashByNum(ctx, blockNum)
+	if err != nil {
+		return "", nil, err
+	}
+	return hash, []byte(strings.Repeat("body-"+hash, 100)), nil
+}
+
+func TestChainTrackerBlockBodyRetention(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 100)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	retention := &chaintracker.BlockBodyRetentionConfig{Blocks: 3}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 10, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 100, BlockBodyRetention: retention}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), &mockBlockDataFetcher{MockChainFetcher: mockChainFetcher}, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+
+	requireRetained := func(latestBlock int64) {
+		for block := latestBlock; block > latestBlock-3; block-- {
+			hash, data, ok := chainTracker.GetBlockBody(block)
+			require.True(t, ok, "block %d", block)
+			require.True(t, mockChainFetcher.IsCorrectHash(hash, block))
+			require.Equal(t, strings.Repeat("body-"+hash, 100), string(data))
+		}
+		_, _, ok := chainTracker.GetBlockBody(latestBlock - 3)
+		require.False(t, ok)
+		blocks, compressedBytes := chainTracker.GetBlockBodyStats()
+		require.Equal(t, 3, blocks)
+		require.Less(t, compressedBytes, uint64(3*100*len("body-stubHash-1000")))
+	}
+	requireRetained(currentLatestBlockInMock)
+
+	for i := 0; i < 5; i++ {
+		currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	}
+	require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == currentLatestBlockInMock }, time.Second, TimeForPollingMock)
+	requireRetained(currentLatestBlockInMock)
+
+	// a fork replaces the retained bodies
+	mockChainFetcher.Fork("fork")
+	require.Eventually(t, func() bool {
+		hash, _, ok := chainTracker.GetBlockBody(currentLatestBlockInMock - 2)
+		return ok && mockChainFetcher.IsCorrectHash(hash, currentLatestBlockInMock-2)
+	}, time.Second, TimeForPollingMock)
+	requireRetained(currentLatestBlockInMock)
+}
+
+func TestChainTrackerBlockBodyMemoryLimit(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 100)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	retention := &chaintracker.BlockBodyRetentionConfig{Blocks: 5, MemoryLimit: 1}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 10, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 100, BlockBodyRetention: retention}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), &mockBlockDataFetcher{MockChainFetcher: mockChainFetcher}, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+	blocks, compressedBytes := chainTracker.GetBlockBodyStats()
+	require.Zero(t, blocks)
+	require.Zero(t, compressedBytes)
+	_, _, ok := chainTracker.GetBlockBody(currentLatestBlockInMock)
+	require.False(t, ok)
+}
+
+func TestChainTrackerBlockBodyRetentionValidation(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, BlockBodyRetention: &chaintracker.BlockBodyRetentionConfig{}}
+	_, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.True(t, chaintracker.InvalidConfigBlockBodyRetention.Is(err))
+}
@@ -0,0 +1,95 @@
+package chaintracker
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	blockIntervalSamples    = 20  // how many recent block intervals are kept for the prediction
+	minBlockIntervalSamples = 3   // below this the configured average block time is used
+	blockTimeEMAWeight      = 0.1 // the weight of a new interval in the average block time estimate
+)
+
+type blockArrival struct {
+	block int64
+	time  time.Time
+}
+
+// called when a new latest block is detected, a catch up of several blocks is spread evenly between them
+func (cs *ChainTracker) recordBlockArrival(block int64, arrivalTime time.Time) {
+	cs.blockArrivalMu.Lock()
+	defer cs.blockArrivalMu.Unlock()
+	lastArrival := cs.lastBlockArrival
+	cs.lastBlockArrival = blockArrival{block: block, time: arrivalTime}
+	if lastArrival.time.IsZero() || block <= lastArrival.block {
+		return
+	}
+	interval := arrivalTime.Sub(lastArrival.time) / time.Duration(block-lastArrival.block)
+	if cs.blockTimeSamples == 0 {
+		cs.averageBlockTimeEMA = interval
+	} else {
+		cs.averageBlockTimeEMA += time.Duration(blockTimeEMAWeight * float64(interval-cs.averageBlockTimeEMA))
+	}
+	cs.blockTimeSamples++
+	cs.blockIntervals = append(cs.blockIntervals, interval)
+	if len(cs.blockIntervals) > blockIntervalSamples {
+		cs.blockIntervals = cs.blockIntervals[len(cs.blockIntervals)-blockIntervalSamples:]
+	}
+}
+
+// the median of the observed intervals, so a single slow block or a catch up doesn't skew the prediction
+func (cs *ChainTracker) expectedBlockIntervalUnsafe() (interval time.Duration, observed bool) {
+	if len(cs.blockIntervals) < minBlockIntervalSamples {
+		return cs.getConfiguredBlockTime(), false
+	}
+	intervals := append([]time.Duration{}, cs.blockIntervals...)
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	return intervals[len(intervals)/2], true
+}
+
+// GetAverageBlockTime returns an exponential moving average of the observed block intervals and how many intervals were observed.
+// before any interval is observed it returns the configured average block time with 0 samples
+func (cs *ChainTracker) GetAverageBlockTime() (averageBlockTime time.Duration, samples uint64) {
+	cs.blockArrivalMu.RLock()
+	defer cs.blockArrivalMu.RUnlock()
+	if cs.blockTimeSamples == 0 {
+		return cs.getConfiguredBlockTime(), 0
+	}
+	return cs.averageBlockTimeEMA, cs.blockTimeSamples
+}
+
+// GetExpectedNextBlockTime returns when the block after the latest one is expected to arrive, based on the observed block intervals
+// the returned time can be in the past when the next block is late, it is zero before the first block was fetched
+func (cs *ChainTracker) GetExpectedNextBlockTime() time.Time {
+	cs.blockArrivalMu.RLock()
+	defer cs.blockArrivalMu.RUnlock()
+	if cs.lastBlockArrival.time.IsZero() {
+		return time.Time{}
+	}
+	interval, _ := cs.expectedBlockIntervalUnsafe()
+	return cs.lastBlockArrival.time.Add(interval)
+}
+
+// when the next block is far away there is no reason to poll the node, this returns how long to wait before polling again
+// polling resumes at the regular rate a margin before the expected arrival, and never waits longer than the average block time
+func (cs *ChainTracker) nextPollDelay(tickerTime time.Duration, now time.Time) time.Duration {
+	cs.blockArrivalMu.RLock()
+	defer cs.blockArrivalMu.RUnlock()
+	interval, observed := cs.expectedBlockIntervalUnsafe()
+	if !observed {
+		return tickerTime
+	}
+	margin := interval / 5
+	if margin < tickerTime {
+		margin = tickerTime
+	}
+	delay := cs.lastBlockArrival.time.Add(interval).Sub(now) - margin
+	if delay <= tickerTime {
+		return tickerTime
+	}
+	if averageBlockTime := cs.getConfiguredBlockTime(); delay > averageBlockTime {
+		return averageBlockTime
+	}
+	return delay
+}
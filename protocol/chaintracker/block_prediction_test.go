@@ -0,0 +1,70 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerExpectedNextBlockTime(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+	averageBlockTime := 10 * TimeForPollingMock
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: averageBlockTime, ServerBlockMemory: 10}
+	beforeInit := time.Now()
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+	// without observed blocks the configured average block time is used
+	expected := chainTracker.GetExpectedNextBlockTime()
+	require.False(t, expected.Before(beforeInit.Add(averageBlockTime)))
+	require.False(t, expected.After(time.Now().Add(averageBlockTime)))
+
+	// blocks arrive at twice the configured rate
+	blockInterval := 2 * averageBlockTime
+	for i := 0; i < 6; i++ {
+		time.Sleep(blockInterval)
+		latestBlock := mockChainFetcher.AdvanceBlock()
+		require.Eventually(t, func() bool {
+			return chainTracker.GetLatestBlockNum() == latestBlock
+		}, time.Second, TimeForPollingMock)
+	}
+	detected := time.Now()
+	expected = chainTracker.GetExpectedNextBlockTime()
+	require.InDelta(t, blockInterval, expected.Sub(detected), float64(blockInterval/2))
+}
+
+func TestChainTrackerAverageBlockTime(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+	configuredBlockTime := 10 * TimeForPollingMock
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: configuredBlockTime, ServerBlockMemory: 10}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+	averageBlockTime, samples := chainTracker.GetAverageBlockTime()
+	require.Equal(t, configuredBlockTime, averageBlockTime)
+	require.Zero(t, samples)
+
+	blockInterval := 3 * configuredBlockTime // the spec's block time is inaccurate
+	blocks := 6
+	for i := 0; i < blocks; i++ {
+		time.Sleep(blockInterval)
+		latestBlock := mockChainFetcher.AdvanceBlock()
+		require.Eventually(t, func() bool {
+			return chainTracker.GetLatestBlockNum() == latestBlock
+		}, time.Second, TimeForPollingMock)
+	}
+	averageBlockTime, samples = chainTracker.GetAverageBlockTime()
+	require.Equal(t, uint64(blocks), samples)
+	require.InDelta(t, blockInterval, averageBlockTime, float64(blockInterval/2))
+
+	chainTrackerService := chaintracker.ChainTrackerService{ChainTracker: chainTracker}
+	reply, err := chainTrackerService.GetAverageBlockTime(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, averageBlockTime.Milliseconds(), reply.AverageBlockTime)
+	require.Equal(t, samples, reply.Samples)
+}
@@ -0,0 +1,107 @@
+package chaintracker
+
+// blocksRing holds the saved blocks, contiguous heights up to the latest one, in a fixed size ring indexed by height.
+// a new block overwrites the slot of the block that falls out of the window, so updates don't allocate or copy the saved blocks
+type blocksRing struct {
+	blocks []BlockStore // slot == height % capacity
+	length int64
+	latest int64 // the height of the newest block, valid when length > 0
+}
+
+// a saved block whose hash was replaced by an update
+type replacedBlock struct {
+	Block   int64
+	OldHash string
+	NewHash string
+}
+
+func newBlocksRing(capacity uint64) *blocksRing {
+	return &blocksRing{blocks: make([]BlockStore, capacity)}
+}
+
+func (br *blocksRing) slot(height int64) int64 {
+	return height % int64(len(br.blocks))
+}
+
+func (br *blocksRing) Len() int64 {
+	return br.length
+}
+
+func (br *blocksRing) Capacity() int64 {
+	return int64(len(br.blocks))
+}
+
+func (br *blocksRing) earliestHeight() int64 {
+	return br.latest - br.length + 1
+}
+
+// Get returns the saved block at height
+func (br *blocksRing) Get(height int64) (BlockStore, bool) {
+	if br.length == 0 || height > br.latest || height < br.earliestHeight() {
+		return BlockStore{}, false
+	}
+	return br.blocks[br.slot(height)], true
+}
+
+// At returns the block idx places after the earliest saved block, idx must be smaller than Len
+func (br *blocksRing) At(idx int64) BlockStore {
+	return br.blocks[br.slot(br.earliestHeight()+idx)]
+}
+
+func (br *blocksRing) Earliest() BlockStore {
+	return br.At(0)
+}
+
+func (br *blocksRing) Latest() BlockStore {
+	return br.blocks[br.slot(br.latest)]
+}
+
+// Update moves the newest block to latestBlock and writes the fetched blocks, the heights that weren't saved before must all be fetched.
+// blocks that fall out of the window are dropped, and saved blocks whose hash changed are returned from the lowest height
+func (br *blocksRing) Update(latestBlock int64, fetched []BlockStore) (replaced []replacedBlock) {
+	if int64(len(fetched)) > br.Capacity() {
+		// fetched before the ring was resized to a smaller window
+		fetched = fetched[:br.Capacity()]
+	}
+	oldEarliest, oldLatest := br.earliestHeight(), br.latest
+	// when the fetched blocks don't reach the saved ones nothing is kept, and the fetched blocks are the whole window
+	keep := br.length > 0 && latestBlock-int64(len(fetched)) <= oldLatest
+	if keep {
+		br.length += latestBlock - oldLatest
+		if br.length < int64(len(fetched)) {
+			// the fetched blocks also filled heights below the saved ones
+			br.length = int64(len(fetched))
+		}
+		if br.length > br.Capacity() {
+			br.length = br.Capacity()
+		}
+	} else {
+		br.length = int64(len(fetched))
+	}
+	br.latest = latestBlock
+	// fetched blocks are ordered from the newest, replacements are reported from the oldest
+	for idx := len(fetched) - 1; idx >= 0; idx-- {
+		block := fetched[idx]
+		slot := br.slot(block.Block)
+		if keep && block.Block >= oldEarliest && block.Block <= oldLatest && br.blocks[slot].Hash != block.Hash {
+			replaced = append(replaced, replacedBlock{Block: block.Block, OldHash: br.blocks[slot].Hash, NewHash: block.Hash})
+		}
+		br.blocks[slot] = block
+	}
+	return replaced
+}
+
+// Resize changes the capacity and keeps the newest saved blocks that fit in it
+func (br *blocksRing) Resize(capacity uint64) {
+	if int64(capacity) == br.Capacity() {
+		return
+	}
+	resized := &blocksRing{blocks: make([]BlockStore, capacity), length: br.length, latest: br.latest}
+	if resized.length > resized.Capacity() {
+		resized.length = resized.Capacity()
+	}
+	for height := resized.earliestHeight(); height <= resized.latest && resized.length > 0; height++ {
+		resized.blocks[resized.slot(height)] = br.blocks[br.slot(height)]
+	}
+	*br = *resized
+}
@@ -0,0 +1,131 @@
+package chaintracker
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/stretchr/testify/require"
+)
+
+func ringBlocks(latestBlock int64, count int64, fork string) []BlockStore {
+	blocks := make([]BlockStore, 0, count)
+	for idx := int64(0); idx < count; idx++ {
+		blocks = append(blocks, BlockStore{Block: latestBlock - idx, Hash: fork + strconv.FormatInt(latestBlock-idx, 10)})
+	}
+	return blocks
+}
+
+func TestBlocksRing(t *testing.T) {
+	ring := newBlocksRing(5)
+	_, ok := ring.Get(100)
+	require.False(t, ok)
+
+	require.Empty(t, ring.Update(100, ringBlocks(100, 5, "a")))
+	require.Equal(t, int64(5), ring.Len())
+	require.Equal(t, int64(96), ring.Earliest().Block)
+	require.Equal(t, int64(100), ring.Latest().Block)
+
+	// new blocks overwrite the oldest slots
+	require.Empty(t, ring.Update(102, ringBlocks(102, 2, "a")))
+	require.Equal(t, int64(5), ring.Len())
+	for idx := int64(0); idx < ring.Len(); idx++ {
+		require.Equal(t, BlockStore{Block: 98 + idx, Hash: "a" + strconv.FormatInt(98+idx, 10)}, ring.At(idx))
+	}
+	_, ok = ring.Get(97)
+	require.False(t, ok)
+	_, ok = ring.Get(103)
+	require.False(t, ok)
+
+	// a fork replaces saved hashes and reports them from the lowest height
+	replaced := ring.Update(103, append(ringBlocks(103, 1, "a"), ringBlocks(102, 2, "b")...))
+	require.Equal(t, []replacedBlock{{Block: 101, OldHash: "a101", NewHash: "b101"}, {Block: 102, OldHash: "a102", NewHash: "b102"}}, replaced)
+	block, ok := ring.Get(101)
+	require.True(t, ok)
+	require.Equal(t, "b101", block.Hash)
+
+	// a gap longer than the window replaces everything without reporting a fork
+	require.Empty(t, ring.Update(200, ringBlocks(200, 5, "c")))
+	require.Equal(t, int64(196), ring.Earliest().Block)
+	require.Equal(t, "c200", ring.Latest().Hash)
+
+	// shrinking keeps the newest blocks
+	ring.Resize(3)
+	require.Equal(t, int64(3), ring.Len())
+	require.Equal(t, int64(198), ring.Earliest().Block)
+	require.Equal(t, "c200", ring.Latest().Hash)
+
+	// growing keeps the saved blocks until the fetched ones fill the heights below them
+	ring.Resize(8)
+	require.Equal(t, int64(3), ring.Len())
+	require.Equal(t, "c198", ring.Earliest().Hash)
+	require.Empty(t, ring.Update(201, ringBlocks(201, 8, "c")))
+	require.Equal(t, int64(8), ring.Len())
+	for idx := int64(0); idx < ring.Len(); idx++ {
+		require.Equal(t, BlockStore{Block: 194 + idx, Hash: "c" + strconv.FormatInt(194+idx, 10)}, ring.At(idx))
+	}
+
+	// blocks fetched before a shrink are cut to the window
+	ring.Resize(4)
+	require.Empty(t, ring.Update(202, ringBlocks(202, 8, "c")))
+	require.Equal(t, int64(4), ring.Len())
+	require.Equal(t, int64(199), ring.Earliest().Block)
+}
+
+// a node producing a new block with a new hash on every call to AdvanceBlock
+type benchmarkChainFetcher struct {
+	latestBlock int64
+}
+
+func (bcf *benchmarkChainFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	return atomic.LoadInt64(&bcf.latestBlock), nil
+}
+
+func (bcf *benchmarkChainFetcher) FetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
+	return strconv.FormatInt(blockNum, 10), nil
+}
+
+func (bcf *benchmarkChainFetcher) FetchEndpoint() lavasession.RPCProviderEndpoint {
+	return lavasession.RPCProviderEndpoint{}
+}
+
+func (bcf *benchmarkChainFetcher) AdvanceBlock() int64 {
+	return atomic.AddInt64(&bcf.latestBlock, 1)
+}
+
+func BenchmarkChainTrackerNewBlock(b *testing.B) {
+	for _, blocksToSave := range []uint64{10, 1000, 100000} {
+		b.Run(strconv.FormatUint(blocksToSave, 10), func(b *testing.B) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			fetcher := &benchmarkChainFetcher{latestBlock: int64(blocksToSave) * 2}
+			// polling is slow enough not to interfere, new blocks are fetched directly
+			chainTracker, err := NewChainTracker(ctx, fetcher, ChainTrackerConfig{BlocksToSave: blocksToSave, AverageBlockTime: time.Hour, ServerBlockMemory: blocksToSave})
+			require.NoError(b, err)
+			defer chainTracker.Close(ctx)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err = chainTracker.fetchAllPreviousBlocks(ctx, fetcher.AdvanceBlock())
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBlocksRingGet(b *testing.B) {
+	ring := newBlocksRing(100000)
+	ring.Update(200000, ringBlocks(200000, 100000, ""))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := ring.Get(100001 + int64(i%100000)); !ok {
+			b.Fatal("missing block")
+		}
+	}
+}
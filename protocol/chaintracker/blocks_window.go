@@ -0,0 +1,54 @@
+package chaintracker
+
+import (
+	"sync/atomic"
+
+	"github.com/lavanet/lava/utils"
+)
+
+func (cs *ChainTracker) getBlocksToSave() uint64 {
+	return atomic.LoadUint64(&cs.blocksToSave)
+}
+
+func (cs *ChainTracker) getServerBlockMemory() uint64 {
+	return atomic.LoadUint64(&cs.serverBlockMemory)
+}
+
+func (cs *ChainTracker) getFinalizationDistance() uint64 {
+	return atomic.LoadUint64(&cs.finalizationDistance)
+}
+
+// returns true while the saved blocks don't fill the window, after it grew
+func (cs *ChainTracker) missingBlocks() bool {
+	cs.blockQueueMu.RLock()
+	defer cs.blockQueueMu.RUnlock()
+	return uint64(cs.blocksQueue.Len()) < cs.getBlocksToSave()
+}
+
+// UpdateBlocksToSave changes the saved blocks window at runtime, used when the spec finalization parameters change.
+// a smaller window drops the oldest saved blocks at once, a larger one is filled from the node on the next poll.
+// a zero serverBlockMemory keeps the default, same as the config
+func (cs *ChainTracker) UpdateBlocksToSave(blocksToSave uint64, serverBlockMemory uint64, finalizationDistance uint64) error {
+	if blocksToSave == 0 {
+		return InvalidConfigErrorBlocksToSave
+	}
+	if finalizationDistance >= blocksToSave {
+		return InvalidConfigFinalization.Wrapf("finalization distance: %d, blocks to save: %d", finalizationDistance, blocksToSave)
+	}
+	if serverBlockMemory == 0 {
+		serverBlockMemory = DefualtAssumedBlockMemory
+	}
+	cs.blockQueueMu.Lock()
+	defer cs.blockQueueMu.Unlock()
+	previousBlocksToSave := cs.getBlocksToSave()
+	if previousBlocksToSave == blocksToSave && cs.getServerBlockMemory() == serverBlockMemory && cs.getFinalizationDistance() == finalizationDistance {
+		return nil
+	}
+	cs.blocksQueue.Resize(blocksToSave)
+	atomic.StoreUint64(&cs.blocksToSave, blocksToSave)
+	atomic.StoreUint64(&cs.serverBlockMemory, serverBlockMemory)
+	atomic.StoreUint64(&cs.finalizationDistance, finalizationDistance)
+	utils.LavaFormatInfo("chain tracker blocks window updated", utils.Attribute{Key: "blocksToSave", Value: blocksToSave}, utils.Attribute{Key: "previousBlocksToSave", Value: previousBlocksToSave},
+		utils.Attribute{Key: "serverBlockMemory", Value: serverBlockMemory}, utils.Attribute{Key: "finalizationDistance", Value: finalizationDistance}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	return nil
+}
@@ -0,0 +1,63 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerUpdateBlocksToSave(t *testing.T) {
+	mockBlocks := int64(100)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 10, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: uint64(mockBlocks), FinalizationDistance: 4}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	requireSavedBlocks := func(blocksToSave int64) {
+		latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-blocksToSave+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+		require.NoError(t, err)
+		require.Len(t, requestedHashes, int(blocksToSave))
+		for idx, blockStore := range requestedHashes {
+			require.Equal(t, latestBlock-blocksToSave+1+int64(idx), blockStore.Block)
+			require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+		}
+		_, _, err = chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-blocksToSave, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+		require.Error(t, err)
+	}
+
+	// invalid windows are refused and keep the current one
+	require.Error(t, chainTracker.UpdateBlocksToSave(0, 0, 0))
+	require.Error(t, chainTracker.UpdateBlocksToSave(5, 0, 5))
+	requireSavedBlocks(10)
+
+	// a smaller window drops the oldest blocks at once
+	require.NoError(t, chainTracker.UpdateBlocksToSave(5, uint64(mockBlocks), 2))
+	requireSavedBlocks(5)
+	require.Equal(t, currentLatestBlockInMock-2, chainTracker.GetLatestFinalizedBlockNum())
+
+	// a larger window is filled from the node without waiting for a new block
+	require.NoError(t, chainTracker.UpdateBlocksToSave(20, uint64(mockBlocks), 6))
+	require.Eventually(t, func() bool {
+		_, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-19, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+		return err == nil && len(requestedHashes) == 20
+	}, time.Second, TimeForPollingMock)
+	require.Equal(t, currentLatestBlockInMock, chainTracker.GetLatestBlockNum())
+	requireSavedBlocks(20)
+	require.Equal(t, currentLatestBlockInMock-6, chainTracker.GetLatestFinalizedBlockNum())
+
+	// new blocks keep the resized window
+	for i := 0; i < 15; i++ {
+		currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+		require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == currentLatestBlockInMock }, time.Second, TimeForPollingMock)
+		requireSavedBlocks(20)
+	}
+}
@@ -2,8 +2,10 @@ package chaintracker
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	fmt "fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
@@ -25,6 +27,8 @@ import (
 const (
 	initRetriesCount = 4
 	BACKOFF_MAX_TIME = 10 * time.Minute
+	// requests answered in one GetLatestBlockDataBatch call, bounds the time the blocks lock is held
+	MaxBlockDataBatchSize = 100
 )
 
 type ChainFetcher interface {
@@ -34,189 +38,326 @@ type ChainFetcher interface {
 }
 
 type ChainTracker struct {
-	chainFetcher            ChainFetcher // used to communicate with the node
-	blocksToSave            uint64       // how many finalized blocks to keep
-	latestBlockNum          int64
-	blockQueueMu            sync.RWMutex
-	blocksQueue             []BlockStore        // holds all past hashes up until latest block
-	forkCallback            func(int64)         // a function to be called when a fork is detected
-	newLatestCallback       func(int64, string) // a function to be called when a new block is detected
-	serverBlockMemory       uint64
-	quit                    chan bool
-	endpoint                lavasession.RPCProviderEndpoint
-	blockCheckpointDistance uint64 // used to do something every X blocks
-	blockCheckpoint         uint64 // last time checkpoint was met
-	ticker                  *time.Ticker
+	chainFetcher             ChainFetcher // used to communicate with the node
+	blocksToSave             uint64       // atomic, how many finalized blocks to keep, changed under blockQueueMu
+	latestBlockNum           int64
+	blockQueueMu             sync.RWMutex
+	blocksQueue              *blocksRing // holds all past hashes up until latest block
+	listenersMu              sync.RWMutex
+	blockListeners           []blockListenerEntry // the config's NewLatestCallback is the first one if set
+	forkListeners            []forkListenerEntry  // the config's ForkCallback is the first one if set
+	nextListenerID           uint64
+	serverBlockMemory        uint64 // atomic, changed under blockQueueMu
+	quit                     chan bool
+	pollingDone              chan struct{} // closed when the polling routine exits
+	closeOnce                sync.Once
+	serverMu                 sync.Mutex
+	httpServer               *http.Server // set when serving, shut down on Close
+	closed                   bool         // protected by serverMu
+	endpoint                 lavasession.RPCProviderEndpoint
+	blockCheckpointDistance  uint64        // atomic, used to do something every X blocks
+	blockCheckpoint          uint64        // atomic, last time checkpoint was met
+	ticker                   *time.Ticker  // only used by the polling routine
+	configChanged            chan struct{} // tells the polling routine the average block time changed, buffered
+	averageBlockTime         time.Duration // atomic, the configured block time polling is based on
+	consecutiveFetchFails    uint64        // atomic, reported on health checks
+	consecutiveFetchTimeouts uint64        // atomic, how many of the consecutive fails timed out
+	latestBlockFetchTimeout  time.Duration
+	blockHashFetchTimeout    time.Duration
+	pollingJitter            float64
+	lastSuccessfulFetch      int64 // atomic, unix nano of the last successful poll
+	referenceFetcher         ReferenceFetcher
+	referenceCheckInterval   uint64
+	maxBlocksBehindReference uint64
+	laggingNodeCallback      func(lagging bool, latestBlock int64, referenceBlock int64)
+	lagging                  uint32 // atomic, 1 when the node is behind the reference
+	referenceCheckRunning    uint32 // atomic
+	reorgHistoryMu           sync.RWMutex
+	reorgHistory             []*ReorgEvent // oldest first, bounded by reorgHistorySize
+	reorgHistorySize         uint64
+	fetchConcurrency         uint64 // how many block hashes are fetched in parallel when filling gaps
+	finalizationDistance     uint64 // atomic, blocks newer than latest - finalizationDistance can still reorg, changed under blockQueueMu
+	blockArrivalMu           sync.RWMutex
+	lastBlockArrival         blockArrival
+	blockIntervals           []time.Duration // recent time per block, used to predict the next block
+	averageBlockTimeEMA      time.Duration   // protected by blockArrivalMu
+	blockTimeSamples         uint64          // number of intervals in averageBlockTimeEMA
+	pollingStart             time.Time       // set before polling starts, the reference for staleness before any new block
+	staleBlocksThreshold     uint64
+	staleChainCallback       func(sinceLastBlock time.Duration)
+	gapDetectedCallback      func(fromBlock int64, toBlock int64)
+	heightRegressionCallback func(latestBlock int64, nodeLatestBlock int64)
+	heightRegressionResync   uint64 // consecutive regressed polls before re-syncing, 0 disables it
+	heightRegressionPolls    uint64 // atomic, consecutive polls that reported a regressed height
+	latestBlockNumLatencies  *fetchLatencies
+	blockHashLatencies       *fetchLatencies
+	hashVerifier             BlockHashVerifier
+	stale                    uint32 // atomic, 1 when no new block arrived for staleBlocksThreshold average block times
+	hashless                 bool   // block hashes are left empty and the node is only queried for the latest height
+	blockBodyRetention       *BlockBodyRetentionConfig
+	blockBodyMu              sync.RWMutex
+	blockBodies              map[int64]*blockBody // compressed raw block replies, only when retention is enabled
+	blockBodiesBytes         uint64
+	initialSnapshot          *ChainTrackerSnapshot // restored on start, nil afterwards
+	pollDelay                int64                 // atomic, the last interval the ticker was set to
 }
 
 // this function returns block hashes of the blocks: [from block - to block] inclusive. an additional specific block hash can be provided. order is sorted ascending
 // it supports requests for [spectypes.LATEST_BLOCK-distance1, spectypes.LATEST_BLOCK-distance2)
 // spectypes.NOT_APPLICABLE in fromBlock or toBlock results in only returning specific block.
 // if specific block is spectypes.NOT_APPLICABLE it is ignored
-func (cs *ChainTracker) GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64) (latestBlock int64, requestedHashes []*BlockStore, err error) {
+// with finalizedOnly, blocks newer than GetLatestFinalizedBlockNum are left out of the returned hashes
+func (cs *ChainTracker) GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (latestBlock int64, requestedHashes []*BlockStore, err error) {
 	cs.blockQueueMu.RLock()
 	defer cs.blockQueueMu.RUnlock()
 
 	latestBlock = cs.GetLatestBlockNum()
-	if len(cs.blocksQueue) == 0 {
-		return latestBlock, nil, utils.LavaFormatError("ChainTracker GetLatestBlockData had no blocks", nil, utils.Attribute{Key: "latestBlock", Value: latestBlock})
+	requestedHashes, err = cs.getLatestBlockDataUnsafe(latestBlock, fromBlock, toBlock, specificBlock, finalizedOnly)
+	return latestBlock, requestedHashes, err
+}
+
+// GetLatestBlockDataBatch answers several GetLatestBlockData requests for the same latest block, a failed request sets its result's error and doesn't fail the others
+func (cs *ChainTracker) GetLatestBlockDataBatch(requests []*LatestBlockData) (latestBlock int64, results []*BlockDataResult, err error) {
+	if len(requests) == 0 || len(requests) > MaxBlockDataBatchSize {
+		return 0, nil, InvalidBlockDataBatch.Wrapf("batch of %d requests", len(requests))
+	}
+	cs.blockQueueMu.RLock()
+	defer cs.blockQueueMu.RUnlock()
+
+	latestBlock = cs.GetLatestBlockNum()
+	results = make([]*BlockDataResult, len(requests))
+	for idx, request := range requests {
+		requestedHashes, err := cs.getLatestBlockDataUnsafe(latestBlock, request.GetFromBlock(), request.GetToBlock(), request.GetSpecificBlock(), request.GetFinalizedOnly())
+		results[idx] = &BlockDataResult{RequestedHashes: requestedHashes}
+		if err != nil {
+			results[idx].Error = err.Error()
+		}
+	}
+	return latestBlock, results, nil
+}
+
+// blockQueueMu must be locked
+func (cs *ChainTracker) getLatestBlockDataUnsafe(latestBlock int64, fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (requestedHashes []*BlockStore, err error) {
+	if cs.blocksQueue.Len() == 0 {
+		return nil, utils.LavaFormatError("ChainTracker GetLatestBlockData had no blocks", nil, utils.Attribute{Key: "latestBlock", Value: latestBlock})
 	}
 	earliestBlockSaved := cs.getEarliestBlockUnsafe().Block
 	wantedBlocksData := WantedBlocksData{}
 	err = wantedBlocksData.New(fromBlock, toBlock, specificBlock, latestBlock, earliestBlockSaved)
 	if err != nil {
-		return latestBlock, nil, sdkerrors.Wrap(err, fmt.Sprintf("invalid input for GetLatestBlockData %v", &map[string]string{
+		return nil, sdkerrors.Wrap(err, fmt.Sprintf("invalid input for GetLatestBlockData %v", &map[string]string{
 			"fromBlock": strconv.FormatInt(fromBlock, 10), "toBlock": strconv.FormatInt(toBlock, 10), "specificBlock": strconv.FormatInt(specificBlock, 10),
 			"latestBlock": strconv.FormatInt(latestBlock, 10), "earliestBlockSaved": strconv.FormatInt(earliestBlockSaved, 10),
 		}))
 	}
 
 	for _, blocksQueueIdx := range wantedBlocksData.IterationIndexes() {
-		blockStore := cs.blocksQueue[blocksQueueIdx]
+		blockStore := cs.blocksQueue.At(int64(blocksQueueIdx))
 		if !wantedBlocksData.IsWanted(blockStore.Block) {
-			return latestBlock, nil, utils.LavaFormatError("invalid wantedBlocksData Iteration", err, utils.Attribute{Key: "blocksQueueIdx", Value: blocksQueueIdx}, utils.Attribute{Key: "blockStore", Value: blockStore},
+			return nil, utils.LavaFormatError("invalid wantedBlocksData Iteration", err, utils.Attribute{Key: "blocksQueueIdx", Value: blocksQueueIdx}, utils.Attribute{Key: "blockStore", Value: blockStore},
 				utils.Attribute{Key: "wantedBlocksData", Value: wantedBlocksData})
 		}
+		if finalizedOnly && blockStore.Block > latestBlock-int64(cs.getFinalizationDistance()) {
+			continue
+		}
 		requestedHashes = append(requestedHashes, &blockStore)
 	}
-	return
+	return requestedHashes, nil
 }
 
 // blockQueueMu must be locked
 func (cs *ChainTracker) getEarliestBlockUnsafe() BlockStore {
-	return cs.blocksQueue[0]
+	return cs.blocksQueue.Earliest()
 }
 
 // blockQueueMu must be locked
 func (cs *ChainTracker) getLatestBlockUnsafe() BlockStore {
-	if len(cs.blocksQueue) == 0 {
+	if cs.blocksQueue.Len() == 0 {
 		return BlockStore{Hash: "BAD-HASH"}
 	}
-	return cs.blocksQueue[len(cs.blocksQueue)-1]
+	return cs.blocksQueue.Latest()
 }
 
 func (cs *ChainTracker) GetLatestBlockNum() int64 {
 	return atomic.LoadInt64(&cs.latestBlockNum)
 }
 
+// GetLatestFinalizedBlockNum returns the latest block that is at least the finalization distance behind the latest seen block
+func (cs *ChainTracker) GetLatestFinalizedBlockNum() int64 {
+	latestFinalized := cs.GetLatestBlockNum() - int64(cs.getFinalizationDistance())
+	if latestFinalized < 0 {
+		return 0
+	}
+	return latestFinalized
+}
+
 func (cs *ChainTracker) setLatestBlockNum(value int64) {
 	atomic.StoreInt64(&cs.latestBlockNum, value)
 }
 
 func (cs *ChainTracker) fetchLatestBlockNum(ctx context.Context) (int64, error) {
-	return cs.chainFetcher.FetchLatestBlockNum(ctx)
+	fetchCtx, cancel := context.WithTimeout(ctx, cs.latestBlockFetchTimeout)
+	defer cancel()
+	start := time.Now()
+	latestBlock, err := cs.chainFetcher.FetchLatestBlockNum(fetchCtx)
+	cs.observeFetchLatency(fetchCallLatestBlockNum, cs.latestBlockNumLatencies, start, err)
+	return latestBlock, fetchTimeoutError(ctx, fetchCtx, err, cs.latestBlockFetchTimeout)
 }
 
 func (cs *ChainTracker) fetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
-	if blockNum < cs.GetLatestBlockNum()-int64(cs.serverBlockMemory) {
-		return "", ErrorFailedToFetchTooEarlyBlock.Wrapf("requested Block: %d, latest block: %d, server memory %d", blockNum, cs.GetLatestBlockNum(), cs.serverBlockMemory)
-	}
-	return cs.chainFetcher.FetchBlockHashByNum(ctx, blockNum)
+	serverBlockMemory := cs.getServerBlockMemory()
+	if blockNum < cs.GetLatestBlockNum()-int64(serverBlockMemory) {
+		return "", ErrorFailedToFetchTooEarlyBlock.Wrapf("requested Block: %d, latest block: %d, server memory %d", blockNum, cs.GetLatestBlockNum(), serverBlockMemory)
+	}
+	if cs.hashless {
+		// every block has the same empty hash, so the saved blocks always overlap the fetched ones and no reorg is ever seen
+		return "", nil
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, cs.blockHashFetchTimeout)
+	defer cancel()
+	start := time.Now()
+	hash, err := cs.fetchBlockHashAndBodyByNum(fetchCtx, blockNum)
+	cs.observeFetchLatency(fetchCallBlockHashByNum, cs.blockHashLatencies, start, err)
+	return hash, fetchTimeoutError(ctx, fetchCtx, err, cs.blockHashFetchTimeout)
 }
 
 // this function fetches all previous blocks from the node starting at the latest provided going backwards blocksToSave blocks
 // if it reaches a hash that it already has it stops reading
 func (cs *ChainTracker) fetchAllPreviousBlocks(ctx context.Context, latestBlock int64) (hashLatest string, err error) {
-	newBlocksQueue := make([]BlockStore, int64(cs.blocksToSave))
 	currentLatestBlock := cs.GetLatestBlockNum()
 	if latestBlock < currentLatestBlock {
 		return "", utils.LavaFormatError("invalid latestBlock provided to fetch, it is older than the current state latest block", err, utils.Attribute{Key: "latestBlock", Value: latestBlock}, utils.Attribute{Key: "currentLatestBlock", Value: currentLatestBlock})
 	}
 	readIndexDiff := latestBlock - currentLatestBlock
-	blocksQueueStartIndex, blocksQueueEndIndex, newQueueStartIndex := int64(0), int64(0), int64(0)
-	blocksQueueStartIndex, blocksQueueEndIndex, newQueueStartIndex, err = cs.readHashes(latestBlock, ctx, blocksQueueStartIndex, blocksQueueEndIndex, newQueueStartIndex, readIndexDiff, newBlocksQueue)
+	fetchedBlocks, err := cs.readHashes(latestBlock, ctx, readIndexDiff)
+	if err != nil {
+		return "", err
+	}
+	err = cs.verifyFetchedBlocks(ctx, fetchedBlocks)
 	if err != nil {
 		return "", err
 	}
-	blocksCopied := int64(cs.blocksToSave)
-	blocksCopied, blocksQueueLen, latestHash := cs.replaceBlocksQueue(latestBlock, newQueueStartIndex, blocksQueueStartIndex, blocksQueueEndIndex, newBlocksQueue, blocksCopied)
-	if blocksQueueLen < cs.blocksToSave {
+	blocksQueueLen, latestHash := cs.replaceBlocksQueue(latestBlock, fetchedBlocks)
+	blocksToSave := cs.getBlocksToSave()
+	if blocksQueueLen < blocksToSave {
 		return "", utils.LavaFormatError("fetchAllPreviousBlocks didn't save enough blocks in Chain Tracker", nil, utils.Attribute{Key: "blocksQueueLen", Value: blocksQueueLen})
 	}
+	if currentLatestBlock != 0 && readIndexDiff > int64(blocksToSave) {
+		cs.onGapDetected(currentLatestBlock, latestBlock, blocksToSave)
+	}
 	// only print logs if there is something interesting or we reached the checkpoint
-	if readIndexDiff > 1 || cs.blockCheckpoint+cs.blockCheckpointDistance < uint64(latestBlock) {
-		cs.blockCheckpoint = uint64(latestBlock)
-		utils.LavaFormatDebug("Chain Tracker Updated block hashes", utils.Attribute{Key: "latest_block", Value: latestBlock}, utils.Attribute{Key: "latestHash", Value: latestHash}, utils.Attribute{Key: "blocksQueueLen", Value: blocksQueueLen}, utils.Attribute{Key: "blocksQueried", Value: int64(cs.blocksToSave) - blocksCopied}, utils.Attribute{Key: "blocksKept", Value: blocksCopied}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface}, utils.Attribute{Key: "nextBlocksUpdate", Value: cs.blockCheckpoint + cs.blockCheckpointDistance})
+	if readIndexDiff > 1 || atomic.LoadUint64(&cs.blockCheckpoint)+cs.getBlockCheckpointDistance() < uint64(latestBlock) {
+		atomic.StoreUint64(&cs.blockCheckpoint, uint64(latestBlock))
+		utils.LavaFormatDebug("Chain Tracker Updated block hashes", utils.Attribute{Key: "latest_block", Value: latestBlock}, utils.Attribute{Key: "latestHash", Value: latestHash}, utils.Attribute{Key: "blocksQueueLen", Value: blocksQueueLen}, utils.Attribute{Key: "blocksQueried", Value: len(fetchedBlocks)}, utils.Attribute{Key: "blocksKept", Value: int64(blocksToSave) - int64(len(fetchedBlocks))}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface}, utils.Attribute{Key: "nextBlocksUpdate", Value: uint64(latestBlock) + cs.getBlockCheckpointDistance()})
 	}
 	return latestHash, nil
 }
 
-func (cs *ChainTracker) replaceBlocksQueue(latestBlock int64, newQueueStartIndex int64, blocksQueueStartIndex int64, blocksQueueEndIndex int64, newBlocksQueue []BlockStore, blocksCopied int64) (int64, uint64, string) {
+// writes the fetched blocks to the queue in place, the saved blocks they didn't replace are kept
+func (cs *ChainTracker) replaceBlocksQueue(latestBlock int64, fetchedBlocks []BlockStore) (uint64, string) {
 	cs.blockQueueMu.Lock()
 	defer cs.blockQueueMu.Unlock()
+	if cs.blocksQueue.Len() > 0 && latestBlock < cs.blocksQueue.Latest().Block {
+		// a snapshot newer than the fetched blocks was restored meanwhile
+		return uint64(cs.blocksQueue.Len()), cs.getLatestBlockUnsafe().Hash
+	}
 	cs.setLatestBlockNum(latestBlock)
-	if newQueueStartIndex > 0 {
-		// means we copy previous blocks
-		cs.blocksQueue = append(cs.blocksQueue[blocksQueueStartIndex:blocksQueueEndIndex], newBlocksQueue[newQueueStartIndex:]...)
-		blocksCopied = blocksQueueEndIndex - blocksQueueStartIndex
-	} else {
-		// this should only happens if we lost connection for a really long time and readIndexDiff is big, or there was a bigger fork than memory
-		cs.blocksQueue = newBlocksQueue
-	}
-	blocksQueueLen := uint64(len(cs.blocksQueue))
-	latestHash := cs.getLatestBlockUnsafe().Hash
-	return blocksCopied, blocksQueueLen, latestHash
+	replaced := cs.blocksQueue.Update(latestBlock, fetchedBlocks)
+	cs.recordReorgIfChangedUnsafe(replaced)
+	cs.pruneBlockBodiesUnsafe(latestBlock)
+	return uint64(cs.blocksQueue.Len()), cs.getLatestBlockUnsafe().Hash
 }
 
-func (cs *ChainTracker) readHashes(latestBlock int64, ctx context.Context, blocksQueueStartIndex int64, blocksQueueEndIndex int64, newQueueStartIndex int64, readIndexDiff int64, newBlocksQueue []BlockStore) (int64, int64, int64, error) {
+// reads the hashes from latestBlock backwards until one matches the saved hash of its block, since its a blockchain all former hashes are the same too.
+// returns the blocks read before the match ordered from the newest, or the whole window if there was no match
+func (cs *ChainTracker) readHashes(latestBlock int64, ctx context.Context, readIndexDiff int64) ([]BlockStore, error) {
 	cs.blockQueueMu.RLock()
 	defer cs.blockQueueMu.RUnlock()
-	// loop through our block queue and compare new hashes to previous ones to find when to stop reading
-	for idx := int64(0); idx < int64(cs.blocksToSave); idx++ {
-		// reading the blocks from the newest to oldest
-		blockNumToFetch := latestBlock - idx
-		newHashForBlock, err := cs.fetchBlockHashByNum(ctx, blockNumToFetch)
-		if err != nil {
-			return 0, 0, 0, utils.LavaFormatError("could not get block data in Chain Tracker", err, utils.Attribute{Key: "block", Value: blockNumToFetch}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface})
+	// the window only changes under the write lock, so it is the same throughout the read
+	blocksToSave := int64(cs.getBlocksToSave())
+	expectedFetches := readIndexDiff + 1
+	if expectedFetches > blocksToSave {
+		expectedFetches = blocksToSave
+	}
+	fetchedBlocks := make([]BlockStore, 0, expectedFetches)
+	// an overlap can't be found before readIndexDiff so these blocks are fetched at once, the rest are fetched in chunks of fetchConcurrency so we don't read much further than the overlap
+	chunkEnd := readIndexDiff + 1
+	for idx := int64(0); idx < blocksToSave; {
+		if chunkEnd <= idx {
+			chunkEnd = idx + int64(cs.fetchConcurrency)
+		}
+		if chunkEnd > blocksToSave {
+			chunkEnd = blocksToSave
 		}
-		var foundOverlap bool
-		foundOverlap, blocksQueueStartIndex, blocksQueueEndIndex, newQueueStartIndex = cs.hashesOverlapIndexes(readIndexDiff, idx, blockNumToFetch, newHashForBlock)
-		if foundOverlap {
-			utils.LavaFormatDebug("Chain Tracker read a block Hash, and it existed, stopping fetch", utils.Attribute{Key: "block", Value: blockNumToFetch}, utils.Attribute{Key: "hash", Value: newHashForBlock}, utils.Attribute{Key: "KeptBlocks", Value: blocksQueueEndIndex - blocksQueueStartIndex}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface})
-			break
+		chunkStart := idx
+		hashes, errs := cs.fetchBlockHashesConcurrently(ctx, latestBlock, chunkStart, chunkEnd)
+		for ; idx < chunkEnd; idx++ {
+			// reading the blocks from the newest to oldest
+			blockNumToFetch := latestBlock - idx
+			newHashForBlock, err := hashes[idx-chunkStart], errs[idx-chunkStart]
+			if err != nil {
+				return nil, utils.LavaFormatError("could not get block data in Chain Tracker", err, utils.Attribute{Key: "block", Value: blockNumToFetch}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface})
+			}
+			if cs.hashOverlapsUnsafe(latestBlock, blockNumToFetch, newHashForBlock) {
+				utils.LavaFormatDebug("Chain Tracker read a block Hash, and it existed, stopping fetch", utils.Attribute{Key: "block", Value: blockNumToFetch}, utils.Attribute{Key: "hash", Value: newHashForBlock}, utils.Attribute{Key: "KeptBlocks", Value: blocksToSave - idx}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface})
+				return fetchedBlocks, nil
+			}
+			// there is no existing hash for this block
+			fetchedBlocks = append(fetchedBlocks, BlockStore{Block: blockNumToFetch, Hash: newHashForBlock})
 		}
-		// there is no existing hash for this block
-		newBlocksQueue[int64(cs.blocksToSave)-1-idx] = BlockStore{Block: blockNumToFetch, Hash: newHashForBlock}
 	}
-	return blocksQueueStartIndex, blocksQueueEndIndex, newQueueStartIndex, nil
+	return fetchedBlocks, nil
 }
 
-// this function finds if there is an existing block data by hash at the existing data, this allows us to stop querying for further data backwards since when there is a match all former blocks are the same
-// it goes over the list backwards looking for a match. when one is found it returns how many blocks are needed from the memory in order to get the required length of queue
-func (cs *ChainTracker) hashesOverlapIndexes(readIndexDiff int64, newQueueIdx int64, fetchedBlockNum int64, newHashForBlock string) (foundOverlap bool, blocksQueueStartIndex int64, blocksQueueEndIndex int64, newQueueStartIndex int64) {
-	savedBlocks := int64(len(cs.blocksQueue))
-	if readIndexDiff >= savedBlocks {
-		// we are too far ahead, there is no overlap for sure
-		return false, 0, 0, 0
-	}
-	blocksQueueEnd := savedBlocks - 1 + readIndexDiff // this is not the real end of the queue, its incremented by readIndexDiff so we traverse it together with newBlockQueue
-	blocksQueueIdx := blocksQueueEnd - newQueueIdx
-	if blocksQueueIdx > 0 && blocksQueueIdx <= savedBlocks-1 {
-		existingBlockStore := cs.blocksQueue[blocksQueueIdx]
-		if existingBlockStore.Block != fetchedBlockNum { // sanity
-			utils.LavaFormatError("mismatching blocksQueue Index and fetch index, blockStore isn't the right block", nil, utils.Attribute{
-				Key: "block", Value: fetchedBlockNum,
-			}, utils.Attribute{Key: "existingBlockStore", Value: existingBlockStore},
-				utils.Attribute{Key: "blocksQueueIdx", Value: blocksQueueEnd}, utils.Attribute{Key: "newQueueIdx", Value: newQueueIdx}, utils.Attribute{Key: "readIndexDiff", Value: readIndexDiff})
-			return false, 0, 0, 0
+// fetches the hashes of blocks latestBlock-fromIdx down to latestBlock-toIdx+1 with up to fetchConcurrency workers, results are ordered by index
+func (cs *ChainTracker) fetchBlockHashesConcurrently(ctx context.Context, latestBlock int64, fromIdx int64, toIdx int64) (hashes []string, errs []error) {
+	count := toIdx - fromIdx
+	hashes = make([]string, count)
+	errs = make([]error, count)
+	workers := int64(cs.fetchConcurrency)
+	if workers > count {
+		workers = count
+	}
+	if workers <= 1 {
+		for i := int64(0); i < count; i++ {
+			hashes[i], errs[i] = cs.fetchBlockHashByNum(ctx, latestBlock-fromIdx-i)
 		}
-		if existingBlockStore.Hash == newHashForBlock { // means we already have that hash, since its a blockchain, this means all previous hashes are the same too
-			overwriteElements := blocksQueueIdx + 1
-			if overwriteElements < int64(cs.blocksToSave)-1-newQueueIdx || readIndexDiff > overwriteElements { // make sure that in the tail we updated and the existing block we have at least cs.blocksToSave
-				utils.LavaFormatError("mismatching blocksQueue Index and fetch index, there aren't enough blocks", nil, utils.Attribute{Key: "block", Value: fetchedBlockNum},
-					utils.Attribute{Key: "existingBlockStore", Value: existingBlockStore},
-					utils.Attribute{Key: "overwriteElements", Value: overwriteElements}, utils.Attribute{Key: "newQueueIdx", Value: newQueueIdx}, utils.Attribute{Key: "readIndexDiff", Value: readIndexDiff})
-				return false, 0, 0, 0
-			} else {
-				return true, readIndexDiff, overwriteElements, overwriteElements - readIndexDiff
+		return hashes, errs
+	}
+	indexes := make(chan int64, count)
+	for i := int64(0); i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	wg.Add(int(workers))
+	for worker := int64(0); worker < workers; worker++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				hashes[i], errs[i] = cs.fetchBlockHashByNum(ctx, latestBlock-fromIdx-i)
 			}
-		}
+		}()
+	}
+	wg.Wait()
+	return hashes, errs
+}
+
+// returns true if the saved hash of fetchedBlockNum is newHashForBlock, and the saved blocks before it fill the window up to latestBlock. blockQueueMu must be locked
+func (cs *ChainTracker) hashOverlapsUnsafe(latestBlock int64, fetchedBlockNum int64, newHashForBlock string) bool {
+	existingBlockStore, ok := cs.blocksQueue.Get(fetchedBlockNum)
+	if !ok || existingBlockStore.Hash != newHashForBlock {
+		return false
 	}
-	return false, 0, 0, 0
+	return cs.blocksQueue.Earliest().Block <= latestBlock-int64(cs.getBlocksToSave())+1
 }
 
 // this function reads the hash of the latest block and finds wether there was a fork, if it identifies a newer block arrived it goes backwards to the block in memory and reads again
 func (cs *ChainTracker) forkChanged(ctx context.Context, newLatestBlock int64) (forked bool, err error) {
+	if cs.hashless {
+		// forks can't be detected without hashes
+		return false, nil
+	}
 	if newLatestBlock == cs.GetLatestBlockNum() {
 		// no new block arrived, compare the last hash
 		hash, err := cs.fetchBlockHashByNum(ctx, newLatestBlock)
@@ -249,29 +390,32 @@ func (cs *ChainTracker) fetchAllPreviousBlocksIfNecessary(ctx context.Context) (
 	if err != nil {
 		return utils.LavaFormatError("could not fetchLatestBlockNum in ChainTracker", err, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
 	}
+	if newLatestBlock < cs.GetLatestBlockNum() {
+		return cs.onHeightRegression(ctx, newLatestBlock)
+	}
+	cs.endHeightRegression(newLatestBlock)
 	gotNewBlock := cs.gotNewBlock(ctx, newLatestBlock)
 	forked, err := cs.forkChanged(ctx, newLatestBlock)
 	if err != nil {
 		return utils.LavaFormatError("could not fetchLatestBlock Hash in ChainTracker", err, utils.Attribute{Key: "block", Value: newLatestBlock}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
 	}
-	if gotNewBlock || forked {
+	// the window grew since the last fetch, the blocks below the saved ones are fetched without waiting for a new block
+	windowGrew := newLatestBlock >= cs.GetLatestBlockNum() && cs.missingBlocks()
+	if gotNewBlock || forked || windowGrew {
 		prev_latest := cs.GetLatestBlockNum()
 		latestHash, err := cs.fetchAllPreviousBlocks(ctx, newLatestBlock)
 		if err != nil {
 			return err
 		}
 		if gotNewBlock {
-			if cs.newLatestCallback != nil {
-				for i := prev_latest + 1; i <= newLatestBlock; i++ {
-					// on catch up of several blocks we don't want to miss any callbacks
-					cs.newLatestCallback(i, latestHash)
-				}
+			cs.recordBlockArrival(newLatestBlock, time.Now())
+			for i := prev_latest + 1; i <= newLatestBlock; i++ {
+				// on catch up of several blocks we don't want to miss any callbacks
+				cs.notifyBlockListeners(i, latestHash)
 			}
 		}
 		if forked {
-			if cs.forkCallback != nil {
-				cs.forkCallback(newLatestBlock)
-			}
+			cs.notifyForkListeners(newLatestBlock, latestHash)
 		}
 	}
 	return err
@@ -280,22 +424,34 @@ func (cs *ChainTracker) fetchAllPreviousBlocksIfNecessary(ctx context.Context) (
 // this function starts the fetching timer periodically checking by polling if updates are necessary
 func (cs *ChainTracker) start(ctx context.Context, pollingBlockTime time.Duration) error {
 	// how often to query latest block.
-	// TODO: improve the polling time, we don't need to poll the first half of every block change
 	tickerTime := pollingBlockTime / 10
-	cs.ticker = time.NewTicker(tickerTime) // divide here so we don't miss new blocks by all that much
+	cs.ticker = time.NewTicker(cs.setPollDelay(cs.jitter(tickerTime))) // divide here so we don't miss new blocks by all that much
 	err := cs.fetchInitDataWithRetry(ctx)
 	if err != nil {
 		return err
 	}
+	cs.pollingStart = time.Now()
 	// Polls blocks and keeps a queue of them
 	go func() {
+		defer close(cs.pollingDone)
 		fetchFails := uint64(0)
+		polls := uint64(0)
 		for {
 			select {
 			case <-cs.ticker.C:
+				polls++
+				tickerTime = cs.getConfiguredBlockTime() / 10 // follows UpdateConfig
+				cs.checkReferenceIfNecessary(ctx, polls)
 				err := cs.fetchAllPreviousBlocksIfNecessary(ctx)
 				if err != nil {
 					fetchFails += 1
+					atomic.StoreUint64(&cs.consecutiveFetchFails, fetchFails)
+					if errors.Is(err, ErrorFetchTimeout) {
+						fetchTimeouts := atomic.AddUint64(&cs.consecutiveFetchTimeouts, 1)
+						cs.updateTickerAfterTimeout(tickerTime, fetchFails)
+						utils.LavaFormatError("node didn't reply in time while fetching all previous blocks", err, utils.Attribute{Key: "fetchFails", Value: fetchFails}, utils.Attribute{Key: "fetchTimeouts", Value: fetchTimeouts})
+						continue
+					}
 					cs.updateTicker(tickerTime, fetchFails)
 					utils.LavaFormatError("failed to fetch all previous blocks and was necessary", err, utils.Attribute{Key: "fetchFails", Value: fetchFails})
 				} else {
@@ -304,6 +460,18 @@ func (cs *ChainTracker) start(ctx context.Context, pollingBlockTime time.Duratio
 						cs.updateTicker(tickerTime, 0)
 					}
 					fetchFails = 0
+					atomic.StoreUint64(&cs.consecutiveFetchFails, 0)
+					atomic.StoreUint64(&cs.consecutiveFetchTimeouts, 0)
+					cs.setLastSuccessfulFetch(time.Now())
+					cs.checkStale(time.Now())
+					// don't poll the node while the next block is far from expected
+					cs.ticker.Reset(cs.setPollDelay(cs.jitter(cs.nextPollDelay(tickerTime, time.Now()))))
+				}
+			case <-cs.configChanged:
+				if fetchFails == 0 {
+					// without this a faster chain would only be polled at the new rate after the current, longer, interval
+					tickerTime = cs.getConfiguredBlockTime() / 10
+					cs.ticker.Reset(cs.setPollDelay(cs.jitter(tickerTime)))
 				}
 			case <-cs.quit:
 				cs.ticker.Stop()
@@ -314,9 +482,38 @@ func (cs *ChainTracker) start(ctx context.Context, pollingBlockTime time.Duratio
 	return nil
 }
 
+// Close stops polling the node, gracefully drains and shuts down the grpc server if one is served and releases the fetcher.
+// the ctx bounds the graceful drain, calling Close more than once is a no-op
+func (cs *ChainTracker) Close(ctx context.Context) (err error) {
+	cs.closeOnce.Do(func() {
+		close(cs.quit)
+		select {
+		case <-cs.pollingDone:
+		case <-ctx.Done():
+			err = utils.LavaFormatWarning("chain tracker close timed out waiting for polling to stop", ctx.Err(), utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+		}
+		cs.serverMu.Lock()
+		cs.closed = true
+		httpServer := cs.httpServer
+		cs.httpServer = nil
+		cs.serverMu.Unlock()
+		if httpServer != nil {
+			if shutdownErr := httpServer.Shutdown(ctx); shutdownErr != nil {
+				err = utils.LavaFormatError("chain tracker failed to shutdown server", shutdownErr, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+			}
+		}
+		if closer, ok := cs.chainFetcher.(io.Closer); ok {
+			if closeErr := closer.Close(); closeErr != nil {
+				err = utils.LavaFormatError("chain tracker failed to close chain fetcher", closeErr, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+			}
+		}
+	})
+	return err
+}
+
 func (cs *ChainTracker) updateTicker(tickerBaseTime time.Duration, fetchFails uint64) {
 	cs.ticker.Stop()
-	cs.ticker = time.NewTicker(exponentialBackoff(tickerBaseTime, fetchFails))
+	cs.ticker = time.NewTicker(cs.setPollDelay(cs.jitter(exponentialBackoff(tickerBaseTime, fetchFails))))
 }
 
 func (cs *ChainTracker) fetchInitDataWithRetry(ctx context.Context) (err error) {
@@ -328,6 +525,9 @@ func (cs *ChainTracker) fetchInitDataWithRetry(ctx context.Context) (err error)
 	if err != nil {
 		return utils.LavaFormatError("critical -- failed fetching data from the node, chain tracker creation error", err, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
 	}
+	if cs.initialSnapshot != nil {
+		cs.restoreInitialSnapshot(newLatestBlock)
+	}
 	_, err = cs.fetchAllPreviousBlocks(ctx, newLatestBlock)
 	for idx := 0; idx < initRetriesCount && err != nil; idx++ {
 		utils.LavaFormatDebug("failed fetching data on chain tracker init, retry", utils.Attribute{Key: "retry Num", Value: idx}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
@@ -336,11 +536,13 @@ func (cs *ChainTracker) fetchInitDataWithRetry(ctx context.Context) (err error)
 	if err != nil {
 		return utils.LavaFormatError("critical -- failed fetching data from the node, chain tracker creation error", err, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
 	}
+	cs.setLastSuccessfulFetch(time.Now())
+	cs.recordBlockArrival(newLatestBlock, time.Now())
 	return nil
 }
 
 // this function serves a grpc server if configuration for it was provided, the goal is to enable stateTracker to serve several processes and minimize node queries
-func (ct *ChainTracker) serve(ctx context.Context, listenAddr string) error {
+func (ct *ChainTracker) serve(ctx context.Context, listenAddr string, serverTLS *ServerTLSConfig, serverLimits *ServerLimitsConfig, serverDebug bool) error {
 	if listenAddr == "" {
 		return nil
 	}
@@ -355,7 +557,16 @@ func (ct *ChainTracker) serve(ctx context.Context, listenAddr string) error {
 	if err != nil {
 		utils.LavaFormatFatal("Chain Tracker failure setting up listener", err, utils.Attribute{Key: "listenAddr", Value: listenAddr})
 	}
-	s := grpc.NewServer()
+	serverOptions := []grpc.ServerOption{}
+	if serverLimits != nil {
+		serverLimiter, err := NewServerLimiter(*serverLimits)
+		if err != nil {
+			lis.Close()
+			return err
+		}
+		serverOptions = append(serverOptions, serverLimiter.ServerOptions()...)
+	}
+	s := grpc.NewServer(serverOptions...)
 
 	wrappedServer := grpcweb.WrapServer(s)
 	handler := func(resp http.ResponseWriter, req *http.Request) {
@@ -363,12 +574,34 @@ func (ct *ChainTracker) serve(ctx context.Context, listenAddr string) error {
 		resp.Header().Set("Access-Control-Allow-Origin", "*")
 		resp.Header().Set("Access-Control-Allow-Headers", "Content-Type,x-grpc-web")
 
+		switch req.URL.Path {
+		case HealthPath:
+			ct.healthHandler(resp, req)
+			return
+		case ReadyPath:
+			ct.readyHandler(resp, req)
+			return
+		}
+		if serverDebug && ct.debugHandler(resp, req) {
+			return
+		}
 		wrappedServer.ServeHTTP(resp, req)
 	}
 
-	httpServer := http.Server{
+	httpServer := &http.Server{
 		Handler: h2c.NewHandler(http.HandlerFunc(handler), &http2.Server{}),
 	}
+	if serverTLS != nil {
+		tlsConfig, err := serverTLS.tlsConfig()
+		if err != nil {
+			lis.Close()
+			return err
+		}
+		// over tls http2 is negotiated with ALPN so there is no need for h2c
+		httpServer.Handler = http.HandlerFunc(handler)
+		httpServer.TLSConfig = tlsConfig
+		lis = tls.NewListener(lis, tlsConfig)
+	}
 
 	go func() {
 		select {
@@ -376,13 +609,15 @@ func (ct *ChainTracker) serve(ctx context.Context, listenAddr string) error {
 			utils.LavaFormatInfo("Chain Tracker Server ctx.Done")
 		case <-signalChan:
 			utils.LavaFormatInfo("Chain Tracker Server signalChan")
+		case <-ct.quit:
+			// closed directly through Close
 		}
 
 		shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
 		defer shutdownRelease()
 
-		if err := httpServer.Shutdown(shutdownCtx); err != nil {
-			utils.LavaFormatFatal("chainTracker failed to shutdown", err)
+		if err := ct.Close(shutdownCtx); err != nil {
+			utils.LavaFormatWarning("chainTracker failed to shutdown", err)
 		}
 	}()
 
@@ -390,7 +625,16 @@ func (ct *ChainTracker) serve(ctx context.Context, listenAddr string) error {
 
 	RegisterChainTrackerServiceServer(s, server)
 
-	utils.LavaFormatInfo("Chain Tracker Listening", utils.Attribute{Key: "Address", Value: lis.Addr().String()})
+	ct.serverMu.Lock()
+	if ct.closed {
+		ct.serverMu.Unlock()
+		lis.Close()
+		return nil
+	}
+	ct.httpServer = httpServer
+	ct.serverMu.Unlock()
+
+	utils.LavaFormatInfo("Chain Tracker Listening", utils.Attribute{Key: "Address", Value: lis.Addr().String()}, utils.Attribute{Key: "tls", Value: serverTLS != nil})
 	if err := httpServer.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
 		utils.LavaFormatFatal("Chain Tracker failed to serve", err, utils.Attribute{Key: "Address", Value: lis.Addr()})
 	}
@@ -402,16 +646,48 @@ func NewChainTracker(ctx context.Context, chainFetcher ChainFetcher, config Chai
 	if err != nil {
 		return nil, err
 	}
-	chainTracker = &ChainTracker{forkCallback: config.ForkCallback, newLatestCallback: config.NewLatestCallback, blocksToSave: config.BlocksToSave, chainFetcher: chainFetcher, latestBlockNum: 0, serverBlockMemory: config.ServerBlockMemory, blockCheckpointDistance: config.blocksCheckpointDistance}
+	chainTracker = &ChainTracker{blocksToSave: config.BlocksToSave, chainFetcher: chainFetcher, latestBlockNum: 0, serverBlockMemory: config.ServerBlockMemory, blockCheckpointDistance: config.BlocksCheckpointDistance, quit: make(chan bool), configChanged: make(chan struct{}, 1), pollingDone: make(chan struct{}), averageBlockTime: config.AverageBlockTime}
+	chainTracker.referenceFetcher = config.ReferenceFetcher
+	chainTracker.referenceCheckInterval = config.ReferenceCheckInterval
+	chainTracker.maxBlocksBehindReference = config.MaxBlocksBehindReference
+	chainTracker.laggingNodeCallback = config.LaggingNodeCallback
+	chainTracker.staleBlocksThreshold = config.StaleBlocksThreshold
+	chainTracker.staleChainCallback = config.StaleChainCallback
+	chainTracker.gapDetectedCallback = config.GapDetectedCallback
+	chainTracker.heightRegressionCallback = config.HeightRegressionCallback
+	chainTracker.heightRegressionResync = config.HeightRegressionResyncPolls
+	chainTracker.hashVerifier = config.HashVerifier
+	chainTracker.hashless = config.HashlessMode
+	chainTracker.latestBlockFetchTimeout = config.LatestBlockFetchTimeout
+	chainTracker.blockHashFetchTimeout = config.BlockHashFetchTimeout
+	chainTracker.pollingJitter = config.PollingJitter
+	chainTracker.latestBlockNumLatencies = newFetchLatencies()
+	chainTracker.blockHashLatencies = newFetchLatencies()
+	if config.NewLatestCallback != nil {
+		chainTracker.RegisterBlockListener(config.NewLatestCallback)
+	}
+	if config.ForkCallback != nil {
+		chainTracker.RegisterForkListener(config.ForkCallback)
+	}
+	chainTracker.reorgHistorySize = config.ReorgHistorySize
+	chainTracker.fetchConcurrency = config.FetchConcurrency
+	chainTracker.finalizationDistance = config.FinalizationDistance
+	chainTracker.blockBodyRetention = config.BlockBodyRetention
+	chainTracker.initialSnapshot = config.InitialSnapshot
+	chainTracker.blockBodies = map[int64]*blockBody{}
+	chainTracker.blocksQueue = newBlocksRing(config.BlocksToSave)
 	if chainFetcher == nil {
 		return nil, utils.LavaFormatError("can't start chainTracker with nil chainFetcher argument", nil)
 	}
 	chainTracker.endpoint = chainFetcher.FetchEndpoint()
+	if chainTracker.hashless {
+		utils.LavaFormatInfo("chain tracker running without block hashes, forks won't be detected", utils.Attribute{Key: "endpoint", Value: chainTracker.endpoint})
+	}
 	err = chainTracker.start(ctx, config.AverageBlockTime)
 	if err != nil {
 		return nil, err
 	}
-	err = chainTracker.serve(ctx, config.ServerAddress)
+	err = chainTracker.serve(ctx, config.ServerAddress, config.ServerTLS, config.ServerLimits, config.ServerDebug)
 	return
 }
 
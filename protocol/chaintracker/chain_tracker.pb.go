@@ -28,6 +28,7 @@ type LatestBlockData struct {
 	FromBlock     int64 `protobuf:"varint,1,opt,name=fromBlock,proto3" json:"fromBlock,omitempty"`
 	ToBlock       int64 `protobuf:"varint,2,opt,name=toBlock,proto3" json:"toBlock,omitempty"`
 	SpecificBlock int64 `protobuf:"varint,3,opt,name=specificBlock,proto3" json:"specificBlock,omitempty"`
+	FinalizedOnly bool  `protobuf:"varint,4,opt,name=finalizedOnly,proto3" json:"finalizedOnly,omitempty"`
 }
 
 func (m *LatestBlockData) Reset()         { *m = LatestBlockData{} }
@@ -84,6 +85,13 @@ func (m *LatestBlockData) GetSpecificBlock() int64 {
 	return 0
 }
 
+func (m *LatestBlockData) GetFinalizedOnly() bool {
+	if m != nil {
+		return m.FinalizedOnly
+	}
+	return false
+}
+
 type LatestBlockDataResponse struct {
 	LatestBlock     int64         `protobuf:"varint,1,opt,name=latestBlock,proto3" json:"latestBlock,omitempty"`
 	RequestedHashes []*BlockStore `protobuf:"bytes,2,rep,name=requestedHashes,proto3" json:"requestedHashes,omitempty"`
@@ -188,226 +196,2982 @@ func (m *BlockStore) GetHash() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*LatestBlockData)(nil), "chainTracker.LatestBlockData")
-	proto.RegisterType((*LatestBlockDataResponse)(nil), "chainTracker.LatestBlockDataResponse")
-	proto.RegisterType((*BlockStore)(nil), "chainTracker.BlockStore")
+type ReorgHistoryRequest struct {
+	Limit uint64 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
 }
 
-func init() { proto.RegisterFile("chainTracker.proto", fileDescriptor_90f7d15fc8a35cee) }
+func (m *ReorgHistoryRequest) Reset()         { *m = ReorgHistoryRequest{} }
+func (m *ReorgHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*ReorgHistoryRequest) ProtoMessage()    {}
+func (*ReorgHistoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{3}
+}
+func (m *ReorgHistoryRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReorgHistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReorgHistoryRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ReorgHistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReorgHistoryRequest.Merge(m, src)
+}
+func (m *ReorgHistoryRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReorgHistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReorgHistoryRequest.DiscardUnknown(m)
+}
 
-var fileDescriptor_90f7d15fc8a35cee = []byte{
-	// 359 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x91, 0xcf, 0x4e, 0xc2, 0x40,
-	0x10, 0xc6, 0x5b, 0xf0, 0x4f, 0x18, 0x54, 0xe2, 0x6a, 0xb4, 0xa9, 0xd8, 0x90, 0x46, 0x13, 0x4e,
-	0x25, 0x41, 0xc3, 0x03, 0xa0, 0x46, 0x8d, 0xc6, 0x43, 0x51, 0x0f, 0xc6, 0xcb, 0x52, 0x07, 0xda,
-	0x50, 0xd8, 0xba, 0xbb, 0xd5, 0x78, 0xf2, 0x15, 0x7c, 0x24, 0x8f, 0x1e, 0x39, 0x7a, 0x34, 0xf0,
-	0x22, 0xc6, 0xad, 0x84, 0xb6, 0x26, 0xde, 0x76, 0xbe, 0xdf, 0xec, 0x7c, 0x3b, 0xdf, 0x02, 0xf1,
-	0x7c, 0x1a, 0x8c, 0xae, 0x39, 0xf5, 0x06, 0xc8, 0x9d, 0x88, 0x33, 0xc9, 0xc8, 0x4a, 0x5a, 0x33,
-	0xad, 0x3e, 0x63, 0xfd, 0x10, 0x1b, 0x8a, 0x75, 0xe3, 0x5e, 0xe3, 0x99, 0xd3, 0x28, 0x42, 0x2e,
-	0x92, 0x6e, 0x73, 0x27, 0xcf, 0x71, 0x18, 0xc9, 0x97, 0x04, 0xda, 0x0c, 0x2a, 0x97, 0x54, 0xa2,
-	0x90, 0xed, 0x90, 0x79, 0x83, 0x63, 0x2a, 0x29, 0xa9, 0x42, 0xa9, 0xc7, 0xd9, 0x50, 0x09, 0x86,
-	0x5e, 0xd3, 0xeb, 0x45, 0x77, 0x2e, 0x10, 0x03, 0x96, 0x25, 0x4b, 0x58, 0x41, 0xb1, 0x59, 0x49,
-	0xf6, 0x60, 0x55, 0x44, 0xe8, 0x05, 0xbd, 0xc0, 0x4b, 0x78, 0x51, 0xf1, 0xac, 0x68, 0xbf, 0xc2,
-	0x76, 0xce, 0xd0, 0x45, 0x11, 0xb1, 0x91, 0x40, 0x52, 0x83, 0x72, 0x38, 0x47, 0xbf, 0xd6, 0x69,
-	0x89, 0xb4, 0xa1, 0xc2, 0xf1, 0x31, 0x46, 0x21, 0xf1, 0xe1, 0x8c, 0x0a, 0x1f, 0x85, 0x51, 0xa8,
-	0x15, 0xeb, 0xe5, 0xa6, 0xe1, 0x64, 0x62, 0x52, 0xdd, 0x1d, 0xc9, 0x38, 0xba, 0xf9, 0x0b, 0x76,
-	0x0b, 0x60, 0x8e, 0xc9, 0x26, 0x2c, 0x76, 0x53, 0x6e, 0x49, 0x41, 0x08, 0x2c, 0xf8, 0x54, 0xf8,
-	0x6a, 0xc3, 0x92, 0xab, 0xce, 0xcd, 0x77, 0x1d, 0x36, 0x8e, 0x52, 0x26, 0x1d, 0xe4, 0x4f, 0x81,
-	0x87, 0xe4, 0x02, 0xd6, 0x4f, 0x51, 0xa6, 0x76, 0xba, 0x8a, 0x87, 0x64, 0xcb, 0x49, 0x42, 0x77,
-	0x66, 0xa1, 0x3b, 0x27, 0x3f, 0xa1, 0x9b, 0xd5, 0x3f, 0xfa, 0xcd, 0xf9, 0x48, 0xb6, 0x0e, 0x6f,
-	0x69, 0x18, 0xa3, 0xad, 0x91, 0x7b, 0x20, 0xd9, 0x61, 0xea, 0x47, 0x76, 0xb3, 0xdb, 0xe5, 0xb0,
-	0xb9, 0xff, 0x2f, 0x9e, 0xc5, 0x6b, 0x6b, 0xed, 0xfa, 0xc7, 0xc4, 0xd2, 0xc7, 0x13, 0x4b, 0xff,
-	0x9a, 0x58, 0xfa, 0xdb, 0xd4, 0xd2, 0xc6, 0x53, 0x4b, 0xfb, 0x9c, 0x5a, 0xda, 0xdd, 0x9a, 0xd3,
-	0x50, 0x33, 0x64, 0x32, 0xa3, 0xbb, 0xa4, 0xde, 0x77, 0xf0, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x72,
-	0xe1, 0x3c, 0xae, 0x7e, 0x02, 0x00, 0x00,
+var xxx_messageInfo_ReorgHistoryRequest proto.InternalMessageInfo
+
+func (m *ReorgHistoryRequest) GetLimit() uint64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
 }
 
-func (m *LatestBlockData) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+type ReorgHistoryResponse struct {
+	Reorgs []*ReorgEvent `protobuf:"bytes,1,rep,name=reorgs,proto3" json:"reorgs,omitempty"`
+}
+
+func (m *ReorgHistoryResponse) Reset()         { *m = ReorgHistoryResponse{} }
+func (m *ReorgHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*ReorgHistoryResponse) ProtoMessage()    {}
+func (*ReorgHistoryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{4}
+}
+func (m *ReorgHistoryResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReorgHistoryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReorgHistoryResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *ReorgHistoryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReorgHistoryResponse.Merge(m, src)
+}
+func (m *ReorgHistoryResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReorgHistoryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReorgHistoryResponse.DiscardUnknown(m)
 }
 
-func (m *LatestBlockData) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_ReorgHistoryResponse proto.InternalMessageInfo
+
+func (m *ReorgHistoryResponse) GetReorgs() []*ReorgEvent {
+	if m != nil {
+		return m.Reorgs
+	}
+	return nil
 }
 
-func (m *LatestBlockData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.SpecificBlock != 0 {
-		i = encodeVarintChainTracker(dAtA, i, uint64(m.SpecificBlock))
-		i--
-		dAtA[i] = 0x18
+type ReorgEvent struct {
+	Height        int64  `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	OldHash       string `protobuf:"bytes,2,opt,name=oldHash,proto3" json:"oldHash,omitempty"`
+	NewHash       string `protobuf:"bytes,3,opt,name=newHash,proto3" json:"newHash,omitempty"`
+	Depth         int64  `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`
+	DetectionTime int64  `protobuf:"varint,5,opt,name=detectionTime,proto3" json:"detectionTime,omitempty"`
+}
+
+func (m *ReorgEvent) Reset()         { *m = ReorgEvent{} }
+func (m *ReorgEvent) String() string { return proto.CompactTextString(m) }
+func (*ReorgEvent) ProtoMessage()    {}
+func (*ReorgEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{5}
+}
+func (m *ReorgEvent) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReorgEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReorgEvent.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if m.ToBlock != 0 {
-		i = encodeVarintChainTracker(dAtA, i, uint64(m.ToBlock))
-		i--
-		dAtA[i] = 0x10
+}
+func (m *ReorgEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReorgEvent.Merge(m, src)
+}
+func (m *ReorgEvent) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReorgEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReorgEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReorgEvent proto.InternalMessageInfo
+
+func (m *ReorgEvent) GetHeight() int64 {
+	if m != nil {
+		return m.Height
 	}
-	if m.FromBlock != 0 {
-		i = encodeVarintChainTracker(dAtA, i, uint64(m.FromBlock))
-		i--
-		dAtA[i] = 0x8
+	return 0
+}
+
+func (m *ReorgEvent) GetOldHash() string {
+	if m != nil {
+		return m.OldHash
 	}
-	return len(dAtA) - i, nil
+	return ""
 }
 
-func (m *LatestBlockDataResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *ReorgEvent) GetNewHash() string {
+	if m != nil {
+		return m.NewHash
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *LatestBlockDataResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *ReorgEvent) GetDepth() int64 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
 }
 
-func (m *LatestBlockDataResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.RequestedHashes) > 0 {
-		for iNdEx := len(m.RequestedHashes) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.RequestedHashes[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintChainTracker(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x12
+func (m *ReorgEvent) GetDetectionTime() int64 {
+	if m != nil {
+		return m.DetectionTime
+	}
+	return 0
+}
+
+type AverageBlockTimeResponse struct {
+	AverageBlockTime int64  `protobuf:"varint,1,opt,name=averageBlockTime,proto3" json:"averageBlockTime,omitempty"`
+	Samples          uint64 `protobuf:"varint,2,opt,name=samples,proto3" json:"samples,omitempty"`
+}
+
+func (m *AverageBlockTimeResponse) Reset()         { *m = AverageBlockTimeResponse{} }
+func (m *AverageBlockTimeResponse) String() string { return proto.CompactTextString(m) }
+func (*AverageBlockTimeResponse) ProtoMessage()    {}
+func (*AverageBlockTimeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{6}
+}
+func (m *AverageBlockTimeResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AverageBlockTimeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AverageBlockTimeResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if m.LatestBlock != 0 {
-		i = encodeVarintChainTracker(dAtA, i, uint64(m.LatestBlock))
-		i--
-		dAtA[i] = 0x8
+}
+func (m *AverageBlockTimeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AverageBlockTimeResponse.Merge(m, src)
+}
+func (m *AverageBlockTimeResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *AverageBlockTimeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AverageBlockTimeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AverageBlockTimeResponse proto.InternalMessageInfo
+
+func (m *AverageBlockTimeResponse) GetAverageBlockTime() int64 {
+	if m != nil {
+		return m.AverageBlockTime
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func (m *BlockStore) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *AverageBlockTimeResponse) GetSamples() uint64 {
+	if m != nil {
+		return m.Samples
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *BlockStore) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ChainTrackerSnapshot struct {
+	LatestBlock     int64         `protobuf:"varint,1,opt,name=latestBlock,proto3" json:"latestBlock,omitempty"`
+	Blocks          []*BlockStore `protobuf:"bytes,2,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	BlockCheckpoint uint64        `protobuf:"varint,3,opt,name=blockCheckpoint,proto3" json:"blockCheckpoint,omitempty"`
+	ChainID         string        `protobuf:"bytes,4,opt,name=chainID,proto3" json:"chainID,omitempty"`
+	ApiInterface    string        `protobuf:"bytes,5,opt,name=apiInterface,proto3" json:"apiInterface,omitempty"`
+	Hashless        bool          `protobuf:"varint,6,opt,name=hashless,proto3" json:"hashless,omitempty"`
+	SnapshotTime    int64         `protobuf:"varint,7,opt,name=snapshotTime,proto3" json:"snapshotTime,omitempty"`
 }
 
-func (m *BlockStore) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Hash) > 0 {
-		i -= len(m.Hash)
-		copy(dAtA[i:], m.Hash)
-		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.Hash)))
-		i--
-		dAtA[i] = 0x12
+func (m *ChainTrackerSnapshot) Reset()         { *m = ChainTrackerSnapshot{} }
+func (m *ChainTrackerSnapshot) String() string { return proto.CompactTextString(m) }
+func (*ChainTrackerSnapshot) ProtoMessage()    {}
+func (*ChainTrackerSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{7}
+}
+func (m *ChainTrackerSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ChainTrackerSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ChainTrackerSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if m.Block != 0 {
-		i = encodeVarintChainTracker(dAtA, i, uint64(m.Block))
-		i--
-		dAtA[i] = 0x8
+}
+func (m *ChainTrackerSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChainTrackerSnapshot.Merge(m, src)
+}
+func (m *ChainTrackerSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *ChainTrackerSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChainTrackerSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChainTrackerSnapshot proto.InternalMessageInfo
+
+func (m *ChainTrackerSnapshot) GetLatestBlock() int64 {
+	if m != nil {
+		return m.LatestBlock
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func encodeVarintChainTracker(dAtA []byte, offset int, v uint64) int {
-	offset -= sovChainTracker(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *ChainTrackerSnapshot) GetBlocks() []*BlockStore {
+	if m != nil {
+		return m.Blocks
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return nil
 }
-func (m *LatestBlockData) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *ChainTrackerSnapshot) GetBlockCheckpoint() uint64 {
+	if m != nil {
+		return m.BlockCheckpoint
 	}
-	var l int
-	_ = l
-	if m.FromBlock != 0 {
-		n += 1 + sovChainTracker(uint64(m.FromBlock))
+	return 0
+}
+
+func (m *ChainTrackerSnapshot) GetChainID() string {
+	if m != nil {
+		return m.ChainID
 	}
-	if m.ToBlock != 0 {
-		n += 1 + sovChainTracker(uint64(m.ToBlock))
+	return ""
+}
+
+func (m *ChainTrackerSnapshot) GetApiInterface() string {
+	if m != nil {
+		return m.ApiInterface
 	}
-	if m.SpecificBlock != 0 {
-		n += 1 + sovChainTracker(uint64(m.SpecificBlock))
+	return ""
+}
+
+func (m *ChainTrackerSnapshot) GetHashless() bool {
+	if m != nil {
+		return m.Hashless
 	}
-	return n
+	return false
+}
+
+func (m *ChainTrackerSnapshot) GetSnapshotTime() int64 {
+	if m != nil {
+		return m.SnapshotTime
+	}
+	return 0
+}
+
+type LatestBlockDataBatch struct {
+	Requests []*LatestBlockData `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (m *LatestBlockDataBatch) Reset()         { *m = LatestBlockDataBatch{} }
+func (m *LatestBlockDataBatch) String() string { return proto.CompactTextString(m) }
+func (*LatestBlockDataBatch) ProtoMessage()    {}
+func (*LatestBlockDataBatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{8}
+}
+func (m *LatestBlockDataBatch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LatestBlockDataBatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LatestBlockDataBatch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LatestBlockDataBatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LatestBlockDataBatch.Merge(m, src)
+}
+func (m *LatestBlockDataBatch) XXX_Size() int {
+	return m.Size()
+}
+func (m *LatestBlockDataBatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_LatestBlockDataBatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LatestBlockDataBatch proto.InternalMessageInfo
+
+func (m *LatestBlockDataBatch) GetRequests() []*LatestBlockData {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+type LatestBlockDataBatchResponse struct {
+	LatestBlock int64              `protobuf:"varint,1,opt,name=latestBlock,proto3" json:"latestBlock,omitempty"`
+	Results     []*BlockDataResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (m *LatestBlockDataBatchResponse) Reset()         { *m = LatestBlockDataBatchResponse{} }
+func (m *LatestBlockDataBatchResponse) String() string { return proto.CompactTextString(m) }
+func (*LatestBlockDataBatchResponse) ProtoMessage()    {}
+func (*LatestBlockDataBatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{9}
+}
+func (m *LatestBlockDataBatchResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LatestBlockDataBatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LatestBlockDataBatchResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LatestBlockDataBatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LatestBlockDataBatchResponse.Merge(m, src)
+}
+func (m *LatestBlockDataBatchResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *LatestBlockDataBatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LatestBlockDataBatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LatestBlockDataBatchResponse proto.InternalMessageInfo
+
+func (m *LatestBlockDataBatchResponse) GetLatestBlock() int64 {
+	if m != nil {
+		return m.LatestBlock
+	}
+	return 0
+}
+
+func (m *LatestBlockDataBatchResponse) GetResults() []*BlockDataResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type BlockDataResult struct {
+	RequestedHashes []*BlockStore `protobuf:"bytes,1,rep,name=requestedHashes,proto3" json:"requestedHashes,omitempty"`
+	Error           string        `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *BlockDataResult) Reset()         { *m = BlockDataResult{} }
+func (m *BlockDataResult) String() string { return proto.CompactTextString(m) }
+func (*BlockDataResult) ProtoMessage()    {}
+func (*BlockDataResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{10}
+}
+func (m *BlockDataResult) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BlockDataResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BlockDataResult.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BlockDataResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockDataResult.Merge(m, src)
+}
+func (m *BlockDataResult) XXX_Size() int {
+	return m.Size()
+}
+func (m *BlockDataResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockDataResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockDataResult proto.InternalMessageInfo
+
+func (m *BlockDataResult) GetRequestedHashes() []*BlockStore {
+	if m != nil {
+		return m.RequestedHashes
+	}
+	return nil
+}
+
+func (m *BlockDataResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type FetcherStatsResponse struct {
+	ChainID        string             `protobuf:"bytes,1,opt,name=chainID,proto3" json:"chainID,omitempty"`
+	ApiInterface   string             `protobuf:"bytes,2,opt,name=apiInterface,proto3" json:"apiInterface,omitempty"`
+	LatestBlockNum *FetchLatencyStats `protobuf:"bytes,3,opt,name=latestBlockNum,proto3" json:"latestBlockNum,omitempty"`
+	BlockHashByNum *FetchLatencyStats `protobuf:"bytes,4,opt,name=blockHashByNum,proto3" json:"blockHashByNum,omitempty"`
+}
+
+func (m *FetcherStatsResponse) Reset()         { *m = FetcherStatsResponse{} }
+func (m *FetcherStatsResponse) String() string { return proto.CompactTextString(m) }
+func (*FetcherStatsResponse) ProtoMessage()    {}
+func (*FetcherStatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{11}
+}
+func (m *FetcherStatsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FetcherStatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FetcherStatsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *FetcherStatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetcherStatsResponse.Merge(m, src)
+}
+func (m *FetcherStatsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *FetcherStatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetcherStatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetcherStatsResponse proto.InternalMessageInfo
+
+func (m *FetcherStatsResponse) GetChainID() string {
+	if m != nil {
+		return m.ChainID
+	}
+	return ""
+}
+
+func (m *FetcherStatsResponse) GetApiInterface() string {
+	if m != nil {
+		return m.ApiInterface
+	}
+	return ""
+}
+
+func (m *FetcherStatsResponse) GetLatestBlockNum() *FetchLatencyStats {
+	if m != nil {
+		return m.LatestBlockNum
+	}
+	return nil
+}
+
+func (m *FetcherStatsResponse) GetBlockHashByNum() *FetchLatencyStats {
+	if m != nil {
+		return m.BlockHashByNum
+	}
+	return nil
+}
+
+type FetchLatencyStats struct {
+	Calls          uint64           `protobuf:"varint,1,opt,name=calls,proto3" json:"calls,omitempty"`
+	Errors         uint64           `protobuf:"varint,2,opt,name=errors,proto3" json:"errors,omitempty"`
+	AverageLatency int64            `protobuf:"varint,3,opt,name=averageLatency,proto3" json:"averageLatency,omitempty"`
+	MaxLatency     int64            `protobuf:"varint,4,opt,name=maxLatency,proto3" json:"maxLatency,omitempty"`
+	P50Latency     int64            `protobuf:"varint,5,opt,name=p50Latency,proto3" json:"p50Latency,omitempty"`
+	P90Latency     int64            `protobuf:"varint,6,opt,name=p90Latency,proto3" json:"p90Latency,omitempty"`
+	P99Latency     int64            `protobuf:"varint,7,opt,name=p99Latency,proto3" json:"p99Latency,omitempty"`
+	Buckets        []*LatencyBucket `protobuf:"bytes,8,rep,name=buckets,proto3" json:"buckets,omitempty"`
+}
+
+func (m *FetchLatencyStats) Reset()         { *m = FetchLatencyStats{} }
+func (m *FetchLatencyStats) String() string { return proto.CompactTextString(m) }
+func (*FetchLatencyStats) ProtoMessage()    {}
+func (*FetchLatencyStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{12}
+}
+func (m *FetchLatencyStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FetchLatencyStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FetchLatencyStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *FetchLatencyStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchLatencyStats.Merge(m, src)
+}
+func (m *FetchLatencyStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *FetchLatencyStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchLatencyStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetchLatencyStats proto.InternalMessageInfo
+
+func (m *FetchLatencyStats) GetCalls() uint64 {
+	if m != nil {
+		return m.Calls
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetErrors() uint64 {
+	if m != nil {
+		return m.Errors
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetAverageLatency() int64 {
+	if m != nil {
+		return m.AverageLatency
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetMaxLatency() int64 {
+	if m != nil {
+		return m.MaxLatency
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetP50Latency() int64 {
+	if m != nil {
+		return m.P50Latency
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetP90Latency() int64 {
+	if m != nil {
+		return m.P90Latency
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetP99Latency() int64 {
+	if m != nil {
+		return m.P99Latency
+	}
+	return 0
+}
+
+func (m *FetchLatencyStats) GetBuckets() []*LatencyBucket {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+type LatencyBucket struct {
+	UpperBound int64  `protobuf:"varint,1,opt,name=upperBound,proto3" json:"upperBound,omitempty"`
+	Count      uint64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (m *LatencyBucket) Reset()         { *m = LatencyBucket{} }
+func (m *LatencyBucket) String() string { return proto.CompactTextString(m) }
+func (*LatencyBucket) ProtoMessage()    {}
+func (*LatencyBucket) Descriptor() ([]byte, []int) {
+	return fileDescriptor_90f7d15fc8a35cee, []int{13}
+}
+func (m *LatencyBucket) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LatencyBucket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LatencyBucket.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LatencyBucket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LatencyBucket.Merge(m, src)
+}
+func (m *LatencyBucket) XXX_Size() int {
+	return m.Size()
+}
+func (m *LatencyBucket) XXX_DiscardUnknown() {
+	xxx_messageInfo_LatencyBucket.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LatencyBucket proto.InternalMessageInfo
+
+func (m *LatencyBucket) GetUpperBound() int64 {
+	if m != nil {
+		return m.UpperBound
+	}
+	return 0
+}
+
+func (m *LatencyBucket) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*LatestBlockData)(nil), "chainTracker.LatestBlockData")
+	proto.RegisterType((*LatestBlockDataResponse)(nil), "chainTracker.LatestBlockDataResponse")
+	proto.RegisterType((*BlockStore)(nil), "chainTracker.BlockStore")
+	proto.RegisterType((*ReorgHistoryRequest)(nil), "chainTracker.ReorgHistoryRequest")
+	proto.RegisterType((*ReorgHistoryResponse)(nil), "chainTracker.ReorgHistoryResponse")
+	proto.RegisterType((*ReorgEvent)(nil), "chainTracker.ReorgEvent")
+	proto.RegisterType((*AverageBlockTimeResponse)(nil), "chainTracker.AverageBlockTimeResponse")
+	proto.RegisterType((*ChainTrackerSnapshot)(nil), "chainTracker.ChainTrackerSnapshot")
+	proto.RegisterType((*LatestBlockDataBatch)(nil), "chainTracker.LatestBlockDataBatch")
+	proto.RegisterType((*LatestBlockDataBatchResponse)(nil), "chainTracker.LatestBlockDataBatchResponse")
+	proto.RegisterType((*BlockDataResult)(nil), "chainTracker.BlockDataResult")
+	proto.RegisterType((*FetcherStatsResponse)(nil), "chainTracker.FetcherStatsResponse")
+	proto.RegisterType((*FetchLatencyStats)(nil), "chainTracker.FetchLatencyStats")
+	proto.RegisterType((*LatencyBucket)(nil), "chainTracker.LatencyBucket")
+}
+
+func init() { proto.RegisterFile("chainTracker.proto", fileDescriptor_90f7d15fc8a35cee) }
+
+var fileDescriptor_90f7d15fc8a35cee = []byte{
+	// 981 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0xc1, 0x6e, 0xdb, 0x46,
+	0x10, 0x15, 0x25, 0x59, 0xb2, 0xc7, 0x89, 0xd5, 0xac, 0x85, 0x84, 0x50, 0x1c, 0xd5, 0x5d, 0xb4,
+	0x81, 0x90, 0x02, 0xb2, 0xe1, 0x36, 0x29, 0x7c, 0xac, 0x12, 0x57, 0x36, 0x12, 0x34, 0x28, 0x9d,
+	0xb6, 0x40, 0xd0, 0x43, 0xd7, 0xd4, 0x48, 0x24, 0x44, 0x91, 0x2c, 0x77, 0xe9, 0x54, 0xbd, 0xb4,
+	0x9f, 0x50, 0x14, 0xed, 0xbd, 0x9f, 0xd3, 0x63, 0x8e, 0x3d, 0x16, 0x36, 0xd0, 0xef, 0x08, 0x76,
+	0xb9, 0x94, 0x48, 0x4a, 0x56, 0xec, 0x1b, 0xe7, 0xbd, 0xd9, 0xe1, 0xec, 0x9b, 0x99, 0x1d, 0x20,
+	0xb6, 0xc3, 0x5c, 0xff, 0x55, 0xc4, 0xec, 0x31, 0x46, 0xdd, 0x30, 0x0a, 0x44, 0x40, 0x6e, 0x65,
+	0xb1, 0x56, 0x7b, 0x14, 0x04, 0x23, 0x0f, 0xf7, 0x14, 0x77, 0x16, 0x0f, 0xf7, 0xde, 0x44, 0x2c,
+	0x0c, 0x31, 0xe2, 0x89, 0x77, 0xeb, 0x7e, 0x91, 0xc7, 0x49, 0x28, 0xa6, 0x09, 0x49, 0xff, 0x32,
+	0xa0, 0xf1, 0x82, 0x09, 0xe4, 0xa2, 0xe7, 0x05, 0xf6, 0xf8, 0x19, 0x13, 0x8c, 0xec, 0xc0, 0xc6,
+	0x30, 0x0a, 0x26, 0x0a, 0x30, 0x8d, 0x5d, 0xa3, 0x53, 0xb1, 0xe6, 0x00, 0x31, 0xa1, 0x2e, 0x82,
+	0x84, 0x2b, 0x2b, 0x2e, 0x35, 0xc9, 0xc7, 0x70, 0x9b, 0x87, 0x68, 0xbb, 0x43, 0xd7, 0x4e, 0xf8,
+	0x8a, 0xe2, 0xf3, 0xa0, 0xf4, 0x1a, 0xba, 0x3e, 0xf3, 0xdc, 0x5f, 0x70, 0xf0, 0xd2, 0xf7, 0xa6,
+	0x66, 0x75, 0xd7, 0xe8, 0xac, 0x5b, 0x79, 0x90, 0xfe, 0x0a, 0xf7, 0x0a, 0x69, 0x59, 0xc8, 0xc3,
+	0xc0, 0xe7, 0x48, 0x76, 0x61, 0xd3, 0x9b, 0x53, 0x3a, 0xc1, 0x2c, 0x44, 0x7a, 0xd0, 0x88, 0xf0,
+	0xa7, 0x18, 0xb9, 0xc0, 0xc1, 0x31, 0xe3, 0x0e, 0x72, 0xb3, 0xbc, 0x5b, 0xe9, 0x6c, 0x1e, 0x98,
+	0xdd, 0x9c, 0x9a, 0xca, 0xfb, 0x54, 0x04, 0x11, 0x5a, 0xc5, 0x03, 0xf4, 0x09, 0xc0, 0x9c, 0x26,
+	0x4d, 0x58, 0x3b, 0xcb, 0xfc, 0x2d, 0x31, 0x08, 0x81, 0xaa, 0xc3, 0xb8, 0xa3, 0x74, 0xd8, 0xb0,
+	0xd4, 0x37, 0xfd, 0x14, 0xb6, 0x2d, 0x0c, 0xa2, 0xd1, 0xb1, 0xcb, 0x45, 0x10, 0x4d, 0xad, 0x24,
+	0xac, 0x0c, 0xe0, 0xb9, 0x13, 0x57, 0xa8, 0x00, 0x55, 0x2b, 0x31, 0xe8, 0x31, 0x34, 0xf3, 0xce,
+	0xfa, 0x8a, 0xfb, 0x50, 0x8b, 0x24, 0xce, 0x4d, 0x63, 0x59, 0xde, 0xea, 0xcc, 0xd1, 0x39, 0xfa,
+	0xc2, 0xd2, 0x7e, 0xf4, 0x4f, 0x03, 0x60, 0x0e, 0x93, 0xbb, 0x50, 0x73, 0xd0, 0x1d, 0x39, 0x42,
+	0x27, 0xac, 0x2d, 0x59, 0xbc, 0xc0, 0x1b, 0x1c, 0xcf, 0x93, 0x4e, 0x4d, 0xc9, 0xf8, 0xf8, 0x46,
+	0x31, 0x95, 0x84, 0xd1, 0xa6, 0x4c, 0x7d, 0x80, 0xa1, 0x70, 0x54, 0xa1, 0x2a, 0x56, 0x62, 0xc8,
+	0x32, 0x0e, 0x50, 0xa0, 0x2d, 0xdc, 0xc0, 0x7f, 0xe5, 0x4e, 0xd0, 0x5c, 0x4b, 0x8a, 0x9d, 0x03,
+	0xe9, 0x8f, 0x60, 0x7e, 0x79, 0x8e, 0x11, 0x1b, 0xa1, 0x12, 0x53, 0x62, 0xb3, 0x4b, 0x3e, 0x82,
+	0x0f, 0x58, 0x81, 0xd3, 0xd9, 0x2e, 0xe0, 0x32, 0x3b, 0xce, 0x26, 0xa1, 0xa7, 0x2a, 0x29, 0x05,
+	0x4c, 0x4d, 0xfa, 0x47, 0x19, 0x9a, 0x4f, 0x33, 0xe2, 0x9c, 0xfa, 0x2c, 0xe4, 0x4e, 0x20, 0xae,
+	0xd1, 0x26, 0xfb, 0x50, 0x53, 0x75, 0x7c, 0x7f, 0x77, 0x68, 0x3f, 0xd2, 0x81, 0x86, 0xfa, 0x7a,
+	0xea, 0xa0, 0x3d, 0x0e, 0x03, 0xd7, 0x17, 0x4a, 0xac, 0xaa, 0x55, 0x84, 0x65, 0xc2, 0x2a, 0xd8,
+	0xc9, 0x33, 0x25, 0xdb, 0x86, 0x95, 0x9a, 0x84, 0xc2, 0x2d, 0x16, 0xba, 0x27, 0xbe, 0xc0, 0x68,
+	0xc8, 0xec, 0x44, 0xb7, 0x0d, 0x2b, 0x87, 0x91, 0x16, 0xac, 0xcb, 0x66, 0xf2, 0x90, 0x73, 0xb3,
+	0xa6, 0xc6, 0x63, 0x66, 0xcb, 0xf3, 0x5c, 0xdf, 0x51, 0x49, 0x56, 0x57, 0x17, 0xcb, 0x61, 0xf4,
+	0x1b, 0x68, 0x16, 0xa6, 0xa7, 0xc7, 0x84, 0xed, 0x90, 0x43, 0x58, 0xd7, 0x7d, 0x9e, 0x76, 0xd6,
+	0x83, 0xfc, 0x9d, 0x8b, 0x33, 0x37, 0x73, 0xa7, 0x53, 0xd8, 0x59, 0x16, 0xf2, 0x06, 0x53, 0xf9,
+	0x05, 0xd4, 0x23, 0xe4, 0xb1, 0x27, 0x52, 0xbd, 0x1f, 0x2c, 0xd1, 0x5b, 0x4f, 0x7a, 0xec, 0x09,
+	0x2b, 0xf5, 0xa6, 0x63, 0x68, 0x14, 0xb8, 0x65, 0x13, 0x6e, 0xdc, 0x70, 0xc2, 0x65, 0x5f, 0x63,
+	0x14, 0x05, 0x91, 0x9e, 0x84, 0xc4, 0xa0, 0xff, 0x1b, 0xd0, 0xfc, 0x0a, 0x85, 0xed, 0x60, 0x74,
+	0x2a, 0x98, 0xe0, 0xb3, 0x0b, 0x66, 0x2a, 0x6a, 0xac, 0xae, 0x68, 0x79, 0x49, 0x45, 0xfb, 0xb0,
+	0x95, 0xd1, 0xe2, 0xeb, 0x78, 0xa2, 0x1a, 0x67, 0xf3, 0xe0, 0xc3, 0x7c, 0xbe, 0xea, 0xcf, 0x52,
+	0x67, 0xdf, 0x9e, 0x26, 0xbf, 0x2f, 0x1c, 0x93, 0x81, 0x54, 0xaf, 0xc9, 0x4b, 0xf4, 0xa6, 0x32,
+	0x50, 0xf5, 0x9a, 0x81, 0xf2, 0xc7, 0xe8, 0xdf, 0x65, 0xb8, 0xb3, 0xe0, 0x25, 0x45, 0xb1, 0x99,
+	0xe7, 0xf1, 0xf4, 0x9d, 0x52, 0x86, 0x7c, 0x4e, 0x94, 0x3a, 0xe9, 0xf4, 0x69, 0x8b, 0x3c, 0x84,
+	0x2d, 0x3d, 0xaa, 0x3a, 0x88, 0x7e, 0xf2, 0x0b, 0x28, 0x69, 0x03, 0x4c, 0xd8, 0xcf, 0xa9, 0x4f,
+	0xf2, 0x8e, 0x64, 0x10, 0xc9, 0x87, 0x8f, 0xf7, 0x53, 0x3e, 0x79, 0x49, 0x32, 0x88, 0xe2, 0x0f,
+	0x67, 0x7c, 0x4d, 0xf3, 0x87, 0x79, 0xfe, 0x30, 0xe5, 0xeb, 0x29, 0x9f, 0x22, 0xe4, 0x31, 0xd4,
+	0xcf, 0x62, 0x7b, 0x8c, 0x82, 0x9b, 0xeb, 0xaa, 0x4d, 0xee, 0x2f, 0xb6, 0xbd, 0x6f, 0x4f, 0x7b,
+	0xca, 0xc7, 0x4a, 0x7d, 0xe9, 0x11, 0xdc, 0xce, 0x31, 0xf2, 0x3f, 0xb1, 0x5c, 0xad, 0xbd, 0x20,
+	0xf6, 0x07, 0xba, 0xc7, 0x33, 0x88, 0x52, 0x2f, 0x88, 0x7d, 0xa1, 0x65, 0x4a, 0x8c, 0x83, 0xdf,
+	0xd6, 0x60, 0x3b, 0xf7, 0x44, 0x61, 0x74, 0xee, 0xda, 0x48, 0x9e, 0xc3, 0x9d, 0x3e, 0x8a, 0x17,
+	0xf9, 0xfa, 0xde, 0xed, 0x26, 0xeb, 0xba, 0x9b, 0xae, 0xeb, 0xee, 0x91, 0x5c, 0xd7, 0xad, 0x9d,
+	0x05, 0xfc, 0xdb, 0x13, 0x5f, 0x3c, 0xf9, 0xfc, 0x3b, 0xe6, 0xc5, 0x48, 0x4b, 0xe4, 0x07, 0x20,
+	0xf9, 0x60, 0x6a, 0x95, 0xaf, 0x1e, 0xef, 0xd6, 0x27, 0x2b, 0xe9, 0xb4, 0xf5, 0x69, 0x89, 0xbc,
+	0x86, 0x46, 0x1f, 0x45, 0x76, 0x57, 0x91, 0x8f, 0x96, 0xec, 0xa4, 0xfc, 0xd2, 0x6b, 0xd1, 0x55,
+	0x2e, 0xb3, 0xd8, 0xdf, 0xc3, 0x76, 0x1f, 0x45, 0x71, 0x4d, 0x5c, 0x29, 0xc4, 0xc3, 0x7c, 0xd0,
+	0xab, 0xd6, 0x0b, 0x2d, 0x91, 0xe7, 0xb0, 0xd9, 0x47, 0x31, 0x5b, 0x08, 0x57, 0x05, 0x2c, 0x64,
+	0xb9, 0x6c, 0x99, 0xd0, 0x12, 0x19, 0xc3, 0xbd, 0x45, 0x7d, 0x93, 0x57, 0x95, 0xae, 0x54, 0x51,
+	0xf9, 0xb4, 0x1e, 0xbd, 0xdf, 0x27, 0x93, 0xf9, 0x4b, 0x25, 0x77, 0xf6, 0x19, 0xba, 0x6e, 0xf6,
+	0xcb, 0x9e, 0x2e, 0x5a, 0xea, 0x75, 0xfe, 0xb9, 0x68, 0x1b, 0x6f, 0x2f, 0xda, 0xc6, 0x7f, 0x17,
+	0x6d, 0xe3, 0xf7, 0xcb, 0x76, 0xe9, 0xed, 0x65, 0xbb, 0xf4, 0xef, 0x65, 0xbb, 0xf4, 0x7a, 0xab,
+	0xbb, 0xa7, 0x02, 0x88, 0x24, 0xc0, 0x59, 0x4d, 0xc5, 0xff, 0xec, 0xdd, 0x00, 0xaa, 0x01, 0x6b,
+	0xf7, 0x78, 0x0a, 0x00, 0x00,
+}
+
+func (m *LatestBlockData) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LatestBlockData) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LatestBlockData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.FinalizedOnly {
+		i--
+		if m.FinalizedOnly {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.SpecificBlock != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.SpecificBlock))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.ToBlock != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.ToBlock))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.FromBlock != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.FromBlock))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LatestBlockDataResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LatestBlockDataResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LatestBlockDataResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.RequestedHashes) > 0 {
+		for iNdEx := len(m.RequestedHashes) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.RequestedHashes[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.LatestBlock != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.LatestBlock))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BlockStore) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlockStore) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BlockStore) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Hash) > 0 {
+		i -= len(m.Hash)
+		copy(dAtA[i:], m.Hash)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.Hash)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Block != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Block))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReorgHistoryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReorgHistoryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReorgHistoryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReorgHistoryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReorgHistoryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReorgHistoryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Reorgs) > 0 {
+		for iNdEx := len(m.Reorgs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Reorgs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReorgEvent) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReorgEvent) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReorgEvent) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.DetectionTime != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.DetectionTime))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Depth != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Depth))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.NewHash) > 0 {
+		i -= len(m.NewHash)
+		copy(dAtA[i:], m.NewHash)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.NewHash)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.OldHash) > 0 {
+		i -= len(m.OldHash)
+		copy(dAtA[i:], m.OldHash)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.OldHash)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Height != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Height))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AverageBlockTimeResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AverageBlockTimeResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AverageBlockTimeResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Samples != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Samples))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.AverageBlockTime != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.AverageBlockTime))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ChainTrackerSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChainTrackerSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ChainTrackerSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.SnapshotTime != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.SnapshotTime))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Hashless {
+		i--
+		if m.Hashless {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.ApiInterface) > 0 {
+		i -= len(m.ApiInterface)
+		copy(dAtA[i:], m.ApiInterface)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.ApiInterface)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.ChainID) > 0 {
+		i -= len(m.ChainID)
+		copy(dAtA[i:], m.ChainID)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.ChainID)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.BlockCheckpoint != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.BlockCheckpoint))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Blocks) > 0 {
+		for iNdEx := len(m.Blocks) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Blocks[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.LatestBlock != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.LatestBlock))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LatestBlockDataBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LatestBlockDataBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LatestBlockDataBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for iNdEx := len(m.Requests) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Requests[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LatestBlockDataBatchResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LatestBlockDataBatchResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LatestBlockDataBatchResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Results) > 0 {
+		for iNdEx := len(m.Results) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Results[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.LatestBlock != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.LatestBlock))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BlockDataResult) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlockDataResult) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BlockDataResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.RequestedHashes) > 0 {
+		for iNdEx := len(m.RequestedHashes) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.RequestedHashes[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FetcherStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FetcherStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FetcherStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.BlockHashByNum != nil {
+		{
+			size, err := m.BlockHashByNum.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintChainTracker(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.LatestBlockNum != nil {
+		{
+			size, err := m.LatestBlockNum.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintChainTracker(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.ApiInterface) > 0 {
+		i -= len(m.ApiInterface)
+		copy(dAtA[i:], m.ApiInterface)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.ApiInterface)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.ChainID) > 0 {
+		i -= len(m.ChainID)
+		copy(dAtA[i:], m.ChainID)
+		i = encodeVarintChainTracker(dAtA, i, uint64(len(m.ChainID)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FetchLatencyStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FetchLatencyStats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FetchLatencyStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Buckets) > 0 {
+		for iNdEx := len(m.Buckets) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Buckets[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintChainTracker(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if m.P99Latency != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.P99Latency))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.P90Latency != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.P90Latency))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.P50Latency != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.P50Latency))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.MaxLatency != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.MaxLatency))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.AverageLatency != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.AverageLatency))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Errors != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Errors))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Calls != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Calls))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LatencyBucket) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LatencyBucket) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LatencyBucket) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Count != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.UpperBound != 0 {
+		i = encodeVarintChainTracker(dAtA, i, uint64(m.UpperBound))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintChainTracker(dAtA []byte, offset int, v uint64) int {
+	offset -= sovChainTracker(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *LatestBlockData) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FromBlock != 0 {
+		n += 1 + sovChainTracker(uint64(m.FromBlock))
+	}
+	if m.ToBlock != 0 {
+		n += 1 + sovChainTracker(uint64(m.ToBlock))
+	}
+	if m.SpecificBlock != 0 {
+		n += 1 + sovChainTracker(uint64(m.SpecificBlock))
+	}
+	if m.FinalizedOnly {
+		n += 2
+	}
+	return n
 }
 
 func (m *LatestBlockDataResponse) Size() (n int) {
 	if m == nil {
 		return 0
 	}
-	var l int
-	_ = l
-	if m.LatestBlock != 0 {
-		n += 1 + sovChainTracker(uint64(m.LatestBlock))
+	var l int
+	_ = l
+	if m.LatestBlock != 0 {
+		n += 1 + sovChainTracker(uint64(m.LatestBlock))
+	}
+	if len(m.RequestedHashes) > 0 {
+		for _, e := range m.RequestedHashes {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *BlockStore) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Block != 0 {
+		n += 1 + sovChainTracker(uint64(m.Block))
+	}
+	l = len(m.Hash)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	return n
+}
+
+func (m *ReorgHistoryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		n += 1 + sovChainTracker(uint64(m.Limit))
+	}
+	return n
+}
+
+func (m *ReorgHistoryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Reorgs) > 0 {
+		for _, e := range m.Reorgs {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ReorgEvent) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Height != 0 {
+		n += 1 + sovChainTracker(uint64(m.Height))
+	}
+	l = len(m.OldHash)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	l = len(m.NewHash)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	if m.Depth != 0 {
+		n += 1 + sovChainTracker(uint64(m.Depth))
+	}
+	if m.DetectionTime != 0 {
+		n += 1 + sovChainTracker(uint64(m.DetectionTime))
+	}
+	return n
+}
+
+func (m *AverageBlockTimeResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.AverageBlockTime != 0 {
+		n += 1 + sovChainTracker(uint64(m.AverageBlockTime))
+	}
+	if m.Samples != 0 {
+		n += 1 + sovChainTracker(uint64(m.Samples))
+	}
+	return n
+}
+
+func (m *ChainTrackerSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LatestBlock != 0 {
+		n += 1 + sovChainTracker(uint64(m.LatestBlock))
+	}
+	if len(m.Blocks) > 0 {
+		for _, e := range m.Blocks {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	if m.BlockCheckpoint != 0 {
+		n += 1 + sovChainTracker(uint64(m.BlockCheckpoint))
+	}
+	l = len(m.ChainID)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	l = len(m.ApiInterface)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	if m.Hashless {
+		n += 2
+	}
+	if m.SnapshotTime != 0 {
+		n += 1 + sovChainTracker(uint64(m.SnapshotTime))
+	}
+	return n
+}
+
+func (m *LatestBlockDataBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for _, e := range m.Requests {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LatestBlockDataBatchResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LatestBlock != 0 {
+		n += 1 + sovChainTracker(uint64(m.LatestBlock))
+	}
+	if len(m.Results) > 0 {
+		for _, e := range m.Results {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *BlockDataResult) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.RequestedHashes) > 0 {
+		for _, e := range m.RequestedHashes {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	return n
+}
+
+func (m *FetcherStatsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ChainID)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	l = len(m.ApiInterface)
+	if l > 0 {
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	if m.LatestBlockNum != nil {
+		l = m.LatestBlockNum.Size()
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	if m.BlockHashByNum != nil {
+		l = m.BlockHashByNum.Size()
+		n += 1 + l + sovChainTracker(uint64(l))
+	}
+	return n
+}
+
+func (m *FetchLatencyStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Calls != 0 {
+		n += 1 + sovChainTracker(uint64(m.Calls))
+	}
+	if m.Errors != 0 {
+		n += 1 + sovChainTracker(uint64(m.Errors))
+	}
+	if m.AverageLatency != 0 {
+		n += 1 + sovChainTracker(uint64(m.AverageLatency))
+	}
+	if m.MaxLatency != 0 {
+		n += 1 + sovChainTracker(uint64(m.MaxLatency))
+	}
+	if m.P50Latency != 0 {
+		n += 1 + sovChainTracker(uint64(m.P50Latency))
+	}
+	if m.P90Latency != 0 {
+		n += 1 + sovChainTracker(uint64(m.P90Latency))
+	}
+	if m.P99Latency != 0 {
+		n += 1 + sovChainTracker(uint64(m.P99Latency))
+	}
+	if len(m.Buckets) > 0 {
+		for _, e := range m.Buckets {
+			l = e.Size()
+			n += 1 + l + sovChainTracker(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LatencyBucket) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.UpperBound != 0 {
+		n += 1 + sovChainTracker(uint64(m.UpperBound))
+	}
+	if m.Count != 0 {
+		n += 1 + sovChainTracker(uint64(m.Count))
+	}
+	return n
+}
+
+func sovChainTracker(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozChainTracker(x uint64) (n int) {
+	return sovChainTracker(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LatestBlockData: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LatestBlockData: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromBlock", wireType)
+			}
+			m.FromBlock = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FromBlock |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ToBlock", wireType)
+			}
+			m.ToBlock = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ToBlock |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpecificBlock", wireType)
+			}
+			m.SpecificBlock = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SpecificBlock |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FinalizedOnly", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.FinalizedOnly = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LatestBlockDataResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LatestBlockDataResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LatestBlockDataResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LatestBlock", wireType)
+			}
+			m.LatestBlock = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LatestBlock |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RequestedHashes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RequestedHashes = append(m.RequestedHashes, &BlockStore{})
+			if err := m.RequestedHashes[len(m.RequestedHashes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlockStore) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlockStore: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlockStore: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Block", wireType)
+			}
+			m.Block = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Block |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Hash = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReorgHistoryRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReorgHistoryRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReorgHistoryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReorgHistoryResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReorgHistoryResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReorgHistoryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reorgs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Reorgs = append(m.Reorgs, &ReorgEvent{})
+			if err := m.Reorgs[len(m.Reorgs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReorgEvent) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReorgEvent: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReorgEvent: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Height", wireType)
+			}
+			m.Height = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Height |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OldHash", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OldHash = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewHash", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.NewHash = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Depth", wireType)
+			}
+			m.Depth = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Depth |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DetectionTime", wireType)
+			}
+			m.DetectionTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DetectionTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AverageBlockTimeResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AverageBlockTimeResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AverageBlockTimeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AverageBlockTime", wireType)
+			}
+			m.AverageBlockTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AverageBlockTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Samples", wireType)
+			}
+			m.Samples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Samples |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ChainTrackerSnapshot) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ChainTrackerSnapshot: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ChainTrackerSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LatestBlock", wireType)
+			}
+			m.LatestBlock = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LatestBlock |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Blocks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Blocks = append(m.Blocks, &BlockStore{})
+			if err := m.Blocks[len(m.Blocks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockCheckpoint", wireType)
+			}
+			m.BlockCheckpoint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlockCheckpoint |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChainID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ChainID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApiInterface", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ApiInterface = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hashless", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Hashless = bool(v != 0)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotTime", wireType)
+			}
+			m.SnapshotTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SnapshotTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LatestBlockDataBatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LatestBlockDataBatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LatestBlockDataBatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Requests", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Requests = append(m.Requests, &LatestBlockData{})
+			if err := m.Requests[len(m.Requests)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	if len(m.RequestedHashes) > 0 {
-		for _, e := range m.RequestedHashes {
-			l = e.Size()
-			n += 1 + l + sovChainTracker(uint64(l))
+	return nil
+}
+func (m *LatestBlockDataBatchResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LatestBlockDataBatchResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LatestBlockDataBatchResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LatestBlock", wireType)
+			}
+			m.LatestBlock = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LatestBlock |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Results", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Results = append(m.Results, &BlockDataResult{})
+			if err := m.Results[len(m.Results)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlockDataResult) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowChainTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlockDataResult: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlockDataResult: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RequestedHashes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RequestedHashes = append(m.RequestedHashes, &BlockStore{})
+			if err := m.RequestedHashes[len(m.RequestedHashes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipChainTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
 
-func (m *BlockStore) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Block != 0 {
-		n += 1 + sovChainTracker(uint64(m.Block))
-	}
-	l = len(m.Hash)
-	if l > 0 {
-		n += 1 + l + sovChainTracker(uint64(l))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func sovChainTracker(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozChainTracker(x uint64) (n int) {
-	return sovChainTracker(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
+func (m *FetcherStatsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -430,17 +3194,17 @@ func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LatestBlockData: wiretype end group for non-group")
+			return fmt.Errorf("proto: FetcherStatsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LatestBlockData: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FetcherStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FromBlock", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChainID", wireType)
 			}
-			m.FromBlock = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowChainTracker
@@ -450,16 +3214,29 @@ func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FromBlock |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ChainID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ToBlock", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ApiInterface", wireType)
 			}
-			m.ToBlock = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowChainTracker
@@ -469,16 +3246,29 @@ func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ToBlock |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ApiInterface = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SpecificBlock", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LatestBlockNum", wireType)
 			}
-			m.SpecificBlock = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowChainTracker
@@ -488,11 +3278,64 @@ func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.SpecificBlock |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.LatestBlockNum == nil {
+				m.LatestBlockNum = &FetchLatencyStats{}
+			}
+			if err := m.LatestBlockNum.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockHashByNum", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthChainTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.BlockHashByNum == nil {
+				m.BlockHashByNum = &FetchLatencyStats{}
+			}
+			if err := m.BlockHashByNum.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipChainTracker(dAtA[iNdEx:])
@@ -514,7 +3357,7 @@ func (m *LatestBlockData) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LatestBlockDataResponse) Unmarshal(dAtA []byte) error {
+func (m *FetchLatencyStats) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -537,17 +3380,17 @@ func (m *LatestBlockDataResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LatestBlockDataResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: FetchLatencyStats: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LatestBlockDataResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FetchLatencyStats: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LatestBlock", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Calls", wireType)
 			}
-			m.LatestBlock = 0
+			m.Calls = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowChainTracker
@@ -557,14 +3400,128 @@ func (m *LatestBlockDataResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.LatestBlock |= int64(b&0x7F) << shift
+				m.Calls |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Errors", wireType)
+			}
+			m.Errors = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Errors |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AverageLatency", wireType)
+			}
+			m.AverageLatency = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AverageLatency |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxLatency", wireType)
+			}
+			m.MaxLatency = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxLatency |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field P50Latency", wireType)
+			}
+			m.P50Latency = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.P50Latency |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field P90Latency", wireType)
+			}
+			m.P90Latency = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.P90Latency |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field P99Latency", wireType)
+			}
+			m.P99Latency = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowChainTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.P99Latency |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RequestedHashes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Buckets", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -591,8 +3548,8 @@ func (m *LatestBlockDataResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.RequestedHashes = append(m.RequestedHashes, &BlockStore{})
-			if err := m.RequestedHashes[len(m.RequestedHashes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Buckets = append(m.Buckets, &LatencyBucket{})
+			if err := m.Buckets[len(m.Buckets)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -617,7 +3574,7 @@ func (m *LatestBlockDataResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *BlockStore) Unmarshal(dAtA []byte) error {
+func (m *LatencyBucket) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -640,17 +3597,17 @@ func (m *BlockStore) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: BlockStore: wiretype end group for non-group")
+			return fmt.Errorf("proto: LatencyBucket: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: BlockStore: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LatencyBucket: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Block", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field UpperBound", wireType)
 			}
-			m.Block = 0
+			m.UpperBound = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowChainTracker
@@ -660,16 +3617,16 @@ func (m *BlockStore) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Block |= int64(b&0x7F) << shift
+				m.UpperBound |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
 			}
-			var stringLen uint64
+			m.Count = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowChainTracker
@@ -679,24 +3636,11 @@ func (m *BlockStore) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Count |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthChainTracker
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthChainTracker
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Hash = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipChainTracker(dAtA[iNdEx:])
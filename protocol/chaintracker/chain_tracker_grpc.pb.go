@@ -26,6 +26,11 @@ const _ = grpc.SupportPackageIsVersion7
 type ChainTrackerServiceClient interface {
 	GetLatestBlockNum(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*wrappers.UInt64Value, error)
 	GetLatestBlockData(ctx context.Context, in *LatestBlockData, opts ...grpc.CallOption) (*LatestBlockDataResponse, error)
+	GetReorgHistory(ctx context.Context, in *ReorgHistoryRequest, opts ...grpc.CallOption) (*ReorgHistoryResponse, error)
+	GetAverageBlockTime(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*AverageBlockTimeResponse, error)
+	GetSnapshot(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ChainTrackerSnapshot, error)
+	GetLatestBlockDataBatch(ctx context.Context, in *LatestBlockDataBatch, opts ...grpc.CallOption) (*LatestBlockDataBatchResponse, error)
+	GetFetcherStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*FetcherStatsResponse, error)
 }
 
 type chainTrackerServiceClient struct {
@@ -54,12 +59,62 @@ func (c *chainTrackerServiceClient) GetLatestBlockData(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *chainTrackerServiceClient) GetReorgHistory(ctx context.Context, in *ReorgHistoryRequest, opts ...grpc.CallOption) (*ReorgHistoryResponse, error) {
+	out := new(ReorgHistoryResponse)
+	err := c.cc.Invoke(ctx, "/chainTracker.ChainTrackerService/GetReorgHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chainTrackerServiceClient) GetAverageBlockTime(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*AverageBlockTimeResponse, error) {
+	out := new(AverageBlockTimeResponse)
+	err := c.cc.Invoke(ctx, "/chainTracker.ChainTrackerService/GetAverageBlockTime", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chainTrackerServiceClient) GetSnapshot(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ChainTrackerSnapshot, error) {
+	out := new(ChainTrackerSnapshot)
+	err := c.cc.Invoke(ctx, "/chainTracker.ChainTrackerService/GetSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chainTrackerServiceClient) GetLatestBlockDataBatch(ctx context.Context, in *LatestBlockDataBatch, opts ...grpc.CallOption) (*LatestBlockDataBatchResponse, error) {
+	out := new(LatestBlockDataBatchResponse)
+	err := c.cc.Invoke(ctx, "/chainTracker.ChainTrackerService/GetLatestBlockDataBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chainTrackerServiceClient) GetFetcherStats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*FetcherStatsResponse, error) {
+	out := new(FetcherStatsResponse)
+	err := c.cc.Invoke(ctx, "/chainTracker.ChainTrackerService/GetFetcherStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChainTrackerServiceServer is the server API for ChainTrackerService service.
 // All implementations must embed UnimplementedChainTrackerServiceServer
 // for forward compatibility
 type ChainTrackerServiceServer interface {
 	GetLatestBlockNum(context.Context, *empty.Empty) (*wrappers.UInt64Value, error)
 	GetLatestBlockData(context.Context, *LatestBlockData) (*LatestBlockDataResponse, error)
+	GetReorgHistory(context.Context, *ReorgHistoryRequest) (*ReorgHistoryResponse, error)
+	GetAverageBlockTime(context.Context, *empty.Empty) (*AverageBlockTimeResponse, error)
+	GetSnapshot(context.Context, *empty.Empty) (*ChainTrackerSnapshot, error)
+	GetLatestBlockDataBatch(context.Context, *LatestBlockDataBatch) (*LatestBlockDataBatchResponse, error)
+	GetFetcherStats(context.Context, *empty.Empty) (*FetcherStatsResponse, error)
 	mustEmbedUnimplementedChainTrackerServiceServer()
 }
 
@@ -73,6 +128,21 @@ func (UnimplementedChainTrackerServiceServer) GetLatestBlockNum(context.Context,
 func (UnimplementedChainTrackerServiceServer) GetLatestBlockData(context.Context, *LatestBlockData) (*LatestBlockDataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetLatestBlockData not implemented")
 }
+func (UnimplementedChainTrackerServiceServer) GetReorgHistory(context.Context, *ReorgHistoryRequest) (*ReorgHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReorgHistory not implemented")
+}
+func (UnimplementedChainTrackerServiceServer) GetAverageBlockTime(context.Context, *empty.Empty) (*AverageBlockTimeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAverageBlockTime not implemented")
+}
+func (UnimplementedChainTrackerServiceServer) GetSnapshot(context.Context, *empty.Empty) (*ChainTrackerSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshot not implemented")
+}
+func (UnimplementedChainTrackerServiceServer) GetLatestBlockDataBatch(context.Context, *LatestBlockDataBatch) (*LatestBlockDataBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatestBlockDataBatch not implemented")
+}
+func (UnimplementedChainTrackerServiceServer) GetFetcherStats(context.Context, *empty.Empty) (*FetcherStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFetcherStats not implemented")
+}
 func (UnimplementedChainTrackerServiceServer) mustEmbedUnimplementedChainTrackerServiceServer() {}
 
 // UnsafeChainTrackerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -122,6 +192,96 @@ func _ChainTrackerService_GetLatestBlockData_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChainTrackerService_GetReorgHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReorgHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainTrackerServiceServer).GetReorgHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chainTracker.ChainTrackerService/GetReorgHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainTrackerServiceServer).GetReorgHistory(ctx, req.(*ReorgHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChainTrackerService_GetAverageBlockTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainTrackerServiceServer).GetAverageBlockTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chainTracker.ChainTrackerService/GetAverageBlockTime",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainTrackerServiceServer).GetAverageBlockTime(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChainTrackerService_GetSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainTrackerServiceServer).GetSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chainTracker.ChainTrackerService/GetSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainTrackerServiceServer).GetSnapshot(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChainTrackerService_GetLatestBlockDataBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatestBlockDataBatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainTrackerServiceServer).GetLatestBlockDataBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chainTracker.ChainTrackerService/GetLatestBlockDataBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainTrackerServiceServer).GetLatestBlockDataBatch(ctx, req.(*LatestBlockDataBatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChainTrackerService_GetFetcherStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainTrackerServiceServer).GetFetcherStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chainTracker.ChainTrackerService/GetFetcherStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainTrackerServiceServer).GetFetcherStats(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChainTrackerService_ServiceDesc is the grpc.ServiceDesc for ChainTrackerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -137,6 +297,26 @@ var ChainTrackerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetLatestBlockData",
 			Handler:    _ChainTrackerService_GetLatestBlockData_Handler,
 		},
+		{
+			MethodName: "GetReorgHistory",
+			Handler:    _ChainTrackerService_GetReorgHistory_Handler,
+		},
+		{
+			MethodName: "GetAverageBlockTime",
+			Handler:    _ChainTrackerService_GetAverageBlockTime_Handler,
+		},
+		{
+			MethodName: "GetSnapshot",
+			Handler:    _ChainTrackerService_GetSnapshot_Handler,
+		},
+		{
+			MethodName: "GetLatestBlockDataBatch",
+			Handler:    _ChainTrackerService_GetLatestBlockDataBatch_Handler,
+		},
+		{
+			MethodName: "GetFetcherStats",
+			Handler:    _ChainTrackerService_GetFetcherStats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "chainTracker.proto",
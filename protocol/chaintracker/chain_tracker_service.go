@@ -21,7 +21,7 @@ func (cts *ChainTrackerService) GetLatestBlockNum(context.Context, *empty.Empty)
 }
 
 func (cts *ChainTrackerService) GetLatestBlockData(ctx context.Context, latestBlockData *LatestBlockData) (*LatestBlockDataResponse, error) {
-	latestBlockNum, requestedHashes, err := cts.ChainTracker.GetLatestBlockData(latestBlockData.FromBlock, latestBlockData.ToBlock, latestBlockData.SpecificBlock)
+	latestBlockNum, requestedHashes, err := cts.ChainTracker.GetLatestBlockData(latestBlockData.FromBlock, latestBlockData.ToBlock, latestBlockData.SpecificBlock, latestBlockData.FinalizedOnly)
 	if err != nil {
 		return nil, err
 	}
@@ -33,3 +33,36 @@ func (cts *ChainTrackerService) GetLatestBlockData(ctx context.Context, latestBl
 	}
 	return &LatestBlockDataResponse{LatestBlock: latestBlockNum, RequestedHashes: requestedHashes}, nil
 }
+
+func (cts *ChainTrackerService) GetReorgHistory(ctx context.Context, request *ReorgHistoryRequest) (*ReorgHistoryResponse, error) {
+	return &ReorgHistoryResponse{Reorgs: cts.ChainTracker.GetReorgHistory(request.GetLimit())}, nil
+}
+
+func (cts *ChainTrackerService) GetAverageBlockTime(context.Context, *empty.Empty) (*AverageBlockTimeResponse, error) {
+	averageBlockTime, samples := cts.ChainTracker.GetAverageBlockTime()
+	return &AverageBlockTimeResponse{AverageBlockTime: averageBlockTime.Milliseconds(), Samples: samples}, nil
+}
+
+func (cts *ChainTrackerService) GetFetcherStats(context.Context, *empty.Empty) (*FetcherStatsResponse, error) {
+	return cts.ChainTracker.GetFetcherStats(), nil
+}
+
+func (cts *ChainTrackerService) GetSnapshot(context.Context, *empty.Empty) (*ChainTrackerSnapshot, error) {
+	return cts.ChainTracker.Snapshot(), nil
+}
+
+func (cts *ChainTrackerService) GetLatestBlockDataBatch(ctx context.Context, batch *LatestBlockDataBatch) (*LatestBlockDataBatchResponse, error) {
+	latestBlockNum, results, err := cts.ChainTracker.GetLatestBlockDataBatch(batch.GetRequests())
+	if err != nil {
+		return nil, err
+	}
+	if latestBlockNum <= 0 {
+		return nil, InvalidLatestBlockNumValue
+	}
+	for _, result := range results {
+		if result.Error == "" && len(result.RequestedHashes) == 0 {
+			result.Error = InvalidReturnedHashes.Error()
+		}
+	}
+	return &LatestBlockDataBatchResponse{LatestBlock: latestBlockNum, Results: results}, nil
+}
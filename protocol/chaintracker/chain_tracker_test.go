@@ -3,6 +3,7 @@ package chaintracker_test
 import (
 	"context"
 	fmt "fmt"
+	"net"
 	"strconv"
 	"sync"
 	"testing"
@@ -27,6 +28,7 @@ type MockChainFetcher struct {
 	blockHashes []*chaintracker.BlockStore
 	mutex       sync.Mutex
 	fork        string
+	failing     bool
 }
 
 func (mcf *MockChainFetcher) FetchEndpoint() lavasession.RPCProviderEndpoint {
@@ -36,9 +38,18 @@ func (mcf *MockChainFetcher) FetchEndpoint() lavasession.RPCProviderEndpoint {
 func (mcf *MockChainFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
 	mcf.mutex.Lock()
 	defer mcf.mutex.Unlock()
+	if mcf.failing {
+		return 0, fmt.Errorf("mock chain fetcher is failing")
+	}
 	return mcf.latestBlock, nil
 }
 
+func (mcf *MockChainFetcher) SetFailing(failing bool) {
+	mcf.mutex.Lock()
+	defer mcf.mutex.Unlock()
+	mcf.failing = failing
+}
+
 func (mcf *MockChainFetcher) FetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
 	mcf.mutex.Lock()
 	defer mcf.mutex.Unlock()
@@ -143,7 +154,7 @@ func TestChainTracker(t *testing.T) {
 				latestBlock := chainTracker.GetLatestBlockNum()
 				require.Equal(t, currentLatestBlockInMock, latestBlock)
 
-				latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(tt.requestBlockFrom, tt.requestBlockTo, tt.specificBlock)
+				latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(tt.requestBlockFrom, tt.requestBlockTo, tt.specificBlock, false)
 				require.GreaterOrEqual(t, latestBlock, int64(0))
 				require.Equal(t, currentLatestBlockInMock, latestBlock)
 				require.NoError(t, err)
@@ -200,7 +211,7 @@ func TestChainTrackerRangeOnly(t *testing.T) {
 				latestBlock := chainTracker.GetLatestBlockNum()
 				require.Equal(t, currentLatestBlockInMock, latestBlock)
 
-				latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(tt.requestBlockFrom, tt.requestBlockTo, tt.specificBlock)
+				latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(tt.requestBlockFrom, tt.requestBlockTo, tt.specificBlock, false)
 				require.Equal(t, currentLatestBlockInMock, latestBlock)
 				require.NoError(t, err)
 				require.Equal(t, tt.requestBlocks, int64(len(requestedHashes)))
@@ -285,7 +296,7 @@ func TestChainTrackerCallbacks(t *testing.T) {
 			latestBlock := chainTracker.GetLatestBlockNum()
 			require.Equal(t, currentLatestBlockInMock, latestBlock)
 
-			latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(requestBlockFrom, requestBlockTo, specificBlock)
+			latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(requestBlockFrom, requestBlockTo, specificBlock, false)
 			require.Equal(t, currentLatestBlockInMock, latestBlock)
 			require.NoError(t, err)
 			require.Equal(t, requestBlocks, len(requestedHashes))
@@ -368,7 +379,7 @@ func TestChainTrackerMaintainMemory(t *testing.T) {
 			latestBlock := chainTracker.GetLatestBlockNum()
 			require.Equal(t, currentLatestBlockInMock, latestBlock)
 
-			latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(requestBlockFrom, requestBlockTo, specificBlock)
+			latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(requestBlockFrom, requestBlockTo, specificBlock, false)
 			require.Equal(t, currentLatestBlockInMock, latestBlock)
 			require.NoError(t, err)
 			require.Equal(t, requestBlocks, len(requestedHashes))
@@ -390,3 +401,80 @@ func TestChainTrackerMaintainMemory(t *testing.T) {
 		}
 	})
 }
+
+func TestChainTrackerConcurrentFetch(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := int64(10)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: uint64(fetcherBlocks), AverageBlockTime: TimeForPollingMock, ServerBlockMemory: uint64(mockBlocks), FetchConcurrency: 4}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+	// advancing by less than, exactly and more than the concurrency covers partial chunks, forks replace all saved hashes
+	for idx, advancement := range []int64{0, 1, 3, 4, 5, 0, 12, 1, 2} {
+		for i := 0; i < int(advancement); i++ {
+			currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+		}
+		if idx%3 == 2 {
+			mockChainFetcher.Fork("fork" + strconv.Itoa(idx))
+		}
+		require.Eventually(t, func() bool {
+			latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-fetcherBlocks+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+			if err != nil || latestBlock != currentLatestBlockInMock || int64(len(requestedHashes)) != fetcherBlocks {
+				return false
+			}
+			for _, blockStore := range requestedHashes {
+				if !mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block) {
+					return false
+				}
+			}
+			return true
+		}, time.Second, TimeForPollingMock)
+	}
+}
+
+func TestChainTrackerClose(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, chainTracker.GetLatestBlockNum())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, chainTracker.Close(ctx))
+	// closing twice is a no-op
+	require.NoError(t, chainTracker.Close(ctx))
+
+	// once closed the tracker stops polling
+	mockChainFetcher.AdvanceBlock()
+	time.Sleep(SleepTime * SleepChunks)
+	require.Equal(t, currentLatestBlockInMock, chainTracker.GetLatestBlockNum())
+}
+
+func TestChainTrackerServerShutdownReleasesListener(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address}
+	served := make(chan error)
+	go func() {
+		_, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+		served <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("chain tracker server did not shut down")
+	}
+	// the address can be served again
+	lis, err := net.Listen("tcp", address)
+	require.NoError(t, err)
+	lis.Close()
+}
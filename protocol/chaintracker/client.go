@@ -0,0 +1,280 @@
+package chaintracker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/lavanet/lava/utils"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	DefaultClientCacheTTL       = 200 * time.Millisecond
+	DefaultClientRequestTimeout = 3 * time.Second
+	clientMaxReconnectDelay     = 5 * time.Second
+	clientCacheMaxEntries       = 1000
+)
+
+// ClientConfig configures a Client, zero values use the defaults
+type ClientConfig struct {
+	CacheTTL       time.Duration    // how long replies are reused, keep it well below the block time
+	RequestTimeout time.Duration    // includes waiting for a reconnection to the server
+	TLS            *ClientTLSConfig // if nil the connection is not encrypted
+}
+
+func (cc *ClientConfig) validate() error {
+	if cc.CacheTTL < 0 || cc.RequestTimeout < 0 {
+		return InvalidConfigClient.Wrapf("cache ttl: %s, request timeout: %s", cc.CacheTTL, cc.RequestTimeout)
+	}
+	if cc.CacheTTL == 0 {
+		cc.CacheTTL = DefaultClientCacheTTL
+	}
+	if cc.RequestTimeout == 0 {
+		cc.RequestTimeout = DefaultClientRequestTimeout
+	}
+	return nil
+}
+
+type latestBlockDataKey struct {
+	fromBlock     int64
+	toBlock       int64
+	specificBlock int64
+	finalizedOnly bool
+}
+
+type latestBlockDataResult struct {
+	latestBlock     int64
+	requestedHashes []*BlockStore
+	err             error
+	fetched         time.Time
+}
+
+// an in flight request, identical requests wait on it instead of sending their own
+type latestBlockDataCall struct {
+	done   chan struct{}
+	result latestBlockDataResult
+}
+
+// Client implements the ChainTracker query methods over the grpc service, so several processes on one host can share a single chain tracker
+// the connection is re-established in the background when the server restarts, identical concurrent requests are sent once and replies are cached for CacheTTL
+type Client struct {
+	conn           *grpc.ClientConn
+	client         ChainTrackerServiceClient
+	cacheTTL       time.Duration
+	requestTimeout time.Duration
+	lock           sync.Mutex
+	cache          map[latestBlockDataKey]latestBlockDataResult
+	inFlight       map[latestBlockDataKey]*latestBlockDataCall
+	latestBlock    latestBlockDataResult
+	latestInFlight *latestBlockDataCall
+}
+
+// NewClient doesn't wait for the server, requests sent before it is reachable wait for it up to the request timeout
+func NewClient(address string, config ClientConfig) (*Client, error) {
+	err := config.validate()
+	if err != nil {
+		return nil, err
+	}
+	transportCredentials := insecure.NewCredentials()
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	backoffConfig := backoff.DefaultConfig
+	backoffConfig.MaxDelay = clientMaxReconnectDelay
+	conn, err := grpc.Dial(address,
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoffConfig}),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+	)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed creating chain tracker client", err, utils.Attribute{Key: "address", Value: address})
+	}
+	return &Client{
+		conn:           conn,
+		client:         NewChainTrackerServiceClient(conn),
+		cacheTTL:       config.CacheTTL,
+		requestTimeout: config.RequestTimeout,
+		cache:          map[latestBlockDataKey]latestBlockDataResult{},
+		inFlight:       map[latestBlockDataKey]*latestBlockDataCall{},
+	}, nil
+}
+
+// GetLatestBlockData has the same arguments and semantics as ChainTracker.GetLatestBlockData, the returned hashes are shared with other callers and must not be modified
+func (ctc *Client) GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (latestBlock int64, requestedHashes []*BlockStore, err error) {
+	key := latestBlockDataKey{fromBlock: fromBlock, toBlock: toBlock, specificBlock: specificBlock, finalizedOnly: finalizedOnly}
+	ctc.lock.Lock()
+	if cached, ok := ctc.cache[key]; ok && time.Since(cached.fetched) < ctc.cacheTTL {
+		ctc.lock.Unlock()
+		return cached.latestBlock, cached.requestedHashes, nil
+	}
+	if call, ok := ctc.inFlight[key]; ok {
+		ctc.lock.Unlock()
+		<-call.done
+		return call.result.latestBlock, call.result.requestedHashes, call.result.err
+	}
+	call := &latestBlockDataCall{done: make(chan struct{})}
+	ctc.inFlight[key] = call
+	ctc.lock.Unlock()
+
+	call.result = ctc.fetchLatestBlockData(key)
+
+	ctc.lock.Lock()
+	delete(ctc.inFlight, key)
+	if call.result.err == nil {
+		ctc.storeInCacheUnsafe(key, call.result)
+	}
+	ctc.lock.Unlock()
+	close(call.done)
+	return call.result.latestBlock, call.result.requestedHashes, call.result.err
+}
+
+func (ctc *Client) fetchLatestBlockData(key latestBlockDataKey) latestBlockDataResult {
+	ctx, cancel := context.WithTimeout(context.Background(), ctc.requestTimeout)
+	defer cancel()
+	reply, err := ctc.client.GetLatestBlockData(ctx, &LatestBlockData{FromBlock: key.fromBlock, ToBlock: key.toBlock, SpecificBlock: key.specificBlock, FinalizedOnly: key.finalizedOnly})
+	if err != nil {
+		return latestBlockDataResult{err: err}
+	}
+	result := latestBlockDataResult{latestBlock: reply.GetLatestBlock(), requestedHashes: reply.GetRequestedHashes(), fetched: time.Now()}
+	// the reply also tells the latest block, so GetLatestBlockNum doesn't need to ask for it
+	ctc.lock.Lock()
+	ctc.latestBlock = latestBlockDataResult{latestBlock: result.latestBlock, fetched: result.fetched}
+	ctc.lock.Unlock()
+	return result
+}
+
+// GetLatestBlockDataBatch sends the requests that aren't cached in a single call, results are in the order of the requests and share the cache with GetLatestBlockData.
+// a request that failed on the server sets its result's error, the returned error is for the whole call
+func (ctc *Client) GetLatestBlockDataBatch(requests []*LatestBlockData) (latestBlock int64, results []*BlockDataResult, err error) {
+	results = make([]*BlockDataResult, len(requests))
+	keys := make([]latestBlockDataKey, len(requests))
+	uncached := []int{} // indexes of the requests sent to the server
+	ctc.lock.Lock()
+	for idx, request := range requests {
+		keys[idx] = latestBlockDataKey{fromBlock: request.GetFromBlock(), toBlock: request.GetToBlock(), specificBlock: request.GetSpecificBlock(), finalizedOnly: request.GetFinalizedOnly()}
+		if cached, ok := ctc.cache[keys[idx]]; ok && time.Since(cached.fetched) < ctc.cacheTTL {
+			results[idx] = &BlockDataResult{RequestedHashes: cached.requestedHashes}
+			if cached.latestBlock > latestBlock {
+				latestBlock = cached.latestBlock
+			}
+			continue
+		}
+		uncached = append(uncached, idx)
+	}
+	ctc.lock.Unlock()
+	if len(uncached) == 0 {
+		return latestBlock, results, nil
+	}
+
+	batch := &LatestBlockDataBatch{Requests: make([]*LatestBlockData, 0, len(uncached))}
+	for _, idx := range uncached {
+		batch.Requests = append(batch.Requests, requests[idx])
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ctc.requestTimeout)
+	defer cancel()
+	reply, err := ctc.client.GetLatestBlockDataBatch(ctx, batch)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(reply.GetResults()) != len(uncached) {
+		return 0, nil, utils.LavaFormatError("chain tracker server returned a wrong number of batch results", nil, utils.Attribute{Key: "requests", Value: len(uncached)}, utils.Attribute{Key: "results", Value: len(reply.GetResults())})
+	}
+	fetched := time.Now()
+	ctc.lock.Lock()
+	defer ctc.lock.Unlock()
+	for resultIdx, idx := range uncached {
+		result := reply.GetResults()[resultIdx]
+		results[idx] = result
+		if result.GetError() == "" {
+			ctc.storeInCacheUnsafe(keys[idx], latestBlockDataResult{latestBlock: reply.GetLatestBlock(), requestedHashes: result.GetRequestedHashes(), fetched: fetched})
+		}
+	}
+	ctc.latestBlock = latestBlockDataResult{latestBlock: reply.GetLatestBlock(), fetched: fetched}
+	return reply.GetLatestBlock(), results, nil
+}
+
+func (ctc *Client) storeInCacheUnsafe(key latestBlockDataKey, result latestBlockDataResult) {
+	if len(ctc.cache) >= clientCacheMaxEntries {
+		for cachedKey, cached := range ctc.cache {
+			if time.Since(cached.fetched) >= ctc.cacheTTL {
+				delete(ctc.cache, cachedKey)
+			}
+		}
+		if len(ctc.cache) >= clientCacheMaxEntries {
+			return
+		}
+	}
+	ctc.cache[key] = result
+}
+
+// GetLatestBlockNum returns the latest block known to the server, when it can't be reached the last known block is returned (0 if there is none)
+func (ctc *Client) GetLatestBlockNum() int64 {
+	ctc.lock.Lock()
+	if time.Since(ctc.latestBlock.fetched) < ctc.cacheTTL {
+		defer ctc.lock.Unlock()
+		return ctc.latestBlock.latestBlock
+	}
+	call := ctc.latestInFlight
+	if call != nil {
+		ctc.lock.Unlock()
+		<-call.done
+		return call.result.latestBlock
+	}
+	call = &latestBlockDataCall{done: make(chan struct{})}
+	ctc.latestInFlight = call
+	ctc.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ctc.requestTimeout)
+	defer cancel()
+	reply, err := ctc.client.GetLatestBlockNum(ctx, &empty.Empty{})
+
+	ctc.lock.Lock()
+	ctc.latestInFlight = nil
+	if err != nil {
+		utils.LavaFormatWarning("failed fetching latest block from chain tracker server, using the last known block", err, utils.Attribute{Key: "address", Value: ctc.conn.Target()}, utils.Attribute{Key: "lastKnownBlock", Value: ctc.latestBlock.latestBlock})
+	} else {
+		ctc.latestBlock = latestBlockDataResult{latestBlock: int64(reply.GetValue()), fetched: time.Now()}
+	}
+	call.result.latestBlock = ctc.latestBlock.latestBlock
+	ctc.lock.Unlock()
+	close(call.done)
+	return call.result.latestBlock
+}
+
+// GetAverageBlockTime returns the server's measured average block time, it isn't cached
+func (ctc *Client) GetAverageBlockTime() (averageBlockTime time.Duration, samples uint64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ctc.requestTimeout)
+	defer cancel()
+	reply, err := ctc.client.GetAverageBlockTime(ctx, &empty.Empty{})
+	if err != nil {
+		return 0, 0, err
+	}
+	return time.Duration(reply.GetAverageBlockTime()) * time.Millisecond, reply.GetSamples(), nil
+}
+
+// GetFetcherStats returns the server's node fetch latency histograms, it isn't cached
+func (ctc *Client) GetFetcherStats() (*FetcherStatsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ctc.requestTimeout)
+	defer cancel()
+	return ctc.client.GetFetcherStats(ctx, &empty.Empty{})
+}
+
+// GetSnapshot returns the server's saved blocks, used to seed a new chain tracker through its InitialSnapshot config
+func (ctc *Client) GetSnapshot() (*ChainTrackerSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ctc.requestTimeout)
+	defer cancel()
+	return ctc.client.GetSnapshot(ctx, &empty.Empty{})
+}
+
+func (ctc *Client) Close() error {
+	return ctc.conn.Close()
+}
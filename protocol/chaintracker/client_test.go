@@ -0,0 +1,114 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerClient(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	cacheTTL := 200 * time.Millisecond
+
+	// the client is created before the server is up and waits for it
+	client, err := chaintracker.NewClient(address, chaintracker.ClientConfig{CacheTTL: cacheTTL, RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	require.Equal(t, currentLatestBlockInMock, client.GetLatestBlockNum())
+
+	// concurrent identical requests get the same reply
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latestBlock, requestedHashes, err := client.GetLatestBlockData(spectypes.LATEST_BLOCK-3, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+			require.NoError(t, err)
+			require.Equal(t, currentLatestBlockInMock, latestBlock)
+			require.Len(t, requestedHashes, 4)
+			for _, blockStore := range requestedHashes {
+				require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// replies are cached for the ttl
+	newLatestBlock := mockChainFetcher.AdvanceBlock()
+	time.Sleep(SleepTime * SleepChunks) // the server polls asynchronously
+	latestBlock, _, err := client.GetLatestBlockData(spectypes.LATEST_BLOCK-3, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, latestBlock)
+
+	time.Sleep(cacheTTL)
+	latestBlock, requestedHashes, err := client.GetLatestBlockData(spectypes.LATEST_BLOCK-3, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Equal(t, newLatestBlock, latestBlock)
+	require.Equal(t, newLatestBlock, requestedHashes[len(requestedHashes)-1].Block)
+	require.Equal(t, newLatestBlock, client.GetLatestBlockNum())
+
+	// errors from the server are returned and not cached
+	_, _, err = client.GetLatestBlockData(newLatestBlock+10, newLatestBlock+20, spectypes.NOT_APPLICABLE, false)
+	require.Error(t, err)
+}
+
+func TestChainTrackerClientBatch(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	client, err := chaintracker.NewClient(address, chaintracker.ClientConfig{CacheTTL: time.Minute, RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	rangeRequest := &chaintracker.LatestBlockData{FromBlock: spectypes.LATEST_BLOCK - 3, ToBlock: spectypes.LATEST_BLOCK, SpecificBlock: spectypes.NOT_APPLICABLE}
+	// cached before the batch, so only the other requests are sent
+	_, _, err = client.GetLatestBlockData(rangeRequest.FromBlock, rangeRequest.ToBlock, rangeRequest.SpecificBlock, false)
+	require.NoError(t, err)
+	requests := []*chaintracker.LatestBlockData{
+		rangeRequest,
+		{FromBlock: spectypes.NOT_APPLICABLE, ToBlock: spectypes.NOT_APPLICABLE, SpecificBlock: currentLatestBlockInMock - 1},
+		{FromBlock: currentLatestBlockInMock + 10, ToBlock: currentLatestBlockInMock + 20, SpecificBlock: spectypes.NOT_APPLICABLE},
+	}
+	latestBlock, results, err := client.GetLatestBlockDataBatch(requests)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, latestBlock)
+	require.Len(t, results, len(requests))
+	require.Empty(t, results[0].Error)
+	require.Len(t, results[0].RequestedHashes, 4)
+	require.Empty(t, results[1].Error)
+	require.Len(t, results[1].RequestedHashes, 1)
+	require.True(t, mockChainFetcher.IsCorrectHash(results[1].RequestedHashes[0].Hash, currentLatestBlockInMock-1))
+	// a failed request doesn't fail the batch
+	require.NotEmpty(t, results[2].Error)
+	require.Empty(t, results[2].RequestedHashes)
+
+	tooManyRequests := make([]*chaintracker.LatestBlockData, chaintracker.MaxBlockDataBatchSize+1)
+	for idx := range tooManyRequests {
+		tooManyRequests[idx] = &chaintracker.LatestBlockData{FromBlock: spectypes.NOT_APPLICABLE, ToBlock: spectypes.NOT_APPLICABLE, SpecificBlock: int64(idx)}
+	}
+	_, _, err = client.GetLatestBlockDataBatch(tooManyRequests)
+	require.Error(t, err)
+}
+
+func TestChainTrackerClientConfigValidation(t *testing.T) {
+	_, err := chaintracker.NewClient("127.0.0.1:0", chaintracker.ClientConfig{CacheTTL: -time.Second})
+	require.True(t, chaintracker.InvalidConfigClient.Is(err))
+}
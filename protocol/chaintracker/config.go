@@ -1,20 +1,47 @@
 package chaintracker
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 const (
 	DefualtAssumedBlockMemory      = 20
 	DefaultBlockCheckpointDistance = 100
+	DefaultFetchConcurrency        = 1
+	DefaultFetchTimeout            = 10 * time.Second
 )
 
 type ChainTrackerConfig struct {
-	ForkCallback             func(block int64)              // a function to be called when a fork is detected
-	NewLatestCallback        func(block int64, hash string) // a function to be called when a new block is detected
-	ServerAddress            string                         // if not empty will open up a grpc server for that address
-	BlocksToSave             uint64
-	AverageBlockTime         time.Duration // how often to query latest block
-	ServerBlockMemory        uint64
-	blocksCheckpointDistance uint64 // this causes the chainTracker to trigger it's checkpoint every X blocks
+	ForkCallback                func(block int64)                                           // a function to be called when a fork is detected
+	NewLatestCallback           func(block int64, hash string)                              // a function to be called when a new block is detected
+	LaggingNodeCallback         func(lagging bool, latestBlock int64, referenceBlock int64) // called when the node starts or stops lagging behind the reference fetcher
+	StaleChainCallback          func(sinceLastBlock time.Duration)                          // called once when the node answers but no new block arrived for StaleBlocksThreshold average block times
+	GapDetectedCallback         func(fromBlock int64, toBlock int64)                        // called when the node advanced past BlocksToSave between polls and the blocks in the range were never tracked
+	HeightRegressionCallback    func(latestBlock int64, nodeLatestBlock int64)              // called once when the node starts reporting a latest block lower than the tracked latest block
+	ReferenceFetcher            ReferenceFetcher                                            // if not nil the latest block is compared against it to detect a lagging node
+	HashVerifier                BlockHashVerifier                                           // if not nil block hashes read from the node are verified with it before they are stored
+	ReferenceCheckInterval      uint64                                                      // compare against the reference every X polls
+	MaxBlocksBehindReference    uint64                                                      // the node is lagging when it is more than this many blocks behind the reference
+	ServerAddress               string                                                      // if not empty will open up a grpc server for that address
+	ServerTLS                   *ServerTLSConfig                                            // if not nil the grpc server is served over tls instead of plaintext h2c
+	ServerLimits                *ServerLimitsConfig                                         // if not nil requests to the grpc server are rate limited
+	ServerDebug                 bool                                                        // if true the server also serves /debug/pprof and a /debug/state dump, for diagnosing stuck trackers
+	BlockBodyRetention          *BlockBodyRetentionConfig                                   // if not nil and the fetcher is a BlockDataFetcher recent block replies are kept
+	InitialSnapshot             *ChainTrackerSnapshot                                       // if not nil the saved blocks are seeded from it on start, and only the blocks after it are fetched from the node
+	BlocksToSave                uint64
+	AverageBlockTime            time.Duration // how often to query latest block
+	ServerBlockMemory           uint64
+	ReorgHistorySize            uint64        // how many detected reorgs to keep for GetReorgHistory
+	FetchConcurrency            uint64        // how many block hashes to fetch in parallel when filling gaps, 1 fetches sequentially
+	FinalizationDistance        uint64        // blocks this far behind the latest can't reorg anymore, 0 treats every seen block as final
+	StaleBlocksThreshold        uint64        // average block times without a new block before the chain is stale
+	HashlessMode                bool          // for nodes that can't serve block hashes reliably, only heights are tracked and fork detection is disabled
+	LatestBlockFetchTimeout     time.Duration // deadline for every latest block query to the node and the reference, defaults to DefaultFetchTimeout
+	BlockHashFetchTimeout       time.Duration // deadline for every block hash query to the node, defaults to DefaultFetchTimeout
+	PollingJitter               float64       // every polling and backoff interval is randomly changed by up to this fraction of it, 0 disables jitter
+	BlocksCheckpointDistance    uint64        // this causes the chainTracker to trigger it's checkpoint every X blocks, defaults to DefaultBlockCheckpointDistance
+	HeightRegressionResyncPolls uint64        // consecutive polls with a regressed height before the saved blocks are rebuilt from the node's height, 0 waits for the node to catch up instead
 }
 
 func (cnf *ChainTrackerConfig) validate() error {
@@ -25,11 +52,58 @@ func (cnf *ChainTrackerConfig) validate() error {
 		return InvalidConfigBlockTime
 	}
 
+	if cnf.FinalizationDistance >= cnf.BlocksToSave {
+		return InvalidConfigFinalization.Wrapf("finalization distance: %d, blocks to save: %d", cnf.FinalizationDistance, cnf.BlocksToSave)
+	}
 	if cnf.ServerBlockMemory == 0 {
 		cnf.ServerBlockMemory = DefualtAssumedBlockMemory
 	}
-	if cnf.blocksCheckpointDistance == 0 {
-		cnf.blocksCheckpointDistance = DefaultBlockCheckpointDistance
+	if cnf.ReorgHistorySize == 0 {
+		cnf.ReorgHistorySize = DefaultReorgHistorySize
+	}
+	if cnf.StaleBlocksThreshold == 0 {
+		cnf.StaleBlocksThreshold = DefaultStaleBlocksThreshold
+	}
+	if cnf.PollingJitter < 0 || cnf.PollingJitter >= 1 || math.IsNaN(cnf.PollingJitter) {
+		return InvalidConfigPollingJitter.Wrapf("polling jitter: %f", cnf.PollingJitter)
+	}
+	if cnf.LatestBlockFetchTimeout < 0 || cnf.BlockHashFetchTimeout < 0 {
+		return InvalidConfigFetchTimeout.Wrapf("latest block fetch timeout: %s, block hash fetch timeout: %s", cnf.LatestBlockFetchTimeout, cnf.BlockHashFetchTimeout)
+	}
+	if cnf.LatestBlockFetchTimeout == 0 {
+		cnf.LatestBlockFetchTimeout = DefaultFetchTimeout
+	}
+	if cnf.BlockHashFetchTimeout == 0 {
+		cnf.BlockHashFetchTimeout = DefaultFetchTimeout
+	}
+	if cnf.FetchConcurrency == 0 {
+		cnf.FetchConcurrency = DefaultFetchConcurrency
+	}
+	if cnf.BlocksCheckpointDistance == 0 {
+		cnf.BlocksCheckpointDistance = DefaultBlockCheckpointDistance
+	}
+	if cnf.ReferenceFetcher != nil {
+		if cnf.ReferenceCheckInterval == 0 {
+			cnf.ReferenceCheckInterval = DefaultReferenceCheckInterval
+		}
+		if cnf.MaxBlocksBehindReference == 0 {
+			cnf.MaxBlocksBehindReference = DefaultMaxBlocksBehindReference
+		}
+	}
+	if cnf.ServerTLS != nil {
+		if err := cnf.ServerTLS.validate(); err != nil {
+			return err
+		}
+	}
+	if cnf.BlockBodyRetention != nil {
+		if err := cnf.BlockBodyRetention.validate(); err != nil {
+			return err
+		}
+	}
+	if cnf.ServerLimits != nil {
+		if err := cnf.ServerLimits.validate(); err != nil {
+			return err
+		}
 	}
 	// TODO: validate address is in the right format if not empty
 	return nil
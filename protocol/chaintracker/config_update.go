@@ -0,0 +1,41 @@
+package chaintracker
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+func (cs *ChainTracker) getConfiguredBlockTime() time.Duration {
+	return time.Duration(atomic.LoadInt64((*int64)(&cs.averageBlockTime)))
+}
+
+func (cs *ChainTracker) getBlockCheckpointDistance() uint64 {
+	return atomic.LoadUint64(&cs.blockCheckpointDistance)
+}
+
+// UpdateConfig applies the blocks window, the average block time polling is based on and the checkpoint distance of config at runtime,
+// so spec upgrades don't need a restart. the other fields can only be set when the tracker is created and are ignored here
+func (cs *ChainTracker) UpdateConfig(config ChainTrackerConfig) error {
+	err := config.validate()
+	if err != nil {
+		return err
+	}
+	err = cs.UpdateBlocksToSave(config.BlocksToSave, config.ServerBlockMemory, config.FinalizationDistance)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint64(&cs.blockCheckpointDistance, config.BlocksCheckpointDistance)
+	previousBlockTime := time.Duration(atomic.SwapInt64((*int64)(&cs.averageBlockTime), int64(config.AverageBlockTime)))
+	if previousBlockTime == config.AverageBlockTime {
+		return nil
+	}
+	// the ticker is only touched by the polling routine, while backing off from failures the new time is used on the next poll
+	select {
+	case cs.configChanged <- struct{}{}:
+	default: // a change is already pending, the routine reads the latest block time
+	}
+	utils.LavaFormatInfo("chain tracker polling time updated", utils.Attribute{Key: "averageBlockTime", Value: config.AverageBlockTime}, utils.Attribute{Key: "previousAverageBlockTime", Value: previousBlockTime}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	return nil
+}
@@ -0,0 +1,92 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerUpdateConfig(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 20)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+
+	// an invalid config changes nothing
+	invalidConfig := chainTrackerConfig
+	invalidConfig.BlocksToSave = 8
+	invalidConfig.FinalizationDistance = 8
+	require.Error(t, chainTracker.UpdateConfig(invalidConfig))
+	require.Equal(t, uint64(5), chainTracker.GetDebugState().BlocksToSave)
+
+	updatedConfig := chainTrackerConfig
+	updatedConfig.BlocksToSave = 8
+	updatedConfig.ServerBlockMemory = 16
+	updatedConfig.AverageBlockTime = 2 * TimeForPollingMock
+	updatedConfig.BlocksCheckpointDistance = 2
+	require.NoError(t, chainTracker.UpdateConfig(updatedConfig))
+	state := chainTracker.GetDebugState()
+	require.Equal(t, uint64(8), state.BlocksToSave)
+	require.Equal(t, uint64(16), state.ServerBlockMemory)
+	require.Equal(t, (2 * TimeForPollingMock / 10).String(), state.TickerInterval)
+
+	// the grown window is filled on the next poll and polling continues at the new rate
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool {
+		state = chainTracker.GetDebugState()
+		return state.LatestBlock == currentLatestBlockInMock && state.SavedBlocks == 8
+	}, 2*time.Second, SleepTime)
+	require.Equal(t, currentLatestBlockInMock-7, state.EarliestSavedBlock)
+
+	// the checkpoint distance is used by checkpoint listeners
+	checkpoints := make(chan int64, 10)
+	_, err = chainTracker.RegisterFilteredBlockListener(func(block int64, hash string) { checkpoints <- block }, chaintracker.BlockListenerFilter{EveryCheckpoint: true})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+		waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	}
+	select {
+	case block := <-checkpoints:
+		require.Zero(t, block%2)
+	case <-time.After(time.Second):
+		require.Fail(t, "checkpoint listener wasn't called")
+	}
+}
+
+func TestChainTrackerUpdateConfigWhilePolling(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 20)
+	mockChainFetcher.AdvanceBlock()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+
+	// the polling routine moves between failing and healthy while the block time changes
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			mockChainFetcher.SetFailing(i%10 < 3)
+			mockChainFetcher.AdvanceBlock()
+			time.Sleep(SleepTime / 4)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		updatedConfig := chainTrackerConfig
+		updatedConfig.AverageBlockTime = time.Duration(1+i%3) * TimeForPollingMock
+		require.NoError(t, chainTracker.UpdateConfig(updatedConfig))
+		time.Sleep(SleepTime / 20)
+	}
+	<-done
+	mockChainFetcher.SetFailing(false)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool {
+		return chainTracker.GetLatestBlockNum() == currentLatestBlockInMock
+	}, 5*time.Second, SleepTime)
+}
@@ -0,0 +1,101 @@
+package chaintracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DebugPprofPath = "/debug/pprof/"
+	DebugStatePath = "/debug/state"
+)
+
+// DebugState is a dump of the tracker internals for diagnosing a stuck tracker
+type DebugState struct {
+	ChainID                      string `json:"chainID"`
+	ApiInterface                 string `json:"apiInterface"`
+	LatestBlock                  int64  `json:"latestBlock"`
+	EarliestSavedBlock           int64  `json:"earliestSavedBlock"`
+	LatestSavedBlock             int64  `json:"latestSavedBlock"`
+	SavedBlocks                  int64  `json:"savedBlocks"`
+	BlocksToSave                 uint64 `json:"blocksToSave"`
+	ServerBlockMemory            uint64 `json:"serverBlockMemory"`
+	FinalizationDistance         uint64 `json:"finalizationDistance"`
+	BlockCheckpoint              uint64 `json:"blockCheckpoint"`
+	TickerInterval               string `json:"tickerInterval"` // the polling interval without failures
+	NextPollDelay                string `json:"nextPollDelay"`  // the last interval the ticker was set to, including backoff and jitter
+	ConsecutiveFetchFails        uint64 `json:"consecutiveFetchFails"`
+	ConsecutiveFetchTimeouts     uint64 `json:"consecutiveFetchTimeouts"`
+	InBackoff                    bool   `json:"inBackoff"`
+	TimeSinceLastSuccessfulFetch string `json:"timeSinceLastSuccessfulFetch"`
+	Lagging                      bool   `json:"lagging"`
+	Stale                        bool   `json:"stale"`
+	Hashless                     bool   `json:"hashless"`
+	BlockBodiesBytes             uint64 `json:"blockBodiesBytes"`
+}
+
+// records the interval the ticker was set to, for the debug state
+func (cs *ChainTracker) setPollDelay(delay time.Duration) time.Duration {
+	atomic.StoreInt64(&cs.pollDelay, int64(delay))
+	return delay
+}
+
+// GetDebugState returns the tracker internals served on DebugStatePath
+func (cs *ChainTracker) GetDebugState() DebugState {
+	fetchFails := atomic.LoadUint64(&cs.consecutiveFetchFails)
+	state := DebugState{
+		ChainID:                      cs.endpoint.ChainID,
+		ApiInterface:                 cs.endpoint.ApiInterface,
+		LatestBlock:                  cs.GetLatestBlockNum(),
+		BlockCheckpoint:              atomic.LoadUint64(&cs.blockCheckpoint),
+		TickerInterval:               (cs.getConfiguredBlockTime() / 10).String(),
+		NextPollDelay:                time.Duration(atomic.LoadInt64(&cs.pollDelay)).String(),
+		ConsecutiveFetchFails:        fetchFails,
+		ConsecutiveFetchTimeouts:     atomic.LoadUint64(&cs.consecutiveFetchTimeouts),
+		InBackoff:                    fetchFails > 0,
+		TimeSinceLastSuccessfulFetch: cs.timeSinceLastSuccessfulFetch().String(),
+		Lagging:                      cs.IsLagging(),
+		Stale:                        cs.IsStale(),
+		Hashless:                     cs.hashless,
+	}
+	cs.blockQueueMu.RLock()
+	state.SavedBlocks = cs.blocksQueue.Len()
+	if state.SavedBlocks > 0 {
+		state.EarliestSavedBlock = cs.getEarliestBlockUnsafe().Block
+		state.LatestSavedBlock = cs.getLatestBlockUnsafe().Block
+	}
+	state.BlocksToSave = cs.getBlocksToSave()
+	state.ServerBlockMemory = cs.getServerBlockMemory()
+	state.FinalizationDistance = cs.getFinalizationDistance()
+	cs.blockQueueMu.RUnlock()
+	cs.blockBodyMu.RLock()
+	state.BlockBodiesBytes = cs.blockBodiesBytes
+	cs.blockBodyMu.RUnlock()
+	return state
+}
+
+// serves the debug paths when the server was configured with them, returns false for other paths
+func (cs *ChainTracker) debugHandler(resp http.ResponseWriter, req *http.Request) bool {
+	switch {
+	case req.URL.Path == DebugStatePath:
+		resp.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(resp).Encode(cs.GetDebugState())
+	case req.URL.Path == DebugPprofPath+"cmdline":
+		pprof.Cmdline(resp, req)
+	case req.URL.Path == DebugPprofPath+"profile":
+		pprof.Profile(resp, req)
+	case req.URL.Path == DebugPprofPath+"symbol":
+		pprof.Symbol(resp, req)
+	case req.URL.Path == DebugPprofPath+"trace":
+		pprof.Trace(resp, req)
+	case strings.HasPrefix(req.URL.Path, DebugPprofPath):
+		pprof.Index(resp, req) // the index and the named profiles, e.g. /debug/pprof/goroutine
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package chaintracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func httpGetWithRetry(t *testing.T, url string) *http.Response {
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < 20; attempt++ {
+		resp, err = http.Get(url)
+		if err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond) // server is still starting up
+	}
+	require.NoError(t, err)
+	return resp
+}
+
+func TestChainTrackerDebugEndpoints(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	address := getFreeAddress(t)
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address, ServerDebug: true}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	getState := func() chaintracker.DebugState {
+		resp := httpGetWithRetry(t, "http://"+address+chaintracker.DebugStatePath)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		state := chaintracker.DebugState{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&state))
+		return state
+	}
+	state := getState()
+	require.Equal(t, currentLatestBlockInMock, state.LatestBlock)
+	require.Equal(t, int64(5), state.SavedBlocks)
+	require.Equal(t, currentLatestBlockInMock-4, state.EarliestSavedBlock)
+	require.Equal(t, currentLatestBlockInMock, state.LatestSavedBlock)
+	require.Equal(t, uint64(5), state.BlocksToSave)
+	require.False(t, state.InBackoff)
+	require.NotEmpty(t, state.NextPollDelay)
+
+	// the backoff of a failing node shows up in the dump
+	mockChainFetcher.SetFailing(true)
+	require.Eventually(t, func() bool {
+		state = getState()
+		return state.InBackoff && state.ConsecutiveFetchFails > 1
+	}, 5*time.Second, 10*time.Millisecond)
+	mockChainFetcher.SetFailing(false)
+
+	resp := httpGetWithRetry(t, "http://"+address+chaintracker.DebugPprofPath+"goroutine?debug=1")
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "goroutine profile")
+
+	// without the flag the debug paths aren't served
+	otherAddress := getFreeAddress(t)
+	chainTrackerConfig.ServerAddress = otherAddress
+	chainTrackerConfig.ServerDebug = false
+	go chaintracker.NewChainTracker(ctx, NewMockChainFetcher(1000, 10), chainTrackerConfig)
+	resp = httpGetWithRetry(t, "http://"+otherAddress+chaintracker.DebugStatePath)
+	resp.Body.Close()
+	require.NotEqual(t, http.StatusOK, resp.StatusCode)
+}
@@ -14,4 +14,18 @@ var ( // Consumer Side Errors
 	RequestedBlocksOutOfRange       = sdkerrors.New("RequestedBlocksOutOfRange", 10707, "requested blocks are outside the supported range by the state tracker")
 	ErrorFailedToFetchTooEarlyBlock = sdkerrors.New("Error ErrorFailedToFetchTooEarlyBlock", 10708, "server memory protection triggered, requested block is too early")
 	InvalidRequestedSpecificBlock   = sdkerrors.New("Error InvalidRequestedSpecificBlock", 10709, "provided requested specific blocks for function do not compose a stored entry")
+	InvalidConfigServerTLS          = sdkerrors.New("Invalid server tls config", 10710, "server tls was enabled without a valid certificate and key")
+	InvalidConfigServerLimits       = sdkerrors.New("Invalid server limits config", 10711, "server limits must not be negative")
+	InvalidConfigBlockBodyRetention = sdkerrors.New("Invalid block body retention config", 10712, "block body retention was enabled without a number of blocks to retain")
+	InvalidConfigClient             = sdkerrors.New("Invalid client config", 10713, "chain tracker client durations must not be negative and tls files must be valid")
+	InvalidConfigFinalization       = sdkerrors.New("Invalid finalization distance", 10714, "finalization distance must be smaller than the blocks to save")
+	InvalidConfigFetchTimeout       = sdkerrors.New("Invalid fetch timeout", 10715, "fetch timeouts must not be negative")
+	ErrorFetchTimeout               = sdkerrors.New("Error FetchTimeout", 10716, "the node didn't reply before the fetch timeout")
+	InvalidConfigPollingJitter      = sdkerrors.New("Invalid polling jitter", 10717, "polling jitter must be a fraction in [0, 1)")
+	InvalidSnapshot                 = sdkerrors.New("Invalid snapshot", 10718, "the snapshot doesn't hold contiguous blocks of this chain tracker's chain")
+	InvalidBlockListenerFilter      = sdkerrors.New("Invalid block listener filter", 10719, "forks only can't be combined with other filters, and every n blocks can't be combined with every checkpoint")
+	InvalidConfigLightClient        = sdkerrors.New("Invalid light client config", 10720, "light client verification requires a primary, a witness and valid trust options")
+	ErrorBlockHashVerification      = sdkerrors.New("Error BlockHashVerification", 10721, "a block hash read from the node failed verification")
+	InvalidBlockDataBatch           = sdkerrors.New("Invalid block data batch", 10722, "a batch must hold at least one request and at most MaxBlockDataBatchSize requests")
+	ErrorHeightRegression           = sdkerrors.New("Error HeightRegression", 10723, "the node reported a latest block lower than the tracked latest block")
 )
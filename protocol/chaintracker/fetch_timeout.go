@@ -0,0 +1,26 @@
+package chaintracker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// reports a fetch that failed because fetchCtx timed out as ErrorFetchTimeout, so a hung node can be told apart from a failing one.
+// a fetch canceled by the parent ctx isn't a timeout
+func fetchTimeoutError(ctx context.Context, fetchCtx context.Context, err error, timeout time.Duration) error {
+	if err == nil || ctx.Err() != nil || !errors.Is(fetchCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return ErrorFetchTimeout.Wrapf("timeout: %s, error: %s", timeout, err)
+}
+
+// a hung node isn't polled more often than once per fetch timeout, so queries don't pile up on it while it doesn't reply
+func (cs *ChainTracker) updateTickerAfterTimeout(tickerBaseTime time.Duration, fetchFails uint64) {
+	backoff := exponentialBackoff(tickerBaseTime, fetchFails)
+	if backoff < cs.latestBlockFetchTimeout {
+		backoff = cs.latestBlockFetchTimeout
+	}
+	cs.ticker.Stop()
+	cs.ticker = time.NewTicker(cs.setPollDelay(cs.jitter(backoff)))
+}
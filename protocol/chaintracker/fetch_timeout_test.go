@@ -0,0 +1,68 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+// a node that doesn't reply to latest block queries until ctx is done while hanging
+type HangingChainFetcher struct {
+	*MockChainFetcher
+	hanging uint32
+}
+
+func (hcf *HangingChainFetcher) SetHanging(hanging bool) {
+	value := uint32(0)
+	if hanging {
+		value = 1
+	}
+	atomic.StoreUint32(&hcf.hanging, value)
+}
+
+func (hcf *HangingChainFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	if atomic.LoadUint32(&hcf.hanging) == 1 {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	return hcf.MockChainFetcher.FetchLatestBlockNum(ctx)
+}
+
+func TestChainTrackerFetchTimeout(t *testing.T) {
+	mockChainFetcher := &HangingChainFetcher{MockChainFetcher: NewMockChainFetcher(1000, 20)}
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 20, LatestBlockFetchTimeout: -time.Second}
+	_, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.ErrorIs(t, err, chaintracker.InvalidConfigFetchTimeout)
+
+	chainTrackerConfig.LatestBlockFetchTimeout = 10 * time.Millisecond
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+	require.Equal(t, currentLatestBlockInMock, chainTracker.GetLatestBlockNum())
+
+	// a hung node times out instead of blocking the polling loop forever
+	mockChainFetcher.SetHanging(true)
+	require.Eventually(t, func() bool {
+		return chainTracker.GetHealthStatus().ConsecutiveFetchTimeouts >= 2
+	}, time.Second, TimeForPollingMock)
+	status := chainTracker.GetHealthStatus()
+	require.Equal(t, status.ConsecutiveFetchFails, status.ConsecutiveFetchTimeouts)
+
+	// once the node replies again polling recovers
+	mockChainFetcher.SetHanging(false)
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool {
+		return chainTracker.GetLatestBlockNum() == currentLatestBlockInMock
+	}, time.Second, TimeForPollingMock)
+	status = chainTracker.GetHealthStatus()
+	require.Zero(t, status.ConsecutiveFetchFails)
+	require.Zero(t, status.ConsecutiveFetchTimeouts)
+}
@@ -0,0 +1,127 @@
+package chaintracker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	fetchCallLatestBlockNum = "latest_block_num"
+	fetchCallBlockHashByNum = "block_hash_by_num"
+)
+
+// upper bounds of the latency buckets, slower calls fall in an extra last bucket
+var fetchLatencyBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+var fetchLatencyHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "lava_chain_tracker_fetch_latency_seconds",
+	Help:    "The latency of the chain tracker queries to the node",
+	Buckets: fetchLatencyBucketsSeconds(),
+}, []string{"spec", "apiInterface", "call"})
+
+func init() {
+	prometheus.MustRegister(fetchLatencyHistogram)
+}
+
+func fetchLatencyBucketsSeconds() []float64 {
+	buckets := make([]float64, len(fetchLatencyBuckets))
+	for idx, bucket := range fetchLatencyBuckets {
+		buckets[idx] = bucket.Seconds()
+	}
+	return buckets
+}
+
+// fetchLatencies keeps the latency histogram of one fetcher call for GetFetcherStats, the counts are since the chain tracker started
+type fetchLatencies struct {
+	lock   sync.Mutex
+	counts []uint64 // one per bucket and one for the slower calls
+	calls  uint64
+	errors uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func newFetchLatencies() *fetchLatencies {
+	return &fetchLatencies{counts: make([]uint64, len(fetchLatencyBuckets)+1)}
+}
+
+func (fl *fetchLatencies) observe(latency time.Duration, failed bool) {
+	bucket := len(fetchLatencyBuckets)
+	for idx, upperBound := range fetchLatencyBuckets {
+		if latency <= upperBound {
+			bucket = idx
+			break
+		}
+	}
+	fl.lock.Lock()
+	defer fl.lock.Unlock()
+	fl.counts[bucket]++
+	fl.calls++
+	if failed {
+		fl.errors++
+	}
+	fl.sum += latency
+	if latency > fl.max {
+		fl.max = latency
+	}
+}
+
+func (fl *fetchLatencies) stats() *FetchLatencyStats {
+	fl.lock.Lock()
+	defer fl.lock.Unlock()
+	stats := &FetchLatencyStats{Calls: fl.calls, Errors: fl.errors, MaxLatency: fl.max.Microseconds(), Buckets: make([]*LatencyBucket, len(fl.counts))}
+	if fl.calls > 0 {
+		stats.AverageLatency = (fl.sum / time.Duration(fl.calls)).Microseconds()
+	}
+	for idx, count := range fl.counts {
+		upperBound := int64(-1)
+		if idx < len(fetchLatencyBuckets) {
+			upperBound = fetchLatencyBuckets[idx].Microseconds()
+		}
+		stats.Buckets[idx] = &LatencyBucket{UpperBound: upperBound, Count: count}
+	}
+	stats.P50Latency = fl.percentileUnsafe(0.5)
+	stats.P90Latency = fl.percentileUnsafe(0.9)
+	stats.P99Latency = fl.percentileUnsafe(0.99)
+	return stats
+}
+
+// the upper bound of the bucket holding the percentile, the max latency when it is in the last bucket
+func (fl *fetchLatencies) percentileUnsafe(percentile float64) int64 {
+	if fl.calls == 0 {
+		return 0
+	}
+	rank := uint64(percentile * float64(fl.calls))
+	if rank == 0 {
+		rank = 1
+	}
+	accumulated := uint64(0)
+	for idx, count := range fl.counts {
+		accumulated += count
+		if accumulated >= rank && idx < len(fetchLatencyBuckets) {
+			return fetchLatencyBuckets[idx].Microseconds()
+		}
+	}
+	return fl.max.Microseconds()
+}
+
+func (cs *ChainTracker) observeFetchLatency(call string, latencies *fetchLatencies, start time.Time, err error) {
+	latency := time.Since(start)
+	latencies.observe(latency, err != nil)
+	fetchLatencyHistogram.WithLabelValues(cs.endpoint.ChainID, cs.endpoint.ApiInterface, call).Observe(latency.Seconds())
+}
+
+// GetFetcherStats returns the latency histograms of the node queries since the chain tracker started, so node endpoints can be compared per chain
+func (cs *ChainTracker) GetFetcherStats() *FetcherStatsResponse {
+	return &FetcherStatsResponse{
+		ChainID:        cs.endpoint.ChainID,
+		ApiInterface:   cs.endpoint.ApiInterface,
+		LatestBlockNum: cs.latestBlockNumLatencies.stats(),
+		BlockHashByNum: cs.blockHashLatencies.stats(),
+	}
+}
@@ -0,0 +1,46 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerFetcherStats(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	client, err := chaintracker.NewClient(address, chaintracker.ClientConfig{RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	client.GetLatestBlockNum() // waits for the server
+	time.Sleep(SleepTime * SleepChunks)
+	mockChainFetcher.SetFailing(true)
+	time.Sleep(SleepTime * SleepChunks)
+	mockChainFetcher.SetFailing(false)
+
+	stats, err := client.GetFetcherStats()
+	require.NoError(t, err)
+	require.Equal(t, mockChainFetcher.FetchEndpoint().ChainID, stats.ChainID)
+	for _, callStats := range []*chaintracker.FetchLatencyStats{stats.LatestBlockNum, stats.BlockHashByNum} {
+		require.Positive(t, callStats.Calls)
+		bucketsSum := uint64(0)
+		for _, bucket := range callStats.Buckets {
+			bucketsSum += bucket.Count
+		}
+		require.Equal(t, callStats.Calls, bucketsSum)
+		require.Equal(t, int64(-1), callStats.Buckets[len(callStats.Buckets)-1].UpperBound)
+		require.LessOrEqual(t, callStats.P50Latency, callStats.P90Latency)
+		require.LessOrEqual(t, callStats.P90Latency, callStats.P99Latency)
+	}
+	require.Positive(t, stats.LatestBlockNum.Errors)
+}
@@ -0,0 +1,54 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerFinalizedBlocks(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := uint64(10)
+	finalizationDistance := uint64(4)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: fetcherBlocks, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: uint64(mockBlocks), FinalizationDistance: fetcherBlocks}
+	_, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.Error(t, err)
+
+	chainTrackerConfig.FinalizationDistance = finalizationDistance
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+	latestFinalized := currentLatestBlockInMock - int64(finalizationDistance)
+	require.Equal(t, latestFinalized, chainTracker.GetLatestFinalizedBlockNum())
+
+	latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(fetcherBlocks)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, latestBlock)
+	require.Len(t, requestedHashes, int(fetcherBlocks))
+
+	latestBlock, requestedHashes, err = chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(fetcherBlocks)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, true)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, latestBlock)
+	require.Len(t, requestedHashes, int(fetcherBlocks-finalizationDistance))
+	for _, blockStore := range requestedHashes {
+		require.LessOrEqual(t, blockStore.Block, latestFinalized)
+		require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+	}
+
+	// a specific block that isn't final yet is left out
+	_, requestedHashes, err = chainTracker.GetLatestBlockData(spectypes.NOT_APPLICABLE, spectypes.NOT_APPLICABLE, currentLatestBlockInMock, true)
+	require.NoError(t, err)
+	require.Empty(t, requestedHashes)
+	_, requestedHashes, err = chainTracker.GetLatestBlockData(spectypes.NOT_APPLICABLE, spectypes.NOT_APPLICABLE, latestFinalized, true)
+	require.NoError(t, err)
+	require.Len(t, requestedHashes, 1)
+	require.Equal(t, latestFinalized, requestedHashes[0].Block)
+}
@@ -0,0 +1,27 @@
+package chaintracker
+
+import (
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var blockGapsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_chain_tracker_block_gaps_total",
+	Help: "The number of times the chain tracker skipped a range of blocks it never tracked",
+}, []string{"spec", "apiInterface"})
+
+func init() {
+	prometheus.MustRegister(blockGapsCounter)
+}
+
+// called when the latest block advanced by more than blocksToSave since the last poll, the blocks between the previous latest
+// and the new queue were never saved nor their hashes checked, so anything derived from them can't be trusted
+func (cs *ChainTracker) onGapDetected(previousLatestBlock int64, latestBlock int64, blocksToSave uint64) {
+	fromBlock := previousLatestBlock + 1
+	toBlock := latestBlock - int64(blocksToSave)
+	blockGapsCounter.WithLabelValues(cs.endpoint.ChainID, cs.endpoint.ApiInterface).Inc()
+	utils.LavaFormatWarning("chain tracker skipped blocks, the node advanced more than the saved blocks between polls", nil, utils.Attribute{Key: "fromBlock", Value: fromBlock}, utils.Attribute{Key: "toBlock", Value: toBlock}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	if cs.gapDetectedCallback != nil {
+		cs.gapDetectedCallback(fromBlock, toBlock)
+	}
+}
@@ -0,0 +1,63 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerGapDetected(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := uint64(5)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	startBlock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lock sync.Mutex
+	gaps := [][2]int64{}
+	gapDetectedCallback := func(fromBlock int64, toBlock int64) {
+		lock.Lock()
+		defer lock.Unlock()
+		gaps = append(gaps, [2]int64{fromBlock, toBlock})
+	}
+	getGaps := func() [][2]int64 {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([][2]int64{}, gaps...)
+	}
+	averageBlockTime := 50 * TimeForPollingMock
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: fetcherBlocks, AverageBlockTime: averageBlockTime, ServerBlockMemory: uint64(mockBlocks), GapDetectedCallback: gapDetectedCallback}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	// advancing up to blocksToSave blocks between polls leaves no gap
+	var latestBlock int64
+	for i := uint64(0); i < fetcherBlocks; i++ {
+		latestBlock = mockChainFetcher.AdvanceBlock()
+	}
+	require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == latestBlock }, time.Second, TimeForPollingMock)
+	require.Equal(t, startBlock+int64(fetcherBlocks), latestBlock)
+	require.Empty(t, getGaps())
+
+	// the node jumps ahead, e.g. after a restart
+	previousLatest := latestBlock
+	for i := 0; i < 20; i++ {
+		latestBlock = mockChainFetcher.AdvanceBlock()
+	}
+	require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == latestBlock }, time.Second, TimeForPollingMock)
+	require.Equal(t, [][2]int64{{previousLatest + 1, latestBlock - int64(fetcherBlocks)}}, getGaps())
+	// the queue after the gap is complete
+	_, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(fetcherBlocks)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Len(t, requestedHashes, int(fetcherBlocks))
+	for _, blockStore := range requestedHashes {
+		require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+	}
+}
@@ -0,0 +1,75 @@
+package chaintracker_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+// a node that serves heights but fails every hash query
+type HashlessChainFetcher struct {
+	*MockChainFetcher
+}
+
+func (hcf HashlessChainFetcher) FetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
+	return "", fmt.Errorf("hashes are not supported")
+}
+
+func TestChainTrackerHashlessMode(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := uint64(10)
+	mockChainFetcher := HashlessChainFetcher{MockChainFetcher: NewMockChainFetcher(1000, mockBlocks)}
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lock sync.Mutex
+	newBlocks := []int64{}
+	forks := 0
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{
+		BlocksToSave:      fetcherBlocks,
+		AverageBlockTime:  TimeForPollingMock,
+		ServerBlockMemory: uint64(mockBlocks),
+		NewLatestCallback: func(block int64, hash string) { lock.Lock(); defer lock.Unlock(); newBlocks = append(newBlocks, block) },
+		ForkCallback:      func(block int64) { lock.Lock(); defer lock.Unlock(); forks++ },
+	}
+	_, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.Error(t, err)
+
+	chainTrackerConfig.HashlessMode = true
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(fetcherBlocks)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, latestBlock)
+	require.Len(t, requestedHashes, int(fetcherBlocks))
+	for idx, blockStore := range requestedHashes {
+		require.Equal(t, currentLatestBlockInMock-int64(fetcherBlocks)+1+int64(idx), blockStore.Block)
+		require.Empty(t, blockStore.Hash)
+	}
+
+	// heights keep advancing and a fork in the node goes unnoticed
+	for i := 0; i < 3; i++ {
+		currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+		time.Sleep(SleepTime)
+	}
+	mockChainFetcher.Fork("fork")
+	time.Sleep(SleepTime)
+	require.Equal(t, currentLatestBlockInMock, chainTracker.GetLatestBlockNum())
+	lock.Lock()
+	require.Equal(t, []int64{currentLatestBlockInMock - 2, currentLatestBlockInMock - 1, currentLatestBlockInMock}, newBlocks)
+	require.Zero(t, forks)
+	lock.Unlock()
+	_, requestedHashes, err = chainTracker.GetLatestBlockData(spectypes.NOT_APPLICABLE, spectypes.NOT_APPLICABLE, currentLatestBlockInMock, false)
+	require.NoError(t, err)
+	require.Len(t, requestedHashes, 1)
+	require.Empty(t, requestedHashes[0].Hash)
+}
@@ -0,0 +1,80 @@
+package chaintracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HealthPath = "/health"
+	ReadyPath  = "/ready"
+	// after this many consecutive failed polls the tracker is considered stuck in backoff and unhealthy
+	HealthMaxConsecutiveFetchFails = 5
+	// the tracker is not ready if it didn't fetch successfully for this many average block times
+	ReadyMaxBlocksSinceSuccessfulFetch = 10
+)
+
+type HealthStatus struct {
+	Healthy                      bool   `json:"healthy"`
+	Ready                        bool   `json:"ready"`
+	LatestBlock                  int64  `json:"latestBlock"`
+	TimeSinceLastSuccessfulFetch string `json:"timeSinceLastSuccessfulFetch"`
+	ConsecutiveFetchFails        uint64 `json:"consecutiveFetchFails"`
+	ConsecutiveFetchTimeouts     uint64 `json:"consecutiveFetchTimeouts"` // how many of the consecutive fails were the node not replying in time
+	Stale                        bool   `json:"stale"`                    // the node answers but doesn't progress, see IsStale
+}
+
+func (cs *ChainTracker) setLastSuccessfulFetch(fetchTime time.Time) {
+	atomic.StoreInt64(&cs.lastSuccessfulFetch, fetchTime.UnixNano())
+}
+
+func (cs *ChainTracker) timeSinceLastSuccessfulFetch() time.Duration {
+	lastFetch := atomic.LoadInt64(&cs.lastSuccessfulFetch)
+	if lastFetch == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, lastFetch))
+}
+
+// GetHealthStatus reports whether the tracker is polling successfully (healthy) and has fresh data to serve (ready)
+func (cs *ChainTracker) GetHealthStatus() HealthStatus {
+	latestBlock := cs.GetLatestBlockNum()
+	fetchFails := atomic.LoadUint64(&cs.consecutiveFetchFails)
+	sinceFetch := cs.timeSinceLastSuccessfulFetch()
+	healthy := fetchFails < HealthMaxConsecutiveFetchFails
+	ready := healthy && latestBlock > 0 && atomic.LoadInt64(&cs.lastSuccessfulFetch) != 0
+	if averageBlockTime := cs.getConfiguredBlockTime(); averageBlockTime > 0 && sinceFetch > averageBlockTime*ReadyMaxBlocksSinceSuccessfulFetch {
+		ready = false
+	}
+	return HealthStatus{
+		Healthy:                      healthy,
+		Ready:                        ready,
+		LatestBlock:                  latestBlock,
+		TimeSinceLastSuccessfulFetch: sinceFetch.String(),
+		ConsecutiveFetchFails:        fetchFails,
+		ConsecutiveFetchTimeouts:     atomic.LoadUint64(&cs.consecutiveFetchTimeouts),
+		Stale:                        cs.IsStale(),
+	}
+}
+
+func writeHealthStatus(resp http.ResponseWriter, status HealthStatus, ok bool) {
+	resp.Header().Set("Content-Type", "application/json")
+	if ok {
+		resp.WriteHeader(http.StatusOK)
+	} else {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(resp).Encode(status)
+}
+
+func (cs *ChainTracker) healthHandler(resp http.ResponseWriter, req *http.Request) {
+	status := cs.GetHealthStatus()
+	writeHealthStatus(resp, status, status.Healthy)
+}
+
+func (cs *ChainTracker) readyHandler(resp http.ResponseWriter, req *http.Request) {
+	status := cs.GetHealthStatus()
+	writeHealthStatus(resp, status, status.Ready)
+}
@@ -0,0 +1,57 @@
+package chaintracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerHealthEndpoints(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	getStatus := func(path string) (int, chaintracker.HealthStatus) {
+		var resp *http.Response
+		var err error
+		for attempt := 0; attempt < 20; attempt++ {
+			resp, err = http.Get("http://" + address + path)
+			if err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond) // server is still starting up
+		}
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		status := chaintracker.HealthStatus{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		return resp.StatusCode, status
+	}
+
+	code, status := getStatus(chaintracker.HealthPath)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, status.Healthy)
+	require.Equal(t, currentLatestBlockInMock, status.LatestBlock)
+	code, status = getStatus(chaintracker.ReadyPath)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, status.Ready)
+
+	// node stops responding, the tracker goes into backoff and reports unhealthy
+	mockChainFetcher.SetFailing(true)
+	require.Eventually(t, func() bool {
+		code, status = getStatus(chaintracker.HealthPath)
+		return code == http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+	require.GreaterOrEqual(t, status.ConsecutiveFetchFails, uint64(chaintracker.HealthMaxConsecutiveFetchFails))
+	code, _ = getStatus(chaintracker.ReadyPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+}
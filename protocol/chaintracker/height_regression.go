@@ -0,0 +1,77 @@
+package chaintracker
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var heightRegressionsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_chain_tracker_height_regressions_total",
+	Help: "The number of times the node reported a latest block lower than the tracked latest block",
+}, []string{"spec", "apiInterface"})
+
+func init() {
+	prometheus.MustRegister(heightRegressionsCounter)
+}
+
+// called when the node reports a lower latest block than the tracked one, a node rollback or a load balancer switching to a node that is behind.
+// the saved blocks are kept and the poll fails until the node catches up, unless it stays lower for heightRegressionResync polls and the saved blocks are rebuilt from its height
+func (cs *ChainTracker) onHeightRegression(ctx context.Context, nodeLatestBlock int64) error {
+	latestBlock := cs.GetLatestBlockNum()
+	polls := atomic.AddUint64(&cs.heightRegressionPolls, 1)
+	if polls == 1 {
+		heightRegressionsCounter.WithLabelValues(cs.endpoint.ChainID, cs.endpoint.ApiInterface).Inc()
+		utils.LavaFormatWarning("node reported a latest block lower than the tracked latest block", nil, utils.Attribute{Key: "latestBlock", Value: latestBlock},
+			utils.Attribute{Key: "nodeLatestBlock", Value: nodeLatestBlock}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+		if cs.heightRegressionCallback != nil {
+			cs.heightRegressionCallback(latestBlock, nodeLatestBlock)
+		}
+	}
+	if cs.heightRegressionResync == 0 || polls < cs.heightRegressionResync {
+		return ErrorHeightRegression.Wrapf("node latest block: %d, tracked latest block: %d, consecutive polls: %d", nodeLatestBlock, latestBlock, polls)
+	}
+	return cs.resyncFromHeight(ctx, nodeLatestBlock)
+}
+
+// the node is at or above the tracked latest block again
+func (cs *ChainTracker) endHeightRegression(nodeLatestBlock int64) {
+	if polls := atomic.SwapUint64(&cs.heightRegressionPolls, 0); polls > 0 {
+		utils.LavaFormatInfo("node caught up with the tracked latest block", utils.Attribute{Key: "nodeLatestBlock", Value: nodeLatestBlock}, utils.Attribute{Key: "regressedPolls", Value: polls}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	}
+}
+
+// rebuilds the saved blocks from the node's lower height, the blocks above it are dropped like blocks reorged out, so fork listeners are notified
+func (cs *ChainTracker) resyncFromHeight(ctx context.Context, nodeLatestBlock int64) error {
+	blocksToSave := int64(cs.getBlocksToSave())
+	hashes, errs := cs.fetchBlockHashesConcurrently(ctx, nodeLatestBlock, 0, blocksToSave)
+	fetchedBlocks := make([]BlockStore, 0, blocksToSave)
+	for idx := range hashes {
+		if errs[idx] != nil {
+			return utils.LavaFormatError("could not get block data to re-sync from the regressed height", errs[idx], utils.Attribute{Key: "block", Value: nodeLatestBlock - int64(idx)}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+		}
+		fetchedBlocks = append(fetchedBlocks, BlockStore{Block: nodeLatestBlock - int64(idx), Hash: hashes[idx]})
+	}
+	err := cs.verifyFetchedBlocks(ctx, fetchedBlocks)
+	if err != nil {
+		return err
+	}
+	cs.blockQueueMu.Lock()
+	previousLatestBlock := cs.GetLatestBlockNum()
+	resynced := newBlocksRing(uint64(cs.blocksQueue.Capacity()))
+	resynced.Update(nodeLatestBlock, fetchedBlocks)
+	cs.blocksQueue = resynced
+	cs.setLatestBlockNum(nodeLatestBlock)
+	atomic.StoreUint64(&cs.blockCheckpoint, uint64(nodeLatestBlock))
+	cs.pruneBlockBodiesUnsafe(nodeLatestBlock)
+	latestHash := cs.getLatestBlockUnsafe().Hash
+	cs.blockQueueMu.Unlock()
+
+	polls := atomic.SwapUint64(&cs.heightRegressionPolls, 0)
+	utils.LavaFormatWarning("chain tracker re-synced from the node's regressed height", nil, utils.Attribute{Key: "previousLatestBlock", Value: previousLatestBlock},
+		utils.Attribute{Key: "latestBlock", Value: nodeLatestBlock}, utils.Attribute{Key: "regressedPolls", Value: polls}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	cs.notifyForkListeners(nodeLatestBlock, latestHash)
+	return nil
+}
@@ -0,0 +1,105 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+// moves the node's latest block without changing its hashes, the mock keeps the hashes of the blocks above it
+func (mcf *MockChainFetcher) SetLatestBlock(latestBlock int64) {
+	mcf.mutex.Lock()
+	defer mcf.mutex.Unlock()
+	mcf.latestBlock = latestBlock
+}
+
+func TestChainTrackerHeightRegression(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := uint64(5)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	latestBlock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lock sync.Mutex
+	regressions := [][2]int64{}
+	heightRegressionCallback := func(latestBlock int64, nodeLatestBlock int64) {
+		lock.Lock()
+		defer lock.Unlock()
+		regressions = append(regressions, [2]int64{latestBlock, nodeLatestBlock})
+	}
+	getRegressions := func() [][2]int64 {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([][2]int64{}, regressions...)
+	}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: fetcherBlocks, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: uint64(mockBlocks), HeightRegressionCallback: heightRegressionCallback}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	// without re-sync the saved blocks are kept while the node is behind, and the callback is called once
+	mockChainFetcher.SetLatestBlock(latestBlock - 2)
+	require.Eventually(t, func() bool { return len(getRegressions()) > 0 }, time.Second, SleepTime)
+	time.Sleep(SleepTime * SleepChunks)
+	require.Equal(t, [][2]int64{{latestBlock, latestBlock - 2}}, getRegressions())
+	require.Equal(t, latestBlock, chainTracker.GetLatestBlockNum())
+
+	// the node caught up, a new regression calls the callback again
+	mockChainFetcher.SetLatestBlock(latestBlock)
+	newLatestBlock := mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == newLatestBlock }, time.Second, SleepTime)
+	mockChainFetcher.SetLatestBlock(newLatestBlock - 1)
+	require.Eventually(t, func() bool { return len(getRegressions()) == 2 }, time.Second, SleepTime)
+	require.Equal(t, newLatestBlock, chainTracker.GetLatestBlockNum())
+}
+
+func TestChainTrackerHeightRegressionResync(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := uint64(5)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	latestBlock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lock sync.Mutex
+	forks := []int64{}
+	forkCallback := func(block int64) {
+		lock.Lock()
+		defer lock.Unlock()
+		forks = append(forks, block)
+	}
+	getForks := func() []int64 {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]int64{}, forks...)
+	}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: fetcherBlocks, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: uint64(mockBlocks), ForkCallback: forkCallback, HeightRegressionResyncPolls: 3}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	// the node rolled back to another fork below the tracked latest block
+	regressedBlock := latestBlock - 3
+	mockChainFetcher.Fork("rollback")
+	mockChainFetcher.SetLatestBlock(regressedBlock)
+	require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == regressedBlock }, time.Second, SleepTime)
+	require.Equal(t, []int64{regressedBlock}, getForks())
+
+	_, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(fetcherBlocks)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Len(t, requestedHashes, int(fetcherBlocks))
+	for _, blockStore := range requestedHashes {
+		require.LessOrEqual(t, blockStore.Block, regressedBlock)
+		require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+	}
+
+	// tracking continues from the regressed height
+	newLatestBlock := mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool { return chainTracker.GetLatestBlockNum() == newLatestBlock }, time.Second, SleepTime)
+}
@@ -0,0 +1,15 @@
+package chaintracker
+
+import (
+	"math/rand"
+	"time"
+)
+
+// jitter randomly changes interval by up to pollingJitter of it in either direction, so trackers sharing a node don't poll it in sync
+func (cs *ChainTracker) jitter(interval time.Duration) time.Duration {
+	maxJitter := int64(float64(interval) * cs.pollingJitter)
+	if maxJitter <= 0 {
+		return interval
+	}
+	return interval - time.Duration(maxJitter) + time.Duration(rand.Int63n(2*maxJitter+1))
+}
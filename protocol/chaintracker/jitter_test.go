@@ -0,0 +1,75 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+// records when the node was polled for the latest block
+type PollRecordingChainFetcher struct {
+	*MockChainFetcher
+	lock  sync.Mutex
+	polls []time.Time
+}
+
+func (prcf *PollRecordingChainFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	prcf.lock.Lock()
+	prcf.polls = append(prcf.polls, time.Now())
+	prcf.lock.Unlock()
+	return prcf.MockChainFetcher.FetchLatestBlockNum(ctx)
+}
+
+func (prcf *PollRecordingChainFetcher) PollIntervals() []time.Duration {
+	prcf.lock.Lock()
+	defer prcf.lock.Unlock()
+	intervals := []time.Duration{}
+	for idx := 1; idx < len(prcf.polls); idx++ {
+		intervals = append(intervals, prcf.polls[idx].Sub(prcf.polls[idx-1]))
+	}
+	return intervals
+}
+
+func TestChainTrackerPollingJitter(t *testing.T) {
+	mockChainFetcher := &PollRecordingChainFetcher{MockChainFetcher: NewMockChainFetcher(1000, 20)}
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	averageBlockTime := 100 * time.Millisecond
+	pollingTime := averageBlockTime / 10
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: averageBlockTime, ServerBlockMemory: 20, PollingJitter: 1}
+	_, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.ErrorIs(t, err, chaintracker.InvalidConfigPollingJitter)
+
+	chainTrackerConfig.PollingJitter = 0.5
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	// without new blocks the node is polled every polling time, changed by up to half of it
+	require.Eventually(t, func() bool {
+		return len(mockChainFetcher.PollIntervals()) >= 40
+	}, 2*time.Second, pollingTime)
+	intervals := mockChainFetcher.PollIntervals()
+	shortest, longest := intervals[0], intervals[0]
+	for _, interval := range intervals {
+		if interval < shortest {
+			shortest = interval
+		}
+		if interval > longest {
+			longest = interval
+		}
+	}
+	require.Less(t, shortest, pollingTime*8/10)
+	require.Greater(t, longest, pollingTime*12/10)
+
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool {
+		return chainTracker.GetLatestBlockNum() == currentLatestBlockInMock
+	}, time.Second, pollingTime)
+}
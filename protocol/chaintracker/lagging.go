@@ -0,0 +1,83 @@
+package chaintracker
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/lavanet/lava/utils"
+	grpc "google.golang.org/grpc"
+)
+
+const (
+	DefaultReferenceCheckInterval   = 10 // polls between reference checks
+	DefaultMaxBlocksBehindReference = 5
+)
+
+// ReferenceFetcher is a secondary source of the latest block used to detect the node falling behind, any ChainFetcher can be used as one
+type ReferenceFetcher interface {
+	FetchLatestBlockNum(ctx context.Context) (int64, error)
+}
+
+// RemoteChainTrackerReferenceFetcher uses a chain tracker served by another process as the reference
+type RemoteChainTrackerReferenceFetcher struct {
+	client ChainTrackerServiceClient
+}
+
+func NewRemoteChainTrackerReferenceFetcher(conn grpc.ClientConnInterface) *RemoteChainTrackerReferenceFetcher {
+	return &RemoteChainTrackerReferenceFetcher{client: NewChainTrackerServiceClient(conn)}
+}
+
+func (rcrf *RemoteChainTrackerReferenceFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	reply, err := rcrf.client.GetLatestBlockNum(ctx, &empty.Empty{})
+	if err != nil {
+		return 0, err
+	}
+	return int64(reply.GetValue()), nil
+}
+
+// IsLagging returns true if the last reference check found the node behind the reference
+func (cs *ChainTracker) IsLagging() bool {
+	return atomic.LoadUint32(&cs.lagging) == 1
+}
+
+// called from the polling routine, only one reference check runs at a time so a slow reference doesn't delay polling
+func (cs *ChainTracker) checkReferenceIfNecessary(ctx context.Context, polls uint64) {
+	if cs.referenceFetcher == nil || polls%cs.referenceCheckInterval != 0 {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&cs.referenceCheckRunning, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreUint32(&cs.referenceCheckRunning, 0)
+		cs.checkReference(ctx)
+	}()
+}
+
+func (cs *ChainTracker) checkReference(ctx context.Context) {
+	fetchCtx, cancel := context.WithTimeout(ctx, cs.latestBlockFetchTimeout)
+	defer cancel()
+	referenceBlock, err := cs.referenceFetcher.FetchLatestBlockNum(fetchCtx)
+	err = fetchTimeoutError(ctx, fetchCtx, err, cs.latestBlockFetchTimeout)
+	if err != nil {
+		utils.LavaFormatWarning("failed fetching latest block from reference, can't tell if the node is lagging", err, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+		return
+	}
+	latestBlock := cs.GetLatestBlockNum()
+	lagging := referenceBlock-latestBlock > int64(cs.maxBlocksBehindReference)
+	wasLagging := cs.IsLagging()
+	if lagging == wasLagging {
+		return
+	}
+	if lagging {
+		atomic.StoreUint32(&cs.lagging, 1)
+		utils.LavaFormatWarning("node is lagging behind the reference", nil, utils.Attribute{Key: "endpoint", Value: cs.endpoint}, utils.Attribute{Key: "latestBlock", Value: latestBlock}, utils.Attribute{Key: "referenceBlock", Value: referenceBlock})
+	} else {
+		atomic.StoreUint32(&cs.lagging, 0)
+		utils.LavaFormatInfo("node caught up with the reference", utils.Attribute{Key: "endpoint", Value: cs.endpoint}, utils.Attribute{Key: "latestBlock", Value: latestBlock}, utils.Attribute{Key: "referenceBlock", Value: referenceBlock})
+	}
+	if cs.laggingNodeCallback != nil {
+		cs.laggingNodeCallback(lagging, latestBlock, referenceBlock)
+	}
+}
@@ -0,0 +1,70 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerLaggingNodeCallback(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	referenceFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+	referenceFetcher.AdvanceBlock()
+
+	var lock sync.Mutex
+	laggingCalls := []bool{}
+	laggingNodeCallback := func(lagging bool, latestBlock int64, referenceBlock int64) {
+		lock.Lock()
+		defer lock.Unlock()
+		laggingCalls = append(laggingCalls, lagging)
+	}
+	lastCall := func() (calls int, lagging bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(laggingCalls) == 0 {
+			return 0, false
+		}
+		return len(laggingCalls), laggingCalls[len(laggingCalls)-1]
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ReferenceFetcher: referenceFetcher, ReferenceCheckInterval: 2, MaxBlocksBehindReference: 3, LaggingNodeCallback: laggingNodeCallback}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+
+	// within the allowed distance nothing is reported
+	for i := 0; i < 3; i++ {
+		referenceFetcher.AdvanceBlock()
+	}
+	time.Sleep(SleepTime * SleepChunks)
+	calls, _ := lastCall()
+	require.Zero(t, calls)
+	require.False(t, chainTracker.IsLagging())
+
+	// the reference moves on while the node is stuck
+	for i := 0; i < 5; i++ {
+		referenceFetcher.AdvanceBlock()
+	}
+	require.Eventually(t, func() bool {
+		calls, lagging := lastCall()
+		return calls == 1 && lagging
+	}, time.Second, TimeForPollingMock)
+	require.True(t, chainTracker.IsLagging())
+
+	// the node catches up
+	for i := 0; i < 8; i++ {
+		mockChainFetcher.AdvanceBlock()
+	}
+	require.Eventually(t, func() bool {
+		calls, lagging := lastCall()
+		return calls == 2 && !lagging
+	}, time.Second, TimeForPollingMock)
+	require.False(t, chainTracker.IsLagging())
+}
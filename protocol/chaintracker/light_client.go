@@ -0,0 +1,90 @@
+package chaintracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/utils"
+	"github.com/tendermint/tendermint/light"
+	lightdb "github.com/tendermint/tendermint/light/store/db"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// BlockHashVerifier checks a block hash read from the node before the chain tracker stores it
+type BlockHashVerifier interface {
+	VerifyBlockHash(ctx context.Context, block int64, hash string) error
+}
+
+// TendermintLightVerifier verifies block hashes against headers verified by a tendermint light client,
+// so a compromised node can't feed hashes that weren't signed by the chain's validator set
+type TendermintLightVerifier struct {
+	lock   sync.Mutex // the light client isn't safe for concurrent verification
+	client *light.Client
+}
+
+// NewTendermintLightVerifier connects to the primary and witnesses and verifies the trusted header
+func NewTendermintLightVerifier(ctx context.Context, config *common.LightClientConfig) (*TendermintLightVerifier, error) {
+	if config == nil || config.Primary == "" || len(config.Witnesses) == 0 {
+		return nil, InvalidConfigLightClient.Wrapf("a primary and at least one witness are required")
+	}
+	trustedHash, err := hex.DecodeString(strings.TrimPrefix(config.TrustedHash, "0x"))
+	if err != nil {
+		return nil, InvalidConfigLightClient.Wrapf("trusted hash %s is not hex: %s", config.TrustedHash, err)
+	}
+	trustOptions := light.TrustOptions{Period: config.TrustingPeriod, Height: config.TrustedHeight, Hash: trustedHash}
+	if err := trustOptions.ValidateBasic(); err != nil {
+		return nil, InvalidConfigLightClient.Wrapf("%s", err)
+	}
+	client, err := light.NewHTTPClient(ctx, config.ChainID, trustOptions, config.Primary, config.Witnesses, lightdb.New(dbm.NewMemDB(), config.ChainID))
+	if err != nil {
+		return nil, utils.LavaFormatError("failed creating light client", err, utils.Attribute{Key: "chainID", Value: config.ChainID}, utils.Attribute{Key: "primary", Value: config.Primary})
+	}
+	return &TendermintLightVerifier{client: client}, nil
+}
+
+// VerifyBlockHash accepts hex hashes with or without 0x and base64 hashes, as nodes return them in either
+func (tlv *TendermintLightVerifier) VerifyBlockHash(ctx context.Context, block int64, hash string) error {
+	hashBytes, err := decodeBlockHash(hash)
+	if err != nil {
+		return ErrorBlockHashVerification.Wrapf("block %d hash %s can't be decoded: %s", block, hash, err)
+	}
+	tlv.lock.Lock()
+	lightBlock, err := tlv.client.VerifyLightBlockAtHeight(ctx, block, time.Now())
+	tlv.lock.Unlock()
+	if err != nil {
+		return ErrorBlockHashVerification.Wrapf("block %d header failed light client verification: %s", block, err)
+	}
+	if !bytes.Equal(lightBlock.Hash(), hashBytes) {
+		return ErrorBlockHashVerification.Wrapf("block %d hash %s doesn't match the verified header hash %s", block, hash, lightBlock.Hash())
+	}
+	return nil
+}
+
+func decodeBlockHash(hash string) ([]byte, error) {
+	hexHash := strings.TrimPrefix(strings.TrimPrefix(hash, "0x"), "0X")
+	hashBytes, err := hex.DecodeString(hexHash)
+	if err == nil {
+		return hashBytes, nil
+	}
+	return base64.StdEncoding.DecodeString(hash)
+}
+
+// verifies the fetched blocks before they are stored, hashless trackers have no hashes to verify
+func (cs *ChainTracker) verifyFetchedBlocks(ctx context.Context, fetchedBlocks []BlockStore) error {
+	if cs.hashVerifier == nil || cs.hashless {
+		return nil
+	}
+	for _, fetchedBlock := range fetchedBlocks {
+		err := cs.hashVerifier.VerifyBlockHash(ctx, fetchedBlock.Block, fetchedBlock.Hash)
+		if err != nil {
+			return utils.LavaFormatError("fetched block hash failed verification, not storing it", err, utils.Attribute{Key: "block", Value: fetchedBlock.Block}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID}, utils.Attribute{Key: "ApiInterface", Value: cs.endpoint.ApiInterface})
+		}
+	}
+	return nil
+}
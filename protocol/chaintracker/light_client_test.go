@@ -0,0 +1,74 @@
+package chaintracker_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/stretchr/testify/require"
+)
+
+type mockHashVerifier struct {
+	lock     sync.Mutex
+	rejected map[int64]bool
+	verified []int64
+}
+
+func (mhv *mockHashVerifier) VerifyBlockHash(ctx context.Context, block int64, hash string) error {
+	mhv.lock.Lock()
+	defer mhv.lock.Unlock()
+	if mhv.rejected[block] {
+		return fmt.Errorf("bogus hash %s for block %d", hash, block)
+	}
+	mhv.verified = append(mhv.verified, block)
+	return nil
+}
+
+func (mhv *mockHashVerifier) setRejected(block int64, rejected bool) {
+	mhv.lock.Lock()
+	defer mhv.lock.Unlock()
+	mhv.rejected[block] = rejected
+}
+
+func TestChainTrackerHashVerifier(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 20)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	verifier := &mockHashVerifier{rejected: map[int64]bool{}}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 20, HashVerifier: verifier}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+	// every block of the initial window was verified
+	verifier.lock.Lock()
+	require.Len(t, verifier.verified, 5)
+	verifier.lock.Unlock()
+
+	// a block failing verification isn't stored, the tracker keeps its verified blocks
+	verifiedLatest := currentLatestBlockInMock
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	verifier.setRejected(currentLatestBlockInMock, true)
+	time.Sleep(10 * TimeForPollingMock)
+	require.Equal(t, verifiedLatest, chainTracker.GetLatestBlockNum())
+
+	verifier.setRejected(currentLatestBlockInMock, false)
+	waitForBlock(t, chainTracker, currentLatestBlockInMock)
+}
+
+func TestTendermintLightVerifierConfig(t *testing.T) {
+	ctx := context.Background()
+	_, err := chaintracker.NewTendermintLightVerifier(ctx, nil)
+	require.Error(t, err)
+	lightClientConfig := &common.LightClientConfig{ChainID: "chain", TrustedHeight: 1, TrustedHash: "not-hex", TrustingPeriod: time.Hour, Primary: "http://127.0.0.1:1", Witnesses: []string{"http://127.0.0.1:2"}}
+	_, err = chaintracker.NewTendermintLightVerifier(ctx, lightClientConfig)
+	require.ErrorIs(t, err, chaintracker.InvalidConfigLightClient)
+	lightClientConfig.TrustedHash = "0xABCD"
+	_, err = chaintracker.NewTendermintLightVerifier(ctx, lightClientConfig)
+	require.ErrorIs(t, err, chaintracker.InvalidConfigLightClient) // too short for a header hash
+	lightClientConfig.Witnesses = nil
+	_, err = chaintracker.NewTendermintLightVerifier(ctx, lightClientConfig)
+	require.ErrorIs(t, err, chaintracker.InvalidConfigLightClient)
+}
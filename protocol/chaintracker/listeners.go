@@ -0,0 +1,154 @@
+package chaintracker
+
+// BlockListener is called for every new latest block, in order, on a catch up of several blocks it is called for each of them
+type BlockListener func(block int64, hash string)
+
+// ForkListener is called with the latest block when a fork is detected
+type ForkListener func(block int64)
+
+// BlockListenerFilter limits which blocks a listener is notified of, the zero value notifies every block
+type BlockListenerFilter struct {
+	EveryNBlocks    uint64 // only blocks that are a multiple of it are notified
+	EveryCheckpoint bool   // same as EveryNBlocks with the tracker's block checkpoint distance
+	ForksOnly       bool   // only the latest block is notified, when a fork is detected
+	FinalizedOnly   bool   // blocks are notified with their own hash once they are the finalization distance behind the latest
+}
+
+func (blf BlockListenerFilter) validate() error {
+	if blf.ForksOnly && (blf.EveryNBlocks > 0 || blf.EveryCheckpoint || blf.FinalizedOnly) {
+		return InvalidBlockListenerFilter
+	}
+	if blf.EveryNBlocks > 0 && blf.EveryCheckpoint {
+		return InvalidBlockListenerFilter
+	}
+	return nil
+}
+
+type blockListenerEntry struct {
+	id       uint64
+	listener BlockListener
+	filter   BlockListenerFilter
+}
+
+type forkListenerEntry struct {
+	id       uint64
+	listener ForkListener
+}
+
+// RegisterBlockListener adds a listener for new blocks, the returned id is used to unregister it
+// listeners are called from the polling routine so they must not block
+func (cs *ChainTracker) RegisterBlockListener(listener BlockListener) (id uint64) {
+	cs.listenersMu.Lock()
+	defer cs.listenersMu.Unlock()
+	cs.nextListenerID++
+	cs.blockListeners = append(cs.blockListeners, blockListenerEntry{id: cs.nextListenerID, listener: listener})
+	return cs.nextListenerID
+}
+
+// RegisterFilteredBlockListener adds a listener notified only of the blocks passing the filter, it is unregistered with UnregisterBlockListener
+func (cs *ChainTracker) RegisterFilteredBlockListener(listener BlockListener, filter BlockListenerFilter) (id uint64, err error) {
+	err = filter.validate()
+	if err != nil {
+		return 0, err
+	}
+	cs.listenersMu.Lock()
+	defer cs.listenersMu.Unlock()
+	cs.nextListenerID++
+	cs.blockListeners = append(cs.blockListeners, blockListenerEntry{id: cs.nextListenerID, listener: listener, filter: filter})
+	return cs.nextListenerID, nil
+}
+
+// UnregisterBlockListener returns false if no listener is registered with the id
+func (cs *ChainTracker) UnregisterBlockListener(id uint64) bool {
+	cs.listenersMu.Lock()
+	defer cs.listenersMu.Unlock()
+	for idx, entry := range cs.blockListeners {
+		if entry.id == id {
+			cs.blockListeners = append(cs.blockListeners[:idx:idx], cs.blockListeners[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterForkListener adds a listener for forks, the returned id is used to unregister it
+// listeners are called from the polling routine so they must not block
+func (cs *ChainTracker) RegisterForkListener(listener ForkListener) (id uint64) {
+	cs.listenersMu.Lock()
+	defer cs.listenersMu.Unlock()
+	cs.nextListenerID++
+	cs.forkListeners = append(cs.forkListeners, forkListenerEntry{id: cs.nextListenerID, listener: listener})
+	return cs.nextListenerID
+}
+
+// UnregisterForkListener returns false if no listener is registered with the id
+func (cs *ChainTracker) UnregisterForkListener(id uint64) bool {
+	cs.listenersMu.Lock()
+	defer cs.listenersMu.Unlock()
+	for idx, entry := range cs.forkListeners {
+		if entry.id == id {
+			cs.forkListeners = append(cs.forkListeners[:idx:idx], cs.forkListeners[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// listeners are called without holding the lock, so they can register and unregister listeners themselves
+func (cs *ChainTracker) notifyBlockListeners(block int64, hash string) {
+	cs.listenersMu.RLock()
+	listeners := cs.blockListeners
+	cs.listenersMu.RUnlock()
+	finalizedLookedUp := false
+	var finalizedBlock BlockStore
+	var finalizedFound bool
+	for _, entry := range listeners {
+		if entry.filter.ForksOnly {
+			continue
+		}
+		notifiedBlock, notifiedHash := block, hash
+		if entry.filter.FinalizedOnly {
+			if !finalizedLookedUp {
+				finalizedBlock, finalizedFound = cs.getBlockStore(block - int64(cs.getFinalizationDistance()))
+				finalizedLookedUp = true
+			}
+			if !finalizedFound {
+				continue
+			}
+			notifiedBlock, notifiedHash = finalizedBlock.Block, finalizedBlock.Hash
+		}
+		everyNBlocks := entry.filter.EveryNBlocks
+		if entry.filter.EveryCheckpoint {
+			everyNBlocks = cs.getBlockCheckpointDistance()
+		}
+		if everyNBlocks > 1 && notifiedBlock%int64(everyNBlocks) != 0 {
+			continue
+		}
+		entry.listener(notifiedBlock, notifiedHash)
+	}
+}
+
+func (cs *ChainTracker) notifyForkListeners(block int64, hash string) {
+	cs.listenersMu.RLock()
+	listeners := cs.forkListeners
+	blockListeners := cs.blockListeners
+	cs.listenersMu.RUnlock()
+	for _, entry := range listeners {
+		entry.listener(block)
+	}
+	for _, entry := range blockListeners {
+		if entry.filter.ForksOnly {
+			entry.listener(block, hash)
+		}
+	}
+}
+
+// returns the saved block at height, false if it isn't in the window
+func (cs *ChainTracker) getBlockStore(height int64) (BlockStore, bool) {
+	if height < 0 {
+		return BlockStore{}, false
+	}
+	cs.blockQueueMu.RLock()
+	defer cs.blockQueueMu.RUnlock()
+	return cs.blocksQueue.Get(height)
+}
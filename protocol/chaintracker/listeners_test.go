@@ -0,0 +1,169 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+type listenerCalls struct {
+	lock   sync.Mutex
+	blocks map[string][]int64
+	forks  map[string]int
+}
+
+func (lc *listenerCalls) blockListener(name string) chaintracker.BlockListener {
+	return func(block int64, hash string) {
+		lc.lock.Lock()
+		defer lc.lock.Unlock()
+		lc.blocks[name] = append(lc.blocks[name], block)
+	}
+}
+
+func (lc *listenerCalls) forkListener(name string) chaintracker.ForkListener {
+	return func(block int64) {
+		lc.lock.Lock()
+		defer lc.lock.Unlock()
+		lc.forks[name]++
+	}
+}
+
+func (lc *listenerCalls) get(name string) (blocks []int64, forks int) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	return append([]int64{}, lc.blocks[name]...), lc.forks[name]
+}
+
+func waitForBlock(t *testing.T, chainTracker *chaintracker.ChainTracker, block int64) {
+	for sleepChunk := 0; sleepChunk < SleepChunks*10 && chainTracker.GetLatestBlockNum() < block; sleepChunk++ {
+		time.Sleep(SleepTime) // stateTracker polls asynchronously
+	}
+	require.Equal(t, block, chainTracker.GetLatestBlockNum())
+}
+
+func TestChainTrackerListeners(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 20)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	calls := &listenerCalls{blocks: map[string][]int64{}, forks: map[string]int{}}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 10, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 20, NewLatestCallback: calls.blockListener("config"), ForkCallback: calls.forkListener("config")}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+
+	firstBlockListener := chainTracker.RegisterBlockListener(calls.blockListener("first"))
+	chainTracker.RegisterBlockListener(calls.blockListener("second"))
+	forkListener := chainTracker.RegisterForkListener(calls.forkListener("first"))
+
+	// a catch up of several blocks calls every listener for each block
+	mockChainFetcher.AdvanceBlock()
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	for _, name := range []string{"config", "first", "second"} {
+		require.Eventually(t, func() bool {
+			blocks, _ := calls.get(name)
+			return len(blocks) == 2 && blocks[0] == currentLatestBlockInMock-1 && blocks[1] == currentLatestBlockInMock
+		}, time.Second, SleepTime, name)
+	}
+
+	// unregistered listeners are not called anymore
+	require.True(t, chainTracker.UnregisterBlockListener(firstBlockListener))
+	require.False(t, chainTracker.UnregisterBlockListener(firstBlockListener))
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	require.Eventually(t, func() bool {
+		blocks, _ := calls.get("second")
+		return len(blocks) == 3
+	}, time.Second, SleepTime)
+	blocks, _ := calls.get("first")
+	require.NotContains(t, blocks, currentLatestBlockInMock)
+
+	// forks
+	mockChainFetcher.Fork("fork")
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	require.Eventually(t, func() bool {
+		_, firstForks := calls.get("first")
+		_, configForks := calls.get("config")
+		return firstForks == 1 && configForks == 1
+	}, time.Second, SleepTime)
+	require.True(t, chainTracker.UnregisterForkListener(forkListener))
+	mockChainFetcher.Fork("another-fork")
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	require.Eventually(t, func() bool {
+		_, configForks := calls.get("config")
+		return configForks == 2
+	}, time.Second, SleepTime)
+	_, forks := calls.get("first")
+	require.Equal(t, 1, forks)
+}
+
+func TestChainTrackerFilteredListeners(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 20)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	calls := &listenerCalls{blocks: map[string][]int64{}, forks: map[string]int{}}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 10, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 20, FinalizationDistance: 3}
+	chainTracker, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(context.Background())
+
+	_, err = chainTracker.RegisterFilteredBlockListener(calls.blockListener("invalid"), chaintracker.BlockListenerFilter{ForksOnly: true, EveryNBlocks: 2})
+	require.Error(t, err)
+	_, err = chainTracker.RegisterFilteredBlockListener(calls.blockListener("everyTwo"), chaintracker.BlockListenerFilter{EveryNBlocks: 2})
+	require.NoError(t, err)
+	_, err = chainTracker.RegisterFilteredBlockListener(calls.blockListener("forks"), chaintracker.BlockListenerFilter{ForksOnly: true})
+	require.NoError(t, err)
+	finalizedHashes := map[int64]string{}
+	hashesLock := sync.Mutex{}
+	_, err = chainTracker.RegisterFilteredBlockListener(func(block int64, hash string) {
+		hashesLock.Lock()
+		defer hashesLock.Unlock()
+		finalizedHashes[block] = hash
+	}, chaintracker.BlockListenerFilter{FinalizedOnly: true})
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+		waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	}
+	require.Eventually(t, func() bool {
+		blocks, _ := calls.get("everyTwo")
+		return len(blocks) == 2
+	}, time.Second, SleepTime)
+	blocks, _ := calls.get("everyTwo")
+	for _, block := range blocks {
+		require.Zero(t, block%2)
+	}
+	blocks, _ = calls.get("forks")
+	require.Empty(t, blocks)
+
+	// finalized blocks are notified with their own hash, the finalization distance behind the latest
+	require.Eventually(t, func() bool {
+		hashesLock.Lock()
+		defer hashesLock.Unlock()
+		_, ok := finalizedHashes[currentLatestBlockInMock-3]
+		return ok
+	}, time.Second, SleepTime)
+	hashesLock.Lock()
+	require.NotContains(t, finalizedHashes, currentLatestBlockInMock-2)
+	for block, hash := range finalizedHashes {
+		_, hashes, err := chainTracker.GetLatestBlockData(spectypes.NOT_APPLICABLE, spectypes.NOT_APPLICABLE, block, false)
+		require.NoError(t, err)
+		require.Equal(t, hashes[0].Hash, hash)
+	}
+	hashesLock.Unlock()
+
+	// forks only listeners get the latest block on a fork
+	mockChainFetcher.Fork("fork")
+	currentLatestBlockInMock = mockChainFetcher.AdvanceBlock()
+	waitForBlock(t, chainTracker, currentLatestBlockInMock)
+	require.Eventually(t, func() bool {
+		blocks, _ := calls.get("forks")
+		return len(blocks) == 1 && blocks[0] == currentLatestBlockInMock
+	}, time.Second, SleepTime)
+}
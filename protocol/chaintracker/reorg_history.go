@@ -0,0 +1,42 @@
+package chaintracker
+
+import (
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	DefaultReorgHistorySize = 100
+)
+
+// records a reorg if the update replaced any saved hash, replaced is ordered from the lowest height. blockQueueMu must be locked
+func (cs *ChainTracker) recordReorgIfChangedUnsafe(replaced []replacedBlock) {
+	if len(replaced) == 0 {
+		return
+	}
+	reorg := &ReorgEvent{Height: replaced[0].Block, OldHash: replaced[0].OldHash, NewHash: replaced[0].NewHash, Depth: int64(len(replaced)), DetectionTime: time.Now().UnixMilli()}
+	utils.LavaFormatInfo("chain tracker detected a reorg", utils.Attribute{Key: "height", Value: reorg.Height}, utils.Attribute{Key: "depth", Value: reorg.Depth}, utils.Attribute{Key: "oldHash", Value: reorg.OldHash}, utils.Attribute{Key: "newHash", Value: reorg.NewHash}, utils.Attribute{Key: "ChainID", Value: cs.endpoint.ChainID})
+	cs.reorgHistoryMu.Lock()
+	defer cs.reorgHistoryMu.Unlock()
+	cs.reorgHistory = append(cs.reorgHistory, reorg)
+	if uint64(len(cs.reorgHistory)) > cs.reorgHistorySize {
+		cs.reorgHistory = cs.reorgHistory[uint64(len(cs.reorgHistory))-cs.reorgHistorySize:]
+	}
+}
+
+// GetReorgHistory returns up to limit of the latest detected reorgs ordered from oldest to newest, a limit of 0 returns all saved reorgs
+func (cs *ChainTracker) GetReorgHistory(limit uint64) []*ReorgEvent {
+	cs.reorgHistoryMu.RLock()
+	defer cs.reorgHistoryMu.RUnlock()
+	start := 0
+	if limit > 0 && uint64(len(cs.reorgHistory)) > limit {
+		start = len(cs.reorgHistory) - int(limit)
+	}
+	reorgs := make([]*ReorgEvent, 0, len(cs.reorgHistory)-start)
+	for _, reorg := range cs.reorgHistory[start:] {
+		reorgCopy := *reorg
+		reorgs = append(reorgs, &reorgCopy)
+	}
+	return reorgs
+}
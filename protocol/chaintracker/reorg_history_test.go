@@ -0,0 +1,52 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerReorgHistory(t *testing.T) {
+	mockBlocks := int64(100)
+	fetcherBlocks := uint64(10)
+	mockChainFetcher := NewMockChainFetcher(1000, mockBlocks)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: fetcherBlocks, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: uint64(mockBlocks), ReorgHistorySize: 2}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+	require.Empty(t, chainTracker.GetReorgHistory(0))
+
+	// every fork replaces all saved hashes, history is bounded to the latest 2
+	forks := []string{"fork", "another-fork", ""}
+	for _, fork := range forks {
+		mockChainFetcher.Fork(fork)
+		require.Eventually(t, func() bool {
+			_, requestedHashes, err := chainTracker.GetLatestBlockData(currentLatestBlockInMock, currentLatestBlockInMock, -1, false)
+			return err == nil && len(requestedHashes) == 1 && mockChainFetcher.IsCorrectHash(requestedHashes[0].Hash, currentLatestBlockInMock)
+		}, time.Second, TimeForPollingMock)
+	}
+	reorgs := chainTracker.GetReorgHistory(0)
+	require.Len(t, reorgs, 2)
+	for idx, reorg := range reorgs {
+		require.Equal(t, currentLatestBlockInMock-int64(fetcherBlocks)+1, reorg.Height)
+		require.Equal(t, int64(fetcherBlocks), reorg.Depth)
+		require.NotEqual(t, reorg.OldHash, reorg.NewHash)
+		require.NotZero(t, reorg.DetectionTime)
+		if idx > 0 {
+			require.Equal(t, reorgs[idx-1].NewHash, reorg.OldHash)
+		}
+	}
+	require.True(t, mockChainFetcher.IsCorrectHash(reorgs[1].NewHash, reorgs[1].Height)) // the last fork is the current chain
+
+	chainTrackerService := chaintracker.ChainTrackerService{ChainTracker: chainTracker}
+	reply, err := chainTrackerService.GetReorgHistory(ctx, &chaintracker.ReorgHistoryRequest{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, reply.Reorgs, 1)
+	require.Equal(t, reorgs[1].NewHash, reply.Reorgs[0].NewHash)
+}
@@ -0,0 +1,271 @@
+package chaintracker_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	btcSecp256k1 "github.com/btcsuite/btcd/btcec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+type SimulatedReorg struct {
+	LatestBlock int64 // the block whose arrival replaced the previous ones
+	Depth       int64 // how many blocks below LatestBlock were replaced
+}
+
+// ReorgSimulationFetcher is a chain agnostic ChainFetcher that generates a deterministic chain from a seed,
+// every reorgInterval new blocks the reorgDepth blocks below the new one are replaced, a zero interval never reorgs
+type ReorgSimulationFetcher struct {
+	lock          sync.RWMutex
+	seed          string
+	firstBlock    int64
+	latestBlock   int64
+	produced      int64 // blocks produced since the fetcher was created, the reorg schedule counts them
+	reorgInterval int64
+	reorgDepth    int64
+	branches      map[int64]int64 // how many times each block was replaced, key == block
+	reorgs        []SimulatedReorg
+}
+
+func NewReorgSimulationFetcher(seed string, firstBlock int64, latestBlock int64, reorgInterval int64, reorgDepth int64) *ReorgSimulationFetcher {
+	return &ReorgSimulationFetcher{seed: seed, firstBlock: firstBlock, latestBlock: latestBlock, reorgInterval: reorgInterval, reorgDepth: reorgDepth, branches: map[int64]int64{}}
+}
+
+func (rsf *ReorgSimulationFetcher) FetchEndpoint() lavasession.RPCProviderEndpoint {
+	return lavasession.RPCProviderEndpoint{ChainID: "reorg-simulation-" + rsf.seed}
+}
+
+func (rsf *ReorgSimulationFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	rsf.lock.RLock()
+	defer rsf.lock.RUnlock()
+	return rsf.latestBlock, nil
+}
+
+func (rsf *ReorgSimulationFetcher) FetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
+	rsf.lock.RLock()
+	defer rsf.lock.RUnlock()
+	if blockNum < rsf.firstBlock || blockNum > rsf.latestBlock {
+		return "", fmt.Errorf("invalid block num requested %d, simulated chain is %d-%d", blockNum, rsf.firstBlock, rsf.latestBlock)
+	}
+	return rsf.hashUnsafe(blockNum), nil
+}
+
+// must be called with the lock locked
+func (rsf *ReorgSimulationFetcher) hashUnsafe(blockNum int64) string {
+	hash := sha256.Sum256([]byte(rsf.seed + "-" + strconv.FormatInt(blockNum, 10) + "-" + strconv.FormatInt(rsf.branches[blockNum], 10)))
+	return hex.EncodeToString(hash[:])
+}
+
+// Hash returns the hash the chain currently has for blockNum
+func (rsf *ReorgSimulationFetcher) Hash(blockNum int64) string {
+	rsf.lock.RLock()
+	defer rsf.lock.RUnlock()
+	return rsf.hashUnsafe(blockNum)
+}
+
+// AdvanceBlock produces a new block and applies the scheduled reorg if it is due
+func (rsf *ReorgSimulationFetcher) AdvanceBlock() (latestBlock int64, reorged bool) {
+	rsf.lock.Lock()
+	defer rsf.lock.Unlock()
+	rsf.latestBlock++
+	rsf.produced++
+	if rsf.reorgInterval == 0 || rsf.produced%rsf.reorgInterval != 0 {
+		return rsf.latestBlock, false
+	}
+	depth := int64(0)
+	for block := rsf.latestBlock - rsf.reorgDepth; block < rsf.latestBlock; block++ {
+		if block < rsf.firstBlock {
+			continue
+		}
+		rsf.branches[block]++
+		depth++
+	}
+	rsf.reorgs = append(rsf.reorgs, SimulatedReorg{LatestBlock: rsf.latestBlock, Depth: depth})
+	return rsf.latestBlock, true
+}
+
+func (rsf *ReorgSimulationFetcher) Reorgs() []SimulatedReorg {
+	rsf.lock.RLock()
+	defer rsf.lock.RUnlock()
+	return append([]SimulatedReorg{}, rsf.reorgs...)
+}
+
+// finalizationProver signs finalization proofs from a chain tracker the way the provider does and verifies them the way the consumer does
+type finalizationProver struct {
+	consumerKey      *btcSecp256k1.PrivateKey
+	consumerAddress  sdk.AccAddress
+	providerKey      *btcSecp256k1.PrivateKey
+	providerAddress  sdk.AccAddress
+	latestProofBlock int64
+}
+
+func newFinalizationProver() *finalizationProver {
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	return &finalizationProver{consumerKey: consumerKey, consumerAddress: consumerAddress, providerKey: providerKey, providerAddress: providerAddress}
+}
+
+// prove returns the finalized blocks hashes of a signed relay reply after the consumer verified them
+func (fp *finalizationProver) prove(t *testing.T, chainTracker *chaintracker.ChainTracker, finalizationDistance uint64, blocksInFinalizationData uint64) (latestBlock int64, finalizedBlocks map[int64]string) {
+	ctx := context.Background()
+	toBlock := spectypes.LATEST_BLOCK - int64(finalizationDistance)
+	fromBlock := toBlock - int64(blocksInFinalizationData) + 1
+	latestBlock, requestedHashes, err := chainTracker.GetLatestBlockData(fromBlock, toBlock, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	finalizedBlockHashes := map[int64]interface{}{}
+	for _, block := range requestedHashes {
+		finalizedBlockHashes[block.Block] = block.Hash
+	}
+	finalizedBlocksHashes, err := json.Marshal(finalizedBlockHashes)
+	require.NoError(t, err)
+
+	singleConsumerSession := &lavasession.SingleConsumerSession{QoSInfo: lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}}, SessionId: 1, RelayNum: 1}
+	relayRequestData := lavaprotocol.NewRelayData(ctx, "POST", "", []byte(`{"method":"block"}`), spectypes.LATEST_BLOCK, "jsonrpc")
+	relayRequest, err := lavaprotocol.ConstructRelayRequest(ctx, fp.consumerKey, "lava", "REORG", relayRequestData, fp.providerAddress.String(), singleConsumerSession, 100, nil)
+	require.NoError(t, err)
+	reply, err := lavaprotocol.SignRelayResponse(fp.consumerAddress, *relayRequest, fp.providerKey, &pairingtypes.RelayReply{FinalizedBlocksHashes: finalizedBlocksHashes, LatestBlock: latestBlock}, true)
+	require.NoError(t, err)
+	finalizedBlocks, finalizationConflict, err := lavaprotocol.VerifyFinalizationData(reply, relayRequest, fp.providerAddress.String(), fp.latestProofBlock, uint32(finalizationDistance))
+	require.NoError(t, err)
+	require.Nil(t, finalizationConflict)
+	fp.latestProofBlock = latestBlock
+	return latestBlock, finalizedBlocks
+}
+
+func TestReorgSimulationFetcherIsDeterministic(t *testing.T) {
+	first := NewReorgSimulationFetcher("seed", 1000, 1010, 3, 2)
+	second := NewReorgSimulationFetcher("seed", 1000, 1010, 3, 2)
+	for i := 0; i < 10; i++ {
+		firstLatest, firstReorged := first.AdvanceBlock()
+		secondLatest, secondReorged := second.AdvanceBlock()
+		require.Equal(t, firstLatest, secondLatest)
+		require.Equal(t, firstReorged, secondReorged)
+	}
+	for block := int64(1000); block <= 1020; block++ {
+		require.Equal(t, first.Hash(block), second.Hash(block))
+	}
+	require.Equal(t, first.Reorgs(), second.Reorgs())
+	require.Len(t, first.Reorgs(), 3)
+	require.NotEqual(t, first.Hash(1010), NewReorgSimulationFetcher("other", 1000, 1010, 3, 2).Hash(1010))
+}
+
+func TestChainTrackerReorgSimulation(t *testing.T) {
+	tests := []struct {
+		name                 string
+		reorgInterval        int64
+		reorgDepth           int64
+		blocksToSave         uint64
+		finalizationDistance uint64
+		advancements         int
+	}{
+		{name: "no reorgs", reorgInterval: 0, reorgDepth: 0, blocksToSave: 10, finalizationDistance: 3, advancements: 15},
+		{name: "shallow reorgs below finalization", reorgInterval: 3, reorgDepth: 2, blocksToSave: 10, finalizationDistance: 4, advancements: 20},
+		{name: "reorg on every block", reorgInterval: 1, reorgDepth: 1, blocksToSave: 10, finalizationDistance: 3, advancements: 15},
+		{name: "deep reorgs past finalization", reorgInterval: 5, reorgDepth: 6, blocksToSave: 10, finalizationDistance: 3, advancements: 20},
+		{name: "reorgs deeper than the queue", reorgInterval: 4, reorgDepth: 15, blocksToSave: 10, finalizationDistance: 3, advancements: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewReorgSimulationFetcher(tt.name, 1000, 1100, tt.reorgInterval, tt.reorgDepth)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var lock sync.Mutex
+			forks := []int64{}
+			chainTrackerConfig := chaintracker.ChainTrackerConfig{
+				BlocksToSave:         tt.blocksToSave,
+				AverageBlockTime:     TimeForPollingMock,
+				ServerBlockMemory:    100,
+				FinalizationDistance: tt.finalizationDistance,
+				ForkCallback:         func(block int64) { lock.Lock(); defer lock.Unlock(); forks = append(forks, block) },
+			}
+			chainTracker, err := chaintracker.NewChainTracker(ctx, fetcher, chainTrackerConfig)
+			require.NoError(t, err)
+			defer chainTracker.Close(ctx)
+
+			// the hashes the finalization proofs were signed with, key == block
+			finalizedHashes := map[int64]string{}
+			regenerated := 0
+			prover := newFinalizationProver()
+			blocksInFinalizationData := tt.blocksToSave - tt.finalizationDistance
+			for i := 0; i < tt.advancements; i++ {
+				latestBlock, _ := fetcher.AdvanceBlock()
+				expectedForks := []int64{}
+				for _, reorg := range fetcher.Reorgs() {
+					expectedForks = append(expectedForks, reorg.LatestBlock)
+				}
+				require.Eventually(t, func() bool {
+					lock.Lock()
+					defer lock.Unlock()
+					return chainTracker.GetLatestBlockNum() == latestBlock && len(forks) == len(expectedForks)
+				}, time.Second, TimeForPollingMock)
+				lock.Lock()
+				require.Equal(t, expectedForks, forks)
+				lock.Unlock()
+
+				// the queue is contiguous and matches the chain after every block and reorg
+				queueLatest, requestedHashes, err := chainTracker.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(tt.blocksToSave)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+				require.NoError(t, err)
+				require.Equal(t, latestBlock, queueLatest)
+				require.Len(t, requestedHashes, int(tt.blocksToSave))
+				for idx, blockStore := range requestedHashes {
+					require.Equal(t, latestBlock-int64(tt.blocksToSave)+1+int64(idx), blockStore.Block)
+					require.Equal(t, fetcher.Hash(blockStore.Block), blockStore.Hash)
+				}
+
+				// the signed finalization proof holds the finalized blocks of the current chain, a reorg past the finalization distance
+				// must regenerate it with the replacing hashes
+				proofLatest, finalizedBlocks := prover.prove(t, chainTracker, tt.finalizationDistance, blocksInFinalizationData)
+				require.Equal(t, latestBlock, proofLatest)
+				require.Len(t, finalizedBlocks, int(blocksInFinalizationData))
+				for block := latestBlock - int64(tt.finalizationDistance) - int64(blocksInFinalizationData) + 1; block <= latestBlock-int64(tt.finalizationDistance); block++ {
+					hash, ok := finalizedBlocks[block]
+					require.True(t, ok, "block %d missing from the finalization proof", block)
+					require.LessOrEqual(t, block, chainTracker.GetLatestFinalizedBlockNum())
+					require.Equal(t, fetcher.Hash(block), hash)
+					if previous, ok := finalizedHashes[block]; ok && previous != hash {
+						regenerated++
+					}
+					finalizedHashes[block] = hash
+				}
+			}
+
+			reorgs := fetcher.Reorgs()
+			if tt.reorgInterval != 0 {
+				require.Len(t, reorgs, tt.advancements/int(tt.reorgInterval))
+			}
+			if tt.reorgDepth < int64(tt.finalizationDistance) {
+				require.Zero(t, regenerated, "a reorg below the finalization distance changed a finalized block")
+			} else {
+				require.NotZero(t, regenerated)
+			}
+
+			// every reorg is recorded with the depth of the saved blocks it replaced
+			reorgHistory := chainTracker.GetReorgHistory(0)
+			require.Len(t, reorgHistory, len(reorgs))
+			for idx, reorgEvent := range reorgHistory {
+				// the saved blocks below the new one are the only ones that can be replaced
+				expectedDepth := reorgs[idx].Depth
+				if expectedDepth > int64(tt.blocksToSave)-1 {
+					expectedDepth = int64(tt.blocksToSave) - 1
+				}
+				require.Equal(t, expectedDepth, reorgEvent.Depth)
+				require.Equal(t, reorgs[idx].LatestBlock-expectedDepth, reorgEvent.Height)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package chaintracker
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	rateLimitCleanupInterval = time.Minute // idle clients are forgotten after this long
+	unknownClientKey         = "unknown"
+)
+
+// ServerLimitsConfig limits the grpc server so a single misbehaving client can't starve the chain tracker, zero values mean unlimited
+type ServerLimitsConfig struct {
+	RequestsPerSecond    float64 `yaml:"requests-per-second,omitempty" json:"requests-per-second,omitempty" mapstructure:"requests-per-second"`          // per client, identified by its remote ip
+	Burst                uint64  `yaml:"burst,omitempty" json:"burst,omitempty" mapstructure:"burst"`                                                    // requests a client can send at once, defaults to the per second rate
+	MaxConcurrentStreams uint64  `yaml:"max-concurrent-streams,omitempty" json:"max-concurrent-streams,omitempty" mapstructure:"max-concurrent-streams"` // calls served at the same time across all clients
+}
+
+func (slc *ServerLimitsConfig) validate() error {
+	if slc.RequestsPerSecond < 0 {
+		return InvalidConfigServerLimits.Wrapf("requests per second: %f", slc.RequestsPerSecond)
+	}
+	if slc.RequestsPerSecond > 0 && slc.Burst == 0 {
+		slc.Burst = uint64(slc.RequestsPerSecond)
+		if slc.Burst == 0 {
+			slc.Burst = 1
+		}
+	}
+	return nil
+}
+
+type clientTokens struct {
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// ServerLimiter enforces ServerLimitsConfig through grpc interceptors, rejected calls return codes.ResourceExhausted
+type ServerLimiter struct {
+	config        ServerLimitsConfig
+	activeStreams int64
+	lock          sync.Mutex
+	clients       map[string]*clientTokens
+	lastCleanup   time.Time
+}
+
+func NewServerLimiter(config ServerLimitsConfig) (*ServerLimiter, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &ServerLimiter{config: config, clients: map[string]*clientTokens{}, lastCleanup: time.Now()}, nil
+}
+
+// ServerOptions returns the interceptors to create a grpc server with
+func (sl *ServerLimiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(sl.UnaryInterceptor), grpc.ChainStreamInterceptor(sl.StreamInterceptor)}
+}
+
+func (sl *ServerLimiter) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, err := sl.acquire(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return handler(ctx, req)
+}
+
+func (sl *ServerLimiter) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, err := sl.acquire(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return handler(srv, stream)
+}
+
+func (sl *ServerLimiter) acquire(ctx context.Context, method string) (release func(), err error) {
+	if !sl.allow(clientKey(ctx), time.Now()) {
+		return nil, status.Errorf(codes.ResourceExhausted, "chain tracker rate limit of %v requests per second exceeded for %s", sl.config.RequestsPerSecond, method)
+	}
+	if sl.config.MaxConcurrentStreams == 0 {
+		return func() {}, nil
+	}
+	if atomic.AddInt64(&sl.activeStreams, 1) > int64(sl.config.MaxConcurrentStreams) {
+		atomic.AddInt64(&sl.activeStreams, -1)
+		return nil, status.Errorf(codes.ResourceExhausted, "chain tracker max concurrent streams of %d reached for %s", sl.config.MaxConcurrentStreams, method)
+	}
+	return func() { atomic.AddInt64(&sl.activeStreams, -1) }, nil
+}
+
+// token bucket per client
+func (sl *ServerLimiter) allow(client string, now time.Time) bool {
+	if sl.config.RequestsPerSecond == 0 {
+		return true
+	}
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	if now.Sub(sl.lastCleanup) > rateLimitCleanupInterval {
+		for key, tokens := range sl.clients {
+			if now.Sub(tokens.lastUpdate) > rateLimitCleanupInterval {
+				delete(sl.clients, key)
+			}
+		}
+		sl.lastCleanup = now
+	}
+	tokens, ok := sl.clients[client]
+	if !ok {
+		tokens = &clientTokens{tokens: float64(sl.config.Burst), lastUpdate: now}
+		sl.clients[client] = tokens
+	}
+	tokens.tokens += now.Sub(tokens.lastUpdate).Seconds() * sl.config.RequestsPerSecond
+	if tokens.tokens > float64(sl.config.Burst) {
+		tokens.tokens = float64(sl.config.Burst)
+	}
+	tokens.lastUpdate = now
+	if tokens.tokens < 1 {
+		return false
+	}
+	tokens.tokens--
+	return true
+}
+
+func clientKey(ctx context.Context) string {
+	clientPeer, ok := peer.FromContext(ctx)
+	if !ok || clientPeer.Addr == nil {
+		return unknownClientKey
+	}
+	host, _, err := net.SplitHostPort(clientPeer.Addr.String())
+	if err != nil {
+		return clientPeer.Addr.String()
+	}
+	return host
+}
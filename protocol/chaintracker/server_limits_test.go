@@ -0,0 +1,78 @@
+package chaintracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestChainTrackerServerRateLimit(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverLimits := &chaintracker.ServerLimitsConfig{RequestsPerSecond: 0.001, Burst: 2}
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address, ServerLimits: serverLimits}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	var conn *grpc.ClientConn
+	var err error
+	for attempt := 0; attempt < 20; attempt++ {
+		dialCtx, dialCancel := context.WithTimeout(ctx, time.Second)
+		conn, err = grpc.DialContext(dialCtx, address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+		dialCancel()
+		if err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond) // server is still starting up
+	}
+	require.NoError(t, err)
+	defer conn.Close()
+	client := chaintracker.NewChainTrackerServiceClient(conn)
+	for i := 0; i < 2; i++ {
+		_, err = client.GetLatestBlockNum(ctx, &empty.Empty{})
+		require.NoError(t, err)
+	}
+	_, err = client.GetLatestBlockNum(ctx, &empty.Empty{})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestServerLimiterMaxConcurrentStreams(t *testing.T) {
+	serverLimiter, err := chaintracker.NewServerLimiter(chaintracker.ServerLimitsConfig{MaxConcurrentStreams: 1})
+	require.NoError(t, err)
+	info := &grpc.UnaryServerInfo{FullMethod: "/chainTracker.ChainTrackerService/GetLatestBlockNum"}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := serverLimiter.UnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-started
+	noopHandler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	_, err = serverLimiter.UnaryInterceptor(context.Background(), nil, info, noopHandler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	close(release)
+	require.NoError(t, <-done)
+	_, err = serverLimiter.UnaryInterceptor(context.Background(), nil, info, noopHandler)
+	require.NoError(t, err)
+}
+
+func TestServerLimitsConfigValidation(t *testing.T) {
+	_, err := chaintracker.NewServerLimiter(chaintracker.ServerLimitsConfig{RequestsPerSecond: -1})
+	require.True(t, chaintracker.InvalidConfigServerLimits.Is(err))
+}
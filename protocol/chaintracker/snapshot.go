@@ -0,0 +1,93 @@
+package chaintracker
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// Snapshot returns the saved blocks and checkpoint, so a tracker on another host can be seeded from them with Restore or InitialSnapshot
+func (cs *ChainTracker) Snapshot() *ChainTrackerSnapshot {
+	cs.blockQueueMu.RLock()
+	defer cs.blockQueueMu.RUnlock()
+	blocks := make([]*BlockStore, 0, cs.blocksQueue.Len())
+	for idx := int64(0); idx < cs.blocksQueue.Len(); idx++ {
+		blockStore := cs.blocksQueue.At(idx)
+		blocks = append(blocks, &blockStore)
+	}
+	return &ChainTrackerSnapshot{
+		LatestBlock:     cs.GetLatestBlockNum(),
+		Blocks:          blocks,
+		BlockCheckpoint: atomic.LoadUint64(&cs.blockCheckpoint),
+		ChainID:         cs.endpoint.ChainID,
+		ApiInterface:    cs.endpoint.ApiInterface,
+		Hashless:        cs.hashless,
+		SnapshotTime:    time.Now().UnixMilli(),
+	}
+}
+
+func (cs *ChainTracker) validateSnapshot(snapshot *ChainTrackerSnapshot) error {
+	if snapshot == nil || len(snapshot.Blocks) == 0 {
+		return InvalidSnapshot.Wrapf("snapshot has no blocks")
+	}
+	if snapshot.ChainID != cs.endpoint.ChainID || snapshot.ApiInterface != cs.endpoint.ApiInterface {
+		return InvalidSnapshot.Wrapf("snapshot of %s %s, chain tracker of %s %s", snapshot.ChainID, snapshot.ApiInterface, cs.endpoint.ChainID, cs.endpoint.ApiInterface)
+	}
+	if snapshot.Hashless != cs.hashless {
+		return InvalidSnapshot.Wrapf("snapshot hashless: %t, chain tracker hashless: %t", snapshot.Hashless, cs.hashless)
+	}
+	for idx, blockStore := range snapshot.Blocks {
+		expectedBlock := snapshot.LatestBlock - int64(len(snapshot.Blocks)) + 1 + int64(idx)
+		if blockStore == nil || blockStore.Block != expectedBlock {
+			return InvalidSnapshot.Wrapf("snapshot blocks aren't contiguous up to the latest block %d, expected block %d at index %d", snapshot.LatestBlock, expectedBlock, idx)
+		}
+	}
+	return nil
+}
+
+// Restore replaces the saved blocks with the snapshot's, it must be newer than the saved blocks.
+// the restored hashes are checked against the node on the next new block like any saved block, and a smaller snapshot than the window is filled from the node
+func (cs *ChainTracker) Restore(snapshot *ChainTrackerSnapshot) error {
+	err := cs.validateSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	cs.blockQueueMu.Lock()
+	defer cs.blockQueueMu.Unlock()
+	if snapshot.LatestBlock <= cs.GetLatestBlockNum() {
+		return InvalidSnapshot.Wrapf("snapshot latest block %d isn't newer than the saved latest block %d", snapshot.LatestBlock, cs.GetLatestBlockNum())
+	}
+	restored := newBlocksRing(uint64(cs.blocksQueue.Capacity()))
+	// the ring is updated from the newest block, and only the blocks that fit in the window are kept
+	fetched := make([]BlockStore, 0, len(snapshot.Blocks))
+	for idx := len(snapshot.Blocks) - 1; idx >= 0 && int64(len(fetched)) < restored.Capacity(); idx-- {
+		fetched = append(fetched, *snapshot.Blocks[idx])
+	}
+	restored.Update(snapshot.LatestBlock, fetched)
+	cs.blocksQueue = restored
+	cs.setLatestBlockNum(snapshot.LatestBlock)
+	atomic.StoreUint64(&cs.blockCheckpoint, snapshot.BlockCheckpoint)
+	cs.pruneBlockBodiesUnsafe(snapshot.LatestBlock)
+	utils.LavaFormatInfo("chain tracker restored from snapshot", utils.Attribute{Key: "latestBlock", Value: snapshot.LatestBlock}, utils.Attribute{Key: "blocks", Value: restored.Len()},
+		utils.Attribute{Key: "snapshotTime", Value: time.UnixMilli(snapshot.SnapshotTime)}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	return nil
+}
+
+// restores the configured snapshot before the first fetch, a snapshot that can't save node queries is skipped
+func (cs *ChainTracker) restoreInitialSnapshot(nodeLatestBlock int64) {
+	snapshot := cs.initialSnapshot
+	cs.initialSnapshot = nil
+	if snapshot.LatestBlock > nodeLatestBlock {
+		utils.LavaFormatWarning("skipping initial snapshot, the node is behind it", nil, utils.Attribute{Key: "snapshotLatestBlock", Value: snapshot.LatestBlock}, utils.Attribute{Key: "nodeLatestBlock", Value: nodeLatestBlock}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+		return
+	}
+	if nodeLatestBlock-snapshot.LatestBlock >= int64(cs.getBlocksToSave()) {
+		utils.LavaFormatWarning("skipping initial snapshot, it is older than the saved blocks", nil, utils.Attribute{Key: "snapshotLatestBlock", Value: snapshot.LatestBlock}, utils.Attribute{Key: "nodeLatestBlock", Value: nodeLatestBlock}, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+		return
+	}
+	err := cs.Restore(snapshot)
+	if err != nil {
+		utils.LavaFormatWarning("skipping initial snapshot", err, utils.Attribute{Key: "endpoint", Value: cs.endpoint})
+	}
+}
@@ -0,0 +1,97 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+type HashCountingChainFetcher struct {
+	*MockChainFetcher
+	hashFetches int64
+}
+
+func (hccf *HashCountingChainFetcher) FetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
+	atomic.AddInt64(&hccf.hashFetches, 1)
+	return hccf.MockChainFetcher.FetchBlockHashByNum(ctx, blockNum)
+}
+
+func (hccf *HashCountingChainFetcher) HashFetches() int64 {
+	return atomic.LoadInt64(&hccf.hashFetches)
+}
+
+func TestChainTrackerSnapshotRestore(t *testing.T) {
+	blocksToSave := uint64(10)
+	mockChainFetcher := NewMockChainFetcher(1000, 100)
+	snapshotLatestBlock := mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the snapshot is served to peers over grpc
+	client, err := chaintracker.NewClient(address, chaintracker.ClientConfig{RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	defer client.Close()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: blocksToSave, AverageBlockTime: time.Hour, ServerBlockMemory: 100, ServerAddress: address}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+	snapshot, err := client.GetSnapshot()
+	require.NoError(t, err)
+	require.Equal(t, snapshotLatestBlock, snapshot.LatestBlock)
+	require.Len(t, snapshot.Blocks, int(blocksToSave))
+	for idx, blockStore := range snapshot.Blocks {
+		require.Equal(t, snapshotLatestBlock-int64(blocksToSave)+1+int64(idx), blockStore.Block)
+		require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+	}
+
+	// a tracker seeded from the snapshot only fetches the blocks after it
+	mockChainFetcher.AdvanceBlock()
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	seededFetcher := &HashCountingChainFetcher{MockChainFetcher: mockChainFetcher}
+	seededConfig := chaintracker.ChainTrackerConfig{BlocksToSave: blocksToSave, AverageBlockTime: time.Hour, ServerBlockMemory: 100, InitialSnapshot: snapshot}
+	seeded, err := chaintracker.NewChainTracker(ctx, seededFetcher, seededConfig)
+	require.NoError(t, err)
+	defer seeded.Close(ctx)
+	require.Equal(t, int64(3), seededFetcher.HashFetches()) // the two new blocks and the overlapping one
+	latestBlock, requestedHashes, err := seeded.GetLatestBlockData(spectypes.LATEST_BLOCK-int64(blocksToSave)+1, spectypes.LATEST_BLOCK, spectypes.NOT_APPLICABLE, false)
+	require.NoError(t, err)
+	require.Equal(t, currentLatestBlockInMock, latestBlock)
+	require.Len(t, requestedHashes, int(blocksToSave))
+	for _, blockStore := range requestedHashes {
+		require.True(t, mockChainFetcher.IsCorrectHash(blockStore.Hash, blockStore.Block))
+	}
+
+	// without a snapshot the whole window is fetched
+	unseededFetcher := &HashCountingChainFetcher{MockChainFetcher: mockChainFetcher}
+	unseeded, err := chaintracker.NewChainTracker(ctx, unseededFetcher, chaintracker.ChainTrackerConfig{BlocksToSave: blocksToSave, AverageBlockTime: time.Hour, ServerBlockMemory: 100})
+	require.NoError(t, err)
+	defer unseeded.Close(ctx)
+	require.Equal(t, int64(blocksToSave), unseededFetcher.HashFetches())
+
+	// the snapshot survives serialization, for migrating a provider through a file
+	data, err := proto.Marshal(seeded.Snapshot())
+	require.NoError(t, err)
+	restoredSnapshot := &chaintracker.ChainTrackerSnapshot{}
+	require.NoError(t, proto.Unmarshal(data, restoredSnapshot))
+	require.Equal(t, currentLatestBlockInMock, restoredSnapshot.LatestBlock)
+	require.Equal(t, seeded.Snapshot().Blocks, restoredSnapshot.Blocks)
+
+	// snapshots that aren't newer or don't match the tracker are refused
+	require.True(t, chaintracker.InvalidSnapshot.Is(seeded.Restore(restoredSnapshot)))
+	newer := &chaintracker.ChainTrackerSnapshot{LatestBlock: currentLatestBlockInMock + 2, Blocks: []*chaintracker.BlockStore{{Block: currentLatestBlockInMock + 1}, {Block: currentLatestBlockInMock + 2}}}
+	newer.ChainID = "other"
+	require.True(t, chaintracker.InvalidSnapshot.Is(seeded.Restore(newer)))
+	newer.ChainID = ""
+	newer.Blocks[0].Block = currentLatestBlockInMock
+	require.True(t, chaintracker.InvalidSnapshot.Is(seeded.Restore(newer)))
+	newer.Hashless = true
+	newer.Blocks[0].Block = currentLatestBlockInMock + 1
+	require.True(t, chaintracker.InvalidSnapshot.Is(seeded.Restore(newer)))
+	require.True(t, chaintracker.InvalidSnapshot.Is(seeded.Restore(&chaintracker.ChainTrackerSnapshot{})))
+	require.Equal(t, currentLatestBlockInMock, seeded.GetLatestBlockNum())
+}
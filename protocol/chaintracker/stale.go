@@ -0,0 +1,46 @@
+package chaintracker
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// the chain is stale after this many average block times without a new block
+const DefaultStaleBlocksThreshold = 10
+
+// IsStale returns true if the node answers but no new block was observed for StaleBlocksThreshold average block times
+func (cs *ChainTracker) IsStale() bool {
+	return atomic.LoadUint32(&cs.stale) == 1
+}
+
+// the time of the latest new block, the start of polling before any new block arrived
+func (cs *ChainTracker) lastNewBlockTime() time.Time {
+	cs.blockArrivalMu.RLock()
+	defer cs.blockArrivalMu.RUnlock()
+	if cs.lastBlockArrival.time.IsZero() {
+		return cs.pollingStart
+	}
+	return cs.lastBlockArrival.time
+}
+
+// called from the polling routine after a successful poll, so a failing node is reported by the health status and not as stale
+func (cs *ChainTracker) checkStale(now time.Time) {
+	averageBlockTime, _ := cs.GetAverageBlockTime()
+	sinceLastBlock := now.Sub(cs.lastNewBlockTime())
+	stale := sinceLastBlock > averageBlockTime*time.Duration(cs.staleBlocksThreshold)
+	if stale == cs.IsStale() {
+		return
+	}
+	if !stale {
+		atomic.StoreUint32(&cs.stale, 0)
+		utils.LavaFormatInfo("chain tracker got a new block, no longer stale", utils.Attribute{Key: "endpoint", Value: cs.endpoint}, utils.Attribute{Key: "latestBlock", Value: cs.GetLatestBlockNum()})
+		return
+	}
+	atomic.StoreUint32(&cs.stale, 1)
+	utils.LavaFormatWarning("chain tracker didn't observe a new block, the node might be halted", nil, utils.Attribute{Key: "endpoint", Value: cs.endpoint}, utils.Attribute{Key: "latestBlock", Value: cs.GetLatestBlockNum()}, utils.Attribute{Key: "sinceLastBlock", Value: sinceLastBlock})
+	if cs.staleChainCallback != nil {
+		cs.staleChainCallback(sinceLastBlock)
+	}
+}
@@ -0,0 +1,65 @@
+package chaintracker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTrackerStaleChainCallback(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	mockChainFetcher.AdvanceBlock()
+
+	var lock sync.Mutex
+	staleCalls := []time.Duration{}
+	staleChainCallback := func(sinceLastBlock time.Duration) {
+		lock.Lock()
+		defer lock.Unlock()
+		staleCalls = append(staleCalls, sinceLastBlock)
+	}
+	callsCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(staleCalls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	averageBlockTime := 10 * TimeForPollingMock
+	staleBlocks := uint64(5)
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: averageBlockTime, ServerBlockMemory: 10, StaleBlocksThreshold: staleBlocks, StaleChainCallback: staleChainCallback}
+	chainTracker, err := chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig)
+	require.NoError(t, err)
+	defer chainTracker.Close(ctx)
+	require.False(t, chainTracker.IsStale())
+
+	// the node keeps answering with the same block
+	staleAfter := averageBlockTime * time.Duration(staleBlocks)
+	require.Eventually(t, chainTracker.IsStale, 5*staleAfter, TimeForPollingMock)
+	require.True(t, chainTracker.GetHealthStatus().Stale)
+	require.True(t, chainTracker.GetHealthStatus().Healthy) // the node answers, it just doesn't progress
+	require.Equal(t, 1, callsCount())
+	lock.Lock()
+	require.GreaterOrEqual(t, staleCalls[0], staleAfter)
+	lock.Unlock()
+
+	// staying stale doesn't call again
+	time.Sleep(staleAfter)
+	require.Equal(t, 1, callsCount())
+
+	// a new block recovers
+	mockChainFetcher.AdvanceBlock()
+	require.Eventually(t, func() bool { return !chainTracker.IsStale() }, time.Second, TimeForPollingMock)
+	require.False(t, chainTracker.GetHealthStatus().Stale)
+	require.Equal(t, 1, callsCount())
+
+	// and halting again calls again, the threshold now uses the observed block time which includes the halt
+	observedBlockTime, samples := chainTracker.GetAverageBlockTime()
+	require.NotZero(t, samples)
+	require.Eventually(t, chainTracker.IsStale, 2*observedBlockTime*time.Duration(staleBlocks), TimeForPollingMock)
+	require.Equal(t, 2, callsCount())
+}
@@ -0,0 +1,78 @@
+package chaintracker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// ServerTLSConfig enables serving the chain tracker over TLS, when ClientCAFile is set clients must present a certificate signed by it (mTLS)
+type ServerTLSConfig struct {
+	CertFile     string `yaml:"cert-file,omitempty" json:"cert-file,omitempty" mapstructure:"cert-file"`
+	KeyFile      string `yaml:"key-file,omitempty" json:"key-file,omitempty" mapstructure:"key-file"`
+	ClientCAFile string `yaml:"client-ca-file,omitempty" json:"client-ca-file,omitempty" mapstructure:"client-ca-file"`
+}
+
+func (stc *ServerTLSConfig) validate() error {
+	if stc.CertFile == "" || stc.KeyFile == "" {
+		return InvalidConfigServerTLS.Wrapf("cert file: %s, key file: %s", stc.CertFile, stc.KeyFile)
+	}
+	return nil
+}
+
+func (stc *ServerTLSConfig) tlsConfig() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(stc.CertFile, stc.KeyFile)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed loading chain tracker server certificate", err, utils.Attribute{Key: "certFile", Value: stc.CertFile}, utils.Attribute{Key: "keyFile", Value: stc.KeyFile})
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if stc.ClientCAFile != "" {
+		caPem, err := os.ReadFile(stc.ClientCAFile)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed reading chain tracker client CA file", err, utils.Attribute{Key: "clientCAFile", Value: stc.ClientCAFile})
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPem) {
+			return nil, utils.LavaFormatError("failed parsing chain tracker client CA file", InvalidConfigServerTLS, utils.Attribute{Key: "clientCAFile", Value: stc.ClientCAFile})
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientTLSConfig is used by Client to connect to a chain tracker served over TLS, CertFile and KeyFile are needed when the server requires client certificates
+type ClientTLSConfig struct {
+	CAFile   string `yaml:"ca-file,omitempty" json:"ca-file,omitempty" mapstructure:"ca-file"` // if empty the system roots are used
+	CertFile string `yaml:"cert-file,omitempty" json:"cert-file,omitempty" mapstructure:"cert-file"`
+	KeyFile  string `yaml:"key-file,omitempty" json:"key-file,omitempty" mapstructure:"key-file"`
+}
+
+func (ctc *ClientTLSConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if ctc.CertFile != "" || ctc.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(ctc.CertFile, ctc.KeyFile)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed loading chain tracker client certificate", err, utils.Attribute{Key: "certFile", Value: ctc.CertFile}, utils.Attribute{Key: "keyFile", Value: ctc.KeyFile})
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if ctc.CAFile != "" {
+		caPem, err := os.ReadFile(ctc.CAFile)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed reading chain tracker CA file", err, utils.Attribute{Key: "caFile", Value: ctc.CAFile})
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPem) {
+			return nil, utils.LavaFormatError("failed parsing chain tracker CA file", InvalidConfigClient, utils.Attribute{Key: "caFile", Value: ctc.CAFile})
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,142 @@
+package chaintracker_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	chaintracker "github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPem []byte
+	keyPem  []byte
+}
+
+func createTestCertificate(t *testing.T, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPem:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func certificateTemplate(serial int64, isCA bool, extKeyUsage x509.ExtKeyUsage) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "chaintracker-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if !isCA {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{extKeyUsage}
+	}
+	return template
+}
+
+func getFreeAddress(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+func TestChainTrackerServeMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := createTestCertificate(t, certificateTemplate(1, true, 0), nil)
+	server := createTestCertificate(t, certificateTemplate(2, false, x509.ExtKeyUsageServerAuth), ca)
+	client := createTestCertificate(t, certificateTemplate(3, false, x509.ExtKeyUsageClientAuth), ca)
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+	serverTLS := &chaintracker.ServerTLSConfig{
+		CertFile:     writeFile("server.crt", server.certPem),
+		KeyFile:      writeFile("server.key", server.keyPem),
+		ClientCAFile: writeFile("ca.crt", ca.certPem),
+	}
+
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	currentLatestBlockInMock := mockChainFetcher.AdvanceBlock()
+	address := getFreeAddress(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerBlockMemory: 10, ServerAddress: address, ServerTLS: serverTLS}
+	go chaintracker.NewChainTracker(ctx, mockChainFetcher, chainTrackerConfig) // blocks while serving
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(ca.certPem)
+	clientKeyPair, err := tls.X509KeyPair(client.certPem, client.keyPem)
+	require.NoError(t, err)
+
+	dial := func(certificates []tls.Certificate) (*grpc.ClientConn, error) {
+		dialCtx, dialCancel := context.WithTimeout(ctx, time.Second)
+		defer dialCancel()
+		creds := credentials.NewTLS(&tls.Config{RootCAs: rootCAs, Certificates: certificates, MinVersion: tls.VersionTLS12})
+		return grpc.DialContext(dialCtx, address, grpc.WithTransportCredentials(creds), grpc.WithBlock())
+	}
+
+	var conn *grpc.ClientConn
+	for attempt := 0; attempt < 20; attempt++ {
+		conn, err = dial([]tls.Certificate{clientKeyPair})
+		if err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond) // server is still starting up
+	}
+	require.NoError(t, err)
+	defer conn.Close()
+	reply, err := chaintracker.NewChainTrackerServiceClient(conn).GetLatestBlockNum(ctx, &empty.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(currentLatestBlockInMock), reply.Value)
+
+	// without a client certificate the handshake is rejected
+	connNoCert, err := dial(nil)
+	if err == nil {
+		defer connNoCert.Close()
+		_, err = chaintracker.NewChainTrackerServiceClient(connNoCert).GetLatestBlockNum(ctx, &empty.Empty{})
+	}
+	require.Error(t, err)
+}
+
+func TestChainTrackerServerTLSConfigValidation(t *testing.T) {
+	mockChainFetcher := NewMockChainFetcher(1000, 10)
+	chainTrackerConfig := chaintracker.ChainTrackerConfig{BlocksToSave: 5, AverageBlockTime: TimeForPollingMock, ServerAddress: "127.0.0.1:0", ServerTLS: &chaintracker.ServerTLSConfig{CertFile: "server.crt"}}
+	_, err := chaintracker.NewChainTracker(context.Background(), mockChainFetcher, chainTrackerConfig)
+	require.True(t, chaintracker.InvalidConfigServerTLS.Is(err))
+}
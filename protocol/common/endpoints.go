@@ -22,6 +22,7 @@ type NodeUrl struct {
 	AuthConfig   AuthConfig    `yaml:"auth-config,omitempty" json:"auth-config,omitempty" mapstructure:"auth-config"`
 	IpForwarding bool          `yaml:"ip-forwarding,omitempty" json:"ip-forwarding,omitempty" mapstructure:"ip-forwarding"`
 	Timeout      time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty" mapstructure:"timeout"`
+	TLSConfig    NodeTLSConfig `yaml:"tls-config,omitempty" json:"tls-config,omitempty" mapstructure:"tls-config"`
 }
 
 func (url *NodeUrl) String() string {
@@ -0,0 +1,14 @@
+package common
+
+import "time"
+
+// LightClientConfig configures verifying the block hashes a tendermint node returns against light client headers,
+// the trusted height and hash are the root of trust and must come from a source other than the node
+type LightClientConfig struct {
+	ChainID        string        `yaml:"chain-id,omitempty" json:"chain-id,omitempty" mapstructure:"chain-id"`                      // the tendermint chain id of the node, not the spec chain id
+	TrustedHeight  int64         `yaml:"trusted-height,omitempty" json:"trusted-height,omitempty" mapstructure:"trusted-height"`    // a height the operator trusts, within the trusting period
+	TrustedHash    string        `yaml:"trusted-hash,omitempty" json:"trusted-hash,omitempty" mapstructure:"trusted-hash"`          // hex hash of the header at the trusted height
+	TrustingPeriod time.Duration `yaml:"trusting-period,omitempty" json:"trusting-period,omitempty" mapstructure:"trusting-period"` // should be well below the chain's unbonding period
+	Primary        string        `yaml:"primary,omitempty" json:"primary,omitempty" mapstructure:"primary"`                         // tendermint rpc the headers are read from, defaults to the endpoint's tendermintrpc node
+	Witnesses      []string      `yaml:"witnesses,omitempty" json:"witnesses,omitempty" mapstructure:"witnesses"`                   // independent tendermint rpcs the primary's headers are cross checked with, at least one
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// NodeTLSConfig configures the tls connection to a node that is served behind a self signed certificate or requires mtls,
+// an empty config connects with the system roots like before
+type NodeTLSConfig struct {
+	CACertFile     string `yaml:"ca-cert,omitempty" json:"ca-cert,omitempty" mapstructure:"ca-cert"`             // pem bundle the node's certificate is verified against instead of the system roots
+	ClientCertFile string `yaml:"client-cert,omitempty" json:"client-cert,omitempty" mapstructure:"client-cert"` // presented to nodes that require mtls, set together with ClientKeyFile
+	ClientKeyFile  string `yaml:"client-key,omitempty" json:"client-key,omitempty" mapstructure:"client-key"`
+	SkipVerify     bool   `yaml:"skip-verify,omitempty" json:"skip-verify,omitempty" mapstructure:"skip-verify"` // insecure, the node's certificate isn't verified at all
+}
+
+func (ntc *NodeTLSConfig) IsSet() bool {
+	return ntc.CACertFile != "" || ntc.ClientCertFile != "" || ntc.ClientKeyFile != "" || ntc.SkipVerify
+}
+
+// ClientTLSConfig returns the tls config for connecting to the node, nil if the node url doesn't configure tls
+func (url *NodeUrl) ClientTLSConfig() (*tls.Config, error) {
+	if url == nil || !url.TLSConfig.IsSet() {
+		return nil, nil
+	}
+	nodeTLS := url.TLSConfig
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if nodeTLS.CACertFile != "" {
+		caCerts, err := os.ReadFile(nodeTLS.CACertFile)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed reading node ca certificates", err, utils.Attribute{Key: "url", Value: url.Url}, utils.Attribute{Key: "caCert", Value: nodeTLS.CACertFile})
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCerts) {
+			return nil, utils.LavaFormatError("no valid pem certificates in node ca certificates", nil, utils.Attribute{Key: "url", Value: url.Url}, utils.Attribute{Key: "caCert", Value: nodeTLS.CACertFile})
+		}
+	}
+	if nodeTLS.ClientCertFile != "" || nodeTLS.ClientKeyFile != "" {
+		if nodeTLS.ClientCertFile == "" || nodeTLS.ClientKeyFile == "" {
+			return nil, utils.LavaFormatError("node client certificate and key must be set together", nil, utils.Attribute{Key: "url", Value: url.Url}, utils.Attribute{Key: "clientCert", Value: nodeTLS.ClientCertFile}, utils.Attribute{Key: "clientKey", Value: nodeTLS.ClientKeyFile})
+		}
+		clientCert, err := tls.LoadX509KeyPair(nodeTLS.ClientCertFile, nodeTLS.ClientKeyFile)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed loading node client certificate", err, utils.Attribute{Key: "url", Value: url.Url}, utils.Attribute{Key: "clientCert", Value: nodeTLS.ClientCertFile}, utils.Attribute{Key: "clientKey", Value: nodeTLS.ClientKeyFile})
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	if nodeTLS.SkipVerify {
+		utils.LavaFormatWarning("!!! TLS VERIFICATION OF THE NODE IS DISABLED !!! its certificate isn't checked and the connection can be intercepted, use skip-verify for testing only", nil, utils.Attribute{Key: "url", Value: url.Url})
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec // explicitly requested by the operator
+	}
+	return tlsConfig, nil
+}
+
+// HTTPTransport returns a transport using the node's tls config, nil if the node url doesn't configure tls so the default transport is used
+func (url *NodeUrl) HTTPTransport() (http.RoundTripper, error) {
+	tlsConfig, err := url.ClientTLSConfig()
+	if err != nil || tlsConfig == nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
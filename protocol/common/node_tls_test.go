@@ -0,0 +1,47 @@
+package common
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeUrlClientTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	get := func(nodeUrl NodeUrl) error {
+		transport, err := nodeUrl.HTTPTransport()
+		if err != nil {
+			return err
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(nodeUrl.Url)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// no tls config keeps the default transport, which doesn't trust the self signed node
+	transport, err := (&NodeUrl{Url: server.URL}).HTTPTransport()
+	require.NoError(t, err)
+	require.Nil(t, transport)
+	require.Error(t, get(NodeUrl{Url: server.URL}))
+
+	require.NoError(t, get(NodeUrl{Url: server.URL, TLSConfig: NodeTLSConfig{CACertFile: caFile}}))
+	require.NoError(t, get(NodeUrl{Url: server.URL, TLSConfig: NodeTLSConfig{SkipVerify: true}}))
+
+	_, err = (&NodeUrl{Url: server.URL, TLSConfig: NodeTLSConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}}).ClientTLSConfig()
+	require.Error(t, err)
+	_, err = (&NodeUrl{Url: server.URL, TLSConfig: NodeTLSConfig{ClientCertFile: caFile}}).ClientTLSConfig()
+	require.Error(t, err)
+}
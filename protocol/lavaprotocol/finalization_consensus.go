@@ -18,6 +18,7 @@ type FinalizationConsensus struct {
 	prevEpochProviderHashesConsensus []ProviderHashesConsensus
 	providerDataContainersMu         sync.RWMutex
 	currentEpoch                     uint64
+	sharedHashes                     *ChainFinalizedHashes // finalized hashes of all the endpoints of the chain, nil when not shared
 }
 
 type ProviderHashesConsensus struct {
@@ -89,6 +90,11 @@ func (fc *FinalizationConsensus) UpdateFinalizedHashes(blockDistanceForFinalized
 	fc.providerDataContainersMu.Lock()
 	defer fc.providerDataContainersMu.Unlock()
 
+	var sharedDisagreements []FinalizedHashesDisagreement
+	if fc.sharedHashes != nil {
+		sharedDisagreements = fc.sharedHashes.Record(fc.currentEpoch, providerAddress, finalizedBlocks)
+	}
+
 	if len(fc.currentProviderHashesConsensus) == 0 && len(fc.prevEpochProviderHashesConsensus) == 0 {
 		newHashConsensus := fc.newProviderHashesConsensus(blockDistanceForFinalizedData, providerAddress, latestBlock, finalizedBlocks, reply, req)
 		fc.currentProviderHashesConsensus = append(make([]ProviderHashesConsensus, 0), newHashConsensus)
@@ -123,9 +129,28 @@ func (fc *FinalizationConsensus) UpdateFinalizedHashes(blockDistanceForFinalized
 		}
 	}
 
+	// the local consensus agreed, check the providers the other endpoints of the chain talked to
+	if len(sharedDisagreements) > 0 {
+		// TODO: bring the other data as proof
+		finalizationConflict = &conflicttypes.FinalizationConflict{RelayReply0: reply}
+		return finalizationConflict, utils.LavaFormatError("Simulation: Conflict found in chain finalized hashes", HashesConsunsusError, utils.Attribute{Key: "chainID", Value: fc.sharedHashes.ChainID()}, utils.Attribute{Key: "provider", Value: providerAddress}, utils.Attribute{Key: "disagreements", Value: sharedDisagreements})
+	}
 	return finalizationConflict, nil
 }
 
+// SetSharedHashes makes the consensus record and check the finalized hashes against the view shared by the endpoints of the chain
+func (fc *FinalizationConsensus) SetSharedHashes(sharedHashes *ChainFinalizedHashes) {
+	fc.providerDataContainersMu.Lock()
+	defer fc.providerDataContainersMu.Unlock()
+	fc.sharedHashes = sharedHashes
+}
+
+func (fc *FinalizationConsensus) SharedHashes() *ChainFinalizedHashes {
+	fc.providerDataContainersMu.RLock()
+	defer fc.providerDataContainersMu.RUnlock()
+	return fc.sharedHashes
+}
+
 func (fc *FinalizationConsensus) discrepancyChecker(finalizedBlocksA map[int64]string, consensus ProviderHashesConsensus) (errRet error) {
 	var toIterate map[int64]string   // the smaller map between the two to compare
 	var otherBlocks map[int64]string // the other map
@@ -0,0 +1,157 @@
+package lavaprotocol
+
+import (
+	"sort"
+	"sync"
+)
+
+// FinalizedHashesDisagreement is a finalized block the providers of a chain reported different hashes for
+type FinalizedHashesDisagreement struct {
+	ChainID string
+	Epoch   uint64 // the last epoch the block was reported in
+	Block   int64
+	Hashes  map[string][]string // key is the hash, value is the providers that reported it
+}
+
+// ChainFinalizedHashes is the consolidated view of the finalized block hashes the providers of a chain reported in the current and previous epochs.
+// it's shared by all the endpoints of the chain, so disagreements are detected across api interfaces and not only within a single endpoint
+type ChainFinalizedHashes struct {
+	lock         sync.RWMutex
+	chainID      string
+	currentEpoch uint64
+	epochs       map[uint64]map[int64]map[string]map[string]struct{} // epoch -> block -> hash -> providers
+}
+
+func NewChainFinalizedHashes(chainID string) *ChainFinalizedHashes {
+	return &ChainFinalizedHashes{chainID: chainID, epochs: map[uint64]map[int64]map[string]map[string]struct{}{}}
+}
+
+func (cfh *ChainFinalizedHashes) ChainID() string {
+	return cfh.chainID
+}
+
+// NewEpoch drops the hashes reported before the previous epoch
+func (cfh *ChainFinalizedHashes) NewEpoch(epoch uint64) {
+	cfh.lock.Lock()
+	defer cfh.lock.Unlock()
+	if epoch <= cfh.currentEpoch {
+		return
+	}
+	previousEpoch := cfh.currentEpoch
+	cfh.currentEpoch = epoch
+	for reportedEpoch := range cfh.epochs {
+		if reportedEpoch != epoch && reportedEpoch != previousEpoch {
+			delete(cfh.epochs, reportedEpoch)
+		}
+	}
+}
+
+// Record adds the hashes reported by the provider in epoch, and returns the blocks the provider disagrees on with other providers of the current or previous epoch
+func (cfh *ChainFinalizedHashes) Record(epoch uint64, providerAddress string, finalizedBlocks map[int64]string) (disagreements []FinalizedHashesDisagreement) {
+	cfh.lock.Lock()
+	defer cfh.lock.Unlock()
+	blocks, ok := cfh.epochs[epoch]
+	if !ok {
+		blocks = map[int64]map[string]map[string]struct{}{}
+		cfh.epochs[epoch] = blocks
+	}
+	for block, hash := range finalizedBlocks {
+		hashes, ok := blocks[block]
+		if !ok {
+			hashes = map[string]map[string]struct{}{}
+			blocks[block] = hashes
+		}
+		providers, ok := hashes[hash]
+		if !ok {
+			providers = map[string]struct{}{}
+			hashes[hash] = providers
+		}
+		providers[providerAddress] = struct{}{}
+	}
+	for block := range finalizedBlocks {
+		if disagreement, ok := cfh.disagreementUnsafe(block); ok {
+			disagreements = append(disagreements, disagreement)
+		}
+	}
+	sortDisagreements(disagreements)
+	return disagreements
+}
+
+// Disagreements returns every finalized block of the current and previous epochs the providers reported different hashes for
+func (cfh *ChainFinalizedHashes) Disagreements() (disagreements []FinalizedHashesDisagreement) {
+	cfh.lock.RLock()
+	defer cfh.lock.RUnlock()
+	checked := map[int64]struct{}{}
+	for _, blocks := range cfh.epochs {
+		for block := range blocks {
+			if _, ok := checked[block]; ok {
+				continue
+			}
+			checked[block] = struct{}{}
+			if disagreement, ok := cfh.disagreementUnsafe(block); ok {
+				disagreements = append(disagreements, disagreement)
+			}
+		}
+	}
+	sortDisagreements(disagreements)
+	return disagreements
+}
+
+// Hashes returns the hashes reported for the block in the tracked epochs and the providers that reported each of them
+func (cfh *ChainFinalizedHashes) Hashes(block int64) map[string][]string {
+	cfh.lock.RLock()
+	defer cfh.lock.RUnlock()
+	hashes, _ := cfh.hashesUnsafe(block)
+	return providersByHash(hashes)
+}
+
+// hashesUnsafe merges the hashes of the block across the tracked epochs, latestEpoch is the last epoch the block was reported in
+func (cfh *ChainFinalizedHashes) hashesUnsafe(block int64) (hashes map[string]map[string]struct{}, latestEpoch uint64) {
+	hashes = map[string]map[string]struct{}{}
+	for epoch, blocks := range cfh.epochs {
+		blockHashes, ok := blocks[block]
+		if !ok {
+			continue
+		}
+		if epoch > latestEpoch {
+			latestEpoch = epoch
+		}
+		for hash, providers := range blockHashes {
+			if _, ok := hashes[hash]; !ok {
+				hashes[hash] = map[string]struct{}{}
+			}
+			for provider := range providers {
+				hashes[hash][provider] = struct{}{}
+			}
+		}
+	}
+	return hashes, latestEpoch
+}
+
+func (cfh *ChainFinalizedHashes) disagreementUnsafe(block int64) (disagreement FinalizedHashesDisagreement, ok bool) {
+	hashes, latestEpoch := cfh.hashesUnsafe(block)
+	if len(hashes) < 2 {
+		return disagreement, false
+	}
+	return FinalizedHashesDisagreement{ChainID: cfh.chainID, Epoch: latestEpoch, Block: block, Hashes: providersByHash(hashes)}, true
+}
+
+func providersByHash(hashes map[string]map[string]struct{}) map[string][]string {
+	result := make(map[string][]string, len(hashes))
+	for hash, providers := range hashes {
+		for provider := range providers {
+			result[hash] = append(result[hash], provider)
+		}
+		sort.Strings(result[hash])
+	}
+	return result
+}
+
+func sortDisagreements(disagreements []FinalizedHashesDisagreement) {
+	sort.Slice(disagreements, func(i, j int) bool {
+		if disagreements[i].Epoch != disagreements[j].Epoch {
+			return disagreements[i].Epoch < disagreements[j].Epoch
+		}
+		return disagreements[i].Block < disagreements[j].Block
+	})
+}
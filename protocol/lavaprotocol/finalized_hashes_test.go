@@ -0,0 +1,51 @@
+package lavaprotocol
+
+import (
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainFinalizedHashes(t *testing.T) {
+	chainHashes := NewChainFinalizedHashes("LAV1")
+	chainHashes.NewEpoch(20)
+	require.Empty(t, chainHashes.Record(20, "provider1", map[int64]string{100: "a", 101: "b"}))
+	require.Empty(t, chainHashes.Record(20, "provider2", map[int64]string{101: "b", 102: "c"}))
+
+	disagreements := chainHashes.Record(20, "provider3", map[int64]string{102: "x", 103: "d"})
+	require.Len(t, disagreements, 1)
+	require.Equal(t, FinalizedHashesDisagreement{ChainID: "LAV1", Epoch: 20, Block: 102, Hashes: map[string][]string{"c": {"provider2"}, "x": {"provider3"}}}, disagreements[0])
+	require.Equal(t, disagreements, chainHashes.Disagreements())
+	require.Equal(t, map[string][]string{"b": {"provider1", "provider2"}}, chainHashes.Hashes(101))
+
+	// the previous epoch is still compared against
+	chainHashes.NewEpoch(40)
+	disagreements = chainHashes.Record(40, "provider4", map[int64]string{100: "y"})
+	require.Len(t, disagreements, 1)
+	require.Equal(t, uint64(40), disagreements[0].Epoch)
+	require.Len(t, chainHashes.Disagreements(), 2)
+
+	// epochs older than the previous one are dropped
+	chainHashes.NewEpoch(60)
+	require.Empty(t, chainHashes.Hashes(102))
+	require.Len(t, chainHashes.Disagreements(), 0)
+	require.Equal(t, map[string][]string{"y": {"provider4"}}, chainHashes.Hashes(100))
+}
+
+func TestFinalizationConsensusSharedHashes(t *testing.T) {
+	chainHashes := NewChainFinalizedHashes("LAV1")
+	// two endpoints of the same chain, each talked to a different provider
+	jsonrpcConsensus := &FinalizationConsensus{}
+	jsonrpcConsensus.SetSharedHashes(chainHashes)
+	restConsensus := &FinalizationConsensus{}
+	restConsensus.SetSharedHashes(chainHashes)
+
+	relaySession := &pairingtypes.RelaySession{SessionId: 1, RelayNum: 1, Epoch: 20}
+	conflict, err := jsonrpcConsensus.UpdateFinalizedHashes(1, "provider1", 102, map[int64]string{100: "a", 101: "b"}, relaySession, &pairingtypes.RelayReply{})
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+	conflict, err = restConsensus.UpdateFinalizedHashes(1, "provider2", 102, map[int64]string{100: "a", 101: "x"}, relaySession, &pairingtypes.RelayReply{})
+	require.True(t, HashesConsunsusError.Is(err))
+	require.NotNil(t, conflict)
+}
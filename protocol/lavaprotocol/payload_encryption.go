@@ -0,0 +1,218 @@
+package lavaprotocol
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"google.golang.org/grpc/metadata"
+)
+
+// relay payloads can be encrypted end to end on top of the transport tls, so proxies in between can't read them.
+// the consumer learns the provider's public key with a probe carrying PayloadEncryptionHandshakeHeader, the provider returns it
+// in PayloadEncryptionPubKeyHeader and the consumer checks it belongs to the provider's address. every session then generates an
+// ephemeral key and sends its public part in PayloadEncryptionEphemeralHeader, both sides derive the session key with ecdh.
+// the relay is signed before it is encrypted and verified after it is decrypted, so signatures and proofs are unchanged
+const (
+	PayloadEncryptionHandshakeHeader = "lava-encryption-handshake"
+	PayloadEncryptionPubKeyHeader    = "lava-encryption-pubkey"
+	PayloadEncryptionEphemeralHeader = "lava-encryption-ephemeral"
+	payloadKeyDerivationLabel        = "lava-relay-payload"
+)
+
+// the direction is authenticated so a reply can't be replayed as a request
+var (
+	payloadRequestAdditionalData = []byte("request")
+	payloadReplyAdditionalData   = []byte("reply")
+)
+
+// PayloadCipher seals relay payloads with the session key using aes-gcm
+type PayloadCipher struct {
+	aead cipher.AEAD
+}
+
+func newPayloadCipher(sharedSecret []byte, ephemeralPubKey []byte) (*PayloadCipher, error) {
+	hash := sha256.New()
+	hash.Write([]byte(payloadKeyDerivationLabel))
+	hash.Write(sharedSecret)
+	hash.Write(ephemeralPubKey)
+	block, err := aes.NewCipher(hash.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &PayloadCipher{aead: aead}, nil
+}
+
+// NewConsumerPayloadKey generates the ephemeral key of a session, the returned secret is passed to NewConsumerPayloadCipher for every relay of the session
+func NewConsumerPayloadKey(providerPubKey []byte) (ephemeralPubKey []byte, sharedSecret []byte, err error) {
+	providerKey, err := btcec.ParsePubKey(providerPubKey, btcec.S256())
+	if err != nil {
+		return nil, nil, PayloadEncryptionError.Wrapf("invalid provider public key: %s", err)
+	}
+	ephemeralKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return nil, nil, err
+	}
+	return ephemeralKey.PubKey().SerializeCompressed(), btcec.GenerateSharedSecret(ephemeralKey, providerKey), nil
+}
+
+func NewConsumerPayloadCipher(ephemeralPubKey []byte, sharedSecret []byte) (*PayloadCipher, error) {
+	return newPayloadCipher(sharedSecret, ephemeralPubKey)
+}
+
+// NewProviderPayloadCipher derives the session key from the consumer's ephemeral public key
+func NewProviderPayloadCipher(privKey *btcec.PrivateKey, ephemeralPubKey []byte) (*PayloadCipher, error) {
+	consumerKey, err := btcec.ParsePubKey(ephemeralPubKey, btcec.S256())
+	if err != nil {
+		return nil, PayloadEncryptionError.Wrapf("invalid ephemeral public key: %s", err)
+	}
+	return newPayloadCipher(btcec.GenerateSharedSecret(privKey, consumerKey), ephemeralPubKey)
+}
+
+// the nonce is random and prepended to the sealed payload
+func (pc *PayloadCipher) seal(plain []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, pc.aead.NonceSize(), pc.aead.NonceSize()+len(plain)+pc.aead.Overhead())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return pc.aead.Seal(nonce, nonce, plain, additionalData), nil
+}
+
+func (pc *PayloadCipher) open(sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < pc.aead.NonceSize() {
+		return nil, PayloadEncryptionError.Wrapf("sealed payload is shorter than the nonce")
+	}
+	plain, err := pc.aead.Open(nil, sealed[:pc.aead.NonceSize()], sealed[pc.aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, PayloadEncryptionError.Wrapf("failed opening payload: %s", err)
+	}
+	return plain, nil
+}
+
+// EncryptRelayRequest returns a copy of the signed request with the data and api url sealed, the original isn't changed
+func (pc *PayloadCipher) EncryptRelayRequest(request *pairingtypes.RelayRequest) (*pairingtypes.RelayRequest, error) {
+	sealedData, err := pc.seal(request.RelayData.Data, payloadRequestAdditionalData)
+	if err != nil {
+		return nil, err
+	}
+	sealedApiUrl, err := pc.seal([]byte(request.RelayData.ApiUrl), payloadRequestAdditionalData)
+	if err != nil {
+		return nil, err
+	}
+	relayData := *request.RelayData
+	relayData.Data = sealedData
+	relayData.ApiUrl = base64.StdEncoding.EncodeToString(sealedApiUrl)
+	encrypted := *request
+	encrypted.RelayData = &relayData
+	return &encrypted, nil
+}
+
+// DecryptRelayRequest opens the data and api url of the request in place
+func (pc *PayloadCipher) DecryptRelayRequest(request *pairingtypes.RelayRequest) error {
+	data, err := pc.open(request.RelayData.Data, payloadRequestAdditionalData)
+	if err != nil {
+		return err
+	}
+	sealedApiUrl, err := base64.StdEncoding.DecodeString(request.RelayData.ApiUrl)
+	if err != nil {
+		return PayloadEncryptionError.Wrapf("sealed api url isn't base64: %s", err)
+	}
+	apiUrl, err := pc.open(sealedApiUrl, payloadRequestAdditionalData)
+	if err != nil {
+		return err
+	}
+	request.RelayData.Data = data
+	request.RelayData.ApiUrl = string(apiUrl)
+	return nil
+}
+
+// EncryptRelayReply returns a copy of the signed reply with the data sealed
+func (pc *PayloadCipher) EncryptRelayReply(reply *pairingtypes.RelayReply) (*pairingtypes.RelayReply, error) {
+	sealedData, err := pc.seal(reply.Data, payloadReplyAdditionalData)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := *reply
+	encrypted.Data = sealedData
+	return &encrypted, nil
+}
+
+// DecryptRelayReply opens the data of the reply in place
+func (pc *PayloadCipher) DecryptRelayReply(reply *pairingtypes.RelayReply) error {
+	data, err := pc.open(reply.Data, payloadReplyAdditionalData)
+	if err != nil {
+		return err
+	}
+	reply.Data = data
+	return nil
+}
+
+// VerifyProviderPayloadPubKey checks the public key returned in a handshake belongs to the provider, so a proxy can't substitute its own
+func VerifyProviderPayloadPubKey(pubKey []byte, providerAddress string) error {
+	if len(pubKey) != secp256k1.PubKeySize {
+		return PayloadEncryptionError.Wrapf("provider public key has %d bytes", len(pubKey))
+	}
+	address := sdk.AccAddress((&secp256k1.PubKey{Key: pubKey}).Address()).String()
+	if address != providerAddress {
+		return PayloadEncryptionError.Wrapf("provider public key belongs to %s and not to %s", address, providerAddress)
+	}
+	return nil
+}
+
+// AppendPayloadEncryptionHandshake marks an outgoing probe as a request for the provider's public key
+func AppendPayloadEncryptionHandshake(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, PayloadEncryptionHandshakeHeader, "true")
+}
+
+func IsPayloadEncryptionHandshake(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	values := md.Get(PayloadEncryptionHandshakeHeader)
+	return len(values) > 0 && values[0] == "true"
+}
+
+// GetPayloadEncryptionPubKey reads the provider's public key from a handshake reply header
+func GetPayloadEncryptionPubKey(header metadata.MD) ([]byte, error) {
+	values := header.Get(PayloadEncryptionPubKeyHeader)
+	if len(values) == 0 {
+		return nil, PayloadEncryptionError.Wrapf("provider didn't return a public key, it doesn't support payload encryption")
+	}
+	return hex.DecodeString(values[0])
+}
+
+// AppendPayloadEncryptionMetadata marks an outgoing relay as encrypted to the session key of the ephemeral key
+func AppendPayloadEncryptionMetadata(ctx context.Context, ephemeralPubKey []byte) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, PayloadEncryptionEphemeralHeader, hex.EncodeToString(ephemeralPubKey))
+}
+
+// GetPayloadEncryptionEphemeral reads the ephemeral key of an incoming relay, encrypted is false for plaintext relays
+func GetPayloadEncryptionEphemeral(ctx context.Context) (ephemeralPubKey []byte, encrypted bool, err error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, false, nil
+	}
+	values := md.Get(PayloadEncryptionEphemeralHeader)
+	if len(values) == 0 {
+		return nil, false, nil
+	}
+	ephemeralPubKey, err = hex.DecodeString(values[0])
+	if err != nil {
+		return nil, true, PayloadEncryptionError.Wrapf("ephemeral public key isn't hex: %s", err)
+	}
+	return ephemeralPubKey, true, nil
+}
@@ -0,0 +1,100 @@
+package lavaprotocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadEncryptionRelay(t *testing.T) {
+	ctx := context.Background()
+	consumerKey, consumerAddress := sigs.GenerateFloatingKey()
+	providerKey, providerAddress := sigs.GenerateFloatingKey()
+	providerPubKey := providerKey.PubKey().SerializeCompressed()
+	require.NoError(t, VerifyProviderPayloadPubKey(providerPubKey, providerAddress.String()))
+	require.Error(t, VerifyProviderPayloadPubKey(providerPubKey, consumerAddress.String()))
+
+	singleConsumerSession := &lavasession.SingleConsumerSession{
+		CuSum:         20,
+		LatestRelayCu: 10,
+		QoSInfo:       lavasession.QoSReport{LastQoSReport: &pairingtypes.QualityOfServiceReport{}},
+		SessionId:     123,
+		RelayNum:      1,
+	}
+	relayRequestData := NewRelayData(ctx, "POST", "stub_url", []byte(`{"method":"secret"}`), 10, "jsonrpc")
+	relay, err := ConstructRelayRequest(ctx, consumerKey, "lava", "LAV1", relayRequestData, providerAddress.String(), singleConsumerSession, 100, nil)
+	require.NoError(t, err)
+
+	ephemeralPubKey, sharedSecret, err := NewConsumerPayloadKey(providerPubKey)
+	require.NoError(t, err)
+	consumerCipher, err := NewConsumerPayloadCipher(ephemeralPubKey, sharedSecret)
+	require.NoError(t, err)
+	encrypted, err := consumerCipher.EncryptRelayRequest(relay)
+	require.NoError(t, err)
+	require.NotContains(t, string(encrypted.RelayData.Data), "secret")
+	require.NotEqual(t, relay.RelayData.ApiUrl, encrypted.RelayData.ApiUrl)
+	require.Equal(t, `{"method":"secret"}`, string(relay.RelayData.Data)) // the signed request isn't changed
+
+	// the provider decrypts and the consumer signature still verifies
+	providerCipher, err := NewProviderPayloadCipher(providerKey, ephemeralPubKey)
+	require.NoError(t, err)
+	require.NoError(t, providerCipher.DecryptRelayRequest(encrypted))
+	require.Equal(t, relay.RelayData.Data, encrypted.RelayData.Data)
+	require.Equal(t, relay.RelayData.ApiUrl, encrypted.RelayData.ApiUrl)
+	extractedConsumerAddress, err := sigs.ExtractSignerAddress(encrypted.RelaySession)
+	require.NoError(t, err)
+	require.Equal(t, consumerAddress, extractedConsumerAddress)
+
+	reply, err := SignRelayResponse(consumerAddress, *encrypted, providerKey, &pairingtypes.RelayReply{Data: []byte(`{"result":"private"}`)}, false)
+	require.NoError(t, err)
+	encryptedReply, err := providerCipher.EncryptRelayReply(reply)
+	require.NoError(t, err)
+	require.NotContains(t, string(encryptedReply.Data), "private")
+	// a sealed reply can't be opened as a request, the direction is authenticated
+	_, err = consumerCipher.open(encryptedReply.Data, payloadRequestAdditionalData)
+	require.Error(t, err)
+	require.NoError(t, consumerCipher.DecryptRelayReply(encryptedReply))
+	require.NoError(t, VerifyRelayReply(encryptedReply, relay, providerAddress.String()))
+
+	// another key can't open the payload
+	otherKey, _ := sigs.GenerateFloatingKey()
+	otherCipher, err := NewProviderPayloadCipher(otherKey, ephemeralPubKey)
+	require.NoError(t, err)
+	encrypted, err = consumerCipher.EncryptRelayRequest(relay)
+	require.NoError(t, err)
+	require.ErrorIs(t, otherCipher.DecryptRelayRequest(encrypted), PayloadEncryptionError)
+}
+
+func BenchmarkPayloadEncryptionRoundTrip(b *testing.B) {
+	providerKey, _ := sigs.GenerateFloatingKey()
+	ephemeralPubKey, sharedSecret, err := NewConsumerPayloadKey(providerKey.PubKey().SerializeCompressed())
+	require.NoError(b, err)
+	consumerCipher, err := NewConsumerPayloadCipher(ephemeralPubKey, sharedSecret)
+	require.NoError(b, err)
+	providerCipher, err := NewProviderPayloadCipher(providerKey, ephemeralPubKey)
+	require.NoError(b, err)
+	relay := &pairingtypes.RelayRequest{RelayData: &pairingtypes.RelayPrivateData{ApiUrl: "stub_url", Data: make([]byte, 1024)}}
+	reply := &pairingtypes.RelayReply{Data: make([]byte, 4096)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		encrypted, _ := consumerCipher.EncryptRelayRequest(relay)
+		_ = providerCipher.DecryptRelayRequest(encrypted)
+		encryptedReply, _ := providerCipher.EncryptRelayReply(reply)
+		_ = consumerCipher.DecryptRelayReply(encryptedReply)
+	}
+}
+
+// the provider derives the session key on every encrypted relay
+func BenchmarkPayloadEncryptionProviderKey(b *testing.B) {
+	providerKey, _ := sigs.GenerateFloatingKey()
+	ephemeralPubKey, _, err := NewConsumerPayloadKey(providerKey.PubKey().SerializeCompressed())
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = NewProviderPayloadCipher(providerKey, ephemeralPubKey)
+	}
+}
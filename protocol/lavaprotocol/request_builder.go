@@ -36,6 +36,9 @@ type RelayResult struct {
 	ProviderAddress string
 	ReplyServer     *pairingtypes.Relayer_RelaySubscribeClient
 	Finalized       bool
+	// set when the provider served part of a range query, see chainlib.SplitRangeRequest
+	ContinuationServed string // the token of the part in the reply
+	ContinuationToken  string // the token of the next part, empty on the last one
 }
 
 func GetSalt(requestData *pairingtypes.RelayPrivateData) uint64 {
@@ -232,6 +235,10 @@ func compareRelaysFindConflict(result1 *RelayResult, result2 *RelayResult) (conf
 		// they have equal data
 		return false, nil
 	}
+	if bytes.Equal(chainlib.CanonicalizeReplyData(result1.Reply.Data), chainlib.CanonicalizeReplyData(result2.Reply.Data)) {
+		// the data only differs in json key order or hex case
+		return false, nil
+	}
 	// they have different data! report!
 	utils.LavaFormatWarning("Simulation: DataReliability detected mismatching results, Reporting...", nil, utils.Attribute{Key: "Data0", Value: string(result1.Reply.Data)}, utils.Attribute{Key: "Data1", Value: result2.Reply.Data})
 	responseConflict = &conflicttypes.ResponseConflict{
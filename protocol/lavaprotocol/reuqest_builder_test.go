@@ -36,3 +36,14 @@ func TestSignAndExtract(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, extractedConsumerAddress, address)
 }
+
+func TestCompareRelaysCanonicalData(t *testing.T) {
+	result := func(data string) *RelayResult {
+		return &RelayResult{Reply: &pairingtypes.RelayReply{Data: []byte(data)}}
+	}
+	conflict, responseConflict := compareRelaysFindConflict(result(`{"result":"0xABCD","id":1}`), result(`{"id":1,"result":"0xabcd"}`))
+	require.False(t, conflict)
+	require.Nil(t, responseConflict)
+	_, responseConflict = compareRelaysFindConflict(result(`{"result":"0xabcd","id":1}`), result(`{"result":"0xabce","id":1}`))
+	require.NotNil(t, responseConflict)
+}
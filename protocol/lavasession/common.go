@@ -40,3 +40,8 @@ func IsSessionSyncLoss(err error) bool {
 	code := status.Code(err)
 	return code == codes.Code(SessionOutOfSyncError.ABCICode())
 }
+
+// IsUnsupportedMethod is true if the provider rejected the relay because its node doesn't have the method, it isn't a provider failure
+func IsUnsupportedMethod(err error) bool {
+	return status.Code(err) == codes.Code(UnsupportedMethodError.ABCICode())
+}
@@ -31,14 +31,26 @@ type ConsumerSessionManager struct {
 
 	// pairingPurge - contains all pairings that are unwanted this epoch, keeps them in memory in order to avoid release.
 	// (if a consumer session still uses one of them or we want to report it.)
-	pairingPurge      map[string]*ConsumerSessionsWithProvider
-	providerOptimizer ProviderOptimizer
+	pairingPurge       map[string]*ConsumerSessionsWithProvider
+	providerOptimizer  ProviderOptimizer
+	cuBudgetController *CUBudgetController
+	errorBudget        *errorBudget
+	qosHistory         *QoSHistoryStore // nil unless a qos history is set, then providers are chosen by their history
+	signerGeneration   uint64           // new sessions are signed with this consumer key generation
 }
 
 func (csm *ConsumerSessionManager) RPCEndpoint() RPCEndpoint {
 	return *csm.rpcEndpoint
 }
 
+func (csm *ConsumerSessionManager) CUBudgetController() *CUBudgetController {
+	return csm.cuBudgetController
+}
+
+func (csm *ConsumerSessionManager) CurrentEpoch() uint64 {
+	return csm.atomicReadCurrentEpoch()
+}
+
 // Update the provider pairing list for the ConsumerSessionManager
 func (csm *ConsumerSessionManager) UpdateAllProviders(epoch uint64, pairingList map[uint64]*ConsumerSessionsWithProvider) error {
 	pairingListLength := len(pairingList)
@@ -65,6 +77,7 @@ func (csm *ConsumerSessionManager) UpdateAllProviders(epoch uint64, pairingList
 	// csm.validAddresses length is reset in setValidAddressesToDefaultValue
 	csm.pairingAddresses = make(map[uint64]string, 0)
 	csm.addedToPurgeAndReport = make(map[string]struct{}, 0)
+	csm.errorBudget.reset()
 	csm.pairingAddressesLength = uint64(pairingListLength)
 	csm.numberOfResets = 0
 
@@ -78,10 +91,83 @@ func (csm *ConsumerSessionManager) UpdateAllProviders(epoch uint64, pairingList
 		csm.pairing[provider.PublicLavaAddress] = provider
 	}
 	csm.setValidAddressesToDefaultValue() // the starting point is that valid addresses are equal to pairing addresses.
+	csm.cuBudgetController.OnNewEpoch(epoch)
+	csm.qosHistory.OnEpoch(epoch)
 	utils.LavaFormatDebug("updated providers", utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "spec", Value: csm.rpcEndpoint.Key()})
 	return nil
 }
 
+// ReconcilePairing replaces the pairing of the current epoch without resetting the epoch state, used when the pairing was re-read
+// from a different lava node. providers in both lists keep their sessions and used compute units, removed providers are moved to
+// the purge list so in-flight sessions on them can still be closed
+func (csm *ConsumerSessionManager) ReconcilePairing(epoch uint64, pairingList map[uint64]*ConsumerSessionsWithProvider) (changed bool, err error) {
+	csm.lock.Lock()
+	defer csm.lock.Unlock()
+	currentEpoch := csm.atomicReadCurrentEpoch()
+	if epoch != currentEpoch {
+		return false, utils.LavaFormatError("trying to reconcile pairing of a different epoch", nil, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "currentEpoch", Value: currentEpoch})
+	}
+
+	pairing := make(map[string]*ConsumerSessionsWithProvider, len(pairingList))
+	pairingAddresses := make(map[uint64]string, len(pairingList))
+	for idx, provider := range pairingList {
+		pairingAddresses[idx] = provider.PublicLavaAddress
+		existing, ok := csm.pairing[provider.PublicLavaAddress]
+		if !ok {
+			changed = true
+			pairing[provider.PublicLavaAddress] = provider
+			continue
+		}
+		existing.Lock.Lock()
+		if existing.MaxComputeUnits != provider.MaxComputeUnits {
+			changed = true
+			existing.MaxComputeUnits = provider.MaxComputeUnits
+		}
+		existing.Lock.Unlock()
+		pairing[provider.PublicLavaAddress] = existing
+	}
+	if csm.pairingPurge == nil {
+		csm.pairingPurge = map[string]*ConsumerSessionsWithProvider{}
+	}
+	for address, provider := range csm.pairing {
+		if _, ok := pairing[address]; !ok {
+			changed = true
+			csm.pairingPurge[address] = provider
+		}
+	}
+	if len(pairingAddresses) != len(csm.pairingAddresses) {
+		changed = true
+	}
+	for idx, address := range pairingAddresses {
+		if csm.pairingAddresses[idx] != address {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	// keep providers that were blocked this epoch blocked
+	blocked := map[string]struct{}{}
+	for address := range csm.pairing {
+		blocked[address] = struct{}{}
+	}
+	for _, address := range csm.validAddresses {
+		delete(blocked, address)
+	}
+	csm.pairing = pairing
+	csm.pairingAddresses = pairingAddresses
+	csm.pairingAddressesLength = uint64(len(pairingAddresses))
+	csm.validAddresses = make([]string, 0, len(pairingAddresses))
+	for _, address := range pairingAddresses {
+		if _, ok := blocked[address]; !ok {
+			csm.validAddresses = append(csm.validAddresses, address)
+		}
+	}
+	utils.LavaFormatInfo("reconciled pairing for current epoch", utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "spec", Value: csm.rpcEndpoint.Key()}, utils.Attribute{Key: "providers", Value: len(pairing)}, utils.Attribute{Key: "validAddresses", Value: len(csm.validAddresses)})
+	return true, nil
+}
+
 // After 2 epochs we need to close all open connections.
 // otherwise golang garbage collector is not closing network connections and they
 // will remain open forever.
@@ -233,7 +319,7 @@ func (csm *ConsumerSessionManager) GetSession(ctx context.Context, cuNeededForSe
 		if err != nil {
 			// verify err is AllProviderEndpointsDisabled and report.
 			if AllProviderEndpointsDisabledError.Is(err) {
-				err = csm.blockProvider(providerAddress, true, sessionEpoch) // reporting and blocking provider this epoch
+				err = csm.blockProvider(providerAddress, true, ReportReasonUnreachable, sessionEpoch) // reporting and blocking provider this epoch
 				if err != nil {
 					if !EpochMismatchError.Is(err) {
 						// only acceptable error is EpochMismatchError so if different, throw fatal
@@ -259,7 +345,7 @@ func (csm *ConsumerSessionManager) GetSession(ctx context.Context, cuNeededForSe
 		}
 
 		// Get session from endpoint or create new or continue. if more than 10 connections are open.
-		consumerSession, pairingEpoch, err := consumerSessionsWithProvider.getConsumerSessionInstanceFromEndpoint(endpoint, numberOfResets)
+		consumerSession, pairingEpoch, err := consumerSessionsWithProvider.getConsumerSessionInstanceFromEndpoint(endpoint, numberOfResets, csm.atomicReadSignerGeneration())
 		if err != nil {
 			utils.LavaFormatDebug("Error on consumerSessionWithProvider.getConsumerSessionInstanceFromEndpoint", utils.Attribute{Key: "Error", Value: err.Error()})
 			if MaximumNumberOfSessionsExceededError.Is(err) {
@@ -267,7 +353,7 @@ func (csm *ConsumerSessionManager) GetSession(ctx context.Context, cuNeededForSe
 				tempIgnoredProviders.providers[providerAddress] = struct{}{}
 			} else if MaximumNumberOfBlockListedSessionsError.Is(err) {
 				// provider has too many block listed sessions. we block it until the next epoch.
-				err = csm.blockProvider(providerAddress, false, sessionEpoch)
+				err = csm.blockProvider(providerAddress, false, "", sessionEpoch)
 				if err != nil {
 					return nil, 0, "", nil, err
 				}
@@ -317,17 +403,17 @@ func (csm *ConsumerSessionManager) getValidProviderAddress(ignoredProvidersList
 		err = PairingListEmptyError
 		return
 	}
-	validAddressIndex := rand.Intn(totalValidLength) // get the N'th valid provider index, only valid providers will increase the addressIndex counter
-	validAddressesCounter := 0                       // this counter will try to reach the addressIndex
-	for index := 0; index < validAddressesLength; index++ {
-		if _, ok := ignoredProvidersList[csm.validAddresses[index]]; !ok { // not ignored -> yes valid
-			if validAddressesCounter == validAddressIndex {
-				return csm.validAddresses[index], nil
-			}
-			validAddressesCounter += 1
+	candidates := make([]string, 0, totalValidLength)
+	for _, validAddress := range csm.validAddresses {
+		if _, ok := ignoredProvidersList[validAddress]; !ok {
+			candidates = append(candidates, validAddress)
 		}
 	}
-	return "", UnreachableCodeError // should not reach here
+	if len(candidates) == 0 {
+		return "", UnreachableCodeError // should not reach here
+	}
+	// the optimizer weighs the providers by their recent latency, errors and sync, and by their qos history when it's set
+	return csm.providerOptimizer.ChooseProvider(candidates), nil
 }
 
 func (csm *ConsumerSessionManager) getValidConsumerSessionsWithProvider(ignoredProviders *ignoredProviders, cuNeededForSession uint64) (consumerSessionsWithProvider *ConsumerSessionsWithProvider, providerAddress string, currentEpoch uint64, err error) {
@@ -367,7 +453,7 @@ func (csm *ConsumerSessionManager) removeAddressFromValidAddresses(address strin
 
 // Blocks a provider making him unavailable for pick this epoch, will also report him as unavailable if reportProvider is set to true.
 // Validates that the sessionEpoch is equal to cs.currentEpoch otherwise doesn't take effect.
-func (csm *ConsumerSessionManager) blockProvider(address string, reportProvider bool, sessionEpoch uint64) error {
+func (csm *ConsumerSessionManager) blockProvider(address string, reportProvider bool, reportReason string, sessionEpoch uint64) error {
 	// find Index of the address
 	if sessionEpoch != csm.atomicReadCurrentEpoch() { // we read here atomically so cs.currentEpoch cant change in the middle, so we can save time if epochs mismatch
 		return EpochMismatchError
@@ -391,8 +477,9 @@ func (csm *ConsumerSessionManager) blockProvider(address string, reportProvider
 
 	if reportProvider { // Report provider flow
 		if _, ok := csm.addedToPurgeAndReport[address]; !ok { // verify it doesn't exist already
-			utils.LavaFormatInfo("Reporting Provider for unresponsiveness", utils.Attribute{Key: "Provider address", Value: address})
+			utils.LavaFormatInfo("Reporting Provider for unresponsiveness", utils.Attribute{Key: "Provider address", Value: address}, utils.Attribute{Key: "reason", Value: reportReason})
 			csm.addedToPurgeAndReport[address] = struct{}{}
+			providerReportsCounter.WithLabelValues(csm.rpcEndpoint.ChainID, csm.rpcEndpoint.ApiInterface, address, reportReason).Inc()
 		}
 	}
 
@@ -466,25 +553,35 @@ func (csm *ConsumerSessionManager) OnSessionFailure(consumerSession *SingleConsu
 
 	// check if need to block & report
 	var blockProvider, reportProvider bool
+	var reportReason string
+	publicProviderAddress, pairingEpoch := parentConsumerSessionsWithProvider.getPublicLavaAddressAndPairingEpoch()
 	if ReportAndBlockProviderError.Is(errorReceived) {
 		blockProvider = true
 		reportProvider = true
+		reportReason = ReportReasonProviderError
 	} else if BlockProviderError.Is(errorReceived) {
 		blockProvider = true
 	}
 
-	// if BlockListed is true here meaning we had a ConsecutiveNumberOfFailures > MaximumNumberOfFailuresAllowedPerConsumerSession or out of sync
-	// we will check the total number of cu for this provider and decide if we need to report it.
-	if consumerSessionBlockListed {
-		if parentConsumerSessionsWithProvider.atomicReadUsedComputeUnits() == 0 { // if we had 0 successful relays and we reached block session we need to report this provider
+	exceeded, errorBudgetEnabled := csm.errorBudget.recordRelay(publicProviderAddress, true, time.Now())
+	csm.providerOptimizer.AppendRelayData(publicProviderAddress, 0, true)
+	csm.qosHistory.RecordRelay(csm.rpcEndpoint.Key(), publicProviderAddress, 0, true)
+	if exceeded && !reportProvider {
+		blockProvider = true
+		reportProvider = true
+		reportReason = ReportReasonErrorBudget
+	} else if !errorBudgetEnabled && consumerSessionBlockListed {
+		// if BlockListed is true here meaning we had a ConsecutiveNumberOfFailures > MaximumNumberOfFailuresAllowedPerConsumerSession or out of sync
+		// we will check the total number of cu for this provider and decide if we need to report it.
+		if parentConsumerSessionsWithProvider.atomicReadUsedComputeUnits() == 0 && !reportProvider { // if we had 0 successful relays and we reached block session we need to report this provider
 			blockProvider = true
 			reportProvider = true
+			reportReason = ReportReasonNoSuccess
 		}
 	}
 
 	if blockProvider {
-		publicProviderAddress, pairingEpoch := parentConsumerSessionsWithProvider.getPublicLavaAddressAndPairingEpoch()
-		err = csm.blockProvider(publicProviderAddress, reportProvider, pairingEpoch)
+		err = csm.blockProvider(publicProviderAddress, reportProvider, reportReason, pairingEpoch)
 		if err != nil {
 			if EpochMismatchError.Is(err) {
 				return nil // no effects this epoch has been changed
@@ -544,7 +641,12 @@ func (csm *ConsumerSessionManager) OnSessionDone(
 		return sdkerrors.Wrapf(err, "OnSessionDone, consumerSession.lock must be locked before accessing this method")
 	}
 
-	defer consumerSession.lock.Unlock()                    // we need to be locked here, if we didn't get it locked we try lock anyway
+	defer consumerSession.lock.Unlock() // we need to be locked here, if we didn't get it locked we try lock anyway
+	csm.cuBudgetController.AddConsumedCU(consumerSession.LatestRelayCu)
+	csm.recordRelaySuccess(consumerSession.Client)
+	csm.qosHistory.RecordRelay(csm.rpcEndpoint.Key(), consumerSession.Client.PublicLavaAddress, currentLatency, false)
+	csm.providerOptimizer.AppendRelayData(consumerSession.Client.PublicLavaAddress, currentLatency, false)
+	csm.providerOptimizer.AppendSyncData(consumerSession.Client.PublicLavaAddress, expectedBH-latestServicedBlock)
 	consumerSession.CuSum += consumerSession.LatestRelayCu // add CuSum to current cu usage.
 	consumerSession.LatestRelayCu = 0                      // reset cu just in case
 	consumerSession.ConsecutiveNumberOfFailures = 0        // reset failures.
@@ -602,7 +704,7 @@ func (csm *ConsumerSessionManager) fetchEndpointFromConsumerSessionsWithProvider
 		if err != nil {
 			// verify err is AllProviderEndpointsDisabled and report.
 			if AllProviderEndpointsDisabledError.Is(err) {
-				err = csm.blockProvider(providerAddress, true, sessionEpoch) // reporting and blocking provider this epoch
+				err = csm.blockProvider(providerAddress, true, ReportReasonUnreachable, sessionEpoch) // reporting and blocking provider this epoch
 				if err != nil {
 					if !EpochMismatchError.Is(err) {
 						// only acceptable error is EpochMismatchError so if different, throw fatal
@@ -648,7 +750,7 @@ func (csm *ConsumerSessionManager) GetDataReliabilitySession(ctx context.Context
 		}
 
 		// get data reliability session from endpoint
-		consumerSession, pairingEpoch, err = consumerSessionWithProvider.getDataReliabilitySingleConsumerSession(endpoint)
+		consumerSession, pairingEpoch, err = consumerSessionWithProvider.getDataReliabilitySingleConsumerSession(endpoint, csm.atomicReadSignerGeneration())
 		if err != nil {
 			return nil, "", currentEpoch, err
 		}
@@ -673,7 +775,9 @@ func (csm *ConsumerSessionManager) OnSessionDoneIncreaseCUOnly(consumerSession *
 		return sdkerrors.Wrapf(err, "OnSessionDoneIncreaseRelayAndCu consumerSession.lock must be locked before accessing this method")
 	}
 
-	defer consumerSession.lock.Unlock()                    // we need to be locked here, if we didn't get it locked we try lock anyway
+	defer consumerSession.lock.Unlock() // we need to be locked here, if we didn't get it locked we try lock anyway
+	csm.cuBudgetController.AddConsumedCU(consumerSession.LatestRelayCu)
+	csm.recordRelaySuccess(consumerSession.Client)
 	consumerSession.CuSum += consumerSession.LatestRelayCu // add CuSum to current cu usage.
 	consumerSession.LatestRelayCu = 0                      // reset cu just in case
 	consumerSession.ConsecutiveNumberOfFailures = 0        // reset failures.
@@ -715,7 +819,7 @@ func (csm *ConsumerSessionManager) OnDataReliabilitySessionFailure(consumerSessi
 
 	if blockProvider {
 		publicProviderAddress, pairingEpoch := parentConsumerSessionsWithProvider.getPublicLavaAddressAndPairingEpoch()
-		err := csm.blockProvider(publicProviderAddress, reportProvider, pairingEpoch)
+		err := csm.blockProvider(publicProviderAddress, reportProvider, ReportReasonProviderError, pairingEpoch)
 		if err != nil {
 			if EpochMismatchError.Is(err) {
 				return nil // no effects this epoch has been changed
@@ -731,5 +835,7 @@ func NewConsumerSessionManager(rpcEndpoint *RPCEndpoint, providerOptimizer Provi
 	csm := ConsumerSessionManager{}
 	csm.rpcEndpoint = rpcEndpoint
 	csm.providerOptimizer = providerOptimizer
+	csm.cuBudgetController = NewCUBudgetController(rpcEndpoint.ChainID, rpcEndpoint.ApiInterface)
+	csm.errorBudget = newErrorBudget()
 	return &csm
 }
@@ -37,7 +37,7 @@ const (
 
 func CreateConsumerSessionManager() *ConsumerSessionManager {
 	rand.Seed(time.Now().UnixNano())
-	return NewConsumerSessionManager(&RPCEndpoint{"stub", "stub", "stub", 0}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_QOS))
+	return NewConsumerSessionManager(&RPCEndpoint{"stub", "stub", "stub", 0, false}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_QOS, provideroptimizer.DefaultExplorationRate))
 }
 
 func createGRPCServer(t *testing.T) *grpc.Server {
@@ -489,6 +489,40 @@ func TestUpdateAllProvidersWithSameEpoch(t *testing.T) {
 	}
 }
 
+func TestReconcilePairing(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("")
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.Nil(t, err)
+	keptProvider := csm.pairing["provider0"]
+	keptProvider.UsedComputeUnits = cuForFirstRequest
+	blockedAddress := csm.validAddresses[0]
+	csm.validAddresses = csm.validAddresses[1:] // one provider was blocked this epoch
+
+	// same pairing is not a change
+	changed, err := csm.ReconcilePairing(firstEpochHeight, createPairingList(""))
+	require.Nil(t, err)
+	require.False(t, changed)
+
+	// the new node returns a pairing without the last provider
+	reconciledList := createPairingList("")
+	delete(reconciledList, numberOfProviders-1)
+	changed, err = csm.ReconcilePairing(firstEpochHeight, reconciledList)
+	require.Nil(t, err)
+	require.True(t, changed)
+	require.Equal(t, uint64(firstEpochHeight), csm.currentEpoch)
+	require.Len(t, csm.pairing, numberOfProviders-1)
+	require.Contains(t, csm.pairingPurge, "provider"+strconv.Itoa(numberOfProviders-1)) // in flight sessions can still finish
+	require.Same(t, keptProvider, csm.pairing["provider0"])
+	require.Equal(t, cuForFirstRequest, csm.pairing["provider0"].UsedComputeUnits)
+	require.NotContains(t, csm.validAddresses, blockedAddress)
+	require.NotContains(t, csm.validAddresses, "provider"+strconv.Itoa(numberOfProviders-1))
+
+	// a different epoch must go through UpdateAllProviders
+	_, err = csm.ReconcilePairing(secondEpochHeight, reconciledList)
+	require.Error(t, err)
+}
+
 func TestGetSession(t *testing.T) {
 	s := createGRPCServer(t) // create a grpcServer so we can connect to its endpoint and validate everything works.
 	defer s.Stop()           // stop the server when finished.
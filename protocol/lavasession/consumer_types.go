@@ -17,8 +17,12 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// ProviderOptimizer scores providers by the relays they served and chooses the provider of new sessions
 type ProviderOptimizer interface {
 	AppendRelayData(providerAddress string, latency time.Duration, failure bool)
+	AppendSyncData(providerAddress string, syncLag int64)
+	ChooseProvider(addresses []string) string
+	SetHistoryScore(historyScore func(providerAddress string) float64)
 }
 
 type ignoredProviders struct {
@@ -47,6 +51,9 @@ type SingleConsumerSession struct {
 	Endpoint                    *Endpoint
 	BlockListed                 bool   // if session lost sync we blacklist it.
 	ConsecutiveNumberOfFailures uint64 // number of times this session has failed
+	SignerGeneration            uint64 // the consumer key generation the session's relays are signed with
+	PayloadEphemeralPubKey      []byte // set on the first encrypted relay of the session, see lavaprotocol.PayloadCipher
+	PayloadSharedSecret         []byte
 }
 
 type DataReliabilitySession struct {
@@ -65,10 +72,11 @@ type Endpoint struct {
 }
 
 type RPCEndpoint struct {
-	NetworkAddress string `yaml:"network-address,omitempty" json:"network-address,omitempty" mapstructure:"network-address"` // HOST:PORT
-	ChainID        string `yaml:"chain-id,omitempty" json:"chain-id,omitempty" mapstructure:"chain-id"`                      // spec chain identifier
-	ApiInterface   string `yaml:"api-interface,omitempty" json:"api-interface,omitempty" mapstructure:"api-interface"`
-	Geolocation    uint64 `yaml:"geolocation,omitempty" json:"geolocation,omitempty" mapstructure:"geolocation"`
+	NetworkAddress  string `yaml:"network-address,omitempty" json:"network-address,omitempty" mapstructure:"network-address"` // HOST:PORT
+	ChainID         string `yaml:"chain-id,omitempty" json:"chain-id,omitempty" mapstructure:"chain-id"`                      // spec chain identifier
+	ApiInterface    string `yaml:"api-interface,omitempty" json:"api-interface,omitempty" mapstructure:"api-interface"`
+	Geolocation     uint64 `yaml:"geolocation,omitempty" json:"geolocation,omitempty" mapstructure:"geolocation"`
+	EncryptPayloads bool   `yaml:"encrypt-payloads,omitempty" json:"encrypt-payloads,omitempty" mapstructure:"encrypt-payloads"` // relay data is encrypted end to end so proxies between the consumer and the provider can't read it
 }
 
 func (endpoint *RPCEndpoint) String() (retStr string) {
@@ -98,6 +106,7 @@ type ConsumerSessionsWithProvider struct {
 	UsedComputeUnits  uint64
 	ReliabilitySent   bool
 	PairingEpoch      uint64
+	PayloadPubKey     []byte // the provider's key relay payloads are encrypted to, learned from a handshake probe
 }
 
 func (cswp *ConsumerSessionsWithProvider) atomicReadUsedComputeUnits() uint64 {
@@ -120,7 +129,7 @@ func (cswp *ConsumerSessionsWithProvider) verifyDataReliabilitySessionWasNotAlre
 }
 
 // get a data reliability session from an endpoint
-func (cswp *ConsumerSessionsWithProvider) getDataReliabilitySingleConsumerSession(endpoint *Endpoint) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
+func (cswp *ConsumerSessionsWithProvider) getDataReliabilitySingleConsumerSession(endpoint *Endpoint, signerGeneration uint64) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
 	cswp.Lock.Lock()
 	defer cswp.Lock.Unlock()
 	// we re validate the data reliability session now that we are locked.
@@ -133,10 +142,11 @@ func (cswp *ConsumerSessionsWithProvider) getDataReliabilitySingleConsumerSessio
 	}
 
 	singleDataReliabilitySession := &SingleConsumerSession{
-		SessionId: DataReliabilitySessionId,
-		Client:    cswp,
-		Endpoint:  endpoint,
-		RelayNum:  0,
+		SessionId:        DataReliabilitySessionId,
+		Client:           cswp,
+		Endpoint:         endpoint,
+		RelayNum:         0,
+		SignerGeneration: signerGeneration,
 	}
 	singleDataReliabilitySession.lock.Lock() // we must lock the session so other requests wont get it.
 
@@ -200,7 +210,7 @@ func (cswp *ConsumerSessionsWithProvider) ConnectRawClientWithTimeout(ctx contex
 	return &c, conn, nil
 }
 
-func (cswp *ConsumerSessionsWithProvider) getConsumerSessionInstanceFromEndpoint(endpoint *Endpoint, numberOfResets uint64) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
+func (cswp *ConsumerSessionsWithProvider) getConsumerSessionInstanceFromEndpoint(endpoint *Endpoint, numberOfResets uint64, signerGeneration uint64) (singleConsumerSession *SingleConsumerSession, pairingEpoch uint64, err error) {
 	// TODO: validate that the endpoint even belongs to the ConsumerSessionsWithProvider and is enabled.
 
 	// Multiply numberOfReset +1 by MaxAllowedBlockListedSessionPerProvider as every reset needs to allow more blocked sessions allowed.
@@ -223,6 +233,12 @@ func (cswp *ConsumerSessionsWithProvider) getConsumerSessionInstanceFromEndpoint
 		}
 
 		if session.lock.TryLock() {
+			if session.SignerGeneration != signerGeneration {
+				// the session is signed by a rotated consumer key and no relay is using it, so it's drained
+				delete(cswp.Sessions, sessionID)
+				session.lock.Unlock()
+				continue
+			}
 			if session.BlockListed { // this session cannot be used.
 				numberOfBlockedSessions += 1 // increase the number of blocked sessions so we can block this provider is too many are blocklisted
 				session.lock.Unlock()
@@ -243,9 +259,10 @@ func (cswp *ConsumerSessionsWithProvider) getConsumerSessionInstanceFromEndpoint
 	}
 
 	consumerSession := &SingleConsumerSession{
-		SessionId: randomSessionId,
-		Client:    cswp,
-		Endpoint:  endpoint,
+		SessionId:        randomSessionId,
+		Client:           cswp,
+		Endpoint:         endpoint,
+		SignerGeneration: signerGeneration,
 	}
 	consumerSession.lock.Lock() // we must lock the session so other requests wont get it.
 
@@ -0,0 +1,297 @@
+package lavasession
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type CUBudgetThrottleLevel int
+
+const (
+	CUBudgetThrottleNone CUBudgetThrottleLevel = iota
+	CUBudgetThrottleModerate
+	CUBudgetThrottleSevere
+)
+
+const (
+	// projected usage ratio (projected epoch cu / epoch allowance) at which we start tightening
+	CUBudgetModerateThreshold = 0.8
+	CUBudgetSevereThreshold   = 1.0
+	// we don't trust a projection made on less than this part of the epoch
+	CUBudgetMinEpochProgress = 0.1
+	// retries allowed on each throttle level, None uses the caller's default
+	CUBudgetModerateMaxRetries = 2
+	CUBudgetSevereMaxRetries   = 1
+)
+
+func (level CUBudgetThrottleLevel) String() string {
+	switch level {
+	case CUBudgetThrottleModerate:
+		return "moderate"
+	case CUBudgetThrottleSevere:
+		return "severe"
+	default:
+		return "none"
+	}
+}
+
+var (
+	cuBudgetAllowanceGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_cu_budget_epoch_allowance",
+		Help: "The total compute units the consumer is allowed to use in the current epoch",
+	}, []string{"spec", "apiInterface"})
+	cuBudgetUsedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_cu_budget_used",
+		Help: "The compute units used so far in the current epoch",
+	}, []string{"spec", "apiInterface"})
+	cuBudgetProjectedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_cu_budget_projected",
+		Help: "The compute units projected to be used by the end of the current epoch",
+	}, []string{"spec", "apiInterface"})
+	cuBudgetThrottleLevelGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_cu_budget_throttle_level",
+		Help: "Current throttle level: 0 - none, 1 - moderate, 2 - severe",
+	}, []string{"spec", "apiInterface"})
+	cuBudgetThrottleDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_consumer_cu_budget_throttle_decisions_total",
+		Help: "The number of relay decisions that were tightened because of the cu budget",
+	}, []string{"spec", "apiInterface", "decision"})
+)
+
+func init() {
+	prometheus.MustRegister(cuBudgetAllowanceGauge, cuBudgetUsedGauge, cuBudgetProjectedGauge, cuBudgetThrottleLevelGauge, cuBudgetThrottleDecisions)
+}
+
+// CUBudgetEvent is passed to the event callback when the throttle level changes or the used cu crosses a usage threshold
+type CUBudgetEvent struct {
+	Spec           string
+	ApiInterface   string
+	Epoch          uint64
+	UsedCU         uint64
+	ProjectedCU    uint64
+	Allowance      uint64
+	Level          CUBudgetThrottleLevel
+	UsageThreshold float64 // the crossed part of the allowance, 0 on throttle level changes
+}
+
+// CUBudgetController tracks the compute units consumed in the current epoch against the epoch allowance
+// and projects the usage to the end of the epoch, when the trajectory predicts exhaustion it tightens
+// retries, hedging (data reliability) and caching in order to stretch the budget
+type CUBudgetController struct {
+	lock          sync.RWMutex
+	spec          string
+	apiInterface  string
+	epoch         uint64
+	epochStart    time.Time
+	epochDuration time.Duration // measured from the previous epoch change, zero until measured
+	allowance     uint64        // the project's epoch cu allowance, zero means unlimited
+	usedCU        uint64
+	level         CUBudgetThrottleLevel
+	now           func() time.Time
+	// usage thresholds are sorted, nextThreshold is the index of the first threshold not crossed this epoch
+	usageThresholds []float64
+	nextThreshold   int
+	eventCallback   func(event CUBudgetEvent)
+}
+
+// SetEventCallback sets a callback for throttle level changes and for the used cu crossing each of the usage thresholds (parts of the allowance) once per epoch
+// the callback is called while the controller is locked so it must not block
+func (cbc *CUBudgetController) SetEventCallback(usageThresholds []float64, callback func(event CUBudgetEvent)) {
+	cbc.lock.Lock()
+	defer cbc.lock.Unlock()
+	cbc.usageThresholds = append([]float64{}, usageThresholds...)
+	sort.Float64s(cbc.usageThresholds)
+	cbc.nextThreshold = 0
+	cbc.eventCallback = callback
+}
+
+// called on pairing update, resets the consumed compute units for the new epoch
+func (cbc *CUBudgetController) OnNewEpoch(epoch uint64) {
+	cbc.lock.Lock()
+	defer cbc.lock.Unlock()
+	now := cbc.now()
+	if epoch <= cbc.epoch {
+		return
+	}
+	if !cbc.epochStart.IsZero() && epoch == cbc.epoch+1 {
+		cbc.epochDuration = now.Sub(cbc.epochStart)
+	}
+	cbc.epoch = epoch
+	cbc.epochStart = now
+	cbc.usedCU = 0
+	cbc.nextThreshold = 0
+	cbc.updateLevelUnsafe(now)
+}
+
+// SetAllowance sets the project's epoch cu allowance (the epoch cu limit of its effective policy) while keeping the consumed compute units,
+// zero and math.MaxUint64 (a policy without a limit) mean unlimited
+func (cbc *CUBudgetController) SetAllowance(allowance uint64) {
+	if allowance == math.MaxUint64 {
+		allowance = 0
+	}
+	cbc.lock.Lock()
+	defer cbc.lock.Unlock()
+	cbc.allowance = allowance
+	cbc.updateLevelUnsafe(cbc.now())
+	cuBudgetAllowanceGauge.WithLabelValues(cbc.spec, cbc.apiInterface).Set(float64(allowance))
+}
+
+// SetEpochDuration allows setting a known epoch duration instead of waiting for it to be measured
+func (cbc *CUBudgetController) SetEpochDuration(epochDuration time.Duration) {
+	cbc.lock.Lock()
+	defer cbc.lock.Unlock()
+	cbc.epochDuration = epochDuration
+	cbc.updateLevelUnsafe(cbc.now())
+}
+
+func (cbc *CUBudgetController) AddConsumedCU(cu uint64) {
+	cbc.lock.Lock()
+	defer cbc.lock.Unlock()
+	cbc.usedCU += cu
+	cbc.updateLevelUnsafe(cbc.now())
+}
+
+func (cbc *CUBudgetController) projectedCUUnsafe(now time.Time) (projected uint64, valid bool) {
+	if cbc.epochDuration <= 0 || cbc.epochStart.IsZero() {
+		return cbc.usedCU, false
+	}
+	elapsed := now.Sub(cbc.epochStart)
+	progress := float64(elapsed) / float64(cbc.epochDuration)
+	if progress < CUBudgetMinEpochProgress {
+		return cbc.usedCU, false
+	}
+	if progress >= 1 {
+		return cbc.usedCU, true
+	}
+	return uint64(float64(cbc.usedCU) / progress), true
+}
+
+func (cbc *CUBudgetController) levelUnsafe(now time.Time) (level CUBudgetThrottleLevel, projected uint64) {
+	level = CUBudgetThrottleNone
+	projected, valid := cbc.projectedCUUnsafe(now)
+	if cbc.allowance > 0 {
+		if cbc.usedCU >= cbc.allowance {
+			level = CUBudgetThrottleSevere
+		} else if valid {
+			ratio := float64(projected) / float64(cbc.allowance)
+			if ratio >= CUBudgetSevereThreshold {
+				level = CUBudgetThrottleSevere
+			} else if ratio >= CUBudgetModerateThreshold {
+				level = CUBudgetThrottleModerate
+			}
+		}
+	}
+	return level, projected
+}
+
+func (cbc *CUBudgetController) updateLevelUnsafe(now time.Time) {
+	level, projected := cbc.levelUnsafe(now)
+	if level != cbc.level {
+		utils.LavaFormatInfo("cu budget throttle level changed",
+			utils.Attribute{Key: "spec", Value: cbc.spec},
+			utils.Attribute{Key: "apiInterface", Value: cbc.apiInterface},
+			utils.Attribute{Key: "epoch", Value: cbc.epoch},
+			utils.Attribute{Key: "level", Value: level.String()},
+			utils.Attribute{Key: "usedCU", Value: cbc.usedCU},
+			utils.Attribute{Key: "projectedCU", Value: projected},
+			utils.Attribute{Key: "allowance", Value: cbc.allowance},
+		)
+		cbc.sendEventUnsafe(level, projected, 0)
+		cuBudgetThrottleLevelGauge.WithLabelValues(cbc.spec, cbc.apiInterface).Set(float64(level))
+	}
+	cbc.level = level
+	for cbc.allowance > 0 && cbc.nextThreshold < len(cbc.usageThresholds) && float64(cbc.usedCU) >= cbc.usageThresholds[cbc.nextThreshold]*float64(cbc.allowance) {
+		cbc.sendEventUnsafe(level, projected, cbc.usageThresholds[cbc.nextThreshold])
+		cbc.nextThreshold++
+	}
+	cuBudgetUsedGauge.WithLabelValues(cbc.spec, cbc.apiInterface).Set(float64(cbc.usedCU))
+	cuBudgetProjectedGauge.WithLabelValues(cbc.spec, cbc.apiInterface).Set(float64(projected))
+}
+
+func (cbc *CUBudgetController) sendEventUnsafe(level CUBudgetThrottleLevel, projected uint64, usageThreshold float64) {
+	if cbc.eventCallback == nil {
+		return
+	}
+	cbc.eventCallback(CUBudgetEvent{
+		Spec:           cbc.spec,
+		ApiInterface:   cbc.apiInterface,
+		Epoch:          cbc.epoch,
+		UsedCU:         cbc.usedCU,
+		ProjectedCU:    projected,
+		Allowance:      cbc.allowance,
+		Level:          level,
+		UsageThreshold: usageThreshold,
+	})
+}
+
+// ThrottleLevel re-evaluates the projection with the current time, so the level relaxes as the epoch progresses without usage
+// it's called on every relay so an unchanged level is returned under the read lock
+func (cbc *CUBudgetController) ThrottleLevel() CUBudgetThrottleLevel {
+	cbc.lock.RLock()
+	level, _ := cbc.levelUnsafe(cbc.now())
+	unchanged := level == cbc.level
+	cbc.lock.RUnlock()
+	if unchanged {
+		return level
+	}
+	cbc.lock.Lock()
+	defer cbc.lock.Unlock()
+	cbc.updateLevelUnsafe(cbc.now())
+	return cbc.level
+}
+
+func (cbc *CUBudgetController) recordDecision(decision string) {
+	cuBudgetThrottleDecisions.WithLabelValues(cbc.spec, cbc.apiInterface, decision).Inc()
+}
+
+// MaxRetries returns the number of relay retries allowed, defaultRetries when not throttled
+func (cbc *CUBudgetController) MaxRetries(defaultRetries int) int {
+	allowed := defaultRetries
+	switch cbc.ThrottleLevel() {
+	case CUBudgetThrottleModerate:
+		allowed = CUBudgetModerateMaxRetries
+	case CUBudgetThrottleSevere:
+		allowed = CUBudgetSevereMaxRetries
+	}
+	if allowed < defaultRetries {
+		cbc.recordDecision("retries")
+		return allowed
+	}
+	return defaultRetries
+}
+
+// RequiredResponses returns the number of responses to hedge for, a throttled budget only waits for one
+func (cbc *CUBudgetController) RequiredResponses(defaultRequired int) int {
+	if defaultRequired > 1 && cbc.ThrottleLevel() != CUBudgetThrottleNone {
+		cbc.recordDecision("hedging")
+		return 1
+	}
+	return defaultRequired
+}
+
+// ShouldSendDataReliability returns false when the budget is about to be exhausted
+func (cbc *CUBudgetController) ShouldSendDataReliability() bool {
+	if cbc.ThrottleLevel() == CUBudgetThrottleSevere {
+		cbc.recordDecision("data_reliability")
+		return false
+	}
+	return true
+}
+
+// AggressiveCaching returns true when non finalized replies should be cached as long as finalized ones
+func (cbc *CUBudgetController) AggressiveCaching() bool {
+	if cbc.ThrottleLevel() != CUBudgetThrottleNone {
+		cbc.recordDecision("caching")
+		return true
+	}
+	return false
+}
+
+func NewCUBudgetController(spec string, apiInterface string) *CUBudgetController {
+	return &CUBudgetController{spec: spec, apiInterface: apiInterface, now: time.Now}
+}
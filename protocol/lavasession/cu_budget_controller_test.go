@@ -0,0 +1,170 @@
+package lavasession
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const maxRelayRetriesForTest = 4
+
+func TestCUBudgetControllerThrottling(t *testing.T) {
+	now := time.Now()
+	cbc := NewCUBudgetController("stub", "stub")
+	cbc.now = func() time.Time { return now }
+	cbc.SetEpochDuration(100 * time.Second)
+	cbc.SetAllowance(1000)
+	cbc.OnNewEpoch(firstEpochHeight)
+	require.Equal(t, CUBudgetThrottleNone, cbc.ThrottleLevel())
+
+	// too early in the epoch to project, usage is not enough to throttle
+	cbc.AddConsumedCU(50)
+	require.Equal(t, CUBudgetThrottleNone, cbc.ThrottleLevel())
+	require.Equal(t, maxRelayRetriesForTest, cbc.MaxRetries(maxRelayRetriesForTest))
+
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		usedCU   uint64
+		expected CUBudgetThrottleLevel
+	}{
+		{name: "on track", elapsed: 50 * time.Second, usedCU: 300, expected: CUBudgetThrottleNone},
+		{name: "trajectory close to the allowance", elapsed: 50 * time.Second, usedCU: 450, expected: CUBudgetThrottleModerate},
+		{name: "trajectory exceeds the allowance", elapsed: 50 * time.Second, usedCU: 600, expected: CUBudgetThrottleSevere},
+		{name: "allowance exhausted", elapsed: 1 * time.Second, usedCU: 1000, expected: CUBudgetThrottleSevere},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cbc.lock.Lock()
+			cbc.usedCU = tt.usedCU
+			cbc.lock.Unlock()
+			now = cbc.epochStart.Add(tt.elapsed)
+			require.Equal(t, tt.expected, cbc.ThrottleLevel())
+		})
+	}
+
+	// severe throttle tightens every decision
+	require.Equal(t, CUBudgetSevereMaxRetries, cbc.MaxRetries(maxRelayRetriesForTest))
+	require.Equal(t, 1, cbc.RequiredResponses(3))
+	require.False(t, cbc.ShouldSendDataReliability())
+	require.True(t, cbc.AggressiveCaching())
+
+	// a new epoch resets the usage and measures the epoch duration
+	now = now.Add(time.Minute)
+	cbc.OnNewEpoch(firstEpochHeight + 1)
+	require.Equal(t, CUBudgetThrottleNone, cbc.ThrottleLevel())
+	require.Equal(t, 3, cbc.RequiredResponses(3))
+	require.True(t, cbc.ShouldSendDataReliability())
+	require.False(t, cbc.AggressiveCaching())
+}
+
+func TestCUBudgetControllerWithSessionManager(t *testing.T) {
+	csm := CreateConsumerSessionManager()
+	pairingList := createPairingList("")
+	err := csm.UpdateAllProviders(firstEpochHeight, pairingList)
+	require.Nil(t, err)
+	cbc := csm.CUBudgetController()
+	cbc.SetAllowance(1000)
+	cbc.AddConsumedCU(100)
+	cbc.lock.RLock()
+	require.Equal(t, uint64(firstEpochHeight), cbc.epoch)
+	cbc.lock.RUnlock()
+
+	// the allowance is the project's, a new pairing only resets the usage
+	err = csm.UpdateAllProviders(firstEpochHeight+1, createPairingList(""))
+	require.Nil(t, err)
+	cbc.lock.RLock()
+	defer cbc.lock.RUnlock()
+	require.Equal(t, uint64(1000), cbc.allowance)
+	require.Equal(t, uint64(firstEpochHeight+1), cbc.epoch)
+	require.Zero(t, cbc.usedCU)
+}
+
+func TestCUBudgetControllerEvents(t *testing.T) {
+	now := time.Now()
+	cbc := NewCUBudgetController("stub", "stub")
+	cbc.now = func() time.Time { return now }
+	events := []CUBudgetEvent{}
+	cbc.SetEventCallback([]float64{1, 0.5}, func(event CUBudgetEvent) {
+		events = append(events, event)
+	})
+	cbc.SetAllowance(1000)
+	cbc.OnNewEpoch(firstEpochHeight)
+	require.Empty(t, events)
+
+	cbc.AddConsumedCU(400)
+	require.Empty(t, events)
+	cbc.AddConsumedCU(100)
+	require.Len(t, events, 1)
+	require.Equal(t, 0.5, events[0].UsageThreshold)
+	require.Equal(t, uint64(500), events[0].UsedCU)
+
+	// each threshold is reported once per epoch
+	cbc.AddConsumedCU(100)
+	require.Len(t, events, 1)
+
+	// exhausting the allowance throttles and crosses the last threshold
+	cbc.AddConsumedCU(400)
+	require.Len(t, events, 3)
+	require.Equal(t, CUBudgetThrottleSevere, events[1].Level)
+	require.Zero(t, events[1].UsageThreshold)
+	require.Equal(t, 1.0, events[2].UsageThreshold)
+
+	// a new epoch releases the throttle and resets the thresholds
+	cbc.OnNewEpoch(firstEpochHeight + 1)
+	require.Len(t, events, 4)
+	require.Equal(t, CUBudgetThrottleNone, events[3].Level)
+	require.Equal(t, uint64(firstEpochHeight+1), events[3].Epoch)
+	cbc.AddConsumedCU(500)
+	require.Len(t, events, 5)
+	require.Equal(t, 0.5, events[4].UsageThreshold)
+}
+
+func TestCUBudgetControllerAllowance(t *testing.T) {
+	cbc := NewCUBudgetController("stub", "stub")
+	cbc.OnNewEpoch(firstEpochHeight)
+	// without an allowance nothing is throttled
+	cbc.AddConsumedCU(500)
+	require.Equal(t, CUBudgetThrottleNone, cbc.ThrottleLevel())
+
+	// an allowance below the usage throttles right away
+	cbc.SetAllowance(400)
+	require.Equal(t, CUBudgetThrottleSevere, cbc.ThrottleLevel())
+
+	// the allowance holds across epochs, a policy without an epoch cu limit is unlimited
+	cbc.OnNewEpoch(firstEpochHeight + 1)
+	cbc.AddConsumedCU(500)
+	require.Equal(t, CUBudgetThrottleSevere, cbc.ThrottleLevel())
+	cbc.SetAllowance(math.MaxUint64)
+	require.Equal(t, CUBudgetThrottleNone, cbc.ThrottleLevel())
+	cbc.lock.RLock()
+	require.Zero(t, cbc.allowance)
+	cbc.lock.RUnlock()
+}
+
+func TestCUBudgetControllerThrottleLevelGauge(t *testing.T) {
+	now := time.Now()
+	cbc := NewCUBudgetController("gauge", "stub")
+	cbc.now = func() time.Time { return now }
+	cbc.SetEpochDuration(100 * time.Second)
+	cbc.SetAllowance(1000)
+	cbc.OnNewEpoch(firstEpochHeight)
+	gauge := cuBudgetThrottleLevelGauge.WithLabelValues("gauge", "stub")
+	cbc.AddConsumedCU(600)
+	now = now.Add(50 * time.Second)
+	require.Equal(t, CUBudgetThrottleSevere, cbc.ThrottleLevel())
+	require.Equal(t, float64(CUBudgetThrottleSevere), testutil.ToFloat64(gauge))
+
+	// reads of an unchanged level don't touch the gauge
+	gauge.Set(-1)
+	require.Equal(t, CUBudgetThrottleSevere, cbc.ThrottleLevel())
+	require.Equal(t, float64(-1), testutil.ToFloat64(gauge))
+
+	// the projection relaxes as the epoch progresses without usage
+	now = now.Add(15 * time.Second)
+	require.Equal(t, CUBudgetThrottleModerate, cbc.ThrottleLevel())
+	require.Equal(t, float64(CUBudgetThrottleModerate), testutil.ToFloat64(gauge))
+}
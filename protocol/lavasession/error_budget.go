@@ -0,0 +1,118 @@
+package lavasession
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ErrorBudgetFailuresFlag   = "error-budget-failures"
+	ErrorBudgetRelaysFlag     = "error-budget-relays"
+	ErrorBudgetWindowFlag     = "error-budget-window"
+	DefaultErrorBudgetRelays  = 20
+	DefaultErrorBudgetWindow  = 5 * time.Minute
+	ReportReasonErrorBudget   = "error-budget"
+	ReportReasonProviderError = "report-error" // the relay failed with ReportAndBlockProviderError
+	ReportReasonNoSuccess     = "no-success"   // a session was blocked before the provider served any relay, used when no policy is set
+	ReportReasonUnreachable   = "unreachable"  // all of the provider's endpoints are disabled
+)
+
+var providerReportsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_consumer_provider_unresponsive_reports_total",
+	Help: "The number of times a provider was added to the unresponsive providers reported in relays",
+}, []string{"spec", "apiInterface", "provider", "reason"})
+
+func init() {
+	prometheus.MustRegister(providerReportsCounter)
+}
+
+// ErrorBudgetPolicy decides when a provider is reported as unresponsive: when at least MaxFailures of its last Relays relays
+// within Window failed, the provider is blocked for the rest of the epoch and reported in the following relay requests
+type ErrorBudgetPolicy struct {
+	MaxFailures uint64
+	Relays      uint64
+	Window      time.Duration
+}
+
+func (ebp *ErrorBudgetPolicy) validate() error {
+	if ebp.Relays == 0 {
+		ebp.Relays = DefaultErrorBudgetRelays
+	}
+	if ebp.Window == 0 {
+		ebp.Window = DefaultErrorBudgetWindow
+	}
+	if ebp.MaxFailures == 0 || ebp.MaxFailures > ebp.Relays || ebp.Window < 0 {
+		return InvalidErrorBudgetPolicyError.Wrapf("max failures: %d, relays: %d, window: %s", ebp.MaxFailures, ebp.Relays, ebp.Window)
+	}
+	return nil
+}
+
+type relayOutcome struct {
+	time   time.Time
+	failed bool
+}
+
+// errorBudget keeps the recent relay outcomes of every provider in the current epoch, it has its own lock so it can be used with a session locked
+type errorBudget struct {
+	lock     sync.Mutex
+	policy   *ErrorBudgetPolicy        // nil until a policy is set
+	outcomes map[string][]relayOutcome // key == provider address
+}
+
+func newErrorBudget() *errorBudget {
+	return &errorBudget{outcomes: map[string][]relayOutcome{}}
+}
+
+// records the relay outcome, exceeded is true if the provider exceeded the error budget and enabled is false if no policy was set
+func (eb *errorBudget) recordRelay(provider string, failed bool, now time.Time) (exceeded bool, enabled bool) {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	if eb.policy == nil {
+		return false, false
+	}
+	outcomes := append(eb.outcomes[provider], relayOutcome{time: now, failed: failed})
+	firstInWindow := 0
+	for firstInWindow < len(outcomes) && (now.Sub(outcomes[firstInWindow].time) > eb.policy.Window || uint64(len(outcomes)-firstInWindow) > eb.policy.Relays) {
+		firstInWindow++
+	}
+	outcomes = outcomes[firstInWindow:]
+	eb.outcomes[provider] = outcomes
+	if !failed {
+		return false, true
+	}
+	failures := uint64(0)
+	for _, outcome := range outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+	return failures >= eb.policy.MaxFailures, true
+}
+
+func (eb *errorBudget) reset() {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+	eb.outcomes = map[string][]relayOutcome{}
+}
+
+// SetErrorBudgetPolicy replaces the default reporting criteria, which reports a provider only if a session was blocked before it served any relay
+func (csm *ConsumerSessionManager) SetErrorBudgetPolicy(policy ErrorBudgetPolicy) error {
+	err := policy.validate()
+	if err != nil {
+		return err
+	}
+	csm.errorBudget.lock.Lock()
+	defer csm.errorBudget.lock.Unlock()
+	csm.errorBudget.policy = &policy
+	csm.errorBudget.outcomes = map[string][]relayOutcome{}
+	utils.LavaFormatInfo("unresponsive provider reports use an error budget", utils.Attribute{Key: "spec", Value: csm.rpcEndpoint.Key()}, utils.Attribute{Key: "policy", Value: policy})
+	return nil
+}
+
+// called with the session locked, the provider address never changes so it is read without locking the provider
+func (csm *ConsumerSessionManager) recordRelaySuccess(consumerSessionsWithProvider *ConsumerSessionsWithProvider) {
+	csm.errorBudget.recordRelay(consumerSessionsWithProvider.PublicLavaAddress, false, time.Now())
+}
@@ -0,0 +1,89 @@
+package lavasession
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorBudgetRecordRelay(t *testing.T) {
+	eb := newErrorBudget()
+	now := time.Now()
+	exceeded, enabled := eb.recordRelay("provider", true, now)
+	require.False(t, exceeded)
+	require.False(t, enabled)
+
+	eb.policy = &ErrorBudgetPolicy{MaxFailures: 3, Relays: 5, Window: time.Minute}
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		failed   bool
+		exceeded bool
+	}{
+		{name: "first failure", elapsed: 0, failed: true, exceeded: false},
+		{name: "success", elapsed: time.Second, failed: false, exceeded: false},
+		{name: "second failure", elapsed: 2 * time.Second, failed: true, exceeded: false},
+		{name: "successes push the first failure out of the last relays", elapsed: 3 * time.Second, failed: false, exceeded: false},
+		{name: "success", elapsed: 4 * time.Second, failed: false, exceeded: false},
+		{name: "success", elapsed: 5 * time.Second, failed: false, exceeded: false},
+		{name: "only two failures in the last relays", elapsed: 6 * time.Second, failed: true, exceeded: false},
+		{name: "the second failure left the last relays", elapsed: 7 * time.Second, failed: true, exceeded: false},
+		{name: "third failure in the last relays", elapsed: 8 * time.Second, failed: true, exceeded: true},
+		{name: "old failures leave the window", elapsed: 2 * time.Minute, failed: true, exceeded: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeded, enabled := eb.recordRelay("provider", tt.failed, now.Add(tt.elapsed))
+			require.True(t, enabled)
+			require.Equal(t, tt.exceeded, exceeded)
+		})
+	}
+	// other providers have their own budget
+	exceeded, _ = eb.recordRelay("other", true, now.Add(2*time.Minute))
+	require.False(t, exceeded)
+
+	require.Error(t, (&ErrorBudgetPolicy{MaxFailures: 0}).validate())
+	require.Error(t, (&ErrorBudgetPolicy{MaxFailures: 10, Relays: 5}).validate())
+	policy := &ErrorBudgetPolicy{MaxFailures: 2}
+	require.NoError(t, policy.validate())
+	require.Equal(t, uint64(DefaultErrorBudgetRelays), policy.Relays)
+	require.Equal(t, DefaultErrorBudgetWindow, policy.Window)
+}
+
+func TestErrorBudgetReportsProvider(t *testing.T) {
+	s := createGRPCServer(t) // create a grpcServer so we can connect to its endpoint and validate everything works.
+	defer s.Stop()           // stop the server when finished.
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	require.NoError(t, csm.SetErrorBudgetPolicy(ErrorBudgetPolicy{MaxFailures: 2, Relays: 5, Window: time.Minute}))
+	err := csm.UpdateAllProviders(firstEpochHeight, createPairingList(""))
+	require.NoError(t, err)
+
+	failures := map[string]int{}
+	for {
+		cs, _, providerAddress, _, err := csm.GetSession(ctx, cuForFirstRequest, nil)
+		require.NoError(t, err)
+		err = csm.OnSessionFailure(cs, fmt.Errorf("relay failed"))
+		require.NoError(t, err)
+		failures[providerAddress]++
+		if failures[providerAddress] == 2 {
+			break
+		}
+	}
+	for providerAddress, providerFailures := range failures {
+		if providerFailures == 2 {
+			require.Contains(t, csm.addedToPurgeAndReport, providerAddress)
+			require.NotContains(t, csm.validAddresses, providerAddress)
+		} else {
+			require.NotContains(t, csm.addedToPurgeAndReport, providerAddress)
+		}
+	}
+
+	// a new epoch starts with a fresh budget
+	err = csm.UpdateAllProviders(firstEpochHeight+1, createPairingList(""))
+	require.NoError(t, err)
+	require.Empty(t, csm.addedToPurgeAndReport)
+}
@@ -26,6 +26,8 @@ var ( // Consumer Side Errors
 	FailedToConnectToEndPointForDataReliabilityError     = sdkerrors.New("FailedToConnectToEndPointForDataReliability Error", 683, "Failed to connect to a providers endpoints")
 	DataReliabilityEpochMismatchError                    = sdkerrors.New("DataReliabilityEpochMismatch Error", 684, "Data reliability epoch mismatch original session epoch.")
 	NoDataReliabilitySessionWasCreatedError              = sdkerrors.New("NoDataReliabilitySessionWasCreated Error", 685, "No Data reliability session was created")
+	InvalidErrorBudgetPolicyError                        = sdkerrors.New("InvalidErrorBudgetPolicy Error", 686, "Error budget max failures must be positive and not more than the relays it is counted over")
+	InvalidQoSHistoryDecayError                          = sdkerrors.New("InvalidQoSHistoryDecay Error", 687, "QoS history decay must be more than 0 and at most 1")
 )
 
 var ( // Provider Side Errors
@@ -48,4 +50,7 @@ var ( // Provider Side Errors
 	CouldNotFindIndexAsConsumerNotYetRegisteredError = sdkerrors.New("CouldNotFindIndexAsConsumerNotYetRegistered Error", 897, "fetching provider index from psm failed")
 	ProviderIndexMisMatchError                       = sdkerrors.New("ProviderIndexMisMatch Error", 898, "provider index mismatch")
 	SessionIdNotFoundError                           = sdkerrors.New("SessionIdNotFound Error", 899, "Session Id not found")
+	UnsupportedMethodError                           = sdkerrors.New("UnsupportedMethod Error", 900, "The provider's node doesn't support the requested method, send the relay to another provider")
+	InvalidSessionsSnapshotError                     = sdkerrors.New("InvalidSessionsSnapshot Error", 901, "Sessions snapshot can't be restored")
+	DuplicateRelayProofError                         = sdkerrors.New("DuplicateRelayProof Error", 902, "Relay proof reuses the relay number or signature of an accepted proof")
 )
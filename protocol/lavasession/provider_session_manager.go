@@ -16,7 +16,8 @@ type ProviderSessionManager struct {
 	lock                                    sync.RWMutex
 	blockedEpochHeight                      uint64 // requests from this epoch are blocked
 	rpcProviderEndpoint                     *RPCProviderEndpoint
-	blockDistanceForEpochValidity           uint64 // sessionsWithAllConsumers with epochs older than ((latest epoch) - numberOfBlocksKeptInMemory) are deleted.
+	blockDistanceForEpochValidity           uint64            // sessionsWithAllConsumers with epochs older than ((latest epoch) - numberOfBlocksKeptInMemory) are deleted.
+	virtualEpochs                           map[uint64]uint64 // epoch to the virtual epochs lava downtime added to it, protected by lock
 }
 
 func (psm *ProviderSessionManager) GetProviderIndexWithConsumer(epoch uint64, consumerAddress string) (int64, int64, error) {
@@ -191,7 +192,8 @@ func (psm *ProviderSessionManager) registerNewConsumer(consumerAddr string, epoc
 
 	providerSessionWithConsumer, foundAddressInMap := mapOfProviderSessionsWithConsumer.sessionMap[consumerAddr]
 	if !foundAddressInMap {
-		epochData := &ProviderSessionsEpochData{MaxComputeUnits: maxCuForConsumer}
+		// consumers registering during downtime get the extended limit of the epoch too
+		epochData := &ProviderSessionsEpochData{MaxComputeUnits: maxCuForConsumer * (psm.virtualEpochs[epoch] + 1), EpochMaxComputeUnits: maxCuForConsumer}
 		providerSessionWithConsumer = NewProviderSessionsWithConsumer(consumerAddr, epochData, notDataReliabilityPSWC, selfProviderIndex, pairedProviders)
 		mapOfProviderSessionsWithConsumer.sessionMap[consumerAddr] = providerSessionWithConsumer
 	}
@@ -290,6 +292,29 @@ func (psm *ProviderSessionManager) UpdateEpoch(epoch uint64) {
 	psm.sessionsWithAllConsumers = filterOldEpochEntries(psm.blockedEpochHeight, psm.sessionsWithAllConsumers)
 	psm.dataReliabilitySessionsWithAllConsumers = filterOldEpochEntries(psm.blockedEpochHeight, psm.dataReliabilitySessionsWithAllConsumers)
 	psm.subscriptionSessionsWithAllConsumers = filterOldEpochEntries(psm.blockedEpochHeight, psm.subscriptionSessionsWithAllConsumers)
+	for virtualEpochKey := range psm.virtualEpochs {
+		if !IsEpochValidForUse(virtualEpochKey, psm.blockedEpochHeight) {
+			delete(psm.virtualEpochs, virtualEpochKey)
+		}
+	}
+}
+
+// UpdateVirtualEpoch extends the max compute units of the consumers of epoch while lava is down, consumers can't move to a new epoch
+// so every virtual epoch allows them another epoch worth of compute units
+func (psm *ProviderSessionManager) UpdateVirtualEpoch(epoch uint64, virtualEpoch uint64) {
+	psm.lock.Lock()
+	defer psm.lock.Unlock()
+	if virtualEpoch <= psm.virtualEpochs[epoch] {
+		return
+	}
+	psm.virtualEpochs[epoch] = virtualEpoch
+	mapOfProviderSessionsWithConsumer, found := psm.sessionsWithAllConsumers[epoch]
+	if !found {
+		return
+	}
+	for _, providerSessionWithConsumer := range mapOfProviderSessionsWithConsumer.sessionMap {
+		providerSessionWithConsumer.atomicWriteMaxComputeUnits(providerSessionWithConsumer.epochData.EpochMaxComputeUnits * (virtualEpoch + 1))
+	}
 }
 
 func filterOldEpochEntries[T dataHandler](blockedEpochHeight uint64, allEpochsMap map[uint64]T) (validEpochsMap map[uint64]T) {
@@ -478,6 +503,7 @@ func NewProviderSessionManager(rpcProviderEndpoint *RPCProviderEndpoint, numberO
 		sessionsWithAllConsumers:                map[uint64]sessionData{},
 		dataReliabilitySessionsWithAllConsumers: map[uint64]sessionData{},
 		subscriptionSessionsWithAllConsumers:    map[uint64]subscriptionData{},
+		virtualEpochs:                           map[uint64]uint64{},
 	}
 }
 
@@ -2,6 +2,7 @@ package lavasession
 
 import (
 	"context"
+	"encoding/json"
 	"math"
 	"math/rand"
 	"testing"
@@ -218,6 +219,38 @@ func TestPSMUpdateCuMaxCuReached(t *testing.T) {
 	require.True(t, MaximumCULimitReachedByConsumer.Is(err))
 }
 
+func TestPSMVirtualEpochExtendsMaxCu(t *testing.T) {
+	ctx := context.Background()
+	// init test
+	psm, sps := prepareSession(t, ctx)
+	err := psm.OnSessionDone(sps, relayNumber)
+	require.Nil(t, err)
+	err = psm.UpdateSessionCU(consumerOneAddress, epoch1, sessionId, maxCu)
+	require.Nil(t, err)
+
+	// lava is down, the consumer is allowed another epoch of cu
+	psm.UpdateVirtualEpoch(epoch1, 1)
+	require.Equal(t, 2*maxCu, sps.userSessionsParent.atomicReadMaxComputeUnits())
+	sps, err = psm.GetSession(ctx, consumerOneAddress, epoch1, sessionId, relayNumber+1)
+	require.Nil(t, err)
+	err = sps.PrepareSessionForUsage(ctx, relayCu, maxCu+relayCu, 0)
+	require.Nil(t, err)
+
+	// an older virtual epoch doesn't shrink the limit
+	psm.UpdateVirtualEpoch(epoch1, 0)
+	require.Equal(t, 2*maxCu, sps.userSessionsParent.atomicReadMaxComputeUnits())
+
+	// consumers registering during the downtime get the extended limit
+	otherConsumer := "consumer2"
+	otherSps, err := psm.RegisterProviderSessionWithConsumer(ctx, otherConsumer, epoch1, sessionId, relayNumber, maxCu, selfProviderIndex, pairedProviders)
+	require.Nil(t, err)
+	require.Equal(t, 2*maxCu, otherSps.userSessionsParent.atomicReadMaxComputeUnits())
+
+	// the virtual epochs are dropped with their epoch
+	psm.UpdateEpoch(epoch2)
+	require.Empty(t, psm.virtualEpochs)
+}
+
 func TestPSMCUMisMatch(t *testing.T) {
 	ctx := context.Background()
 	// init test
@@ -753,3 +786,38 @@ func initSessionStore(numSessions int, epoch uint64) []*testSessionData {
 	}
 	return retSessions
 }
+
+func TestPSMSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	psm, sps := prepareSession(t, ctx)
+	err := psm.OnSessionDone(sps, relayNumber)
+	require.Nil(t, err)
+
+	// the snapshot is written to a file on shutdown
+	snapshotBytes, err := json.Marshal(psm.Snapshot())
+	require.Nil(t, err)
+	snapshot := &ProviderSessionsSnapshot{}
+	err = json.Unmarshal(snapshotBytes, snapshot)
+	require.Nil(t, err)
+	require.Len(t, snapshot.Consumers, 1)
+
+	restoredPsm := initProviderSessionManager()
+	err = restoredPsm.Restore(snapshot)
+	require.Nil(t, err)
+
+	// the consumer continues the session from the next relay number
+	_, err = restoredPsm.GetSession(ctx, consumerOneAddress, epoch1, sessionId, relayNumber)
+	require.True(t, SessionOutOfSyncError.Is(err))
+	restoredSps, err := restoredPsm.GetSession(ctx, consumerOneAddress, epoch1, sessionId, relayNumber+1)
+	require.Nil(t, err)
+	require.Equal(t, relayCu, restoredSps.CuSum)
+	require.Equal(t, relayNumber, restoredSps.RelayNum)
+	err = restoredSps.PrepareSessionForUsage(ctx, relayCu, relayCu*2, 0)
+	require.Nil(t, err)
+	require.Equal(t, relayCu*2, restoredSps.userSessionsParent.atomicReadUsedComputeUnits())
+
+	// a snapshot of another endpoint isn't restored
+	snapshot.Endpoint = "ETH1jsonrpc"
+	err = initProviderSessionManager().Restore(snapshot)
+	require.True(t, InvalidSessionsSnapshotError.Is(err))
+}
@@ -0,0 +1,142 @@
+package lavasession
+
+import (
+	"sync/atomic"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// ProviderSessionsSnapshot holds the sessions of an endpoint, so a provider restarted within the epoch keeps accepting the relay numbers and cu sums the consumers continue from
+type ProviderSessionsSnapshot struct {
+	Endpoint  string                      `json:"endpoint"`
+	Consumers []*ConsumerSessionsSnapshot `json:"consumers"`
+}
+
+type ConsumerSessionsSnapshot struct {
+	Epoch               uint64                   `json:"epoch"`
+	Consumer            string                   `json:"consumer"`
+	DataReliability     bool                     `json:"data-reliability"`
+	BlockListed         bool                     `json:"block-listed"`
+	UsedComputeUnits    uint64                   `json:"used-compute-units"`
+	MaxComputeUnits     uint64                   `json:"max-compute-units"`
+	MissingComputeUnits uint64                   `json:"missing-compute-units"`
+	SelfProviderIndex   int64                    `json:"self-provider-index"`
+	PairedProviders     int64                    `json:"paired-providers"`
+	Sessions            []*SingleSessionSnapshot `json:"sessions"`
+}
+
+type SingleSessionSnapshot struct {
+	SessionID    uint64 `json:"session-id"`
+	CuSum        uint64 `json:"cu-sum"`
+	RelayNum     uint64 `json:"relay-num"`
+	PairingEpoch uint64 `json:"pairing-epoch"`
+}
+
+// Snapshot returns the sessions of the epochs still valid for use, sessions in the middle of a relay are skipped so the snapshot should be taken after the listeners stopped
+func (psm *ProviderSessionManager) Snapshot() *ProviderSessionsSnapshot {
+	psm.lock.RLock()
+	defer psm.lock.RUnlock()
+	snapshot := &ProviderSessionsSnapshot{Endpoint: psm.rpcProviderEndpoint.Key(), Consumers: []*ConsumerSessionsSnapshot{}}
+	for _, allConsumers := range []map[uint64]sessionData{psm.sessionsWithAllConsumers, psm.dataReliabilitySessionsWithAllConsumers} {
+		for epoch, epochSessions := range allConsumers {
+			if !IsEpochValidForUse(epoch, psm.atomicReadBlockedEpoch()) {
+				continue
+			}
+			for _, providerSessionsWithConsumer := range epochSessions.sessionMap {
+				snapshot.Consumers = append(snapshot.Consumers, providerSessionsWithConsumer.snapshot(epoch))
+			}
+		}
+	}
+	return snapshot
+}
+
+func (pswc *ProviderSessionsWithConsumer) snapshot(epoch uint64) *ConsumerSessionsSnapshot {
+	pswc.Lock.RLock()
+	defer pswc.Lock.RUnlock()
+	consumerSnapshot := &ConsumerSessionsSnapshot{
+		Epoch:             epoch,
+		Consumer:          pswc.consumerAddr,
+		DataReliability:   pswc.atomicReadIsDataReliability() == isDataReliabilityPSWC,
+		BlockListed:       pswc.atomicReadConsumerBlocked() == blockListedConsumer,
+		SelfProviderIndex: pswc.atomicReadProviderIndex(),
+		PairedProviders:   pswc.atomicReadPairedProviders(),
+		Sessions:          make([]*SingleSessionSnapshot, 0, len(pswc.Sessions)),
+	}
+	if pswc.epochData != nil { // data reliability sessions have no epoch data
+		consumerSnapshot.UsedComputeUnits = pswc.atomicReadUsedComputeUnits()
+		consumerSnapshot.MaxComputeUnits = pswc.atomicReadMaxComputeUnits()
+		consumerSnapshot.MissingComputeUnits = pswc.atomicReadMissingComputeUnits()
+	}
+	for sessionID, session := range pswc.Sessions {
+		if !session.lock.TryLock() {
+			utils.LavaFormatDebug("skipping session in use from the sessions snapshot", utils.Attribute{Key: "sessionID", Value: sessionID}, utils.Attribute{Key: "consumer", Value: pswc.consumerAddr})
+			continue
+		}
+		consumerSnapshot.Sessions = append(consumerSnapshot.Sessions, &SingleSessionSnapshot{
+			SessionID:    session.SessionID,
+			CuSum:        session.CuSum,
+			RelayNum:     session.RelayNum,
+			PairingEpoch: session.PairingEpoch,
+		})
+		session.lock.Unlock()
+	}
+	return consumerSnapshot
+}
+
+// Restore registers the snapshot's sessions, it's called on startup before relays are served. epochs that are no longer valid are skipped
+func (psm *ProviderSessionManager) Restore(snapshot *ProviderSessionsSnapshot) error {
+	if snapshot == nil {
+		return nil
+	}
+	if snapshot.Endpoint != psm.rpcProviderEndpoint.Key() {
+		return InvalidSessionsSnapshotError.Wrapf("snapshot of endpoint %s, session manager of endpoint %s", snapshot.Endpoint, psm.rpcProviderEndpoint.Key())
+	}
+	psm.lock.Lock()
+	defer psm.lock.Unlock()
+	restored := 0
+	for _, consumerSnapshot := range snapshot.Consumers {
+		if consumerSnapshot == nil || !psm.IsValidEpoch(consumerSnapshot.Epoch) {
+			continue
+		}
+		allConsumers := psm.sessionsWithAllConsumers
+		isDataReliability := uint32(notDataReliabilityPSWC)
+		var epochData *ProviderSessionsEpochData
+		if consumerSnapshot.DataReliability {
+			allConsumers = psm.dataReliabilitySessionsWithAllConsumers
+			isDataReliability = isDataReliabilityPSWC
+		} else {
+			epochData = &ProviderSessionsEpochData{
+				UsedComputeUnits:     consumerSnapshot.UsedComputeUnits,
+				MaxComputeUnits:      consumerSnapshot.MaxComputeUnits,
+				MissingComputeUnits:  consumerSnapshot.MissingComputeUnits,
+				EpochMaxComputeUnits: consumerSnapshot.MaxComputeUnits,
+			}
+		}
+		epochSessions, found := allConsumers[consumerSnapshot.Epoch]
+		if !found {
+			epochSessions = sessionData{sessionMap: make(map[string]*ProviderSessionsWithConsumer)}
+			allConsumers[consumerSnapshot.Epoch] = epochSessions
+		}
+		if _, found := epochSessions.sessionMap[consumerSnapshot.Consumer]; found {
+			// the consumer already relayed since startup, its live sessions are newer than the snapshot
+			continue
+		}
+		providerSessionsWithConsumer := NewProviderSessionsWithConsumer(consumerSnapshot.Consumer, epochData, isDataReliability, consumerSnapshot.SelfProviderIndex, consumerSnapshot.PairedProviders)
+		if consumerSnapshot.BlockListed {
+			atomic.StoreUint32(&providerSessionsWithConsumer.isBlockListed, blockListedConsumer)
+		}
+		for _, sessionSnapshot := range consumerSnapshot.Sessions {
+			providerSessionsWithConsumer.Sessions[sessionSnapshot.SessionID] = &SingleProviderSession{
+				userSessionsParent: providerSessionsWithConsumer,
+				SessionID:          sessionSnapshot.SessionID,
+				CuSum:              sessionSnapshot.CuSum,
+				RelayNum:           sessionSnapshot.RelayNum,
+				PairingEpoch:       sessionSnapshot.PairingEpoch,
+			}
+		}
+		epochSessions.sessionMap[consumerSnapshot.Consumer] = providerSessionsWithConsumer
+		restored++
+	}
+	utils.LavaFormatInfo("provider sessions restored from snapshot", utils.Attribute{Key: "endpoint", Value: snapshot.Endpoint}, utils.Attribute{Key: "consumers", Value: restored})
+	return nil
+}
@@ -13,17 +13,21 @@ import (
 )
 
 type ProviderSessionsEpochData struct {
-	UsedComputeUnits    uint64
-	MaxComputeUnits     uint64
-	MissingComputeUnits uint64
+	UsedComputeUnits     uint64
+	MaxComputeUnits      uint64
+	MissingComputeUnits  uint64
+	EpochMaxComputeUnits uint64 // MaxComputeUnits without the virtual epochs added by lava downtime
 }
 
 type RPCProviderEndpoint struct {
-	NetworkAddress string           `yaml:"network-address,omitempty" json:"network-address,omitempty" mapstructure:"network-address,omitempty"` // HOST:PORT
-	ChainID        string           `yaml:"chain-id,omitempty" json:"chain-id,omitempty" mapstructure:"chain-id"`                                // spec chain identifier
-	ApiInterface   string           `yaml:"api-interface,omitempty" json:"api-interface,omitempty" mapstructure:"api-interface"`
-	Geolocation    uint64           `yaml:"geolocation,omitempty" json:"geolocation,omitempty" mapstructure:"geolocation"`
-	NodeUrls       []common.NodeUrl `yaml:"node-urls,omitempty" json:"node-urls,omitempty" mapstructure:"node-urls"`
+	NetworkAddress           string                    `yaml:"network-address,omitempty" json:"network-address,omitempty" mapstructure:"network-address,omitempty"` // HOST:PORT
+	ChainID                  string                    `yaml:"chain-id,omitempty" json:"chain-id,omitempty" mapstructure:"chain-id"`                                // spec chain identifier
+	ApiInterface             string                    `yaml:"api-interface,omitempty" json:"api-interface,omitempty" mapstructure:"api-interface"`
+	Geolocation              uint64                    `yaml:"geolocation,omitempty" json:"geolocation,omitempty" mapstructure:"geolocation"`
+	NodeUrls                 []common.NodeUrl          `yaml:"node-urls,omitempty" json:"node-urls,omitempty" mapstructure:"node-urls"`
+	LightClient              *common.LightClientConfig `yaml:"light-client,omitempty" json:"light-client,omitempty" mapstructure:"light-client"`                                           // if set, block hashes read from the node are verified against light client headers before the chain tracker stores them
+	RequireEncryptedPayloads bool                      `yaml:"require-encrypted-payloads,omitempty" json:"require-encrypted-payloads,omitempty" mapstructure:"require-encrypted-payloads"` // relays with plaintext payloads are refused, subscriptions aren't encrypted so they are refused too
+	Wallet                   string                    `yaml:"wallet,omitempty" json:"wallet,omitempty" mapstructure:"wallet"`                                                             // keyring name of the provider address that serves the endpoint and claims its rewards, empty uses --from
 }
 
 func (endpoint *RPCProviderEndpoint) UrlsString() string {
@@ -47,6 +51,11 @@ func (endpoint *RPCProviderEndpoint) Validate() error {
 		if err != nil {
 			return err
 		}
+		// fail on startup rather than on the first connection if the tls files are wrong
+		_, err = url.ClientTLSConfig()
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -144,6 +153,10 @@ func (pswc *ProviderSessionsWithConsumer) atomicReadMaxComputeUnits() (maxComput
 	return atomic.LoadUint64(&pswc.epochData.MaxComputeUnits)
 }
 
+func (pswc *ProviderSessionsWithConsumer) atomicWriteMaxComputeUnits(maxComputeUnits uint64) {
+	atomic.StoreUint64(&pswc.epochData.MaxComputeUnits, maxComputeUnits)
+}
+
 func (pswc *ProviderSessionsWithConsumer) atomicReadUsedComputeUnits() (usedComputeUnits uint64) {
 	return atomic.LoadUint64(&pswc.epochData.UsedComputeUnits)
 }
@@ -0,0 +1,265 @@
+package lavasession
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	QoSHistoryFileFlag     = "qos-history-file"
+	QoSHistoryDecayFlag    = "qos-history-decay"
+	DefaultQoSHistoryDecay = 0.9 // the weight the history keeps on every epoch
+	qosHistorySaveInterval = time.Minute
+	minQoSHistoryDecay     = 0.000001 // history below this weight is dropped
+)
+
+// ProviderQoSHistory is the decayed relay history of a provider on a single spec
+type ProviderQoSHistory struct {
+	Relays   float64 `json:"relays"`
+	Failures float64 `json:"failures"`
+	Latency  float64 `json:"latency"` // sum of the successful relays latency in seconds
+}
+
+// Score is the smoothed success rate of the provider, a provider with no history scores 0.5
+func (pqh ProviderQoSHistory) Score() float64 {
+	return (pqh.Relays - pqh.Failures + 1) / (pqh.Relays + 2)
+}
+
+func (pqh ProviderQoSHistory) AverageLatency() time.Duration {
+	successes := pqh.Relays - pqh.Failures
+	if successes <= 0 {
+		return 0
+	}
+	return time.Duration(math.Round(pqh.Latency / successes * float64(time.Second)))
+}
+
+func (pqh *ProviderQoSHistory) decay(weight float64) {
+	pqh.Relays *= weight
+	pqh.Failures *= weight
+	pqh.Latency *= weight
+}
+
+type qosHistoryState struct {
+	Epoch       uint64                                    `json:"epoch"`
+	EpochBlocks uint64                                    `json:"epochBlocks"` // the smallest epoch change seen, used to count epochs passed while the consumer was down
+	Providers   map[string]map[string]*ProviderQoSHistory `json:"providers"`   // key == spec key, provider address
+}
+
+// QoSHistoryStore keeps the relay history of providers across consumer restarts, so a restarted consumer keeps preferring the providers
+// that served it well. the history is decayed on every epoch so recent relays weigh more, and saved to a file periodically
+type QoSHistoryStore struct {
+	lock  sync.RWMutex
+	path  string
+	decay float64
+	state qosHistoryState
+	dirty bool
+}
+
+// NewQoSHistoryStore loads the history saved at path if it exists
+func NewQoSHistoryStore(path string, decay float64) (*QoSHistoryStore, error) {
+	if decay <= 0 || decay > 1 || math.IsNaN(decay) {
+		return nil, InvalidQoSHistoryDecayError.Wrapf("decay: %f", decay)
+	}
+	store := &QoSHistoryStore{path: path, decay: decay, state: qosHistoryState{Providers: map[string]map[string]*ProviderQoSHistory{}}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, utils.LavaFormatError("failed reading qos history file", err, utils.Attribute{Key: "path", Value: path})
+	}
+	err = json.Unmarshal(data, &store.state)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed parsing qos history file", err, utils.Attribute{Key: "path", Value: path})
+	}
+	if store.state.Providers == nil {
+		store.state.Providers = map[string]map[string]*ProviderQoSHistory{}
+	}
+	utils.LavaFormatInfo("loaded qos history", utils.Attribute{Key: "path", Value: path}, utils.Attribute{Key: "epoch", Value: store.state.Epoch}, utils.Attribute{Key: "specs", Value: len(store.state.Providers)})
+	return store, nil
+}
+
+// OnEpoch decays the history once for every epoch passed since the last one, it is called by every session manager so repeated epochs are ignored
+func (qhs *QoSHistoryStore) OnEpoch(epoch uint64) {
+	if qhs == nil {
+		return
+	}
+	qhs.lock.Lock()
+	defer qhs.lock.Unlock()
+	if epoch <= qhs.state.Epoch {
+		return
+	}
+	epochs := uint64(1)
+	if qhs.state.Epoch != 0 {
+		passed := epoch - qhs.state.Epoch
+		if qhs.state.EpochBlocks == 0 || passed < qhs.state.EpochBlocks {
+			qhs.state.EpochBlocks = passed
+		}
+		epochs = passed / qhs.state.EpochBlocks
+	}
+	qhs.state.Epoch = epoch
+	qhs.dirty = true
+	weight := math.Pow(qhs.decay, float64(epochs))
+	for specKey, providers := range qhs.state.Providers {
+		for provider, history := range providers {
+			history.decay(weight)
+			if history.Relays < minQoSHistoryDecay {
+				delete(providers, provider)
+			}
+		}
+		if len(providers) == 0 {
+			delete(qhs.state.Providers, specKey)
+		}
+	}
+}
+
+func (qhs *QoSHistoryStore) RecordRelay(specKey string, provider string, latency time.Duration, failed bool) {
+	if qhs == nil {
+		return
+	}
+	qhs.lock.Lock()
+	defer qhs.lock.Unlock()
+	providers, ok := qhs.state.Providers[specKey]
+	if !ok {
+		providers = map[string]*ProviderQoSHistory{}
+		qhs.state.Providers[specKey] = providers
+	}
+	history, ok := providers[provider]
+	if !ok {
+		history = &ProviderQoSHistory{}
+		providers[provider] = history
+	}
+	history.Relays++
+	if failed {
+		history.Failures++
+	} else {
+		history.Latency += latency.Seconds()
+	}
+	qhs.dirty = true
+}
+
+// Score returns the score of the provider on the spec, a provider with no history gets the score of an unknown provider
+func (qhs *QoSHistoryStore) Score(specKey string, provider string) float64 {
+	history, _ := qhs.GetProviderHistory(specKey, provider)
+	return history.Score()
+}
+
+func (qhs *QoSHistoryStore) GetProviderHistory(specKey string, provider string) (history ProviderQoSHistory, found bool) {
+	if qhs == nil {
+		return ProviderQoSHistory{}, false
+	}
+	qhs.lock.RLock()
+	defer qhs.lock.RUnlock()
+	stored, found := qhs.state.Providers[specKey][provider]
+	if !found {
+		return ProviderQoSHistory{}, false
+	}
+	return *stored, true
+}
+
+// GetHistory returns a copy of the history of all providers, key == spec key, provider address
+func (qhs *QoSHistoryStore) GetHistory() map[string]map[string]ProviderQoSHistory {
+	qhs.lock.RLock()
+	defer qhs.lock.RUnlock()
+	history := make(map[string]map[string]ProviderQoSHistory, len(qhs.state.Providers))
+	for specKey, providers := range qhs.state.Providers {
+		history[specKey] = make(map[string]ProviderQoSHistory, len(providers))
+		for provider, providerHistory := range providers {
+			history[specKey][provider] = *providerHistory
+		}
+	}
+	return history
+}
+
+// ResetProvider removes the history of the provider on the spec, or on all specs if specKey is empty. returns false if there was none
+func (qhs *QoSHistoryStore) ResetProvider(specKey string, provider string) bool {
+	qhs.lock.Lock()
+	defer qhs.lock.Unlock()
+	removed := false
+	for key, providers := range qhs.state.Providers {
+		if specKey != "" && key != specKey {
+			continue
+		}
+		if _, ok := providers[provider]; ok {
+			delete(providers, provider)
+			removed = true
+		}
+	}
+	qhs.dirty = qhs.dirty || removed
+	return removed
+}
+
+// Save writes the history to the file if it changed since the last save, the file is replaced atomically
+func (qhs *QoSHistoryStore) Save() error {
+	qhs.lock.Lock()
+	defer qhs.lock.Unlock()
+	if !qhs.dirty {
+		return nil
+	}
+	data, err := json.Marshal(qhs.state)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(qhs.path), filepath.Base(qhs.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpFile.Name(), qhs.path)
+	if err != nil {
+		return err
+	}
+	qhs.dirty = false
+	return nil
+}
+
+// Start saves the history periodically and once more when ctx is done
+func (qhs *QoSHistoryStore) Start(ctx context.Context) {
+	if qhs == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(qosHistorySaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := qhs.Save(); err != nil {
+					utils.LavaFormatError("failed saving qos history", err, utils.Attribute{Key: "path", Value: qhs.path})
+				}
+				return
+			case <-ticker.C:
+				if err := qhs.Save(); err != nil {
+					utils.LavaFormatError("failed saving qos history", err, utils.Attribute{Key: "path", Value: qhs.path})
+				}
+			}
+		}
+	}()
+}
+
+// chooses a provider from addresses at random, weighted by the provider's history score
+// SetQoSHistory makes the session manager record relays in store and weigh the provider optimizer's choices by the history, set it before serving relays
+func (csm *ConsumerSessionManager) SetQoSHistory(store *QoSHistoryStore) {
+	csm.lock.Lock()
+	defer csm.lock.Unlock()
+	csm.qosHistory = store
+	store.OnEpoch(csm.atomicReadCurrentEpoch())
+	specKey := csm.rpcEndpoint.Key()
+	csm.providerOptimizer.SetHistoryScore(func(providerAddress string) float64 {
+		return store.Score(specKey, providerAddress)
+	})
+}
@@ -0,0 +1,89 @@
+package lavasession
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQoSHistoryDecayAndPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qos_history.json")
+	_, err := NewQoSHistoryStore(path, 0)
+	require.Error(t, err)
+	_, err = NewQoSHistoryStore(path, 1.5)
+	require.Error(t, err)
+
+	store, err := NewQoSHistoryStore(path, 0.5)
+	require.NoError(t, err)
+	store.OnEpoch(100)
+	for i := 0; i < 8; i++ {
+		store.RecordRelay("spec", "good", 100*time.Millisecond, false)
+		store.RecordRelay("spec", "bad", 0, i%2 == 0)
+	}
+	store.RecordRelay("other", "good", 0, true)
+	require.Greater(t, store.Score("spec", "good"), store.Score("spec", "bad"))
+	require.Equal(t, 0.5, store.Score("spec", "unknown"))
+	history, found := store.GetProviderHistory("spec", "good")
+	require.True(t, found)
+	require.Equal(t, 100*time.Millisecond, history.AverageLatency())
+
+	// the history survives a restart
+	require.NoError(t, store.Save())
+	restarted, err := NewQoSHistoryStore(path, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, store.GetHistory(), restarted.GetHistory())
+
+	// a single epoch halves the history, the epoch size is learned from the first change
+	restarted.OnEpoch(120)
+	history, _ = restarted.GetProviderHistory("spec", "good")
+	require.InDelta(t, 4, history.Relays, 0.0001)
+	restarted.OnEpoch(120) // the same epoch from another session manager
+	history, _ = restarted.GetProviderHistory("spec", "good")
+	require.InDelta(t, 4, history.Relays, 0.0001)
+	// epochs passed while the consumer was down are counted
+	restarted.OnEpoch(160)
+	history, _ = restarted.GetProviderHistory("spec", "good")
+	require.InDelta(t, 1, history.Relays, 0.0001)
+	require.Equal(t, 100*time.Millisecond, history.AverageLatency())
+
+	// reset on a single spec or on all of them
+	require.True(t, restarted.ResetProvider("spec", "good"))
+	_, found = restarted.GetProviderHistory("spec", "good")
+	require.False(t, found)
+	_, found = restarted.GetProviderHistory("other", "good")
+	require.True(t, found)
+	require.True(t, restarted.ResetProvider("", "good"))
+	_, found = restarted.GetProviderHistory("other", "good")
+	require.False(t, found)
+	require.False(t, restarted.ResetProvider("", "good"))
+}
+
+func TestQoSHistoryChooseProvider(t *testing.T) {
+	store, err := NewQoSHistoryStore(filepath.Join(t.TempDir(), "qos_history.json"), DefaultQoSHistoryDecay)
+	require.NoError(t, err)
+	specKey := (&RPCEndpoint{"stub", "stub", "stub", 0, false}).Key()
+	for i := 0; i < 100; i++ {
+		store.RecordRelay(specKey, "good", time.Millisecond, false)
+		store.RecordRelay(specKey, "bad", 0, true)
+	}
+	choices := func(csm *ConsumerSessionManager) map[string]int {
+		chosen := map[string]int{}
+		for i := 0; i < 1000; i++ {
+			chosen[csm.providerOptimizer.ChooseProvider([]string{"good", "bad"})]++
+		}
+		return chosen
+	}
+
+	// the history weighs the optimizer's choices
+	csm := NewConsumerSessionManager(&RPCEndpoint{"stub", "stub", "stub", 0, false}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_QOS, 0))
+	csm.SetQoSHistory(store)
+	require.Greater(t, choices(csm)["good"], 900)
+
+	// and the exploration rate still applies on top of it
+	csm = NewConsumerSessionManager(&RPCEndpoint{"stub", "stub", "stub", 0, false}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_QOS, 1))
+	csm.SetQoSHistory(store)
+	require.InDelta(t, 500, choices(csm)["bad"], 100)
+}
@@ -0,0 +1,32 @@
+package lavasession
+
+import "sync/atomic"
+
+func (csm *ConsumerSessionManager) atomicReadSignerGeneration() uint64 {
+	return atomic.LoadUint64(&csm.signerGeneration)
+}
+
+// SetSignerGeneration is called when the consumer rotates its signing key, new sessions are created for the new generation.
+// sessions of other generations keep serving the relays already using them, and are dropped from the pool once released
+func (csm *ConsumerSessionManager) SetSignerGeneration(generation uint64) {
+	atomic.StoreUint64(&csm.signerGeneration, generation)
+}
+
+// SessionsBySignerGeneration counts the sessions of each key generation still in the pool, a rotated key is drained when it has none
+func (csm *ConsumerSessionManager) SessionsBySignerGeneration() map[uint64]int {
+	csm.lock.RLock()
+	defer csm.lock.RUnlock()
+	sessions := map[uint64]int{}
+	countSessions := func(providers map[string]*ConsumerSessionsWithProvider) {
+		for _, cswp := range providers {
+			cswp.Lock.Lock()
+			for _, session := range cswp.Sessions {
+				sessions[session.SignerGeneration]++
+			}
+			cswp.Lock.Unlock()
+		}
+	}
+	countSessions(csm.pairing)
+	countSessions(csm.pairingPurge)
+	return sessions
+}
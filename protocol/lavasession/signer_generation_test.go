@@ -0,0 +1,39 @@
+package lavasession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerGenerationDrain(t *testing.T) {
+	s := createGRPCServer(t)
+	defer s.Stop()
+	ctx := context.Background()
+	csm := CreateConsumerSessionManager()
+	err := csm.UpdateAllProviders(firstEpochHeight, createPairingList(""))
+	require.Nil(t, err)
+	oldSession, _, _, _, err := csm.GetSession(ctx, cuForFirstRequest, nil)
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), oldSession.SignerGeneration)
+
+	// the key was rotated while a relay is using the old session, so a new session is created beside it
+	csm.SetSignerGeneration(1)
+	cswp := oldSession.Client
+	newSession, _, err := cswp.getConsumerSessionInstanceFromEndpoint(oldSession.Endpoint, 0, csm.atomicReadSignerGeneration())
+	require.Nil(t, err)
+	require.NotEqual(t, oldSession.SessionId, newSession.SessionId)
+	require.Equal(t, uint64(1), newSession.SignerGeneration)
+	require.Equal(t, map[uint64]int{0: 1, 1: 1}, csm.SessionsBySignerGeneration())
+
+	// the old session finishes its relay, and is dropped instead of being reused
+	err = csm.OnSessionDone(oldSession, firstEpochHeight, servicedBlockNumber, cuForFirstRequest, time.Millisecond, oldSession.CalculateExpectedLatency(2*time.Millisecond), servicedBlockNumber-1, numberOfProviders, numberOfProviders)
+	require.Nil(t, err)
+	anotherSession, _, err := cswp.getConsumerSessionInstanceFromEndpoint(oldSession.Endpoint, 0, csm.atomicReadSignerGeneration())
+	require.Nil(t, err)
+	require.NotEqual(t, oldSession.SessionId, anotherSession.SessionId)
+	require.Equal(t, uint64(1), anotherSession.SignerGeneration)
+	require.Equal(t, map[uint64]int{1: 2}, csm.SessionsBySignerGeneration())
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	MetricsListenFlagName = "metrics-listen-address"
+	MetricsPath           = "/metrics"
+)
+
+// StartPrometheusServer exposes all metrics registered on the default prometheus registry on addr
+func StartPrometheusServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.Handler())
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			utils.LavaFormatError("prometheus metrics server failed", err, utils.Attribute{Key: "address", Value: addr})
+		}
+	}()
+	utils.LavaFormatInfo("started prometheus metrics server", utils.Attribute{Key: "address", Value: addr}, utils.Attribute{Key: "path", Value: MetricsPath})
+	return nil
+}
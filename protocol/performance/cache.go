@@ -49,6 +49,12 @@ func (cache *Cache) GetEntry(ctx context.Context, request *pairingtypes.RelayReq
 }
 
 func (cache *Cache) SetEntry(ctx context.Context, request *pairingtypes.RelayRequest, apiInterface string, blockHash []byte, chainID string, bucketID string, reply *pairingtypes.RelayReply, finalized bool) error {
+	return cache.SetEntryWithExpiration(ctx, request, apiInterface, blockHash, chainID, bucketID, reply, finalized, 0)
+}
+
+// SetEntryWithExpiration sets an entry that expires after expiration instead of the cache's expiration for its finalization, zero keeps the cache's expiration
+// the cache server must honor the expiration, see RelayCacheSet.ExpirationMs
+func (cache *Cache) SetEntryWithExpiration(ctx context.Context, request *pairingtypes.RelayRequest, apiInterface string, blockHash []byte, chainID string, bucketID string, reply *pairingtypes.RelayReply, finalized bool, expiration time.Duration) error {
 	if cache == nil {
 		// TODO: try to connect again once in a while
 		return NotInitialisedError
@@ -57,6 +63,6 @@ func (cache *Cache) SetEntry(ctx context.Context, request *pairingtypes.RelayReq
 		return NotConnectedError.Wrapf("No client connected to address: %s", cache.address)
 	}
 	// TODO: handle disconnections and SetRelay error types here
-	_, err := cache.client.SetRelay(ctx, &pairingtypes.RelayCacheSet{Request: request, ApiInterface: apiInterface, BlockHash: blockHash, ChainID: chainID, Response: reply, Finalized: finalized, BucketID: bucketID})
+	_, err := cache.client.SetRelay(ctx, &pairingtypes.RelayCacheSet{Request: request, ApiInterface: apiInterface, BlockHash: blockHash, ChainID: chainID, Response: reply, Finalized: finalized, BucketID: bucketID, ExpirationMs: uint64(expiration.Milliseconds())})
 	return err
 }
@@ -1,23 +1,213 @@
 package provideroptimizer
 
-import "time"
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
 
-type ProviderOptimizer struct {
-	strategy Strategy
-}
+const (
+	ExplorationRateFlag    = "provider-exploration-rate"
+	DefaultExplorationRate = 0.1
+	DecayHalfLife          = 5 * time.Minute        // relay data loses half its weight in this time, so providers that improved or degraded are rescored
+	referenceLatency       = 500 * time.Millisecond // a provider of this latency has a latency score of 0.5
+	syncLagTolerance       = 1.0                    // blocks behind the expected block height that halve the sync score
+	priorWeight            = 1.0                    // weight of the assumed data of a provider with no relays, scores drift back to it as data decays
+	minDataWeight          = 0.01                   // providers whose data decayed below this weight are forgotten
+)
 
 type Strategy int
 
 const (
-	STRATEGY_QOS Strategy = iota
-	STRATEGY_COST
-	STRATEGY_PRIVACY
-	STRATEGY_ACCURACY
+	STRATEGY_QOS      Strategy = iota // availability, latency and sync
+	STRATEGY_COST                     // availability only, failed relays are paid for again in retries
+	STRATEGY_PRIVACY                  // relays are spread uniformly so no provider sees most of them
+	STRATEGY_ACCURACY                 // availability and sync, latency isn't traded for fresher data
 )
 
+// ProviderOptimizer scores the providers of an endpoint by their exponentially decayed latency, error rate and sync lag, and chooses
+// providers at random weighted by the score. a share of the choices, the exploration rate, is uniform so providers with a low score
+// are still relayed to and rescored
+type ProviderOptimizer struct {
+	strategy        Strategy
+	explorationRate float64
+	lock            sync.RWMutex
+	providers       map[string]*providerData
+	historyScore    func(providerAddress string) float64 // optional, a long term score multiplied into the recent one
+}
+
+// decayedValue is a sum of samples whose weight halves every DecayHalfLife
+type decayedValue struct {
+	sum     float64
+	weight  float64
+	updated time.Time
+}
+
+func (dv *decayedValue) decayedWeight(now time.Time) float64 {
+	if dv.weight == 0 {
+		return 0
+	}
+	return dv.weight * decayFactor(now.Sub(dv.updated))
+}
+
+func (dv *decayedValue) add(value float64, now time.Time) {
+	if dv.weight > 0 {
+		factor := decayFactor(now.Sub(dv.updated))
+		dv.sum *= factor
+		dv.weight *= factor
+	}
+	dv.sum += value
+	dv.weight++
+	dv.updated = now
+}
+
+// average mixes in prior with priorWeight, so it's prior without samples and returns to it as the samples decay
+func (dv *decayedValue) average(now time.Time, prior float64) float64 {
+	factor := 0.0
+	if dv.weight > 0 {
+		factor = decayFactor(now.Sub(dv.updated))
+	}
+	return (dv.sum*factor + prior*priorWeight) / (dv.weight*factor + priorWeight)
+}
+
+func decayFactor(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 1
+	}
+	return math.Exp2(-elapsed.Seconds() / DecayHalfLife.Seconds())
+}
+
+type providerData struct {
+	latency  decayedValue // seconds of successful relays
+	failures decayedValue // 1 for a failed relay and 0 for a successful one, the average is the error rate
+	syncLag  decayedValue // blocks behind the expected block height
+}
+
+func (pd *providerData) score(now time.Time, strategy Strategy) float64 {
+	if strategy == STRATEGY_PRIVACY {
+		return 1
+	}
+	availability := 1 - pd.failures.average(now, 0.5)
+	if strategy == STRATEGY_COST {
+		return availability
+	}
+	syncScore := 1 / (1 + pd.syncLag.average(now, 0)/syncLagTolerance)
+	if strategy == STRATEGY_ACCURACY {
+		return availability * syncScore
+	}
+	latency := pd.latency.average(now, referenceLatency.Seconds())
+	latencyScore := referenceLatency.Seconds() / (referenceLatency.Seconds() + latency)
+	return availability * latencyScore * syncScore
+}
+
+func (pd *providerData) stale(now time.Time) bool {
+	return pd.latency.decayedWeight(now) < minDataWeight && pd.failures.decayedWeight(now) < minDataWeight && pd.syncLag.decayedWeight(now) < minDataWeight
+}
+
+// AppendRelayData records a relay or a probe of the provider, the latency of failures isn't counted
 func (po *ProviderOptimizer) AppendRelayData(providerAddress string, latency time.Duration, failure bool) {
+	now := time.Now()
+	po.lock.Lock()
+	defer po.lock.Unlock()
+	data := po.providerDataUnsafe(providerAddress, now)
+	if failure {
+		data.failures.add(1, now)
+		return
+	}
+	data.failures.add(0, now)
+	data.latency.add(latency.Seconds(), now)
+}
+
+// AppendSyncData records how many blocks behind the expected block height a reply of the provider was
+func (po *ProviderOptimizer) AppendSyncData(providerAddress string, syncLag int64) {
+	if syncLag < 0 {
+		syncLag = 0
+	}
+	now := time.Now()
+	po.lock.Lock()
+	defer po.lock.Unlock()
+	po.providerDataUnsafe(providerAddress, now).syncLag.add(float64(syncLag), now)
+}
+
+func (po *ProviderOptimizer) providerDataUnsafe(providerAddress string, now time.Time) *providerData {
+	data, ok := po.providers[providerAddress]
+	if !ok {
+		// providers leave the pairing, a new one is a good time to forget the ones with no recent relays
+		for address, existing := range po.providers {
+			if existing.stale(now) {
+				delete(po.providers, address)
+			}
+		}
+		data = &providerData{}
+		po.providers[providerAddress] = data
+	}
+	return data
+}
+
+// SetHistoryScore weighs the choices by a long term score of the providers as well, such as one kept across restarts.
+// the privacy strategy ignores it
+func (po *ProviderOptimizer) SetHistoryScore(historyScore func(providerAddress string) float64) {
+	po.lock.Lock()
+	defer po.lock.Unlock()
+	po.historyScore = historyScore
+}
+
+// Score is the score of the provider between 0 and 1 by the optimizer's strategy, higher is better. a provider with no relays scores as
+// one of average availability and reference latency that's synced
+func (po *ProviderOptimizer) Score(providerAddress string) float64 {
+	po.lock.RLock()
+	defer po.lock.RUnlock()
+	return po.scoreUnsafe(providerAddress, time.Now())
+}
+
+func (po *ProviderOptimizer) scoreUnsafe(providerAddress string, now time.Time) float64 {
+	data, ok := po.providers[providerAddress]
+	if !ok {
+		data = &providerData{}
+	}
+	return data.score(now, po.strategy)
+}
+
+// ChooseProvider picks one of addresses at random, uniformly at the exploration rate and weighted by the providers score (times
+// their history score if set) otherwise
+func (po *ProviderOptimizer) ChooseProvider(addresses []string) string {
+	if len(addresses) == 0 {
+		return ""
+	}
+	if po.strategy == STRATEGY_PRIVACY || rand.Float64() < po.explorationRate {
+		return addresses[rand.Intn(len(addresses))]
+	}
+	now := time.Now()
+	po.lock.RLock()
+	scores := make([]float64, len(addresses))
+	total := 0.0
+	for idx, address := range addresses {
+		scores[idx] = po.scoreUnsafe(address, now)
+		if po.historyScore != nil {
+			scores[idx] *= po.historyScore(address)
+		}
+		total += scores[idx]
+	}
+	po.lock.RUnlock()
+	if total <= 0 {
+		return addresses[rand.Intn(len(addresses))]
+	}
+	choice := rand.Float64() * total
+	for idx, score := range scores {
+		if choice < score {
+			return addresses[idx]
+		}
+		choice -= score
+	}
+	return addresses[len(addresses)-1]
 }
 
-func NewProviderOptimizer(strategy Strategy) *ProviderOptimizer {
-	return &ProviderOptimizer{strategy: strategy}
+// NewProviderOptimizer creates an optimizer that explores uniformly at explorationRate, clamped to [0, 1]
+func NewProviderOptimizer(strategy Strategy, explorationRate float64) *ProviderOptimizer {
+	explorationRate = math.Max(0, math.Min(1, explorationRate))
+	if math.IsNaN(explorationRate) {
+		explorationRate = DefaultExplorationRate
+	}
+	return &ProviderOptimizer{strategy: strategy, explorationRate: explorationRate, providers: map[string]*providerData{}}
 }
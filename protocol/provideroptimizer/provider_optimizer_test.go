@@ -0,0 +1,116 @@
+package provideroptimizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderOptimizerScores(t *testing.T) {
+	po := NewProviderOptimizer(STRATEGY_QOS, 0)
+	unknownScore := po.Score("unknown")
+	for i := 0; i < 10; i++ {
+		po.AppendRelayData("fast", 100*time.Millisecond, false)
+		po.AppendRelayData("slow", 2*time.Second, false)
+		po.AppendRelayData("failing", 100*time.Millisecond, i%2 == 0)
+		po.AppendRelayData("lagging", 100*time.Millisecond, false)
+		po.AppendSyncData("lagging", 5)
+		po.AppendSyncData("fast", -1) // ahead of the expected block isn't rewarded
+	}
+	require.Greater(t, po.Score("fast"), unknownScore)
+	require.Less(t, po.Score("slow"), po.Score("fast"))
+	require.Less(t, po.Score("failing"), po.Score("fast"))
+	require.Less(t, po.Score("lagging"), po.Score("fast"))
+
+	// the data decays, a provider that wasn't relayed to for long scores as an unknown one
+	for _, data := range po.providers {
+		for _, value := range []*decayedValue{&data.latency, &data.failures, &data.syncLag} {
+			value.updated = value.updated.Add(-100 * DecayHalfLife)
+		}
+	}
+	require.InDelta(t, unknownScore, po.Score("slow"), 0.001)
+	// a new provider forgets the stale ones
+	po.AppendRelayData("new", 100*time.Millisecond, false)
+	require.Len(t, po.providers, 1)
+}
+
+func TestProviderOptimizerChooseProvider(t *testing.T) {
+	addresses := []string{"good", "bad"}
+	choices := func(po *ProviderOptimizer) map[string]int {
+		chosen := map[string]int{}
+		for i := 0; i < 2000; i++ {
+			chosen[po.ChooseProvider(addresses)]++
+		}
+		return chosen
+	}
+
+	po := NewProviderOptimizer(STRATEGY_QOS, 0)
+	for i := 0; i < 20; i++ {
+		po.AppendRelayData("good", 50*time.Millisecond, false)
+		po.AppendRelayData("bad", 3*time.Second, true)
+	}
+	chosen := choices(po)
+	require.Greater(t, chosen["good"], 1800)
+	require.Greater(t, chosen["bad"], 0) // chosen by score, not always the best
+
+	// full exploration ignores the scores
+	po.explorationRate = 1
+	chosen = choices(po)
+	require.InDelta(t, 1000, chosen["bad"], 200)
+
+	require.Equal(t, "", po.ChooseProvider(nil))
+	require.Equal(t, 1.0, NewProviderOptimizer(STRATEGY_QOS, 2).explorationRate)
+}
+
+func TestProviderOptimizerStrategies(t *testing.T) {
+	scores := func(strategy Strategy) (slow float64, lagging float64, failing float64) {
+		po := NewProviderOptimizer(strategy, 0)
+		for i := 0; i < 10; i++ {
+			po.AppendRelayData("slow", 2*time.Second, false)
+			po.AppendRelayData("lagging", 100*time.Millisecond, false)
+			po.AppendSyncData("lagging", 5)
+			po.AppendRelayData("failing", 100*time.Millisecond, i%2 == 0)
+		}
+		return po.Score("slow"), po.Score("lagging"), po.Score("failing")
+	}
+
+	// accuracy doesn't trade sync for latency
+	slow, lagging, failing := scores(STRATEGY_ACCURACY)
+	require.Greater(t, slow, lagging)
+	require.Greater(t, slow, failing)
+	// cost only weighs availability
+	slow, lagging, failing = scores(STRATEGY_COST)
+	require.InDelta(t, slow, lagging, 0.001)
+	require.Greater(t, lagging, failing)
+	// privacy weighs all providers the same
+	slow, lagging, failing = scores(STRATEGY_PRIVACY)
+	require.Equal(t, []float64{1, 1, 1}, []float64{slow, lagging, failing})
+}
+
+func TestProviderOptimizerHistoryScore(t *testing.T) {
+	addresses := []string{"good", "bad"}
+	history := func(providerAddress string) float64 {
+		if providerAddress == "bad" {
+			return 0.01
+		}
+		return 1
+	}
+	choices := func(po *ProviderOptimizer) map[string]int {
+		chosen := map[string]int{}
+		for i := 0; i < 2000; i++ {
+			chosen[po.ChooseProvider(addresses)]++
+		}
+		return chosen
+	}
+
+	// providers with the same recent score are weighed by their history
+	po := NewProviderOptimizer(STRATEGY_QOS, 0)
+	po.SetHistoryScore(history)
+	require.Greater(t, choices(po)["good"], 1900)
+
+	// privacy ignores the history
+	po = NewProviderOptimizer(STRATEGY_PRIVACY, 0)
+	po.SetHistoryScore(history)
+	require.InDelta(t, 1000, choices(po)["bad"], 200)
+}
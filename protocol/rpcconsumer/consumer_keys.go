@@ -0,0 +1,217 @@
+package rpcconsumer
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	ProjectKeysFlag = "project-keys"
+	// a rotated key is kept this long even without sessions, relays that read its generation before the rotation may still create one
+	ConsumerKeyDrainGracePeriod = time.Minute
+)
+
+var (
+	ConsumerKeyNotLoadedError = errors.New("consumer key isn't loaded")
+	ConsumerKeyInUseError     = errors.New("consumer key is in use")
+)
+
+// signerSessions is implemented by the consumer session manager
+type signerSessions interface {
+	SetSignerGeneration(generation uint64)
+	SessionsBySignerGeneration() map[uint64]int
+}
+
+type consumerKey struct {
+	name    string
+	privKey *btcec.PrivateKey
+	address string
+}
+
+type consumerKeyStatus struct {
+	Name             string `json:"name"`
+	Address          string `json:"address"`
+	Active           bool   `json:"active"`
+	DrainingSessions int    `json:"drainingSessions"`
+}
+
+// ConsumerKeys holds the project keys the consumer can sign relays with.
+// every provider session is signed by the key that was active when it was created, so rotating the key drains the old sessions instead of breaking them
+type ConsumerKeys struct {
+	lock            sync.RWMutex
+	keys            map[string]*consumerKey // loaded keys by address
+	generation      uint64                  // the generation of the active key, incremented on every rotation
+	signers         map[uint64]*consumerKey // the key of every generation that still has sessions
+	rotated         map[uint64]time.Time    // when each rotated generation in signers stopped being active
+	sessionManagers []signerSessions
+	loadKey         func(keyName string) (*consumerKey, error)
+	now             func() time.Time
+}
+
+func newConsumerKeys(activeKey *consumerKey, loadKey func(keyName string) (*consumerKey, error)) *ConsumerKeys {
+	return &ConsumerKeys{
+		keys:    map[string]*consumerKey{activeKey.address: activeKey},
+		signers: map[uint64]*consumerKey{0: activeKey},
+		rotated: map[uint64]time.Time{},
+		loadKey: loadKey,
+		now:     time.Now,
+	}
+}
+
+// LoadConsumerKeys loads the --from key as the active key and the project keys from the keyring
+func LoadConsumerKeys(clientCtx client.Context, projectKeyNames []string) (*ConsumerKeys, error) {
+	keyName, err := getConsumerKeyName(clientCtx)
+	if err != nil {
+		return nil, err
+	}
+	loadKey := func(keyName string) (*consumerKey, error) {
+		privKey, addr, err := getConsumerKeyByName(clientCtx, keyName)
+		if err != nil {
+			return nil, err
+		}
+		return &consumerKey{name: keyName, privKey: privKey, address: addr.String()}, nil
+	}
+	activeKey, err := loadKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	ck := newConsumerKeys(activeKey, loadKey)
+	for _, projectKeyName := range projectKeyNames {
+		_, err = ck.AddKey(projectKeyName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ck, nil
+}
+
+// ActiveAddress returns the address of the key new sessions are signed with
+func (ck *ConsumerKeys) ActiveAddress() sdk.AccAddress {
+	ck.lock.RLock()
+	defer ck.lock.RUnlock()
+	addr, _ := sdk.AccAddressFromBech32(ck.signers[ck.generation].address)
+	return addr
+}
+
+// RegisterSessionManager makes the session manager create its sessions for the active key generation
+func (ck *ConsumerKeys) RegisterSessionManager(csm *lavasession.ConsumerSessionManager) {
+	ck.registerSignerSessions(csm)
+}
+
+func (ck *ConsumerKeys) registerSignerSessions(sessions signerSessions) {
+	ck.lock.Lock()
+	defer ck.lock.Unlock()
+	sessions.SetSignerGeneration(ck.generation)
+	ck.sessionManagers = append(ck.sessionManagers, sessions)
+}
+
+// SignerKey returns the private key the sessions of generation are signed with
+func (ck *ConsumerKeys) SignerKey(generation uint64) (*btcec.PrivateKey, error) {
+	ck.lock.RLock()
+	defer ck.lock.RUnlock()
+	signer, ok := ck.signers[generation]
+	if !ok {
+		return nil, utils.LavaFormatError("no consumer key for session generation, it was drained", nil, utils.Attribute{Key: "generation", Value: generation}, utils.Attribute{Key: "activeGeneration", Value: ck.generation})
+	}
+	return signer.privKey, nil
+}
+
+// AddKey loads a project key from the keyring so it can be rotated to, returns its address
+func (ck *ConsumerKeys) AddKey(keyName string) (string, error) {
+	key, err := ck.loadKey(keyName)
+	if err != nil {
+		return "", err
+	}
+	ck.lock.Lock()
+	defer ck.lock.Unlock()
+	if _, ok := ck.keys[key.address]; ok {
+		return "", utils.LavaFormatWarning("consumer key already loaded", nil, utils.Attribute{Key: "name", Value: keyName}, utils.Attribute{Key: "address", Value: key.address})
+	}
+	ck.keys[key.address] = key
+	utils.LavaFormatInfo("loaded consumer key", utils.Attribute{Key: "name", Value: keyName}, utils.Attribute{Key: "address", Value: key.address})
+	return key.address, nil
+}
+
+// RemoveKey unloads a key that isn't active and whose sessions were drained
+func (ck *ConsumerKeys) RemoveKey(address string) error {
+	ck.lock.Lock()
+	defer ck.lock.Unlock()
+	if _, ok := ck.keys[address]; !ok {
+		return utils.LavaFormatWarning("can't remove consumer key", ConsumerKeyNotLoadedError, utils.Attribute{Key: "address", Value: address})
+	}
+	drainingSessions := ck.pruneDrainedSignersUnsafe()
+	if _, draining := drainingSessions[address]; draining || ck.signers[ck.generation].address == address {
+		return utils.LavaFormatWarning("consumer key is still signing sessions", ConsumerKeyInUseError, utils.Attribute{Key: "address", Value: address}, utils.Attribute{Key: "drainingSessions", Value: drainingSessions[address]})
+	}
+	delete(ck.keys, address)
+	utils.LavaFormatInfo("removed consumer key", utils.Attribute{Key: "address", Value: address})
+	return nil
+}
+
+// Rotate makes a loaded key the active key, sessions of the previous key serve the relays already using them and are then dropped.
+// the previous key isn't pruned here, a relay may have read its generation and not created its session yet
+func (ck *ConsumerKeys) Rotate(address string) error {
+	ck.lock.Lock()
+	defer ck.lock.Unlock()
+	key, ok := ck.keys[address]
+	if !ok {
+		return utils.LavaFormatWarning("can't rotate consumer key", ConsumerKeyNotLoadedError, utils.Attribute{Key: "address", Value: address})
+	}
+	previous := ck.signers[ck.generation]
+	if previous.address == address {
+		return utils.LavaFormatWarning("consumer key is already active", ConsumerKeyInUseError, utils.Attribute{Key: "address", Value: address})
+	}
+	ck.rotated[ck.generation] = ck.now()
+	ck.generation++
+	ck.signers[ck.generation] = key
+	for _, csm := range ck.sessionManagers {
+		csm.SetSignerGeneration(ck.generation)
+	}
+	utils.LavaFormatInfo("rotated consumer key", utils.Attribute{Key: "previous", Value: previous.address}, utils.Attribute{Key: "active", Value: address}, utils.Attribute{Key: "generation", Value: ck.generation})
+	return nil
+}
+
+// Status lists the loaded keys and how many sessions each rotated key still has
+func (ck *ConsumerKeys) Status() []consumerKeyStatus {
+	ck.lock.Lock()
+	defer ck.lock.Unlock()
+	drainingSessions := ck.pruneDrainedSignersUnsafe()
+	status := make([]consumerKeyStatus, 0, len(ck.keys))
+	for address, key := range ck.keys {
+		status = append(status, consumerKeyStatus{Name: key.name, Address: address, Active: ck.signers[ck.generation].address == address, DrainingSessions: drainingSessions[address]})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Name < status[j].Name })
+	return status
+}
+
+// drops the keys of generations rotated more than the grace period ago with no sessions left, returns the sessions left per address
+// of the rotated generations that are kept
+func (ck *ConsumerKeys) pruneDrainedSignersUnsafe() map[string]int {
+	sessions := map[uint64]int{}
+	for _, csm := range ck.sessionManagers {
+		for generation, count := range csm.SessionsBySignerGeneration() {
+			sessions[generation] += count
+		}
+	}
+	drainingSessions := map[string]int{}
+	for generation, signer := range ck.signers {
+		if generation == ck.generation {
+			continue
+		}
+		if sessions[generation] == 0 && ck.now().Sub(ck.rotated[generation]) >= ConsumerKeyDrainGracePeriod {
+			delete(ck.signers, generation)
+			delete(ck.rotated, generation)
+			continue
+		}
+		drainingSessions[signer.address] += sessions[generation]
+	}
+	return drainingSessions
+}
@@ -0,0 +1,109 @@
+package rpcconsumer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	ConsumerKeysAdminAddressFlag = "keys-admin-address"
+	ConsumerKeysAdminPath        = "/lava/keys"
+	ConsumerKeysRotatePath       = "/lava/keys/rotate"
+	consumerKeyNameParam         = "name"
+	consumerKeyAddressParam      = "address"
+)
+
+// StartConsumerKeysAdminServer serves the consumer keys on addr:
+// GET lists the loaded keys, POST loads a keyring key by the name query param, DELETE unloads a drained key by the address query param,
+// and POST on the rotate path makes the key of the address query param the active signing key
+func StartConsumerKeysAdminServer(addr string, consumerKeys *ConsumerKeys) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ConsumerKeysAdminPath, func(resp http.ResponseWriter, req *http.Request) {
+		consumerKeysHandler(resp, req, consumerKeys)
+	})
+	mux.HandleFunc(ConsumerKeysRotatePath, func(resp http.ResponseWriter, req *http.Request) {
+		consumerKeysRotateHandler(resp, req, consumerKeys)
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			utils.LavaFormatError("consumer keys admin server failed", err, utils.Attribute{Key: "address", Value: addr})
+		}
+	}()
+	utils.LavaFormatInfo("started consumer keys admin server", utils.Attribute{Key: "address", Value: addr}, utils.Attribute{Key: "path", Value: ConsumerKeysAdminPath})
+}
+
+func consumerKeysHandler(resp http.ResponseWriter, req *http.Request, consumerKeys *ConsumerKeys) {
+	switch req.Method {
+	case http.MethodGet:
+		writeConsumerKeysStatus(resp, consumerKeys)
+	case http.MethodPost:
+		name := req.URL.Query().Get(consumerKeyNameParam)
+		if name == "" {
+			http.Error(resp, "missing "+consumerKeyNameParam+" query param", http.StatusBadRequest)
+			return
+		}
+		_, err := consumerKeys.AddKey(name)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeConsumerKeysStatus(resp, consumerKeys)
+	case http.MethodDelete:
+		address := req.URL.Query().Get(consumerKeyAddressParam)
+		if address == "" {
+			http.Error(resp, "missing "+consumerKeyAddressParam+" query param", http.StatusBadRequest)
+			return
+		}
+		err := consumerKeys.RemoveKey(address)
+		if err != nil {
+			http.Error(resp, err.Error(), consumerKeysErrorStatus(err))
+			return
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	default:
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func consumerKeysRotateHandler(resp http.ResponseWriter, req *http.Request, consumerKeys *ConsumerKeys) {
+	if req.Method != http.MethodPost {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	address := req.URL.Query().Get(consumerKeyAddressParam)
+	if address == "" {
+		http.Error(resp, "missing "+consumerKeyAddressParam+" query param", http.StatusBadRequest)
+		return
+	}
+	err := consumerKeys.Rotate(address)
+	if err != nil {
+		http.Error(resp, err.Error(), consumerKeysErrorStatus(err))
+		return
+	}
+	writeConsumerKeysStatus(resp, consumerKeys)
+}
+
+// unknown keys are not found, keys that are active or still draining conflict with the request
+func consumerKeysErrorStatus(err error) int {
+	if errors.Is(err, ConsumerKeyNotLoadedError) {
+		return http.StatusNotFound
+	}
+	return http.StatusConflict
+}
+
+func writeConsumerKeysStatus(resp http.ResponseWriter, consumerKeys *ConsumerKeys) {
+	resp.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(resp).Encode(consumerKeys.Status())
+	if err != nil {
+		utils.LavaFormatWarning("failed writing consumer keys reply", err)
+	}
+}
@@ -0,0 +1,207 @@
+package rpcconsumer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/utils/sigs"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSignerSessions counts sessions per key generation like the consumer session manager
+type fakeSignerSessions struct {
+	lock       sync.Mutex
+	generation uint64
+	sessions   map[uint64]int
+}
+
+func (fss *fakeSignerSessions) SetSignerGeneration(generation uint64) {
+	fss.lock.Lock()
+	defer fss.lock.Unlock()
+	fss.generation = generation
+}
+
+func (fss *fakeSignerSessions) SessionsBySignerGeneration() map[uint64]int {
+	fss.lock.Lock()
+	defer fss.lock.Unlock()
+	sessions := map[uint64]int{}
+	for generation, count := range fss.sessions {
+		if count > 0 {
+			sessions[generation] = count
+		}
+	}
+	return sessions
+}
+
+func (fss *fakeSignerSessions) setSessions(generation uint64, count int) {
+	fss.lock.Lock()
+	defer fss.lock.Unlock()
+	fss.sessions[generation] = count
+}
+
+// newTestConsumerKeys loads keys from a fake keyring of the given names, the first is active
+func newTestConsumerKeys(t *testing.T, keyNames ...string) (*ConsumerKeys, map[string]string, *fakeSignerSessions, *time.Time) {
+	keyring := map[string]*consumerKey{}
+	addresses := map[string]string{}
+	for _, keyName := range keyNames {
+		privKey, addr := sigs.GenerateFloatingKey()
+		keyring[keyName] = &consumerKey{name: keyName, privKey: privKey, address: addr.String()}
+		addresses[keyName] = addr.String()
+	}
+	loadKey := func(keyName string) (*consumerKey, error) {
+		key, ok := keyring[keyName]
+		if !ok {
+			return nil, errors.New("key not found in keyring")
+		}
+		return key, nil
+	}
+	ck := newConsumerKeys(keyring[keyNames[0]], loadKey)
+	now := time.Now()
+	ck.now = func() time.Time { return now }
+	sessions := &fakeSignerSessions{sessions: map[uint64]int{}}
+	ck.registerSignerSessions(sessions)
+	return ck, addresses, sessions, &now
+}
+
+func TestConsumerKeysRotateAndDrain(t *testing.T) {
+	ck, addresses, sessions, now := newTestConsumerKeys(t, "main", "backup")
+	address, err := ck.AddKey("backup")
+	require.NoError(t, err)
+	require.Equal(t, addresses["backup"], address)
+	_, err = ck.AddKey("backup")
+	require.Error(t, err)
+	_, err = ck.AddKey("missing")
+	require.Error(t, err)
+
+	mainKey, err := ck.SignerKey(0)
+	require.NoError(t, err)
+	sessions.setSessions(0, 2)
+	require.ErrorIs(t, ck.Rotate(addresses["main"]), ConsumerKeyInUseError)
+	require.ErrorIs(t, ck.Rotate("lava@unknown"), ConsumerKeyNotLoadedError)
+
+	require.NoError(t, ck.Rotate(addresses["backup"]))
+	require.Equal(t, uint64(1), sessions.generation)
+	require.Equal(t, addresses["backup"], ck.ActiveAddress().String())
+	// relays already using the old sessions still sign with the old key
+	oldKey, err := ck.SignerKey(0)
+	require.NoError(t, err)
+	require.Equal(t, mainKey, oldKey)
+	require.Equal(t, []consumerKeyStatus{
+		{Name: "backup", Address: addresses["backup"], Active: true},
+		{Name: "main", Address: addresses["main"], DrainingSessions: 2},
+	}, ck.Status())
+
+	// a drained key is kept for the grace period, a relay may have read its generation without creating its session yet
+	sessions.setSessions(0, 0)
+	require.Len(t, ck.Status(), 2)
+	_, err = ck.SignerKey(0)
+	require.NoError(t, err)
+	*now = now.Add(ConsumerKeyDrainGracePeriod)
+	ck.Status()
+	_, err = ck.SignerKey(0)
+	require.Error(t, err)
+	_, err = ck.SignerKey(1)
+	require.NoError(t, err)
+}
+
+func TestConsumerKeysRotateDoesNotPrune(t *testing.T) {
+	ck, addresses, _, now := newTestConsumerKeys(t, "main", "backup")
+	_, err := ck.AddKey("backup")
+	require.NoError(t, err)
+	// the previous key had no sessions when rotating, a relay that read its generation can still sign with it
+	*now = now.Add(time.Hour)
+	require.NoError(t, ck.Rotate(addresses["backup"]))
+	_, err = ck.SignerKey(0)
+	require.NoError(t, err)
+	// rotating back starts a new generation with the same key
+	require.NoError(t, ck.Rotate(addresses["main"]))
+	_, err = ck.SignerKey(1)
+	require.NoError(t, err)
+	require.Equal(t, addresses["main"], ck.ActiveAddress().String())
+}
+
+func TestConsumerKeysRemove(t *testing.T) {
+	ck, addresses, sessions, now := newTestConsumerKeys(t, "main", "backup")
+	require.ErrorIs(t, ck.RemoveKey(addresses["backup"]), ConsumerKeyNotLoadedError)
+	_, err := ck.AddKey("backup")
+	require.NoError(t, err)
+	// the active key can't be removed
+	require.ErrorIs(t, ck.RemoveKey(addresses["main"]), ConsumerKeyInUseError)
+	// a loaded key that never signed is removed right away
+	require.NoError(t, ck.RemoveKey(addresses["backup"]))
+	require.Len(t, ck.Status(), 1)
+
+	_, err = ck.AddKey("backup")
+	require.NoError(t, err)
+	sessions.setSessions(0, 1)
+	require.NoError(t, ck.Rotate(addresses["backup"]))
+	require.ErrorIs(t, ck.RemoveKey(addresses["main"]), ConsumerKeyInUseError)
+	sessions.setSessions(0, 0)
+	// drained but within the grace period
+	require.ErrorIs(t, ck.RemoveKey(addresses["main"]), ConsumerKeyInUseError)
+	*now = now.Add(ConsumerKeyDrainGracePeriod)
+	require.NoError(t, ck.RemoveKey(addresses["main"]))
+	require.Equal(t, []consumerKeyStatus{{Name: "backup", Address: addresses["backup"], Active: true}}, ck.Status())
+}
+
+func serveConsumerKeys(ck *ConsumerKeys, method string, target string) (int, []consumerKeyStatus) {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	if req.URL.Path == ConsumerKeysRotatePath {
+		consumerKeysRotateHandler(recorder, req, ck)
+	} else {
+		consumerKeysHandler(recorder, req, ck)
+	}
+	status := []consumerKeyStatus{}
+	json.Unmarshal(recorder.Body.Bytes(), &status)
+	return recorder.Code, status
+}
+
+func TestConsumerKeysAdminHandlers(t *testing.T) {
+	ck, addresses, sessions, now := newTestConsumerKeys(t, "main", "backup")
+	code, status := serveConsumerKeys(ck, http.MethodGet, ConsumerKeysAdminPath)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, status, 1)
+
+	code, status = serveConsumerKeys(ck, http.MethodPost, ConsumerKeysAdminPath+"?name=backup")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, status, 2)
+	code, _ = serveConsumerKeys(ck, http.MethodPost, ConsumerKeysAdminPath+"?name=missing")
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = serveConsumerKeys(ck, http.MethodPost, ConsumerKeysAdminPath)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	sessions.setSessions(0, 1)
+	code, status = serveConsumerKeys(ck, http.MethodPost, ConsumerKeysRotatePath+"?address="+addresses["backup"])
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []consumerKeyStatus{
+		{Name: "backup", Address: addresses["backup"], Active: true},
+		{Name: "main", Address: addresses["main"], DrainingSessions: 1},
+	}, status)
+	code, _ = serveConsumerKeys(ck, http.MethodPost, ConsumerKeysRotatePath+"?address="+addresses["backup"])
+	require.Equal(t, http.StatusConflict, code)
+	code, _ = serveConsumerKeys(ck, http.MethodPost, ConsumerKeysRotatePath+"?address=lava@unknown")
+	require.Equal(t, http.StatusNotFound, code)
+	code, _ = serveConsumerKeys(ck, http.MethodGet, ConsumerKeysRotatePath+"?address="+addresses["main"])
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+
+	// a key that is still draining conflicts, an unknown key isn't found
+	code, _ = serveConsumerKeys(ck, http.MethodDelete, ConsumerKeysAdminPath+"?address="+addresses["main"])
+	require.Equal(t, http.StatusConflict, code)
+	code, _ = serveConsumerKeys(ck, http.MethodDelete, ConsumerKeysAdminPath+"?address=lava@unknown")
+	require.Equal(t, http.StatusNotFound, code)
+	code, _ = serveConsumerKeys(ck, http.MethodDelete, ConsumerKeysAdminPath)
+	require.Equal(t, http.StatusBadRequest, code)
+	sessions.setSessions(0, 0)
+	*now = now.Add(ConsumerKeyDrainGracePeriod)
+	code, _ = serveConsumerKeys(ck, http.MethodDelete, ConsumerKeysAdminPath+"?address="+addresses["main"])
+	require.Equal(t, http.StatusNoContent, code)
+
+	code, _ = serveConsumerKeys(ck, http.MethodPut, ConsumerKeysAdminPath)
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+}
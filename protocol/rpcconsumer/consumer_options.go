@@ -0,0 +1,114 @@
+package rpcconsumer
+
+import (
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/lavanet/lava/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConsumerOptions holds the optional consumer features, the zero value of a field keeps its feature off or at its default
+type ConsumerOptions struct {
+	SpecOverlays             map[string]*statetracker.SpecOverlay
+	QuotaWebhooks            *QuotaWebhookNotifier
+	ErrorBudgetPolicy        *lavasession.ErrorBudgetPolicy
+	QoSHistory               *lavasession.QoSHistoryStore
+	ExplorationRate          float64
+	GrpcDescriptors          *chainlib.GrpcDescriptors
+	PairingPrefetchBlocks    uint64
+	LavaNodeBackups          []string
+	SubscriptionThresholds   statetracker.SubscriptionThresholds
+	ProtocolVersionAction    statetracker.ProtocolVersionAction
+	StateTrackerDebugAddress string
+	UpdaterParallelism       uint64
+	ProcessingLagAlertBlocks uint64
+	TxGasAdjustment          float64
+}
+
+// ParseConsumerOptions builds the consumer options from the rpcconsumer command flags
+func ParseConsumerOptions(cmd *cobra.Command, networkChainId string) (*ConsumerOptions, error) {
+	options := &ConsumerOptions{}
+	specOverlayFile, err := cmd.Flags().GetString(statetracker.SpecOverlayFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read spec overlay flag", err)
+	}
+	options.SpecOverlays, err = statetracker.ReadSpecOverlays(specOverlayFile, networkChainId)
+	if err != nil {
+		return nil, err
+	}
+	options.QuotaWebhooks, err = ParseQuotaWebhooks(viper.GetViper())
+	if err != nil {
+		return nil, err
+	}
+	options.ErrorBudgetPolicy, err = parseErrorBudgetPolicy(cmd)
+	if err != nil {
+		return nil, err
+	}
+	options.QoSHistory, err = parseQoSHistory(cmd)
+	if err != nil {
+		return nil, err
+	}
+	options.ExplorationRate, err = cmd.Flags().GetFloat64(provideroptimizer.ExplorationRateFlag)
+	if err != nil {
+		return nil, err
+	}
+	if options.ExplorationRate < 0 || options.ExplorationRate > 1 {
+		return nil, utils.LavaFormatError("invalid provider exploration rate, must be between 0 and 1", nil, utils.Attribute{Key: "explorationRate", Value: options.ExplorationRate})
+	}
+	grpcDescriptorSetFile, err := cmd.Flags().GetString(chainlib.GrpcDescriptorSetFlagName)
+	if err != nil {
+		return nil, err
+	}
+	options.GrpcDescriptors, err = chainlib.LoadGrpcDescriptors(grpcDescriptorSetFile)
+	if err != nil {
+		return nil, err
+	}
+	options.PairingPrefetchBlocks, err = cmd.Flags().GetUint64(statetracker.PairingPrefetchBlocksFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read pairing prefetch blocks flag", err)
+	}
+	options.LavaNodeBackups, err = cmd.Flags().GetStringSlice(statetracker.LavaNodeBackupsFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read lava node backups flag", err)
+	}
+	subscriptionCuThresholds, err := cmd.Flags().GetFloat64Slice(statetracker.SubscriptionCuThresholdsFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read subscription cu thresholds flag", err)
+	}
+	subscriptionExpiryDays, err := cmd.Flags().GetUintSlice(statetracker.SubscriptionExpiryDaysFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read subscription expiry days flag", err)
+	}
+	options.SubscriptionThresholds = statetracker.SubscriptionThresholds{CuUsed: subscriptionCuThresholds}
+	for _, days := range subscriptionExpiryDays {
+		options.SubscriptionThresholds.DaysToExpiry = append(options.SubscriptionThresholds.DaysToExpiry, uint64(days))
+	}
+	options.ProcessingLagAlertBlocks, err = cmd.Flags().GetUint64(statetracker.ProcessingLagAlertFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read state tracker lag alert flag", err)
+	}
+	options.TxGasAdjustment, err = cmd.Flags().GetFloat64(statetracker.TxGasAdjustmentFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read tx gas adjustment flag", err)
+	}
+	options.UpdaterParallelism, err = cmd.Flags().GetUint64(statetracker.UpdaterParallelismFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read state tracker parallelism flag", err)
+	}
+	options.StateTrackerDebugAddress, err = cmd.Flags().GetString(statetracker.StateTrackerDebugAddressFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read state tracker debug address flag", err)
+	}
+	protocolVersionActionFlag, err := cmd.Flags().GetString(statetracker.ProtocolVersionActionFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read protocol version action flag", err)
+	}
+	options.ProtocolVersionAction, err = statetracker.ParseProtocolVersionAction(protocolVersionActionFlag)
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
@@ -0,0 +1,57 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// fetchContinuationParts gets the rest of a range query the provider served in parts, the results are merged into a copy
+// of the first reply since the relay result itself is still used for data reliability
+func (rpccs *RPCConsumerServer) fetchContinuationParts(ctx context.Context, chainMessage chainlib.ChainMessage, relayRequestData *pairingtypes.RelayPrivateData, dappID string, firstResult *lavaprotocol.RelayResult) (*pairingtypes.RelayReply, error) {
+	merged := *firstResult.Reply
+	token := firstResult.ContinuationToken
+	for parts := 1; token != ""; parts++ {
+		if parts >= chainlib.MaxContinuationParts {
+			return nil, utils.LavaFormatError("range request has too many parts", nil, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "parts", Value: parts})
+		}
+		partResult, err := rpccs.sendContinuationPart(ctx, chainMessage, relayRequestData, dappID, token)
+		if err != nil {
+			return nil, err
+		}
+		merged.Data, err = chainlib.MergePartialReplies(merged.Data, partResult.Reply.Data)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed merging range request parts", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "token", Value: token})
+		}
+		token = partResult.ContinuationToken
+	}
+	return &merged, nil
+}
+
+// any provider can serve a part, one that doesn't support continuation tokens serves the whole range and is skipped
+func (rpccs *RPCConsumerServer) sendContinuationPart(ctx context.Context, chainMessage chainlib.ChainMessage, relayRequestData *pairingtypes.RelayPrivateData, dappID string, token string) (*lavaprotocol.RelayResult, error) {
+	partCtx := chainlib.WithContinuationToken(ctx, token)
+	unwantedProviders := map[string]struct{}{}
+	relayErrors := []error{}
+	for retries := 0; retries < MaxRelayRetries; retries++ {
+		relayResult, err := rpccs.sendRelayToProvider(partCtx, chainMessage, relayRequestData, dappID, &unwantedProviders)
+		if err == nil && relayResult.ContinuationServed != token {
+			err = utils.LavaFormatWarning("provider didn't serve the requested part of a range request", nil, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "token", Value: token}, utils.Attribute{Key: "served", Value: relayResult.ContinuationServed}, utils.Attribute{Key: "provider", Value: relayResult.ProviderAddress})
+		}
+		if err == nil {
+			return relayResult, nil
+		}
+		if relayResult.ProviderAddress != "" {
+			unwantedProviders[relayResult.ProviderAddress] = struct{}{}
+		}
+		relayErrors = append(relayErrors, err)
+		if lavasession.PairingListEmptyError.Is(err) {
+			break
+		}
+	}
+	return nil, utils.LavaFormatError("failed fetching part of a range request", nil, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "token", Value: token}, utils.Attribute{Key: "errors", Value: relayErrors})
+}
@@ -0,0 +1,133 @@
+package rpcconsumer
+
+import (
+	"context"
+
+	"github.com/coniks-sys/coniks-go/crypto/vrf"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/performance"
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// LavaClientConfig configures an in process consumer, endpoints only need ChainID, ApiInterface and Geolocation as nothing is listened on
+type LavaClientConfig struct {
+	ClientCtx         client.Context // must have the consumer key set as from
+	TxFactory         tx.Factory
+	Endpoints         []*lavasession.RPCEndpoint
+	RequiredResponses int                                  // defaults to 1
+	VrfSk             vrf.PrivateKey                       // if nil a key is read or created from the client context home
+	Cache             *performance.Cache                   // optional
+	SpecOverlays      map[string]*statetracker.SpecOverlay // optional
+	QuotaWebhooks     *QuotaWebhookNotifier                // optional
+	ErrorBudgetPolicy *lavasession.ErrorBudgetPolicy       // optional, when to report providers as unresponsive
+	QoSHistory        *lavasession.QoSHistoryStore         // optional, providers are chosen by their history, the caller saves it
+	ExplorationRate   float64                              // share of sessions opened with a uniformly random provider, 0 always weighs providers by their score
+}
+
+// LavaRelayRequest is a single api request in the form the rpcconsumer listeners pass it on:
+// rest uses the path (with the query for GET) as Url, grpc the method, json-rpc and tendermint-rpc leave it empty and put the body in Data
+type LavaRelayRequest struct {
+	ApiInterface   string // can be left empty when a single api interface is configured for the chain
+	ConnectionType string // http method for rest and json-rpc
+	Url            string
+	Data           string
+	DappID         string // optional, used for metrics
+}
+
+// LavaClient sends relays through the lava protocol from inside a go application, with the same pairing, QoS and session handling as rpcconsumer
+// but without opening any listeners
+type LavaClient struct {
+	consumerStateTracker *statetracker.ConsumerStateTracker
+	relaySenders         map[string]map[string]*RPCConsumerServer // chainID -> apiInterface
+}
+
+func NewLavaClient(ctx context.Context, config LavaClientConfig) (*LavaClient, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, utils.LavaFormatError("lava client needs at least one endpoint", nil)
+	}
+	if config.RequiredResponses <= 0 {
+		config.RequiredResponses = 1
+	}
+	vrfSk := config.VrfSk
+	if vrfSk == nil {
+		var err error
+		vrfSk, _, err = utils.GetOrCreateVRFKey(config.ClientCtx)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed getting or creating a vrf key", err)
+		}
+	}
+	_, clientCtx := statetracker.NewLavaNodeClient(config.ClientCtx)
+	lavaChainFetcher := chainlib.NewLavaChainFetcher(ctx, clientCtx)
+	consumerStateTracker, err := statetracker.NewConsumerStateTracker(ctx, config.TxFactory, clientCtx, lavaChainFetcher)
+	if err != nil {
+		return nil, err
+	}
+	consumerStateTracker.SetSpecOverlays(config.SpecOverlays)
+	consumerKeys, err := LoadConsumerKeys(clientCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	addr := consumerKeys.ActiveAddress()
+	config.QuotaWebhooks.Start(ctx, addr.String())
+	lavaClient := &LavaClient{consumerStateTracker: consumerStateTracker, relaySenders: map[string]map[string]*RPCConsumerServer{}}
+	for _, rpcEndpoint := range config.Endpoints {
+		consumerSessionManager, chainParser, finalizationConsensus, err := setupEndpoint(ctx, rpcEndpoint, consumerStateTracker, config.ErrorBudgetPolicy, config.QoSHistory, config.ExplorationRate)
+		if err != nil {
+			return nil, err
+		}
+		config.QuotaWebhooks.RegisterCUBudgetController(consumerSessionManager.CUBudgetController())
+		consumerKeys.RegisterSessionManager(consumerSessionManager)
+		rpcConsumerServer := &RPCConsumerServer{}
+		rpcConsumerServer.initialize(rpcEndpoint, consumerStateTracker, chainParser, finalizationConsensus, consumerSessionManager, config.RequiredResponses, consumerKeys, vrfSk, clientCtx.ChainID, config.Cache)
+		if _, ok := lavaClient.relaySenders[rpcEndpoint.ChainID]; !ok {
+			lavaClient.relaySenders[rpcEndpoint.ChainID] = map[string]*RPCConsumerServer{}
+		}
+		lavaClient.relaySenders[rpcEndpoint.ChainID][rpcEndpoint.ApiInterface] = rpcConsumerServer
+	}
+	utils.LavaFormatInfo("lava client ready for relays", utils.Attribute{Key: "consumer", Value: addr.String()}, utils.Attribute{Key: "endpoints", Value: len(config.Endpoints)})
+	return lavaClient, nil
+}
+
+func (lc *LavaClient) relaySender(chainID string, apiInterface string) (*RPCConsumerServer, error) {
+	apiInterfaces, ok := lc.relaySenders[chainID]
+	if !ok {
+		return nil, utils.LavaFormatError("lava client is not configured for chain", nil, utils.Attribute{Key: "chainID", Value: chainID})
+	}
+	if apiInterface == "" && len(apiInterfaces) == 1 {
+		for _, rpcConsumerServer := range apiInterfaces {
+			return rpcConsumerServer, nil
+		}
+	}
+	rpcConsumerServer, ok := apiInterfaces[apiInterface]
+	if !ok {
+		return nil, utils.LavaFormatError("lava client is not configured for api interface", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: apiInterface})
+	}
+	return rpcConsumerServer, nil
+}
+
+// SendRelay sends the request to providers of the chain, subscriptions are not supported
+func (lc *LavaClient) SendRelay(ctx context.Context, chainID string, request LavaRelayRequest) (*pairingtypes.RelayReply, error) {
+	rpcConsumerServer, err := lc.relaySender(chainID, request.ApiInterface)
+	if err != nil {
+		return nil, err
+	}
+	if _, found := utils.GetUniqueIdentifier(ctx); !found {
+		ctx = utils.WithUniqueIdentifier(ctx, utils.GenerateUniqueIdentifier())
+	}
+	dappID := request.DappID
+	if dappID == "" {
+		dappID = "NoDappID"
+	}
+	relayReply, _, err := rpcConsumerServer.SendRelay(ctx, request.Url, request.Data, request.ConnectionType, dappID, nil)
+	return relayReply, err
+}
+
+// SwitchLavaNode moves the client to a different lava node without losing session state
+func (lc *LavaClient) SwitchLavaNode(ctx context.Context, nodeURI string) error {
+	return lc.consumerStateTracker.SwitchLavaNode(ctx, nodeURI)
+}
@@ -0,0 +1,49 @@
+package rpcconsumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLavaClientWithoutEndpoints(t *testing.T) {
+	_, err := NewLavaClient(context.Background(), LavaClientConfig{})
+	require.Error(t, err)
+}
+
+func TestLavaClientRelaySender(t *testing.T) {
+	jsonrpc := &RPCConsumerServer{}
+	rest := &RPCConsumerServer{}
+	tendermintrpc := &RPCConsumerServer{}
+	lavaClient := &LavaClient{relaySenders: map[string]map[string]*RPCConsumerServer{
+		"ETH1": {"jsonrpc": jsonrpc},
+		"LAV1": {"rest": rest, "tendermintrpc": tendermintrpc},
+	}}
+
+	relaySender, err := lavaClient.relaySender("ETH1", "jsonrpc")
+	require.NoError(t, err)
+	require.Same(t, jsonrpc, relaySender)
+	// the api interface can be left out when the chain has a single one
+	relaySender, err = lavaClient.relaySender("ETH1", "")
+	require.NoError(t, err)
+	require.Same(t, jsonrpc, relaySender)
+
+	relaySender, err = lavaClient.relaySender("LAV1", "tendermintrpc")
+	require.NoError(t, err)
+	require.Same(t, tendermintrpc, relaySender)
+	_, err = lavaClient.relaySender("LAV1", "")
+	require.Error(t, err)
+	_, err = lavaClient.relaySender("LAV1", "grpc")
+	require.Error(t, err)
+	_, err = lavaClient.relaySender("COS3", "rest")
+	require.Error(t, err)
+}
+
+func TestLavaClientSendRelayUnknownChain(t *testing.T) {
+	lavaClient := &LavaClient{relaySenders: map[string]map[string]*RPCConsumerServer{"ETH1": {"jsonrpc": {}}}}
+	_, err := lavaClient.SendRelay(context.Background(), "COS3", LavaRelayRequest{Data: `{"jsonrpc":"2.0","method":"eth_blockNumber","id":1}`})
+	require.Error(t, err)
+	_, err = lavaClient.SendRelay(context.Background(), "ETH1", LavaRelayRequest{ApiInterface: "rest", Url: "/blocks/latest"})
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package rpcconsumer
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// returns the cipher of the session's relays, the session key is derived on its first encrypted relay
+func sessionPayloadCipher(ctx context.Context, singleConsumerSession *lavasession.SingleConsumerSession) (cipher *lavaprotocol.PayloadCipher, ephemeralPubKey []byte, err error) {
+	if singleConsumerSession.PayloadSharedSecret == nil {
+		providerPubKey, err := providerPayloadPubKey(ctx, singleConsumerSession)
+		if err != nil {
+			return nil, nil, err
+		}
+		ephemeralPubKey, sharedSecret, err := lavaprotocol.NewConsumerPayloadKey(providerPubKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		singleConsumerSession.PayloadEphemeralPubKey = ephemeralPubKey
+		singleConsumerSession.PayloadSharedSecret = sharedSecret
+	}
+	cipher, err = lavaprotocol.NewConsumerPayloadCipher(singleConsumerSession.PayloadEphemeralPubKey, singleConsumerSession.PayloadSharedSecret)
+	return cipher, singleConsumerSession.PayloadEphemeralPubKey, err
+}
+
+// the provider's public key is read once per pairing with a handshake probe and checked against its address
+func providerPayloadPubKey(ctx context.Context, singleConsumerSession *lavasession.SingleConsumerSession) ([]byte, error) {
+	consumerSessionsWithProvider := singleConsumerSession.Client
+	consumerSessionsWithProvider.Lock.Lock()
+	providerPubKey := consumerSessionsWithProvider.PayloadPubKey
+	consumerSessionsWithProvider.Lock.Unlock()
+	if providerPubKey != nil {
+		return providerPubKey, nil
+	}
+	var header metadata.MD
+	probeCtx, cancel := context.WithTimeout(lavaprotocol.AppendPayloadEncryptionHandshake(ctx), lavasession.AverageWorldLatency)
+	defer cancel()
+	_, err := (*singleConsumerSession.Endpoint.Client).Probe(probeCtx, &wrapperspb.UInt64Value{Value: rand.Uint64()}, grpc.Header(&header))
+	if err != nil {
+		return nil, utils.LavaFormatWarning("payload encryption handshake probe failed", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "provider", Value: consumerSessionsWithProvider.PublicLavaAddress})
+	}
+	providerPubKey, err = lavaprotocol.GetPayloadEncryptionPubKey(header)
+	if err != nil {
+		return nil, err
+	}
+	err = lavaprotocol.VerifyProviderPayloadPubKey(providerPubKey, consumerSessionsWithProvider.PublicLavaAddress)
+	if err != nil {
+		return nil, err
+	}
+	consumerSessionsWithProvider.Lock.Lock()
+	consumerSessionsWithProvider.PayloadPubKey = providerPubKey
+	consumerSessionsWithProvider.Lock.Unlock()
+	return providerPubKey, nil
+}
@@ -0,0 +1,97 @@
+package rpcconsumer
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	QoSHistoryAdminAddressFlag = "qos-history-admin-address"
+	QoSHistoryAdminPath        = "/lava/qos-history"
+	qosHistorySpecParam        = "spec"
+	qosHistoryProviderParam    = "provider"
+)
+
+type providerQoSHistoryReply struct {
+	Spec           string                         `json:"spec"`
+	Provider       string                         `json:"provider"`
+	Score          float64                        `json:"score"`
+	AverageLatency time.Duration                  `json:"averageLatency"`
+	History        lavasession.ProviderQoSHistory `json:"history"`
+}
+
+// returns nil if no qos history file is set
+func parseQoSHistory(cmd *cobra.Command) (*lavasession.QoSHistoryStore, error) {
+	path, err := cmd.Flags().GetString(lavasession.QoSHistoryFileFlag)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	decay, err := cmd.Flags().GetFloat64(lavasession.QoSHistoryDecayFlag)
+	if err != nil {
+		return nil, err
+	}
+	return lavasession.NewQoSHistoryStore(path, decay)
+}
+
+// StartQoSHistoryAdminServer serves the qos history on addr:
+// GET lists the history, optionally filtered by the spec and provider query params, DELETE resets a provider's history on the spec or on all specs
+func StartQoSHistoryAdminServer(addr string, store *lavasession.QoSHistoryStore) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(QoSHistoryAdminPath, func(resp http.ResponseWriter, req *http.Request) {
+		qosHistoryHandler(resp, req, store)
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			utils.LavaFormatError("qos history admin server failed", err, utils.Attribute{Key: "address", Value: addr})
+		}
+	}()
+	utils.LavaFormatInfo("started qos history admin server", utils.Attribute{Key: "address", Value: addr}, utils.Attribute{Key: "path", Value: QoSHistoryAdminPath})
+}
+
+func qosHistoryHandler(resp http.ResponseWriter, req *http.Request, store *lavasession.QoSHistoryStore) {
+	spec := req.URL.Query().Get(qosHistorySpecParam)
+	provider := req.URL.Query().Get(qosHistoryProviderParam)
+	switch req.Method {
+	case http.MethodGet:
+		reply := []providerQoSHistoryReply{}
+		for specKey, providers := range store.GetHistory() {
+			if spec != "" && spec != specKey {
+				continue
+			}
+			for providerAddress, history := range providers {
+				if provider != "" && provider != providerAddress {
+					continue
+				}
+				reply = append(reply, providerQoSHistoryReply{Spec: specKey, Provider: providerAddress, Score: history.Score(), AverageLatency: history.AverageLatency(), History: history})
+			}
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(resp).Encode(reply)
+		if err != nil {
+			utils.LavaFormatWarning("failed writing qos history reply", err)
+		}
+	case http.MethodDelete:
+		if provider == "" {
+			http.Error(resp, "missing "+qosHistoryProviderParam+" query param", http.StatusBadRequest)
+			return
+		}
+		if !store.ResetProvider(spec, provider) {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		utils.LavaFormatInfo("reset provider qos history", utils.Attribute{Key: "provider", Value: provider}, utils.Attribute{Key: "spec", Value: spec})
+		resp.WriteHeader(http.StatusNoContent)
+	default:
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
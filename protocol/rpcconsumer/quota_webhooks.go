@@ -0,0 +1,295 @@
+package rpcconsumer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/lavanet/lava/utils"
+	"github.com/spf13/viper"
+)
+
+const (
+	QuotaWebhooksConfigName         = "quota-webhooks"
+	QuotaEventUsageThreshold        = "usage-threshold"
+	QuotaEventThrottleLevelChanged  = "throttle-level-changed"
+	QuotaEventSubscriptionCuUsed    = statetracker.SubscriptionEventCuUsed
+	QuotaEventSubscriptionExpiry    = statetracker.SubscriptionEventExpiry
+	QuotaEventBadgeRevoked          = "badge-revoked" // the consumer key was removed from its project, relays signed with it are rejected
+	QuotaWebhookSignatureHeader     = "X-Lava-Signature"
+	QuotaWebhookTimestampHeader     = "X-Lava-Timestamp"
+	QuotaWebhookTimeout             = 5 * time.Second
+	QuotaWebhookMaxAttempts         = 4
+	QuotaWebhookRetryBackoff        = time.Second // doubled on every retry
+	quotaWebhookQueueSize           = 100
+	DefaultQuotaWebhookUsageTrigger = 0.8
+)
+
+// QuotaWebhook is defined per project in the consumer config file:
+//
+//	quota-webhooks:
+//	  - url: https://billing.example.com/lava
+//	    secret: signing-secret
+//	    headers:
+//	      Authorization: Bearer token
+//	    events: [usage-threshold, throttle-level-changed, subscription-cu-used, subscription-expiry, badge-revoked]
+//	    usage-thresholds: [0.5, 0.8, 1]
+type QuotaWebhook struct {
+	URL             string            `yaml:"url,omitempty" json:"url,omitempty" mapstructure:"url"`
+	Secret          string            `yaml:"secret,omitempty" json:"secret,omitempty" mapstructure:"secret"`                               // if set payloads are signed with hmac-sha256
+	Headers         map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" mapstructure:"headers"`                            // custom headers added to every request
+	Events          []string          `yaml:"events,omitempty" json:"events,omitempty" mapstructure:"events"`                               // empty means all events
+	UsageThresholds []float64         `yaml:"usage-thresholds,omitempty" json:"usage-thresholds,omitempty" mapstructure:"usage-thresholds"` // parts of the epoch allowance
+}
+
+func (qw *QuotaWebhook) validate() error {
+	if qw.URL == "" {
+		return utils.LavaFormatError("quota webhook is missing a url", nil)
+	}
+	for _, event := range qw.Events {
+		if event != QuotaEventUsageThreshold && event != QuotaEventThrottleLevelChanged && event != QuotaEventSubscriptionCuUsed && event != QuotaEventSubscriptionExpiry && event != QuotaEventBadgeRevoked {
+			return utils.LavaFormatError("invalid quota webhook event", nil, utils.Attribute{Key: "url", Value: qw.URL}, utils.Attribute{Key: "event", Value: event})
+		}
+	}
+	if len(qw.UsageThresholds) == 0 {
+		qw.UsageThresholds = []float64{DefaultQuotaWebhookUsageTrigger}
+	}
+	for _, threshold := range qw.UsageThresholds {
+		if threshold <= 0 {
+			return utils.LavaFormatError("invalid quota webhook usage threshold, must be positive", nil, utils.Attribute{Key: "url", Value: qw.URL}, utils.Attribute{Key: "threshold", Value: threshold})
+		}
+	}
+	return nil
+}
+
+func (qw *QuotaWebhook) wants(event QuotaEvent) bool {
+	if len(qw.Events) > 0 {
+		found := false
+		for _, wantedEvent := range qw.Events {
+			found = found || wantedEvent == event.Event
+		}
+		if !found {
+			return false
+		}
+	}
+	if event.Event != QuotaEventUsageThreshold {
+		return true
+	}
+	for _, threshold := range qw.UsageThresholds {
+		if threshold == event.UsageThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaEvent is the json payload posted to the webhooks
+type QuotaEvent struct {
+	Event          string    `json:"event"`
+	Project        string    `json:"project"`                // the consumer address
+	ProjectIndex   string    `json:"projectIndex,omitempty"` // the project the consumer key was removed from
+	ChainID        string    `json:"chainID"`
+	ApiInterface   string    `json:"apiInterface"`
+	Epoch          uint64    `json:"epoch"`
+	UsedCU         uint64    `json:"usedCU"`
+	ProjectedCU    uint64    `json:"projectedCU"`
+	Allowance      uint64    `json:"allowance"`
+	ThrottleLevel  string    `json:"throttleLevel"`
+	UsageThreshold float64   `json:"usageThreshold,omitempty"`
+	Plan           string    `json:"plan,omitempty"`
+	DaysToExpiry   uint64    `json:"daysToExpiry,omitempty"`
+	ExpiryTime     int64     `json:"expiryTime,omitempty"` // unix seconds the subscription expires at
+	Time           time.Time `json:"time"`
+}
+
+// QuotaWebhookNotifier posts cu budget events of the consumer's endpoints to the configured webhooks, failed deliveries are retried with backoff
+type QuotaWebhookNotifier struct {
+	webhooks     []QuotaWebhook
+	project      string
+	events       chan QuotaEvent
+	httpClient   *http.Client
+	retryBackoff time.Duration
+}
+
+// Start sends queued events until ctx is done, project identifies the consumer in the payloads
+func (qwn *QuotaWebhookNotifier) Start(ctx context.Context, project string) {
+	if qwn == nil {
+		return
+	}
+	qwn.project = project
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-qwn.events:
+				event.Project = qwn.project
+				for _, webhook := range qwn.webhooks {
+					if webhook.wants(event) {
+						qwn.send(ctx, webhook, event)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// RegisterCUBudgetController is safe to call on a nil notifier, in that case webhooks are not configured
+func (qwn *QuotaWebhookNotifier) RegisterCUBudgetController(cuBudgetController *lavasession.CUBudgetController) {
+	if qwn == nil {
+		return
+	}
+	usageThresholds := []float64{}
+	for _, webhook := range qwn.webhooks {
+		usageThresholds = append(usageThresholds, webhook.UsageThresholds...)
+	}
+	cuBudgetController.SetEventCallback(usageThresholds, qwn.onCUBudgetEvent)
+}
+
+// called with the cu budget controller locked, so events are queued and dropped if the queue is full
+func (qwn *QuotaWebhookNotifier) onCUBudgetEvent(cuBudgetEvent lavasession.CUBudgetEvent) {
+	event := QuotaEvent{
+		Event:          QuotaEventThrottleLevelChanged,
+		ChainID:        cuBudgetEvent.Spec,
+		ApiInterface:   cuBudgetEvent.ApiInterface,
+		Epoch:          cuBudgetEvent.Epoch,
+		UsedCU:         cuBudgetEvent.UsedCU,
+		ProjectedCU:    cuBudgetEvent.ProjectedCU,
+		Allowance:      cuBudgetEvent.Allowance,
+		ThrottleLevel:  cuBudgetEvent.Level.String(),
+		UsageThreshold: cuBudgetEvent.UsageThreshold,
+		Time:           time.Now(),
+	}
+	if cuBudgetEvent.UsageThreshold > 0 {
+		event.Event = QuotaEventUsageThreshold
+	}
+	qwn.queue(event)
+}
+
+// SubscriptionThresholdCrossed posts the subscription events, usage thresholds of subscription cu events are the crossed part of the monthly compute units
+func (qwn *QuotaWebhookNotifier) SubscriptionThresholdCrossed(subscriptionEvent statetracker.SubscriptionEvent) {
+	event := QuotaEvent{
+		Event:      subscriptionEvent.Event,
+		Plan:       subscriptionEvent.Plan,
+		UsedCU:     subscriptionEvent.MonthCuTotal - subscriptionEvent.MonthCuLeft,
+		Allowance:  subscriptionEvent.MonthCuTotal,
+		ExpiryTime: subscriptionEvent.ExpiryTime.Unix(),
+		Time:       time.Now(),
+	}
+	if subscriptionEvent.Event == QuotaEventSubscriptionExpiry {
+		event.DaysToExpiry = uint64(subscriptionEvent.Threshold)
+	} else {
+		event.UsageThreshold = subscriptionEvent.Threshold
+	}
+	qwn.queue(event)
+}
+
+// PolicyChanged is required to follow the consumer's project, only revocations are posted
+func (qwn *QuotaWebhookNotifier) PolicyChanged(policy *statetracker.EffectivePolicy) {}
+
+// ProjectKeyRevoked posts the badge revoked event when the consumer key is removed from its project
+func (qwn *QuotaWebhookNotifier) ProjectKeyRevoked(consumer string, project string) {
+	qwn.queue(QuotaEvent{Event: QuotaEventBadgeRevoked, ProjectIndex: project, Time: time.Now()})
+}
+
+func (qwn *QuotaWebhookNotifier) queue(event QuotaEvent) {
+	select {
+	case qwn.events <- event:
+	default:
+		utils.LavaFormatWarning("quota webhook queue is full, dropping event", nil, utils.Attribute{Key: "event", Value: event})
+	}
+}
+
+func (qwn *QuotaWebhookNotifier) send(ctx context.Context, webhook QuotaWebhook, event QuotaEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		utils.LavaFormatError("failed marshaling quota event", err)
+		return
+	}
+	backoff := qwn.retryBackoff
+	for attempt := 1; attempt <= QuotaWebhookMaxAttempts; attempt++ {
+		retry, err := qwn.post(ctx, webhook, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == QuotaWebhookMaxAttempts {
+			utils.LavaFormatWarning("failed sending quota webhook", err, utils.Attribute{Key: "url", Value: webhook.URL}, utils.Attribute{Key: "event", Value: event.Event}, utils.Attribute{Key: "attempts", Value: attempt})
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (qwn *QuotaWebhookNotifier) post(ctx context.Context, webhook QuotaWebhook, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, QuotaWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return false, err
+	}
+	for key, value := range webhook.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(QuotaWebhookTimestampHeader, timestamp)
+		req.Header.Set(QuotaWebhookSignatureHeader, "sha256="+SignQuotaWebhookPayload(webhook.Secret, timestamp, body))
+	}
+	resp, err := qwn.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return true, utils.LavaFormatWarning("quota webhook returned an error status", nil, utils.Attribute{Key: "status", Value: resp.StatusCode})
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return false, utils.LavaFormatWarning("quota webhook rejected the event", nil, utils.Attribute{Key: "status", Value: resp.StatusCode})
+	}
+	return false, nil
+}
+
+// SignQuotaWebhookPayload returns the hex hmac-sha256 of "timestamp.body", receivers compare it with the signature header and reject old timestamps
+func SignQuotaWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewQuotaWebhookNotifier(webhooks []QuotaWebhook) (*QuotaWebhookNotifier, error) {
+	for idx := range webhooks {
+		err := webhooks[idx].validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &QuotaWebhookNotifier{webhooks: webhooks, events: make(chan QuotaEvent, quotaWebhookQueueSize), httpClient: &http.Client{Timeout: QuotaWebhookTimeout}, retryBackoff: QuotaWebhookRetryBackoff}, nil
+}
+
+// ParseQuotaWebhooks reads the webhooks from the consumer config, returns a nil notifier if none are defined
+func ParseQuotaWebhooks(viperConfig *viper.Viper) (*QuotaWebhookNotifier, error) {
+	var webhooks []QuotaWebhook
+	err := viperConfig.UnmarshalKey(QuotaWebhooksConfigName, &webhooks)
+	if err != nil {
+		return nil, utils.LavaFormatError("could not unmarshal quota webhooks", err)
+	}
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+	return NewQuotaWebhookNotifier(webhooks)
+}
@@ -0,0 +1,173 @@
+package rpcconsumer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/stretchr/testify/require"
+)
+
+// quotaWebhookReceiver records the requests it gets and replies with the next status, 200 when none are left
+type quotaWebhookReceiver struct {
+	lock     sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (qwr *quotaWebhookReceiver) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	qwr.lock.Lock()
+	defer qwr.lock.Unlock()
+	qwr.requests = append(qwr.requests, req)
+	qwr.bodies = append(qwr.bodies, body)
+	status := http.StatusOK
+	if len(qwr.statuses) > 0 {
+		status = qwr.statuses[0]
+		qwr.statuses = qwr.statuses[1:]
+	}
+	resp.WriteHeader(status)
+}
+
+func (qwr *quotaWebhookReceiver) received() int {
+	qwr.lock.Lock()
+	defer qwr.lock.Unlock()
+	return len(qwr.requests)
+}
+
+func newTestQuotaWebhookNotifier(t *testing.T, webhook QuotaWebhook) *QuotaWebhookNotifier {
+	notifier, err := NewQuotaWebhookNotifier([]QuotaWebhook{webhook})
+	require.NoError(t, err)
+	notifier.retryBackoff = time.Millisecond
+	return notifier
+}
+
+func TestQuotaWebhookSubscriptionEvents(t *testing.T) {
+	_, err := NewQuotaWebhookNotifier([]QuotaWebhook{{URL: "http://localhost", Events: []string{QuotaEventSubscriptionCuUsed, QuotaEventSubscriptionExpiry}}})
+	require.NoError(t, err)
+	_, err = NewQuotaWebhookNotifier([]QuotaWebhook{{URL: "http://localhost", Events: []string{"subscription-renewed"}}})
+	require.Error(t, err)
+
+	notifier, err := NewQuotaWebhookNotifier([]QuotaWebhook{{URL: "http://localhost"}})
+	require.NoError(t, err)
+	expiryTime := time.Now().AddDate(0, 0, 3)
+	notifier.SubscriptionThresholdCrossed(statetracker.SubscriptionEvent{Event: statetracker.SubscriptionEventCuUsed, Plan: "basic", Threshold: 0.8, MonthCuTotal: 100, MonthCuLeft: 15, ExpiryTime: expiryTime})
+	notifier.SubscriptionThresholdCrossed(statetracker.SubscriptionEvent{Event: statetracker.SubscriptionEventExpiry, Plan: "basic", Threshold: 7, MonthCuTotal: 100, MonthCuLeft: 15, ExpiryTime: expiryTime})
+
+	cuUsed := <-notifier.events
+	require.Equal(t, QuotaEventSubscriptionCuUsed, cuUsed.Event)
+	require.Equal(t, "basic", cuUsed.Plan)
+	require.Equal(t, uint64(85), cuUsed.UsedCU)
+	require.Equal(t, uint64(100), cuUsed.Allowance)
+	require.Equal(t, 0.8, cuUsed.UsageThreshold)
+	require.Zero(t, cuUsed.DaysToExpiry)
+	require.Equal(t, expiryTime.Unix(), cuUsed.ExpiryTime)
+
+	// the threshold of expiry events is the days before the expiry
+	expiry := <-notifier.events
+	require.Equal(t, QuotaEventSubscriptionExpiry, expiry.Event)
+	require.Equal(t, uint64(7), expiry.DaysToExpiry)
+	require.Zero(t, expiry.UsageThreshold)
+}
+
+func TestQuotaWebhookWantsSubscriptionEvents(t *testing.T) {
+	webhook := QuotaWebhook{URL: "http://localhost", Events: []string{QuotaEventSubscriptionExpiry}, UsageThresholds: []float64{0.5}}
+	require.True(t, webhook.wants(QuotaEvent{Event: QuotaEventSubscriptionExpiry}))
+	require.False(t, webhook.wants(QuotaEvent{Event: QuotaEventSubscriptionCuUsed, UsageThreshold: 0.5}))
+	// the usage thresholds of a webhook filter cu budget events only, subscription cu events are posted at the subscription thresholds
+	allEvents := QuotaWebhook{URL: "http://localhost", UsageThresholds: []float64{0.5}}
+	require.True(t, allEvents.wants(QuotaEvent{Event: QuotaEventSubscriptionCuUsed, UsageThreshold: 0.8}))
+	require.False(t, allEvents.wants(QuotaEvent{Event: QuotaEventUsageThreshold, UsageThreshold: 0.8}))
+}
+
+func TestQuotaWebhookSignedDelivery(t *testing.T) {
+	receiver := &quotaWebhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	notifier := newTestQuotaWebhookNotifier(t, QuotaWebhook{URL: server.URL, Secret: "secret", Headers: map[string]string{"Authorization": "Bearer token", "X-Tenant": "billing"}})
+
+	notifier.send(context.Background(), notifier.webhooks[0], QuotaEvent{Event: QuotaEventBadgeRevoked, Project: "lava@consumer", ProjectIndex: "project"})
+	require.Equal(t, 1, receiver.received())
+	req, body := receiver.requests[0], receiver.bodies[0]
+	require.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	require.Equal(t, "billing", req.Header.Get("X-Tenant"))
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	event := QuotaEvent{}
+	require.NoError(t, json.Unmarshal(body, &event))
+	require.Equal(t, QuotaEventBadgeRevoked, event.Event)
+	require.Equal(t, "project", event.ProjectIndex)
+
+	// the signature is the hmac of the timestamp and the exact body received
+	timestamp := req.Header.Get(QuotaWebhookTimestampHeader)
+	require.NotEmpty(t, timestamp)
+	require.Equal(t, "sha256="+SignQuotaWebhookPayload("secret", timestamp, body), req.Header.Get(QuotaWebhookSignatureHeader))
+	require.NotEqual(t, SignQuotaWebhookPayload("other", timestamp, body), SignQuotaWebhookPayload("secret", timestamp, body))
+
+	// payloads aren't signed without a secret
+	unsigned := newTestQuotaWebhookNotifier(t, QuotaWebhook{URL: server.URL})
+	unsigned.send(context.Background(), unsigned.webhooks[0], QuotaEvent{Event: QuotaEventBadgeRevoked})
+	require.Equal(t, 2, receiver.received())
+	require.Empty(t, receiver.requests[1].Header.Get(QuotaWebhookSignatureHeader))
+}
+
+func TestQuotaWebhookRetries(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{name: "unavailable is retried until delivered", statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}, attempts: 3},
+		{name: "unavailable is retried up to the max attempts", statuses: []int{503, 503, 503, 503, 503}, attempts: QuotaWebhookMaxAttempts},
+		{name: "too many requests is retried", statuses: []int{http.StatusTooManyRequests}, attempts: 2},
+		{name: "bad request isn't retried", statuses: []int{http.StatusBadRequest}, attempts: 1},
+		{name: "unauthorized isn't retried", statuses: []int{http.StatusUnauthorized}, attempts: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &quotaWebhookReceiver{statuses: tt.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+			notifier := newTestQuotaWebhookNotifier(t, QuotaWebhook{URL: server.URL})
+			notifier.send(context.Background(), notifier.webhooks[0], QuotaEvent{Event: QuotaEventThrottleLevelChanged})
+			require.Equal(t, tt.attempts, receiver.received())
+		})
+	}
+}
+
+func TestQuotaWebhookBadgeRevoked(t *testing.T) {
+	_, err := NewQuotaWebhookNotifier([]QuotaWebhook{{URL: "http://localhost", Events: []string{QuotaEventBadgeRevoked}}})
+	require.NoError(t, err)
+
+	receiver := &quotaWebhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	notifier, err := NewQuotaWebhookNotifier([]QuotaWebhook{
+		{URL: server.URL, Events: []string{QuotaEventBadgeRevoked}},
+		{URL: server.URL + "/usage", Events: []string{QuotaEventUsageThreshold}},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier.Start(ctx, "lava@consumer")
+
+	// the policy updater calls the notifier when the consumer key is removed from its project
+	var policyUpdatable statetracker.PolicyUpdatable = notifier
+	revokedUpdatable, ok := policyUpdatable.(statetracker.ProjectKeyRevokedUpdatable)
+	require.True(t, ok)
+	revokedUpdatable.ProjectKeyRevoked("lava@consumer", "project")
+	require.Eventually(t, func() bool { return receiver.received() == 1 }, time.Second, time.Millisecond)
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+	require.Equal(t, "/", receiver.requests[0].URL.Path) // only the webhook that wants it
+	event := QuotaEvent{}
+	require.NoError(t, json.Unmarshal(receiver.bodies[0], &event))
+	require.Equal(t, QuotaEventBadgeRevoked, event.Event)
+	require.Equal(t, "lava@consumer", event.Project)
+	require.Equal(t, "project", event.ProjectIndex)
+}
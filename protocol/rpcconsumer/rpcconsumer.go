@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/coniks-sys/coniks-go/crypto/vrf"
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/flags"
@@ -22,6 +23,7 @@ import (
 	commonlib "github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavaprotocol"
 	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/metrics"
 	"github.com/lavanet/lava/protocol/performance"
 	"github.com/lavanet/lava/protocol/provideroptimizer"
 	"github.com/lavanet/lava/protocol/statetracker"
@@ -40,7 +42,7 @@ var (
 type ConsumerStateTrackerInf interface {
 	RegisterConsumerSessionManagerForPairingUpdates(ctx context.Context, consumerSessionManager *lavasession.ConsumerSessionManager)
 	RegisterChainParserForSpecUpdates(ctx context.Context, chainParser chainlib.ChainParser, chainID string) error
-	RegisterFinalizationConsensusForUpdates(context.Context, *lavaprotocol.FinalizationConsensus)
+	RegisterFinalizationConsensusForUpdates(context.Context, *lavaprotocol.FinalizationConsensus, string)
 	TxConflictDetection(ctx context.Context, finalizationConflict *conflicttypes.FinalizationConflict, responseConflict *conflicttypes.ResponseConflict, sameProviderConflict *conflicttypes.FinalizationConflict) error
 }
 
@@ -49,33 +51,49 @@ type RPCConsumer struct {
 }
 
 // spawns a new RPCConsumer server with all it's processes and internals ready for communications
-func (rpcc *RPCConsumer) Start(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, rpcEndpoints []*lavasession.RPCEndpoint, requiredResponses int, vrf_sk vrf.PrivateKey, cache *performance.Cache) (err error) {
+func (rpcc *RPCConsumer) Start(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, rpcEndpoints []*lavasession.RPCEndpoint, requiredResponses int, vrf_sk vrf.PrivateKey, cache *performance.Cache, consumerKeys *ConsumerKeys, options *ConsumerOptions) (err error) {
 	if commonlib.IsTestMode(ctx) {
 		testModeWarn("RPCConsumer running tests")
 	}
-	// spawn up ConsumerStateTracker
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// spawn up ConsumerStateTracker, the lava node client is shared so switching lava nodes moves the chain fetcher as well
+	_, clientCtx = statetracker.NewLavaNodeClient(clientCtx)
 	lavaChainFetcher := chainlib.NewLavaChainFetcher(ctx, clientCtx)
 	consumerStateTracker, err := statetracker.NewConsumerStateTracker(ctx, txFactory, clientCtx, lavaChainFetcher)
 	if err != nil {
 		return err
 	}
-	rpcc.consumerStateTracker = consumerStateTracker
-	lavaChainID := clientCtx.ChainID
-	keyName, err := sigs.GetKeyName(clientCtx)
+	consumerStateTracker.SetSpecOverlays(options.SpecOverlays)
+	consumerStateTracker.SetPairingPrefetchBlocks(options.PairingPrefetchBlocks)
+	err = consumerStateTracker.SetTxGasAdjustment(options.TxGasAdjustment)
 	if err != nil {
-		utils.LavaFormatFatal("failed getting key name from clientCtx", err)
+		return err
 	}
-	privKey, err := sigs.GetPrivKey(clientCtx, keyName)
+	err = consumerStateTracker.StartLavaNodeFailover(ctx, options.LavaNodeBackups)
 	if err != nil {
-		utils.LavaFormatFatal("failed getting private key from key name", err, utils.Attribute{Key: "keyName", Value: keyName})
+		return err
 	}
-	clientKey, _ := clientCtx.Keyring.Key(keyName)
-
-	var addr sdk.AccAddress
-	err = addr.Unmarshal(clientKey.GetPubKey().Address())
-	if err != nil {
-		utils.LavaFormatFatal("failed unmarshaling public address", err, utils.Attribute{Key: "keyName", Value: keyName}, utils.Attribute{Key: "pubkey", Value: clientKey.GetPubKey().Address()})
+	consumerStateTracker.SetUpdaterParallelism(options.UpdaterParallelism)
+	consumerStateTracker.SetProcessingLagAlert(options.ProcessingLagAlertBlocks, nil)
+	if options.StateTrackerDebugAddress != "" {
+		consumerStateTracker.StartDebugServer(ctx, options.StateTrackerDebugAddress)
+	}
+	consumerStateTracker.EnforceProtocolVersion(ctx, version.Version, options.ProtocolVersionAction, cancel)
+	rpcc.consumerStateTracker = consumerStateTracker
+	lavaChainID := clientCtx.ChainID
+	addr := consumerKeys.ActiveAddress()
+	options.QuotaWebhooks.Start(ctx, addr.String())
+	var subscriptionUpdatable statetracker.SubscriptionUpdatable
+	if options.QuotaWebhooks != nil {
+		subscriptionUpdatable = options.QuotaWebhooks
+	}
+	consumerStateTracker.RegisterForSubscriptionUpdates(ctx, addr.String(), options.SubscriptionThresholds, subscriptionUpdatable)
+	if options.QuotaWebhooks != nil {
+		// posts the badge revoked event when the consumer key is removed from its project
+		consumerStateTracker.RegisterForPolicyUpdates(ctx, addr.String(), options.QuotaWebhooks)
 	}
+	options.QoSHistory.Start(ctx)
 
 	var wg sync.WaitGroup
 	parallelJobs := len(rpcEndpoints)
@@ -87,32 +105,25 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, txFactory tx.Factory, client
 	for _, rpcEndpoint := range rpcEndpoints {
 		go func(rpcEndpoint *lavasession.RPCEndpoint) error {
 			defer wg.Done()
-			strategy := provideroptimizer.STRATEGY_QOS
-			optimizer := provideroptimizer.NewProviderOptimizer(strategy)
-			consumerSessionManager := lavasession.NewConsumerSessionManager(rpcEndpoint, optimizer)
-			rpcc.consumerStateTracker.RegisterConsumerSessionManagerForPairingUpdates(ctx, consumerSessionManager)
-			chainParser, err := chainlib.NewChainParser(rpcEndpoint.ApiInterface)
+			consumerSessionManager, chainParser, finalizationConsensus, err := setupEndpoint(ctx, rpcEndpoint, rpcc.consumerStateTracker, options.ErrorBudgetPolicy, options.QoSHistory, options.ExplorationRate)
 			if err != nil {
-				err = utils.LavaFormatError("failed creating chain parser", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
 				errCh <- err
 				return err
 			}
-			err = consumerStateTracker.RegisterChainParserForSpecUpdates(ctx, chainParser, rpcEndpoint.ChainID)
-			if err != nil {
-				err = utils.LavaFormatError("failed registering for spec updates", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
-				errCh <- err
-				return err
+			options.QuotaWebhooks.RegisterCUBudgetController(consumerSessionManager.CUBudgetController())
+			consumerKeys.RegisterSessionManager(consumerSessionManager)
+			if grpcChainParser, ok := chainParser.(*chainlib.GrpcChainParser); ok {
+				grpcChainParser.SetDescriptors(options.GrpcDescriptors)
 			}
-			finalizationConsensus := &lavaprotocol.FinalizationConsensus{}
-			consumerStateTracker.RegisterFinalizationConsensusForUpdates(ctx, finalizationConsensus)
 			rpcConsumerServer := &RPCConsumerServer{}
 			utils.LavaFormatInfo("RPCConsumer Listening", utils.Attribute{Key: "endpoints", Value: rpcEndpoint.String()})
-			err = rpcConsumerServer.ServeRPCRequests(ctx, rpcEndpoint, rpcc.consumerStateTracker, chainParser, finalizationConsensus, consumerSessionManager, requiredResponses, privKey, vrf_sk, lavaChainID, cache)
+			err = rpcConsumerServer.ServeRPCRequests(ctx, rpcEndpoint, rpcc.consumerStateTracker, chainParser, finalizationConsensus, consumerSessionManager, requiredResponses, consumerKeys, vrf_sk, lavaChainID, cache)
 			if err != nil {
 				err = utils.LavaFormatError("failed serving rpc requests", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
 				errCh <- err
 				return err
 			}
+			consumerStateTracker.RegisterForPolicyUpdates(ctx, addr.String(), rpcConsumerServer)
 			return nil
 		}(rpcEndpoint)
 	}
@@ -128,10 +139,89 @@ func (rpcc *RPCConsumer) Start(ctx context.Context, txFactory tx.Factory, client
 
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt)
-	<-signalChan
+	select {
+	case <-signalChan:
+	case <-ctx.Done():
+		utils.LavaFormatInfo("RPCConsumer shutting down")
+	}
+	if options.QoSHistory != nil {
+		err = options.QoSHistory.Save()
+		if err != nil {
+			utils.LavaFormatError("failed saving qos history on shutdown", err)
+		}
+	}
 	return nil
 }
 
+// registers a new session manager, chain parser and finalization consensus of the endpoint for updates from the lava chain
+func setupEndpoint(ctx context.Context, rpcEndpoint *lavasession.RPCEndpoint, consumerStateTracker ConsumerStateTrackerInf, errorBudgetPolicy *lavasession.ErrorBudgetPolicy, qosHistory *lavasession.QoSHistoryStore, explorationRate float64) (*lavasession.ConsumerSessionManager, chainlib.ChainParser, *lavaprotocol.FinalizationConsensus, error) {
+	strategy := provideroptimizer.STRATEGY_QOS
+	optimizer := provideroptimizer.NewProviderOptimizer(strategy, explorationRate)
+	consumerSessionManager := lavasession.NewConsumerSessionManager(rpcEndpoint, optimizer)
+	if errorBudgetPolicy != nil {
+		err := consumerSessionManager.SetErrorBudgetPolicy(*errorBudgetPolicy)
+		if err != nil {
+			return nil, nil, nil, utils.LavaFormatError("invalid error budget policy", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
+		}
+	}
+	if qosHistory != nil {
+		consumerSessionManager.SetQoSHistory(qosHistory)
+	}
+	consumerStateTracker.RegisterConsumerSessionManagerForPairingUpdates(ctx, consumerSessionManager)
+	chainParser, err := chainlib.NewChainParser(rpcEndpoint.ApiInterface)
+	if err != nil {
+		return nil, nil, nil, utils.LavaFormatError("failed creating chain parser", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
+	}
+	err = consumerStateTracker.RegisterChainParserForSpecUpdates(ctx, chainParser, rpcEndpoint.ChainID)
+	if err != nil {
+		return nil, nil, nil, utils.LavaFormatError("failed registering for spec updates", err, utils.Attribute{Key: "endpoint", Value: rpcEndpoint})
+	}
+	finalizationConsensus := &lavaprotocol.FinalizationConsensus{}
+	consumerStateTracker.RegisterFinalizationConsensusForUpdates(ctx, finalizationConsensus, rpcEndpoint.ChainID)
+	return consumerSessionManager, chainParser, finalizationConsensus, nil
+}
+
+// returns the name of the key set in the client context --from flag
+func getConsumerKeyName(clientCtx client.Context) (string, error) {
+	keyName, err := sigs.GetKeyName(clientCtx)
+	if err != nil {
+		return "", utils.LavaFormatError("failed getting key name from clientCtx", err)
+	}
+	return keyName, nil
+}
+
+// returns the private key and address of a key in the client context keyring
+func getConsumerKeyByName(clientCtx client.Context, keyName string) (*btcec.PrivateKey, sdk.AccAddress, error) {
+	privKey, err := sigs.GetPrivKey(clientCtx, keyName)
+	if err != nil {
+		return nil, nil, utils.LavaFormatError("failed getting private key from key name", err, utils.Attribute{Key: "keyName", Value: keyName})
+	}
+	clientKey, _ := clientCtx.Keyring.Key(keyName)
+	var addr sdk.AccAddress
+	err = addr.Unmarshal(clientKey.GetPubKey().Address())
+	if err != nil {
+		return nil, nil, utils.LavaFormatError("failed unmarshaling public address", err, utils.Attribute{Key: "keyName", Value: keyName}, utils.Attribute{Key: "pubkey", Value: clientKey.GetPubKey().Address()})
+	}
+	return privKey, addr, nil
+}
+
+// returns nil if the error budget is not enabled
+func parseErrorBudgetPolicy(cmd *cobra.Command) (*lavasession.ErrorBudgetPolicy, error) {
+	maxFailures, err := cmd.Flags().GetUint64(lavasession.ErrorBudgetFailuresFlag)
+	if err != nil || maxFailures == 0 {
+		return nil, err
+	}
+	relays, err := cmd.Flags().GetUint64(lavasession.ErrorBudgetRelaysFlag)
+	if err != nil {
+		return nil, err
+	}
+	window, err := cmd.Flags().GetDuration(lavasession.ErrorBudgetWindowFlag)
+	if err != nil {
+		return nil, err
+	}
+	return &lavasession.ErrorBudgetPolicy{MaxFailures: maxFailures, Relays: relays, Window: window}, nil
+}
+
 func ParseEndpoints(viper_endpoints *viper.Viper, geolocation uint64) (endpoints []*lavasession.RPCEndpoint, err error) {
 	err = viper_endpoints.UnmarshalKey(commonlib.EndpointsConfigName, &endpoints)
 	if err != nil {
@@ -243,6 +333,16 @@ rpcconsumer 127.0.0.1:3333 COS3 tendermintrpc 127.0.0.1:3334 COS3 rest <flags>`,
 					return utils.LavaFormatError("failed to start pprof HTTP server", err)
 				}
 			}
+			metricsListenAddress, err := cmd.Flags().GetString(metrics.MetricsListenFlagName)
+			if err != nil {
+				utils.LavaFormatFatal("failed to read metrics listen address flag", err)
+			}
+			if metricsListenAddress != "" {
+				err = metrics.StartPrometheusServer(metricsListenAddress)
+				if err != nil {
+					return utils.LavaFormatError("failed to start prometheus metrics server", err)
+				}
+			}
 			clientCtx = clientCtx.WithChainID(networkChainId)
 			txFactory := tx.NewFactoryCLI(clientCtx, cmd.Flags())
 			rpcConsumer := RPCConsumer{}
@@ -265,7 +365,36 @@ rpcconsumer 127.0.0.1:3333 COS3 tendermintrpc 127.0.0.1:3334 COS3 rest <flags>`,
 					utils.LavaFormatInfo("cache service connected", utils.Attribute{Key: "address", Value: cacheAddr})
 				}
 			}
-			err = rpcConsumer.Start(ctx, txFactory, clientCtx, rpcEndpoints, requiredResponses, vrf_sk, cache)
+			options, err := ParseConsumerOptions(cmd, networkChainId)
+			if err != nil {
+				return err
+			}
+			qosHistoryAdminAddress, err := cmd.Flags().GetString(QoSHistoryAdminAddressFlag)
+			if err != nil {
+				return err
+			}
+			if qosHistoryAdminAddress != "" {
+				if options.QoSHistory == nil {
+					return utils.LavaFormatError("the qos history admin server requires a qos history file", nil, utils.Attribute{Key: "flag", Value: lavasession.QoSHistoryFileFlag})
+				}
+				StartQoSHistoryAdminServer(qosHistoryAdminAddress, options.QoSHistory)
+			}
+			projectKeyNames, err := cmd.Flags().GetStringSlice(ProjectKeysFlag)
+			if err != nil {
+				return err
+			}
+			consumerKeys, err := LoadConsumerKeys(clientCtx, projectKeyNames)
+			if err != nil {
+				return err
+			}
+			consumerKeysAdminAddress, err := cmd.Flags().GetString(ConsumerKeysAdminAddressFlag)
+			if err != nil {
+				return err
+			}
+			if consumerKeysAdminAddress != "" {
+				StartConsumerKeysAdminServer(consumerKeysAdminAddress, consumerKeys)
+			}
+			err = rpcConsumer.Start(ctx, txFactory, clientCtx, rpcEndpoints, requiredResponses, vrf_sk, cache, consumerKeys, options)
 			return err
 		},
 	}
@@ -280,6 +409,27 @@ rpcconsumer 127.0.0.1:3333 COS3 tendermintrpc 127.0.0.1:3334 COS3 rest <flags>`,
 	cmdRPCConsumer.Flags().Bool(commonlib.TestModeFlagName, false, "test mode causes rpcconsumer to send dummy data and print all of the metadata in it's listeners")
 	cmdRPCConsumer.Flags().String(performance.PprofAddressFlagName, "", "pprof server address, used for code profiling")
 	cmdRPCConsumer.Flags().String(performance.CacheFlagName, "", "address for a cache server to improve performance")
+	cmdRPCConsumer.Flags().String(statetracker.SpecOverlayFlagName, "", "path to a json file with local spec modifications for devnets and forks, disabled on mainnet")
+	cmdRPCConsumer.Flags().Uint64(statetracker.PairingPrefetchBlocksFlag, statetracker.DefaultPairingPrefetchBlocks, "blocks before the epoch boundary to start querying the next pairing so it's ready when the epoch starts, 0 to disable")
+	cmdRPCConsumer.Flags().StringSlice(statetracker.LavaNodeBackupsFlagName, []string{}, "backup lava node rpc addresses in order of preference, queries and transactions fail over to them when the --node fails or falls behind")
+	cmdRPCConsumer.Flags().Float64Slice(statetracker.SubscriptionCuThresholdsFlag, statetracker.DefaultSubscriptionCuThresholds, "parts of the subscription's monthly compute units that log a warning and send a subscription-cu-used quota webhook when used")
+	cmdRPCConsumer.Flags().UintSlice(statetracker.SubscriptionExpiryDaysFlag, []uint{7, 1}, "days before the subscription expires to log a warning and send a subscription-expiry quota webhook")
+	cmdRPCConsumer.Flags().Float64(statetracker.TxGasAdjustmentFlag, statetracker.DefaultTxGasAdjustment, "multiplier of the simulated gas of conflict detection transactions")
+	cmdRPCConsumer.Flags().Uint64(statetracker.ProcessingLagAlertFlag, statetracker.DefaultProcessingLagAlertBlocks, "lava blocks the state tracker can fall behind the chain tip before a warning is logged, 0 disables the warning")
+	cmdRPCConsumer.Flags().Uint64(statetracker.UpdaterParallelismFlag, statetracker.DefaultUpdaterParallelism, "how many state tracker updaters of the same priority run at once on a new lava block, so a slow query doesn't delay the others")
+	cmdRPCConsumer.Flags().String(statetracker.StateTrackerDebugAddressFlag, "", "address to serve the state tracker debug listing of updaters, updatables and their last results on, disabled if empty")
+	cmdRPCConsumer.Flags().String(statetracker.ProtocolVersionActionFlag, string(statetracker.ProtocolVersionActionWarn), "what to do when the binary is below the minimum protocol version of the lava chain: warn, unhealthy or shutdown")
+	cmdRPCConsumer.Flags().String(metrics.MetricsListenFlagName, "", "address to expose prometheus metrics on, disabled if empty")
+	cmdRPCConsumer.Flags().Uint64(lavasession.ErrorBudgetFailuresFlag, 0, "report a provider as unresponsive when this many of its recent relays failed, 0 reports only providers that never served a relay")
+	cmdRPCConsumer.Flags().Uint64(lavasession.ErrorBudgetRelaysFlag, lavasession.DefaultErrorBudgetRelays, "how many recent relays of a provider the error budget counts failures over")
+	cmdRPCConsumer.Flags().Duration(lavasession.ErrorBudgetWindowFlag, lavasession.DefaultErrorBudgetWindow, "relays older than this are not counted in the error budget")
+	cmdRPCConsumer.Flags().String(lavasession.QoSHistoryFileFlag, "", "path to a file the providers qos history is kept in across restarts, providers are chosen by their history when set")
+	cmdRPCConsumer.Flags().Float64(lavasession.QoSHistoryDecayFlag, lavasession.DefaultQoSHistoryDecay, "the weight the qos history keeps on every epoch, 1 never forgets")
+	cmdRPCConsumer.Flags().Float64(provideroptimizer.ExplorationRateFlag, provideroptimizer.DefaultExplorationRate, "share of sessions opened with a uniformly random provider instead of one weighted by its latency, error rate and sync score, between 0 and 1")
+	cmdRPCConsumer.Flags().String(QoSHistoryAdminAddressFlag, "", "address to serve the qos history admin api on, for inspecting and resetting providers history, disabled if empty")
+	cmdRPCConsumer.Flags().StringSlice(ProjectKeysFlag, []string{}, "names of additional keyring keys of the consumer project, the signing key can be rotated to them at runtime with the keys admin api")
+	cmdRPCConsumer.Flags().String(ConsumerKeysAdminAddressFlag, "", "address to serve the consumer keys admin api on, for loading project keys and rotating the signing key without a restart, disabled if empty")
+	cmdRPCConsumer.Flags().String(chainlib.GrpcDescriptorSetFlagName, "", "path to a protobuf descriptor set (protoc --descriptor_set_out --include_imports) of spec grpc services that aren't compiled in, grpc endpoints describe them over reflection and relay them")
 
 	return cmdRPCConsumer
 }
@@ -5,9 +5,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"strconv"
+	"sync"
 	"time"
 
-	"github.com/btcsuite/btcd/btcec"
 	"github.com/coniks-sys/coniks-go/crypto/vrf"
 	"github.com/lavanet/lava/protocol/chainlib"
 	"github.com/lavanet/lava/protocol/common"
@@ -15,10 +15,13 @@ import (
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/metrics"
 	"github.com/lavanet/lava/protocol/performance"
+	"github.com/lavanet/lava/protocol/statetracker"
 	"github.com/lavanet/lava/utils"
 	conflicttypes "github.com/lavanet/lava/x/conflict/types"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 const (
@@ -32,12 +35,14 @@ type RPCConsumerServer struct {
 	listenEndpoint         *lavasession.RPCEndpoint
 	rpcConsumerLogs        *common.RPCConsumerLogs
 	cache                  *performance.Cache
-	privKey                *btcec.PrivateKey
+	consumerKeys           *ConsumerKeys
 	consumerTxSender       ConsumerTxSender
 	requiredResponses      int
 	finalizationConsensus  *lavaprotocol.FinalizationConsensus
 	VrfSk                  vrf.PrivateKey
 	lavaChainID            string
+	policyLock             sync.RWMutex
+	policy                 *statetracker.EffectivePolicy // nil when the consumer has no project policy
 }
 
 type ConsumerTxSender interface {
@@ -50,11 +55,32 @@ func (rpccs *RPCConsumerServer) ServeRPCRequests(ctx context.Context, listenEndp
 	finalizationConsensus *lavaprotocol.FinalizationConsensus,
 	consumerSessionManager *lavasession.ConsumerSessionManager,
 	requiredResponses int,
-	privKey *btcec.PrivateKey,
+	consumerKeys *ConsumerKeys,
 	vrfSk vrf.PrivateKey,
 	lavaChainID string,
 	cache *performance.Cache, // optional
 ) (err error) {
+	rpccs.initialize(listenEndpoint, consumerStateTracker, chainParser, finalizationConsensus, consumerSessionManager, requiredResponses, consumerKeys, vrfSk, lavaChainID, cache)
+	chainListener, err := chainlib.NewChainListener(ctx, listenEndpoint, rpccs, rpccs.rpcConsumerLogs, chainParser)
+	if err != nil {
+		return err
+	}
+	go chainListener.Serve(ctx)
+	return nil
+}
+
+// initialize sets up everything needed for SendRelay without listening for requests
+func (rpccs *RPCConsumerServer) initialize(listenEndpoint *lavasession.RPCEndpoint,
+	consumerStateTracker ConsumerStateTrackerInf,
+	chainParser chainlib.ChainParser,
+	finalizationConsensus *lavaprotocol.FinalizationConsensus,
+	consumerSessionManager *lavasession.ConsumerSessionManager,
+	requiredResponses int,
+	consumerKeys *ConsumerKeys,
+	vrfSk vrf.PrivateKey,
+	lavaChainID string,
+	cache *performance.Cache, // optional
+) {
 	rpccs.consumerSessionManager = consumerSessionManager
 	rpccs.listenEndpoint = listenEndpoint
 	rpccs.cache = cache
@@ -67,14 +93,42 @@ func (rpccs *RPCConsumerServer) ServeRPCRequests(ctx context.Context, listenEndp
 	}
 	rpccs.lavaChainID = lavaChainID
 	rpccs.rpcConsumerLogs = pLogs
-	rpccs.privKey = privKey
+	rpccs.consumerKeys = consumerKeys
 	rpccs.chainParser = chainParser
 	rpccs.finalizationConsensus = finalizationConsensus
-	chainListener, err := chainlib.NewChainListener(ctx, listenEndpoint, rpccs, pLogs)
-	if err != nil {
-		return err
+}
+
+// PolicyChanged applies the project's policy to the endpoint, relays the policy doesn't allow are rejected and the epoch cu budget is capped by it
+func (rpccs *RPCConsumerServer) PolicyChanged(policy *statetracker.EffectivePolicy) {
+	rpccs.policyLock.Lock()
+	rpccs.policy = policy
+	rpccs.policyLock.Unlock()
+	chainID := rpccs.listenEndpoint.ChainID
+	if !policy.AllowsChain(chainID) {
+		utils.LavaFormatError("the project policy doesn't allow the endpoint's chain, relays are rejected", nil, utils.Attribute{Key: "chainID", Value: chainID},
+			utils.Attribute{Key: "apiInterface", Value: rpccs.listenEndpoint.ApiInterface}, utils.Attribute{Key: "project", Value: policy.Project})
+	}
+	if rpccs.listenEndpoint.Geolocation&policy.Geolocation == 0 {
+		utils.LavaFormatWarning("the project policy doesn't allow the endpoint's geolocation, providers are paired from the policy geolocations", nil, utils.Attribute{Key: "chainID", Value: chainID},
+			utils.Attribute{Key: "geolocation", Value: rpccs.listenEndpoint.Geolocation}, utils.Attribute{Key: "policyGeolocation", Value: policy.Geolocation})
+	}
+	rpccs.consumerSessionManager.CUBudgetController().SetAllowance(policy.EpochCuLimit)
+}
+
+func (rpccs *RPCConsumerServer) checkPolicy(api string) error {
+	rpccs.policyLock.RLock()
+	policy := rpccs.policy
+	rpccs.policyLock.RUnlock()
+	if policy == nil {
+		return nil
+	}
+	chainID := rpccs.listenEndpoint.ChainID
+	if !policy.AllowsChain(chainID) {
+		return utils.LavaFormatWarning("relay rejected, the project policy doesn't allow the chain", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "project", Value: policy.Project})
+	}
+	if !policy.AllowsApi(chainID, api) {
+		return utils.LavaFormatWarning("relay rejected, the project policy doesn't allow the api", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "api", Value: api}, utils.Attribute{Key: "project", Value: policy.Project})
 	}
-	go chainListener.Serve(ctx)
 	return nil
 }
 
@@ -98,6 +152,10 @@ func (rpccs *RPCConsumerServer) SendRelay(
 	if err != nil {
 		return nil, nil, err
 	}
+	err = rpccs.checkPolicy(chainMessage.GetServiceApi().Name)
+	if err != nil {
+		return nil, nil, err
+	}
 	// Unmarshal request
 	unwantedProviders := map[string]struct{}{}
 
@@ -106,7 +164,11 @@ func (rpccs *RPCConsumerServer) SendRelay(
 	relayResults := []*lavaprotocol.RelayResult{}
 	relayErrors := []error{}
 	blockOnSyncLoss := true
-	for retries := 0; retries < MaxRelayRetries; retries++ {
+	// when the epoch cu budget is about to run out we retry and hedge less
+	cuBudgetController := rpccs.consumerSessionManager.CUBudgetController()
+	maxRelayRetries := cuBudgetController.MaxRetries(MaxRelayRetries)
+	requiredResponses := cuBudgetController.RequiredResponses(rpccs.requiredResponses)
+	for retries := 0; retries < maxRelayRetries; retries++ {
 		// TODO: make this async between different providers
 		relayResult, err := rpccs.sendRelayToProvider(ctx, chainMessage, relayRequestData, dappID, &unwantedProviders)
 		if relayResult.ProviderAddress != "" {
@@ -128,7 +190,7 @@ func (rpccs *RPCConsumerServer) SendRelay(
 			continue
 		}
 		relayResults = append(relayResults, relayResult)
-		if len(relayResults) >= rpccs.requiredResponses {
+		if len(relayResults) >= requiredResponses {
 			break
 		}
 		// future requests need to ask for the same block height to get consensus on the reply
@@ -136,8 +198,11 @@ func (rpccs *RPCConsumerServer) SendRelay(
 	}
 
 	enabled, dataReliabilityThreshold := rpccs.chainParser.DataReliabilityParams()
-	if enabled {
+	if enabled && cuBudgetController.ShouldSendDataReliability() {
 		for _, relayResult := range relayResults {
+			if relayResult.ContinuationServed != "" {
+				continue // a partial reply can't be compared with another provider's reply
+			}
 			// new context is needed for data reliability as some clients cancel the context they provide when the relay returns
 			// as data reliability happens in a go routine it will continue while the response returns.
 			guid, found := utils.GetUniqueIdentifier(ctx)
@@ -160,6 +225,13 @@ func (rpccs *RPCConsumerServer) SendRelay(
 		// TODO: go over rpccs.requiredResponses and get majority
 		returnedResult = iteratedResult
 	}
+	reply := returnedResult.Reply
+	if returnedResult.ContinuationToken != "" {
+		reply, err = rpccs.fetchContinuationParts(ctx, chainMessage, relayRequestData, dappID, returnedResult)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
 	if analytics != nil {
 		currentLatency := time.Since(relaySentTime)
@@ -167,7 +239,7 @@ func (rpccs *RPCConsumerServer) SendRelay(
 		analytics.ComputeUnits = returnedResult.Request.RelaySession.CuSum
 	}
 
-	return returnedResult.Reply, returnedResult.ReplyServer, nil
+	return reply, returnedResult.ReplyServer, nil
 }
 
 func (rpccs *RPCConsumerServer) sendRelayToProvider(
@@ -197,7 +269,15 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	if err != nil {
 		return relayResult, err
 	}
-	privKey := rpccs.privKey
+	// the session is signed by the key that was active when it was created
+	privKey, err := rpccs.consumerKeys.SignerKey(singleConsumerSession.SignerGeneration)
+	if err != nil {
+		errUnused := rpccs.consumerSessionManager.OnSessionUnUsed(singleConsumerSession)
+		if errUnused != nil {
+			utils.LavaFormatError("failed releasing session without a signer", errUnused, utils.Attribute{Key: "GUID", Value: ctx})
+		}
+		return relayResult, err
+	}
 	chainID := rpccs.listenEndpoint.ChainID
 	lavaChainID := rpccs.lavaChainID
 	relayRequest, err := lavaprotocol.ConstructRelayRequest(ctx, privKey, lavaChainID, chainID, relayRequestData, providerPublicAddress, singleConsumerSession, int64(epoch), reportedProviders)
@@ -214,7 +294,10 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	// try using cache before sending relay
 	var reply *pairingtypes.RelayReply
 
-	reply, err = rpccs.cache.GetEntry(ctx, relayRequest, chainMessage.GetInterface().Interface, nil, chainID, false) // caching in the portal doesn't care about hashes, and we don't have data on finalization yet
+	continuationPart := chainlib.ContinuationTokenFromContext(ctx) != "" // parts of a range query aren't cached
+	if !continuationPart {
+		reply, err = rpccs.cache.GetEntry(ctx, chainlib.CanonicalRelayRequest(relayRequest), chainMessage.GetInterface().Interface, nil, chainID, false) // caching in the portal doesn't care about hashes, and we don't have data on finalization yet
+	}
 	if err == nil && reply != nil {
 		// Info was fetched from cache, so we don't need to change the state
 		// so we can return here, no need to update anything and calculate as this info was fetched from the cache
@@ -235,6 +318,14 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	relayTimeout := extraRelayTimeout + lavaprotocol.GetTimePerCu(singleConsumerSession.LatestRelayCu) + lavasession.AverageWorldLatency
 	relayResult, relayLatency, err, backoff := rpccs.relayInner(ctx, singleConsumerSession, relayResult, relayTimeout)
 	if err != nil {
+		if lavasession.IsUnsupportedMethod(err) {
+			// the provider's node doesn't have the method, another provider is tried without counting it as a provider failure
+			errUnused := rpccs.consumerSessionManager.OnSessionUnUsed(singleConsumerSession)
+			if errUnused != nil {
+				utils.LavaFormatError("failed releasing session of an unsupported method relay", errUnused, utils.Attribute{Key: "GUID", Value: ctx})
+			}
+			return relayResult, err
+		}
 		failRelaySession := func(origErr error, backoff_ bool) {
 			backOffDuration := 0 * time.Second
 			if backoff_ {
@@ -256,12 +347,16 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	latestBlock := relayResult.Reply.LatestBlock
 	err = rpccs.consumerSessionManager.OnSessionDone(singleConsumerSession, epoch, latestBlock, chainMessage.GetServiceApi().ComputeUnits, relayLatency, singleConsumerSession.CalculateExpectedLatency(relayTimeout), expectedBH, numOfProviders, pairingAddressesLen) // session done successfully
 
+	cacheExpiration := relayCacheExpiration(relayResult.Finalized, rpccs.consumerSessionManager.CUBudgetController(), rpccs.chainParser)
+	if relayResult.ContinuationServed != "" {
+		return relayResult, err
+	}
 	// set cache in a non blocking call
 	go func() {
 		new_ctx := context.Background()
 		new_ctx, cancel := context.WithTimeout(new_ctx, chainlib.DataReliabilityTimeoutIncrease)
 		defer cancel()
-		err2 := rpccs.cache.SetEntry(new_ctx, relayRequest, chainMessage.GetInterface().Interface, nil, chainID, dappID, relayResult.Reply, relayResult.Finalized) // caching in the portal doesn't care about hashes
+		err2 := rpccs.cache.SetEntryWithExpiration(new_ctx, chainlib.CanonicalRelayRequest(relayRequest), chainMessage.GetInterface().Interface, nil, chainID, dappID, relayResult.Reply, relayResult.Finalized, cacheExpiration) // caching in the portal doesn't care about hashes
 		if err2 != nil && !performance.NotInitialisedError.Is(err2) {
 			utils.LavaFormatWarning("error updating cache with new entry", err2)
 		}
@@ -269,16 +364,45 @@ func (rpccs *RPCConsumerServer) sendRelayToProvider(
 	return relayResult, err
 }
 
+// on a tight cu budget non finalized replies are reused until they could have been finalized, zero keeps the cache's expiration
+func relayCacheExpiration(finalized bool, cuBudgetController *lavasession.CUBudgetController, chainParser chainlib.ChainParser) time.Duration {
+	if finalized || !cuBudgetController.AggressiveCaching() {
+		return 0
+	}
+	_, averageBlockTime, blockDistanceForFinalizedData, _ := chainParser.ChainBlockStats()
+	return averageBlockTime * time.Duration(blockDistanceForFinalizedData)
+}
+
 func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSession *lavasession.SingleConsumerSession, relayResult *lavaprotocol.RelayResult, relayTimeout time.Duration) (relayResultRet *lavaprotocol.RelayResult, relayLatency time.Duration, err error, needsBackoff bool) {
 	existingSessionLatestBlock := singleConsumerSession.LatestBlock // we read it now because singleConsumerSession is locked, and later it's not
 	endpointClient := *singleConsumerSession.Endpoint.Client
 	providerPublicAddress := relayResult.ProviderAddress
 	relayRequest := relayResult.Request
+	var header metadata.MD // continuation tokens are returned in the reply header
 	callRelay := func() (reply *pairingtypes.RelayReply, relayLatency time.Duration, err error, backoff bool) {
 		relaySentTime := time.Now()
 		connectCtx, connectCtxCancel := context.WithTimeout(ctx, relayTimeout)
 		defer connectCtxCancel()
-		reply, err = endpointClient.Relay(connectCtx, relayRequest)
+		if relayRequest.DataReliability == nil {
+			// data reliability compares whole replies, so only regular relays can be served in parts
+			connectCtx = chainlib.AppendContinuationMetadata(connectCtx)
+		}
+		sentRequest := relayRequest
+		var payloadCipher *lavaprotocol.PayloadCipher
+		if rpccs.listenEndpoint.EncryptPayloads {
+			var ephemeralPubKey []byte
+			payloadCipher, ephemeralPubKey, err = sessionPayloadCipher(connectCtx, singleConsumerSession)
+			if err != nil {
+				return nil, 0, err, false
+			}
+			// the relay is signed in plaintext, only the copy that is sent is encrypted
+			sentRequest, err = payloadCipher.EncryptRelayRequest(relayRequest)
+			if err != nil {
+				return nil, 0, err, false
+			}
+			connectCtx = lavaprotocol.AppendPayloadEncryptionMetadata(connectCtx, ephemeralPubKey)
+		}
+		reply, err = endpointClient.Relay(connectCtx, sentRequest, grpc.Header(&header))
 		relayLatency = time.Since(relaySentTime)
 		if err != nil {
 			backoff := false
@@ -287,6 +411,12 @@ func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSe
 			}
 			return reply, 0, err, backoff
 		}
+		if payloadCipher != nil {
+			err = payloadCipher.DecryptRelayReply(reply)
+			if err != nil {
+				return nil, 0, err, false
+			}
+		}
 		return reply, relayLatency, nil, false
 	}
 	reply, relayLatency, err, backoff := callRelay()
@@ -294,6 +424,7 @@ func (rpccs *RPCConsumerServer) relayInner(ctx context.Context, singleConsumerSe
 		return relayResult, 0, err, backoff
 	}
 	relayResult.Reply = reply
+	relayResult.ContinuationServed, relayResult.ContinuationToken = chainlib.GetContinuationReply(header)
 	lavaprotocol.UpdateRequestedBlock(relayRequest.RelayData, reply) // update relay request requestedBlock to the provided one in case it was arbitrary
 	_, _, blockDistanceForFinalizedData, _ := rpccs.chainParser.ChainBlockStats()
 	finalized := spectypes.IsFinalizedBlock(relayRequest.RelayData.RequestBlock, reply.LatestBlock, blockDistanceForFinalizedData)
@@ -407,7 +538,11 @@ func (rpccs *RPCConsumerServer) sendDataReliabilityRelayIfApplicable(ctx context
 			reportedProviders = nil
 			utils.LavaFormatError("failed reading reported providers for epoch", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "epoch", Value: epoch})
 		}
-		reliabilityRequest, err := lavaprotocol.ConstructDataReliabilityRelayRequest(ctx, rpccs.lavaChainID, vrfData, rpccs.privKey, rpccs.listenEndpoint.ChainID, relayResult.Request.RelayData, providerAddress, epoch, reportedProviders, singleConsumerSession.RelayNum)
+		privKey, err := rpccs.consumerKeys.SignerKey(singleConsumerSession.SignerGeneration)
+		if err != nil {
+			return nil, err
+		}
+		reliabilityRequest, err := lavaprotocol.ConstructDataReliabilityRelayRequest(ctx, rpccs.lavaChainID, vrfData, privKey, rpccs.listenEndpoint.ChainID, relayResult.Request.RelayData, providerAddress, epoch, reportedProviders, singleConsumerSession.RelayNum)
 		if err != nil {
 			return nil, utils.LavaFormatError("failed creating data reliability relay", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "relayRequestData", Value: relayResult.Request.RelayData})
 		}
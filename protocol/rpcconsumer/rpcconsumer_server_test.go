@@ -0,0 +1,37 @@
+package rpcconsumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlockStatsChainParser answers only the chain block stats
+type fakeBlockStatsChainParser struct {
+	chainlib.ChainParser
+	averageBlockTime              time.Duration
+	blockDistanceForFinalizedData uint32
+}
+
+func (fbscp *fakeBlockStatsChainParser) ChainBlockStats() (allowedBlockLagForQosSync int64, averageBlockTime time.Duration, blockDistanceForFinalizedData uint32, blocksInFinalizationProof uint32) {
+	return 0, fbscp.averageBlockTime, fbscp.blockDistanceForFinalizedData, 1
+}
+
+func TestRelayCacheExpiration(t *testing.T) {
+	chainParser := &fakeBlockStatsChainParser{averageBlockTime: 10 * time.Second, blockDistanceForFinalizedData: 7}
+	cuBudgetController := lavasession.NewCUBudgetController("LAV1", "rest")
+	cuBudgetController.OnNewEpoch(20)
+	cuBudgetController.AddConsumedCU(500)
+	// without a throttle the cache's expiration is kept
+	require.Equal(t, lavasession.CUBudgetThrottleNone, cuBudgetController.ThrottleLevel())
+	require.Zero(t, relayCacheExpiration(false, cuBudgetController, chainParser))
+
+	cuBudgetController.SetAllowance(400)
+	require.Equal(t, lavasession.CUBudgetThrottleSevere, cuBudgetController.ThrottleLevel())
+	// a non finalized reply is cached until it could have been finalized
+	require.Equal(t, 70*time.Second, relayCacheExpiration(false, cuBudgetController, chainParser))
+	require.Zero(t, relayCacheExpiration(true, cuBudgetController, chainParser))
+}
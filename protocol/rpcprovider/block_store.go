@@ -0,0 +1,116 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	BlockBodyRetentionFlagName   = "block-body-retention"
+	BlockBodyMemoryLimitFlagName = "block-body-memory-limit"
+)
+
+var (
+	blockStoreServedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_block_store_served_total",
+		Help: "The number of block requests served from the retained block bodies instead of the node",
+	}, []string{"spec", "apiInterface"})
+	blockStoreServedBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_block_store_served_bytes_total",
+		Help: "The bytes of block replies served from the retained block bodies instead of the node",
+	}, []string{"spec", "apiInterface"})
+	blockStoreMemoryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_block_store_memory_bytes",
+		Help: "The compressed size of the retained block bodies",
+	}, []string{"spec"})
+)
+
+func init() {
+	prometheus.MustRegister(blockStoreServedCounter, blockStoreServedBytesCounter, blockStoreMemoryGauge)
+}
+
+// BlockStoreInf is implemented by the chain tracker when block body retention is enabled
+type BlockStoreInf interface {
+	GetLatestBlockNum() int64
+	GetBlockBody(blockNum int64) (hash string, data []byte, ok bool)
+	GetBlockBodyStats() (blocks int, compressedBytes uint64)
+}
+
+// serves json-rpc get block by number requests from the retained block bodies, returns nil if the request can't be served from them
+// the request must only differ from the api's function template by the requested block, and the reply gets the request's id
+func (rpcps *RPCProviderServer) replyFromBlockStore(ctx context.Context, chainMsg chainlib.ChainMessage) *pairingtypes.RelayReply {
+	if rpcps.blockStore == nil {
+		return nil
+	}
+	reqMsg, ok := chainMsg.GetRPCMessage().(*rpcInterfaceMessages.JsonrpcMessage)
+	if !ok {
+		return nil
+	}
+	serviceApi, ok := rpcps.chainParser.GetSpecApiByTag(spectypes.GET_BLOCK_BY_NUM)
+	if !ok || serviceApi.Name != chainMsg.GetServiceApi().Name || serviceApi.GetParsing().FunctionTemplate == "" {
+		return nil
+	}
+	blockParsing := chainMsg.GetServiceApi().BlockParsing
+	if blockParsing.ParserFunc != spectypes.PARSER_FUNC_PARSE_BY_ARG || len(blockParsing.ParserArg) != 1 {
+		return nil
+	}
+	blockParamIdx, err := strconv.Atoi(blockParsing.ParserArg[0])
+	if err != nil {
+		return nil
+	}
+	blockNum := chainMsg.RequestedBlock()
+	if blockNum == spectypes.LATEST_BLOCK {
+		blockNum = rpcps.blockStore.GetLatestBlockNum()
+	} else if blockNum < 0 {
+		return nil
+	}
+	_, data, ok := rpcps.blockStore.GetBlockBody(blockNum)
+	if !ok {
+		return nil
+	}
+	templateMsg, err := rpcInterfaceMessages.ParseJsonRPCMsg([]byte(fmt.Sprintf(serviceApi.GetParsing().FunctionTemplate, blockNum)))
+	if err != nil || !sameParamsExceptBlock(reqMsg.Params, templateMsg.Params, blockParamIdx) {
+		return nil
+	}
+	nodeReply, err := rpcInterfaceMessages.ParseJsonRPCMsg(data)
+	if err != nil || nodeReply.Error != nil || len(nodeReply.Result) == 0 {
+		return nil
+	}
+	replyData, err := json.Marshal(rpcInterfaceMessages.JsonrpcMessage{Version: nodeReply.Version, ID: reqMsg.ID, Result: nodeReply.Result})
+	if err != nil {
+		return nil
+	}
+	blockStoreServedCounter.WithLabelValues(rpcps.rpcProviderEndpoint.ChainID, rpcps.rpcProviderEndpoint.ApiInterface).Inc()
+	blockStoreServedBytesCounter.WithLabelValues(rpcps.rpcProviderEndpoint.ChainID, rpcps.rpcProviderEndpoint.ApiInterface).Add(float64(len(replyData)))
+	_, compressedBytes := rpcps.blockStore.GetBlockBodyStats()
+	blockStoreMemoryGauge.WithLabelValues(rpcps.rpcProviderEndpoint.ChainID).Set(float64(compressedBytes))
+	utils.LavaFormatDebug("served block from the block store", utils.Attribute{Key: "block", Value: blockNum}, utils.Attribute{Key: "GUID", Value: ctx})
+	return &pairingtypes.RelayReply{Data: replyData}
+}
+
+func sameParamsExceptBlock(params interface{}, templateParams interface{}, blockParamIdx int) bool {
+	paramsList, ok := params.([]interface{})
+	if !ok {
+		return false
+	}
+	templateParamsList, ok := templateParams.([]interface{})
+	if !ok || len(paramsList) != len(templateParamsList) || blockParamIdx >= len(paramsList) {
+		return false
+	}
+	for idx := range paramsList {
+		if idx != blockParamIdx && !reflect.DeepEqual(paramsList[idx], templateParamsList[idx]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package rpcprovider
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+)
+
+// the chain tracker window needed to serve finalization proofs of the spec
+func chainTrackerBlocksWindow(blocksToFinalization uint32, blocksInFinalizationData uint32) (blocksToSave uint64, serverBlockMemory uint64, finalizationDistance uint64) {
+	blocksToSave = uint64(blocksToFinalization + blocksInFinalizationData)
+	if blocksInFinalizationData > 0 {
+		// the finalization distance has to leave at least one saved block finalized
+		finalizationDistance = uint64(blocksToFinalization)
+	}
+	return blocksToSave, ChainTrackerDefaultMemory + blocksToSave, finalizationDistance
+}
+
+// the light client reads headers from the endpoint's own tendermint rpc node unless another primary is configured, the witnesses are what protect against a compromised node
+func newLightClientVerifier(ctx context.Context, rpcProviderEndpoint *lavasession.RPCProviderEndpoint) (*chaintracker.TendermintLightVerifier, error) {
+	lightClientConfig := *rpcProviderEndpoint.LightClient
+	if lightClientConfig.Primary == "" && rpcProviderEndpoint.ApiInterface == spectypes.APIInterfaceTendermintRPC {
+		for _, nodeUrl := range rpcProviderEndpoint.NodeUrls {
+			if strings.HasPrefix(nodeUrl.Url, "http") {
+				lightClientConfig.Primary = nodeUrl.Url
+				break
+			}
+		}
+	}
+	return chaintracker.NewTendermintLightVerifier(ctx, &lightClientConfig)
+}
+
+// chainTrackerSpecUpdater updates the chain tracker window and polling time when governance changes the spec, without a restart
+type chainTrackerSpecUpdater struct {
+	chainTracker *chaintracker.ChainTracker
+	config       chaintracker.ChainTrackerConfig // the config the tracker was created with
+}
+
+func (ctsu *chainTrackerSpecUpdater) SetSpec(spec spectypes.Spec) {
+	config := ctsu.config
+	config.BlocksToSave, config.ServerBlockMemory, config.FinalizationDistance = chainTrackerBlocksWindow(spec.BlockDistanceForFinalizedData, spec.BlocksInFinalizationProof)
+	if spec.AverageBlockTime > 0 {
+		config.AverageBlockTime = time.Duration(spec.AverageBlockTime) * time.Millisecond
+	}
+	err := ctsu.chainTracker.UpdateConfig(config)
+	if err != nil {
+		utils.LavaFormatError("failed updating the chain tracker config to the new spec, keeping the current one", err, utils.Attribute{Key: "chainID", Value: spec.Index},
+			utils.Attribute{Key: "blockDistanceForFinalizedData", Value: spec.BlockDistanceForFinalizedData}, utils.Attribute{Key: "blocksInFinalizationProof", Value: spec.BlocksInFinalizationProof})
+	}
+}
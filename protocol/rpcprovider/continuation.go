@@ -0,0 +1,43 @@
+package rpcprovider
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const MaxRangeBlocksFlagName = "max-range-blocks"
+
+// splits a heavy range query when the consumer supports continuation tokens, split is true if the returned chain message
+// only covers part of the request. the served part and the token of the next one are returned in the reply headers
+func (rpcps *RPCProviderServer) splitRangeRequest(ctx context.Context, request *pairingtypes.RelayRequest, chainMsg chainlib.ChainMessage) (partialMsg chainlib.ChainMessage, split bool, err error) {
+	if rpcps.maxRangeBlocks == 0 {
+		return chainMsg, false, nil
+	}
+	supported, token := chainlib.GetContinuationRequest(ctx)
+	if !supported {
+		return chainMsg, false, nil
+	}
+	partialData, servedToken, nextToken, err := chainlib.SplitRangeRequest(rpcps.rpcProviderEndpoint.ApiInterface, request.RelayData.Data, token, rpcps.maxRangeBlocks)
+	if err != nil || partialData == nil {
+		return chainMsg, false, err
+	}
+	partialMsg, err = rpcps.chainParser.ParseMsg(request.RelayData.ApiUrl, partialData, request.RelayData.ConnectionType)
+	if err != nil {
+		return nil, false, utils.LavaFormatError("failed parsing partial range request", err, utils.Attribute{Key: "GUID", Value: ctx})
+	}
+	header := metadata.Pairs(chainlib.ContinuationServedHeader, servedToken)
+	if nextToken != "" {
+		header.Append(chainlib.ContinuationTokenHeader, nextToken)
+	}
+	err = grpc.SetHeader(ctx, header)
+	if err != nil {
+		return nil, false, utils.LavaFormatError("failed setting continuation headers", err, utils.Attribute{Key: "GUID", Value: ctx})
+	}
+	utils.LavaFormatDebug("serving part of a range request", utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "served", Value: servedToken}, utils.Attribute{Key: "next", Value: nextToken})
+	return partialMsg, true, nil
+}
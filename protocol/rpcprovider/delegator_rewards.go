@@ -0,0 +1,62 @@
+package rpcprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	DelegatorRewardsClaimIntervalFlagName = "delegator-rewards-claim-interval"
+)
+
+type delegatorRewardsClaimer interface {
+	TxClaimDelegatorRewards(ctx context.Context, validators []string) error
+}
+
+// DelegatorRewardsHandler claims the rewards of the provider's delegations once per claim interval, a zero interval only exports them
+type DelegatorRewardsHandler struct {
+	ctx           context.Context
+	claimInterval time.Duration
+	claimer       delegatorRewardsClaimer
+	lock          sync.Mutex
+	lastClaim     time.Time
+	claiming      bool
+}
+
+func NewDelegatorRewardsHandler(ctx context.Context, claimInterval time.Duration, claimer delegatorRewardsClaimer) *DelegatorRewardsHandler {
+	// the first claim is an interval after the start, the rewards since the last run might have been claimed before the restart
+	return &DelegatorRewardsHandler{ctx: ctx, claimInterval: claimInterval, claimer: claimer, lastClaim: time.Now()}
+}
+
+func (drh *DelegatorRewardsHandler) DelegatorRewardsUpdated(rewards statetracker.DelegatorRewards) {
+	if drh.claimInterval <= 0 || rewards.Total.IsZero() {
+		return
+	}
+	drh.lock.Lock()
+	if drh.claiming || time.Since(drh.lastClaim) < drh.claimInterval {
+		drh.lock.Unlock()
+		return
+	}
+	drh.claiming = true
+	drh.lock.Unlock()
+	validators := rewards.ClaimableValidators()
+	// the transactions are sent outside of the state tracker update
+	go func() {
+		err := drh.claimer.TxClaimDelegatorRewards(drh.ctx, validators)
+		drh.lock.Lock()
+		drh.claiming = false
+		if err == nil {
+			drh.lastClaim = time.Now()
+		}
+		drh.lock.Unlock()
+		if err != nil {
+			utils.LavaFormatError("failed claiming delegator rewards, retrying on the next rewards update", err, utils.Attribute{Key: "validators", Value: validators})
+			return
+		}
+		utils.LavaFormatInfo("sent delegator rewards claim transactions", utils.Attribute{Key: "validators", Value: validators}, utils.Attribute{Key: "rewards", Value: rewards.Total.String()})
+	}()
+}
@@ -0,0 +1,77 @@
+package rpcprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDelegatorRewardsClaimer sends the claimed validators on claims and returns err
+type fakeDelegatorRewardsClaimer struct {
+	claims chan []string
+	err    error
+}
+
+func (fdrc *fakeDelegatorRewardsClaimer) TxClaimDelegatorRewards(ctx context.Context, validators []string) error {
+	fdrc.claims <- validators
+	return fdrc.err
+}
+
+func testDelegatorRewards(amount int64) statetracker.DelegatorRewards {
+	coins := sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(amount)))
+	return statetracker.DelegatorRewards{Rewards: map[string]sdk.DecCoins{"lavavaloper@a": coins}, Total: coins}
+}
+
+// waitForClaimDone waits for the claim goroutine to record its result
+func waitForClaimDone(t *testing.T, drh *DelegatorRewardsHandler) {
+	require.Eventually(t, func() bool {
+		drh.lock.Lock()
+		defer drh.lock.Unlock()
+		return !drh.claiming
+	}, time.Second, time.Millisecond)
+}
+
+func TestDelegatorRewardsHandlerClaimInterval(t *testing.T) {
+	claimer := &fakeDelegatorRewardsClaimer{claims: make(chan []string, 10)}
+	handler := NewDelegatorRewardsHandler(context.Background(), time.Hour, claimer)
+	// the first claim is an interval after the start
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(5))
+	require.Empty(t, claimer.claims)
+
+	handler.lastClaim = time.Now().Add(-time.Hour)
+	// nothing to claim
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(0))
+	require.Empty(t, claimer.claims)
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(5))
+	require.Equal(t, []string{"lavavaloper@a"}, <-claimer.claims)
+	waitForClaimDone(t, handler)
+	// a successful claim starts a new interval
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(5))
+	require.Empty(t, claimer.claims)
+}
+
+func TestDelegatorRewardsHandlerRetriesFailedClaim(t *testing.T) {
+	claimer := &fakeDelegatorRewardsClaimer{claims: make(chan []string, 10), err: errors.New("claim failed")}
+	handler := NewDelegatorRewardsHandler(context.Background(), time.Hour, claimer)
+	handler.lastClaim = time.Now().Add(-time.Hour)
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(5))
+	<-claimer.claims
+	waitForClaimDone(t, handler)
+	// the interval didn't restart, the next update claims again
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(5))
+	<-claimer.claims
+	waitForClaimDone(t, handler)
+}
+
+func TestDelegatorRewardsHandlerDisabled(t *testing.T) {
+	claimer := &fakeDelegatorRewardsClaimer{claims: make(chan []string, 10)}
+	handler := NewDelegatorRewardsHandler(context.Background(), 0, claimer)
+	handler.lastClaim = time.Now().Add(-time.Hour)
+	handler.DelegatorRewardsUpdated(testDelegatorRewards(5))
+	require.Empty(t, claimer.claims)
+}
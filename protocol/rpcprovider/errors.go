@@ -0,0 +1,10 @@
+package rpcprovider
+
+import (
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+var (
+	NodeSaturatedError     = sdkerrors.New("NodeSaturated Error", 950, "node is saturated and the relay can't be scheduled before its deadline")
+	RelayHandlerStuckError = sdkerrors.New("RelayHandlerStuck Error", 951, "relay handler exceeded the hard ceiling and was force cancelled")
+)
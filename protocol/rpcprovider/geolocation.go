@@ -0,0 +1,188 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ProviderHealthPath        = "/lava/health"
+	GeolocationQueryParam     = "geolocation"
+	geolocationHealthInterval = 10 * time.Second // how often the health gauges are updated
+)
+
+var (
+	geolocationRelaysCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_geolocation_relays_total",
+		Help: "The number of relays served per geolocation",
+	}, []string{"geolocation", "spec", "apiInterface"})
+	geolocationRelayErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_geolocation_relay_errors_total",
+		Help: "The number of relays that failed per geolocation",
+	}, []string{"geolocation", "spec", "apiInterface"})
+	geolocationEndpointHealthyGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_geolocation_endpoint_healthy",
+		Help: "1 if the endpoint's chain tracker is healthy, not stale and not lagging, 0 otherwise",
+	}, []string{"geolocation", "spec", "apiInterface"})
+)
+
+func init() {
+	prometheus.MustRegister(geolocationRelaysCounter, geolocationRelayErrorsCounter, geolocationEndpointHealthyGauge)
+}
+
+func recordGeolocationRelay(endpoint *lavasession.RPCProviderEndpoint, success bool) {
+	geolocation := strconv.FormatUint(endpoint.Geolocation, 10)
+	geolocationRelaysCounter.WithLabelValues(geolocation, endpoint.ChainID, endpoint.ApiInterface).Inc()
+	if !success {
+		geolocationRelayErrorsCounter.WithLabelValues(geolocation, endpoint.ChainID, endpoint.ApiInterface).Inc()
+	}
+}
+
+// GeolocationHealth is the health of all the endpoints served for a single geolocation
+type GeolocationHealth struct {
+	Geolocation uint64             `json:"geolocation"`
+	Healthy     bool               `json:"healthy"`
+	Endpoints   []ManifestEndpoint `json:"endpoints"`
+}
+
+// ProviderGeolocations groups the endpoints of a provider process by geolocation, so a single process can serve several
+// geolocations from different listeners and each one's health is reported separately
+type ProviderGeolocations struct {
+	lock          sync.RWMutex
+	endpoints     map[uint64][]manifestEndpoint
+	processHealth func() bool // checks of the whole process, nil if none
+}
+
+func NewProviderGeolocations() *ProviderGeolocations {
+	return &ProviderGeolocations{endpoints: map[uint64][]manifestEndpoint{}}
+}
+
+func (pg *ProviderGeolocations) RegisterEndpoint(endpoint *lavasession.RPCProviderEndpoint, chainTracker ManifestChainTrackerInf) {
+	if pg == nil {
+		return
+	}
+	pg.lock.Lock()
+	defer pg.lock.Unlock()
+	pg.endpoints[endpoint.Geolocation] = append(pg.endpoints[endpoint.Geolocation], manifestEndpoint{endpoint: endpoint, chainTracker: chainTracker})
+}
+
+// Geolocations returns the served geolocations in ascending order
+func (pg *ProviderGeolocations) Geolocations() []uint64 {
+	pg.lock.RLock()
+	defer pg.lock.RUnlock()
+	geolocations := make([]uint64, 0, len(pg.endpoints))
+	for geolocation := range pg.endpoints {
+		geolocations = append(geolocations, geolocation)
+	}
+	sort.Slice(geolocations, func(i, j int) bool { return geolocations[i] < geolocations[j] })
+	return geolocations
+}
+
+// SetProcessHealthCheck adds a check that makes every geolocation unhealthy when it fails, such as an unsupported protocol version
+func (pg *ProviderGeolocations) SetProcessHealthCheck(processHealth func() bool) {
+	pg.lock.Lock()
+	defer pg.lock.Unlock()
+	pg.processHealth = processHealth
+}
+
+// GetGeolocationHealth is healthy if every endpoint of the geolocation is healthy, not stale and not lagging, a geolocation with no endpoints is unhealthy
+func (pg *ProviderGeolocations) GetGeolocationHealth(geolocation uint64) GeolocationHealth {
+	pg.lock.RLock()
+	registered := pg.endpoints[geolocation]
+	processHealth := pg.processHealth
+	pg.lock.RUnlock()
+	health := GeolocationHealth{Geolocation: geolocation, Healthy: len(registered) > 0 && (processHealth == nil || processHealth()), Endpoints: []ManifestEndpoint{}}
+	for _, endpointHealth := range registered {
+		manifestEndpoint := ManifestEndpoint{
+			ChainID:      endpointHealth.endpoint.ChainID,
+			ApiInterface: endpointHealth.endpoint.ApiInterface,
+			Geolocation:  geolocation,
+			Lagging:      endpointHealth.chainTracker.IsLagging(),
+			Health:       endpointHealth.chainTracker.GetHealthStatus(),
+		}
+		health.Healthy = health.Healthy && endpointHealthy(manifestEndpoint)
+		health.Endpoints = append(health.Endpoints, manifestEndpoint)
+	}
+	return health
+}
+
+// a stale node answers but serves outdated data, so it is unhealthy as well
+func endpointHealthy(endpoint ManifestEndpoint) bool {
+	return endpoint.Health.Healthy && !endpoint.Health.Stale && !endpoint.Lagging
+}
+
+func (pg *ProviderGeolocations) updateHealthGauges() {
+	for _, geolocation := range pg.Geolocations() {
+		health := pg.GetGeolocationHealth(geolocation)
+		for _, endpoint := range health.Endpoints {
+			healthy := 0.0
+			if endpointHealthy(endpoint) {
+				healthy = 1
+			}
+			geolocationEndpointHealthyGauge.WithLabelValues(strconv.FormatUint(geolocation, 10), endpoint.ChainID, endpoint.ApiInterface).Set(healthy)
+		}
+	}
+}
+
+// Start updates the per geolocation health gauges until ctx is done
+func (pg *ProviderGeolocations) Start(ctx context.Context) {
+	if pg == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(geolocationHealthInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				pg.updateHealthGauges()
+			}
+		}
+	}()
+}
+
+// healthHandler reports the geolocation in the query, or the listener's geolocations if none is requested.
+// it replies 503 if any reported geolocation is unhealthy so load balancers can check each geolocation's listener
+func (pg *ProviderGeolocations) healthHandler(resp http.ResponseWriter, req *http.Request, listenerGeolocations []uint64) {
+	if req.Method != http.MethodGet {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	geolocations := listenerGeolocations
+	if requested := req.URL.Query().Get(GeolocationQueryParam); requested != "" {
+		geolocation, err := strconv.ParseUint(requested, 10, 64)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		geolocations = []uint64{geolocation}
+	}
+	reply := []GeolocationHealth{}
+	healthy := len(geolocations) > 0
+	for _, geolocation := range geolocations {
+		health := pg.GetGeolocationHealth(geolocation)
+		healthy = healthy && health.Healthy
+		reply = append(reply, health)
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	if healthy {
+		resp.WriteHeader(http.StatusOK)
+	} else {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(resp).Encode(reply)
+	if err != nil {
+		utils.LavaFormatWarning("failed writing provider health reply", err)
+	}
+}
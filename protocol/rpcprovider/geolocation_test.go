@@ -0,0 +1,122 @@
+package rpcprovider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var healthyChainTracker = &fakeManifestChainTracker{health: chaintracker.HealthStatus{Healthy: true, Ready: true}}
+
+func newTestProviderGeolocations() *ProviderGeolocations {
+	pg := NewProviderGeolocations()
+	pg.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "GEO1", ApiInterface: "jsonrpc", Geolocation: 2}, healthyChainTracker)
+	pg.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "GEO1", ApiInterface: "jsonrpc", Geolocation: 1}, healthyChainTracker)
+	pg.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "GEO2", ApiInterface: "rest", Geolocation: 1}, healthyChainTracker)
+	return pg
+}
+
+func serveHealth(pg *ProviderGeolocations, method string, target string, listenerGeolocations []uint64) (int, []GeolocationHealth) {
+	recorder := httptest.NewRecorder()
+	pg.healthHandler(recorder, httptest.NewRequest(method, target, nil), listenerGeolocations)
+	reply := []GeolocationHealth{}
+	json.Unmarshal(recorder.Body.Bytes(), &reply)
+	return recorder.Code, reply
+}
+
+func TestProviderGeolocationsHealth(t *testing.T) {
+	pg := newTestProviderGeolocations()
+	require.Equal(t, []uint64{1, 2}, pg.Geolocations())
+	health := pg.GetGeolocationHealth(1)
+	require.True(t, health.Healthy)
+	require.Len(t, health.Endpoints, 2)
+	// a geolocation without endpoints isn't served
+	require.False(t, pg.GetGeolocationHealth(4).Healthy)
+
+	// a stale or lagging endpoint makes only its own geolocation unhealthy
+	for _, unhealthy := range []*fakeManifestChainTracker{
+		{health: chaintracker.HealthStatus{Healthy: false}},
+		{health: chaintracker.HealthStatus{Healthy: true, Stale: true}},
+		{health: chaintracker.HealthStatus{Healthy: true}, lagging: true},
+	} {
+		pg := newTestProviderGeolocations()
+		pg.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "GEO3", ApiInterface: "grpc", Geolocation: 2}, unhealthy)
+		require.True(t, pg.GetGeolocationHealth(1).Healthy)
+		require.False(t, pg.GetGeolocationHealth(2).Healthy)
+	}
+
+	// a failing process check makes every geolocation unhealthy
+	processHealthy := false
+	pg.SetProcessHealthCheck(func() bool { return processHealthy })
+	require.False(t, pg.GetGeolocationHealth(1).Healthy)
+	require.False(t, pg.GetGeolocationHealth(2).Healthy)
+	processHealthy = true
+	require.True(t, pg.GetGeolocationHealth(2).Healthy)
+}
+
+func TestProviderGeolocationsHealthHandler(t *testing.T) {
+	pg := newTestProviderGeolocations()
+	pg.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "GEO3", ApiInterface: "grpc", Geolocation: 2}, &fakeManifestChainTracker{health: chaintracker.HealthStatus{Healthy: true}, lagging: true})
+
+	// the listener's geolocations are reported when none is requested
+	code, reply := serveHealth(pg, http.MethodGet, ProviderHealthPath, []uint64{1})
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, reply, 1)
+	require.Equal(t, uint64(1), reply[0].Geolocation)
+	code, reply = serveHealth(pg, http.MethodGet, ProviderHealthPath, []uint64{1, 2})
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Len(t, reply, 2)
+	require.False(t, reply[1].Healthy)
+	require.True(t, reply[1].Endpoints[1].Lagging)
+
+	// a requested geolocation replaces the listener's
+	code, reply = serveHealth(pg, http.MethodGet, ProviderHealthPath+"?geolocation=2", []uint64{1})
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, uint64(2), reply[0].Geolocation)
+	code, _ = serveHealth(pg, http.MethodGet, ProviderHealthPath+"?geolocation=1", nil)
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = serveHealth(pg, http.MethodGet, ProviderHealthPath, nil)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	code, _ = serveHealth(pg, http.MethodGet, ProviderHealthPath+"?geolocation=eu", []uint64{1})
+	require.Equal(t, http.StatusBadRequest, code)
+	code, _ = serveHealth(pg, http.MethodPost, ProviderHealthPath, []uint64{1})
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestProviderGeolocationsMetrics(t *testing.T) {
+	pg := newTestProviderGeolocations()
+	pg.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "GEO4", ApiInterface: "grpc", Geolocation: 2}, &fakeManifestChainTracker{health: chaintracker.HealthStatus{Healthy: true, Stale: true}})
+	pg.updateHealthGauges()
+	require.Equal(t, float64(1), testutil.ToFloat64(geolocationEndpointHealthyGauge.WithLabelValues("2", "GEO1", "jsonrpc")))
+	require.Equal(t, float64(0), testutil.ToFloat64(geolocationEndpointHealthyGauge.WithLabelValues("2", "GEO4", "grpc")))
+
+	endpoint := &lavasession.RPCProviderEndpoint{ChainID: "GEO5", ApiInterface: "rest", Geolocation: 4}
+	relays := testutil.ToFloat64(geolocationRelaysCounter.WithLabelValues("4", "GEO5", "rest"))
+	relayErrors := testutil.ToFloat64(geolocationRelayErrorsCounter.WithLabelValues("4", "GEO5", "rest"))
+	recordGeolocationRelay(endpoint, true)
+	recordGeolocationRelay(endpoint, false)
+	require.Equal(t, relays+2, testutil.ToFloat64(geolocationRelaysCounter.WithLabelValues("4", "GEO5", "rest")))
+	require.Equal(t, relayErrors+1, testutil.ToFloat64(geolocationRelayErrorsCounter.WithLabelValues("4", "GEO5", "rest")))
+}
+
+func TestProviderListenerGeolocations(t *testing.T) {
+	pl := &ProviderListener{}
+	pl.addGeolocation(2)
+	pl.addGeolocation(1)
+	pl.addGeolocation(2)
+	geolocations := pl.getGeolocations()
+	require.Equal(t, []uint64{2, 1}, geolocations)
+	// the returned slice is a copy
+	geolocations[0] = 4
+	require.Equal(t, []uint64{2, 1}, pl.getGeolocations())
+
+	var nilGeolocations *ProviderGeolocations
+	nilGeolocations.RegisterEndpoint(&lavasession.RPCProviderEndpoint{}, healthyChainTracker)
+}
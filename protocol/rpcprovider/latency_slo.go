@@ -0,0 +1,277 @@
+package rpcprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+)
+
+const (
+	LatencySLOsConfigName           = "latency-slos"
+	LatencySLOWebhookConfigName     = "latency-slo-webhook"
+	DefaultLatencySLOWindow         = 10 * time.Minute
+	DefaultLatencySLOAlertBurnRate  = 2.0
+	LatencySLOWindowBuckets         = 10
+	LatencySLOWebhookTimeout        = 5 * time.Second
+	LatencySLOCategoryDefault       = "default"
+	LatencySLOCategoryDeterministic = "deterministic"
+	LatencySLOCategoryLocal         = "local"
+	LatencySLOCategorySubscription  = "subscription"
+	LatencySLOCategoryStateful      = "stateful"
+	LatencySLOCategoryHanging       = "hanging"
+)
+
+var (
+	sloRelaysCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_latency_slo_relays_total",
+		Help: "The number of relays measured against a latency slo",
+	}, []string{"spec", "apiInterface", "category"})
+	sloViolationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_latency_slo_violations_total",
+		Help: "The number of relays that violated their latency slo, failed relays are violations",
+	}, []string{"spec", "apiInterface", "category"})
+	sloBurnRateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_latency_slo_burn_rate",
+		Help: "The rate the error budget is consumed in the current window, 1 means exactly on budget",
+	}, []string{"spec", "apiInterface", "category"})
+)
+
+func init() {
+	prometheus.MustRegister(sloRelaysCounter, sloViolationsCounter, sloBurnRateGauge)
+}
+
+// LatencySLO is defined by the operator per api category in the provider config file:
+//
+//	latency-slos:
+//	  - category: deterministic
+//	    threshold: 500ms
+//	    objective: 0.99
+//	    window: 10m
+//	    alert-burn-rate: 2
+type LatencySLO struct {
+	Category      string        `yaml:"category,omitempty" json:"category,omitempty" mapstructure:"category"`
+	Threshold     time.Duration `yaml:"threshold,omitempty" json:"threshold,omitempty" mapstructure:"threshold"`
+	Objective     float64       `yaml:"objective,omitempty" json:"objective,omitempty" mapstructure:"objective"`
+	Window        time.Duration `yaml:"window,omitempty" json:"window,omitempty" mapstructure:"window"`
+	AlertBurnRate float64       `yaml:"alert-burn-rate,omitempty" json:"alert-burn-rate,omitempty" mapstructure:"alert-burn-rate"`
+}
+
+func (slo *LatencySLO) validate() error {
+	if slo.Category == "" {
+		slo.Category = LatencySLOCategoryDefault
+	}
+	if slo.Threshold <= 0 {
+		return utils.LavaFormatError("invalid latency slo threshold", nil, utils.Attribute{Key: "slo", Value: slo})
+	}
+	if slo.Objective <= 0 || slo.Objective >= 1 {
+		return utils.LavaFormatError("invalid latency slo objective, must be between 0 and 1", nil, utils.Attribute{Key: "slo", Value: slo})
+	}
+	if slo.Window <= 0 {
+		slo.Window = DefaultLatencySLOWindow
+	}
+	if slo.AlertBurnRate <= 0 {
+		slo.AlertBurnRate = DefaultLatencySLOAlertBurnRate
+	}
+	return nil
+}
+
+type LatencySLOAlert struct {
+	ChainID      string        `json:"chainID"`
+	ApiInterface string        `json:"apiInterface"`
+	Category     string        `json:"category"`
+	Threshold    time.Duration `json:"threshold"`
+	Objective    float64       `json:"objective"`
+	BurnRate     float64       `json:"burnRate"`
+	Relays       uint64        `json:"relays"`
+	Violations   uint64        `json:"violations"`
+	Time         time.Time     `json:"time"`
+}
+
+type sloBucket struct {
+	start      time.Time
+	relays     uint64
+	violations uint64
+}
+
+type sloState struct {
+	slo       LatencySLO
+	buckets   []sloBucket
+	lastAlert time.Time
+}
+
+func (ss *sloState) add(now time.Time, violation bool) {
+	bucketDuration := ss.slo.Window / LatencySLOWindowBuckets
+	idx := len(ss.buckets) - 1
+	if idx < 0 || now.Sub(ss.buckets[idx].start) >= bucketDuration {
+		ss.buckets = append(ss.buckets, sloBucket{start: now})
+		idx++
+	}
+	ss.buckets[idx].relays++
+	if violation {
+		ss.buckets[idx].violations++
+	}
+	// drop buckets that left the window
+	for len(ss.buckets) > 0 && now.Sub(ss.buckets[0].start) > ss.slo.Window {
+		ss.buckets = ss.buckets[1:]
+	}
+}
+
+func (ss *sloState) burnRate() (burnRate float64, relays uint64, violations uint64) {
+	for _, bucket := range ss.buckets {
+		relays += bucket.relays
+		violations += bucket.violations
+	}
+	if relays == 0 {
+		return 0, 0, 0
+	}
+	errorBudget := 1 - ss.slo.Objective
+	return (float64(violations) / float64(relays)) / errorBudget, relays, violations
+}
+
+// LatencySLOTracker measures relay latencies against the operator's slos per api category,
+// exposes the burn rate as metrics and calls a webhook when the burn rate passes the alert threshold
+type LatencySLOTracker struct {
+	lock       sync.Mutex
+	slos       map[string]LatencySLO
+	states     map[string]*sloState // key is chainID + apiInterface + category
+	webhookURL string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func LatencySLOCategory(category *spectypes.SpecCategory) string {
+	switch {
+	case category == nil:
+		return LatencySLOCategoryDefault
+	case category.HangingApi:
+		return LatencySLOCategoryHanging
+	case category.Subscription:
+		return LatencySLOCategorySubscription
+	case category.Stateful != 0:
+		return LatencySLOCategoryStateful
+	case category.Local:
+		return LatencySLOCategoryLocal
+	case category.Deterministic:
+		return LatencySLOCategoryDeterministic
+	default:
+		return LatencySLOCategoryDefault
+	}
+}
+
+// RecordRelay is safe to call on a nil tracker, in that case slos are not configured
+func (lst *LatencySLOTracker) RecordRelay(chainID string, apiInterface string, category *spectypes.SpecCategory, latency time.Duration, success bool) {
+	if lst == nil {
+		return
+	}
+	categoryName := LatencySLOCategory(category)
+	slo, ok := lst.slos[categoryName]
+	if !ok {
+		slo, ok = lst.slos[LatencySLOCategoryDefault]
+		if !ok {
+			return
+		}
+		categoryName = LatencySLOCategoryDefault
+	}
+	violation := !success || latency > slo.Threshold
+	sloRelaysCounter.WithLabelValues(chainID, apiInterface, categoryName).Inc()
+	if violation {
+		sloViolationsCounter.WithLabelValues(chainID, apiInterface, categoryName).Inc()
+	}
+
+	lst.lock.Lock()
+	defer lst.lock.Unlock()
+	now := lst.now()
+	key := chainID + apiInterface + categoryName
+	state, ok := lst.states[key]
+	if !ok {
+		state = &sloState{slo: slo}
+		lst.states[key] = state
+	}
+	state.add(now, violation)
+	burnRate, relays, violations := state.burnRate()
+	sloBurnRateGauge.WithLabelValues(chainID, apiInterface, categoryName).Set(burnRate)
+	// alert at most once per window, so a sustained violation doesn't flood the webhook
+	if burnRate >= slo.AlertBurnRate && now.Sub(state.lastAlert) >= slo.Window {
+		state.lastAlert = now
+		alert := LatencySLOAlert{
+			ChainID:      chainID,
+			ApiInterface: apiInterface,
+			Category:     categoryName,
+			Threshold:    slo.Threshold,
+			Objective:    slo.Objective,
+			BurnRate:     burnRate,
+			Relays:       relays,
+			Violations:   violations,
+			Time:         now,
+		}
+		utils.LavaFormatWarning("latency slo violated", nil, utils.Attribute{Key: "alert", Value: alert})
+		go lst.sendAlert(alert)
+	}
+}
+
+func (lst *LatencySLOTracker) sendAlert(alert LatencySLOAlert) {
+	if lst.webhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		utils.LavaFormatError("failed marshaling latency slo alert", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), LatencySLOWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lst.webhookURL, bytes.NewBuffer(body))
+	if err != nil {
+		utils.LavaFormatError("failed creating latency slo webhook request", err, utils.Attribute{Key: "url", Value: lst.webhookURL})
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := lst.httpClient.Do(req)
+	if err != nil {
+		utils.LavaFormatWarning("failed sending latency slo webhook", err, utils.Attribute{Key: "url", Value: lst.webhookURL})
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		utils.LavaFormatWarning("latency slo webhook returned an error status", nil, utils.Attribute{Key: "url", Value: lst.webhookURL}, utils.Attribute{Key: "status", Value: resp.StatusCode})
+	}
+}
+
+func NewLatencySLOTracker(slos []LatencySLO, webhookURL string) (*LatencySLOTracker, error) {
+	lst := &LatencySLOTracker{
+		slos:       map[string]LatencySLO{},
+		states:     map[string]*sloState{},
+		webhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: LatencySLOWebhookTimeout},
+		now:        time.Now,
+	}
+	for _, slo := range slos {
+		err := slo.validate()
+		if err != nil {
+			return nil, err
+		}
+		lst.slos[slo.Category] = slo
+	}
+	return lst, nil
+}
+
+// ParseLatencySLOs reads the slo definitions from the provider config, returns a nil tracker if none are defined
+func ParseLatencySLOs(viperConfig *viper.Viper) (*LatencySLOTracker, error) {
+	var slos []LatencySLO
+	err := viperConfig.UnmarshalKey(LatencySLOsConfigName, &slos)
+	if err != nil {
+		return nil, utils.LavaFormatError("could not unmarshal latency slos", err)
+	}
+	if len(slos) == 0 {
+		return nil, nil
+	}
+	return NewLatencySLOTracker(slos, viperConfig.GetString(LatencySLOWebhookConfigName))
+}
@@ -0,0 +1,187 @@
+package rpcprovider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLatencySLOTracker(t *testing.T, webhookURL string, slos ...LatencySLO) (*LatencySLOTracker, *time.Time) {
+	tracker, err := NewLatencySLOTracker(slos, webhookURL)
+	require.NoError(t, err)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
+
+func TestLatencySLOValidate(t *testing.T) {
+	slo := LatencySLO{Threshold: time.Second, Objective: 0.99}
+	require.NoError(t, slo.validate())
+	require.Equal(t, LatencySLO{Category: LatencySLOCategoryDefault, Threshold: time.Second, Objective: 0.99, Window: DefaultLatencySLOWindow, AlertBurnRate: DefaultLatencySLOAlertBurnRate}, slo)
+
+	for _, invalid := range []LatencySLO{{Objective: 0.99}, {Threshold: time.Second}, {Threshold: time.Second, Objective: 1}} {
+		require.Error(t, invalid.validate())
+	}
+	_, err := NewLatencySLOTracker([]LatencySLO{{Threshold: time.Second}}, "")
+	require.Error(t, err)
+}
+
+func TestLatencySLOCategory(t *testing.T) {
+	require.Equal(t, LatencySLOCategoryDefault, LatencySLOCategory(nil))
+	require.Equal(t, LatencySLOCategoryHanging, LatencySLOCategory(&spectypes.SpecCategory{HangingApi: true, Deterministic: true}))
+	require.Equal(t, LatencySLOCategorySubscription, LatencySLOCategory(&spectypes.SpecCategory{Subscription: true}))
+	require.Equal(t, LatencySLOCategoryStateful, LatencySLOCategory(&spectypes.SpecCategory{Stateful: 1, Deterministic: true}))
+	require.Equal(t, LatencySLOCategoryLocal, LatencySLOCategory(&spectypes.SpecCategory{Local: true}))
+	require.Equal(t, LatencySLOCategoryDeterministic, LatencySLOCategory(&spectypes.SpecCategory{Deterministic: true}))
+	require.Equal(t, LatencySLOCategoryDefault, LatencySLOCategory(&spectypes.SpecCategory{}))
+}
+
+func TestLatencySLOBucketing(t *testing.T) {
+	state := &sloState{slo: LatencySLO{Threshold: time.Second, Objective: 0.9, Window: 10 * time.Second}}
+	now := time.Now()
+	// relays within a bucket duration share a bucket
+	state.add(now, false)
+	state.add(now.Add(500*time.Millisecond), true)
+	require.Len(t, state.buckets, 1)
+	state.add(now.Add(time.Second), false)
+	require.Len(t, state.buckets, 2)
+	burnRate, relays, violations := state.burnRate()
+	require.Equal(t, uint64(3), relays)
+	require.Equal(t, uint64(1), violations)
+	require.InDelta(t, (1.0/3)/0.1, burnRate, 1e-9)
+
+	// buckets that left the window are dropped, with them their violation
+	state.add(now.Add(10*time.Second+time.Millisecond), false)
+	require.Len(t, state.buckets, 2)
+	burnRate, relays, violations = state.burnRate()
+	require.Equal(t, uint64(2), relays)
+	require.Zero(t, violations)
+	require.Zero(t, burnRate)
+
+	burnRate, relays, _ = (&sloState{slo: state.slo}).burnRate()
+	require.Zero(t, burnRate)
+	require.Zero(t, relays)
+}
+
+func TestLatencySLORecordRelay(t *testing.T) {
+	tracker, now := newTestLatencySLOTracker(t, "",
+		LatencySLO{Category: LatencySLOCategoryDeterministic, Threshold: 100 * time.Millisecond, Objective: 0.5, AlertBurnRate: 10},
+		LatencySLO{Threshold: time.Second, Objective: 0.5, AlertBurnRate: 10},
+	)
+	deterministic := &spectypes.SpecCategory{Deterministic: true}
+	relays := testutil.ToFloat64(sloRelaysCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDeterministic))
+	violations := testutil.ToFloat64(sloViolationsCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDeterministic))
+	defaultRelays := testutil.ToFloat64(sloRelaysCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDefault))
+	defaultViolations := testutil.ToFloat64(sloViolationsCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDefault))
+	tracker.RecordRelay("SLO1", "jsonrpc", deterministic, 50*time.Millisecond, true)
+	// slow and failed relays are violations
+	tracker.RecordRelay("SLO1", "jsonrpc", deterministic, 200*time.Millisecond, true)
+	tracker.RecordRelay("SLO1", "jsonrpc", deterministic, 50*time.Millisecond, false)
+	require.Equal(t, relays+3, testutil.ToFloat64(sloRelaysCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDeterministic)))
+	require.Equal(t, violations+2, testutil.ToFloat64(sloViolationsCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDeterministic)))
+	require.InDelta(t, (2.0/3)/0.5, testutil.ToFloat64(sloBurnRateGauge.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDeterministic)), 1e-9)
+
+	// a category without its own slo is measured against the default slo
+	tracker.RecordRelay("SLO1", "jsonrpc", &spectypes.SpecCategory{Local: true}, 200*time.Millisecond, true)
+	require.Equal(t, defaultRelays+1, testutil.ToFloat64(sloRelaysCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDefault)))
+	require.Equal(t, defaultViolations, testutil.ToFloat64(sloViolationsCounter.WithLabelValues("SLO1", "jsonrpc", LatencySLOCategoryDefault)))
+
+	// states are kept per chain and interface
+	*now = now.Add(time.Second)
+	tracker.RecordRelay("SLO1", "rest", deterministic, 50*time.Millisecond, true)
+	require.Zero(t, testutil.ToFloat64(sloBurnRateGauge.WithLabelValues("SLO1", "rest", LatencySLOCategoryDeterministic)))
+	require.Len(t, tracker.states, 3)
+
+	// without a matching or default slo nothing is measured, a nil tracker is a noop
+	onlyDeterministic, _ := newTestLatencySLOTracker(t, "", LatencySLO{Category: LatencySLOCategoryDeterministic, Threshold: time.Second, Objective: 0.5})
+	onlyDeterministic.RecordRelay("SLO2", "jsonrpc", nil, time.Minute, false)
+	require.Empty(t, onlyDeterministic.states)
+	var nilTracker *LatencySLOTracker
+	nilTracker.RecordRelay("SLO2", "jsonrpc", nil, time.Minute, false)
+}
+
+func TestLatencySLOBreachAlerts(t *testing.T) {
+	alerts := make(chan LatencySLOAlert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := LatencySLOAlert{}
+		if json.NewDecoder(r.Body).Decode(&alert) == nil {
+			alerts <- alert
+		}
+	}))
+	defer server.Close()
+	tracker, now := newTestLatencySLOTracker(t, server.URL, LatencySLO{Threshold: 100 * time.Millisecond, Objective: 0.9, Window: 10 * time.Second, AlertBurnRate: 2})
+
+	// 1 violation in 10 relays is exactly on budget
+	for i := 0; i < 9; i++ {
+		tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Millisecond, true)
+	}
+	tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Second, true)
+	// a burn rate of 2/11 / 0.1 is below the alert burn rate, 3/12 / 0.1 alerts
+	tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Second, true)
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert %+v", alert)
+	case <-time.After(100 * time.Millisecond):
+	}
+	tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Second, true)
+	select {
+	case alert := <-alerts:
+		require.Equal(t, "SLO3", alert.ChainID)
+		require.Equal(t, "jsonrpc", alert.ApiInterface)
+		require.Equal(t, LatencySLOCategoryDefault, alert.Category)
+		require.Equal(t, uint64(12), alert.Relays)
+		require.Equal(t, uint64(3), alert.Violations)
+		require.InDelta(t, (3.0/12)/0.1, alert.BurnRate, 1e-9)
+		require.Equal(t, 100*time.Millisecond, alert.Threshold)
+	case <-time.After(5 * time.Second):
+		t.Fatal("slo breach wasn't reported")
+	}
+
+	// a sustained breach alerts once per window
+	tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Second, true)
+	*now = now.Add(5 * time.Second)
+	tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Second, true)
+	*now = now.Add(5 * time.Second)
+	tracker.RecordRelay("SLO3", "jsonrpc", nil, time.Second, true)
+	select {
+	case alert := <-alerts:
+		require.Equal(t, now.Unix(), alert.Time.Unix())
+	case <-time.After(5 * time.Second):
+		t.Fatal("slo breach wasn't reported after the window")
+	}
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert %+v", alert)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestParseLatencySLOs(t *testing.T) {
+	viperConfig := viper.New()
+	viperConfig.SetConfigType("yml")
+	require.NoError(t, viperConfig.ReadConfig(strings.NewReader("")))
+	tracker, err := ParseLatencySLOs(viperConfig)
+	require.NoError(t, err)
+	require.Nil(t, tracker)
+
+	require.NoError(t, viperConfig.ReadConfig(strings.NewReader(`
+latency-slos:
+  - category: deterministic
+    threshold: 500ms
+    objective: 0.99
+    window: 1m
+latency-slo-webhook: http://localhost/alerts
+`)))
+	tracker, err = ParseLatencySLOs(viperConfig)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost/alerts", tracker.webhookURL)
+	require.Equal(t, LatencySLO{Category: LatencySLOCategoryDeterministic, Threshold: 500 * time.Millisecond, Objective: 0.99, Window: time.Minute, AlertBurnRate: DefaultLatencySLOAlertBurnRate}, tracker.slos[LatencySLOCategoryDeterministic])
+}
@@ -0,0 +1,185 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	btcSecp256k1 "github.com/btcsuite/btcd/btcec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/version"
+	"github.com/lavanet/lava/protocol/chainlib"
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/sigs"
+)
+
+const (
+	ManifestPath          = "/lava/manifest"
+	manifestCacheTime     = 10 * time.Second // the manifest is re-signed at most this often, crawlers can't make the provider sign on every request
+	manifestFetchTimeout  = 5 * time.Second
+	manifestMaxReplyBytes = 1024 * 1024
+)
+
+// ProviderManifest describes what the provider serves, it is returned on ManifestPath signed with the provider key
+type ProviderManifest struct {
+	Provider    string             `json:"provider"`
+	LavaChainID string             `json:"lavaChainID"`
+	Version     string             `json:"version"`
+	Time        time.Time          `json:"time"`
+	Endpoints   []ManifestEndpoint `json:"endpoints"`
+}
+
+type ManifestEndpoint struct {
+	ChainID         string                    `json:"chainID"`
+	ApiInterface    string                    `json:"apiInterface"`
+	Geolocation     uint64                    `json:"geolocation"`
+	Lagging         bool                      `json:"lagging"`
+	Health          chaintracker.HealthStatus `json:"health"`
+	NodeModules     []string                  `json:"nodeModules,omitempty"`     // the namespaces the node reported, if it was probed
+	UnsupportedApis []string                  `json:"unsupportedApis,omitempty"` // apis the node doesn't have, relays for them are rejected
+}
+
+// SignedProviderManifest keeps the manifest as signed, so verifying doesn't depend on how it is re-encoded
+type SignedProviderManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature []byte          `json:"signature"`
+}
+
+// ManifestChainTrackerInf is the chain tracker of a served endpoint, used for the health summary
+type ManifestChainTrackerInf interface {
+	GetHealthStatus() chaintracker.HealthStatus
+	IsLagging() bool
+}
+
+type manifestEndpoint struct {
+	endpoint     *lavasession.RPCProviderEndpoint
+	chainTracker ManifestChainTrackerInf
+}
+
+// ProviderManifestServer builds and signs the manifest of all the endpoints the provider finished setting up
+type ProviderManifestServer struct {
+	privKey            *btcSecp256k1.PrivateKey
+	provider           string
+	lavaChainID        string
+	methodAvailability *chainlib.MethodAvailabilityCache // optional
+	lock               sync.Mutex
+	endpoints          []manifestEndpoint
+	cached             []byte
+	cachedTime         time.Time
+}
+
+func NewProviderManifestServer(privKey *btcSecp256k1.PrivateKey, provider sdk.AccAddress, lavaChainID string, methodAvailability *chainlib.MethodAvailabilityCache) *ProviderManifestServer {
+	return &ProviderManifestServer{privKey: privKey, provider: provider.String(), lavaChainID: lavaChainID, methodAvailability: methodAvailability}
+}
+
+func (pms *ProviderManifestServer) RegisterEndpoint(endpoint *lavasession.RPCProviderEndpoint, chainTracker ManifestChainTrackerInf) {
+	if pms == nil {
+		return
+	}
+	pms.lock.Lock()
+	defer pms.lock.Unlock()
+	pms.endpoints = append(pms.endpoints, manifestEndpoint{endpoint: endpoint, chainTracker: chainTracker})
+	pms.cached = nil
+}
+
+func (pms *ProviderManifestServer) signedManifest() ([]byte, error) {
+	pms.lock.Lock()
+	defer pms.lock.Unlock()
+	if pms.cached != nil && time.Since(pms.cachedTime) < manifestCacheTime {
+		return pms.cached, nil
+	}
+	manifest := ProviderManifest{Provider: pms.provider, LavaChainID: pms.lavaChainID, Version: version.Version, Time: time.Now().UTC(), Endpoints: []ManifestEndpoint{}}
+	for _, registered := range pms.endpoints {
+		nodeModules, unsupported := pms.methodAvailability.GetAvailability(registered.endpoint.UrlsString())
+		manifest.Endpoints = append(manifest.Endpoints, ManifestEndpoint{
+			ChainID:         registered.endpoint.ChainID,
+			ApiInterface:    registered.endpoint.ApiInterface,
+			Geolocation:     registered.endpoint.Geolocation,
+			Lagging:         registered.chainTracker.IsLagging(),
+			Health:          registered.chainTracker.GetHealthStatus(),
+			NodeModules:     nodeModules,
+			UnsupportedApis: unsupported,
+		})
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sigs.SignProviderManifest(pms.privKey, manifestBytes)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := json.Marshal(SignedProviderManifest{Manifest: manifestBytes, Signature: sig})
+	if err != nil {
+		return nil, err
+	}
+	pms.cached = signed
+	pms.cachedTime = time.Now()
+	return signed, nil
+}
+
+func (pms *ProviderManifestServer) manifestHandler(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	signed, err := pms.signedManifest()
+	if err != nil {
+		utils.LavaFormatError("failed creating provider manifest", err)
+		resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(signed)
+}
+
+// VerifyProviderManifest checks the manifest was signed by the provider it names
+func VerifyProviderManifest(signed SignedProviderManifest) (*ProviderManifest, error) {
+	manifest := &ProviderManifest{}
+	err := json.Unmarshal(signed.Manifest, manifest)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed parsing provider manifest", err)
+	}
+	pubKey, err := sigs.RecoverPubKeyFromProviderManifest(signed.Manifest, signed.Signature)
+	if err != nil {
+		return nil, err
+	}
+	signer := sdk.AccAddress(pubKey.Address())
+	if signer.String() != manifest.Provider {
+		return nil, utils.LavaFormatWarning("provider manifest signer mismatch", nil, utils.Attribute{Key: "signer", Value: signer.String()}, utils.Attribute{Key: "provider", Value: manifest.Provider})
+	}
+	return manifest, nil
+}
+
+// FetchProviderManifest gets the manifest from a provider's network address (scheme://host:port) and verifies its signature
+func FetchProviderManifest(ctx context.Context, providerURL string) (*ProviderManifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, manifestFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, providerURL+ManifestPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, utils.LavaFormatWarning("provider manifest request failed", nil, utils.Attribute{Key: "url", Value: providerURL}, utils.Attribute{Key: "status", Value: resp.StatusCode})
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, manifestMaxReplyBytes))
+	if err != nil {
+		return nil, err
+	}
+	signed := SignedProviderManifest{}
+	err = json.Unmarshal(body, &signed)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed parsing signed provider manifest", err, utils.Attribute{Key: "url", Value: providerURL})
+	}
+	return VerifyProviderManifest(signed)
+}
@@ -0,0 +1,126 @@
+package rpcprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils/sigs"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeManifestChainTracker struct {
+	lagging bool
+	health  chaintracker.HealthStatus
+}
+
+func (fmct *fakeManifestChainTracker) GetHealthStatus() chaintracker.HealthStatus {
+	return fmct.health
+}
+
+func (fmct *fakeManifestChainTracker) IsLagging() bool {
+	return fmct.lagging
+}
+
+func newTestManifestServer() (*ProviderManifestServer, string) {
+	privKey, provider := sigs.GenerateFloatingKey()
+	pms := NewProviderManifestServer(privKey, provider, "lava-testnet", nil)
+	pms.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "ETH1", ApiInterface: "jsonrpc", Geolocation: 1}, &fakeManifestChainTracker{health: chaintracker.HealthStatus{Healthy: true, Ready: true, LatestBlock: 100}})
+	pms.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "LAV1", ApiInterface: "rest", Geolocation: 2}, &fakeManifestChainTracker{lagging: true})
+	return pms, provider.String()
+}
+
+func readSignedManifest(t *testing.T, pms *ProviderManifestServer) SignedProviderManifest {
+	signedBytes, err := pms.signedManifest()
+	require.NoError(t, err)
+	signed := SignedProviderManifest{}
+	require.NoError(t, json.Unmarshal(signedBytes, &signed))
+	return signed
+}
+
+func TestProviderManifestSignAndVerify(t *testing.T) {
+	pms, provider := newTestManifestServer()
+	manifest, err := VerifyProviderManifest(readSignedManifest(t, pms))
+	require.NoError(t, err)
+	require.Equal(t, provider, manifest.Provider)
+	require.Equal(t, "lava-testnet", manifest.LavaChainID)
+	require.Equal(t, []ManifestEndpoint{
+		{ChainID: "ETH1", ApiInterface: "jsonrpc", Geolocation: 1, Health: chaintracker.HealthStatus{Healthy: true, Ready: true, LatestBlock: 100}},
+		{ChainID: "LAV1", ApiInterface: "rest", Geolocation: 2, Lagging: true},
+	}, manifest.Endpoints)
+}
+
+func TestProviderManifestVerifyRejectsTampering(t *testing.T) {
+	pms, _ := newTestManifestServer()
+	signed := readSignedManifest(t, pms)
+	// a modified manifest recovers a different signer
+	tampered := signed
+	tampered.Manifest = bytes.Replace(signed.Manifest, []byte(`"lagging":true`), []byte(`"lagging":false`), 1)
+	require.NotEqual(t, signed.Manifest, tampered.Manifest)
+	_, err := VerifyProviderManifest(tampered)
+	require.Error(t, err)
+
+	// a manifest claiming another provider
+	otherPms, _ := newTestManifestServer()
+	manifest := ProviderManifest{}
+	require.NoError(t, json.Unmarshal(readSignedManifest(t, otherPms).Manifest, &manifest))
+	_, otherProvider := sigs.GenerateFloatingKey()
+	manifest.Provider = otherProvider.String()
+	manifestBytes, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	sig, err := sigs.SignProviderManifest(otherPms.privKey, manifestBytes)
+	require.NoError(t, err)
+	_, err = VerifyProviderManifest(SignedProviderManifest{Manifest: manifestBytes, Signature: sig})
+	require.Error(t, err)
+
+	_, err = VerifyProviderManifest(SignedProviderManifest{Manifest: []byte("manifest"), Signature: signed.Signature})
+	require.Error(t, err)
+}
+
+func TestProviderManifestCache(t *testing.T) {
+	pms, _ := newTestManifestServer()
+	first, err := pms.signedManifest()
+	require.NoError(t, err)
+	second, err := pms.signedManifest()
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	// a new endpoint is published right away
+	pms.RegisterEndpoint(&lavasession.RPCProviderEndpoint{ChainID: "COS3", ApiInterface: "grpc"}, &fakeManifestChainTracker{})
+	manifest, err := VerifyProviderManifest(readSignedManifest(t, pms))
+	require.NoError(t, err)
+	require.Len(t, manifest.Endpoints, 3)
+
+	var nilServer *ProviderManifestServer
+	nilServer.RegisterEndpoint(&lavasession.RPCProviderEndpoint{}, &fakeManifestChainTracker{})
+}
+
+func TestFetchProviderManifest(t *testing.T) {
+	pms, provider := newTestManifestServer()
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != ManifestPath {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		pms.manifestHandler(resp, req)
+	}))
+	defer server.Close()
+
+	manifest, err := FetchProviderManifest(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, provider, manifest.Provider)
+	require.Len(t, manifest.Endpoints, 2)
+
+	resp, err := http.Post(server.URL+ManifestPath, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	_, err = FetchProviderManifest(context.Background(), server.URL+"/other")
+	require.Error(t, err)
+}
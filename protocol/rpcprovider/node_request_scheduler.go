@@ -0,0 +1,195 @@
+package rpcprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type NodeRequestPriority int
+
+const (
+	NodeRequestPriorityLatest  NodeRequestPriority = iota // requests for the latest state, stale answers are useless so they go first
+	NodeRequestPriorityDefault                            // historical and non block related requests
+	numberOfNodeRequestPriorities
+)
+
+const (
+	NodeMaxInFlightFlagName = "node-max-inflight"
+	DefaultNodeMaxInFlight  = 100
+	nodeLatencyDecay        = 0.1 // weight of a new latency sample in the moving average
+)
+
+var (
+	nodeInFlightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_node_requests_in_flight",
+		Help: "The number of requests currently sent to the node",
+	}, []string{"spec", "apiInterface"})
+	nodeQueueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_node_requests_queued",
+		Help: "The number of relays waiting for the node to free up",
+	}, []string{"spec", "apiInterface"})
+	nodeLatencyGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_node_latency_milliseconds",
+		Help: "Moving average of the node response latency",
+	}, []string{"spec", "apiInterface"})
+	nodeRejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_node_requests_rejected_total",
+		Help: "The number of relays rejected because the node was saturated until their deadline",
+	}, []string{"spec", "apiInterface"})
+)
+
+func init() {
+	prometheus.MustRegister(nodeInFlightGauge, nodeQueueDepthGauge, nodeLatencyGauge, nodeRejectedCounter)
+}
+
+type nodeRequestWaiter struct {
+	granted chan struct{}
+}
+
+// NodeRequestScheduler limits the concurrent requests sent to a node, when the node is saturated relays are queued
+// by priority, and relays that can't be served before their deadline according to the measured latency are rejected early
+type NodeRequestScheduler struct {
+	lock         sync.Mutex
+	maxInFlight  int
+	inFlight     int
+	queues       [numberOfNodeRequestPriorities][]*nodeRequestWaiter
+	queued       int
+	latency      time.Duration // moving average
+	chainID      string
+	apiInterface string
+}
+
+// estimated time until a newly queued request will be sent to the node, lock must be held
+func (nrs *NodeRequestScheduler) expectedWaitUnsafe(priority NodeRequestPriority) time.Duration {
+	ahead := 0
+	for p := NodeRequestPriority(0); p <= priority; p++ {
+		ahead += len(nrs.queues[p])
+	}
+	// every latency period maxInFlight requests are freed
+	rounds := ahead/nrs.maxInFlight + 1
+	return time.Duration(rounds) * nrs.latency
+}
+
+func (nrs *NodeRequestScheduler) updateMetricsUnsafe() {
+	nodeInFlightGauge.WithLabelValues(nrs.chainID, nrs.apiInterface).Set(float64(nrs.inFlight))
+	nodeQueueDepthGauge.WithLabelValues(nrs.chainID, nrs.apiInterface).Set(float64(nrs.queued))
+}
+
+// Acquire blocks until the request can be sent to the node, the returned release must be called once the node responded.
+// a nil scheduler doesn't limit anything
+func (nrs *NodeRequestScheduler) Acquire(ctx context.Context, priority NodeRequestPriority) (release func(), err error) {
+	if nrs == nil {
+		return func() {}, nil
+	}
+	if priority < 0 || priority >= numberOfNodeRequestPriorities {
+		priority = NodeRequestPriorityDefault
+	}
+	nrs.lock.Lock()
+	if nrs.inFlight < nrs.maxInFlight && nrs.queued == 0 {
+		nrs.inFlight++
+		nrs.updateMetricsUnsafe()
+		nrs.lock.Unlock()
+		return nrs.releaseFunc(), nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && nrs.latency > 0 {
+		if expectedWait := nrs.expectedWaitUnsafe(priority); time.Until(deadline) < expectedWait+nrs.latency {
+			nrs.lock.Unlock()
+			nodeRejectedCounter.WithLabelValues(nrs.chainID, nrs.apiInterface).Inc()
+			return nil, NodeSaturatedError.Wrapf("expected wait %s, node latency %s, in flight %d", expectedWait, nrs.latency, nrs.maxInFlight)
+		}
+	}
+	waiter := &nodeRequestWaiter{granted: make(chan struct{})}
+	nrs.queues[priority] = append(nrs.queues[priority], waiter)
+	nrs.queued++
+	nrs.updateMetricsUnsafe()
+	nrs.lock.Unlock()
+
+	select {
+	case <-waiter.granted:
+		return nrs.releaseFunc(), nil
+	case <-ctx.Done():
+		nrs.lock.Lock()
+		defer nrs.lock.Unlock()
+		select {
+		case <-waiter.granted:
+			// granted while we were cancelled, pass the slot on
+			nrs.releaseUnsafe()
+		default:
+			nrs.removeWaiterUnsafe(priority, waiter)
+		}
+		nrs.updateMetricsUnsafe()
+		nodeRejectedCounter.WithLabelValues(nrs.chainID, nrs.apiInterface).Inc()
+		return nil, NodeSaturatedError.Wrapf("context done while waiting for the node: %s", ctx.Err())
+	}
+}
+
+func (nrs *NodeRequestScheduler) releaseFunc() func() {
+	start := time.Now()
+	released := false
+	return func() {
+		nrs.lock.Lock()
+		defer nrs.lock.Unlock()
+		if released {
+			return
+		}
+		released = true
+		nrs.addLatencySampleUnsafe(time.Since(start))
+		nrs.releaseUnsafe()
+		nrs.updateMetricsUnsafe()
+	}
+}
+
+func (nrs *NodeRequestScheduler) addLatencySampleUnsafe(latency time.Duration) {
+	if nrs.latency == 0 {
+		nrs.latency = latency
+	} else {
+		nrs.latency = time.Duration((1-nodeLatencyDecay)*float64(nrs.latency) + nodeLatencyDecay*float64(latency))
+	}
+	nodeLatencyGauge.WithLabelValues(nrs.chainID, nrs.apiInterface).Set(float64(nrs.latency.Milliseconds()))
+}
+
+// frees a slot and hands it to the highest priority waiter, lock must be held
+func (nrs *NodeRequestScheduler) releaseUnsafe() {
+	nrs.inFlight--
+	for priority := range nrs.queues {
+		if len(nrs.queues[priority]) == 0 {
+			continue
+		}
+		waiter := nrs.queues[priority][0]
+		nrs.queues[priority] = nrs.queues[priority][1:]
+		nrs.queued--
+		nrs.inFlight++
+		close(waiter.granted)
+		return
+	}
+}
+
+func (nrs *NodeRequestScheduler) removeWaiterUnsafe(priority NodeRequestPriority, waiter *nodeRequestWaiter) {
+	queue := nrs.queues[priority]
+	for idx, queuedWaiter := range queue {
+		if queuedWaiter == waiter {
+			nrs.queues[priority] = append(queue[:idx], queue[idx+1:]...)
+			nrs.queued--
+			return
+		}
+	}
+}
+
+func (nrs *NodeRequestScheduler) Stats() (inFlight int, queued int, latency time.Duration) {
+	nrs.lock.Lock()
+	defer nrs.lock.Unlock()
+	return nrs.inFlight, nrs.queued, nrs.latency
+}
+
+// NewNodeRequestScheduler returns nil if maxInFlight is 0, meaning node requests are not limited
+func NewNodeRequestScheduler(chainID string, apiInterface string, maxInFlight uint) *NodeRequestScheduler {
+	if maxInFlight == 0 {
+		return nil
+	}
+	utils.LavaFormatDebug("node request scheduler enabled", utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: apiInterface}, utils.Attribute{Key: "maxInFlight", Value: maxInFlight})
+	return &NodeRequestScheduler{maxInFlight: int(maxInFlight), chainID: chainID, apiInterface: apiInterface}
+}
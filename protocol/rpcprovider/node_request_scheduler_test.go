@@ -0,0 +1,142 @@
+package rpcprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// acquireAsync queues a request and reports the order it was granted in on granted
+func acquireAsync(ctx context.Context, nrs *NodeRequestScheduler, priority NodeRequestPriority, name string, granted chan string, errs chan error) {
+	go func() {
+		release, err := nrs.Acquire(ctx, priority)
+		if err != nil {
+			errs <- err
+			return
+		}
+		granted <- name
+		release()
+	}()
+}
+
+func waitForQueued(t *testing.T, nrs *NodeRequestScheduler, queued int) {
+	require.Eventually(t, func() bool {
+		_, currentlyQueued, _ := nrs.Stats()
+		return currentlyQueued == queued
+	}, time.Second, time.Millisecond)
+}
+
+func TestNodeRequestSchedulerDisabled(t *testing.T) {
+	nrs := NewNodeRequestScheduler("SCH1", "jsonrpc", 0)
+	require.Nil(t, nrs)
+	release, err := nrs.Acquire(context.Background(), NodeRequestPriorityDefault)
+	require.NoError(t, err)
+	release()
+}
+
+func TestNodeRequestSchedulerLimitsInFlight(t *testing.T) {
+	nrs := NewNodeRequestScheduler("SCH1", "jsonrpc", 2)
+	first, err := nrs.Acquire(context.Background(), NodeRequestPriorityDefault)
+	require.NoError(t, err)
+	second, err := nrs.Acquire(context.Background(), NodeRequestPriorityDefault)
+	require.NoError(t, err)
+	inFlight, queued, latency := nrs.Stats()
+	require.Equal(t, 2, inFlight)
+	require.Zero(t, queued)
+	require.Zero(t, latency)
+
+	granted := make(chan string, 1)
+	errs := make(chan error, 1)
+	acquireAsync(context.Background(), nrs, NodeRequestPriorityDefault, "third", granted, errs)
+	waitForQueued(t, nrs, 1)
+	// releasing twice frees a single slot
+	first()
+	first()
+	require.Equal(t, "third", <-granted)
+	second()
+	require.Eventually(t, func() bool {
+		inFlight, _, _ := nrs.Stats()
+		return inFlight == 0
+	}, time.Second, time.Millisecond)
+	_, _, latency = nrs.Stats()
+	require.Positive(t, latency)
+}
+
+func TestNodeRequestSchedulerPriorities(t *testing.T) {
+	nrs := NewNodeRequestScheduler("SCH1", "jsonrpc", 1)
+	release, err := nrs.Acquire(context.Background(), NodeRequestPriorityDefault)
+	require.NoError(t, err)
+
+	granted := make(chan string, 3)
+	errs := make(chan error, 3)
+	acquireAsync(context.Background(), nrs, NodeRequestPriorityDefault, "historical", granted, errs)
+	waitForQueued(t, nrs, 1)
+	acquireAsync(context.Background(), nrs, NodeRequestPriorityLatest, "latest", granted, errs)
+	waitForQueued(t, nrs, 2)
+	// an invalid priority is queued as default, behind the earlier default request
+	acquireAsync(context.Background(), nrs, numberOfNodeRequestPriorities, "invalid", granted, errs)
+	waitForQueued(t, nrs, 3)
+
+	release()
+	require.Equal(t, "latest", <-granted)
+	require.Equal(t, "historical", <-granted)
+	require.Equal(t, "invalid", <-granted)
+	require.Empty(t, errs)
+}
+
+func TestNodeRequestSchedulerRejectsBeforeDeadline(t *testing.T) {
+	nrs := NewNodeRequestScheduler("SCH1", "jsonrpc", 1)
+	release, err := nrs.Acquire(context.Background(), NodeRequestPriorityDefault)
+	require.NoError(t, err)
+	nrs.lock.Lock()
+	nrs.latency = time.Second
+	nrs.lock.Unlock()
+
+	// the node can't answer before the deadline, the relay is rejected without queueing
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = nrs.Acquire(ctx, NodeRequestPriorityLatest)
+	require.ErrorIs(t, err, NodeSaturatedError)
+	_, queued, _ := nrs.Stats()
+	require.Zero(t, queued)
+
+	// a deadline allowing for the wait and the node's latency is queued
+	granted := make(chan string, 1)
+	errs := make(chan error, 1)
+	acquireAsync(context.Background(), nrs, NodeRequestPriorityDefault, "no deadline", granted, errs)
+	waitForQueued(t, nrs, 1)
+	longCtx, longCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer longCancel()
+	acquireAsync(longCtx, nrs, NodeRequestPriorityDefault, "long deadline", granted, errs)
+	waitForQueued(t, nrs, 2)
+	release()
+	require.Equal(t, "no deadline", <-granted)
+	require.Equal(t, "long deadline", <-granted)
+}
+
+func TestNodeRequestSchedulerCancelledWaiter(t *testing.T) {
+	nrs := NewNodeRequestScheduler("SCH1", "jsonrpc", 1)
+	release, err := nrs.Acquire(context.Background(), NodeRequestPriorityDefault)
+	require.NoError(t, err)
+
+	granted := make(chan string, 2)
+	errs := make(chan error, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	acquireAsync(ctx, nrs, NodeRequestPriorityLatest, "cancelled", granted, errs)
+	waitForQueued(t, nrs, 1)
+	acquireAsync(context.Background(), nrs, NodeRequestPriorityDefault, "waiting", granted, errs)
+	waitForQueued(t, nrs, 2)
+
+	// a cancelled waiter leaves the queue and doesn't hold a slot
+	cancel()
+	require.ErrorIs(t, <-errs, NodeSaturatedError)
+	waitForQueued(t, nrs, 1)
+	release()
+	require.Equal(t, "waiting", <-granted)
+	require.Eventually(t, func() bool {
+		inFlight, queued, _ := nrs.Stats()
+		return inFlight == 0 && queued == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,80 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+
+	"github.com/lavanet/lava/protocol/lavaprotocol"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	payloadCiphersCacheMaxCost     = 10 * 1024 // sessions
+	payloadCiphersCacheNumCounters = 100000
+	payloadCipherTTL               = time.Hour // sessions don't outlive an epoch
+)
+
+func newPayloadCiphersCache() *ristretto.Cache {
+	cache, err := ristretto.NewCache(&ristretto.Config{NumCounters: payloadCiphersCacheNumCounters, MaxCost: payloadCiphersCacheMaxCost, BufferItems: 64})
+	if err != nil {
+		utils.LavaFormatError("failed creating payload ciphers cache, session keys are derived on every encrypted relay", err)
+		return nil
+	}
+	return cache
+}
+
+// deriving the session key is an ecdh, so it is done once per consumer session and not on every relay
+func (rpcps *RPCProviderServer) getPayloadCipher(ephemeralPubKey []byte) (*lavaprotocol.PayloadCipher, error) {
+	if rpcps.payloadCiphers != nil {
+		if cached, found := rpcps.payloadCiphers.Get(string(ephemeralPubKey)); found {
+			if payloadCipher, ok := cached.(*lavaprotocol.PayloadCipher); ok {
+				return payloadCipher, nil
+			}
+		}
+	}
+	payloadCipher, err := rpcps.getPayloadCipher(ephemeralPubKey)
+	if err != nil {
+		return nil, err
+	}
+	if rpcps.payloadCiphers != nil {
+		rpcps.payloadCiphers.SetWithTTL(string(ephemeralPubKey), payloadCipher, 1, payloadCipherTTL)
+	}
+	return payloadCipher, nil
+}
+
+// decrypts the request in place when the consumer encrypted it, the returned cipher is nil for plaintext relays
+func (rpcps *RPCProviderServer) decryptRelayRequest(ctx context.Context, request *pairingtypes.RelayRequest) (*lavaprotocol.PayloadCipher, error) {
+	ephemeralPubKey, encrypted, err := lavaprotocol.GetPayloadEncryptionEphemeral(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		if rpcps.rpcProviderEndpoint.RequireEncryptedPayloads {
+			return nil, utils.LavaFormatWarning("refusing a plaintext relay, the endpoint requires encrypted payloads", lavaprotocol.PayloadEncryptionError, utils.Attribute{Key: "GUID", Value: ctx})
+		}
+		return nil, nil
+	}
+	payloadCipher, err := rpcps.getPayloadCipher(ephemeralPubKey)
+	if err != nil {
+		return nil, err
+	}
+	err = payloadCipher.DecryptRelayRequest(request)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed decrypting relay payload", err, utils.Attribute{Key: "GUID", Value: ctx})
+	}
+	return payloadCipher, nil
+}
+
+// returns the provider's public key to consumers that probe with a payload encryption handshake
+func setPayloadEncryptionHandshakeHeader(ctx context.Context, payloadPubKey []byte) error {
+	if payloadPubKey == nil || !lavaprotocol.IsPayloadEncryptionHandshake(ctx) {
+		return nil
+	}
+	return grpc.SetHeader(ctx, metadata.Pairs(lavaprotocol.PayloadEncryptionPubKeyHeader, hex.EncodeToString(payloadPubKey)))
+}
@@ -0,0 +1,87 @@
+package rpcprovider
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	PaymentReconciliationIntervalFlagName = "payment-reconciliation-interval"
+)
+
+type providerPaymentsQuery interface {
+	ProviderPaymentEvents(ctx context.Context, provider string, fromBlock int64, toBlock int64) ([]*rewardserver.PaymentRequest, error)
+	EarliestBlockInMemory(ctx context.Context) (uint64, error)
+	LatestBlock() int64
+}
+
+// startPaymentReconciliation diffs the payments the lava chain holds for the provider wallets against the reward server's claims and
+// payments every interval, over the blocks claims can still be paid in. a zero interval disables it
+func startPaymentReconciliation(ctx context.Context, interval time.Duration, query providerPaymentsQuery, rewardServer *rewardserver.RewardServer, wallets map[string]*providerWallet) {
+	if interval <= 0 {
+		return
+	}
+	providers := walletAddresses(wallets)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reconcileProviderPayments(ctx, query, rewardServer, providers)
+			}
+		}
+	}()
+}
+
+func reconcileProviderPayments(ctx context.Context, query providerPaymentsQuery, rewardServer *rewardserver.RewardServer, providers []string) {
+	earliestBlockInMemory, err := query.EarliestBlockInMemory(ctx)
+	if err != nil {
+		utils.LavaFormatWarning("failed reading the earliest block in memory, skipping the payment reconciliation", err)
+		return
+	}
+	fromBlock, toBlock := int64(earliestBlockInMemory), query.LatestBlock()
+	payments := []*rewardserver.PaymentRequest{}
+	for _, provider := range providers {
+		providerPayments, err := query.ProviderPaymentEvents(ctx, provider, fromBlock, toBlock)
+		if err != nil {
+			utils.LavaFormatWarning("failed querying the payment events of the provider, skipping the payment reconciliation", err, utils.Attribute{Key: "provider", Value: provider}, utils.Attribute{Key: "fromBlock", Value: fromBlock}, utils.Attribute{Key: "toBlock", Value: toBlock})
+			return
+		}
+		payments = append(payments, providerPayments...)
+	}
+	report, err := rewardServer.ReconcilePayments(payments, fromBlock, toBlock)
+	if err != nil {
+		utils.LavaFormatWarning("failed reconciling the provider payments", err)
+		return
+	}
+	attributes := []utils.Attribute{
+		{Key: "fromBlock", Value: fromBlock}, {Key: "toBlock", Value: toBlock}, {Key: "matched", Value: report.Matched},
+		{Key: "unpaid", Value: len(report.Unpaid)}, {Key: "doublePaid", Value: len(report.DoublePaid)}, {Key: "unknown", Value: len(report.Unknown)},
+	}
+	if len(report.Unpaid)+len(report.DoublePaid)+len(report.Unknown) == 0 {
+		utils.LavaFormatInfo("provider payments reconciled with the lava chain", attributes...)
+		return
+	}
+	utils.LavaFormatWarning("provider payments on the lava chain don't match the claims, the report is served on the rewards api", nil, attributes...)
+}
+
+// walletAddresses are the distinct provider addresses of the wallets, sorted
+func walletAddresses(wallets map[string]*providerWallet) []string {
+	unique := map[string]struct{}{}
+	for _, wallet := range wallets {
+		unique[wallet.address.String()] = struct{}{}
+	}
+	addresses := make([]string, 0, len(unique))
+	for address := range unique {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
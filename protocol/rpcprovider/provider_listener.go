@@ -22,6 +22,8 @@ type ProviderListener struct {
 	networkAddress string
 	relayServer    *relayServer
 	httpServer     http.Server
+	lock           sync.RWMutex
+	geolocations   []uint64 // the geolocations of the receivers on this listener, reported by the health path
 }
 
 func (pl *ProviderListener) Key() string {
@@ -38,10 +40,28 @@ func (pl *ProviderListener) RegisterReceiver(existingReceiver RelayReceiver, end
 		return utils.LavaFormatError("double_receiver_setup receiver already defined on this address with the same chainID and apiInterface", nil, utils.Attribute{Key: "chainID", Value: endpoint.ChainID}, utils.Attribute{Key: "apiInterface", Value: endpoint.ApiInterface})
 	}
 	pl.relayServer.relayReceivers[listen_endpoint.Key()] = existingReceiver
-	utils.LavaFormatInfo("Provider Listening on Address", utils.Attribute{Key: "chainID", Value: endpoint.ChainID}, utils.Attribute{Key: "apiInterface", Value: endpoint.ApiInterface}, utils.Attribute{Key: "Address", Value: endpoint.NetworkAddress})
+	pl.addGeolocation(endpoint.Geolocation)
+	utils.LavaFormatInfo("Provider Listening on Address", utils.Attribute{Key: "chainID", Value: endpoint.ChainID}, utils.Attribute{Key: "apiInterface", Value: endpoint.ApiInterface}, utils.Attribute{Key: "Address", Value: endpoint.NetworkAddress}, utils.Attribute{Key: "geolocation", Value: endpoint.Geolocation})
 	return nil
 }
 
+func (pl *ProviderListener) addGeolocation(geolocation uint64) {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+	for _, existing := range pl.geolocations {
+		if existing == geolocation {
+			return
+		}
+	}
+	pl.geolocations = append(pl.geolocations, geolocation)
+}
+
+func (pl *ProviderListener) getGeolocations() []uint64 {
+	pl.lock.RLock()
+	defer pl.lock.RUnlock()
+	return append([]uint64{}, pl.geolocations...)
+}
+
 func (pl *ProviderListener) Shutdown(shutdownCtx context.Context) error {
 	if err := pl.httpServer.Shutdown(shutdownCtx); err != nil {
 		utils.LavaFormatFatal("Provider failed to shutdown", err)
@@ -49,7 +69,7 @@ func (pl *ProviderListener) Shutdown(shutdownCtx context.Context) error {
 	return nil
 }
 
-func NewProviderListener(ctx context.Context, networkAddress string) *ProviderListener {
+func NewProviderListener(ctx context.Context, networkAddress string, manifestServer *ProviderManifestServer, geolocations *ProviderGeolocations, payloadPubKey []byte) *ProviderListener {
 	pl := &ProviderListener{networkAddress: networkAddress}
 
 	// GRPC
@@ -62,13 +82,21 @@ func NewProviderListener(ctx context.Context, networkAddress string) *ProviderLi
 		resp.Header().Set("Access-Control-Allow-Origin", "*")
 		resp.Header().Set("Access-Control-Allow-Headers", "Content-Type,x-grpc-web")
 
+		if manifestServer != nil && req.URL.Path == ManifestPath {
+			manifestServer.manifestHandler(resp, req)
+			return
+		}
+		if geolocations != nil && req.URL.Path == ProviderHealthPath {
+			geolocations.healthHandler(resp, req, pl.getGeolocations())
+			return
+		}
 		wrappedServer.ServeHTTP(resp, req)
 	}
 
 	pl.httpServer = http.Server{
 		Handler: h2c.NewHandler(http.HandlerFunc(handler), &http2.Server{}),
 	}
-	relayServer := &relayServer{relayReceivers: map[string]RelayReceiver{}}
+	relayServer := &relayServer{relayReceivers: map[string]RelayReceiver{}, payloadPubKey: payloadPubKey}
 	pl.relayServer = relayServer
 	pairingtypes.RegisterRelayerServer(grpcServer, relayServer)
 	go func() {
@@ -85,6 +113,7 @@ type relayServer struct {
 	pairingtypes.UnimplementedRelayerServer
 	relayReceivers map[string]RelayReceiver
 	lock           sync.RWMutex
+	payloadPubKey  []byte // returned on handshake probes for payload encryption
 }
 
 type RelayReceiver interface {
@@ -104,6 +133,10 @@ func (rs *relayServer) Relay(ctx context.Context, request *pairingtypes.RelayReq
 }
 
 func (rs *relayServer) Probe(ctx context.Context, probeReq *wrapperspb.UInt64Value) (*wrapperspb.UInt64Value, error) {
+	err := setPayloadEncryptionHandshakeHeader(ctx, rs.payloadPubKey)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed setting payload encryption handshake header", err)
+	}
 	return probeReq, nil
 }
 
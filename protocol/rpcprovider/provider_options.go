@@ -0,0 +1,226 @@
+package rpcprovider
+
+import (
+	"time"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/lavanet/lava/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ProviderOptions holds the optional provider features, the zero value of a field keeps its feature off or at its default
+type ProviderOptions struct {
+	NodeMaxInFlight               uint
+	LatencySLOTracker             *LatencySLOTracker
+	RelayWatchdog                 *RelayWatchdog
+	BlockBodyRetention            *chaintracker.BlockBodyRetentionConfig
+	SpecOverlays                  map[string]*statetracker.SpecOverlay
+	MaxRangeBlocks                uint64 // 0 serves range queries whole
+	ShutdownSnapshotPath          string
+	DowntimeDuration              time.Duration
+	LavaNodeBackups               []string
+	ProtocolVersionAction         statetracker.ProtocolVersionAction
+	ReorgSafetyBlocks             uint64
+	StateTrackerDebugAddress      string
+	UpdaterParallelism            uint64
+	ProcessingLagAlertBlocks      uint64
+	AutoUnfreeze                  bool
+	TxGasAdjustment               float64
+	TxMaxPerBlock                 int
+	DelegatorRewardsClaimInterval time.Duration
+	RewardDBPath                  string
+	RewardDBBackend               string
+	ClaimThresholds               rewardserver.ClaimThresholds
+	ClaimQueueLimits              rewardserver.ClaimQueueLimits
+	ClaimFeeStrategy              *statetracker.TxFeeStrategy
+	RewardsAPIAddress             string
+	PaymentAlerts                 *rewardserver.PaymentAlerts
+	PaymentNotifier               *rewardserver.PaymentNotifier
+	PaymentReconciliationInterval time.Duration
+}
+
+// ParseProviderOptions builds the provider options from the rpcprovider command flags
+func ParseProviderOptions(cmd *cobra.Command, networkChainId string) (*ProviderOptions, error) {
+	options := &ProviderOptions{}
+	var err error
+	options.NodeMaxInFlight, err = cmd.Flags().GetUint(NodeMaxInFlightFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("error fetching node max in flight flag", err)
+	}
+	relayHardCeiling, err := cmd.Flags().GetDuration(RelayHardCeilingFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("error fetching relay hard ceiling flag", err)
+	}
+	options.RelayWatchdog = NewRelayWatchdog(relayHardCeiling)
+	blockBodyRetentionBlocks, err := cmd.Flags().GetUint64(BlockBodyRetentionFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("error fetching block body retention flag", err)
+	}
+	if blockBodyRetentionBlocks > 0 {
+		blockBodyMemoryLimit, err := cmd.Flags().GetUint64(BlockBodyMemoryLimitFlagName)
+		if err != nil {
+			utils.LavaFormatFatal("error fetching block body memory limit flag", err)
+		}
+		options.BlockBodyRetention = &chaintracker.BlockBodyRetentionConfig{Blocks: blockBodyRetentionBlocks, MemoryLimit: blockBodyMemoryLimit}
+	}
+	options.LatencySLOTracker, err = ParseLatencySLOs(viper.GetViper())
+	if err != nil {
+		return nil, err
+	}
+	options.MaxRangeBlocks, err = cmd.Flags().GetUint64(MaxRangeBlocksFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read max range blocks flag", err)
+	}
+	specOverlayFile, err := cmd.Flags().GetString(statetracker.SpecOverlayFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read spec overlay flag", err)
+	}
+	options.SpecOverlays, err = statetracker.ReadSpecOverlays(specOverlayFile, networkChainId)
+	if err != nil {
+		return nil, err
+	}
+	options.ShutdownSnapshotPath, err = cmd.Flags().GetString(ShutdownSnapshotFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read shutdown snapshot flag", err)
+	}
+	options.DowntimeDuration, err = cmd.Flags().GetDuration(statetracker.DowntimeDurationFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read downtime duration flag", err)
+	}
+	options.LavaNodeBackups, err = cmd.Flags().GetStringSlice(statetracker.LavaNodeBackupsFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read lava node backups flag", err)
+	}
+	options.StateTrackerDebugAddress, err = cmd.Flags().GetString(statetracker.StateTrackerDebugAddressFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read state tracker debug address flag", err)
+	}
+	options.AutoUnfreeze, err = cmd.Flags().GetBool(AutoUnfreezeFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read auto unfreeze flag", err)
+	}
+	options.ProcessingLagAlertBlocks, err = cmd.Flags().GetUint64(statetracker.ProcessingLagAlertFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read state tracker lag alert flag", err)
+	}
+	options.UpdaterParallelism, err = cmd.Flags().GetUint64(statetracker.UpdaterParallelismFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read state tracker parallelism flag", err)
+	}
+	options.ReorgSafetyBlocks, err = cmd.Flags().GetUint64(statetracker.ReorgSafetyBlocksFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reorg safety blocks flag", err)
+	}
+	options.TxGasAdjustment, err = cmd.Flags().GetFloat64(statetracker.TxGasAdjustmentFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read tx gas adjustment flag", err)
+	}
+	options.TxMaxPerBlock, err = cmd.Flags().GetInt(statetracker.TxMaxPerBlockFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read tx max per block flag", err)
+	}
+	options.PaymentReconciliationInterval, err = cmd.Flags().GetDuration(PaymentReconciliationIntervalFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment reconciliation interval flag", err)
+	}
+	options.DelegatorRewardsClaimInterval, err = cmd.Flags().GetDuration(DelegatorRewardsClaimIntervalFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read delegator rewards claim interval flag", err)
+	}
+	options.RewardDBPath, err = cmd.Flags().GetString(rewardserver.RewardDBPathFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward db path flag", err)
+	}
+	options.RewardDBBackend, err = cmd.Flags().GetString(rewardserver.RewardDBBackendFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward db backend flag", err)
+	}
+	options.ClaimThresholds.CU, err = cmd.Flags().GetUint64(rewardserver.ClaimCUThresholdFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward claim cu threshold flag", err)
+	}
+	options.ClaimThresholds.Proofs, err = cmd.Flags().GetInt(rewardserver.ClaimProofsThresholdFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward claim proofs threshold flag", err)
+	}
+	options.ClaimThresholds.ExpiryBlocks, err = cmd.Flags().GetUint64(rewardserver.ClaimExpiryBlocksFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward claim expiry blocks flag", err)
+	}
+	options.ClaimQueueLimits.MaxRelaysPerTx, err = cmd.Flags().GetInt(rewardserver.ClaimMaxRelaysPerTxFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward claim max relays per tx flag", err)
+	}
+	options.ClaimQueueLimits.MaxTxsPerEpoch, err = cmd.Flags().GetInt(rewardserver.ClaimMaxTxsPerEpochFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read reward claim max txs per epoch flag", err)
+	}
+	options.RewardsAPIAddress, err = cmd.Flags().GetString(rewardserver.RewardsAPIAddressFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read rewards api address flag", err)
+	}
+	paymentAlertWebhook, err := cmd.Flags().GetString(rewardserver.PaymentAlertWebhookFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment alert webhook flag", err)
+	}
+	paymentAlertCommand, err := cmd.Flags().GetString(rewardserver.PaymentAlertCommandFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment alert command flag", err)
+	}
+	paymentAlertMissingEpochs, err := cmd.Flags().GetUint64(rewardserver.PaymentAlertMissingEpochsFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment alert missing epochs flag", err)
+	}
+	paymentAlertFailedClaims, err := cmd.Flags().GetInt(rewardserver.PaymentAlertFailedClaimsFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment alert failed claims flag", err)
+	}
+	options.PaymentAlerts = rewardserver.NewPaymentAlerts(paymentAlertWebhook, paymentAlertCommand, paymentAlertMissingEpochs, paymentAlertFailedClaims)
+	paymentWebhooks, err := cmd.Flags().GetStringSlice(rewardserver.PaymentWebhookFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment webhook flag", err)
+	}
+	paymentCommand, err := cmd.Flags().GetString(rewardserver.PaymentCommandFlagName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read payment command flag", err)
+	}
+	if len(paymentWebhooks) > 0 || paymentCommand != "" {
+		options.PaymentNotifier = rewardserver.NewPaymentNotifier(paymentWebhooks, paymentCommand)
+	}
+	claimGasPrices, err := cmd.Flags().GetString(statetracker.ClaimGasPricesFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read claim gas prices flag", err)
+	}
+	claimGasAdjustment, err := cmd.Flags().GetFloat64(statetracker.ClaimGasAdjustmentFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read claim gas adjustment flag", err)
+	}
+	claimFeeGranter, err := cmd.Flags().GetString(statetracker.ClaimFeeGranterFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read claim fee granter flag", err)
+	}
+	claimMaxFee, err := cmd.Flags().GetString(statetracker.ClaimMaxFeeFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read claim max fee flag", err)
+	}
+	claimOutOfGasRetries, err := cmd.Flags().GetInt(statetracker.ClaimOutOfGasRetriesFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read claim out of gas retries flag", err)
+	}
+	options.ClaimFeeStrategy, err = statetracker.ParseTxFeeStrategy(claimGasPrices, claimGasAdjustment, claimFeeGranter, claimMaxFee, claimOutOfGasRetries)
+	if err != nil {
+		return nil, err
+	}
+	protocolVersionActionFlag, err := cmd.Flags().GetString(statetracker.ProtocolVersionActionFlag)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read protocol version action flag", err)
+	}
+	options.ProtocolVersionAction, err = statetracker.ParseProtocolVersionAction(protocolVersionActionFlag)
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
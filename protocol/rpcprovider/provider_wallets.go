@@ -0,0 +1,85 @@
+package rpcprovider
+
+import (
+	"context"
+
+	btcSecp256k1 "github.com/btcsuite/btcd/btcec"
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/sigs"
+)
+
+// providerWallet is a provider address endpoints serve relays with, its relays are signed and its rewards claimed with its key
+type providerWallet struct {
+	keyName string
+	privKey *btcSecp256k1.PrivateKey
+	address sdk.AccAddress
+}
+
+func loadProviderWallet(clientCtx client.Context, keyName string) (*providerWallet, error) {
+	privKey, err := sigs.GetPrivKey(clientCtx, keyName)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed getting private key of provider wallet", err, utils.Attribute{Key: "keyName", Value: keyName})
+	}
+	key, err := clientCtx.Keyring.Key(keyName)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed reading provider wallet from the keyring", err, utils.Attribute{Key: "keyName", Value: keyName})
+	}
+	var address sdk.AccAddress
+	err = address.Unmarshal(key.GetPubKey().Address())
+	if err != nil {
+		return nil, utils.LavaFormatError("failed unmarshaling provider wallet address", err, utils.Attribute{Key: "keyName", Value: keyName})
+	}
+	return &providerWallet{keyName: keyName, privKey: privKey, address: address}, nil
+}
+
+// setupProviderWallets loads the wallets the endpoints are configured with beside the --from one, each gets its own claim signer in
+// the reward server. the returned map is keyed by the keyring name and holds the default wallet too
+func setupProviderWallets(ctx context.Context, clientCtx client.Context, defaultWallet *providerWallet, endpoints []*lavasession.RPCProviderEndpoint, providerStateTracker ProviderStateTrackerInf, rewardServer *rewardserver.RewardServer) (map[string]*providerWallet, error) {
+	wallets := map[string]*providerWallet{defaultWallet.keyName: defaultWallet}
+	for _, endpoint := range endpoints {
+		if endpoint.Wallet == "" {
+			continue
+		}
+		if _, ok := wallets[endpoint.Wallet]; ok {
+			continue
+		}
+		wallet, err := loadProviderWallet(clientCtx, endpoint.Wallet)
+		if err != nil {
+			return nil, err
+		}
+		if loaded := walletByAddress(wallets, wallet.address); loaded != nil {
+			// another key name of a loaded account, a second tx sender would race its claims on the account sequence
+			wallets[endpoint.Wallet] = loaded
+			continue
+		}
+		claimTxSender, err := providerStateTracker.NewClaimTxSender(ctx, wallet.keyName, wallet.address)
+		if err != nil {
+			return nil, err
+		}
+		rewardServer.SetClaimSigner(wallet.address.String(), claimTxSender)
+		wallets[wallet.keyName] = wallet
+		utils.LavaFormatInfo("RPCProvider serving with an additional wallet", utils.Attribute{Key: "keyName", Value: wallet.keyName}, utils.Attribute{Key: "address", Value: wallet.address.String()})
+	}
+	return wallets, nil
+}
+
+func walletByAddress(wallets map[string]*providerWallet, address sdk.AccAddress) *providerWallet {
+	for _, wallet := range wallets {
+		if wallet.address.Equals(address) {
+			return wallet
+		}
+	}
+	return nil
+}
+
+// endpointWallet is the wallet that serves the endpoint
+func endpointWallet(wallets map[string]*providerWallet, defaultWallet *providerWallet, endpoint *lavasession.RPCProviderEndpoint) *providerWallet {
+	if wallet, ok := wallets[endpoint.Wallet]; ok {
+		return wallet
+	}
+	return defaultWallet
+}
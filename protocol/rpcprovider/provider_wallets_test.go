@@ -0,0 +1,22 @@
+package rpcprovider
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderWalletsByAddress(t *testing.T) {
+	defaultWallet := &providerWallet{keyName: "provider", address: sdk.AccAddress([]byte("provider____________"))}
+	claimWallet := &providerWallet{keyName: "claims", address: sdk.AccAddress([]byte("claims______________"))}
+	wallets := map[string]*providerWallet{defaultWallet.keyName: defaultWallet, claimWallet.keyName: claimWallet}
+	// a second key name of the same account shares its wallet
+	require.Same(t, claimWallet, walletByAddress(wallets, sdk.AccAddress([]byte("claims______________"))))
+	require.Nil(t, walletByAddress(wallets, sdk.AccAddress([]byte("other_______________"))))
+
+	wallets["claims-alias"] = claimWallet
+	require.Same(t, claimWallet, endpointWallet(wallets, defaultWallet, &lavasession.RPCProviderEndpoint{Wallet: "claims-alias"}))
+	require.Same(t, defaultWallet, endpointWallet(wallets, defaultWallet, &lavasession.RPCProviderEndpoint{}))
+}
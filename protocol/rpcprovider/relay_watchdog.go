@@ -0,0 +1,249 @@
+package rpcprovider
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	RelayHardCeilingFlagName  = "relay-hard-ceiling"
+	DefaultRelayHardCeiling   = 2 * time.Minute
+	watchdogSamplesPerCeiling = 4 // how many times the watchdog samples during a hard ceiling period
+	maxStackTraceLength       = 64 * 1024
+)
+
+var (
+	relayHandlersInFlightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_relay_handlers_in_flight",
+		Help: "The number of relay handlers currently waiting for a node response",
+	}, []string{"spec", "apiInterface"})
+	relayOldestInFlightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_relay_oldest_in_flight_seconds",
+		Help: "The age of the oldest relay handler still waiting for a node response",
+	}, []string{"spec", "apiInterface"})
+	relayHandlersAbandonedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_relay_handlers_abandoned",
+		Help: "Relay handlers that were force cancelled and didn't return yet, a growing value indicates a goroutine leak",
+	}, []string{"spec", "apiInterface"})
+	relayHandlersForceCancelledCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_relay_handlers_force_cancelled_total",
+		Help: "The number of relay handlers force cancelled for exceeding the hard ceiling",
+	}, []string{"spec", "apiInterface"})
+	providerGoroutinesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lava_provider_goroutines",
+		Help: "The number of goroutines in the provider process",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(relayHandlersInFlightGauge, relayOldestInFlightGauge, relayHandlersAbandonedGauge, relayHandlersForceCancelledCounter, providerGoroutinesGauge)
+}
+
+type watchedRelay struct {
+	chainID      string
+	apiInterface string
+	start        time.Time
+	goroutineID  string // set by the handler goroutine, guarded by the watchdog lock
+	cancel       context.CancelFunc
+	forced       chan struct{} // closed when the watchdog gives up on the handler
+}
+
+type watchdogKey struct {
+	chainID      string
+	apiInterface string
+}
+
+// RelayWatchdog samples the age of relays waiting on the node, relays exceeding the hard ceiling are cancelled and their handler
+// returns even if the node call ignores the context. handlers that never return are reported as abandoned
+type RelayWatchdog struct {
+	lock        sync.Mutex
+	hardCeiling time.Duration
+	inFlight    map[uint64]*watchedRelay
+	abandoned   map[watchdogKey]int
+	nextID      uint64
+}
+
+// RunRelay runs the relay function under the watchdog, a nil watchdog just runs it
+func (rw *RelayWatchdog) RunRelay(ctx context.Context, chainID string, apiInterface string, relayFunc func(ctx context.Context) (*pairingtypes.RelayReply, error)) (*pairingtypes.RelayReply, error) {
+	if rw == nil {
+		return relayFunc(ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type relayResult struct {
+		reply *pairingtypes.RelayReply
+		err   error
+	}
+	done := make(chan relayResult, 1)
+	relay := &watchedRelay{chainID: chainID, apiInterface: apiInterface, start: time.Now(), cancel: cancel, forced: make(chan struct{})}
+	id := rw.add(relay)
+	go func() {
+		rw.setGoroutineID(relay, currentGoroutineID())
+		reply, err := relayFunc(ctx)
+		rw.remove(id, relay)
+		done <- relayResult{reply: reply, err: err}
+	}()
+	select {
+	case result := <-done:
+		return result.reply, result.err
+	case <-relay.forced:
+		return nil, RelayHandlerStuckError.Wrapf("relay exceeded the hard ceiling of %s", rw.hardCeiling)
+	}
+}
+
+func (rw *RelayWatchdog) add(relay *watchedRelay) uint64 {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.nextID++
+	rw.inFlight[rw.nextID] = relay
+	return rw.nextID
+}
+
+func (rw *RelayWatchdog) setGoroutineID(relay *watchedRelay, goroutineID string) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	relay.goroutineID = goroutineID
+}
+
+func (rw *RelayWatchdog) remove(id uint64, relay *watchedRelay) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	if _, ok := rw.inFlight[id]; ok {
+		delete(rw.inFlight, id)
+		return
+	}
+	// the watchdog already gave up on this handler, it finally returned
+	select {
+	case <-relay.forced:
+		key := watchdogKey{chainID: relay.chainID, apiInterface: relay.apiInterface}
+		rw.abandoned[key]--
+		relayHandlersAbandonedGauge.WithLabelValues(relay.chainID, relay.apiInterface).Set(float64(rw.abandoned[key]))
+	default:
+	}
+}
+
+// Start samples in flight relays until ctx is done
+func (rw *RelayWatchdog) Start(ctx context.Context) {
+	if rw == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(rw.hardCeiling / watchdogSamplesPerCeiling)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rw.sample()
+			}
+		}
+	}()
+}
+
+func (rw *RelayWatchdog) sample() {
+	providerGoroutinesGauge.Set(float64(runtime.NumGoroutine()))
+	now := time.Now()
+	type stuckRelay struct {
+		*watchedRelay
+		goroutineID string // copied under the lock, the handler sets it concurrently
+	}
+	stuck := []stuckRelay{}
+	inFlight := map[watchdogKey]int{}
+	oldest := map[watchdogKey]time.Duration{}
+	rw.lock.Lock()
+	for id, relay := range rw.inFlight {
+		key := watchdogKey{chainID: relay.chainID, apiInterface: relay.apiInterface}
+		age := now.Sub(relay.start)
+		if age > rw.hardCeiling {
+			delete(rw.inFlight, id)
+			rw.abandoned[key]++
+			stuck = append(stuck, stuckRelay{watchedRelay: relay, goroutineID: relay.goroutineID})
+			continue
+		}
+		inFlight[key]++
+		if age > oldest[key] {
+			oldest[key] = age
+		}
+	}
+	for key, count := range rw.abandoned {
+		relayHandlersAbandonedGauge.WithLabelValues(key.chainID, key.apiInterface).Set(float64(count))
+		if _, ok := inFlight[key]; !ok {
+			inFlight[key] = 0 // reset the gauges of chains with no relays in flight
+		}
+	}
+	rw.lock.Unlock()
+	for key, count := range inFlight {
+		relayHandlersInFlightGauge.WithLabelValues(key.chainID, key.apiInterface).Set(float64(count))
+		relayOldestInFlightGauge.WithLabelValues(key.chainID, key.apiInterface).Set(oldest[key].Seconds())
+	}
+	if len(stuck) == 0 {
+		return
+	}
+	stackTraces := allGoroutineStacks()
+	for _, relay := range stuck {
+		relayHandlersForceCancelledCounter.WithLabelValues(relay.chainID, relay.apiInterface).Inc()
+		utils.LavaFormatError("relay handler exceeded the hard ceiling, force cancelling", nil,
+			utils.Attribute{Key: "chainID", Value: relay.chainID},
+			utils.Attribute{Key: "apiInterface", Value: relay.apiInterface},
+			utils.Attribute{Key: "age", Value: now.Sub(relay.start)},
+			utils.Attribute{Key: "stack", Value: goroutineStack(stackTraces, relay.goroutineID)},
+		)
+		relay.cancel()
+		close(relay.forced)
+	}
+}
+
+// NewRelayWatchdog returns nil if hardCeiling is 0, meaning relays are not watched
+func NewRelayWatchdog(hardCeiling time.Duration) *RelayWatchdog {
+	if hardCeiling <= 0 {
+		return nil
+	}
+	return &RelayWatchdog{hardCeiling: hardCeiling, inFlight: map[uint64]*watchedRelay{}, abandoned: map[watchdogKey]int{}}
+}
+
+// parses the "goroutine N [running]:" header of the current goroutine stack
+func currentGoroutineID() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if idx := bytes.IndexByte(buf, ' '); idx > 0 {
+		buf = buf[:idx]
+	}
+	if _, err := strconv.ParseUint(string(buf), 10, 64); err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
+func allGoroutineStacks() []byte {
+	buf := make([]byte, maxStackTraceLength)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64*maxStackTraceLength {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// returns the stack of a single goroutine from a dump of all goroutines
+func goroutineStack(stackTraces []byte, goroutineID string) string {
+	if goroutineID == "" {
+		return "unknown goroutine"
+	}
+	for _, stack := range bytes.Split(stackTraces, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, []byte("goroutine "+goroutineID+" ")) {
+			return string(stack)
+		}
+	}
+	return "goroutine " + goroutineID + " not found"
+}
@@ -0,0 +1,112 @@
+package rpcprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func watchedRelaysInFlight(rw *RelayWatchdog) int {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return len(rw.inFlight)
+}
+
+func TestRelayWatchdogDisabled(t *testing.T) {
+	rw := NewRelayWatchdog(0)
+	require.Nil(t, rw)
+	rw.Start(context.Background())
+	reply, err := rw.RunRelay(context.Background(), "WD1", "jsonrpc", func(ctx context.Context) (*pairingtypes.RelayReply, error) {
+		return &pairingtypes.RelayReply{Data: []byte("reply")}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("reply"), reply.Data)
+}
+
+func TestRelayWatchdogRunRelay(t *testing.T) {
+	rw := NewRelayWatchdog(time.Minute)
+	reply, err := rw.RunRelay(context.Background(), "WD1", "jsonrpc", func(ctx context.Context) (*pairingtypes.RelayReply, error) {
+		return &pairingtypes.RelayReply{Data: []byte("reply")}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("reply"), reply.Data)
+	relayErr := errors.New("node failed")
+	_, err = rw.RunRelay(context.Background(), "WD1", "jsonrpc", func(ctx context.Context) (*pairingtypes.RelayReply, error) {
+		return nil, relayErr
+	})
+	require.ErrorIs(t, err, relayErr)
+	require.Zero(t, watchedRelaysInFlight(rw))
+}
+
+func TestRelayWatchdogSamplesInFlight(t *testing.T) {
+	rw := NewRelayWatchdog(time.Minute)
+	unblock := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		_, err := rw.RunRelay(context.Background(), "WD2", "jsonrpc", func(ctx context.Context) (*pairingtypes.RelayReply, error) {
+			<-unblock
+			return &pairingtypes.RelayReply{}, nil
+		})
+		result <- err
+	}()
+	require.Eventually(t, func() bool { return watchedRelaysInFlight(rw) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	// a relay under the hard ceiling is only reported
+	rw.sample()
+	require.Equal(t, float64(1), testutil.ToFloat64(relayHandlersInFlightGauge.WithLabelValues("WD2", "jsonrpc")))
+	require.Positive(t, testutil.ToFloat64(relayOldestInFlightGauge.WithLabelValues("WD2", "jsonrpc")))
+	require.Zero(t, testutil.ToFloat64(relayHandlersForceCancelledCounter.WithLabelValues("WD2", "jsonrpc")))
+	close(unblock)
+	require.NoError(t, <-result)
+}
+
+func TestRelayWatchdogForceCancelsStuckRelay(t *testing.T) {
+	rw := NewRelayWatchdog(10 * time.Millisecond)
+	forceCancelled := testutil.ToFloat64(relayHandlersForceCancelledCounter.WithLabelValues("WD3", "jsonrpc"))
+	unblock := make(chan struct{})
+	handlerCtxErr := make(chan error, 1)
+	result := make(chan error, 1)
+	go func() {
+		_, err := rw.RunRelay(context.Background(), "WD3", "jsonrpc", func(ctx context.Context) (*pairingtypes.RelayReply, error) {
+			// a node call that ignores the context
+			<-unblock
+			handlerCtxErr <- ctx.Err()
+			return &pairingtypes.RelayReply{}, nil
+		})
+		result <- err
+	}()
+	require.Eventually(t, func() bool { return watchedRelaysInFlight(rw) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	rw.sample()
+
+	// the relay returns although its handler is still stuck, the handler is reported as abandoned
+	require.ErrorIs(t, <-result, RelayHandlerStuckError)
+	require.Equal(t, forceCancelled+1, testutil.ToFloat64(relayHandlersForceCancelledCounter.WithLabelValues("WD3", "jsonrpc")))
+	require.Equal(t, float64(1), testutil.ToFloat64(relayHandlersAbandonedGauge.WithLabelValues("WD3", "jsonrpc")))
+	require.Zero(t, watchedRelaysInFlight(rw))
+	rw.sample()
+	require.Zero(t, testutil.ToFloat64(relayHandlersInFlightGauge.WithLabelValues("WD3", "jsonrpc")))
+
+	// the handler's context was cancelled, once it returns it's no longer abandoned
+	close(unblock)
+	require.ErrorIs(t, <-handlerCtxErr, context.Canceled)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(relayHandlersAbandonedGauge.WithLabelValues("WD3", "jsonrpc")) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestRelayWatchdogGoroutineStack(t *testing.T) {
+	goroutineID := currentGoroutineID()
+	require.NotEmpty(t, goroutineID)
+	stack := goroutineStack(allGoroutineStacks(), goroutineID)
+	require.Contains(t, stack, "goroutine "+goroutineID+" ")
+	require.Contains(t, stack, "TestRelayWatchdogGoroutineStack")
+
+	require.Equal(t, "unknown goroutine", goroutineStack(nil, ""))
+	require.Equal(t, "goroutine 0 not found", goroutineStack([]byte("goroutine 1 [running]:\nmain.main()"), "0"))
+}
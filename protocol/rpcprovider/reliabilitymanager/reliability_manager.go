@@ -124,8 +124,8 @@ func (rm *ReliabilityManager) VoteHandler(voteParams *VoteParams, nodeHeight uin
 	}
 }
 
-func (rm *ReliabilityManager) GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64) (latestBlock int64, requestedHashes []*chaintracker.BlockStore, err error) {
-	return rm.chainTracker.GetLatestBlockData(fromBlock, toBlock, specificBlock)
+func (rm *ReliabilityManager) GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (latestBlock int64, requestedHashes []*chaintracker.BlockStore, err error) {
+	return rm.chainTracker.GetLatestBlockData(fromBlock, toBlock, specificBlock, finalizedOnly)
 }
 
 func (rm *ReliabilityManager) GetLatestBlockNum() int64 {
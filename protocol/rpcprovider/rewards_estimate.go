@@ -0,0 +1,81 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/utils"
+	"github.com/spf13/cobra"
+)
+
+const rewardsAPIQueryTimeout = 30 * time.Second
+
+// CreateRewardsEstimateCobraCommand prints the payout a running provider expects for the proofs it holds, read from its rewards api
+func CreateRewardsEstimateCobraCommand() *cobra.Command {
+	cmdRewardsEstimate := &cobra.Command{
+		Use:   `rewards-estimate [rewards-api-address] {spec-chain-id}`,
+		Short: `estimates the LAVA a running provider would be paid for the proofs it holds if it claimed them now`,
+		Long: `queries the rewards api of a running rpcprovider (--rewards-api-address) for the expected payout of its held proofs, computed with the
+		current pairing params of the lava chain, nothing is claimed. operators can use it to forecast income and decide when to claim`,
+		Example: `rewards-estimate 127.0.0.1:7779
+		rewards-estimate 127.0.0.1:7779 ETH1`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if len(args) > 1 {
+				query.Set("chainID", args[1])
+			}
+			estimate, err := fetchRewardsEstimate(cmd.Context(), args[0], query)
+			if err != nil {
+				return err
+			}
+			output, err := json.MarshalIndent(estimate, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(output))
+			return nil
+		},
+	}
+	return cmdRewardsEstimate
+}
+
+func fetchRewardsEstimate(ctx context.Context, address string, query url.Values) (*rewardserver.RewardsEstimate, error) {
+	estimate := &rewardserver.RewardsEstimate{}
+	err := queryRewardsAPI(ctx, address, rewardserver.RewardsEstimatePath, query, func(body io.Reader) error {
+		err := json.NewDecoder(body).Decode(estimate)
+		if err != nil {
+			return utils.LavaFormatError("failed decoding the rewards estimate", err)
+		}
+		return nil
+	})
+	return estimate, err
+}
+
+// queryRewardsAPI gets path from the rewards api of a running provider and passes the reply body to handle
+func queryRewardsAPI(ctx context.Context, address string, path string, query url.Values, handle func(body io.Reader) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, rewardsAPIQueryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return utils.LavaFormatError("failed querying the provider rewards api", err, utils.Attribute{Key: "address", Value: address})
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return utils.LavaFormatError("provider rewards api returned an error", nil, utils.Attribute{Key: "address", Value: address}, utils.Attribute{Key: "status", Value: resp.Status})
+	}
+	return handle(resp.Body)
+}
@@ -0,0 +1,71 @@
+package rpcprovider
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/spf13/cobra"
+)
+
+const (
+	RewardsExportFormatFlag    = "format"
+	RewardsExportFromBlockFlag = "from-block"
+	RewardsExportToBlockFlag   = "to-block"
+	RewardsExportOutputFlag    = "output"
+)
+
+// CreateRewardsExportCobraCommand writes the rewards a running provider was paid per epoch, chain and consumer, read from its rewards api
+func CreateRewardsExportCobraCommand() *cobra.Command {
+	cmdRewardsExport := &cobra.Command{
+		Use:   `rewards-export [rewards-api-address] [--format csv|json] [--from-block 0] [--to-block 0] [--output rewards.csv]`,
+		Short: `exports the rewards a running provider was paid per epoch, chain and consumer as csv or json`,
+		Long: `queries the rewards api of a running rpcprovider (--rewards-api-address) for the payments it received, summed per epoch, chain and consumer,
+		for accounting and tax reporting. with a reward db (--reward-db-path) all the payments since it was created are exported, without it only
+		the payments since the provider started`,
+		Example: `rewards-export 127.0.0.1:7779
+		rewards-export 127.0.0.1:7779 --format json --from-block 100000 --to-block 200000 --output rewards.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			format, err := cmd.Flags().GetString(RewardsExportFormatFlag)
+			if err != nil {
+				return err
+			}
+			query.Set("format", format)
+			for _, flag := range []struct{ name, param string }{{RewardsExportFromBlockFlag, "fromBlock"}, {RewardsExportToBlockFlag, "toBlock"}} {
+				block, err := cmd.Flags().GetUint64(flag.name)
+				if err != nil {
+					return err
+				}
+				if block > 0 {
+					query.Set(flag.param, strconv.FormatUint(block, 10))
+				}
+			}
+			outputPath, err := cmd.Flags().GetString(RewardsExportOutputFlag)
+			if err != nil {
+				return err
+			}
+			output := io.Writer(os.Stdout)
+			if outputPath != "" {
+				file, err := os.Create(outputPath)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				output = file
+			}
+			return queryRewardsAPI(cmd.Context(), args[0], rewardserver.RewardsExportPath, query, func(body io.Reader) error {
+				_, err := io.Copy(output, body)
+				return err
+			})
+		},
+	}
+	cmdRewardsExport.Flags().String(RewardsExportFormatFlag, rewardserver.RewardsExportFormatCSV, "export format, csv or json")
+	cmdRewardsExport.Flags().Uint64(RewardsExportFromBlockFlag, 0, "first payment block to export")
+	cmdRewardsExport.Flags().Uint64(RewardsExportToBlockFlag, 0, "last payment block to export, 0 exports up to the latest payment")
+	cmdRewardsExport.Flags().String(RewardsExportOutputFlag, "", "file to write the export to, stdout if empty")
+	return cmdRewardsExport
+}
@@ -0,0 +1,84 @@
+package rewardserver
+
+import (
+	"sort"
+
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+type claimSessionKey struct {
+	consumer  string
+	epoch     uint64
+	sessionID uint64
+}
+
+type claimDataReliabilityKey struct {
+	consumer string
+	epoch    uint64
+}
+
+// claimAggregator merges the proofs of a chain into the claim the pairing module accepts: a session is paid once per consumer and epoch
+// whatever api interface served it, so only its proof with the most cu is kept, and a consumer has one data reliability proof per epoch
+// that is used by one of its relays. a duplicate or an unused data reliability proof would fail the whole claim
+type claimAggregator struct {
+	sessions        map[claimSessionKey]*pairingtypes.RelaySession
+	dataReliability map[claimDataReliabilityKey]*pairingtypes.VRFData
+	consumerEpochs  map[claimDataReliabilityKey]struct{} // the consumers and epochs with relays in the claim
+	merged          int
+}
+
+func newClaimAggregator() *claimAggregator {
+	return &claimAggregator{sessions: map[claimSessionKey]*pairingtypes.RelaySession{}, dataReliability: map[claimDataReliabilityKey]*pairingtypes.VRFData{}, consumerEpochs: map[claimDataReliabilityKey]struct{}{}}
+}
+
+// add adds the proofs of the consumer in epoch, the epoch start the pairing module pays the sessions in
+func (ca *claimAggregator) add(consumer string, epoch uint64, proofs []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData) {
+	for _, proof := range proofs {
+		ca.consumerEpochs[claimDataReliabilityKey{consumer: consumer, epoch: epoch}] = struct{}{}
+		key := claimSessionKey{consumer: consumer, epoch: epoch, sessionID: proof.SessionId}
+		if stored, ok := ca.sessions[key]; ok {
+			ca.merged++
+			if stored.CuSum >= proof.CuSum {
+				continue
+			}
+		}
+		ca.sessions[key] = proof
+	}
+	for _, dataReliability := range dataReliabilityProofs {
+		key := claimDataReliabilityKey{consumer: consumer, epoch: uint64(dataReliability.Epoch)}
+		if _, ok := ca.dataReliability[key]; ok {
+			ca.merged++
+			continue
+		}
+		ca.dataReliability[key] = dataReliability
+	}
+}
+
+// claim returns the aggregated proofs ordered by epoch and session, so the claim transaction doesn't depend on map order
+func (ca *claimAggregator) claim(chainID string) (proofs []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData) {
+	for _, proof := range ca.sessions {
+		proofs = append(proofs, proof)
+	}
+	sort.Slice(proofs, func(i, j int) bool {
+		if proofs[i].Epoch != proofs[j].Epoch {
+			return proofs[i].Epoch < proofs[j].Epoch
+		}
+		return proofs[i].SessionId < proofs[j].SessionId
+	})
+	for key, dataReliability := range ca.dataReliability {
+		// a data reliability proof is only paid with a relay of its consumer in the claim
+		if _, ok := ca.consumerEpochs[key]; !ok {
+			ca.merged++
+			continue
+		}
+		dataReliabilityProofs = append(dataReliabilityProofs, dataReliability)
+	}
+	sort.Slice(dataReliabilityProofs, func(i, j int) bool {
+		return dataReliabilityProofs[i].Epoch < dataReliabilityProofs[j].Epoch
+	})
+	if ca.merged > 0 {
+		utils.LavaFormatDebug("aggregated the proofs of the claim", utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "sessions", Value: len(proofs)}, utils.Attribute{Key: "dropped", Value: ca.merged})
+	}
+	return proofs, dataReliabilityProofs
+}
@@ -0,0 +1,24 @@
+package rewardserver
+
+import (
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimAggregation(t *testing.T) {
+	aggregator := newClaimAggregator()
+	// the same session served on two api interfaces
+	aggregator.add("consumer1", 20, []*pairingtypes.RelaySession{{SessionId: 1, CuSum: 10, Epoch: 20}, {SessionId: 2, CuSum: 5, Epoch: 20}}, []*pairingtypes.VRFData{{Epoch: 20}})
+	aggregator.add("consumer1", 20, []*pairingtypes.RelaySession{{SessionId: 1, CuSum: 30, Epoch: 20}}, []*pairingtypes.VRFData{{Epoch: 20}})
+	// a data reliability proof without relays of its epoch isn't used
+	aggregator.add("consumer2", 40, []*pairingtypes.RelaySession{{SessionId: 1, CuSum: 7, Epoch: 40}}, []*pairingtypes.VRFData{{Epoch: 20}})
+
+	proofs, dataReliabilityProofs := aggregator.claim("LAV1")
+	require.Len(t, proofs, 3)
+	require.Equal(t, uint64(30), proofs[0].CuSum)
+	require.Equal(t, uint64(5), proofs[1].CuSum)
+	require.Equal(t, uint64(7), proofs[2].CuSum)
+	require.Len(t, dataReliabilityProofs, 1)
+}
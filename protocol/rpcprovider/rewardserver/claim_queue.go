@@ -0,0 +1,119 @@
+package rewardserver
+
+import (
+	"sort"
+)
+
+const (
+	ClaimMaxRelaysPerTxFlagName = "reward-claim-max-relays-per-tx"
+	ClaimMaxTxsPerEpochFlagName = "reward-claim-max-txs-per-epoch"
+	claimTxBlockBytesFraction   = 2 // a claim transaction uses at most this fraction of the max bytes of a block
+)
+
+// ClaimQueueLimits split the claims into transactions and throttle them, the proofs closest to the payment collection deadline are
+// claimed first so a backlog, like the one after downtime, loses the fewest proofs to expiry
+type ClaimQueueLimits struct {
+	MaxRelaysPerTx int // 0 is unlimited
+	MaxTxsPerEpoch int // 0 is unlimited, the proofs that don't fit wait for the next epoch
+}
+
+// claimUnit are the proofs of a consumer in an epoch, they're claimed in the same transaction since sessions served on more than one
+// api interface and the data reliability proof are aggregated together
+type claimUnit struct {
+	epoch    uint64
+	consumer string
+	keys     []string // consumer rewards keys
+	relays   int
+	bytes    int
+}
+
+// claimBatch is the part of a chain claim sent in one transaction
+type claimBatch struct {
+	claim       *chainClaim
+	units       []*claimUnit
+	relays      int
+	bytes       int
+	oldestEpoch uint64
+}
+
+func (cb *claimBatch) fits(unit *claimUnit, maxRelays int, maxBytes int) bool {
+	if len(cb.units) == 0 {
+		// a unit bigger than the limits is sent alone
+		return true
+	}
+	return (maxRelays <= 0 || cb.relays+unit.relays <= maxRelays) && (maxBytes <= 0 || cb.bytes+unit.bytes <= maxBytes)
+}
+
+func (cb *claimBatch) add(unit *claimUnit) {
+	if len(cb.units) == 0 || unit.epoch < cb.oldestEpoch {
+		cb.oldestEpoch = unit.epoch
+	}
+	cb.units = append(cb.units, unit)
+	cb.relays += unit.relays
+	cb.bytes += unit.bytes
+}
+
+// claimUnitsUnsafe groups the proofs of the claim by epoch and consumer, oldest epoch first
+func (rws *RewardServer) claimUnitsUnsafe(claim *chainClaim) []*claimUnit {
+	units := map[claimDataReliabilityKey]*claimUnit{}
+	for epoch, consumerRewardsKeys := range claim.consumerRewards {
+		epochRewards := rws.rewards[epoch]
+		for _, consumerRewardsKey := range consumerRewardsKeys {
+			rewards := epochRewards.consumerRewards[consumerRewardsKey]
+			key := claimDataReliabilityKey{consumer: rewards.consumer, epoch: epoch}
+			unit, ok := units[key]
+			if !ok {
+				unit = &claimUnit{epoch: epoch, consumer: rewards.consumer}
+				units[key] = unit
+			}
+			unit.keys = append(unit.keys, consumerRewardsKey)
+			for _, proof := range rewards.proofs {
+				unit.relays++
+				unit.bytes += proof.Size()
+			}
+			for _, dataReliability := range rewards.dataReliabilityProofs {
+				unit.bytes += dataReliability.Size()
+			}
+		}
+	}
+	sorted := make([]*claimUnit, 0, len(units))
+	for _, unit := range units {
+		sort.Strings(unit.keys)
+		sorted = append(sorted, unit)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].epoch != sorted[j].epoch {
+			return sorted[i].epoch < sorted[j].epoch
+		}
+		return sorted[i].consumer < sorted[j].consumer
+	})
+	return sorted
+}
+
+// queueClaimsUnsafe splits the claims into transaction batches within the limits and the chain's max transaction bytes, orders them
+// by their oldest epoch, the first to expire, and keeps the batches that fit in the transactions of this epoch
+func (rws *RewardServer) queueClaimsUnsafe(claims []*chainClaim, limits ClaimQueueLimits, maxTxBytes int) (queued []*claimBatch, deferred int) {
+	batches := []*claimBatch{}
+	for _, claim := range claims {
+		batch := &claimBatch{claim: claim}
+		for _, unit := range rws.claimUnitsUnsafe(claim) {
+			if !batch.fits(unit, limits.MaxRelaysPerTx, maxTxBytes) {
+				batches = append(batches, batch)
+				batch = &claimBatch{claim: claim}
+			}
+			batch.add(unit)
+		}
+		if len(batch.units) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+	// claims are ordered by provider and chain, so batches of the same epoch keep that order
+	sort.SliceStable(batches, func(i, j int) bool { return batches[i].oldestEpoch < batches[j].oldestEpoch })
+	if limits.MaxTxsPerEpoch <= 0 || len(batches) <= limits.MaxTxsPerEpoch {
+		return batches, 0
+	}
+	for _, batch := range batches[limits.MaxTxsPerEpoch:] {
+		deferred += batch.relays
+	}
+	return batches[:limits.MaxTxsPerEpoch], deferred
+}
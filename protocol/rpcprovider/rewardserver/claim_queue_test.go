@@ -0,0 +1,44 @@
+package rewardserver
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimQueue(t *testing.T) {
+	txSender := &fakeRewardsTxSender{}
+	rws := NewRewardServer(txSender, ClaimThresholds{})
+	rws.SetClaimQueueLimits(ClaimQueueLimits{MaxRelaysPerTx: 2, MaxTxsPerEpoch: 2})
+
+	ctx := context.Background()
+	// a backlog of three epochs, the newest chain has the oldest proofs
+	proofs := []struct {
+		chainID string
+		epoch   uint64
+	}{{"LAV1", 60}, {"LAV1", 60}, {"LAV1", 60}, {"ETH1", 20}, {"ETH1", 40}}
+	for idx, proof := range proofs {
+		relay := &pairingtypes.RelaySession{SpecId: proof.chainID, SessionId: uint64(idx), CuSum: 10, Epoch: int64(proof.epoch), Provider: "provider", Sig: []byte(strconv.Itoa(idx))}
+		_, updated, err := rws.SendNewProof(ctx, relay, proof.epoch, "consumer"+strconv.Itoa(idx), "rest")
+		require.NoError(t, err)
+		require.True(t, updated)
+	}
+
+	require.NoError(t, rws.sendRewardsClaim(ctx, 100))
+	// the two ETH1 epochs fit in a transaction, the LAV1 relays need two and only one is sent
+	require.Len(t, txSender.claims, 2)
+	require.Len(t, txSender.claims[0], 2)
+	require.Equal(t, "ETH1", txSender.claims[0][0].SpecId)
+	require.Len(t, txSender.claims[1], 2)
+	require.Equal(t, "LAV1", txSender.claims[1][0].SpecId)
+	require.Len(t, rws.rewards[60].consumerRewards, 1)
+
+	// the throttled proof is claimed the next epoch
+	require.NoError(t, rws.sendRewardsClaim(ctx, 120))
+	require.Len(t, txSender.claims, 3)
+	require.Len(t, txSender.claims[2], 1)
+	require.Empty(t, rws.rewards)
+}
@@ -0,0 +1,29 @@
+package rewardserver
+
+import (
+	"context"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// ClaimSigner signs and sends the reward claims of a provider address
+type ClaimSigner interface {
+	TxRelayPayment(ctx context.Context, relayRequests []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData, description string) error
+}
+
+// SetClaimSigner makes signer send the claims of the proofs served by providerAddress, for a process serving relays with
+// more than one provider wallet. the proofs of addresses without a signer are claimed by the rewards tx sender
+func (rws *RewardServer) SetClaimSigner(providerAddress string, signer ClaimSigner) {
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	rws.claimSigners[providerAddress] = signer
+}
+
+func (rws *RewardServer) claimSigner(providerAddress string) ClaimSigner {
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	if signer, ok := rws.claimSigners[providerAddress]; ok {
+		return signer
+	}
+	return rws.rewardsTxSender
+}
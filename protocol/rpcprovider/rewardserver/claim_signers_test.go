@@ -0,0 +1,65 @@
+package rewardserver
+
+import (
+	"context"
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingClaimSigner struct {
+	claims          [][]*pairingtypes.RelaySession
+	dataReliability [][]*pairingtypes.VRFData
+}
+
+func (rcs *recordingClaimSigner) TxRelayPayment(ctx context.Context, relayRequests []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData, description string) error {
+	rcs.claims = append(rcs.claims, relayRequests)
+	rcs.dataReliability = append(rcs.dataReliability, dataReliabilityProofs)
+	return nil
+}
+
+type fakeRewardsTxSender struct {
+	recordingClaimSigner
+	earliestBlockInMemory uint64
+}
+
+func (frts *fakeRewardsTxSender) GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error) {
+	return 20, nil
+}
+
+func (frts *fakeRewardsTxSender) EarliestBlockInMemory(ctx context.Context) (uint64, error) {
+	return frts.earliestBlockInMemory, nil
+}
+
+func (frts *fakeRewardsTxSender) GetBlockMaxBytes(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (frts *fakeRewardsTxSender) GetPairingParams(ctx context.Context) (*pairingtypes.Params, error) {
+	params := pairingtypes.DefaultParams()
+	return &params, nil
+}
+
+func TestClaimSigners(t *testing.T) {
+	defaultSigner := &fakeRewardsTxSender{}
+	walletSigner := &recordingClaimSigner{}
+	rws := NewRewardServer(defaultSigner, ClaimThresholds{})
+	rws.SetClaimSigner("provider2", walletSigner)
+
+	ctx := context.Background()
+	// the same consumer and session served by two wallets of the process are kept apart
+	for _, provider := range []string{"provider1", "provider2"} {
+		_, updated, err := rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 20, Provider: provider, Sig: []byte(provider)}, 20, "consumer", "rest")
+		require.NoError(t, err)
+		require.True(t, updated)
+	}
+
+	require.NoError(t, rws.sendRewardsClaim(ctx, 100))
+	require.Len(t, defaultSigner.claims, 1)
+	require.Len(t, defaultSigner.claims[0], 1)
+	require.Equal(t, "provider1", defaultSigner.claims[0][0].Provider)
+	require.Len(t, walletSigner.claims, 1)
+	require.Len(t, walletSigner.claims[0], 1)
+	require.Equal(t, "provider2", walletSigner.claims[0][0].Provider)
+}
@@ -0,0 +1,91 @@
+package rewardserver
+
+import (
+	"sort"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+const (
+	ClaimCUThresholdFlagName     = "reward-claim-cu-threshold"
+	ClaimProofsThresholdFlagName = "reward-claim-proofs-threshold"
+	ClaimExpiryBlocksFlagName    = "reward-claim-expiry-blocks"
+)
+
+// ClaimThresholds hold the proofs of a chain that can be claimed until one of the set thresholds is crossed, so a single claim
+// transaction carries more sessions. with no threshold set the proofs are claimed every epoch
+type ClaimThresholds struct {
+	CU           uint64 // claim once the cu of the claimable proofs of the chain reaches this
+	Proofs       int    // claim once the chain has this many claimable proofs
+	ExpiryBlocks uint64 // claim once the oldest claimable proof of the chain is this many blocks from leaving the lava memory, should be at least an epoch
+}
+
+func (ct ClaimThresholds) IsSet() bool {
+	return ct.CU > 0 || ct.Proofs > 0 || ct.ExpiryBlocks > 0
+}
+
+// chainClaim are the claimable proofs a provider address served on a chain
+type chainClaim struct {
+	chainID               string
+	provider              string
+	reason                string // the threshold that was crossed, empty if the claim is held
+	cu                    uint64
+	proofs                int
+	oldestEpoch           uint64
+	consumerRewards       map[uint64][]string // epoch to consumer rewards keys
+	relaySessions         []*pairingtypes.RelaySession
+	dataReliabilityProofs []*pairingtypes.VRFData
+}
+
+func newChainClaim(chainID string, provider string) *chainClaim {
+	return &chainClaim{chainID: chainID, provider: provider, consumerRewards: map[uint64][]string{}}
+}
+
+func claimKey(provider string, chainID string) string {
+	return provider + "/" + chainID
+}
+
+func (cc *chainClaim) add(epoch uint64, key string, consumerRewards *ConsumerRewards) {
+	if cc.proofs == 0 || epoch < cc.oldestEpoch {
+		cc.oldestEpoch = epoch
+	}
+	for _, proof := range consumerRewards.proofs {
+		cc.cu += proof.CuSum
+		cc.proofs++
+	}
+	cc.consumerRewards[epoch] = append(cc.consumerRewards[epoch], key)
+}
+
+// thresholdCrossed returns the reason to claim the chain now, empty if it can wait. earliestBlockInMemory is only used if the
+// expiry threshold is set
+func (cc *chainClaim) thresholdCrossed(thresholds ClaimThresholds, earliestBlockInMemory uint64) string {
+	switch {
+	case !thresholds.IsSet():
+		return "epoch"
+	case thresholds.CU > 0 && cc.cu >= thresholds.CU:
+		return "cu"
+	case thresholds.Proofs > 0 && cc.proofs >= thresholds.Proofs:
+		return "proofs"
+	case thresholds.ExpiryBlocks > 0 && blocksToExpiry(cc.oldestEpoch, earliestBlockInMemory) <= thresholds.ExpiryBlocks:
+		return "expiry"
+	}
+	return ""
+}
+
+// blocksToExpiry is the number of blocks until the proofs of epoch can't be claimed, the lava memory moves forward a block per block
+func blocksToExpiry(epoch uint64, earliestBlockInMemory uint64) uint64 {
+	if epoch <= earliestBlockInMemory {
+		return 0
+	}
+	return epoch - earliestBlockInMemory
+}
+
+// sortedChainIDs returns the claim keys ordered by provider and chain
+func sortedChainIDs(claims map[string]*chainClaim) []string {
+	chainIDs := make([]string, 0, len(claims))
+	for chainID := range claims {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Strings(chainIDs)
+	return chainIDs
+}
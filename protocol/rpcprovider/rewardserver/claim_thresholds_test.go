@@ -0,0 +1,60 @@
+package rewardserver
+
+import (
+	"context"
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimThresholdCrossed(t *testing.T) {
+	claim := newChainClaim("LAV1", "provider")
+	claim.add(40, "key1", &ConsumerRewards{proofs: map[uint64]*pairingtypes.RelaySession{1: {CuSum: 10}, 2: {CuSum: 5}}})
+	claim.add(20, "key2", &ConsumerRewards{proofs: map[uint64]*pairingtypes.RelaySession{1: {CuSum: 7}}})
+	require.Equal(t, uint64(22), claim.cu)
+	require.Equal(t, 3, claim.proofs)
+	require.Equal(t, uint64(20), claim.oldestEpoch)
+
+	tests := []struct {
+		name                  string
+		thresholds            ClaimThresholds
+		earliestBlockInMemory uint64
+		expected              string
+	}{
+		{name: "no thresholds claims every epoch", expected: "epoch"},
+		{name: "cu reached", thresholds: ClaimThresholds{CU: 22}, expected: "cu"},
+		{name: "cu below", thresholds: ClaimThresholds{CU: 23}},
+		{name: "proofs reached", thresholds: ClaimThresholds{CU: 100, Proofs: 3}, expected: "proofs"},
+		{name: "proofs below", thresholds: ClaimThresholds{Proofs: 4}},
+		{name: "oldest epoch close to expiry", thresholds: ClaimThresholds{ExpiryBlocks: 10}, earliestBlockInMemory: 10, expected: "expiry"},
+		{name: "oldest epoch far from expiry", thresholds: ClaimThresholds{ExpiryBlocks: 10}, earliestBlockInMemory: 9},
+		{name: "oldest epoch expired", thresholds: ClaimThresholds{ExpiryBlocks: 10}, earliestBlockInMemory: 30, expected: "expiry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, claim.thresholdCrossed(tt.thresholds, tt.earliestBlockInMemory))
+		})
+	}
+}
+
+func TestClaimThresholdsHoldClaims(t *testing.T) {
+	ctx := context.Background()
+	txSender := &fakeRewardsTxSender{}
+	rws := NewRewardServer(txSender, ClaimThresholds{CU: 25})
+	_, _, err := rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 20, Sig: []byte("sig1")}, 20, "consumer", "rest")
+	require.NoError(t, err)
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV2", SessionId: 2, CuSum: 30, Epoch: 20, Sig: []byte("sig2")}, 20, "consumer", "rest")
+	require.NoError(t, err)
+	// only the chain past the threshold is claimed, the other chain's proofs are held
+	require.NoError(t, rws.sendRewardsClaim(ctx, 100))
+	require.Len(t, txSender.claims, 1)
+	require.Equal(t, "LAV2", txSender.claims[0][0].SpecId)
+
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 3, CuSum: 15, Epoch: 40, Sig: []byte("sig3")}, 40, "consumer", "rest")
+	require.NoError(t, err)
+	// the held proofs are claimed with the newer ones in a single transaction
+	require.NoError(t, rws.sendRewardsClaim(ctx, 100))
+	require.Len(t, txSender.claims, 2)
+	require.Len(t, txSender.claims[1], 2)
+}
@@ -0,0 +1,61 @@
+package rewardserver
+
+import (
+	"strconv"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	duplicateRelayNum  = "relay_num"
+	duplicateSignature = "signature"
+)
+
+var rejectedProofsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_provider_rejected_proofs_total",
+	Help: "Relay proofs rejected before aggregation, by reason: relay_num for more cu on a relay number that was already proven, signature for a reused signature",
+}, []string{"spec", "reason"})
+
+func init() {
+	prometheus.MustRegister(rejectedProofsCounter)
+}
+
+func proofIdentity(consumerRewardsKey string, proof *pairingtypes.RelaySession) string {
+	return consumerRewardsKey + "/" + strconv.FormatUint(proof.SessionId, 10) + "/" + strconv.FormatUint(proof.RelayNum, 10)
+}
+
+// duplicateProofUnsafe returns why the proof duplicates an accepted one, empty if it doesn't. claiming a duplicate would inflate the
+// claim and get the provider slashed for fraud. a proof resent as is isn't a duplicate
+func (rws *RewardServer) duplicateProofUnsafe(proof *pairingtypes.RelaySession, epoch uint64, consumerRewardsKey string) string {
+	if len(proof.Sig) > 0 {
+		if owner, ok := rws.proofSignatures[epoch][string(proof.Sig)]; ok && owner != proofIdentity(consumerRewardsKey, proof) {
+			return duplicateSignature
+		}
+	}
+	epochRewards, ok := rws.rewards[epoch]
+	if !ok {
+		return ""
+	}
+	consumerRewards, ok := epochRewards.consumerRewards[consumerRewardsKey]
+	if !ok {
+		return ""
+	}
+	stored, ok := consumerRewards.proofs[proof.SessionId]
+	if ok && proof.RelayNum <= stored.RelayNum && proof.CuSum > stored.CuSum {
+		return duplicateRelayNum
+	}
+	return ""
+}
+
+func (rws *RewardServer) recordProofSignatureUnsafe(proof *pairingtypes.RelaySession, epoch uint64, consumerRewardsKey string) {
+	if len(proof.Sig) == 0 {
+		return
+	}
+	signatures, ok := rws.proofSignatures[epoch]
+	if !ok {
+		signatures = map[string]string{}
+		rws.proofSignatures[epoch] = signatures
+	}
+	signatures[string(proof.Sig)] = proofIdentity(consumerRewardsKey, proof)
+}
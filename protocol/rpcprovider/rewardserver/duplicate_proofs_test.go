@@ -0,0 +1,37 @@
+package rewardserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateProofs(t *testing.T) {
+	ctx := context.Background()
+	rws := NewRewardServer(nil, ClaimThresholds{})
+	proof := &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 1, CuSum: 10, Sig: []byte("sig1")}
+	_, updated, err := rws.SendNewProof(ctx, proof, 20, "consumer", "jsonrpc")
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	// resending the same proof isn't a duplicate
+	existingCU, updated, err := rws.SendNewProof(ctx, proof, 20, "consumer", "jsonrpc")
+	require.NoError(t, err)
+	require.False(t, updated)
+	require.Equal(t, uint64(10), existingCU)
+
+	// more cu on a relay number that was already proven
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 1, CuSum: 20, Sig: []byte("sig2")}, 20, "consumer", "jsonrpc")
+	require.True(t, lavasession.DuplicateRelayProofError.Is(err))
+
+	// the signature of the first proof on another session
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 2, RelayNum: 1, CuSum: 10, Sig: []byte("sig1")}, 20, "consumer", "jsonrpc")
+	require.True(t, lavasession.DuplicateRelayProofError.Is(err))
+
+	_, updated, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 2, CuSum: 20, Sig: []byte("sig3")}, 20, "consumer", "jsonrpc")
+	require.NoError(t, err)
+	require.True(t, updated)
+}
@@ -0,0 +1,124 @@
+package rewardserver
+
+import (
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var forkDroppedProofsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_provider_fork_dropped_data_reliability_proofs_total",
+	Help: "Data reliability proofs left out of claims since the served chain reorged the finalized hashes signed in their epoch",
+}, []string{"spec"})
+
+func init() {
+	prometheus.MustRegister(forkDroppedProofsCounter)
+}
+
+// ChainHashTracker is the chain tracker of a served chain, implemented by chaintracker.ChainTracker
+type ChainHashTracker interface {
+	GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (latestBlock int64, requestedHashes []*chaintracker.BlockStore, err error)
+	RegisterForkListener(listener chaintracker.ForkListener) (id uint64)
+}
+
+// signedFinalization are the finalized block hashes signed in the replies to a consumer in an epoch
+type signedFinalization struct {
+	chainID  string
+	hashes   map[int64]string
+	replaced bool // a signed hash isn't on the chain anymore
+}
+
+// RegisterChainTracker revalidates the signed finalization hashes of chainID every time tracker detects a fork
+func (rws *RewardServer) RegisterChainTracker(chainID string, tracker ChainHashTracker) {
+	tracker.RegisterForkListener(func(block int64) {
+		// the listener is called from the polling routine, it mustn't wait for a claim holding the lock
+		go rws.revalidateFinalizations(chainID, tracker, block)
+	})
+}
+
+// RecordSignedFinalization keeps the finalized block hashes signed in a reply to the consumer, a data reliability proof of the epoch
+// vouches for them so it isn't claimed if one of them is replaced by a fork
+func (rws *RewardServer) RecordSignedFinalization(epoch uint64, consumerAddr string, specId string, apiInterface string, providerAddr string, hashes map[int64]string) {
+	if len(hashes) == 0 {
+		return
+	}
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	epochFinalizations, ok := rws.signedFinalizations[epoch]
+	if !ok {
+		epochFinalizations = map[string]*signedFinalization{}
+		rws.signedFinalizations[epoch] = epochFinalizations
+	}
+	consumerRewardsKey := getKeyForConsumerRewards(specId, apiInterface, consumerAddr, providerAddr)
+	finalization, ok := epochFinalizations[consumerRewardsKey]
+	if !ok {
+		finalization = &signedFinalization{chainID: specId, hashes: map[int64]string{}}
+		epochFinalizations[consumerRewardsKey] = finalization
+	}
+	for block, hash := range hashes {
+		if signedHash, ok := finalization.hashes[block]; ok && signedHash != hash {
+			// the chain tracker already moved to a fork, the earlier reply signed the replaced hash
+			finalization.replaced = true
+		}
+		finalization.hashes[block] = hash
+	}
+}
+
+// revalidateFinalizations compares the signed hashes of chainID with the ones tracker holds after a fork, blocks that left its window
+// are older than the fork and are skipped
+func (rws *RewardServer) revalidateFinalizations(chainID string, tracker ChainHashTracker, forkBlock int64) {
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	replaced := 0
+	for epoch, epochFinalizations := range rws.signedFinalizations {
+		for consumerRewardsKey, finalization := range epochFinalizations {
+			if finalization.chainID != chainID || finalization.replaced {
+				continue
+			}
+			for block, signedHash := range finalization.hashes {
+				_, blocks, err := tracker.GetLatestBlockData(spectypes.NOT_APPLICABLE, spectypes.NOT_APPLICABLE, block, false)
+				if err != nil || len(blocks) == 0 || blocks[0].Hash == "" {
+					continue
+				}
+				if blocks[0].Hash != signedHash {
+					finalization.replaced = true
+					replaced++
+					utils.LavaFormatWarning("a finalized block hash signed to a consumer was replaced by a fork, its data reliability proof won't be claimed", nil,
+						utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumerRewardsKey", Value: consumerRewardsKey},
+						utils.Attribute{Key: "block", Value: block}, utils.Attribute{Key: "signedHash", Value: signedHash}, utils.Attribute{Key: "hash", Value: blocks[0].Hash})
+					break
+				}
+			}
+		}
+	}
+	utils.LavaFormatDebug("revalidated signed finalizations after a fork", utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "forkBlock", Value: forkBlock}, utils.Attribute{Key: "replaced", Value: replaced})
+}
+
+// dropForkedDataReliabilityUnsafe removes the data reliability proofs of consumer rewards whose signed finalization was replaced,
+// their relay proofs don't reference block hashes and are still claimed
+func (rws *RewardServer) dropForkedDataReliabilityUnsafe(epoch uint64, consumerRewardsKey string, consumerRewards *ConsumerRewards) {
+	finalization, ok := rws.signedFinalizations[epoch][consumerRewardsKey]
+	if !ok || !finalization.replaced || len(consumerRewards.dataReliabilityProofs) == 0 {
+		return
+	}
+	forkDroppedProofsCounter.WithLabelValues(finalization.chainID).Add(float64(len(consumerRewards.dataReliabilityProofs)))
+	consumerRewards.dataReliabilityProofs = []*pairingtypes.VRFData{}
+	if rws.rewardStore != nil {
+		err := rws.rewardStore.DeleteDataReliabilityProof(epoch, consumerRewardsKey, consumerRewards.consumer, finalization.chainID)
+		if err != nil {
+			utils.LavaFormatWarning("failed deleting a forked data reliability proof from the reward db", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerRewards.consumer})
+		}
+	}
+}
+
+// pruneSignedFinalizationsUnsafe forgets the finalizations of epochs that take no more proofs and have none left to claim
+func (rws *RewardServer) pruneSignedFinalizationsUnsafe(activeEpochThreshold uint64) {
+	for epoch := range rws.signedFinalizations {
+		if _, ok := rws.rewards[epoch]; !ok && !lavasession.IsEpochValidForUse(epoch, activeEpochThreshold) {
+			delete(rws.signedFinalizations, epoch)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package rewardserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChainHashTracker struct {
+	hashes   map[int64]string
+	listener chaintracker.ForkListener
+}
+
+func (fcht *fakeChainHashTracker) GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (latestBlock int64, requestedHashes []*chaintracker.BlockStore, err error) {
+	hash, ok := fcht.hashes[specificBlock]
+	if !ok {
+		return 0, nil, chaintracker.InvalidRequestedSpecificBlock
+	}
+	return 0, []*chaintracker.BlockStore{{Block: specificBlock, Hash: hash}}, nil
+}
+
+func (fcht *fakeChainHashTracker) RegisterForkListener(listener chaintracker.ForkListener) uint64 {
+	fcht.listener = listener
+	return 1
+}
+
+func TestForkRevalidation(t *testing.T) {
+	txSender := &fakeRewardsTxSender{}
+	rws := NewRewardServer(txSender, ClaimThresholds{})
+	tracker := &fakeChainHashTracker{hashes: map[int64]string{100: "a", 101: "b"}}
+	rws.RegisterChainTracker("LAV1", tracker)
+	require.NotNil(t, tracker.listener)
+
+	ctx := context.Background()
+	for _, consumer := range []string{"consumer1", "consumer2"} {
+		_, updated, err := rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 20, Provider: "provider", Sig: []byte(consumer)}, 20, consumer, "rest")
+		require.NoError(t, err)
+		require.True(t, updated)
+		require.True(t, rws.SendNewDataReliabilityProof(ctx, &pairingtypes.VRFData{ChainId: "LAV1", Epoch: 20, Sig: []byte(consumer)}, 20, consumer, "LAV1", "rest", "provider"))
+	}
+	rws.RecordSignedFinalization(20, "consumer1", "LAV1", "rest", "provider", map[int64]string{100: "a", 101: "b"})
+	// block 90 left the window, it's older than the fork
+	rws.RecordSignedFinalization(20, "consumer2", "LAV1", "rest", "provider", map[int64]string{90: "z", 100: "a"})
+
+	// the fork replaced block 101
+	tracker.hashes[101] = "c"
+	rws.revalidateFinalizations("LAV1", tracker, 102)
+
+	require.NoError(t, rws.sendRewardsClaim(ctx, 100))
+	require.Len(t, txSender.claims, 1)
+	require.Len(t, txSender.claims[0], 2)
+	require.Len(t, txSender.dataReliability[0], 1)
+	require.Equal(t, []byte("consumer2"), txSender.dataReliability[0][0].Sig)
+	require.Empty(t, rws.signedFinalizations[20])
+}
+
+func TestSignedFinalizationReplacedOnRecord(t *testing.T) {
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	rws.RecordSignedFinalization(20, "consumer", "LAV1", "rest", "provider", map[int64]string{100: "a"})
+	require.False(t, rws.signedFinalizations[20][getKeyForConsumerRewards("LAV1", "rest", "consumer", "provider")].replaced)
+	// a later reply signed another hash for the same block, the tracker already moved to a fork
+	rws.RecordSignedFinalization(20, "consumer", "LAV1", "rest", "provider", map[int64]string{100: "b"})
+	require.True(t, rws.signedFinalizations[20][getKeyForConsumerRewards("LAV1", "rest", "consumer", "provider")].replaced)
+}
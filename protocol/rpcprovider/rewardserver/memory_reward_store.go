@@ -0,0 +1,195 @@
+package rewardserver
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// MemoryRewardStore is a RewardStore held in the process memory, the state is lost on restart. it's a reference for external
+// stores and keeps the encoded proofs so callers can't change them after saving
+type MemoryRewardStore struct {
+	lock            sync.RWMutex
+	proofs          map[memoryRewardKey][]byte
+	dataReliability map[memoryRewardKey][]byte
+	claims          map[memoryRewardKey][]byte
+	paidRewards     map[string]PaidReward // key is the paid reward key, ordered by the payment block
+	serverID        uint64
+	serverIDFound   bool
+}
+
+// memoryRewardKey is the epoch, consumer and spec of an entry, and its session or consumer rewards key when the entry has one
+type memoryRewardKey struct {
+	epoch              uint64
+	consumer           string
+	specID             string
+	sessionID          uint64
+	consumerRewardsKey string
+}
+
+func NewMemoryRewardStore() *MemoryRewardStore {
+	return &MemoryRewardStore{
+		proofs:          map[memoryRewardKey][]byte{},
+		dataReliability: map[memoryRewardKey][]byte{},
+		claims:          map[memoryRewardKey][]byte{},
+		paidRewards:     map[string]PaidReward{},
+	}
+}
+
+func (mrs *MemoryRewardStore) SaveProof(epoch uint64, consumerRewardsKey string, consumer string, proof *pairingtypes.RelaySession) error {
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return err
+	}
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	mrs.proofs[memoryRewardKey{epoch: epoch, consumer: consumer, specID: proof.SpecId, sessionID: proof.SessionId, consumerRewardsKey: consumerRewardsKey}] = proofBytes
+	return nil
+}
+
+func (mrs *MemoryRewardStore) SaveDataReliabilityProof(epoch uint64, consumerRewardsKey string, consumer string, specID string, dataReliability *pairingtypes.VRFData) error {
+	proofBytes, err := dataReliability.Marshal()
+	if err != nil {
+		return err
+	}
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	mrs.dataReliability[memoryRewardKey{epoch: epoch, consumer: consumer, specID: specID, consumerRewardsKey: consumerRewardsKey}] = proofBytes
+	return nil
+}
+
+func (mrs *MemoryRewardStore) DeleteProof(epoch uint64, consumer string, specID string, sessionID uint64) error {
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	for key := range mrs.proofs {
+		if key.epoch == epoch && key.consumer == consumer && key.specID == specID && key.sessionID == sessionID {
+			delete(mrs.proofs, key)
+		}
+	}
+	return nil
+}
+
+func (mrs *MemoryRewardStore) DeleteDataReliabilityProof(epoch uint64, consumerRewardsKey string, consumer string, specID string) error {
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	delete(mrs.dataReliability, memoryRewardKey{epoch: epoch, consumer: consumer, specID: specID, consumerRewardsKey: consumerRewardsKey})
+	return nil
+}
+
+func (mrs *MemoryRewardStore) DeleteEpochsBefore(epoch uint64) (deleted int, err error) {
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	for _, entries := range []map[memoryRewardKey][]byte{mrs.proofs, mrs.dataReliability, mrs.claims} {
+		for key := range entries {
+			if key.epoch < epoch {
+				delete(entries, key)
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+func (mrs *MemoryRewardStore) LoadRewards() ([]*ConsumerRewardsSnapshot, error) {
+	mrs.lock.RLock()
+	defer mrs.lock.RUnlock()
+	rewards := map[string]*ConsumerRewardsSnapshot{} // key is the epoch and the consumer rewards key
+	consumerRewards := func(key memoryRewardKey) *ConsumerRewardsSnapshot {
+		snapshotKey := strconv.FormatUint(key.epoch, 10) + "/" + key.consumerRewardsKey
+		snapshot, ok := rewards[snapshotKey]
+		if !ok {
+			snapshot = &ConsumerRewardsSnapshot{Epoch: key.epoch, Key: key.consumerRewardsKey, Consumer: key.consumer}
+			rewards[snapshotKey] = snapshot
+		}
+		return snapshot
+	}
+	for key, proofBytes := range mrs.proofs {
+		snapshot := consumerRewards(key)
+		snapshot.Proofs = append(snapshot.Proofs, append([]byte{}, proofBytes...))
+	}
+	for key, proofBytes := range mrs.dataReliability {
+		snapshot := consumerRewards(key)
+		snapshot.DataReliabilityProofs = append(snapshot.DataReliabilityProofs, append([]byte{}, proofBytes...))
+	}
+	result := make([]*ConsumerRewardsSnapshot, 0, len(rewards))
+	for _, snapshot := range rewards {
+		result = append(result, snapshot)
+	}
+	return result, nil
+}
+
+func (mrs *MemoryRewardStore) SaveClaim(claim ClaimSnapshot) error {
+	value, err := encodeClaim(claim)
+	if err != nil {
+		return err
+	}
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	mrs.claims[memoryRewardKey{epoch: uint64(claim.Proof.Epoch), consumer: claim.Consumer, specID: claim.Proof.SpecId, sessionID: claim.Proof.SessionId}] = value
+	return nil
+}
+
+func (mrs *MemoryRewardStore) DeleteClaim(epoch uint64, consumer string, specID string, sessionID uint64) error {
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	delete(mrs.claims, memoryRewardKey{epoch: epoch, consumer: consumer, specID: specID, sessionID: sessionID})
+	return nil
+}
+
+func (mrs *MemoryRewardStore) LoadClaims() ([]ClaimSnapshot, error) {
+	mrs.lock.RLock()
+	defer mrs.lock.RUnlock()
+	claims := make([]ClaimSnapshot, 0, len(mrs.claims))
+	for _, value := range mrs.claims {
+		claim, err := decodeClaim(value)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, nil
+}
+
+func (mrs *MemoryRewardStore) SaveServerID(serverID uint64) error {
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	mrs.serverID = serverID
+	mrs.serverIDFound = true
+	return nil
+}
+
+func (mrs *MemoryRewardStore) LoadServerID() (serverID uint64, found bool, err error) {
+	mrs.lock.RLock()
+	defer mrs.lock.RUnlock()
+	return mrs.serverID, mrs.serverIDFound, nil
+}
+
+func (mrs *MemoryRewardStore) SavePaidReward(paid PaidReward) error {
+	mrs.lock.Lock()
+	defer mrs.lock.Unlock()
+	mrs.paidRewards[string(paidRewardKey(paid))] = paid
+	return nil
+}
+
+func (mrs *MemoryRewardStore) LoadPaidRewards(fromBlock int64, toBlock int64) ([]PaidReward, error) {
+	mrs.lock.RLock()
+	defer mrs.lock.RUnlock()
+	keys := []string{}
+	for key, paid := range mrs.paidRewards {
+		if paid.Block >= fromBlock && (toBlock <= 0 || paid.Block <= toBlock) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	paidRewards := make([]PaidReward, 0, len(keys))
+	for _, key := range keys {
+		paidRewards = append(paidRewards, mrs.paidRewards[key])
+	}
+	return paidRewards, nil
+}
+
+func (mrs *MemoryRewardStore) Close() error {
+	return nil
+}
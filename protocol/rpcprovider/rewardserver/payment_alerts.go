@@ -0,0 +1,186 @@
+package rewardserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	PaymentAlertWebhookFlagName       = "payment-alert-webhook"
+	PaymentAlertCommandFlagName       = "payment-alert-command"
+	PaymentAlertMissingEpochsFlagName = "payment-alert-missing-epochs"
+	PaymentAlertFailedClaimsFlagName  = "payment-alert-failed-claims"
+	DefaultPaymentAlertMissingEpochs  = 3
+	DefaultPaymentAlertFailedClaims   = 3
+	PaymentAlertTimeout               = 10 * time.Second
+	PaymentAlertKindMissingPayments   = "missing_payments"
+	PaymentAlertKindFailedClaims      = "failed_claims"
+)
+
+// PaymentAlert is posted to the webhook and written to the command's stdin as json
+type PaymentAlert struct {
+	Kind                 string    `json:"kind"`
+	ChainID              string    `json:"chainID"`
+	Epoch                uint64    `json:"epoch"`
+	EpochsWithoutPayment uint64    `json:"epochsWithoutPayment,omitempty"`
+	FailedClaims         int       `json:"failedClaims,omitempty"`
+	LastError            string    `json:"lastError,omitempty"`
+	Time                 time.Time `json:"time"`
+}
+
+type chainAlertState struct {
+	awaitingPayment      bool // claims were sent and not all were paid
+	epochsWithoutPayment uint64
+	failedClaims         int // consecutive failed claim transactions
+	missingAlerted       bool
+	failedAlerted        bool
+}
+
+// PaymentAlerts alerts once when a chain's claims go unpaid for missingEpochs epochs and once when failedClaims claim transactions
+// of a chain fail in a row, until a payment or a successful claim clears it. alerts are always logged, the webhook and the command
+// are optional, a zero threshold disables its alert
+type PaymentAlerts struct {
+	lock          sync.Mutex
+	webhookURL    string
+	command       string
+	missingEpochs uint64
+	failedClaims  int
+	chains        map[string]*chainAlertState
+	httpClient    *http.Client
+	sendAlert     func(alert PaymentAlert)
+}
+
+func NewPaymentAlerts(webhookURL string, command string, missingEpochs uint64, failedClaims int) *PaymentAlerts {
+	pa := &PaymentAlerts{
+		webhookURL:    webhookURL,
+		command:       command,
+		missingEpochs: missingEpochs,
+		failedClaims:  failedClaims,
+		chains:        map[string]*chainAlertState{},
+		httpClient:    &http.Client{Timeout: PaymentAlertTimeout},
+	}
+	pa.sendAlert = pa.dispatch
+	return pa
+}
+
+func (pa *PaymentAlerts) chainStateUnsafe(chainID string) *chainAlertState {
+	state, ok := pa.chains[chainID]
+	if !ok {
+		state = &chainAlertState{}
+		pa.chains[chainID] = state
+	}
+	return state
+}
+
+func (pa *PaymentAlerts) claimSent(chainID string) {
+	pa.lock.Lock()
+	defer pa.lock.Unlock()
+	state := pa.chainStateUnsafe(chainID)
+	state.failedClaims = 0
+	state.failedAlerted = false
+	if !state.awaitingPayment {
+		state.awaitingPayment = true
+		state.epochsWithoutPayment = 0
+	}
+}
+
+func (pa *PaymentAlerts) claimFailed(chainID string, epoch uint64, txErr error) {
+	pa.lock.Lock()
+	defer pa.lock.Unlock()
+	state := pa.chainStateUnsafe(chainID)
+	state.failedClaims++
+	if pa.failedClaims <= 0 || state.failedClaims < pa.failedClaims || state.failedAlerted {
+		return
+	}
+	state.failedAlerted = true
+	pa.alertUnsafe(PaymentAlert{Kind: PaymentAlertKindFailedClaims, ChainID: chainID, Epoch: epoch, FailedClaims: state.failedClaims, LastError: txErr.Error(), Time: time.Now()})
+}
+
+// paymentSeen clears the missing payments of the chain, outstanding tells if claims of the chain are still waiting for payment
+func (pa *PaymentAlerts) paymentSeen(chainID string, outstanding bool) {
+	pa.lock.Lock()
+	defer pa.lock.Unlock()
+	state := pa.chainStateUnsafe(chainID)
+	state.awaitingPayment = outstanding
+	state.epochsWithoutPayment = 0
+	state.missingAlerted = false
+}
+
+func (pa *PaymentAlerts) epochPassed(epoch uint64) {
+	pa.lock.Lock()
+	defer pa.lock.Unlock()
+	for chainID, state := range pa.chains {
+		if !state.awaitingPayment {
+			continue
+		}
+		state.epochsWithoutPayment++
+		if pa.missingEpochs == 0 || state.epochsWithoutPayment < pa.missingEpochs || state.missingAlerted {
+			continue
+		}
+		state.missingAlerted = true
+		pa.alertUnsafe(PaymentAlert{Kind: PaymentAlertKindMissingPayments, ChainID: chainID, Epoch: epoch, EpochsWithoutPayment: state.epochsWithoutPayment, Time: time.Now()})
+	}
+}
+
+func (pa *PaymentAlerts) alertUnsafe(alert PaymentAlert) {
+	utils.LavaFormatWarning("rewards payment alert", nil, utils.Attribute{Key: "alert", Value: alert})
+	go pa.sendAlert(alert)
+}
+
+func (pa *PaymentAlerts) dispatch(alert PaymentAlert) {
+	if pa.webhookURL == "" && pa.command == "" {
+		return
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		utils.LavaFormatError("failed marshaling payment alert", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), PaymentAlertTimeout)
+	defer cancel()
+	if pa.webhookURL != "" {
+		postWebhook(ctx, pa.httpClient, pa.webhookURL, body, "payment alert")
+	}
+	if pa.command != "" {
+		// the alert's kind and chain are in the environment too
+		runCommand(ctx, pa.command, body, []string{"LAVA_PAYMENT_ALERT_KIND=" + alert.Kind, "LAVA_PAYMENT_ALERT_CHAIN_ID=" + alert.ChainID}, "payment alert")
+	}
+}
+
+// postWebhook posts the json body to url, name is what the body is in the logs
+func postWebhook(ctx context.Context, httpClient *http.Client, url string, body []byte, name string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		utils.LavaFormatError("failed creating "+name+" webhook request", err, utils.Attribute{Key: "url", Value: url})
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		utils.LavaFormatWarning("failed sending "+name+" webhook", err, utils.Attribute{Key: "url", Value: url})
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		utils.LavaFormatWarning(name+" webhook returned an error status", nil, utils.Attribute{Key: "url", Value: url}, utils.Attribute{Key: "status", Value: resp.StatusCode})
+	}
+}
+
+// runCommand runs the command in a shell with the json body on stdin and env added to the environment
+func runCommand(ctx context.Context, command string, body []byte, env []string, name string) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		utils.LavaFormatWarning(name+" command failed", err, utils.Attribute{Key: "command", Value: command}, utils.Attribute{Key: "output", Value: string(output)})
+	}
+}
@@ -0,0 +1,89 @@
+package rewardserver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPaymentAlerts(missingEpochs uint64, failedClaims int) (*PaymentAlerts, chan PaymentAlert) {
+	alerts := make(chan PaymentAlert, 10)
+	pa := NewPaymentAlerts("", "", missingEpochs, failedClaims)
+	pa.sendAlert = func(alert PaymentAlert) { alerts <- alert }
+	return pa, alerts
+}
+
+func requireAlert(t *testing.T, alerts chan PaymentAlert) PaymentAlert {
+	select {
+	case alert := <-alerts:
+		return alert
+	case <-time.After(time.Second):
+		require.FailNow(t, "no alert was sent")
+		return PaymentAlert{}
+	}
+}
+
+func requireNoAlert(t *testing.T, alerts chan PaymentAlert) {
+	select {
+	case alert := <-alerts:
+		require.FailNow(t, "unexpected alert", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPaymentAlertsMissingPayments(t *testing.T) {
+	pa, alerts := newTestPaymentAlerts(2, 0)
+	// no claims, nothing is missing
+	pa.epochPassed(10)
+	pa.epochPassed(20)
+	requireNoAlert(t, alerts)
+
+	pa.claimSent("LAV1")
+	pa.epochPassed(30)
+	requireNoAlert(t, alerts)
+	pa.epochPassed(40)
+	alert := requireAlert(t, alerts)
+	require.Equal(t, PaymentAlertKindMissingPayments, alert.Kind)
+	require.Equal(t, "LAV1", alert.ChainID)
+	require.Equal(t, uint64(2), alert.EpochsWithoutPayment)
+	// alerted once until a payment is seen
+	pa.epochPassed(50)
+	requireNoAlert(t, alerts)
+
+	// a payment with claims still outstanding restarts the count
+	pa.paymentSeen("LAV1", true)
+	pa.epochPassed(60)
+	requireNoAlert(t, alerts)
+	pa.epochPassed(70)
+	requireAlert(t, alerts)
+
+	// everything paid, no more alerts
+	pa.paymentSeen("LAV1", false)
+	pa.epochPassed(80)
+	pa.epochPassed(90)
+	requireNoAlert(t, alerts)
+}
+
+func TestPaymentAlertsFailedClaims(t *testing.T) {
+	pa, alerts := newTestPaymentAlerts(0, 2)
+	pa.claimFailed("LAV1", 10, fmt.Errorf("out of gas"))
+	requireNoAlert(t, alerts)
+	// a successful claim resets the failures
+	pa.claimSent("LAV1")
+	pa.claimFailed("LAV1", 20, fmt.Errorf("out of gas"))
+	requireNoAlert(t, alerts)
+	pa.claimFailed("LAV1", 30, fmt.Errorf("insufficient fee"))
+	alert := requireAlert(t, alerts)
+	require.Equal(t, PaymentAlertKindFailedClaims, alert.Kind)
+	require.Equal(t, 2, alert.FailedClaims)
+	require.Equal(t, "insufficient fee", alert.LastError)
+	pa.claimFailed("LAV1", 40, fmt.Errorf("insufficient fee"))
+	requireNoAlert(t, alerts)
+	// the missing payments alert is disabled
+	pa.claimSent("LAV1")
+	pa.epochPassed(50)
+	pa.epochPassed(60)
+	requireNoAlert(t, alerts)
+}
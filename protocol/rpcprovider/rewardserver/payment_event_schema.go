@@ -0,0 +1,123 @@
+package rewardserver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+	terderminttypes "github.com/tendermint/tendermint/abci/types"
+)
+
+// PaymentEventSchema names the attributes of the relay payment event the lava chain emits from MinProtocolVersion on
+type PaymentEventSchema struct {
+	MinProtocolVersion string
+	ChainID            string
+	Mint               string
+	CU                 string
+	Client             string
+	UniqueIdentifier   string
+	Description        string
+	Informational      []string // emitted attributes that aren't decoded
+}
+
+// PaymentEventSchemas are the supported payment event schemas, ordered by MinProtocolVersion
+var PaymentEventSchemas = []PaymentEventSchema{
+	{
+		MinProtocolVersion: "0.0.0",
+		ChainID:            "chainID",
+		Mint:               "Mint",
+		CU:                 "CU",
+		Client:             "client",
+		UniqueIdentifier:   "uniqueIdentifier",
+		Description:        "descriptionString",
+		Informational:      []string{"provider", "BasePay", "totalCUInEpoch", "QoSReport", "QoSScore", "clientFee", "reliabilityPay", "relayNumber"},
+	},
+}
+
+// LatestPaymentEventSchema is the schema of the lava chain this binary was built with
+func LatestPaymentEventSchema() PaymentEventSchema {
+	return PaymentEventSchemas[len(PaymentEventSchemas)-1]
+}
+
+func (pes PaymentEventSchema) known() map[string]struct{} {
+	known := map[string]struct{}{pes.ChainID: {}, pes.Mint: {}, pes.CU: {}, pes.Client: {}, pes.UniqueIdentifier: {}, pes.Description: {}}
+	for _, attribute := range pes.Informational {
+		known[attribute] = struct{}{}
+	}
+	return known
+}
+
+// BuildPaymentFromRelayPaymentEventWithSchema decodes the payment with the attribute names of schema, unknownAttributes are the
+// attributes of the event the schema doesn't name, a sign the chain emits a newer schema
+func BuildPaymentFromRelayPaymentEventWithSchema(event terderminttypes.Event, block int64, schema PaymentEventSchema) (payment *PaymentRequest, unknownAttributes []string, err error) {
+	attributes := map[string]string{}
+	for _, attribute := range event.Attributes {
+		attributes[string(attribute.Key)] = string(attribute.Value)
+	}
+	known := schema.known()
+	for key := range attributes {
+		if _, ok := known[key]; !ok {
+			unknownAttributes = append(unknownAttributes, key)
+		}
+	}
+	sort.Strings(unknownAttributes)
+	attribute := func(key string) (string, error) {
+		value, ok := attributes[key]
+		if !ok {
+			return "", utils.LavaFormatError("failed building PaymentRequest from relay_payment event", fmt.Errorf("missing attribute %s", key), utils.Attribute{Key: "schema", Value: schema.MinProtocolVersion}, utils.Attribute{Key: "attributes", Value: attributes})
+		}
+		return value, nil
+	}
+	chainID, err := attribute(schema.ChainID)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	mint, err := attribute(schema.Mint)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	mintedCoins, err := sdk.ParseCoinNormalized(mint)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	cuStr, err := attribute(schema.CU)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	cu, err := strconv.ParseUint(cuStr, 10, 64)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	consumer, err := attribute(schema.Client)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	consumerAddr, err := sdk.AccAddressFromBech32(consumer)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	uniqueIdentifier, err := attribute(schema.UniqueIdentifier)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	uniqueID, err := strconv.ParseUint(uniqueIdentifier, 10, 64)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	description, err := attribute(schema.Description)
+	if err != nil {
+		return nil, unknownAttributes, err
+	}
+	payment = &PaymentRequest{
+		CU:                  cu,
+		BlockHeightDeadline: block,
+		Amount:              mintedCoins,
+		Client:              consumerAddr,
+		Description:         description,
+		UniqueIdentifier:    uniqueID,
+		ChainID:             chainID,
+	}
+	return payment, unknownAttributes, nil
+}
@@ -0,0 +1,47 @@
+package rewardserver
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	terderminttypes "github.com/tendermint/tendermint/abci/types"
+)
+
+func relayPaymentEvent(attributes map[string]string) terderminttypes.Event {
+	event := terderminttypes.Event{Type: "lava_relay_payment"}
+	for key, value := range attributes {
+		event.Attributes = append(event.Attributes, terderminttypes.EventAttribute{Key: []byte(key), Value: []byte(value)})
+	}
+	return event
+}
+
+func TestPaymentEventSchemas(t *testing.T) {
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	// an event of each supported schema, as the lava chain emits it
+	fixtures := map[string]map[string]string{
+		"0.0.0": {
+			"chainID": "LAV1", "client": consumer.String(), "provider": "provider", "CU": "10", "BasePay": "100ulava", "totalCUInEpoch": "30",
+			"uniqueIdentifier": "7", "descriptionString": "1234", "QoSReport": "Latency: 1.0", "QoSScore": "1.0", "clientFee": "0ulava",
+			"reliabilityPay": "false", "Mint": "100ulava", "relayNumber": "3",
+		},
+	}
+	require.Len(t, fixtures, len(PaymentEventSchemas))
+	for _, schema := range PaymentEventSchemas {
+		attributes, ok := fixtures[schema.MinProtocolVersion]
+		require.True(t, ok, schema.MinProtocolVersion)
+		payment, unknownAttributes, err := BuildPaymentFromRelayPaymentEventWithSchema(relayPaymentEvent(attributes), 50, schema)
+		require.NoError(t, err)
+		require.Empty(t, unknownAttributes)
+		require.Equal(t, &PaymentRequest{CU: 10, BlockHeightDeadline: 50, Amount: sdk.NewInt64Coin("ulava", 100), Client: consumer, UniqueIdentifier: 7, Description: "1234", ChainID: "LAV1"}, payment)
+	}
+}
+
+func TestPaymentEventSchemaMismatch(t *testing.T) {
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	// a chain that renamed an attribute, the rename shows up as unknown and the decoding fails on the missing one
+	attributes := map[string]string{"chainID": "LAV1", "client": consumer.String(), "cu": "10", "uniqueIdentifier": "7", "descriptionString": "1234", "Mint": "100ulava"}
+	_, unknownAttributes, err := BuildPaymentFromRelayPaymentEventWithSchema(relayPaymentEvent(attributes), 50, LatestPaymentEventSchema())
+	require.Error(t, err)
+	require.Equal(t, []string{"cu"}, unknownAttributes)
+}
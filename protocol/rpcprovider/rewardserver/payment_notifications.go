@@ -0,0 +1,101 @@
+package rewardserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	PaymentWebhookFlagName        = "payment-webhook"
+	PaymentCommandFlagName        = "payment-command"
+	paymentNotificationsQueueSize = 1000 // notifications waiting to be sent, newer ones are dropped when it's full
+)
+
+// PaymentNotification is posted to the webhooks and written to the command's stdin as json for every payment credited to the provider
+type PaymentNotification struct {
+	ChainID   string    `json:"chainID"`
+	Epoch     uint64    `json:"epoch"` // the epoch of the relays of the session
+	Consumer  string    `json:"consumer"`
+	SessionID uint64    `json:"sessionID"`
+	CU        uint64    `json:"cu"`
+	Amount    string    `json:"amount"`
+	Block     int64     `json:"block"` // the block the payment event was emitted in
+	Time      time.Time `json:"time"`
+}
+
+// PaymentNotifier sends the payments the payment updater credits to the webhooks and the command, one at a time so a burst of
+// payments in a block doesn't flood them
+type PaymentNotifier struct {
+	webhookURLs      []string
+	command          string
+	httpClient       *http.Client
+	queue            chan PaymentNotification
+	sendNotification func(notification PaymentNotification)
+}
+
+func NewPaymentNotifier(webhookURLs []string, command string) *PaymentNotifier {
+	pn := &PaymentNotifier{
+		webhookURLs: webhookURLs,
+		command:     command,
+		httpClient:  &http.Client{Timeout: PaymentAlertTimeout},
+		queue:       make(chan PaymentNotification, paymentNotificationsQueueSize),
+	}
+	pn.sendNotification = pn.dispatch
+	go pn.processQueue()
+	return pn
+}
+
+// paymentCredited queues the notification of the payment of the session claimed in expectedPayment, a nil notifier does nothing
+func (pn *PaymentNotifier) paymentCredited(expectedPayment PaymentRequest, payment *PaymentRequest) {
+	if pn == nil {
+		return
+	}
+	notification := PaymentNotification{
+		ChainID:   payment.ChainID,
+		Epoch:     uint64(expectedPayment.BlockHeightDeadline),
+		Consumer:  payment.Client.String(),
+		SessionID: payment.UniqueIdentifier,
+		CU:        payment.CU,
+		Amount:    payment.Amount.String(),
+		Block:     payment.BlockHeightDeadline,
+		Time:      time.Now(),
+	}
+	select {
+	case pn.queue <- notification:
+	default:
+		utils.LavaFormatWarning("payment notifications queue is full, dropping a notification", nil, utils.Attribute{Key: "notification", Value: notification})
+	}
+}
+
+func (pn *PaymentNotifier) processQueue() {
+	for notification := range pn.queue {
+		pn.sendNotification(notification)
+	}
+}
+
+func (pn *PaymentNotifier) dispatch(notification PaymentNotification) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		utils.LavaFormatError("failed marshaling payment notification", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), PaymentAlertTimeout)
+	defer cancel()
+	for _, webhookURL := range pn.webhookURLs {
+		postWebhook(ctx, pn.httpClient, webhookURL, body, "payment notification")
+	}
+	if pn.command != "" {
+		env := []string{
+			"LAVA_PAYMENT_CHAIN_ID=" + notification.ChainID,
+			"LAVA_PAYMENT_EPOCH=" + strconv.FormatUint(notification.Epoch, 10),
+			"LAVA_PAYMENT_CU=" + strconv.FormatUint(notification.CU, 10),
+			"LAVA_PAYMENT_AMOUNT=" + notification.Amount,
+		}
+		runCommand(ctx, pn.command, body, env, "payment notification")
+	}
+}
@@ -0,0 +1,64 @@
+package rewardserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentNotifications(t *testing.T) {
+	notifications := make(chan PaymentNotification, 10)
+	notifier := NewPaymentNotifier(nil, "")
+	notifier.sendNotification = func(notification PaymentNotification) { notifications <- notification }
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	rws.SetPaymentNotifier(notifier)
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 1})
+
+	payment := func(sessionID uint64) *PaymentRequest {
+		return &PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 45, Client: consumer, UniqueIdentifier: sessionID, Amount: sdk.NewInt64Coin("ulava", 5), Description: strconv.FormatUint(rws.serverID, 10)}
+	}
+	// a payment of a session that wasn't claimed isn't credited
+	rws.PaymentHandler(payment(2))
+	rws.PaymentHandler(payment(1))
+	select {
+	case notification := <-notifications:
+		require.Equal(t, PaymentNotification{ChainID: "LAV1", Epoch: 20, Consumer: consumer.String(), SessionID: 1, CU: 10, Amount: "5ulava", Block: 45, Time: notification.Time}, notification)
+	case <-time.After(time.Second):
+		require.FailNow(t, "no payment notification was sent")
+	}
+	select {
+	case notification := <-notifications:
+		require.FailNow(t, "unexpected payment notification", notification)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPaymentNotificationWebhooks(t *testing.T) {
+	received := make(chan PaymentNotification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		notification := PaymentNotification{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&notification))
+		received <- notification
+	}))
+	defer server.Close()
+	notifier := NewPaymentNotifier([]string{server.URL, server.URL}, "")
+	notifier.paymentCredited(PaymentRequest{BlockHeightDeadline: 20}, &PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 45, Client: sdk.AccAddress([]byte("consumer____________")), UniqueIdentifier: 1, Amount: sdk.NewInt64Coin("ulava", 5)})
+	// every webhook gets the payment
+	for i := 0; i < 2; i++ {
+		select {
+		case notification := <-received:
+			require.Equal(t, "LAV1", notification.ChainID)
+			require.Equal(t, uint64(20), notification.Epoch)
+			require.Equal(t, "5ulava", notification.Amount)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "webhook wasn't called")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package rewardserver
+
+import (
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	mismatchUnderpaid  = "underpaid"
+	mismatchOverpaid   = "overpaid"
+	mismatchMissing    = "missing"
+	mismatchUnexpected = "unexpected"
+)
+
+var (
+	expectedCUCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_expected_cu_total",
+		Help: "The cu of the relay proofs the provider claimed",
+	}, []string{"spec"})
+	paidCUCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_paid_cu_total",
+		Help: "The cu the provider was paid for its claims",
+	}, []string{"spec"})
+	paidRewardsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_paid_rewards_total",
+		Help: "The rewards the provider was paid for its claims",
+	}, []string{"spec", "denom"})
+	paymentMismatchCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_payment_mismatches_total",
+		Help: "Sessions whose payment didn't match the claim, by kind: underpaid, overpaid, missing or unexpected",
+	}, []string{"spec", "kind"})
+)
+
+func init() {
+	prometheus.MustRegister(expectedCUCounter, paidCUCounter, paidRewardsCounter, paymentMismatchCounter)
+}
+
+type paymentReconciliationKey struct {
+	chainID  string
+	epoch    int64
+	consumer string
+}
+
+// paymentReconciliation sums the claims of a consumer in an epoch against their payments, it's logged once all the sessions settled
+type paymentReconciliation struct {
+	expectedCU      uint64
+	paidCU          uint64
+	sessions        int
+	settledSessions int // paid or missing
+	mismatches      int
+}
+
+func reconciliationKey(payment PaymentRequest) paymentReconciliationKey {
+	return paymentReconciliationKey{chainID: payment.ChainID, epoch: payment.BlockHeightDeadline, consumer: payment.Client.String()}
+}
+
+func (rws *RewardServer) expectPaymentUnsafe(expectedPay PaymentRequest) {
+	key := reconciliationKey(expectedPay)
+	reconciliation, ok := rws.paymentReconciliations[key]
+	if !ok {
+		reconciliation = &paymentReconciliation{}
+		rws.paymentReconciliations[key] = reconciliation
+	}
+	reconciliation.expectedCU += expectedPay.CU
+	reconciliation.sessions++
+	expectedCUCounter.WithLabelValues(expectedPay.ChainID).Add(float64(expectedPay.CU))
+}
+
+// reconcilePaymentUnsafe settles the expected payment with the payment that arrived for its session
+func (rws *RewardServer) reconcilePaymentUnsafe(expectedPay PaymentRequest, payment *PaymentRequest) {
+	paidCUCounter.WithLabelValues(expectedPay.ChainID).Add(float64(payment.CU))
+	if payment.Amount.IsValid() && !payment.Amount.IsZero() {
+		amount, _ := payment.Amount.Amount.ToDec().Float64()
+		paidRewardsCounter.WithLabelValues(expectedPay.ChainID, payment.Amount.Denom).Add(amount)
+	}
+	mismatch := ""
+	switch {
+	case payment.CU < expectedPay.CU:
+		mismatch = mismatchUnderpaid
+	case payment.CU > expectedPay.CU:
+		mismatch = mismatchOverpaid
+	}
+	if mismatch != "" {
+		paymentMismatchCounter.WithLabelValues(expectedPay.ChainID, mismatch).Inc()
+		utils.LavaFormatWarning("payment doesn't match the claimed cu", nil, utils.Attribute{Key: "kind", Value: mismatch}, utils.Attribute{Key: "chainID", Value: expectedPay.ChainID}, utils.Attribute{Key: "consumer", Value: expectedPay.Client.String()}, utils.Attribute{Key: "epoch", Value: expectedPay.BlockHeightDeadline}, utils.Attribute{Key: "session", Value: expectedPay.UniqueIdentifier}, utils.Attribute{Key: "expectedCU", Value: expectedPay.CU}, utils.Attribute{Key: "paidCU", Value: payment.CU})
+	}
+	rws.settleReconciliationUnsafe(expectedPay, payment.CU, mismatch != "")
+}
+
+// reconcileMissingPaymentUnsafe settles an expected payment that can't arrive anymore
+func (rws *RewardServer) reconcileMissingPaymentUnsafe(expectedPay PaymentRequest) {
+	paymentMismatchCounter.WithLabelValues(expectedPay.ChainID, mismatchMissing).Inc()
+	rws.settleReconciliationUnsafe(expectedPay, 0, true)
+}
+
+func (rws *RewardServer) settleReconciliationUnsafe(expectedPay PaymentRequest, paidCU uint64, mismatch bool) {
+	key := reconciliationKey(expectedPay)
+	reconciliation, ok := rws.paymentReconciliations[key]
+	if !ok {
+		// expected by a server that wasn't restored
+		return
+	}
+	reconciliation.paidCU += paidCU
+	reconciliation.settledSessions++
+	if mismatch {
+		reconciliation.mismatches++
+	}
+	if reconciliation.settledSessions < reconciliation.sessions {
+		return
+	}
+	delete(rws.paymentReconciliations, key)
+	if reconciliation.mismatches > 0 {
+		utils.LavaFormatWarning("payments of the epoch don't match the claims", nil, utils.Attribute{Key: "chainID", Value: key.chainID}, utils.Attribute{Key: "consumer", Value: key.consumer}, utils.Attribute{Key: "epoch", Value: key.epoch}, utils.Attribute{Key: "expectedCU", Value: reconciliation.expectedCU}, utils.Attribute{Key: "paidCU", Value: reconciliation.paidCU}, utils.Attribute{Key: "sessions", Value: reconciliation.sessions}, utils.Attribute{Key: "mismatchedSessions", Value: reconciliation.mismatches})
+	}
+}
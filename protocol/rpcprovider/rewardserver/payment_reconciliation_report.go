@@ -0,0 +1,130 @@
+package rewardserver
+
+import (
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const RewardsReconciliationPath = "/lava/rewards/reconciliation"
+
+// ReconciledSession is a claimed session or an on chain payment that didn't reconcile
+type ReconciledSession struct {
+	ChainID       string  `json:"chainID"`
+	Consumer      string  `json:"consumer"`
+	SessionID     uint64  `json:"sessionID"`
+	Epoch         uint64  `json:"epoch,omitempty"`        // zero for payments the provider has no record of
+	ClaimedEpoch  uint64  `json:"claimedEpoch,omitempty"` // the epoch of the last claim, a claim of the latest epoch may still be in flight
+	CU            uint64  `json:"cu"`
+	Amount        string  `json:"amount,omitempty"`
+	PaymentBlocks []int64 `json:"paymentBlocks,omitempty"`
+}
+
+// PaymentReconciliationReport diffs the payments the lava chain emitted for the provider in blocks (FromBlock, ToBlock] against the
+// claims and payments the reward server recorded
+type PaymentReconciliationReport struct {
+	FromBlock  int64               `json:"fromBlock"`
+	ToBlock    int64               `json:"toBlock"`
+	Matched    int                 `json:"matched"`
+	Unpaid     []ReconciledSession `json:"unpaid"`     // claimed sessions the chain has no payment for
+	DoublePaid []ReconciledSession `json:"doublePaid"` // sessions the chain paid more than once
+	Unknown    []ReconciledSession `json:"unknown"`    // payments of sessions the provider has no claim or payment of
+}
+
+type reconciledSessionKey struct {
+	chainID   string
+	consumer  string
+	sessionID uint64
+}
+
+// ReconcilePayments builds the report of the chain payments of blocks (fromBlock, toBlock] and keeps it for the rewards api
+func (rws *RewardServer) ReconcilePayments(chainPayments []*PaymentRequest, fromBlock int64, toBlock int64) (PaymentReconciliationReport, error) {
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	paidRewards, err := rws.paidRewardsInRangeUnsafe(fromBlock+1, toBlock)
+	if err != nil {
+		return PaymentReconciliationReport{}, err
+	}
+	report := reconcilePayments(chainPayments, rws.unpaidClaims, paidRewards, fromBlock, toBlock)
+	rws.reconciliationReport = &report
+	return report, nil
+}
+
+// LastReconciliationReport is the report of the last reconciliation, nil if none ran yet
+func (rws *RewardServer) LastReconciliationReport() *PaymentReconciliationReport {
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	return rws.reconciliationReport
+}
+
+func reconcilePayments(chainPayments []*PaymentRequest, unpaidClaims map[string]*unpaidClaim, paidRewards []PaidReward, fromBlock int64, toBlock int64) PaymentReconciliationReport {
+	report := PaymentReconciliationReport{FromBlock: fromBlock, ToBlock: toBlock, Unpaid: []ReconciledSession{}, DoublePaid: []ReconciledSession{}, Unknown: []ReconciledSession{}}
+	paid := map[reconciledSessionKey]*ReconciledSession{}
+	amounts := map[reconciledSessionKey]sdk.Coins{}
+	for _, payment := range chainPayments {
+		key := reconciledSessionKey{chainID: payment.ChainID, consumer: payment.Client.String(), sessionID: payment.UniqueIdentifier}
+		session, ok := paid[key]
+		if !ok {
+			session = &ReconciledSession{ChainID: key.chainID, Consumer: key.consumer, SessionID: key.sessionID}
+			paid[key] = session
+			amounts[key] = sdk.Coins{}
+		}
+		session.CU += payment.CU
+		session.PaymentBlocks = append(session.PaymentBlocks, payment.BlockHeightDeadline)
+		if payment.Amount.IsValid() && !payment.Amount.IsZero() {
+			amounts[key] = amounts[key].Add(payment.Amount)
+		}
+	}
+	claimed := map[reconciledSessionKey]*unpaidClaim{}
+	for _, claim := range unpaidClaims {
+		claimed[reconciledSessionKey{chainID: claim.proof.SpecId, consumer: claim.consumer, sessionID: claim.proof.SessionId}] = claim
+	}
+	recorded := map[reconciledSessionKey]PaidReward{}
+	for _, paidReward := range paidRewards {
+		recorded[reconciledSessionKey{chainID: paidReward.ChainID, consumer: paidReward.Consumer, sessionID: paidReward.SessionID}] = paidReward
+	}
+
+	for key, session := range paid {
+		session.Amount = amounts[key].String()
+		if claim, ok := claimed[key]; ok {
+			session.Epoch = uint64(claim.proof.Epoch)
+			session.ClaimedEpoch = claim.claimedEpoch
+		} else if paidReward, ok := recorded[key]; ok {
+			session.Epoch = paidReward.Epoch
+		} else {
+			report.Unknown = append(report.Unknown, *session)
+			continue
+		}
+		if len(session.PaymentBlocks) > 1 {
+			report.DoublePaid = append(report.DoublePaid, *session)
+			continue
+		}
+		report.Matched++
+	}
+	for key, claim := range claimed {
+		if _, ok := paid[key]; ok {
+			continue
+		}
+		report.Unpaid = append(report.Unpaid, ReconciledSession{ChainID: key.chainID, Consumer: key.consumer, SessionID: key.sessionID, Epoch: uint64(claim.proof.Epoch), ClaimedEpoch: claim.claimedEpoch, CU: claim.proof.CuSum})
+	}
+	for _, sessions := range [][]ReconciledSession{report.Unpaid, report.DoublePaid, report.Unknown} {
+		sortReconciledSessions(sessions)
+	}
+	return report
+}
+
+// sortReconciledSessions orders the sessions by chain, epoch, consumer and session
+func sortReconciledSessions(sessions []ReconciledSession) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].ChainID != sessions[j].ChainID {
+			return sessions[i].ChainID < sessions[j].ChainID
+		}
+		if sessions[i].Epoch != sessions[j].Epoch {
+			return sessions[i].Epoch < sessions[j].Epoch
+		}
+		if sessions[i].Consumer != sessions[j].Consumer {
+			return sessions[i].Consumer < sessions[j].Consumer
+		}
+		return sessions[i].SessionID < sessions[j].SessionID
+	})
+}
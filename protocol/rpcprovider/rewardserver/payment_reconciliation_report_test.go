@@ -0,0 +1,31 @@
+package rewardserver
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcilePayments(t *testing.T) {
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	// sessions 1 and 2 are claimed and unpaid, session 3 was paid and recorded
+	for _, sessionID := range []uint64{1, 2} {
+		rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV1", SessionId: sessionID, CuSum: 10, Epoch: 20}, consumer.String(), 40)
+	}
+	rws.savePaidReward(PaymentRequest{BlockHeightDeadline: 20}, &PaymentRequest{ChainID: "LAV1", Client: consumer, UniqueIdentifier: 3, CU: 10, BlockHeightDeadline: 45, Amount: sdk.NewInt64Coin("ulava", 5)})
+
+	payment := func(sessionID uint64, block int64) *PaymentRequest {
+		return &PaymentRequest{ChainID: "LAV1", Client: consumer, UniqueIdentifier: sessionID, CU: 10, BlockHeightDeadline: block, Amount: sdk.NewInt64Coin("ulava", 5)}
+	}
+	// session 1 is paid, session 3 is paid twice and session 4 was never claimed
+	report, err := rws.ReconcilePayments([]*PaymentRequest{payment(1, 41), payment(3, 45), payment(3, 46), payment(4, 47)}, 40, 50)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Matched)
+	require.Equal(t, []ReconciledSession{{ChainID: "LAV1", Consumer: consumer.String(), SessionID: 2, Epoch: 20, ClaimedEpoch: 40, CU: 10}}, report.Unpaid)
+	require.Equal(t, []ReconciledSession{{ChainID: "LAV1", Consumer: consumer.String(), SessionID: 3, Epoch: 20, CU: 20, Amount: "10ulava", PaymentBlocks: []int64{45, 46}}}, report.DoublePaid)
+	require.Equal(t, []ReconciledSession{{ChainID: "LAV1", Consumer: consumer.String(), SessionID: 4, CU: 10, Amount: "5ulava", PaymentBlocks: []int64{47}}}, report.Unknown)
+	require.Equal(t, &report, rws.LastReconciliationReport())
+}
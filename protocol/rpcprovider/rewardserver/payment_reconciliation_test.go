@@ -0,0 +1,68 @@
+package rewardserver
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentReconciliation(t *testing.T) {
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	mismatches := func(kind string) float64 {
+		return testutil.ToFloat64(paymentMismatchCounter.WithLabelValues("REC1", kind))
+	}
+	underpaid, overpaid := mismatches(mismatchUnderpaid), mismatches(mismatchOverpaid)
+	expectedCU := testutil.ToFloat64(expectedCUCounter.WithLabelValues("REC1"))
+	paidRewards := testutil.ToFloat64(paidRewardsCounter.WithLabelValues("REC1", "ulava"))
+	for sessionID := uint64(1); sessionID <= 3; sessionID++ {
+		rws.addExpectedPayment(PaymentRequest{ChainID: "REC1", CU: 10, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: sessionID})
+	}
+	require.Equal(t, expectedCU+30, testutil.ToFloat64(expectedCUCounter.WithLabelValues("REC1")))
+	key := paymentReconciliationKey{chainID: "REC1", epoch: 20, consumer: consumer.String()}
+	require.Equal(t, &paymentReconciliation{expectedCU: 30, sessions: 3}, rws.paymentReconciliations[key])
+
+	_, removed := rws.removeExpectedPayment(&PaymentRequest{ChainID: "REC1", CU: 10, Client: consumer, UniqueIdentifier: 1, Amount: sdk.NewCoin("ulava", sdk.NewInt(4))})
+	require.True(t, removed)
+	_, removed = rws.removeExpectedPayment(&PaymentRequest{ChainID: "REC1", CU: 7, Client: consumer, UniqueIdentifier: 2})
+	require.True(t, removed)
+	require.Equal(t, underpaid+1, mismatches(mismatchUnderpaid))
+	require.Equal(t, paidRewards+4, testutil.ToFloat64(paidRewardsCounter.WithLabelValues("REC1", "ulava")))
+	require.Equal(t, &paymentReconciliation{expectedCU: 30, paidCU: 17, sessions: 3, settledSessions: 2, mismatches: 1}, rws.paymentReconciliations[key])
+
+	// the epoch is reconciled once all its sessions settled
+	_, removed = rws.removeExpectedPayment(&PaymentRequest{ChainID: "REC1", CU: 12, Client: consumer, UniqueIdentifier: 3})
+	require.True(t, removed)
+	require.Equal(t, overpaid+1, mismatches(mismatchOverpaid))
+	require.NotContains(t, rws.paymentReconciliations, key)
+}
+
+func TestPaymentReconciliationMissingAndUnexpected(t *testing.T) {
+	ctx := context.Background()
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	txSender := &fakeRewardsTxSender{}
+	rws := NewRewardServer(txSender, ClaimThresholds{})
+	mismatches := func(kind string) float64 {
+		return testutil.ToFloat64(paymentMismatchCounter.WithLabelValues("REC2", kind))
+	}
+	missing, unexpected := mismatches(mismatchMissing), mismatches(mismatchUnexpected)
+	rws.addExpectedPayment(PaymentRequest{ChainID: "REC2", CU: 10, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 1})
+
+	// a payment of this server that wasn't expected
+	rws.PaymentHandler(&PaymentRequest{ChainID: "REC2", CU: 10, Client: consumer, UniqueIdentifier: 2, Description: strconv.FormatUint(rws.serverID, 10)})
+	require.Equal(t, unexpected+1, mismatches(mismatchUnexpected))
+	// payments of other servers aren't reconciled
+	rws.PaymentHandler(&PaymentRequest{ChainID: "REC2", CU: 10, Client: consumer, UniqueIdentifier: 2, Description: strconv.FormatUint(rws.serverID+1, 10)})
+	require.Equal(t, unexpected+1, mismatches(mismatchUnexpected))
+
+	// a payment that can't arrive anymore settles the epoch as missing
+	txSender.earliestBlockInMemory = 21
+	_, err := rws.identifyMissingPayments(ctx)
+	require.NoError(t, err)
+	require.Equal(t, missing+1, mismatches(mismatchMissing))
+	require.Empty(t, rws.paymentReconciliations)
+}
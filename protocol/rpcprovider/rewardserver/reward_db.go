@@ -0,0 +1,248 @@
+package rewardserver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+const (
+	RewardDBPathFlagName    = "reward-db-path"
+	RewardDBBackendFlagName = "reward-db-backend"
+	DefaultRewardDBBackend  = string(dbm.GoLevelDBBackend)
+	rewardDBName            = "rewards"
+	proofKeyPrefix          = "proof/"
+	dataReliabilityPrefix   = "dr/"
+	claimKeyPrefix          = "claim/"
+	serverIDKey             = "server-id"
+)
+
+// TmRewardDB is the on-disk RewardStore, on a tendermint key value store. keys are ordered by epoch so old epochs are compacted with a range scan
+type TmRewardDB struct {
+	db dbm.DB
+}
+
+func NewTmRewardDB(db dbm.DB) *TmRewardDB {
+	return &TmRewardDB{db: db}
+}
+
+// OpenRewardDB opens the reward db in dir with one of the tm-db backends, goleveldb if backend is empty
+func OpenRewardDB(dir string, backend string) (*TmRewardDB, error) {
+	if backend == "" {
+		backend = DefaultRewardDBBackend
+	}
+	db, err := dbm.NewDB(rewardDBName, dbm.BackendType(backend), dir)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed opening the reward db", err, utils.Attribute{Key: "dir", Value: dir}, utils.Attribute{Key: "backend", Value: backend})
+	}
+	return NewTmRewardDB(db), nil
+}
+
+// proof keys are proof/epoch/consumer/spec/session/consumer rewards key, the rewards key is last since payments don't carry the api interface in it
+func proofKey(epoch uint64, consumer string, specID string, sessionID uint64, consumerRewardsKey string) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%s/%s/%d/%s", proofKeyPrefix, epoch, consumer, specID, sessionID, consumerRewardsKey))
+}
+
+// claim keys are claim/epoch/consumer/spec/session, the key of the expected payment
+func unpaidClaimDBKey(epoch uint64, consumer string, specID string, sessionID uint64) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%s/%s/%d", claimKeyPrefix, epoch, consumer, specID, sessionID))
+}
+
+func dataReliabilityKey(epoch uint64, consumer string, specID string, consumerRewardsKey string) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%s/%s/%s", dataReliabilityPrefix, epoch, consumer, specID, consumerRewardsKey))
+}
+
+// parseRewardKey returns the epoch, consumer and consumer rewards key of a proof or data reliability key without its prefix
+func parseRewardKey(key string, isProof bool) (epoch uint64, consumer string, consumerRewardsKey string, err error) {
+	parts := strings.Split(key, "/")
+	expectedParts := 4
+	if isProof {
+		expectedParts = 5
+	}
+	if len(parts) != expectedParts {
+		return 0, "", "", fmt.Errorf("invalid reward db key %q", key)
+	}
+	epoch, err = strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return epoch, parts[1], parts[expectedParts-1], nil
+}
+
+func (trd *TmRewardDB) SaveProof(epoch uint64, consumerRewardsKey string, consumer string, proof *pairingtypes.RelaySession) error {
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return err
+	}
+	return trd.db.Set(proofKey(epoch, consumer, proof.SpecId, proof.SessionId, consumerRewardsKey), proofBytes)
+}
+
+func (trd *TmRewardDB) SaveDataReliabilityProof(epoch uint64, consumerRewardsKey string, consumer string, specID string, dataReliability *pairingtypes.VRFData) error {
+	proofBytes, err := dataReliability.Marshal()
+	if err != nil {
+		return err
+	}
+	return trd.db.Set(dataReliabilityKey(epoch, consumer, specID, consumerRewardsKey), proofBytes)
+}
+
+func (trd *TmRewardDB) DeleteProof(epoch uint64, consumer string, specID string, sessionID uint64) error {
+	prefix := proofKey(epoch, consumer, specID, sessionID, "")
+	return trd.deleteRange(prefix, prefixEnd(prefix))
+}
+
+func (trd *TmRewardDB) DeleteDataReliabilityProof(epoch uint64, consumerRewardsKey string, consumer string, specID string) error {
+	return trd.db.Delete(dataReliabilityKey(epoch, consumer, specID, consumerRewardsKey))
+}
+
+func (trd *TmRewardDB) DeleteEpochsBefore(epoch uint64) (deleted int, err error) {
+	for _, prefix := range []string{proofKeyPrefix, dataReliabilityPrefix, claimKeyPrefix} {
+		end := []byte(fmt.Sprintf("%s%020d", prefix, epoch))
+		keys, err := trd.keysInRange([]byte(prefix), end)
+		if err != nil {
+			return deleted, err
+		}
+		for _, key := range keys {
+			err = trd.db.Delete(key)
+			if err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (trd *TmRewardDB) LoadRewards() ([]*ConsumerRewardsSnapshot, error) {
+	rewards := map[string]*ConsumerRewardsSnapshot{} // key is the epoch and the consumer rewards key
+	consumerRewards := func(epoch uint64, consumer string, key string) *ConsumerRewardsSnapshot {
+		snapshotKey := strconv.FormatUint(epoch, 10) + "/" + key
+		snapshot, ok := rewards[snapshotKey]
+		if !ok {
+			snapshot = &ConsumerRewardsSnapshot{Epoch: epoch, Key: key, Consumer: consumer}
+			rewards[snapshotKey] = snapshot
+		}
+		return snapshot
+	}
+	for _, prefix := range []string{proofKeyPrefix, dataReliabilityPrefix} {
+		iterator, err := trd.db.Iterator([]byte(prefix), prefixEnd([]byte(prefix)))
+		if err != nil {
+			return nil, err
+		}
+		for ; iterator.Valid(); iterator.Next() {
+			epoch, consumer, key, err := parseRewardKey(strings.TrimPrefix(string(iterator.Key()), prefix), prefix == proofKeyPrefix)
+			if err != nil {
+				utils.LavaFormatWarning("skipping invalid reward db entry", err)
+				continue
+			}
+			snapshot := consumerRewards(epoch, consumer, key)
+			value := append([]byte{}, iterator.Value()...)
+			if prefix == proofKeyPrefix {
+				snapshot.Proofs = append(snapshot.Proofs, value)
+			} else {
+				snapshot.DataReliabilityProofs = append(snapshot.DataReliabilityProofs, value)
+			}
+		}
+		err = iterator.Error()
+		iterator.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := make([]*ConsumerRewardsSnapshot, 0, len(rewards))
+	for _, snapshot := range rewards {
+		result = append(result, snapshot)
+	}
+	return result, nil
+}
+
+func (trd *TmRewardDB) SaveClaim(claim ClaimSnapshot) error {
+	value, err := encodeClaim(claim)
+	if err != nil {
+		return err
+	}
+	return trd.db.Set(unpaidClaimDBKey(uint64(claim.Proof.Epoch), claim.Consumer, claim.Proof.SpecId, claim.Proof.SessionId), value)
+}
+
+func (trd *TmRewardDB) DeleteClaim(epoch uint64, consumer string, specID string, sessionID uint64) error {
+	return trd.db.Delete(unpaidClaimDBKey(epoch, consumer, specID, sessionID))
+}
+
+func (trd *TmRewardDB) LoadClaims() ([]ClaimSnapshot, error) {
+	iterator, err := trd.db.Iterator([]byte(claimKeyPrefix), prefixEnd([]byte(claimKeyPrefix)))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	claims := []ClaimSnapshot{}
+	for ; iterator.Valid(); iterator.Next() {
+		claim, err := decodeClaim(iterator.Value())
+		if err != nil {
+			utils.LavaFormatWarning("skipping invalid unpaid claim in the reward db", err, utils.Attribute{Key: "key", Value: string(iterator.Key())})
+			continue
+		}
+		claims = append(claims, claim)
+	}
+	return claims, iterator.Error()
+}
+
+func (trd *TmRewardDB) SaveServerID(serverID uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, serverID)
+	return trd.db.SetSync([]byte(serverIDKey), value)
+}
+
+func (trd *TmRewardDB) LoadServerID() (serverID uint64, found bool, err error) {
+	value, err := trd.db.Get([]byte(serverIDKey))
+	if err != nil || len(value) != 8 {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(value), true, nil
+}
+
+func (trd *TmRewardDB) Close() error {
+	return trd.db.Close()
+}
+
+func (trd *TmRewardDB) deleteRange(start []byte, end []byte) error {
+	keys, err := trd.keysInRange(start, end)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		err = trd.db.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the keys are collected before deleting, some backends don't allow writes while iterating
+func (trd *TmRewardDB) keysInRange(start []byte, end []byte) (keys [][]byte, err error) {
+	iterator, err := trd.db.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	for ; iterator.Valid(); iterator.Next() {
+		keys = append(keys, append([]byte{}, iterator.Key()...))
+	}
+	return keys, iterator.Error()
+}
+
+// prefixEnd is the first key after all the keys starting with prefix
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for idx := len(end) - 1; idx >= 0; idx-- {
+		if end[idx] < 0xff {
+			end[idx]++
+			return end[:idx+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rewardserver
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestRewardDBRestoresProofs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rewardDB, err := OpenRewardDB(dir, "")
+	require.NoError(t, err)
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	require.NoError(t, rws.SetRewardStore(rewardDB))
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 1, CuSum: 10, Epoch: 20, Sig: []byte("sig1")}, 20, consumer.String(), "jsonrpc")
+	require.NoError(t, err)
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 2, CuSum: 30, Epoch: 20, Sig: []byte("sig2")}, 20, consumer.String(), "jsonrpc")
+	require.NoError(t, err)
+	_, _, err = rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 2, RelayNum: 1, CuSum: 5, Epoch: 20, Sig: []byte("sig3")}, 20, consumer.String(), "jsonrpc")
+	require.NoError(t, err)
+	rws.SendNewDataReliabilityProof(ctx, &pairingtypes.VRFData{ChainId: "LAV1", Epoch: 20}, 20, consumer.String(), "LAV1", "jsonrpc", "")
+	require.NoError(t, rewardDB.Close())
+
+	// a provider restarting after a crash gets the unpaid proofs back, with the latest cu of every session
+	rewardDB, err = OpenRewardDB(dir, "")
+	require.NoError(t, err)
+	defer rewardDB.Close()
+	restarted := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	require.NoError(t, restarted.SetRewardStore(rewardDB))
+	require.Equal(t, rws.serverID, restarted.serverID)
+	consumerRewards := restarted.rewards[20].consumerRewards[getKeyForConsumerRewards("LAV1", "jsonrpc", consumer.String(), "")]
+	require.NotNil(t, consumerRewards)
+	require.Len(t, consumerRewards.proofs, 2)
+	require.Equal(t, uint64(30), consumerRewards.proofs[1].CuSum)
+	require.Len(t, consumerRewards.dataReliabilityProofs, 1)
+
+	// a paid proof is deleted from the db
+	restarted.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 30, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 1})
+	restarted.PaymentHandler(&PaymentRequest{ChainID: "LAV1", CU: 30, BlockHeightDeadline: 45, Client: consumer, UniqueIdentifier: 1, Amount: sdk.NewCoin("ulava", sdk.NewInt(30)), Description: strconv.FormatUint(restarted.serverID, 10)})
+	rewards, err := rewardDB.LoadRewards()
+	require.NoError(t, err)
+	require.Len(t, rewards, 1)
+	require.Len(t, rewards[0].Proofs, 1)
+	proof := &pairingtypes.RelaySession{}
+	require.NoError(t, proof.Unmarshal(rewards[0].Proofs[0]))
+	require.Equal(t, uint64(2), proof.SessionId)
+}
+
+func TestRewardDBSkipsInvalidEntries(t *testing.T) {
+	db := dbm.NewMemDB()
+	rewardDB := NewTmRewardDB(db)
+	require.NoError(t, rewardDB.SaveProof(20, "key", "consumer", &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1}))
+	require.NoError(t, db.Set([]byte(proofKeyPrefix+"not-an-epoch/consumer/LAV1/1/key"), []byte{}))
+	require.NoError(t, db.Set([]byte(proofKeyPrefix+"20/consumer"), []byte{}))
+	require.NoError(t, db.Set([]byte(claimKeyPrefix+"20/consumer/LAV1/1"), []byte("not a claim")))
+	rewards, err := rewardDB.LoadRewards()
+	require.NoError(t, err)
+	require.Len(t, rewards, 1)
+	require.Equal(t, "consumer", rewards[0].Consumer)
+	claims, err := rewardDB.LoadClaims()
+	require.NoError(t, err)
+	require.Empty(t, claims)
+}
+
+func TestRewardDBKeys(t *testing.T) {
+	require.Equal(t, []byte("proof0"), prefixEnd([]byte("proof/")))
+	require.Equal(t, []byte("b"), prefixEnd([]byte{'a', 0xff}))
+	require.Nil(t, prefixEnd([]byte{0xff}))
+
+	// a session's prefix doesn't match the sessions starting with its digits
+	rewardDB := NewTmRewardDB(dbm.NewMemDB())
+	require.NoError(t, rewardDB.SaveProof(20, "key", "consumer", &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1}))
+	require.NoError(t, rewardDB.SaveProof(20, "key", "consumer", &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 12}))
+	require.NoError(t, rewardDB.DeleteProof(20, "consumer", "LAV1", 1))
+	rewards, err := rewardDB.LoadRewards()
+	require.NoError(t, err)
+	require.Len(t, rewards[0].Proofs, 1)
+
+	// epochs are zero padded so the keys sort by epoch
+	require.Less(t, string(proofKey(9, "consumer", "LAV1", 1, "key")), string(proofKey(10, "consumer", "LAV1", 1, "key")))
+}
@@ -0,0 +1,127 @@
+package rewardserver
+
+import (
+	"context"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+const RewardsEstimatePath = "/lava/rewards/estimate"
+
+type claimEstimateKey struct {
+	chainID  string
+	consumer string
+	epoch    uint64
+}
+
+// ChainRewardsEstimate is the expected payout of the proofs held for a chain
+type ChainRewardsEstimate struct {
+	ChainID               string `json:"chainID"`
+	Sessions              int    `json:"sessions"`
+	CU                    uint64 `json:"cu"`
+	DataReliabilityProofs int    `json:"dataReliabilityProofs"`
+	Amount                string `json:"amount"`
+}
+
+// RewardsEstimate is the payout the held proofs would get if they were claimed now, with the pairing params it was computed with
+type RewardsEstimate struct {
+	MintCoinsPerCU        string                 `json:"mintCoinsPerCU"`
+	QoSWeight             string                 `json:"qosWeight"`
+	DataReliabilityReward string                 `json:"dataReliabilityReward"`
+	Chains                []ChainRewardsEstimate `json:"chains"`
+	Total                 string                 `json:"total"`
+}
+
+// EstimateRewards computes the payout of the proofs the server holds with the current pairing params without claiming them, an empty
+// chainID estimates all chains. the stake of the consumer only caps its cu per epoch, which is enforced when relays are served, so the
+// estimate is the upper bound the chain would pay
+func (rws *RewardServer) EstimateRewards(ctx context.Context, chainID string) (*RewardsEstimate, error) {
+	params, err := rws.rewardsTxSender.GetPairingParams(ctx)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed fetching pairing params for the rewards estimate", err)
+	}
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	return rws.estimateRewardsUnsafe(*params, chainID), nil
+}
+
+func (rws *RewardServer) estimateRewardsUnsafe(params pairingtypes.Params, chainID string) *RewardsEstimate {
+	// the claim is aggregated like a real one, so sessions served on several api interfaces and unused data reliability proofs aren't counted
+	aggregators := map[claimEstimateKey]*claimAggregator{}
+	for epoch, epochRewards := range rws.rewards {
+		for _, consumerRewards := range epochRewards.consumerRewards {
+			consumerChainID := consumerRewards.chainID()
+			if consumerChainID == "" || (chainID != "" && chainID != consumerChainID) {
+				continue
+			}
+			claimables, dataReliabilities, err := consumerRewards.PrepareRewardsForClaim()
+			if err != nil {
+				continue
+			}
+			key := claimEstimateKey{chainID: consumerChainID, consumer: consumerRewards.consumer, epoch: epoch}
+			aggregator, ok := aggregators[key]
+			if !ok {
+				aggregator = newClaimAggregator()
+				aggregators[key] = aggregator
+			}
+			aggregator.add(consumerRewards.consumer, epoch, claimables, dataReliabilities)
+		}
+	}
+	chains := map[string]*ChainRewardsEstimate{}
+	amounts := map[string]sdk.Int{}
+	for key, aggregator := range aggregators {
+		relays, dataReliabilityProofs := aggregator.claim(key.chainID)
+		chain, ok := chains[key.chainID]
+		if !ok {
+			chain = &ChainRewardsEstimate{ChainID: key.chainID}
+			chains[key.chainID] = chain
+			amounts[key.chainID] = sdk.ZeroInt()
+		}
+		chain.DataReliabilityProofs += len(dataReliabilityProofs)
+		for idx, relay := range relays {
+			chain.Sessions++
+			chain.CU += relay.CuSum
+			// the data reliability proof of the consumer's epoch adds its reward to one of the relays
+			amounts[key.chainID] = amounts[key.chainID].Add(estimateRelayReward(params, relay, idx < len(dataReliabilityProofs)))
+		}
+	}
+	estimate := &RewardsEstimate{
+		MintCoinsPerCU:        params.MintCoinsPerCU.String(),
+		QoSWeight:             params.QoSWeight.String(),
+		DataReliabilityReward: params.DataReliabilityReward.String(),
+		Chains:                []ChainRewardsEstimate{},
+	}
+	total := sdk.ZeroInt()
+	for chainID, chain := range chains {
+		chain.Amount = sdk.NewCoin(epochstoragetypes.TokenDenom, amounts[chainID]).String()
+		total = total.Add(amounts[chainID])
+		estimate.Chains = append(estimate.Chains, *chain)
+	}
+	sort.Slice(estimate.Chains, func(i, j int) bool { return estimate.Chains[i].ChainID < estimate.Chains[j].ChainID })
+	estimate.Total = sdk.NewCoin(epochstoragetypes.TokenDenom, total).String()
+	return estimate
+}
+
+// estimateRelayReward mirrors the mint of the pairing module's relay payment
+func estimateRelayReward(params pairingtypes.Params, relay *pairingtypes.RelaySession, dataReliability bool) sdk.Int {
+	reward := params.MintCoinsPerCU.MulInt64(int64(relay.CuSum))
+	if reward.IsZero() {
+		return sdk.ZeroInt()
+	}
+	if relay.QosReport != nil {
+		qos, err := relay.QosReport.ComputeQoS()
+		if err != nil {
+			// the chain rejects a relay with a bad qos report
+			return sdk.ZeroInt()
+		}
+		reward = reward.Mul(qos.Mul(params.QoSWeight).Add(sdk.OneDec().Sub(params.QoSWeight)))
+	}
+	if dataReliability {
+		reward = reward.Add(reward.Mul(params.DataReliabilityReward))
+	}
+	return reward.TruncateInt()
+}
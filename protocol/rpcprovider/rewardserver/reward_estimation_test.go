@@ -0,0 +1,38 @@
+package rewardserver
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateRewards(t *testing.T) {
+	params := pairingtypes.DefaultParams()
+	params.MintCoinsPerCU = sdk.NewDec(2)
+	params.QoSWeight = sdk.NewDecWithPrec(5, 1)
+	params.DataReliabilityReward = sdk.NewDecWithPrec(1, 1)
+
+	rws := NewRewardServer(nil, ClaimThresholds{})
+	rws.rewards[20] = &EpochRewards{epoch: 20, consumerRewards: map[string]*ConsumerRewards{
+		// the same session on two api interfaces is paid once
+		"LAV1rest": {epoch: 20, consumer: "consumer1", proofs: map[uint64]*pairingtypes.RelaySession{1: {SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 20}}, dataReliabilityProofs: []*pairingtypes.VRFData{{Epoch: 20}}},
+		"LAV1grpc": {epoch: 20, consumer: "consumer1", proofs: map[uint64]*pairingtypes.RelaySession{1: {SpecId: "LAV1", SessionId: 1, CuSum: 20, Epoch: 20}}},
+		"ETH1": {epoch: 20, consumer: "consumer2", proofs: map[uint64]*pairingtypes.RelaySession{
+			1: {SpecId: "ETH1", SessionId: 1, CuSum: 10, Epoch: 20, QosReport: &pairingtypes.QualityOfServiceReport{Latency: sdk.ZeroDec(), Availability: sdk.ZeroDec(), Sync: sdk.ZeroDec()}},
+		}},
+	}}
+
+	estimate := rws.estimateRewardsUnsafe(params, "")
+	require.Len(t, estimate.Chains, 2)
+	// 10 cu at 2 per cu with a zero qos score weighted by half
+	require.Equal(t, ChainRewardsEstimate{ChainID: "ETH1", Sessions: 1, CU: 10, Amount: "10ulava"}, estimate.Chains[0])
+	// 20 cu at 2 per cu and a tenth more for the data reliability proof
+	require.Equal(t, ChainRewardsEstimate{ChainID: "LAV1", Sessions: 1, CU: 20, DataReliabilityProofs: 1, Amount: "44ulava"}, estimate.Chains[1])
+	require.Equal(t, "54ulava", estimate.Total)
+
+	estimate = rws.estimateRewardsUnsafe(params, "LAV1")
+	require.Len(t, estimate.Chains, 1)
+	require.Equal(t, "44ulava", estimate.Total)
+}
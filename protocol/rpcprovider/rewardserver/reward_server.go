@@ -49,31 +49,85 @@ func (csrw *ConsumerRewards) PrepareRewardsForClaim() (retProofs []*pairingtypes
 	return
 }
 
+// provider returns the provider address that served the proofs, empty if there are only data reliability proofs
+func (csrw *ConsumerRewards) provider() string {
+	for _, proof := range csrw.proofs {
+		return proof.Provider
+	}
+	return ""
+}
+
+// chainID returns the spec of the proofs, empty if there are only data reliability proofs
+func (csrw *ConsumerRewards) chainID() string {
+	for _, proof := range csrw.proofs {
+		return proof.SpecId
+	}
+	return ""
+}
+
 type EpochRewards struct {
 	epoch           uint64
 	consumerRewards map[string]*ConsumerRewards // key is consumer
+	virtualEpoch    uint64                      // epochs lava downtime added to this epoch, its proofs can hold more than an epoch of cu
 }
 
 type RewardServer struct {
-	rewardsTxSender  RewardsTxSender
-	lock             sync.RWMutex
-	rewards          map[uint64]*EpochRewards
-	serverID         uint64
-	expectedPayments []PaymentRequest
-	totalCUServiced  uint64
-	totalCUPaid      uint64
+	rewardsTxSender        RewardsTxSender
+	lock                   sync.RWMutex
+	rewards                map[uint64]*EpochRewards
+	serverID               uint64
+	expectedPayments       []PaymentRequest
+	totalCUServiced        uint64
+	totalCUPaid            uint64
+	rewardStore            RewardStore // nil if the proofs are only held in memory
+	claimThresholds        ClaimThresholds
+	unpaidClaims           map[string]*unpaidClaim // key is the chain, consumer, epoch and session of the payment
+	paymentReconciliations map[paymentReconciliationKey]*paymentReconciliation
+	paidHistory            map[chainEpochKey]*paidRewards
+	proofSignatures        map[uint64]map[string]string // epoch to the signatures of the accepted proofs and the proof they signed
+	chainPayments          map[string]*chainPayments
+	paymentAlerts          *PaymentAlerts
+	paymentNotifier        *PaymentNotifier       // nil without payment webhooks or a command
+	claimSigners           map[string]ClaimSigner // provider address to the signer of its claims, the rewards tx sender signs for the rest
+	paidRewards            []PaidReward           // only kept in memory without a reward db
+	claimQueueLimits       ClaimQueueLimits
+	signedFinalizations    map[uint64]map[string]*signedFinalization // epoch to the consumer rewards key
+	reconciliationReport   *PaymentReconciliationReport
 }
 
 type RewardsTxSender interface {
 	TxRelayPayment(ctx context.Context, relayRequests []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData, description string) error
 	GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error)
 	EarliestBlockInMemory(ctx context.Context) (uint64, error)
+	GetPairingParams(ctx context.Context) (*pairingtypes.Params, error)
+	GetBlockMaxBytes(ctx context.Context) (int64, error)
 }
 
-func (rws *RewardServer) SendNewProof(ctx context.Context, proof *pairingtypes.RelaySession, epoch uint64, consumerAddr string, apiInterface string) (existingCU uint64, updatedWithProof bool) {
+func (rws *RewardServer) SendNewProof(ctx context.Context, proof *pairingtypes.RelaySession, epoch uint64, consumerAddr string, apiInterface string) (existingCU uint64, updatedWithProof bool, err error) {
 	rws.lock.Lock() // assuming 99% of the time we will need to write the new entry so there's no use in doing the read lock first to check stuff
 	defer rws.lock.Unlock()
-	consumerRewardsKey := getKeyForConsumerRewards(proof.SpecId, apiInterface, consumerAddr)
+	consumerRewardsKey := getKeyForConsumerRewards(proof.SpecId, apiInterface, consumerAddr, proof.Provider)
+	if reason := rws.duplicateProofUnsafe(proof, epoch, consumerRewardsKey); reason != "" {
+		rejectedProofsCounter.WithLabelValues(proof.SpecId, reason).Inc()
+		return 0, false, utils.LavaFormatWarning("rejected duplicate relay proof", lavasession.DuplicateRelayProofError, utils.Attribute{Key: "reason", Value: reason}, utils.Attribute{Key: "consumer", Value: consumerAddr},
+			utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "session", Value: proof.SessionId}, utils.Attribute{Key: "relayNum", Value: proof.RelayNum})
+	}
+	existingCU, updatedWithProof = rws.addProofUnsafe(proof, epoch, consumerAddr, apiInterface)
+	if updatedWithProof {
+		rws.recordProofSignatureUnsafe(proof, epoch, consumerRewardsKey)
+	}
+	if updatedWithProof && rws.rewardStore != nil {
+		// written under the lock, so a proof with a lower cu sum never overwrites a newer one
+		err := rws.rewardStore.SaveProof(epoch, consumerRewardsKey, consumerAddr, proof)
+		if err != nil {
+			utils.LavaFormatError("failed persisting relay proof, it's lost if the provider crashes before claiming it", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerAddr})
+		}
+	}
+	return existingCU, updatedWithProof, nil
+}
+
+func (rws *RewardServer) addProofUnsafe(proof *pairingtypes.RelaySession, epoch uint64, consumerAddr string, apiInterface string) (existingCU uint64, updatedWithProof bool) {
+	consumerRewardsKey := getKeyForConsumerRewards(proof.SpecId, apiInterface, consumerAddr, proof.Provider)
 	epochRewards, ok := rws.rewards[epoch]
 	if !ok {
 		proofs := map[uint64]*pairingtypes.RelaySession{proof.SessionId: proof}
@@ -101,10 +155,22 @@ func (rws *RewardServer) SendNewProof(ctx context.Context, proof *pairingtypes.R
 	return 0, true
 }
 
-func (rws *RewardServer) SendNewDataReliabilityProof(ctx context.Context, dataReliability *pairingtypes.VRFData, epoch uint64, consumerAddr string, specId string, apiInterface string) (updatedWithProof bool) {
+// SendNewDataReliabilityProof stores the data reliability proof of the consumer with the relays it served providerAddr
+func (rws *RewardServer) SendNewDataReliabilityProof(ctx context.Context, dataReliability *pairingtypes.VRFData, epoch uint64, consumerAddr string, specId string, apiInterface string, providerAddr string) (updatedWithProof bool) {
 	rws.lock.Lock() // assuming 99% of the time we will need to write the new entry so there's no use in doing the read lock first to check stuff
 	defer rws.lock.Unlock()
-	consumerRewardsKey := getKeyForConsumerRewards(specId, apiInterface, consumerAddr)
+	updatedWithProof = rws.addDataReliabilityProofUnsafe(dataReliability, epoch, consumerAddr, specId, apiInterface, providerAddr)
+	if updatedWithProof && rws.rewardStore != nil {
+		err := rws.rewardStore.SaveDataReliabilityProof(epoch, getKeyForConsumerRewards(specId, apiInterface, consumerAddr, providerAddr), consumerAddr, specId, dataReliability)
+		if err != nil {
+			utils.LavaFormatError("failed persisting data reliability proof", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerAddr})
+		}
+	}
+	return updatedWithProof
+}
+
+func (rws *RewardServer) addDataReliabilityProofUnsafe(dataReliability *pairingtypes.VRFData, epoch uint64, consumerAddr string, specId string, apiInterface string, providerAddr string) (updatedWithProof bool) {
+	consumerRewardsKey := getKeyForConsumerRewards(specId, apiInterface, consumerAddr, providerAddr)
 	epochRewards, ok := rws.rewards[epoch]
 	if !ok {
 		consumerRewardsMap := map[string]*ConsumerRewards{(consumerRewardsKey): {epoch: epoch, consumer: consumerAddr, proofs: map[uint64]*pairingtypes.RelaySession{}, dataReliabilityProofs: []*pairingtypes.VRFData{dataReliability}}}
@@ -127,33 +193,76 @@ func (rws *RewardServer) SendNewDataReliabilityProof(ctx context.Context, dataRe
 func (rws *RewardServer) UpdateEpoch(epoch uint64) {
 	ctx := context.Background()
 	_ = rws.sendRewardsClaim(ctx, epoch)
+	_ = rws.reclaimUnpaidProofs(ctx, epoch)
 	_, _ = rws.identifyMissingPayments(ctx)
+	rws.paymentAlerts.epochPassed(epoch)
+}
+
+// SetClaimQueueLimits sets how the claims are split into transactions and throttled
+func (rws *RewardServer) SetClaimQueueLimits(limits ClaimQueueLimits) {
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	rws.claimQueueLimits = limits
+}
+
+// SetPaymentAlerts replaces the default payment alerts, that are only logged
+func (rws *RewardServer) SetPaymentAlerts(paymentAlerts *PaymentAlerts) {
+	rws.paymentAlerts = paymentAlerts
+}
+
+func (rws *RewardServer) SetPaymentNotifier(paymentNotifier *PaymentNotifier) {
+	rws.paymentNotifier = paymentNotifier
+}
+
+// UpdateVirtualEpoch marks the rewards of epoch as extended by lava downtime, consumers were allowed virtualEpoch more epochs of compute units
+func (rws *RewardServer) UpdateVirtualEpoch(epoch uint64, virtualEpoch uint64) {
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	epochRewards, ok := rws.rewards[epoch]
+	if !ok {
+		epochRewards = &EpochRewards{epoch: epoch, consumerRewards: map[string]*ConsumerRewards{}}
+		rws.rewards[epoch] = epochRewards
+	}
+	if virtualEpoch > epochRewards.virtualEpoch {
+		epochRewards.virtualEpoch = virtualEpoch
+	}
 }
 
 func (rws *RewardServer) sendRewardsClaim(ctx context.Context, epoch uint64) error {
-	rewardsToClaim, dataReliabilityProofs, err := rws.gatherRewardsForClaim(ctx, epoch)
+	claims, err := rws.gatherRewardsForClaim(ctx, epoch)
 	if err != nil {
 		return err
 	}
-	for _, relay := range rewardsToClaim {
-		consumerAddr, err := sigs.ExtractSignerAddress(relay)
-		if err != nil {
-			utils.LavaFormatError("invalid consumer address extraction from relay", err, utils.Attribute{Key: "relay", Value: relay})
+	if len(claims) == 0 {
+		utils.LavaFormatDebug("no rewards to claim")
+		return nil
+	}
+	// a transaction per chain, batching all the claimable sessions of the chain
+	for _, claim := range claims {
+		if len(claim.relaySessions) == 0 {
 			continue
 		}
-		expectedPay := PaymentRequest{ChainID: relay.SpecId, CU: relay.CuSum, BlockHeightDeadline: relay.Epoch, Amount: sdk.Coin{}, Client: consumerAddr, UniqueIdentifier: relay.SessionId, Description: strconv.FormatUint(rws.serverID, 10)}
-		rws.addExpectedPayment(expectedPay)
-		rws.updateCUServiced(relay.CuSum)
-	}
-	if len(rewardsToClaim) > 0 {
-		err = rws.rewardsTxSender.TxRelayPayment(ctx, rewardsToClaim, dataReliabilityProofs, strconv.FormatUint(rws.serverID, 10))
-		if err != nil {
-			return utils.LavaFormatError("failed sending rewards claim", err)
+		for _, relay := range claim.relaySessions {
+			consumerAddr, err := sigs.ExtractSignerAddress(relay)
+			if err != nil {
+				utils.LavaFormatError("invalid consumer address extraction from relay", err, utils.Attribute{Key: "relay", Value: relay})
+				continue
+			}
+			expectedPay := PaymentRequest{ChainID: relay.SpecId, CU: relay.CuSum, BlockHeightDeadline: relay.Epoch, Amount: sdk.Coin{}, Client: consumerAddr, UniqueIdentifier: relay.SessionId, Description: strconv.FormatUint(rws.serverID, 10)}
+			rws.addExpectedPayment(expectedPay)
+			rws.addUnpaidClaim(relay, consumerAddr.String(), epoch)
+			rws.updateCUServiced(relay.CuSum)
 		}
-	} else {
-		utils.LavaFormatDebug("no rewards to claim")
+		utils.LavaFormatInfo("sending rewards claim", utils.Attribute{Key: "chainID", Value: claim.chainID}, utils.Attribute{Key: "provider", Value: claim.provider}, utils.Attribute{Key: "reason", Value: claim.reason}, utils.Attribute{Key: "sessions", Value: len(claim.relaySessions)}, utils.Attribute{Key: "cu", Value: claim.cu})
+		txErr := rws.claimSigner(claim.provider).TxRelayPayment(ctx, claim.relaySessions, claim.dataReliabilityProofs, strconv.FormatUint(rws.serverID, 10))
+		if txErr != nil {
+			err = utils.LavaFormatError("failed sending rewards claim", txErr, utils.Attribute{Key: "chainID", Value: claim.chainID}, utils.Attribute{Key: "provider", Value: claim.provider})
+			rws.paymentAlerts.claimFailed(claim.chainID, epoch, txErr)
+			continue
+		}
+		rws.paymentAlerts.claimSent(claim.chainID)
 	}
-	return nil
+	return err
 }
 
 func (rws *RewardServer) identifyMissingPayments(ctx context.Context) (missingPayments bool, err error) {
@@ -174,6 +283,9 @@ func (rws *RewardServer) identifyMissingPayments(ctx context.Context) (missingPa
 				utils.Attribute{Key: "expectedPay.BlockHeightDeadline", Value: expectedPay.BlockHeightDeadline},
 				utils.Attribute{Key: "lastBlockInMemory", Value: lastBlockInMemory},
 			)
+			lostClaimsCounter.WithLabelValues(expectedPay.ChainID).Inc()
+			rws.reconcileMissingPaymentUnsafe(expectedPay)
+			rws.deleteUnpaidClaimUnsafe(expectedPay.ChainID, expectedPay.Client.String(), expectedPay.BlockHeightDeadline, expectedPay.UniqueIdentifier)
 			missingPayments = true
 			continue
 		}
@@ -185,6 +297,16 @@ func (rws *RewardServer) identifyMissingPayments(ctx context.Context) (missingPa
 	// Update expectedPayment
 	rws.expectedPayments = updatedExpectedPayments
 
+	if rws.rewardStore != nil {
+		// proofs of epochs before the earliest block in memory can't be claimed anymore, paid or not
+		deleted, err := rws.rewardStore.DeleteEpochsBefore(lastBlockInMemory)
+		if err != nil {
+			utils.LavaFormatWarning("failed compacting the reward db", err, utils.Attribute{Key: "lastBlockInMemory", Value: lastBlockInMemory})
+		} else if deleted > 0 {
+			utils.LavaFormatDebug("compacted the reward db", utils.Attribute{Key: "deleted", Value: deleted}, utils.Attribute{Key: "lastBlockInMemory", Value: lastBlockInMemory})
+		}
+	}
+
 	// can be modified in this race window, so we double-check
 
 	utils.LavaFormatInfo("Service report",
@@ -206,56 +328,165 @@ func (rws *RewardServer) addExpectedPayment(expectedPay PaymentRequest) {
 	rws.lock.Lock() // this can be a separate lock, if we have performance issues
 	defer rws.lock.Unlock()
 	rws.expectedPayments = append(rws.expectedPayments, expectedPay)
+	rws.expectPaymentUnsafe(expectedPay)
 }
 
 func (rws *RewardServer) RemoveExpectedPayment(paidCUToFInd uint64, expectedClient sdk.AccAddress, blockHeight int64, uniqueID uint64, chainID string) bool {
+	_, removed := rws.removeExpectedPayment(&PaymentRequest{CU: paidCUToFInd, Client: expectedClient, BlockHeightDeadline: blockHeight, UniqueIdentifier: uniqueID, ChainID: chainID})
+	return removed
+}
+
+// removeExpectedPayment settles the expected payment of the paid session, a payment of a different cu than claimed still settles it
+// and is counted as a mismatch
+func (rws *RewardServer) removeExpectedPayment(payment *PaymentRequest) (removedPayment PaymentRequest, removed bool) {
+	expectedClient, uniqueID, chainID := payment.Client, payment.UniqueIdentifier, payment.ChainID
 	rws.lock.Lock() // this can be a separate lock, if we have performance issues
 	defer rws.lock.Unlock()
 	for idx, expectedPayment := range rws.expectedPayments {
 		// TODO: make sure the payment is not too far from expected block, expectedPayment.BlockHeightDeadline == blockHeight
-		if expectedPayment.CU == paidCUToFInd && expectedPayment.Client.Equals(expectedClient) && uniqueID == expectedPayment.UniqueIdentifier && chainID == expectedPayment.ChainID {
+		if expectedPayment.Client.Equals(expectedClient) && uniqueID == expectedPayment.UniqueIdentifier && chainID == expectedPayment.ChainID {
 			// found payment for expected payment
 			rws.expectedPayments[idx] = rws.expectedPayments[len(rws.expectedPayments)-1] // replace the element at delete index with the last one
 			rws.expectedPayments = rws.expectedPayments[:len(rws.expectedPayments)-1]     // remove last element
+			rws.deleteUnpaidClaimUnsafe(chainID, expectedClient.String(), expectedPayment.BlockHeightDeadline, uniqueID)
+			rws.reconcilePaymentUnsafe(expectedPayment, payment)
+			rws.recordPaidUnsafe(expectedPayment, payment)
+			rws.paymentAlerts.paymentSeen(chainID, rws.awaitingPaymentUnsafe(chainID))
+			return expectedPayment, true
+		}
+	}
+	return PaymentRequest{}, false
+}
+
+func (rws *RewardServer) awaitingPaymentUnsafe(chainID string) bool {
+	for _, expectedPayment := range rws.expectedPayments {
+		if expectedPayment.ChainID == chainID {
 			return true
 		}
 	}
 	return false
 }
 
-func (rws *RewardServer) gatherRewardsForClaim(ctx context.Context, currentEpoch uint64) (rewardsForClaim []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData, errRet error) {
+// gatherRewardsForClaim removes the proofs of the chains that crossed a claim threshold from the server and returns them as claim
+// transactions, the oldest proofs first. the proofs of a chain below all the thresholds or over the transactions of this epoch are
+// held for a later epoch
+func (rws *RewardServer) gatherRewardsForClaim(ctx context.Context, currentEpoch uint64) (claims []*chainClaim, errRet error) {
 	rws.lock.Lock()
 	defer rws.lock.Unlock()
 	blockDistanceForEpochValidity, err := rws.rewardsTxSender.GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx)
 	if err != nil {
-		return nil, nil, utils.LavaFormatError("gatherRewardsForClaim failed to GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment", err)
+		return nil, utils.LavaFormatError("gatherRewardsForClaim failed to GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment", err)
 	}
 
 	if blockDistanceForEpochValidity > currentEpoch {
-		return nil, nil, utils.LavaFormatWarning("gatherRewardsForClaim current epoch is too low to claim rewards", nil, utils.Attribute{Key: "current epoch", Value: currentEpoch})
+		return nil, utils.LavaFormatWarning("gatherRewardsForClaim current epoch is too low to claim rewards", nil, utils.Attribute{Key: "current epoch", Value: currentEpoch})
 	}
 	activeEpochThreshold := currentEpoch - blockDistanceForEpochValidity
+	for epoch := range rws.proofSignatures {
+		if !lavasession.IsEpochValidForUse(epoch, activeEpochThreshold) {
+			// no new proofs are accepted for the epoch
+			delete(rws.proofSignatures, epoch)
+		}
+	}
+	rws.pruneSignedFinalizationsUnsafe(activeEpochThreshold)
+	chainClaims := map[string]*chainClaim{}
 	for epoch, epochRewards := range rws.rewards {
 		if lavasession.IsEpochValidForUse(epoch, activeEpochThreshold) {
 			// Epoch is still active so we don't claim the rewards yet.
 			continue
 		}
 
-		for consumerAddr, rewards := range epochRewards.consumerRewards {
-			claimables, dataReliabilities, err := rewards.PrepareRewardsForClaim()
-			if err != nil {
-				// can't claim this now
+		for consumerRewardsKey, consumerRewards := range epochRewards.consumerRewards {
+			chainID := consumerRewards.chainID()
+			if chainID == "" {
+				// data reliability proofs aren't claimed without relay proofs
+				delete(epochRewards.consumerRewards, consumerRewardsKey)
 				continue
 			}
-			rewardsForClaim = append(rewardsForClaim, claimables...)
-			dataReliabilityProofs = append(dataReliabilityProofs, dataReliabilities...)
-			delete(epochRewards.consumerRewards, consumerAddr)
+			provider := consumerRewards.provider()
+			claim, ok := chainClaims[claimKey(provider, chainID)]
+			if !ok {
+				claim = newChainClaim(chainID, provider)
+				chainClaims[claimKey(provider, chainID)] = claim
+			}
+			claim.add(epoch, consumerRewardsKey, consumerRewards)
 		}
 		if len(epochRewards.consumerRewards) == 0 {
 			delete(rws.rewards, epoch)
 		}
 	}
-	return rewardsForClaim, dataReliabilityProofs, errRet
+	if len(chainClaims) == 0 {
+		return nil, nil
+	}
+
+	thresholds := rws.claimThresholds
+	earliestBlockInMemory := uint64(0)
+	if thresholds.ExpiryBlocks > 0 {
+		earliestBlockInMemory, err = rws.rewardsTxSender.EarliestBlockInMemory(ctx)
+		if err != nil {
+			// can't tell how close the proofs are to expiring, claiming them all rather than risking losing them
+			utils.LavaFormatWarning("failed reading the earliest block in memory, claiming all the claimable rewards", err)
+			thresholds = ClaimThresholds{}
+		}
+	}
+	crossed := []*chainClaim{}
+	for _, key := range sortedChainIDs(chainClaims) {
+		claim := chainClaims[key]
+		claim.reason = claim.thresholdCrossed(thresholds, earliestBlockInMemory)
+		if claim.reason == "" {
+			utils.LavaFormatDebug("holding rewards claim until a threshold is crossed", utils.Attribute{Key: "chainID", Value: claim.chainID}, utils.Attribute{Key: "provider", Value: claim.provider}, utils.Attribute{Key: "cu", Value: claim.cu}, utils.Attribute{Key: "proofs", Value: claim.proofs}, utils.Attribute{Key: "oldestEpoch", Value: claim.oldestEpoch})
+			continue
+		}
+		crossed = append(crossed, claim)
+	}
+	if len(crossed) == 0 {
+		return nil, nil
+	}
+	maxTxBytes := 0
+	blockMaxBytes, err := rws.rewardsTxSender.GetBlockMaxBytes(ctx)
+	if err != nil {
+		utils.LavaFormatWarning("failed reading the block max bytes, claims are only split by the relays limit", err)
+	} else if blockMaxBytes > 0 {
+		maxTxBytes = int(blockMaxBytes / claimTxBlockBytesFraction)
+	}
+	batches, deferred := rws.queueClaimsUnsafe(crossed, rws.claimQueueLimits, maxTxBytes)
+	if deferred > 0 {
+		utils.LavaFormatInfo("claim transactions of this epoch are throttled, the newest proofs are claimed next epoch", utils.Attribute{Key: "transactions", Value: len(batches)}, utils.Attribute{Key: "deferredProofs", Value: deferred})
+	}
+	for _, batch := range batches {
+		claim := newChainClaim(batch.claim.chainID, batch.claim.provider)
+		claim.reason = batch.claim.reason
+		claim.oldestEpoch = batch.oldestEpoch
+		aggregator := newClaimAggregator()
+		for _, unit := range batch.units {
+			epochRewards := rws.rewards[unit.epoch]
+			for _, consumerRewardsKey := range unit.keys {
+				rewards := epochRewards.consumerRewards[consumerRewardsKey]
+				rws.dropForkedDataReliabilityUnsafe(unit.epoch, consumerRewardsKey, rewards)
+				claimables, dataReliabilities, err := rewards.PrepareRewardsForClaim()
+				if err != nil {
+					// can't claim this now
+					continue
+				}
+				if epochRewards.virtualEpoch > 0 {
+					utils.LavaFormatInfo("claiming rewards of an epoch extended by lava downtime", utils.Attribute{Key: "epoch", Value: unit.epoch}, utils.Attribute{Key: "virtualEpoch", Value: epochRewards.virtualEpoch}, utils.Attribute{Key: "consumer", Value: rewards.consumer})
+				}
+				aggregator.add(rewards.consumer, unit.epoch, claimables, dataReliabilities)
+				delete(epochRewards.consumerRewards, consumerRewardsKey)
+				delete(rws.signedFinalizations[unit.epoch], consumerRewardsKey)
+			}
+			if len(epochRewards.consumerRewards) == 0 {
+				delete(rws.rewards, unit.epoch)
+			}
+		}
+		claim.relaySessions, claim.dataReliabilityProofs = aggregator.claim(claim.chainID)
+		for _, relay := range claim.relaySessions {
+			claim.cu += relay.CuSum
+		}
+		claim.proofs = len(claim.relaySessions)
+		claims = append(claims, claim)
+	}
+	return claims, errRet
 }
 
 func (rws *RewardServer) SubscribeStarted(consumer string, epoch uint64, subscribeID string) {
@@ -292,82 +523,107 @@ func (rws *RewardServer) PaymentHandler(payment *PaymentRequest) {
 	}
 	if serverID == rws.serverID {
 		rws.updateCUPaid(payment.CU)
-		removedPayment := rws.RemoveExpectedPayment(payment.CU, payment.Client, payment.BlockHeightDeadline, payment.UniqueIdentifier, payment.ChainID)
+		expectedPayment, removedPayment := rws.removeExpectedPayment(payment)
 		if !removedPayment {
+			paymentMismatchCounter.WithLabelValues(payment.ChainID, mismatchUnexpected).Inc()
 			utils.LavaFormatWarning("tried removing payment that wasn;t expected", nil, utils.Attribute{Key: "payment", Value: payment})
+			return
+		}
+		if rws.rewardStore != nil {
+			// the claimed proof is kept until it's paid, so a crash between the claim and the payment doesn't lose it
+			err := rws.rewardStore.DeleteProof(uint64(expectedPayment.BlockHeightDeadline), payment.Client.String(), payment.ChainID, payment.UniqueIdentifier)
+			if err != nil {
+				utils.LavaFormatWarning("failed removing paid proof from the reward db", err, utils.Attribute{Key: "payment", Value: payment})
+			}
 		}
+		rws.savePaidReward(expectedPayment, payment)
+		rws.paymentNotifier.paymentCredited(expectedPayment, payment)
 	}
 }
 
-func NewRewardServer(rewardsTxSender RewardsTxSender) *RewardServer {
+func NewRewardServer(rewardsTxSender RewardsTxSender, claimThresholds ClaimThresholds) *RewardServer {
 	//
 	rws := &RewardServer{totalCUServiced: 0, totalCUPaid: 0}
 	rws.serverID = uint64(rand.Int63())
 	rws.rewardsTxSender = rewardsTxSender
+	rws.claimThresholds = claimThresholds
 	rws.expectedPayments = []PaymentRequest{}
+	rws.unpaidClaims = map[string]*unpaidClaim{}
+	rws.paymentReconciliations = map[paymentReconciliationKey]*paymentReconciliation{}
+	rws.paidHistory = map[chainEpochKey]*paidRewards{}
+	rws.proofSignatures = map[uint64]map[string]string{}
+	rws.chainPayments = map[string]*chainPayments{}
+	rws.claimSigners = map[string]ClaimSigner{}
+	rws.signedFinalizations = map[uint64]map[string]*signedFinalization{}
+	rws.paymentAlerts = NewPaymentAlerts("", "", DefaultPaymentAlertMissingEpochs, DefaultPaymentAlertFailedClaims)
 	// TODO: load this from persistency
 	rws.rewards = map[uint64]*EpochRewards{}
 	return rws
 }
 
-func BuildPaymentFromRelayPaymentEvent(event terderminttypes.Event, block int64) (*PaymentRequest, error) {
-	attributes := map[string]string{}
-	for _, attribute := range event.Attributes {
-		attributes[string(attribute.Key)] = string(attribute.Value)
-	}
-	chainID, ok := attributes["chainID"]
-	if !ok {
-		return nil, utils.LavaFormatError("failed building PaymentRequest from relay_payment event", nil, utils.Attribute{Key: "attributes", Value: attributes})
-	}
-	mint, ok := attributes["Mint"]
-	if !ok {
-		return nil, utils.LavaFormatError("failed building PaymentRequest from relay_payment event", nil, utils.Attribute{Key: "attributes", Value: attributes})
-	}
-	mintedCoins, err := sdk.ParseCoinNormalized(mint)
+// SetRewardStore restores the proofs and unpaid claims persisted in rewardStore before a crash and persists them from now on,
+// the proofs and claims the server already holds are written to it too
+func (rws *RewardServer) SetRewardStore(rewardStore RewardStore) error {
+	rewards, err := rewardStore.LoadRewards()
 	if err != nil {
-		return nil, err
+		return utils.LavaFormatError("failed loading proofs from the reward db", err)
 	}
-	cu_str, ok := attributes["CU"]
-	if !ok {
-		return nil, utils.LavaFormatError("failed building PaymentRequest from relay_payment event", nil, utils.Attribute{Key: "attributes", Value: attributes})
+	claims, err := rewardStore.LoadClaims()
+	if err != nil {
+		return utils.LavaFormatError("failed loading unpaid claims from the reward db", err)
 	}
-	cu, err := strconv.ParseUint(cu_str, 10, 64)
+	serverID, found, err := rewardStore.LoadServerID()
 	if err != nil {
-		return nil, err
+		return utils.LavaFormatError("failed loading the server id from the reward db", err)
 	}
-	consumer, ok := attributes["client"]
-	if !ok {
-		return nil, utils.LavaFormatError("failed building PaymentRequest from relay_payment event", nil, utils.Attribute{Key: "attributes", Value: attributes})
+	if !found {
+		serverID = rws.serverID
 	}
-	consumerAddr, err := sdk.AccAddressFromBech32(consumer)
+	// the persisted server id matches payments of claims sent before the crash
+	err = rws.Restore(&RewardServerSnapshot{ServerID: serverID, Rewards: rewards})
 	if err != nil {
-		return nil, err
-	}
-
-	uniqueIdentifier, ok := attributes["uniqueIdentifier"]
-	if !ok {
-		return nil, utils.LavaFormatError("failed building PaymentRequest from relay_payment event", nil, utils.Attribute{Key: "attributes", Value: attributes})
+		return err
 	}
-	uniqueID, err := strconv.ParseUint(uniqueIdentifier, 10, 64)
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	err = rewardStore.SaveServerID(rws.serverID)
 	if err != nil {
-		return nil, err
+		return utils.LavaFormatError("failed saving the server id to the reward db", err)
 	}
-	description, ok := attributes["descriptionString"]
-	if !ok {
-		return nil, utils.LavaFormatError("failed building PaymentRequest from relay_payment event", nil, utils.Attribute{Key: "attributes", Value: attributes})
+	rws.restoreClaimsUnsafe(claims)
+	for epoch, epochRewards := range rws.rewards {
+		for key, consumerRewards := range epochRewards.consumerRewards {
+			for _, proof := range consumerRewards.proofs {
+				err = rewardStore.SaveProof(epoch, key, consumerRewards.consumer, proof)
+				if err != nil {
+					return utils.LavaFormatError("failed persisting relay proof", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerRewards.consumer})
+				}
+			}
+			for _, dataReliabilityProof := range consumerRewards.dataReliabilityProofs {
+				err = rewardStore.SaveDataReliabilityProof(epoch, key, consumerRewards.consumer, dataReliabilityProof.ChainId, dataReliabilityProof)
+				if err != nil {
+					return utils.LavaFormatError("failed persisting data reliability proof", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerRewards.consumer})
+				}
+			}
+		}
 	}
-	payment := &PaymentRequest{
-		CU:                  cu,
-		BlockHeightDeadline: block,
-		Amount:              mintedCoins,
-		Client:              consumerAddr,
-		Description:         description,
-		UniqueIdentifier:    uniqueID,
-		ChainID:             chainID,
+	for _, claim := range rws.unpaidClaims {
+		err = rewardStore.SaveClaim(claim.snapshot())
+		if err != nil {
+			return utils.LavaFormatError("failed persisting unpaid claim", err, utils.Attribute{Key: "epoch", Value: claim.proof.Epoch}, utils.Attribute{Key: "consumer", Value: claim.consumer})
+		}
 	}
-	return payment, nil
+	rws.rewardStore = rewardStore
+	return nil
+}
+
+// BuildPaymentFromRelayPaymentEvent decodes the payment with the latest payment event schema
+func BuildPaymentFromRelayPaymentEvent(event terderminttypes.Event, block int64) (*PaymentRequest, error) {
+	payment, _, err := BuildPaymentFromRelayPaymentEventWithSchema(event, block, LatestPaymentEventSchema())
+	return payment, err
 }
 
-func getKeyForConsumerRewards(specId string, apiInterface string, consumerAddress string) string {
-	return specId + apiInterface + consumerAddress
+// getKeyForConsumerRewards keys the proofs by the provider address that served them too, so the proofs of each wallet are claimed by it
+func getKeyForConsumerRewards(specId string, apiInterface string, consumerAddress string, providerAddress string) string {
+	return specId + apiInterface + consumerAddress + providerAddress
 }
@@ -0,0 +1,136 @@
+package rewardserver
+
+import (
+	"sync/atomic"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/sigs"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+// RewardServerSnapshot holds the proofs not claimed yet and the payments expected for sent claims, the proofs are protobuf encoded
+type RewardServerSnapshot struct {
+	ServerID         uint64                     `json:"server-id"`
+	Rewards          []*ConsumerRewardsSnapshot `json:"rewards"`
+	ExpectedPayments []PaymentRequest           `json:"expected-payments"`
+	TotalCUServiced  uint64                     `json:"total-cu-serviced"`
+	TotalCUPaid      uint64                     `json:"total-cu-paid"`
+	UnpaidClaims     [][]byte                   `json:"unpaid-claims"`
+}
+
+type ConsumerRewardsSnapshot struct {
+	Epoch                 uint64   `json:"epoch"`
+	Key                   string   `json:"key"`
+	Consumer              string   `json:"consumer"`
+	Proofs                [][]byte `json:"proofs"`
+	DataReliabilityProofs [][]byte `json:"data-reliability-proofs"`
+}
+
+func (rws *RewardServer) Snapshot() (*RewardServerSnapshot, error) {
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	snapshot := &RewardServerSnapshot{
+		ServerID:         rws.serverID,
+		Rewards:          []*ConsumerRewardsSnapshot{},
+		ExpectedPayments: append([]PaymentRequest{}, rws.expectedPayments...),
+		TotalCUServiced:  atomic.LoadUint64(&rws.totalCUServiced),
+		TotalCUPaid:      atomic.LoadUint64(&rws.totalCUPaid),
+	}
+	for _, claim := range rws.unpaidClaims {
+		proofBytes, err := claim.proof.Marshal()
+		if err != nil {
+			return nil, utils.LavaFormatError("failed encoding unpaid claim for the reward server snapshot", err, utils.Attribute{Key: "epoch", Value: claim.proof.Epoch})
+		}
+		snapshot.UnpaidClaims = append(snapshot.UnpaidClaims, proofBytes)
+	}
+	for epoch, epochRewards := range rws.rewards {
+		for key, consumerRewards := range epochRewards.consumerRewards {
+			consumerSnapshot := &ConsumerRewardsSnapshot{Epoch: epoch, Key: key, Consumer: consumerRewards.consumer}
+			for _, proof := range consumerRewards.proofs {
+				proofBytes, err := proof.Marshal()
+				if err != nil {
+					return nil, utils.LavaFormatError("failed encoding relay proof for the reward server snapshot", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerRewards.consumer})
+				}
+				consumerSnapshot.Proofs = append(consumerSnapshot.Proofs, proofBytes)
+			}
+			for _, dataReliabilityProof := range consumerRewards.dataReliabilityProofs {
+				proofBytes, err := dataReliabilityProof.Marshal()
+				if err != nil {
+					return nil, utils.LavaFormatError("failed encoding data reliability proof for the reward server snapshot", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: consumerRewards.consumer})
+				}
+				consumerSnapshot.DataReliabilityProofs = append(consumerSnapshot.DataReliabilityProofs, proofBytes)
+			}
+			snapshot.Rewards = append(snapshot.Rewards, consumerSnapshot)
+		}
+	}
+	return snapshot, nil
+}
+
+// Restore adds the snapshot's proofs, a proof the server already holds with a higher cu sum is kept.
+// the server id is restored so payments for claims sent before the restart are still matched
+func (rws *RewardServer) Restore(snapshot *RewardServerSnapshot) error {
+	if snapshot == nil {
+		return nil
+	}
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	restoredProofs := 0
+	for _, consumerSnapshot := range snapshot.Rewards {
+		epochRewards, ok := rws.rewards[consumerSnapshot.Epoch]
+		if !ok {
+			epochRewards = &EpochRewards{epoch: consumerSnapshot.Epoch, consumerRewards: map[string]*ConsumerRewards{}}
+			rws.rewards[consumerSnapshot.Epoch] = epochRewards
+		}
+		consumerRewards, ok := epochRewards.consumerRewards[consumerSnapshot.Key]
+		if !ok {
+			consumerRewards = &ConsumerRewards{epoch: consumerSnapshot.Epoch, consumer: consumerSnapshot.Consumer, proofs: map[uint64]*pairingtypes.RelaySession{}, dataReliabilityProofs: []*pairingtypes.VRFData{}}
+			epochRewards.consumerRewards[consumerSnapshot.Key] = consumerRewards
+		}
+		for _, proofBytes := range consumerSnapshot.Proofs {
+			proof := &pairingtypes.RelaySession{}
+			err := proof.Unmarshal(proofBytes)
+			if err != nil {
+				return utils.LavaFormatError("failed decoding relay proof from the reward server snapshot", err, utils.Attribute{Key: "epoch", Value: consumerSnapshot.Epoch}, utils.Attribute{Key: "consumer", Value: consumerSnapshot.Consumer})
+			}
+			if existing, ok := consumerRewards.proofs[proof.SessionId]; ok && existing.CuSum >= proof.CuSum {
+				continue
+			}
+			consumerRewards.proofs[proof.SessionId] = proof
+			rws.recordProofSignatureUnsafe(proof, consumerSnapshot.Epoch, consumerSnapshot.Key)
+			restoredProofs++
+		}
+		if len(consumerRewards.dataReliabilityProofs) == 0 { // currently support only one per epoch
+			for _, proofBytes := range consumerSnapshot.DataReliabilityProofs {
+				dataReliabilityProof := &pairingtypes.VRFData{}
+				err := dataReliabilityProof.Unmarshal(proofBytes)
+				if err != nil {
+					return utils.LavaFormatError("failed decoding data reliability proof from the reward server snapshot", err, utils.Attribute{Key: "epoch", Value: consumerSnapshot.Epoch}, utils.Attribute{Key: "consumer", Value: consumerSnapshot.Consumer})
+				}
+				consumerRewards.dataReliabilityProofs = append(consumerRewards.dataReliabilityProofs, dataReliabilityProof)
+			}
+		}
+	}
+	rws.serverID = snapshot.ServerID
+	rws.expectedPayments = append(rws.expectedPayments, snapshot.ExpectedPayments...)
+	for _, expectedPay := range snapshot.ExpectedPayments {
+		rws.expectPaymentUnsafe(expectedPay)
+	}
+	for _, proofBytes := range snapshot.UnpaidClaims {
+		proof := &pairingtypes.RelaySession{}
+		err := proof.Unmarshal(proofBytes)
+		if err != nil {
+			return utils.LavaFormatError("failed decoding unpaid claim from the reward server snapshot", err)
+		}
+		consumerAddr, err := sigs.ExtractSignerAddress(proof)
+		if err != nil {
+			utils.LavaFormatWarning("skipping unpaid claim with an invalid consumer signature", err, utils.Attribute{Key: "epoch", Value: proof.Epoch})
+			continue
+		}
+		// the claim epoch isn't kept, it's eligible to be claimed again right away
+		rws.unpaidClaims[unpaidClaimKey(proof.SpecId, consumerAddr.String(), proof.Epoch, proof.SessionId)] = &unpaidClaim{proof: proof, consumer: consumerAddr.String(), claimAttempts: 1}
+	}
+	atomic.AddUint64(&rws.totalCUServiced, snapshot.TotalCUServiced)
+	atomic.AddUint64(&rws.totalCUPaid, snapshot.TotalCUPaid)
+	utils.LavaFormatInfo("reward server restored from snapshot", utils.Attribute{Key: "proofs", Value: restoredProofs}, utils.Attribute{Key: "expectedPayments", Value: len(snapshot.ExpectedPayments)})
+	return nil
+}
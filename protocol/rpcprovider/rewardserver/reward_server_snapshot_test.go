@@ -0,0 +1,55 @@
+package rewardserver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardServerSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	for _, consumer := range []string{"consumer1", "consumer2"} {
+		_, updated, err := rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 2, CuSum: 20, Epoch: 20, Provider: "provider", Sig: []byte(consumer)}, 20, consumer, "rest")
+		require.NoError(t, err)
+		require.True(t, updated)
+		require.True(t, rws.SendNewDataReliabilityProof(ctx, &pairingtypes.VRFData{ChainId: "LAV1", Epoch: 20, Sig: []byte(consumer)}, 20, consumer, "LAV1", "rest", "provider"))
+	}
+
+	// the snapshot is written to a file on shutdown
+	snapshot, err := rws.Snapshot()
+	require.NoError(t, err)
+	snapshotBytes, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	restoredSnapshot := &RewardServerSnapshot{}
+	require.NoError(t, json.Unmarshal(snapshotBytes, restoredSnapshot))
+	require.Len(t, restoredSnapshot.Rewards, 2)
+
+	txSender := &fakeRewardsTxSender{}
+	restored := NewRewardServer(txSender, ClaimThresholds{})
+	// a proof the server already holds with a higher cu sum is kept
+	_, _, err = restored.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 3, CuSum: 30, Epoch: 20, Provider: "provider", Sig: []byte("consumer1-newer")}, 20, "consumer1", "rest")
+	require.NoError(t, err)
+	require.NoError(t, restored.Restore(restoredSnapshot))
+	require.Equal(t, rws.serverID, restored.serverID)
+	require.Len(t, restored.rewards[20].consumerRewards, 2)
+	for _, consumerRewards := range restored.rewards[20].consumerRewards {
+		require.Len(t, consumerRewards.proofs, 1)
+		require.Len(t, consumerRewards.dataReliabilityProofs, 1)
+		if consumerRewards.consumer == "consumer1" {
+			require.Equal(t, uint64(30), consumerRewards.proofs[1].CuSum)
+		} else {
+			require.Equal(t, uint64(20), consumerRewards.proofs[1].CuSum)
+		}
+	}
+
+	// the restored proofs are claimed once the epoch can be claimed
+	require.NoError(t, restored.sendRewardsClaim(ctx, 100))
+	require.Len(t, txSender.claims, 1)
+	require.Len(t, txSender.claims[0], 2)
+
+	require.NoError(t, NewRewardServer(nil, ClaimThresholds{}).Restore(nil))
+}
@@ -0,0 +1,110 @@
+package rewardserver
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+)
+
+const MemoryRewardStoreBackend = "memory"
+
+// RewardStore persists the state of the reward server, the relay proofs as they're aggregated, the claims no payment was seen for and
+// the payments. proofs and claims that weren't paid yet survive a crash, and instances of a provider sharing a store share its rewards.
+// TmRewardDB and MemoryRewardStore implement it, other databases are plugged with RegisterRewardStoreBackend
+type RewardStore interface {
+	SaveProof(epoch uint64, consumerRewardsKey string, consumer string, proof *pairingtypes.RelaySession) error
+	SaveDataReliabilityProof(epoch uint64, consumerRewardsKey string, consumer string, specID string, dataReliability *pairingtypes.VRFData) error
+	// DeleteProof removes the proof of the session once its payment arrived, on all api interfaces
+	DeleteProof(epoch uint64, consumer string, specID string, sessionID uint64) error
+	// DeleteDataReliabilityProof removes a data reliability proof that won't be claimed
+	DeleteDataReliabilityProof(epoch uint64, consumerRewardsKey string, consumer string, specID string) error
+	// DeleteEpochsBefore removes the proofs and claims of epochs that can't be claimed anymore
+	DeleteEpochsBefore(epoch uint64) (deleted int, err error)
+	LoadRewards() ([]*ConsumerRewardsSnapshot, error)
+	// SaveClaim keeps a claimed proof until its payment arrives, a claim of the same session replaces it
+	SaveClaim(claim ClaimSnapshot) error
+	DeleteClaim(epoch uint64, consumer string, specID string, sessionID uint64) error
+	LoadClaims() ([]ClaimSnapshot, error)
+	SaveServerID(serverID uint64) error
+	LoadServerID() (serverID uint64, found bool, err error)
+	// SavePaidReward keeps the payment of a session for the rewards export, paid rewards aren't compacted
+	SavePaidReward(paid PaidReward) error
+	LoadPaidRewards(fromBlock int64, toBlock int64) ([]PaidReward, error)
+	Close() error
+}
+
+// ClaimSnapshot is a claimed proof no payment was seen for yet
+type ClaimSnapshot struct {
+	Consumer      string
+	ClaimedEpoch  uint64 // the epoch of the last claim
+	ClaimAttempts int
+	Proof         *pairingtypes.RelaySession
+}
+
+type encodedClaim struct {
+	Consumer      string `json:"consumer"`
+	ClaimedEpoch  uint64 `json:"claimed-epoch"`
+	ClaimAttempts int    `json:"claim-attempts"`
+	Proof         []byte `json:"proof"`
+}
+
+func encodeClaim(claim ClaimSnapshot) ([]byte, error) {
+	proofBytes, err := claim.Proof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodedClaim{Consumer: claim.Consumer, ClaimedEpoch: claim.ClaimedEpoch, ClaimAttempts: claim.ClaimAttempts, Proof: proofBytes})
+}
+
+func decodeClaim(value []byte) (ClaimSnapshot, error) {
+	encoded := encodedClaim{}
+	err := json.Unmarshal(value, &encoded)
+	if err != nil {
+		return ClaimSnapshot{}, err
+	}
+	proof := &pairingtypes.RelaySession{}
+	err = proof.Unmarshal(encoded.Proof)
+	if err != nil {
+		return ClaimSnapshot{}, err
+	}
+	return ClaimSnapshot{Consumer: encoded.Consumer, ClaimedEpoch: encoded.ClaimedEpoch, ClaimAttempts: encoded.ClaimAttempts, Proof: proof}, nil
+}
+
+// RewardStoreOpener opens the reward store of a backend, dir is the reward db path flag, a connection string for remote databases
+type RewardStoreOpener func(dir string) (RewardStore, error)
+
+var (
+	rewardStoreBackendsLock sync.RWMutex
+	rewardStoreBackends     = map[string]RewardStoreOpener{
+		MemoryRewardStoreBackend: func(string) (RewardStore, error) { return NewMemoryRewardStore(), nil },
+	}
+)
+
+// RegisterRewardStoreBackend makes a reward store selectable with the reward db backend flag, called from an init of the package
+// implementing it. a backend of the same name is replaced
+func RegisterRewardStoreBackend(backend string, opener RewardStoreOpener) {
+	rewardStoreBackendsLock.Lock()
+	defer rewardStoreBackendsLock.Unlock()
+	rewardStoreBackends[backend] = opener
+}
+
+// OpenRewardStore opens the reward store of a registered backend, any other backend is a tm-db backend of a TmRewardDB in dir
+func OpenRewardStore(dir string, backend string) (RewardStore, error) {
+	rewardStoreBackendsLock.RLock()
+	opener, ok := rewardStoreBackends[backend]
+	rewardStoreBackendsLock.RUnlock()
+	if !ok {
+		rewardDB, err := OpenRewardDB(dir, backend)
+		if err != nil {
+			return nil, err
+		}
+		return rewardDB, nil
+	}
+	rewardStore, err := opener(dir)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed opening the reward store", err, utils.Attribute{Key: "backend", Value: backend})
+	}
+	return rewardStore, nil
+}
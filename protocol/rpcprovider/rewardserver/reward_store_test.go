@@ -0,0 +1,112 @@
+package rewardserver
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestRewardStores(t *testing.T) {
+	stores := map[string]func() RewardStore{
+		"tm-db":  func() RewardStore { return NewTmRewardDB(dbm.NewMemDB()) },
+		"memory": func() RewardStore { return NewMemoryRewardStore() },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore()
+			proof := func(sessionID uint64, epoch int64) *pairingtypes.RelaySession {
+				return &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: sessionID, CuSum: 10, Epoch: epoch}
+			}
+			require.NoError(t, store.SaveProof(20, "key1", "consumer1", proof(1, 20)))
+			require.NoError(t, store.SaveProof(20, "key1", "consumer1", proof(2, 20)))
+			require.NoError(t, store.SaveProof(40, "key2", "consumer1", proof(3, 40)))
+			require.NoError(t, store.SaveDataReliabilityProof(20, "key1", "consumer1", "LAV1", &pairingtypes.VRFData{ChainId: "LAV1", Epoch: 20}))
+			require.NoError(t, store.DeleteProof(20, "consumer1", "LAV1", 2))
+			rewards, err := store.LoadRewards()
+			require.NoError(t, err)
+			require.Len(t, rewards, 2)
+			for _, snapshot := range rewards {
+				require.Len(t, snapshot.Proofs, 1)
+				if snapshot.Epoch == 20 {
+					require.Equal(t, "key1", snapshot.Key)
+					require.Len(t, snapshot.DataReliabilityProofs, 1)
+				}
+			}
+
+			// a claim of the same session replaces the earlier one
+			require.NoError(t, store.SaveClaim(ClaimSnapshot{Consumer: "consumer1", ClaimedEpoch: 40, ClaimAttempts: 1, Proof: proof(1, 20)}))
+			require.NoError(t, store.SaveClaim(ClaimSnapshot{Consumer: "consumer1", ClaimedEpoch: 60, ClaimAttempts: 2, Proof: proof(1, 20)}))
+			require.NoError(t, store.SaveClaim(ClaimSnapshot{Consumer: "consumer1", ClaimedEpoch: 60, ClaimAttempts: 1, Proof: proof(3, 40)}))
+			require.NoError(t, store.SaveClaim(ClaimSnapshot{Consumer: "consumer2", ClaimedEpoch: 60, ClaimAttempts: 1, Proof: proof(4, 40)}))
+			require.NoError(t, store.DeleteClaim(40, "consumer2", "LAV1", 4))
+			claims, err := store.LoadClaims()
+			require.NoError(t, err)
+			require.Len(t, claims, 2)
+			for _, claim := range claims {
+				if claim.Proof.SessionId == 1 {
+					require.Equal(t, uint64(60), claim.ClaimedEpoch)
+					require.Equal(t, 2, claim.ClaimAttempts)
+				}
+			}
+
+			// epoch 20 expired, its proof, data reliability proof and claim are deleted
+			deleted, err := store.DeleteEpochsBefore(40)
+			require.NoError(t, err)
+			require.Equal(t, 3, deleted)
+			rewards, err = store.LoadRewards()
+			require.NoError(t, err)
+			require.Len(t, rewards, 1)
+			claims, err = store.LoadClaims()
+			require.NoError(t, err)
+			require.Len(t, claims, 1)
+
+			_, found, err := store.LoadServerID()
+			require.NoError(t, err)
+			require.False(t, found)
+			require.NoError(t, store.SaveServerID(7))
+			serverID, found, err := store.LoadServerID()
+			require.NoError(t, err)
+			require.True(t, found)
+			require.Equal(t, uint64(7), serverID)
+
+			require.NoError(t, store.SavePaidReward(PaidReward{Block: 130, ChainID: "LAV1", Consumer: "consumer1", SessionID: 2}))
+			require.NoError(t, store.SavePaidReward(PaidReward{Block: 110, ChainID: "LAV1", Consumer: "consumer1", SessionID: 1}))
+			require.NoError(t, store.SavePaidReward(PaidReward{Block: 150, ChainID: "LAV1", Consumer: "consumer1", SessionID: 3}))
+			paidRewards, err := store.LoadPaidRewards(110, 130)
+			require.NoError(t, err)
+			require.Len(t, paidRewards, 2)
+			require.Equal(t, int64(110), paidRewards[0].Block)
+			require.Equal(t, int64(130), paidRewards[1].Block)
+			require.NoError(t, store.Close())
+		})
+	}
+}
+
+func TestRewardStoreRestoresClaims(t *testing.T) {
+	store := NewMemoryRewardStore()
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	require.NoError(t, rws.SetRewardStore(store))
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 1})
+	rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 20}, consumer.String(), 40)
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 2})
+	rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 2, CuSum: 10, Epoch: 20}, consumer.String(), 40)
+	_, removed := rws.removeExpectedPayment(&PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 45, Client: consumer, UniqueIdentifier: 2})
+	require.True(t, removed)
+
+	// a provider restarting on the store, or another instance sharing it, still expects the unpaid claim
+	restarted := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	require.NoError(t, restarted.SetRewardStore(store))
+	require.Equal(t, rws.serverID, restarted.serverID)
+	require.Len(t, restarted.unpaidClaims, 1)
+	require.Len(t, restarted.expectedPayments, 1)
+	require.Equal(t, uint64(1), restarted.expectedPayments[0].UniqueIdentifier)
+	require.Equal(t, uint64(40), restarted.unpaidClaims[unpaidClaimKey("LAV1", consumer.String(), 20, 1)].claimedEpoch)
+
+	// claims the server already holds, like the ones of the shutdown snapshot, aren't expected twice
+	require.NoError(t, restarted.SetRewardStore(store))
+	require.Len(t, restarted.expectedPayments, 1)
+}
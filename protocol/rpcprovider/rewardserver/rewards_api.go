@@ -0,0 +1,277 @@
+package rewardserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	RewardsAPIAddressFlagName = "rewards-api-address"
+	RewardsAPIPath            = "/lava/rewards"
+	maxPaidHistory            = 1000 // chain and epoch entries of paid rewards kept, the oldest epochs are dropped first
+)
+
+// ChainEpochRewards sums the sessions of a chain in an epoch
+type ChainEpochRewards struct {
+	ChainID  string `json:"chainID"`
+	Epoch    uint64 `json:"epoch"`
+	Sessions int    `json:"sessions"`
+	CU       uint64 `json:"cu"`
+	Amount   string `json:"amount,omitempty"` // only for paid rewards
+}
+
+// RewardsState is the reply of the rewards api, the reward pipeline of the provider from proofs to payments
+type RewardsState struct {
+	ServerID string              `json:"serverID"`
+	Pending  []ChainEpochRewards `json:"pending"` // proofs that weren't claimed yet
+	Unpaid   []ChainEpochRewards `json:"unpaid"`  // claimed proofs no payment was seen for
+	Paid     []ChainEpochRewards `json:"paid"`
+}
+
+type chainEpochKey struct {
+	chainID string
+	epoch   uint64
+}
+
+type paidRewards struct {
+	sessions int
+	cu       uint64
+	amount   sdk.Coins
+}
+
+func (rws *RewardServer) recordPaidUnsafe(expectedPay PaymentRequest, payment *PaymentRequest) {
+	key := chainEpochKey{chainID: expectedPay.ChainID, epoch: uint64(expectedPay.BlockHeightDeadline)}
+	paid, ok := rws.paidHistory[key]
+	if !ok {
+		paid = &paidRewards{}
+		rws.paidHistory[key] = paid
+		if len(rws.paidHistory) > maxPaidHistory {
+			rws.dropOldestPaidUnsafe()
+		}
+	}
+	paid.sessions++
+	paid.cu += payment.CU
+	if payment.Amount.IsValid() && !payment.Amount.IsZero() {
+		paid.amount = paid.amount.Add(payment.Amount)
+	}
+	rws.recordChainPaymentUnsafe(expectedPay.ChainID, payment.BlockHeightDeadline, payment.Amount, time.Now())
+}
+
+func (rws *RewardServer) dropOldestPaidUnsafe() {
+	var oldest *chainEpochKey
+	for key := range rws.paidHistory {
+		if oldest == nil || key.epoch < oldest.epoch {
+			keyCopy := key
+			oldest = &keyCopy
+		}
+	}
+	if oldest != nil {
+		delete(rws.paidHistory, *oldest)
+	}
+}
+
+// RewardsState lists the pending, unpaid and paid rewards by chain and epoch, an empty chainID or a zero epoch don't filter
+func (rws *RewardServer) RewardsState(chainID string, epoch uint64) RewardsState {
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	matches := func(entryChainID string, entryEpoch uint64) bool {
+		return (chainID == "" || chainID == entryChainID) && (epoch == 0 || epoch == entryEpoch)
+	}
+	state := RewardsState{ServerID: strconv.FormatUint(rws.serverID, 10), Pending: []ChainEpochRewards{}, Unpaid: []ChainEpochRewards{}, Paid: []ChainEpochRewards{}}
+
+	pending := map[chainEpochKey]*ChainEpochRewards{}
+	for rewardsEpoch, epochRewards := range rws.rewards {
+		for _, consumerRewards := range epochRewards.consumerRewards {
+			for _, proof := range consumerRewards.proofs {
+				if !matches(proof.SpecId, rewardsEpoch) {
+					continue
+				}
+				entry := chainEpochEntry(pending, proof.SpecId, rewardsEpoch)
+				entry.Sessions++
+				entry.CU += proof.CuSum
+			}
+		}
+	}
+	state.Pending = sortedChainEpochRewards(pending)
+
+	unpaid := map[chainEpochKey]*ChainEpochRewards{}
+	for _, expectedPay := range rws.expectedPayments {
+		if !matches(expectedPay.ChainID, uint64(expectedPay.BlockHeightDeadline)) {
+			continue
+		}
+		entry := chainEpochEntry(unpaid, expectedPay.ChainID, uint64(expectedPay.BlockHeightDeadline))
+		entry.Sessions++
+		entry.CU += expectedPay.CU
+	}
+	state.Unpaid = sortedChainEpochRewards(unpaid)
+
+	paid := map[chainEpochKey]*ChainEpochRewards{}
+	for key, paidEntry := range rws.paidHistory {
+		if !matches(key.chainID, key.epoch) {
+			continue
+		}
+		paid[key] = &ChainEpochRewards{ChainID: key.chainID, Epoch: key.epoch, Sessions: paidEntry.sessions, CU: paidEntry.cu, Amount: paidEntry.amount.String()}
+	}
+	state.Paid = sortedChainEpochRewards(paid)
+	return state
+}
+
+func chainEpochEntry(entries map[chainEpochKey]*ChainEpochRewards, chainID string, epoch uint64) *ChainEpochRewards {
+	key := chainEpochKey{chainID: chainID, epoch: epoch}
+	entry, ok := entries[key]
+	if !ok {
+		entry = &ChainEpochRewards{ChainID: chainID, Epoch: epoch}
+		entries[key] = entry
+	}
+	return entry
+}
+
+// sortedChainEpochRewards orders the entries by chain and then by epoch
+func sortedChainEpochRewards(entries map[chainEpochKey]*ChainEpochRewards) []ChainEpochRewards {
+	sorted := make([]ChainEpochRewards, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, *entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ChainID != sorted[j].ChainID {
+			return sorted[i].ChainID < sorted[j].ChainID
+		}
+		return sorted[i].Epoch < sorted[j].Epoch
+	})
+	return sorted
+}
+
+// serveRewardsState replies with the rewards state, filtered by the chainID and epoch query parameters
+func (rws *RewardServer) serveRewardsState(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := req.URL.Query()
+	epoch := uint64(0)
+	if epochParam := query.Get("epoch"); epochParam != "" {
+		var err error
+		epoch, err = strconv.ParseUint(epochParam, 10, 64)
+		if err != nil {
+			http.Error(resp, "invalid epoch", http.StatusBadRequest)
+			return
+		}
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(resp).Encode(rws.RewardsState(query.Get("chainID"), epoch))
+	if err != nil {
+		utils.LavaFormatWarning("failed writing rewards api reply", err)
+	}
+}
+
+// StartRewardsAPIServer serves the rewards state, summary, estimate, export and payment reconciliation on addr until ctx is done, the chainID and epoch
+// query parameters filter it
+func (rws *RewardServer) StartRewardsAPIServer(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(RewardsAPIPath, rws.serveRewardsState)
+	mux.HandleFunc(RewardsSummaryPath, func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(resp).Encode(rws.RewardsSummary())
+		if err != nil {
+			utils.LavaFormatWarning("failed writing rewards summary reply", err)
+		}
+	})
+	mux.HandleFunc(RewardsExportPath, func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		query := req.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = RewardsExportFormatCSV
+		}
+		if format != RewardsExportFormatCSV && format != RewardsExportFormatJSON {
+			http.Error(resp, "invalid format, use csv or json", http.StatusBadRequest)
+			return
+		}
+		blocks := map[string]int64{"fromBlock": 0, "toBlock": 0}
+		for param := range blocks {
+			if value := query.Get(param); value != "" {
+				block, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || block < 0 {
+					http.Error(resp, "invalid "+param, http.StatusBadRequest)
+					return
+				}
+				blocks[param] = block
+			}
+		}
+		exported, err := rws.ExportedRewards(blocks["fromBlock"], blocks["toBlock"])
+		if err != nil {
+			http.Error(resp, "failed loading paid rewards", http.StatusInternalServerError)
+			return
+		}
+		if format == RewardsExportFormatCSV {
+			resp.Header().Set("Content-Type", "text/csv")
+		} else {
+			resp.Header().Set("Content-Type", "application/json")
+		}
+		err = WriteExportedRewards(resp, format, exported)
+		if err != nil {
+			utils.LavaFormatWarning("failed writing rewards export reply", err)
+		}
+	})
+	mux.HandleFunc(RewardsEstimatePath, func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		estimate, err := rws.EstimateRewards(req.Context(), req.URL.Query().Get("chainID"))
+		if err != nil {
+			http.Error(resp, "failed fetching the pairing params", http.StatusServiceUnavailable)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(resp).Encode(estimate)
+		if err != nil {
+			utils.LavaFormatWarning("failed writing rewards estimate reply", err)
+		}
+	})
+	mux.HandleFunc(RewardsReconciliationPath, func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		report := rws.LastReconciliationReport()
+		if report == nil {
+			http.Error(resp, "no payment reconciliation ran yet", http.StatusNotFound)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(resp).Encode(report)
+		if err != nil {
+			utils.LavaFormatWarning("failed writing payment reconciliation reply", err)
+		}
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			utils.LavaFormatError("rewards api server failed", err, utils.Attribute{Key: "address", Value: addr})
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	utils.LavaFormatInfo("started rewards api server", utils.Attribute{Key: "address", Value: addr}, utils.Attribute{Key: "path", Value: RewardsAPIPath})
+}
@@ -0,0 +1,76 @@
+package rewardserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRewardsStateServer(t *testing.T) *RewardServer {
+	ctx := context.Background()
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	for sessionID, spec := range map[uint64]string{1: "LAV1", 2: "LAV1", 3: "LAV2"} {
+		_, _, err := rws.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: spec, SessionId: sessionID, CuSum: 10, Epoch: 40, Sig: []byte(strconv.FormatUint(sessionID, 10))}, 40, consumer.String(), "rest")
+		require.NoError(t, err)
+	}
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 1})
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 15, BlockHeightDeadline: 20, Client: consumer, UniqueIdentifier: 2})
+	_, removed := rws.removeExpectedPayment(&PaymentRequest{ChainID: "LAV1", CU: 15, Client: consumer, UniqueIdentifier: 2, Amount: sdk.NewCoin("ulava", sdk.NewInt(3))})
+	require.True(t, removed)
+	return rws
+}
+
+func TestRewardsState(t *testing.T) {
+	rws := newTestRewardsStateServer(t)
+	state := rws.RewardsState("", 0)
+	require.Equal(t, strconv.FormatUint(rws.serverID, 10), state.ServerID)
+	require.Equal(t, []ChainEpochRewards{{ChainID: "LAV1", Epoch: 40, Sessions: 2, CU: 20}, {ChainID: "LAV2", Epoch: 40, Sessions: 1, CU: 10}}, state.Pending)
+	require.Equal(t, []ChainEpochRewards{{ChainID: "LAV1", Epoch: 20, Sessions: 1, CU: 10}}, state.Unpaid)
+	require.Equal(t, []ChainEpochRewards{{ChainID: "LAV1", Epoch: 20, Sessions: 1, CU: 15, Amount: "3ulava"}}, state.Paid)
+
+	state = rws.RewardsState("LAV2", 0)
+	require.Len(t, state.Pending, 1)
+	require.Empty(t, state.Unpaid)
+	require.Empty(t, state.Paid)
+	state = rws.RewardsState("", 20)
+	require.Empty(t, state.Pending)
+	require.Len(t, state.Unpaid, 1)
+}
+
+func TestRewardsStateHTTP(t *testing.T) {
+	rws := newTestRewardsStateServer(t)
+	recorder := httptest.NewRecorder()
+	rws.serveRewardsState(recorder, httptest.NewRequest(http.MethodGet, RewardsAPIPath+"?chainID=LAV1&epoch=40", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	state := RewardsState{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &state))
+	require.Equal(t, []ChainEpochRewards{{ChainID: "LAV1", Epoch: 40, Sessions: 2, CU: 20}}, state.Pending)
+	require.Empty(t, state.Paid)
+
+	recorder = httptest.NewRecorder()
+	rws.serveRewardsState(recorder, httptest.NewRequest(http.MethodGet, RewardsAPIPath+"?epoch=latest", nil))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = httptest.NewRecorder()
+	rws.serveRewardsState(recorder, httptest.NewRequest(http.MethodPost, RewardsAPIPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestPaidHistoryLimit(t *testing.T) {
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	for epoch := int64(1); epoch <= maxPaidHistory+1; epoch++ {
+		rws.recordPaidUnsafe(PaymentRequest{ChainID: "LAV1", BlockHeightDeadline: epoch}, &PaymentRequest{CU: 1})
+	}
+	// the oldest epoch is dropped
+	require.Len(t, rws.paidHistory, maxPaidHistory)
+	require.NotContains(t, rws.paidHistory, chainEpochKey{chainID: "LAV1", epoch: 1})
+	require.Contains(t, rws.paidHistory, chainEpochKey{chainID: "LAV1", epoch: maxPaidHistory + 1})
+}
@@ -0,0 +1,204 @@
+package rewardserver
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	RewardsExportPath       = "/lava/rewards/export"
+	RewardsExportFormatCSV  = "csv"
+	RewardsExportFormatJSON = "json"
+	maxPaidRewardsInMemory  = 100000 // paid sessions kept for the export without a reward db, the oldest are dropped first
+	paidRewardKeyPrefix     = "paid/"
+)
+
+// PaidReward is the payment of a claimed session, kept for the rewards export
+type PaidReward struct {
+	Block     int64  `json:"block"` // the block the payment event was emitted in
+	Epoch     uint64 `json:"epoch"` // the epoch of the relays of the session
+	ChainID   string `json:"chainID"`
+	Consumer  string `json:"consumer"`
+	SessionID uint64 `json:"sessionID"`
+	CU        uint64 `json:"cu"`
+	Amount    string `json:"amount"`
+}
+
+// ExportedRewards are the paid rewards of a consumer on a chain in an epoch
+type ExportedRewards struct {
+	Epoch            uint64 `json:"epoch"`
+	ChainID          string `json:"chainID"`
+	Consumer         string `json:"consumer"`
+	Sessions         int    `json:"sessions"`
+	CU               uint64 `json:"cu"`
+	Amount           string `json:"amount"`
+	LastPaymentBlock int64  `json:"lastPaymentBlock"`
+}
+
+var exportCSVHeader = []string{"epoch", "chain_id", "consumer", "sessions", "cu", "amount", "last_payment_block"}
+
+// paid reward keys are paid/block/chain/consumer/session, ordered by the payment block
+func paidRewardKey(paid PaidReward) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%s/%s/%d", paidRewardKeyPrefix, paid.Block, paid.ChainID, paid.Consumer, paid.SessionID))
+}
+
+func (trd *TmRewardDB) SavePaidReward(paid PaidReward) error {
+	value, err := json.Marshal(paid)
+	if err != nil {
+		return err
+	}
+	return trd.db.Set(paidRewardKey(paid), value)
+}
+
+func (trd *TmRewardDB) LoadPaidRewards(fromBlock int64, toBlock int64) ([]PaidReward, error) {
+	start := []byte(fmt.Sprintf("%s%020d", paidRewardKeyPrefix, fromBlock))
+	end := prefixEnd([]byte(paidRewardKeyPrefix))
+	if toBlock > 0 {
+		end = []byte(fmt.Sprintf("%s%020d", paidRewardKeyPrefix, toBlock+1))
+	}
+	iterator, err := trd.db.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	paidRewards := []PaidReward{}
+	for ; iterator.Valid(); iterator.Next() {
+		paid := PaidReward{}
+		err := json.Unmarshal(iterator.Value(), &paid)
+		if err != nil {
+			utils.LavaFormatWarning("skipping invalid paid reward in the reward db", err, utils.Attribute{Key: "key", Value: string(iterator.Key())})
+			continue
+		}
+		paidRewards = append(paidRewards, paid)
+	}
+	return paidRewards, iterator.Error()
+}
+
+func (rws *RewardServer) savePaidReward(expectedPayment PaymentRequest, payment *PaymentRequest) {
+	paid := PaidReward{
+		Block:     payment.BlockHeightDeadline,
+		Epoch:     uint64(expectedPayment.BlockHeightDeadline),
+		ChainID:   payment.ChainID,
+		Consumer:  payment.Client.String(),
+		SessionID: payment.UniqueIdentifier,
+		CU:        payment.CU,
+		Amount:    payment.Amount.String(),
+	}
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	if rws.rewardStore != nil {
+		err := rws.rewardStore.SavePaidReward(paid)
+		if err != nil {
+			utils.LavaFormatWarning("failed persisting paid reward, it's missing from the rewards export after a restart", err, utils.Attribute{Key: "payment", Value: payment})
+		}
+		return
+	}
+	rws.paidRewards = append(rws.paidRewards, paid)
+	if len(rws.paidRewards) > maxPaidRewardsInMemory {
+		rws.paidRewards = rws.paidRewards[len(rws.paidRewards)-maxPaidRewardsInMemory:]
+	}
+}
+
+// ExportedRewards sums the rewards paid in blocks [fromBlock, toBlock] per epoch, chain and consumer, a zero toBlock has no upper bound.
+// with a reward db all the payments seen since it was created are exported, without it only the payments since the provider started
+func (rws *RewardServer) ExportedRewards(fromBlock int64, toBlock int64) ([]ExportedRewards, error) {
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	paidRewards, err := rws.paidRewardsInRangeUnsafe(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	return aggregatePaidRewards(paidRewards), nil
+}
+
+// paidRewardsInRangeUnsafe returns the payments seen in blocks [fromBlock, toBlock], a zero toBlock has no upper bound
+func (rws *RewardServer) paidRewardsInRangeUnsafe(fromBlock int64, toBlock int64) (paidRewards []PaidReward, err error) {
+	if rws.rewardStore != nil {
+		paidRewards, err = rws.rewardStore.LoadPaidRewards(fromBlock, toBlock)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed loading paid rewards from the reward db", err)
+		}
+		return paidRewards, nil
+	}
+	for _, paid := range rws.paidRewards {
+		if paid.Block >= fromBlock && (toBlock == 0 || paid.Block <= toBlock) {
+			paidRewards = append(paidRewards, paid)
+		}
+	}
+	return paidRewards, nil
+}
+
+type exportKey struct {
+	epoch    uint64
+	chainID  string
+	consumer string
+}
+
+func aggregatePaidRewards(paidRewards []PaidReward) []ExportedRewards {
+	rows := map[exportKey]*ExportedRewards{}
+	amounts := map[exportKey]sdk.Coins{}
+	for _, paid := range paidRewards {
+		key := exportKey{epoch: paid.Epoch, chainID: paid.ChainID, consumer: paid.Consumer}
+		row, ok := rows[key]
+		if !ok {
+			row = &ExportedRewards{Epoch: paid.Epoch, ChainID: paid.ChainID, Consumer: paid.Consumer}
+			rows[key] = row
+			amounts[key] = sdk.Coins{}
+		}
+		row.Sessions++
+		row.CU += paid.CU
+		if paid.Block > row.LastPaymentBlock {
+			row.LastPaymentBlock = paid.Block
+		}
+		amount, err := sdk.ParseCoinNormalized(paid.Amount)
+		if err == nil && !amount.IsZero() {
+			amounts[key] = amounts[key].Add(amount)
+		}
+	}
+	exported := make([]ExportedRewards, 0, len(rows))
+	for key, row := range rows {
+		row.Amount = amounts[key].String()
+		exported = append(exported, *row)
+	}
+	sort.Slice(exported, func(i, j int) bool {
+		if exported[i].Epoch != exported[j].Epoch {
+			return exported[i].Epoch < exported[j].Epoch
+		}
+		if exported[i].ChainID != exported[j].ChainID {
+			return exported[i].ChainID < exported[j].ChainID
+		}
+		return exported[i].Consumer < exported[j].Consumer
+	})
+	return exported
+}
+
+// WriteExportedRewards writes the rows as csv with a header line or as a json array
+func WriteExportedRewards(writer io.Writer, format string, exported []ExportedRewards) error {
+	switch format {
+	case RewardsExportFormatJSON:
+		return json.NewEncoder(writer).Encode(exported)
+	case RewardsExportFormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		err := csvWriter.Write(exportCSVHeader)
+		if err != nil {
+			return err
+		}
+		for _, row := range exported {
+			err = csvWriter.Write([]string{strconv.FormatUint(row.Epoch, 10), row.ChainID, row.Consumer, strconv.Itoa(row.Sessions), strconv.FormatUint(row.CU, 10), row.Amount, strconv.FormatInt(row.LastPaymentBlock, 10)})
+			if err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	default:
+		return fmt.Errorf("unsupported rewards export format %q, use %s or %s", format, RewardsExportFormatCSV, RewardsExportFormatJSON)
+	}
+}
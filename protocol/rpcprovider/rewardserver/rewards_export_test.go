@@ -0,0 +1,43 @@
+package rewardserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func TestRewardsExport(t *testing.T) {
+	rewardDB := NewTmRewardDB(dbm.NewMemDB())
+	paidRewards := []PaidReward{
+		{Block: 110, Epoch: 100, ChainID: "LAV1", Consumer: "consumer1", SessionID: 1, CU: 10, Amount: "20ulava"},
+		{Block: 120, Epoch: 100, ChainID: "LAV1", Consumer: "consumer1", SessionID: 2, CU: 5, Amount: "10ulava"},
+		{Block: 120, Epoch: 100, ChainID: "ETH1", Consumer: "consumer1", SessionID: 1, CU: 7, Amount: "14ulava"},
+		{Block: 230, Epoch: 200, ChainID: "LAV1", Consumer: "consumer2", SessionID: 3, CU: 1, Amount: "2ulava"},
+	}
+	for _, paid := range paidRewards {
+		require.NoError(t, rewardDB.SavePaidReward(paid))
+	}
+	rws := NewRewardServer(nil, ClaimThresholds{})
+	rws.rewardStore = rewardDB
+
+	exported, err := rws.ExportedRewards(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, []ExportedRewards{
+		{Epoch: 100, ChainID: "ETH1", Consumer: "consumer1", Sessions: 1, CU: 7, Amount: "14ulava", LastPaymentBlock: 120},
+		{Epoch: 100, ChainID: "LAV1", Consumer: "consumer1", Sessions: 2, CU: 15, Amount: "30ulava", LastPaymentBlock: 120},
+		{Epoch: 200, ChainID: "LAV1", Consumer: "consumer2", Sessions: 1, CU: 1, Amount: "2ulava", LastPaymentBlock: 230},
+	}, exported)
+
+	// the block range is inclusive
+	exported, err = rws.ExportedRewards(120, 120)
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+	require.Equal(t, uint64(5), exported[1].CU)
+
+	output := &bytes.Buffer{}
+	require.NoError(t, WriteExportedRewards(output, RewardsExportFormatCSV, exported))
+	require.Equal(t, "epoch,chain_id,consumer,sessions,cu,amount,last_payment_block\n100,ETH1,consumer1,1,7,14ulava,120\n100,LAV1,consumer1,1,5,10ulava,120\n", output.String())
+	require.Error(t, WriteExportedRewards(output, "xml", exported))
+}
@@ -0,0 +1,96 @@
+package rewardserver
+
+import (
+	"sort"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	RewardsSummaryPath = "/rewards/summary"
+	summaryMonthLayout = "2006-01"
+)
+
+// ChainRewardsSummary is the dashboard view of the rewards of a chain
+type ChainRewardsSummary struct {
+	ChainID          string `json:"chainID"`
+	PendingCU        uint64 `json:"pendingCU"`     // cu of the proofs that weren't claimed yet
+	PendingClaims    int    `json:"pendingClaims"` // claimed sessions waiting for their payment
+	LastPaymentBlock int64  `json:"lastPaymentBlock"`
+	PaidThisMonth    string `json:"paidThisMonth"` // empty if nothing was paid
+}
+
+// RewardsSummary is the reply of the rewards summary endpoint, the month is in utc and payments seen before a restart aren't counted
+type RewardsSummary struct {
+	Month  string                `json:"month"`
+	Chains []ChainRewardsSummary `json:"chains"`
+}
+
+type chainPayments struct {
+	lastPaymentBlock int64
+	month            string
+	paidThisMonth    sdk.Coins
+}
+
+func (rws *RewardServer) recordChainPaymentUnsafe(chainID string, block int64, amount sdk.Coin, now time.Time) {
+	payments, ok := rws.chainPayments[chainID]
+	if !ok {
+		payments = &chainPayments{}
+		rws.chainPayments[chainID] = payments
+	}
+	if block > payments.lastPaymentBlock {
+		payments.lastPaymentBlock = block
+	}
+	month := now.UTC().Format(summaryMonthLayout)
+	if payments.month != month {
+		payments.month = month
+		payments.paidThisMonth = sdk.Coins{}
+	}
+	if amount.IsValid() && !amount.IsZero() {
+		payments.paidThisMonth = payments.paidThisMonth.Add(amount)
+	}
+}
+
+// RewardsSummary sums the pending cu, the claims waiting for payment and the payments of this month of every chain
+func (rws *RewardServer) RewardsSummary() RewardsSummary {
+	return rws.rewardsSummary(time.Now())
+}
+
+func (rws *RewardServer) rewardsSummary(now time.Time) RewardsSummary {
+	rws.lock.RLock()
+	defer rws.lock.RUnlock()
+	month := now.UTC().Format(summaryMonthLayout)
+	chains := map[string]*ChainRewardsSummary{}
+	chainEntry := func(chainID string) *ChainRewardsSummary {
+		entry, ok := chains[chainID]
+		if !ok {
+			entry = &ChainRewardsSummary{ChainID: chainID}
+			chains[chainID] = entry
+		}
+		return entry
+	}
+	for _, epochRewards := range rws.rewards {
+		for _, consumerRewards := range epochRewards.consumerRewards {
+			for _, proof := range consumerRewards.proofs {
+				chainEntry(proof.SpecId).PendingCU += proof.CuSum
+			}
+		}
+	}
+	for _, expectedPay := range rws.expectedPayments {
+		chainEntry(expectedPay.ChainID).PendingClaims++
+	}
+	for chainID, payments := range rws.chainPayments {
+		entry := chainEntry(chainID)
+		entry.LastPaymentBlock = payments.lastPaymentBlock
+		if payments.month == month {
+			entry.PaidThisMonth = payments.paidThisMonth.String()
+		}
+	}
+	summary := RewardsSummary{Month: month, Chains: make([]ChainRewardsSummary, 0, len(chains))}
+	for _, entry := range chains {
+		summary.Chains = append(summary.Chains, *entry)
+	}
+	sort.Slice(summary.Chains, func(i, j int) bool { return summary.Chains[i].ChainID < summary.Chains[j].ChainID })
+	return summary
+}
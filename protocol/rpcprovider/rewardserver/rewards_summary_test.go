@@ -0,0 +1,36 @@
+package rewardserver
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardsSummary(t *testing.T) {
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rws := NewRewardServer(nil, ClaimThresholds{})
+	rws.rewards[20] = &EpochRewards{epoch: 20, consumerRewards: map[string]*ConsumerRewards{
+		"LAV1": {epoch: 20, consumer: consumer.String(), proofs: map[uint64]*pairingtypes.RelaySession{1: {SpecId: "LAV1", SessionId: 1, CuSum: 10}, 2: {SpecId: "LAV1", SessionId: 2, CuSum: 5}}},
+	}}
+	rws.expectedPayments = []PaymentRequest{{ChainID: "ETH1", Client: consumer, CU: 7, UniqueIdentifier: 3, BlockHeightDeadline: 10}}
+
+	lastMonth := time.Date(2023, 4, 30, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 5, 2, 12, 0, 0, 0, time.UTC)
+	rws.recordChainPaymentUnsafe("ETH1", 90, sdk.NewInt64Coin("ulava", 100), lastMonth)
+	rws.recordChainPaymentUnsafe("ETH1", 120, sdk.NewInt64Coin("ulava", 30), now)
+	rws.recordChainPaymentUnsafe("ETH1", 110, sdk.NewInt64Coin("ulava", 20), now)
+
+	summary := rws.rewardsSummary(now)
+	require.Equal(t, "2023-05", summary.Month)
+	require.Equal(t, []ChainRewardsSummary{
+		{ChainID: "ETH1", PendingClaims: 1, LastPaymentBlock: 120, PaidThisMonth: "50ulava"},
+		{ChainID: "LAV1", PendingCU: 15},
+	}, summary.Chains)
+
+	// a month without payments doesn't show the previous month's
+	summary = rws.rewardsSummary(now.AddDate(0, 1, 0))
+	require.Equal(t, "", summary.Chains[0].PaidThisMonth)
+}
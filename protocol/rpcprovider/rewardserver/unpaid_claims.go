@@ -0,0 +1,156 @@
+package rewardserver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const maxClaimAttempts = 3 // the first claim included
+
+var (
+	reclaimedProofsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_reclaimed_proofs_total",
+		Help: "Relay proofs claimed again since no payment was seen for them and they were about to expire",
+	}, []string{"spec"})
+	lostClaimsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_provider_lost_claims_total",
+		Help: "Claimed relay proofs that were never paid before they left the lava memory",
+	}, []string{"spec"})
+)
+
+func init() {
+	prometheus.MustRegister(reclaimedProofsCounter, lostClaimsCounter)
+}
+
+// unpaidClaim is a claimed proof no payment was seen for yet, held to claim it again before it expires
+type unpaidClaim struct {
+	proof         *pairingtypes.RelaySession
+	consumer      string
+	claimedEpoch  uint64 // the epoch of the last claim
+	claimAttempts int
+}
+
+func unpaidClaimKey(chainID string, client string, epoch int64, sessionID uint64) string {
+	return fmt.Sprintf("%s/%s/%d/%d", chainID, client, epoch, sessionID)
+}
+
+func (claim *unpaidClaim) snapshot() ClaimSnapshot {
+	return ClaimSnapshot{Consumer: claim.consumer, ClaimedEpoch: claim.claimedEpoch, ClaimAttempts: claim.claimAttempts, Proof: claim.proof}
+}
+
+func (rws *RewardServer) addUnpaidClaim(proof *pairingtypes.RelaySession, client string, currentEpoch uint64) {
+	rws.lock.Lock()
+	defer rws.lock.Unlock()
+	claim := &unpaidClaim{proof: proof, consumer: client, claimedEpoch: currentEpoch, claimAttempts: 1}
+	rws.unpaidClaims[unpaidClaimKey(proof.SpecId, client, proof.Epoch, proof.SessionId)] = claim
+	rws.saveClaimUnsafe(claim)
+}
+
+// saveClaimUnsafe persists the claim so it's still expected and reclaimed after a crash
+func (rws *RewardServer) saveClaimUnsafe(claim *unpaidClaim) {
+	if rws.rewardStore == nil {
+		return
+	}
+	err := rws.rewardStore.SaveClaim(claim.snapshot())
+	if err != nil {
+		utils.LavaFormatWarning("failed persisting unpaid claim to the reward db", err, utils.Attribute{Key: "epoch", Value: claim.proof.Epoch}, utils.Attribute{Key: "consumer", Value: claim.consumer})
+	}
+}
+
+func (rws *RewardServer) deleteUnpaidClaimUnsafe(chainID string, client string, epoch int64, sessionID uint64) {
+	delete(rws.unpaidClaims, unpaidClaimKey(chainID, client, epoch, sessionID))
+	if rws.rewardStore == nil {
+		return
+	}
+	err := rws.rewardStore.DeleteClaim(uint64(epoch), client, chainID, sessionID)
+	if err != nil {
+		utils.LavaFormatWarning("failed removing unpaid claim from the reward db", err, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "consumer", Value: client})
+	}
+}
+
+// restoreClaimsUnsafe expects the payments of claims persisted before a crash, claims the server already holds from the shutdown
+// snapshot aren't expected twice
+func (rws *RewardServer) restoreClaimsUnsafe(claims []ClaimSnapshot) {
+	restored := 0
+	for _, claimSnapshot := range claims {
+		proof := claimSnapshot.Proof
+		key := unpaidClaimKey(proof.SpecId, claimSnapshot.Consumer, proof.Epoch, proof.SessionId)
+		if _, ok := rws.unpaidClaims[key]; ok {
+			continue
+		}
+		consumerAddr, err := sdk.AccAddressFromBech32(claimSnapshot.Consumer)
+		if err != nil {
+			utils.LavaFormatWarning("skipping unpaid claim with an invalid consumer address", err, utils.Attribute{Key: "consumer", Value: claimSnapshot.Consumer})
+			continue
+		}
+		rws.unpaidClaims[key] = &unpaidClaim{proof: proof, consumer: claimSnapshot.Consumer, claimedEpoch: claimSnapshot.ClaimedEpoch, claimAttempts: claimSnapshot.ClaimAttempts}
+		expectedPay := PaymentRequest{ChainID: proof.SpecId, CU: proof.CuSum, BlockHeightDeadline: proof.Epoch, Amount: sdk.Coin{}, Client: consumerAddr, UniqueIdentifier: proof.SessionId, Description: strconv.FormatUint(rws.serverID, 10)}
+		rws.expectedPayments = append(rws.expectedPayments, expectedPay)
+		rws.expectPaymentUnsafe(expectedPay)
+		restored++
+	}
+	if restored > 0 {
+		utils.LavaFormatInfo("restored unpaid claims from the reward db", utils.Attribute{Key: "claims", Value: restored})
+	}
+}
+
+// reclaimUnpaidProofs claims again the proofs that weren't paid since an earlier epoch and will expire within the recommended
+// collection window, a transaction per chain
+func (rws *RewardServer) reclaimUnpaidProofs(ctx context.Context, currentEpoch uint64) error {
+	rws.lock.RLock()
+	unpaid := len(rws.unpaidClaims)
+	rws.lock.RUnlock()
+	if unpaid == 0 {
+		return nil
+	}
+	earliestBlockInMemory, err := rws.rewardsTxSender.EarliestBlockInMemory(ctx)
+	if err != nil {
+		return utils.LavaFormatWarning("failed reading the earliest block in memory, can't reclaim unpaid proofs", err)
+	}
+	reclaimWindow, err := rws.rewardsTxSender.GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx)
+	if err != nil {
+		return utils.LavaFormatWarning("failed reading the recommended collection window, can't reclaim unpaid proofs", err)
+	}
+	reclaims := map[string][]*pairingtypes.RelaySession{} // key is the provider and the chain
+	rws.lock.Lock()
+	for _, claim := range rws.unpaidClaims {
+		epoch := uint64(claim.proof.Epoch)
+		if epoch < earliestBlockInMemory {
+			// expired, identifyMissingPayments counts it as lost
+			continue
+		}
+		if claim.claimedEpoch >= currentEpoch || claim.claimAttempts >= maxClaimAttempts || blocksToExpiry(epoch, earliestBlockInMemory) > reclaimWindow {
+			continue
+		}
+		claim.claimedEpoch = currentEpoch
+		claim.claimAttempts++
+		rws.saveClaimUnsafe(claim)
+		key := claimKey(claim.proof.Provider, claim.proof.SpecId)
+		reclaims[key] = append(reclaims[key], claim.proof)
+	}
+	rws.lock.Unlock()
+
+	keys := make([]string, 0, len(reclaims))
+	for key := range reclaims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		proofs := reclaims[key]
+		chainID, provider := proofs[0].SpecId, proofs[0].Provider
+		utils.LavaFormatWarning("claiming unpaid proofs again before they expire", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "provider", Value: provider}, utils.Attribute{Key: "sessions", Value: len(proofs)}, utils.Attribute{Key: "earliestBlockInMemory", Value: earliestBlockInMemory})
+		reclaimedProofsCounter.WithLabelValues(chainID).Add(float64(len(proofs)))
+		txErr := rws.claimSigner(provider).TxRelayPayment(ctx, proofs, nil, strconv.FormatUint(rws.serverID, 10))
+		if txErr != nil {
+			err = utils.LavaFormatError("failed sending unpaid proofs claim", txErr, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "provider", Value: provider})
+		}
+	}
+	return err
+}
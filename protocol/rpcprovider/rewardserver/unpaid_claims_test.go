@@ -0,0 +1,75 @@
+package rewardserver
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReclaimUnpaidProofs(t *testing.T) {
+	ctx := context.Background()
+	consumer := sdk.AccAddress([]byte("consumer____________")).String()
+	txSender := &fakeRewardsTxSender{}
+	rws := NewRewardServer(txSender, ClaimThresholds{})
+	rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 100}, consumer, 100)
+	rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV2", SessionId: 2, CuSum: 10, Epoch: 100}, consumer, 100)
+	claim := rws.unpaidClaims[unpaidClaimKey("LAV1", consumer, 100, 1)]
+	reclaimed := testutil.ToFloat64(reclaimedProofsCounter.WithLabelValues("LAV1"))
+
+	// far from expiring, the payment can still arrive
+	txSender.earliestBlockInMemory = 50
+	require.NoError(t, rws.reclaimUnpaidProofs(ctx, 120))
+	require.Empty(t, txSender.claims)
+
+	// within the collection window of expiring, a transaction per chain
+	txSender.earliestBlockInMemory = 85
+	require.NoError(t, rws.reclaimUnpaidProofs(ctx, 120))
+	require.Len(t, txSender.claims, 2)
+	require.Equal(t, 2, claim.claimAttempts)
+	require.Equal(t, uint64(120), claim.claimedEpoch)
+	require.Equal(t, reclaimed+1, testutil.ToFloat64(reclaimedProofsCounter.WithLabelValues("LAV1")))
+	// claimed once per epoch
+	require.NoError(t, rws.reclaimUnpaidProofs(ctx, 120))
+	require.Len(t, txSender.claims, 2)
+
+	// up to maxClaimAttempts claims in total
+	require.NoError(t, rws.reclaimUnpaidProofs(ctx, 140))
+	require.Equal(t, maxClaimAttempts, claim.claimAttempts)
+	require.NoError(t, rws.reclaimUnpaidProofs(ctx, 160))
+	require.Len(t, txSender.claims, 4)
+}
+
+func TestUnpaidClaimsLost(t *testing.T) {
+	ctx := context.Background()
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	txSender := &fakeRewardsTxSender{}
+	rws := NewRewardServer(txSender, ClaimThresholds{})
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 100, Client: consumer, UniqueIdentifier: 1})
+	rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 100}, consumer.String(), 100)
+	lost := testutil.ToFloat64(lostClaimsCounter.WithLabelValues("LAV1"))
+
+	// an expired proof isn't claimed again, it's counted as lost
+	txSender.earliestBlockInMemory = 101
+	require.NoError(t, rws.reclaimUnpaidProofs(ctx, 120))
+	require.Empty(t, txSender.claims)
+	missingPayments, err := rws.identifyMissingPayments(ctx)
+	require.NoError(t, err)
+	require.True(t, missingPayments)
+	require.Equal(t, lost+1, testutil.ToFloat64(lostClaimsCounter.WithLabelValues("LAV1")))
+	require.Empty(t, rws.unpaidClaims)
+	require.Empty(t, rws.expectedPayments)
+}
+
+func TestUnpaidClaimPaid(t *testing.T) {
+	consumer := sdk.AccAddress([]byte("consumer____________"))
+	rws := NewRewardServer(&fakeRewardsTxSender{}, ClaimThresholds{})
+	rws.addExpectedPayment(PaymentRequest{ChainID: "LAV1", CU: 10, BlockHeightDeadline: 100, Client: consumer, UniqueIdentifier: 1})
+	rws.addUnpaidClaim(&pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, CuSum: 10, Epoch: 100}, consumer.String(), 100)
+	// a payment of any of the claims settles the proof
+	require.True(t, rws.RemoveExpectedPayment(10, consumer, 130, 1, "LAV1"))
+	require.Empty(t, rws.unpaidClaims)
+}
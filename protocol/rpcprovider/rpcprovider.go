@@ -22,6 +22,7 @@ import (
 	"github.com/lavanet/lava/protocol/chaintracker"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/metrics"
 	"github.com/lavanet/lava/protocol/performance"
 	"github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
 	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
@@ -29,13 +30,17 @@ import (
 	"github.com/lavanet/lava/utils"
 	"github.com/lavanet/lava/utils/sigs"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 const (
-	ChainTrackerDefaultMemory  = 100
-	DEFAULT_ALLOWED_MISSING_CU = 0.2
+	ChainTrackerDefaultMemory    = 100
+	ChainTrackerFetchConcurrency = 10  // parallel hash fetches when the chain tracker fills its memory, mostly on startup
+	ChainTrackerPollingJitter    = 0.2 // chain trackers of several chains often share a node, jitter spreads their polls
+	ChainTrackerRegressionResync = 10  // polls a node stays below the tracked latest block before the chain tracker re-syncs from its height, a rolled back node would fail every poll otherwise
+	DEFAULT_ALLOWED_MISSING_CU   = 0.2
 )
 
 var (
@@ -45,8 +50,10 @@ var (
 
 type ProviderStateTrackerInf interface {
 	RegisterChainParserForSpecUpdates(ctx context.Context, chainParser chainlib.ChainParser, chainID string) error
+	RegisterForSpecUpdates(ctx context.Context, specUpdatable statetracker.SpecUpdatable, chainID string) error
 	RegisterReliabilityManagerForVoteUpdates(ctx context.Context, voteUpdatable statetracker.VoteUpdatable, endpointP *lavasession.RPCProviderEndpoint)
 	RegisterForEpochUpdates(ctx context.Context, epochUpdatable statetracker.EpochUpdatable)
+	RegisterForDowntimeUpdates(ctx context.Context, downtimeUpdatable statetracker.DowntimeUpdatable)
 	TxRelayPayment(ctx context.Context, relayRequests []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData, description string) error
 	SendVoteReveal(voteID string, vote *reliabilitymanager.VoteData) error
 	SendVoteCommitment(voteID string, vote *reliabilitymanager.VoteData) error
@@ -55,10 +62,14 @@ type ProviderStateTrackerInf interface {
 	VerifyPairing(ctx context.Context, consumerAddress string, providerAddress string, epoch uint64, chainID string) (valid bool, index, total int64, err error)
 	GetProvidersCountForConsumer(ctx context.Context, consumerAddress string, epoch uint64, chainID string) (uint32, error)
 	GetEpochSize(ctx context.Context) (uint64, error)
+	CurrentEpoch(ctx context.Context) (uint64, error)
 	EarliestBlockInMemory(ctx context.Context) (uint64, error)
 	RegisterPaymentUpdatableForPayments(ctx context.Context, paymentUpdatable statetracker.PaymentUpdatable)
 	GetRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error)
 	GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error)
+	GetPairingParams(ctx context.Context) (*pairingtypes.Params, error)
+	NewClaimTxSender(ctx context.Context, keyName string, address sdk.AccAddress) (*statetracker.ProviderTxSender, error)
+	GetBlockMaxBytes(ctx context.Context) (int64, error)
 }
 
 type RPCProvider struct {
@@ -67,7 +78,7 @@ type RPCProvider struct {
 	lock                 sync.Mutex
 }
 
-func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, rpcProviderEndpoints []*lavasession.RPCProviderEndpoint, cache *performance.Cache, parallelConnections uint) (err error) {
+func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, rpcProviderEndpoints []*lavasession.RPCProviderEndpoint, cache *performance.Cache, parallelConnections uint, options *ProviderOptions) (err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt)
@@ -76,17 +87,41 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 		cancel()
 	}()
 	rpcp.rpcProviderListeners = make(map[string]*ProviderListener)
-	// single state tracker
+	options.RelayWatchdog.Start(ctx)
+	// single state tracker, the lava node client is shared so switching lava nodes moves the chain fetcher as well
+	_, clientCtx = statetracker.NewLavaNodeClient(clientCtx)
 	lavaChainFetcher := chainlib.NewLavaChainFetcher(ctx, clientCtx)
 	providerStateTracker, err := statetracker.NewProviderStateTracker(ctx, txFactory, clientCtx, lavaChainFetcher)
 	if err != nil {
 		return err
 	}
+	providerStateTracker.SetSpecOverlays(options.SpecOverlays)
+	providerStateTracker.SetDowntimeDuration(options.DowntimeDuration)
+	err = providerStateTracker.SetReorgSafetyBlocks(options.ReorgSafetyBlocks)
+	if err != nil {
+		return err
+	}
+	err = providerStateTracker.SetTxGasAdjustment(options.TxGasAdjustment)
+	if err != nil {
+		return err
+	}
+	err = providerStateTracker.SetTxMaxPerBlock(options.TxMaxPerBlock)
+	if err != nil {
+		return err
+	}
+	providerStateTracker.SetClaimFeeStrategy(options.ClaimFeeStrategy)
+	providerStateTracker.SetUpdaterParallelism(options.UpdaterParallelism)
+	providerStateTracker.SetProcessingLagAlert(options.ProcessingLagAlertBlocks, nil)
+	if options.StateTrackerDebugAddress != "" {
+		providerStateTracker.StartDebugServer(ctx, options.StateTrackerDebugAddress)
+	}
+	err = providerStateTracker.StartLavaNodeFailover(ctx, options.LavaNodeBackups)
+	if err != nil {
+		return err
+	}
+	// the shutdown action cancels ctx, which tears down the listeners and saves the shutdown snapshot
+	protocolVersionUpdater := providerStateTracker.EnforceProtocolVersion(ctx, version.Version, options.ProtocolVersionAction, cancel)
 	rpcp.providerStateTracker = providerStateTracker
-	// single reward server
-	rewardServer := rewardserver.NewRewardServer(providerStateTracker)
-	rpcp.providerStateTracker.RegisterForEpochUpdates(ctx, rewardServer)
-	rpcp.providerStateTracker.RegisterPaymentUpdatableForPayments(ctx, rewardServer)
 	keyName, err := sigs.GetKeyName(clientCtx)
 	if err != nil {
 		utils.LavaFormatFatal("failed getting key name from clientCtx", err)
@@ -103,6 +138,49 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 		utils.LavaFormatFatal("failed unmarshaling public address", err, utils.Attribute{Key: "keyName", Value: keyName}, utils.Attribute{Key: "pubkey", Value: clientKey.GetPubKey().Address()})
 	}
 	utils.LavaFormatInfo("RPCProvider pubkey: " + addr.String())
+	var shutdownSnapshot *ProviderShutdownSnapshot
+	if options.ShutdownSnapshotPath != "" {
+		shutdownSnapshot = readShutdownSnapshot(ctx, options.ShutdownSnapshotPath, addr.String(), providerStateTracker)
+	}
+	// single reward server
+	rewardServer := rewardserver.NewRewardServer(providerStateTracker, options.ClaimThresholds)
+	err = rewardServer.Restore(shutdownSnapshot.rewards())
+	if err != nil {
+		utils.LavaFormatError("failed restoring reward server from the shutdown snapshot", err)
+	}
+	if options.RewardDBPath != "" {
+		rewardStore, err := rewardserver.OpenRewardStore(options.RewardDBPath, options.RewardDBBackend)
+		if err != nil {
+			return err
+		}
+		defer rewardStore.Close()
+		err = rewardServer.SetRewardStore(rewardStore)
+		if err != nil {
+			return err
+		}
+	}
+	rewardServer.SetPaymentAlerts(options.PaymentAlerts)
+	rewardServer.SetPaymentNotifier(options.PaymentNotifier)
+	rewardServer.SetClaimQueueLimits(options.ClaimQueueLimits)
+	defaultWallet := &providerWallet{keyName: keyName, privKey: privKey, address: addr}
+	wallets, err := setupProviderWallets(ctx, clientCtx, defaultWallet, rpcProviderEndpoints, providerStateTracker, rewardServer)
+	if err != nil {
+		return err
+	}
+	if options.RewardsAPIAddress != "" {
+		rewardServer.StartRewardsAPIServer(ctx, options.RewardsAPIAddress)
+	}
+	startPaymentReconciliation(ctx, options.PaymentReconciliationInterval, providerStateTracker, rewardServer, wallets)
+	rpcp.providerStateTracker.RegisterForEpochUpdates(ctx, rewardServer)
+	rpcp.providerStateTracker.RegisterPaymentUpdatableForPayments(ctx, rewardServer)
+	rpcp.providerStateTracker.RegisterForDowntimeUpdates(ctx, rewardServer)
+	providerStateTracker.RegisterForDelegatorRewardsUpdates(ctx, NewDelegatorRewardsHandler(ctx, options.DelegatorRewardsClaimInterval, providerStateTracker))
+	// shared by all endpoints, so chains served from the same node learn its methods together
+	methodAvailability := chainlib.NewMethodAvailabilityCache(chainlib.DefaultMethodAvailabilityTTL)
+	manifestServer := NewProviderManifestServer(privKey, addr, lavaChainID, methodAvailability)
+	providerGeolocations := NewProviderGeolocations()
+	providerGeolocations.Start(ctx)
+	providerGeolocations.SetProcessHealthCheck(protocolVersionUpdater.Healthy)
 	utils.LavaFormatInfo("RPCProvider setting up endpoints", utils.Attribute{Key: "count", Value: strconv.Itoa(len(rpcProviderEndpoints))})
 	blockMemorySize, err := rpcp.providerStateTracker.GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx) // get the number of blocks to keep in PSM.
 	if err != nil {
@@ -117,7 +195,12 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 			endpoint.NetworkAddress = rpcProviderEndpoints[idx-1].NetworkAddress
 		}
 	}
+	for chainID := range chainMutexes {
+		providerStateTracker.RegisterForStakeStatusUpdates(ctx, NewStakeStatusHandler(ctx, chainID, options.AutoUnfreeze, providerStateTracker), chainID)
+	}
+	// keyed by chain and geolocation, each geolocation has its own nodes so it gets its own chain tracker and health
 	var stateTrackersPerChain sync.Map
+	var sessionManagersPerEndpoint sync.Map // kept for the shutdown snapshot
 	var wg sync.WaitGroup
 	parallelJobs := len(rpcProviderEndpoints)
 	wg.Add(parallelJobs)
@@ -131,8 +214,15 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 				return utils.LavaFormatError("panic severity critical error, aborting support for chain api due to invalid node url definition, continuing with others", err, utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint.String()})
 			}
 			chainID := rpcProviderEndpoint.ChainID
+			chainTrackerKey := chainID + "-" + strconv.FormatUint(rpcProviderEndpoint.Geolocation, 10)
 			providerSessionManager := lavasession.NewProviderSessionManager(rpcProviderEndpoint, blockMemorySize)
+			err = providerSessionManager.Restore(shutdownSnapshot.sessions(rpcProviderEndpoint.Key()))
+			if err != nil {
+				utils.LavaFormatError("failed restoring provider sessions from the shutdown snapshot", err, utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint.Key()})
+			}
+			sessionManagersPerEndpoint.Store(rpcProviderEndpoint.Key(), providerSessionManager)
 			rpcp.providerStateTracker.RegisterForEpochUpdates(ctx, providerSessionManager)
+			rpcp.providerStateTracker.RegisterForDowntimeUpdates(ctx, providerSessionManager)
 			chainParser, err := chainlib.NewChainParser(rpcProviderEndpoint.ApiInterface)
 			if err != nil {
 				disabledEndpoints <- rpcProviderEndpoint
@@ -153,27 +243,50 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 			chainCommonSetup := func() error {
 				chainMutexes[chainID].Lock()
 				defer chainMutexes[chainID].Unlock()
-				chainTrackerInf, found := stateTrackersPerChain.Load(chainID)
+				chainTrackerInf, found := stateTrackersPerChain.Load(chainTrackerKey)
 				if !found {
-					blocksToSaveChainTracker := uint64(blocksToFinalization + blocksInFinalizationData)
+					blocksToSaveChainTracker, serverBlockMemory, finalizationDistance := chainTrackerBlocksWindow(blocksToFinalization, blocksInFinalizationData)
 					chainTrackerConfig := chaintracker.ChainTrackerConfig{
-						BlocksToSave:      blocksToSaveChainTracker,
-						AverageBlockTime:  averageBlockTime,
-						ServerBlockMemory: ChainTrackerDefaultMemory + blocksToSaveChainTracker,
+						BlocksToSave:                blocksToSaveChainTracker,
+						AverageBlockTime:            averageBlockTime,
+						ServerBlockMemory:           serverBlockMemory,
+						FinalizationDistance:        finalizationDistance,
+						FetchConcurrency:            ChainTrackerFetchConcurrency,
+						BlockBodyRetention:          options.BlockBodyRetention,
+						PollingJitter:               ChainTrackerPollingJitter,
+						HeightRegressionResyncPolls: ChainTrackerRegressionResync,
+						InitialSnapshot:             shutdownSnapshot.chainTracker(chainTrackerKey),
+					}
+					if _, ok := chainParser.GetSpecApiByTag(spectypes.GET_BLOCK_BY_NUM); !ok {
+						// the node can't be queried for block hashes, serve heights only instead of dropping the endpoint
+						chainTrackerConfig.HashlessMode = true
+					}
+					if rpcProviderEndpoint.LightClient != nil {
+						chainTrackerConfig.HashVerifier, err = newLightClientVerifier(ctx, rpcProviderEndpoint)
+						if err != nil {
+							return utils.LavaFormatError("panic severity critical error, aborting support for chain api due to light client setup, continuing with other endpoints", err, utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint})
+						}
 					}
 					chainFetcher := chainlib.NewChainFetcher(ctx, chainProxy, chainParser, rpcProviderEndpoint)
 					chainTracker, err = chaintracker.NewChainTracker(ctx, chainFetcher, chainTrackerConfig)
 					if err != nil {
 						return utils.LavaFormatError("panic severity critical error, aborting support for chain api due to node access, continuing with other endpoints", err, utils.Attribute{Key: "chainTrackerConfig", Value: chainTrackerConfig}, utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint})
 					}
-					stateTrackersPerChain.Store(rpcProviderEndpoint.ChainID, chainTracker)
+					stateTrackersPerChain.Store(chainTrackerKey, chainTracker)
+					// claims are revalidated when the chain reorgs finalized hashes signed to consumers
+					rewardServer.RegisterChainTracker(chainID, chainTracker)
+					// the window follows governance changes of the spec finalization parameters
+					err = providerStateTracker.RegisterForSpecUpdates(ctx, &chainTrackerSpecUpdater{chainTracker: chainTracker, config: chainTrackerConfig}, chainID)
+					if err != nil {
+						utils.LavaFormatError("failed registering chain tracker for spec updates, the window won't follow spec changes until restart", err, utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint})
+					}
 				} else {
 					var ok bool
 					chainTracker, ok = chainTrackerInf.(*chaintracker.ChainTracker)
 					if !ok {
 						utils.LavaFormatFatal("invalid usage of syncmap, could not cast result into a chaintracker", nil)
 					}
-					utils.LavaFormatDebug("reusing chain tracker", utils.Attribute{Key: "chain", Value: rpcProviderEndpoint.ChainID})
+					utils.LavaFormatDebug("reusing chain tracker", utils.Attribute{Key: "chain", Value: rpcProviderEndpoint.ChainID}, utils.Attribute{Key: "geolocation", Value: rpcProviderEndpoint.Geolocation})
 				}
 
 				return nil
@@ -183,11 +296,25 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 				disabledEndpoints <- rpcProviderEndpoint
 				return err
 			}
+			// a failed probe isn't critical, unsupported methods are also learned from the node replies
+			methodAvailability.ProbeModules(ctx, rpcProviderEndpoint.UrlsString(), rpcProviderEndpoint.ApiInterface, chainParser, chainProxy)
 			reliabilityManager := reliabilitymanager.NewReliabilityManager(chainTracker, providerStateTracker, addr.String(), chainProxy, chainParser)
 			providerStateTracker.RegisterReliabilityManagerForVoteUpdates(ctx, reliabilityManager, rpcProviderEndpoint)
 
+			var blockStore BlockStoreInf
+			if options.BlockBodyRetention != nil {
+				blockStore = chainTracker
+			}
+			wallet := endpointWallet(wallets, defaultWallet, rpcProviderEndpoint)
 			rpcProviderServer := &RPCProviderServer{}
-			rpcProviderServer.ServeRPCRequests(ctx, rpcProviderEndpoint, chainParser, rewardServer, providerSessionManager, reliabilityManager, privKey, cache, chainProxy, providerStateTracker, addr, lavaChainID, DEFAULT_ALLOWED_MISSING_CU)
+			rpcProviderServer.ServeRPCRequests(ctx, rpcProviderEndpoint, chainParser, rewardServer, providerSessionManager, reliabilityManager, wallet.privKey, cache, chainProxy, providerStateTracker, wallet.address, lavaChainID, DEFAULT_ALLOWED_MISSING_CU, RPCProviderServerOptions{
+				LatencySLOTracker:    options.LatencySLOTracker,
+				NodeRequestScheduler: NewNodeRequestScheduler(chainID, rpcProviderEndpoint.ApiInterface, options.NodeMaxInFlight),
+				RelayWatchdog:        options.RelayWatchdog,
+				BlockStore:           blockStore,
+				MaxRangeBlocks:       options.MaxRangeBlocks,
+				MethodAvailability:   methodAvailability,
+			})
 			// set up grpc listener
 			var listener *ProviderListener
 			func() {
@@ -197,7 +324,7 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 				listener, ok = rpcp.rpcProviderListeners[rpcProviderEndpoint.NetworkAddress]
 				if !ok {
 					utils.LavaFormatDebug("creating new listener", utils.Attribute{Key: "NetworkAddress", Value: rpcProviderEndpoint.NetworkAddress})
-					listener = NewProviderListener(ctx, rpcProviderEndpoint.NetworkAddress)
+					listener = NewProviderListener(ctx, rpcProviderEndpoint.NetworkAddress, manifestServer, providerGeolocations, privKey.PubKey().SerializeCompressed())
 					rpcp.rpcProviderListeners[rpcProviderEndpoint.NetworkAddress] = listener
 				}
 			}()
@@ -205,13 +332,15 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 				utils.LavaFormatFatal("listener not defined, cant register RPCProviderServer", nil, utils.Attribute{Key: "RPCProviderEndpoint", Value: rpcProviderEndpoint.String()})
 			}
 			listener.RegisterReceiver(rpcProviderServer, rpcProviderEndpoint)
+			manifestServer.RegisterEndpoint(rpcProviderEndpoint, chainTracker)
+			providerGeolocations.RegisterEndpoint(rpcProviderEndpoint, chainTracker)
 			utils.LavaFormatDebug("provider finished setting up endpoint", utils.Attribute{Key: "endpoint", Value: rpcProviderEndpoint.Key()})
 			return nil
 		}(rpcProviderEndpoint) // continue on error
 	}
 	wg.Wait()
 	close(disabledEndpoints)
-	utils.LavaFormatInfo("RPCProvider done setting up endpoints, ready for service")
+	utils.LavaFormatInfo("RPCProvider done setting up endpoints, ready for service", utils.Attribute{Key: "geolocations", Value: providerGeolocations.Geolocations()})
 	disabledEndpointsList := []*lavasession.RPCProviderEndpoint{}
 	for disabledEndpoint := range disabledEndpoints {
 		disabledEndpointsList = append(disabledEndpointsList, disabledEndpoint)
@@ -235,17 +364,49 @@ func (rpcp *RPCProvider) Start(ctx context.Context, txFactory tx.Factory, client
 		listener.Shutdown(shutdownCtx)
 		defer shutdownRelease()
 	}
+	if options.ShutdownSnapshotPath != "" {
+		rpcp.saveShutdownSnapshot(options.ShutdownSnapshotPath, addr.String(), rewardServer, &sessionManagersPerEndpoint, &stateTrackersPerChain)
+	}
 
 	return nil
 }
 
+// the listeners are already shut down, the ctx may be done so the epoch is queried with a new one
+func (rpcp *RPCProvider) saveShutdownSnapshot(path string, provider string, rewardServer *rewardserver.RewardServer, sessionManagers *sync.Map, chainTrackers *sync.Map) {
+	epochCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	epoch, err := rpcp.providerStateTracker.CurrentEpoch(epochCtx)
+	if err != nil {
+		utils.LavaFormatError("failed reading the current epoch, shutdown snapshot not saved", err)
+		return
+	}
+	snapshot, err := collectShutdownSnapshot(provider, epoch, rewardServer, sessionManagers, chainTrackers)
+	if err != nil {
+		utils.LavaFormatError("failed collecting shutdown snapshot", err)
+		return
+	}
+	err = writeShutdownSnapshot(path, snapshot)
+	if err != nil {
+		utils.LavaFormatError("failed writing shutdown snapshot", err, utils.Attribute{Key: "path", Value: path})
+		return
+	}
+	utils.LavaFormatInfo("shutdown snapshot saved", utils.Attribute{Key: "path", Value: path}, utils.Attribute{Key: "epoch", Value: epoch},
+		utils.Attribute{Key: "endpoints", Value: len(snapshot.Sessions)}, utils.Attribute{Key: "chainTrackers", Value: len(snapshot.ChainTrackers)})
+}
+
+// ParseEndpoints uses geolocation for endpoints that don't set their own, so one process can serve several geolocations
 func ParseEndpoints(viper_endpoints *viper.Viper, geolocation uint64) (endpoints []*lavasession.RPCProviderEndpoint, err error) {
 	err = viper_endpoints.UnmarshalKey(common.EndpointsConfigName, &endpoints)
 	if err != nil {
 		utils.LavaFormatFatal("could not unmarshal endpoints", err, utils.Attribute{Key: "viper_endpoints", Value: viper_endpoints.AllSettings()})
 	}
 	for _, endpoint := range endpoints {
-		endpoint.Geolocation = geolocation
+		if endpoint.Geolocation == 0 {
+			endpoint.Geolocation = geolocation
+		}
+		if endpoint.Geolocation == 0 {
+			return nil, utils.LavaFormatError("endpoint has no geolocation, set it in the endpoint config or with the geolocation flag", nil, utils.Attribute{Key: "endpoint", Value: endpoint.String()})
+		}
 	}
 	return
 }
@@ -260,7 +421,7 @@ func CreateRPCProviderCobraCommand() *cobra.Command {
 		if one argument is passed, its assumed the config file name
 		--gas-adjustment "1.5" --gas "auto" --gas-prices $GASPRICE are necessary to send reward transactions, according to the current lava gas price set in validators
 		`,
-		Example: `required flags: --geolocation 1 --from alice
+		Example: `required flags: --from alice, and --geolocation 1 unless every endpoint in the config sets its geolocation
 optional: --save-conf
 rpcprovider <flags>
 rpcprovider rpcprovider_conf.yml <flags>
@@ -321,7 +482,7 @@ rpcprovider 127.0.0.1:3333 COS3 tendermintrpc "wss://www.node-path.com:80,https:
 			}
 			geolocation, err := cmd.Flags().GetUint64(lavasession.GeolocationFlag)
 			if err != nil {
-				utils.LavaFormatFatal("failed to read geolocation flag, required flag", err)
+				utils.LavaFormatFatal("failed to read geolocation flag", err)
 			}
 			rpcProviderEndpoints, err = ParseEndpoints(viper.GetViper(), geolocation)
 			if err != nil || len(rpcProviderEndpoints) == 0 {
@@ -378,8 +539,22 @@ rpcprovider 127.0.0.1:3333 COS3 tendermintrpc "wss://www.node-path.com:80,https:
 			for _, endpoint := range rpcProviderEndpoints {
 				utils.LavaFormatDebug("endpoint description", utils.Attribute{Key: "endpoint", Value: endpoint})
 			}
+			metricsListenAddress, err := cmd.Flags().GetString(metrics.MetricsListenFlagName)
+			if err != nil {
+				utils.LavaFormatFatal("failed to read metrics listen address flag", err)
+			}
+			if metricsListenAddress != "" {
+				err = metrics.StartPrometheusServer(metricsListenAddress)
+				if err != nil {
+					return utils.LavaFormatError("failed to start prometheus metrics server", err)
+				}
+			}
+			options, err := ParseProviderOptions(cmd, networkChainId)
+			if err != nil {
+				return err
+			}
 			rpcProvider := RPCProvider{}
-			err = rpcProvider.Start(ctx, txFactory, clientCtx, rpcProviderEndpoints, cache, numberOfNodeParallelConnections)
+			err = rpcProvider.Start(ctx, txFactory, clientCtx, rpcProviderEndpoints, cache, numberOfNodeParallelConnections, options)
 			return err
 		},
 	}
@@ -389,12 +564,50 @@ rpcprovider 127.0.0.1:3333 COS3 tendermintrpc "wss://www.node-path.com:80,https:
 	cmdRPCProvider.MarkFlagRequired(flags.FlagFrom)
 	cmdRPCProvider.Flags().Bool(common.SaveConfigFlagName, false, "save cmd args to a config file")
 	cmdRPCProvider.Flags().String(flags.FlagChainID, app.Name, "network chain id")
-	cmdRPCProvider.Flags().Uint64(common.GeolocationFlag, 0, "geolocation to run from")
-	cmdRPCProvider.MarkFlagRequired(common.GeolocationFlag)
+	cmdRPCProvider.Flags().Uint64(common.GeolocationFlag, 0, "geolocation to run from, used for endpoints that don't set a geolocation in the config")
 	cmdRPCProvider.Flags().String(performance.PprofAddressFlagName, "", "pprof server address, used for code profiling")
 	cmdRPCProvider.Flags().String(performance.CacheFlagName, "", "address for a cache server to improve performance")
 	cmdRPCProvider.Flags().Uint(chainproxy.ParallelConnectionsFlag, chainproxy.NumberOfParallelConnections, "parallel connections")
+	cmdRPCProvider.Flags().Uint(NodeMaxInFlightFlagName, DefaultNodeMaxInFlight, "max concurrent requests sent to each node, further relays are queued with latest block requests first, 0 for unlimited")
+	cmdRPCProvider.Flags().Duration(RelayHardCeilingFlagName, DefaultRelayHardCeiling, "relays waiting on the node longer than this are force cancelled and their stack is logged, 0 to disable")
+	cmdRPCProvider.Flags().Uint64(BlockBodyRetentionFlagName, 0, "number of latest blocks to keep in memory to serve get block by number requests without the node, bounded by the chain's finalization blocks, 0 to disable")
+	cmdRPCProvider.Flags().Uint64(BlockBodyMemoryLimitFlagName, chaintracker.DefaultBlockBodyMemoryLimit, "max compressed bytes of retained blocks per chain")
 	cmdRPCProvider.Flags().String(flags.FlagLogLevel, "debug", "log level")
+	cmdRPCProvider.Flags().String(statetracker.SpecOverlayFlagName, "", "path to a json file with local spec modifications for devnets and forks, disabled on mainnet")
+	cmdRPCProvider.Flags().Uint64(MaxRangeBlocksFlagName, 0, "range queries (eth_getLogs) spanning more blocks are served in parts to consumers that support continuation tokens, 0 to disable")
+	cmdRPCProvider.Flags().String(metrics.MetricsListenFlagName, "", "address to expose prometheus metrics on, disabled if empty")
+	cmdRPCProvider.Flags().Duration(statetracker.DowntimeDurationFlagName, statetracker.DefaultDowntimeDuration, "time without new lava blocks after which lava is considered down and consumers are allowed more compute units per virtual epoch")
+	cmdRPCProvider.Flags().StringSlice(statetracker.LavaNodeBackupsFlagName, []string{}, "backup lava node rpc addresses in order of preference, queries and transactions fail over to them when the --node fails or falls behind")
+	cmdRPCProvider.Flags().String(statetracker.StateTrackerDebugAddressFlag, "", "address to serve the state tracker debug listing of updaters, updatables and their last results on, disabled if empty")
+	cmdRPCProvider.Flags().Bool(AutoUnfreezeFlagName, false, "send an unfreeze transaction when the provider is found frozen on a served chain, don't use it if you freeze the provider yourself for maintenance")
+	cmdRPCProvider.Flags().Uint64(statetracker.ProcessingLagAlertFlag, statetracker.DefaultProcessingLagAlertBlocks, "lava blocks the state tracker can fall behind the chain tip before a warning is logged, 0 disables the warning")
+	cmdRPCProvider.Flags().Uint64(statetracker.UpdaterParallelismFlag, statetracker.DefaultUpdaterParallelism, "how many state tracker updaters of the same priority run at once on a new lava block, so a slow query doesn't delay the others")
+	cmdRPCProvider.Flags().Float64(statetracker.TxGasAdjustmentFlag, statetracker.DefaultTxGasAdjustment, "multiplier of the simulated gas of reward claims, conflict votes and unfreeze transactions")
+	cmdRPCProvider.Flags().Int(statetracker.TxMaxPerBlockFlag, 0, "send at most this many transactions of an account while the latest lava block doesn't change, the rest wait for the next block, 0 is unlimited")
+	cmdRPCProvider.Flags().Duration(DelegatorRewardsClaimIntervalFlagName, 0, "claim the rewards of the provider address delegations to validators on this interval, 0 only exports them as metrics")
+	cmdRPCProvider.Flags().String(rewardserver.RewardDBPathFlagName, "", "directory of the on-disk db the relay proofs and claims are persisted to until they're paid, or the address of a registered reward store backend, empty keeps them only in memory")
+	cmdRPCProvider.Flags().String(rewardserver.RewardDBBackendFlagName, rewardserver.DefaultRewardDBBackend, "tm-db backend of the reward db, memory or a reward store backend registered with rewardserver.RegisterRewardStoreBackend")
+	cmdRPCProvider.Flags().Uint64(rewardserver.ClaimCUThresholdFlagName, 0, "claim the rewards of a chain once its claimable proofs reach this much cu, with no claim threshold set rewards are claimed every epoch")
+	cmdRPCProvider.Flags().Int(rewardserver.ClaimProofsThresholdFlagName, 0, "claim the rewards of a chain once it has this many claimable proofs")
+	cmdRPCProvider.Flags().Uint64(rewardserver.ClaimExpiryBlocksFlagName, 0, "claim the rewards of a chain once its oldest claimable proof is this many blocks from expiring, should be at least an epoch")
+	cmdRPCProvider.Flags().Int(rewardserver.ClaimMaxRelaysPerTxFlagName, 0, "split the rewards claim of a chain into transactions of at most this many relays, 0 only splits by the lava block max bytes")
+	cmdRPCProvider.Flags().Int(rewardserver.ClaimMaxTxsPerEpochFlagName, 0, "send at most this many rewards claim transactions an epoch, the proofs closest to expiring first, 0 is unlimited")
+	cmdRPCProvider.Flags().String(statetracker.ClaimGasPricesFlag, "", "gas prices of the reward claim transactions, empty uses the default gas price")
+	cmdRPCProvider.Flags().Float64(statetracker.ClaimGasAdjustmentFlag, 0, "multiplier of the simulated gas of the reward claim transactions, 0 uses --"+statetracker.TxGasAdjustmentFlag)
+	cmdRPCProvider.Flags().String(statetracker.ClaimFeeGranterFlag, "", "address that pays the fee of the reward claim transactions through a fee grant")
+	cmdRPCProvider.Flags().String(statetracker.ClaimMaxFeeFlag, "", "reward claim transactions with a higher fee aren't sent, empty is unlimited")
+	cmdRPCProvider.Flags().Int(statetracker.ClaimOutOfGasRetriesFlag, statetracker.DefaultOutOfGasRetries, "times a reward claim that ran out of gas is sent again with more gas")
+	cmdRPCProvider.Flags().String(rewardserver.RewardsAPIAddressFlagName, "", "address to serve the pending, unpaid and paid rewards by chain and epoch, the rewards summary and the rewards estimate on, disabled if empty")
+	cmdRPCProvider.Flags().String(rewardserver.PaymentAlertWebhookFlagName, "", "url the missing payment and failed claim alerts are posted to as json")
+	cmdRPCProvider.Flags().String(rewardserver.PaymentAlertCommandFlagName, "", "shell command run on a missing payment or failed claim alert, with the alert json on stdin")
+	cmdRPCProvider.Flags().Uint64(rewardserver.PaymentAlertMissingEpochsFlagName, rewardserver.DefaultPaymentAlertMissingEpochs, "alert when no payment of a chain was seen for this many epochs after a claim was sent, 0 disables it")
+	cmdRPCProvider.Flags().Int(rewardserver.PaymentAlertFailedClaimsFlagName, rewardserver.DefaultPaymentAlertFailedClaims, "alert when this many claim transactions of a chain fail in a row, 0 disables it")
+	cmdRPCProvider.Flags().StringSlice(rewardserver.PaymentWebhookFlagName, []string{}, "urls every payment credited to the provider is posted to as json, with its chain, epoch, cu and amount")
+	cmdRPCProvider.Flags().String(rewardserver.PaymentCommandFlagName, "", "shell command run on every payment credited to the provider, with the payment json on stdin")
+	cmdRPCProvider.Flags().Duration(PaymentReconciliationIntervalFlagName, 0, "diff the payments the lava chain emitted for the provider wallets against the local claims on this interval, reporting unpaid, double paid and unknown payments on the rewards api, 0 disables it")
+	cmdRPCProvider.Flags().Uint64(statetracker.ReorgSafetyBlocksFlag, 0, "blocks behind the latest lava block that payment and conflict vote events are processed at, so reorged events aren't acted on, 0 processes them at the latest block")
+	cmdRPCProvider.Flags().String(statetracker.ProtocolVersionActionFlag, string(statetracker.ProtocolVersionActionWarn), "what to do when the binary is below the minimum protocol version of the lava chain: warn, unhealthy (also fail the health check) or shutdown")
+	cmdRPCProvider.Flags().String(ShutdownSnapshotFlagName, "", "file to save sessions, unclaimed rewards and chain trackers to on graceful shutdown, restored on startup if the epoch hasn't rolled, disabled if empty")
 
 	return cmdRPCProvider
 }
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/dgraph-io/ristretto"
 	"github.com/gogo/status"
 	"github.com/lavanet/lava/protocol/chainlib"
 	"github.com/lavanet/lava/protocol/chainlib/chainproxy/rpcInterfaceMessages"
@@ -29,6 +31,7 @@ type RPCProviderServer struct {
 	cache                     *performance.Cache
 	chainProxy                chainlib.ChainProxy
 	privKey                   *btcec.PrivateKey
+	payloadCiphers            *ristretto.Cache // session keys of encrypted relays, keyed by the consumer's ephemeral key
 	reliabilityManager        ReliabilityManagerInf
 	providerSessionManager    *lavasession.ProviderSessionManager
 	rewardServer              RewardServerInf
@@ -38,18 +41,25 @@ type RPCProviderServer struct {
 	providerAddress           sdk.AccAddress
 	lavaChainID               string
 	allowedMissingCUThreshold float64
+	latencySLOTracker         *LatencySLOTracker
+	nodeRequestScheduler      *NodeRequestScheduler
+	relayWatchdog             *RelayWatchdog
+	blockStore                BlockStoreInf
+	maxRangeBlocks            uint64 // range queries longer than this are served in parts to consumers that support it
+	methodAvailability        *chainlib.MethodAvailabilityCache
 }
 
 type ReliabilityManagerInf interface {
-	GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64) (latestBlock int64, requestedHashes []*chaintracker.BlockStore, err error)
+	GetLatestBlockData(fromBlock int64, toBlock int64, specificBlock int64, finalizedOnly bool) (latestBlock int64, requestedHashes []*chaintracker.BlockStore, err error)
 	GetLatestBlockNum() int64
 }
 
 type RewardServerInf interface {
-	SendNewProof(ctx context.Context, proof *pairingtypes.RelaySession, epoch uint64, consumerAddr string, apiInterface string) (existingCU uint64, updatedWithProof bool)
-	SendNewDataReliabilityProof(ctx context.Context, dataReliability *pairingtypes.VRFData, epoch uint64, consumerAddr string, specId string, apiInterface string) (updatedWithProof bool)
+	SendNewProof(ctx context.Context, proof *pairingtypes.RelaySession, epoch uint64, consumerAddr string, apiInterface string) (existingCU uint64, updatedWithProof bool, err error)
+	SendNewDataReliabilityProof(ctx context.Context, dataReliability *pairingtypes.VRFData, epoch uint64, consumerAddr string, specId string, apiInterface string, providerAddr string) (updatedWithProof bool)
 	SubscribeStarted(consumer string, epoch uint64, subscribeID string)
 	SubscribeEnded(consumer string, epoch uint64, subscribeID string)
+	RecordSignedFinalization(epoch uint64, consumerAddr string, specId string, apiInterface string, providerAddr string, hashes map[int64]string)
 }
 
 type StateTrackerInf interface {
@@ -59,6 +69,16 @@ type StateTrackerInf interface {
 	GetProvidersCountForConsumer(ctx context.Context, consumerAddress string, epoch uint64, chainID string) (uint32, error)
 }
 
+// RPCProviderServerOptions holds the optional components of a served endpoint, a nil component is off
+type RPCProviderServerOptions struct {
+	LatencySLOTracker    *LatencySLOTracker
+	NodeRequestScheduler *NodeRequestScheduler
+	RelayWatchdog        *RelayWatchdog
+	BlockStore           BlockStoreInf
+	MaxRangeBlocks       uint64 // 0 serves range queries whole
+	MethodAvailability   *chainlib.MethodAvailabilityCache
+}
+
 func (rpcps *RPCProviderServer) ServeRPCRequests(
 	ctx context.Context, rpcProviderEndpoint *lavasession.RPCProviderEndpoint,
 	chainParser chainlib.ChainParser,
@@ -71,10 +91,12 @@ func (rpcps *RPCProviderServer) ServeRPCRequests(
 	providerAddress sdk.AccAddress,
 	lavaChainID string,
 	allowedMissingCUThreshold float64,
+	options RPCProviderServerOptions,
 ) {
 	rpcps.cache = cache
 	rpcps.chainProxy = chainProxy
 	rpcps.privKey = privKey
+	rpcps.payloadCiphers = newPayloadCiphersCache()
 	rpcps.providerSessionManager = providerSessionManager
 	rpcps.reliabilityManager = reliabilityManager
 	rpcps.rewardServer = rewardServer
@@ -84,6 +106,12 @@ func (rpcps *RPCProviderServer) ServeRPCRequests(
 	rpcps.providerAddress = providerAddress
 	rpcps.lavaChainID = lavaChainID
 	rpcps.allowedMissingCUThreshold = allowedMissingCUThreshold
+	rpcps.latencySLOTracker = options.LatencySLOTracker
+	rpcps.nodeRequestScheduler = options.NodeRequestScheduler
+	rpcps.relayWatchdog = options.RelayWatchdog
+	rpcps.blockStore = options.BlockStore
+	rpcps.maxRangeBlocks = options.MaxRangeBlocks
+	rpcps.methodAvailability = options.MethodAvailability
 }
 
 // function used to handle relay requests from a consumer, it is called by a provider_listener by calling RegisterReceiver
@@ -100,6 +128,11 @@ func (rpcps *RPCProviderServer) Relay(ctx context.Context, request *pairingtypes
 		utils.Attribute{Key: "relay_timeout", Value: common.GetRemainingTimeoutFromContext(ctx)},
 	)
 
+	payloadCipher, err := rpcps.decryptRelayRequest(ctx, request)
+	if err != nil {
+		return nil, rpcps.handleRelayErrorStatus(err)
+	}
+
 	// Init relay
 	relaySession, consumerAddress, chainMessage, err := rpcps.initRelay(ctx, request)
 	if err != nil {
@@ -107,7 +140,12 @@ func (rpcps *RPCProviderServer) Relay(ctx context.Context, request *pairingtypes
 	}
 
 	// Try sending relay
-	reply, err := rpcps.TryRelay(ctx, request, consumerAddress, chainMessage)
+	relayStartTime := time.Now()
+	reply, err := rpcps.relayWatchdog.RunRelay(ctx, rpcps.rpcProviderEndpoint.ChainID, rpcps.rpcProviderEndpoint.ApiInterface, func(ctx context.Context) (*pairingtypes.RelayReply, error) {
+		return rpcps.TryRelay(ctx, request, consumerAddress, chainMessage)
+	})
+	rpcps.latencySLOTracker.RecordRelay(rpcps.rpcProviderEndpoint.ChainID, rpcps.rpcProviderEndpoint.ApiInterface, chainMessage.GetInterface().GetCategory(), time.Since(relayStartTime), err == nil)
+	recordGeolocationRelay(rpcps.rpcProviderEndpoint, err == nil)
 
 	if err != nil || common.ContextOutOfTime(ctx) {
 		// failed to send relay. we need to adjust session state. cuSum and relayNumber.
@@ -133,6 +171,9 @@ func (rpcps *RPCProviderServer) Relay(ctx context.Context, request *pairingtypes
 		if relayError != nil {
 			utils.LavaFormatError("OnSession Done failure: ", relayError)
 		} else {
+			if len(reply.FinalizedBlocksHashes) > 0 {
+				go rpcps.recordSignedFinalization(ctx, pairingEpoch, request, consumerAddress, chainMessage.GetServiceApi().ApiInterfaces[0].Interface, reply)
+			}
 			if request.DataReliability == nil {
 				if sendRewards {
 					// SendProof gets the request copy, as in the case of data reliability enabled the request.blockNumber is changed.
@@ -148,7 +189,7 @@ func (rpcps *RPCProviderServer) Relay(ctx context.Context, request *pairingtypes
 				}
 			} else {
 				updateRewardServer := func() {
-					updated := rpcps.rewardServer.SendNewDataReliabilityProof(ctx, request.DataReliability, pairingEpoch, consumerAddress.String(), request.RelaySession.SpecId, chainMessage.GetServiceApi().ApiInterfaces[0].Interface)
+					updated := rpcps.rewardServer.SendNewDataReliabilityProof(ctx, request.DataReliability, pairingEpoch, consumerAddress.String(), request.RelaySession.SpecId, chainMessage.GetServiceApi().ApiInterfaces[0].Interface, rpcps.providerAddress.String())
 					if !updated {
 						utils.LavaFormatError("existing data reliability proof", lavasession.DataReliabilityAlreadySentThisEpochError, utils.Attribute{Key: "GUID", Value: ctx})
 					}
@@ -169,6 +210,10 @@ func (rpcps *RPCProviderServer) Relay(ctx context.Context, request *pairingtypes
 		utils.Attribute{Key: "request.cu", Value: request.RelaySession.CuSum},
 		utils.Attribute{Key: "relay_timeout", Value: common.GetRemainingTimeoutFromContext(ctx)},
 	)
+	if payloadCipher != nil && err == nil && reply != nil {
+		// the reply was signed in plaintext, the consumer verifies it after decrypting
+		reply, err = payloadCipher.EncryptRelayReply(reply)
+	}
 	return reply, rpcps.handleRelayErrorStatus(err)
 }
 
@@ -200,6 +245,9 @@ func (rpcps *RPCProviderServer) RelaySubscribe(request *pairingtypes.RelayReques
 	if request.RelayData == nil || request.RelaySession == nil {
 		return utils.LavaFormatError("invalid relay subscribe request, internal fields are nil", nil)
 	}
+	if rpcps.rpcProviderEndpoint.RequireEncryptedPayloads {
+		return utils.LavaFormatWarning("refusing a subscription, the endpoint requires encrypted payloads and subscriptions aren't encrypted", lavaprotocol.PayloadEncryptionError)
+	}
 	ctx := utils.AppendUniqueIdentifier(context.Background(), lavaprotocol.GetSalt(request.RelayData))
 	utils.LavaFormatDebug("Provider got relay subscribe request",
 		utils.Attribute{Key: "request.SessionId", Value: request.RelaySession.SessionId},
@@ -240,7 +288,10 @@ func (rpcps *RPCProviderServer) RelaySubscribe(request *pairingtypes.RelayReques
 }
 
 func (rpcps *RPCProviderServer) SendProof(ctx context.Context, epoch uint64, request *pairingtypes.RelayRequest, consumerAddress sdk.AccAddress, apiInterface string) error {
-	storedCU, updatedWithProof := rpcps.rewardServer.SendNewProof(ctx, request.RelaySession, epoch, consumerAddress.String(), apiInterface)
+	storedCU, updatedWithProof, err := rpcps.rewardServer.SendNewProof(ctx, request.RelaySession, epoch, consumerAddress.String(), apiInterface)
+	if err != nil {
+		return rpcps.handleRelayErrorStatus(err)
+	}
 	if !updatedWithProof && storedCU > request.RelaySession.CuSum {
 		rpcps.providerSessionManager.UpdateSessionCU(consumerAddress.String(), epoch, request.RelaySession.SessionId, storedCU)
 		err := utils.LavaFormatError("Cu in relay smaller than existing proof", lavasession.ProviderConsumerCuMisMatch, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "existing_proof_cu", Value: storedCU})
@@ -249,6 +300,17 @@ func (rpcps *RPCProviderServer) SendProof(ctx context.Context, epoch uint64, req
 	return nil
 }
 
+// recordSignedFinalization keeps the finalized hashes signed in the reply, so a fork replacing them is caught before claiming
+func (rpcps *RPCProviderServer) recordSignedFinalization(ctx context.Context, epoch uint64, request *pairingtypes.RelayRequest, consumerAddress sdk.AccAddress, apiInterface string, reply *pairingtypes.RelayReply) {
+	hashes := map[int64]string{}
+	err := json.Unmarshal(reply.FinalizedBlocksHashes, &hashes)
+	if err != nil {
+		utils.LavaFormatWarning("failed parsing the signed finalized blocks hashes", err, utils.Attribute{Key: "GUID", Value: ctx})
+		return
+	}
+	rpcps.rewardServer.RecordSignedFinalization(epoch, consumerAddress.String(), request.RelaySession.SpecId, apiInterface, rpcps.providerAddress.String(), hashes)
+}
+
 func (rpcps *RPCProviderServer) TryRelaySubscribe(ctx context.Context, requestBlockHeight uint64, srv pairingtypes.Relayer_RelaySubscribeServer, chainMessage chainlib.ChainMessage, consumerAddress sdk.AccAddress, relaySession *lavasession.SingleProviderSession, relayNumber uint64) (subscribed bool, errRet error) {
 	var reply *pairingtypes.RelayReply
 	var clientSub *rpcclient.ClientSubscription
@@ -633,11 +695,22 @@ func (rpcps *RPCProviderServer) handleRelayErrorStatus(err error) error {
 		err = status.Error(codes.Code(lavasession.SessionOutOfSyncError.ABCICode()), err.Error())
 	} else if lavasession.EpochMismatchError.Is(err) {
 		err = status.Error(codes.Code(lavasession.EpochMismatchError.ABCICode()), err.Error())
+	} else if lavasession.UnsupportedMethodError.Is(err) {
+		err = status.Error(codes.Code(lavasession.UnsupportedMethodError.ABCICode()), err.Error())
 	}
 	return err
 }
 
 func (rpcps *RPCProviderServer) TryRelay(ctx context.Context, request *pairingtypes.RelayRequest, consumerAddr sdk.AccAddress, chainMsg chainlib.ChainMessage) (*pairingtypes.RelayReply, error) {
+	// heavy range queries are served in parts for consumers that support continuation tokens, parts aren't cached
+	chainMsg, partial, splitErr := rpcps.splitRangeRequest(ctx, request, chainMsg)
+	if splitErr != nil {
+		return nil, splitErr
+	}
+	nodeKey := rpcps.rpcProviderEndpoint.UrlsString()
+	if !rpcps.methodAvailability.IsSupported(nodeKey, chainMsg.GetServiceApi().Name) {
+		return nil, lavasession.UnsupportedMethodError.Wrapf("method: %s, chainID: %s", chainMsg.GetServiceApi().Name, rpcps.rpcProviderEndpoint.ChainID)
+	}
 	// Send
 	var reqMsg *rpcInterfaceMessages.JsonrpcMessage
 	var reqParams interface{}
@@ -660,11 +733,11 @@ func (rpcps *RPCProviderServer) TryRelay(ctx context.Context, request *pairingty
 		toBlock := spectypes.LATEST_BLOCK - int64(blockDistanceToFinalization)
 		fromBlock := toBlock - int64(blocksInFinalizationData) + 1
 		var requestedHashes []*chaintracker.BlockStore
-		latestBlock, requestedHashes, err = rpcps.reliabilityManager.GetLatestBlockData(fromBlock, toBlock, request.RelayData.RequestBlock)
+		latestBlock, requestedHashes, err = rpcps.reliabilityManager.GetLatestBlockData(fromBlock, toBlock, request.RelayData.RequestBlock, false)
 		if err != nil {
 			if chaintracker.InvalidRequestedSpecificBlock.Is(err) {
 				// specific block is invalid, try again without specific block
-				latestBlock, requestedHashes, err = rpcps.reliabilityManager.GetLatestBlockData(fromBlock, toBlock, spectypes.NOT_APPLICABLE)
+				latestBlock, requestedHashes, err = rpcps.reliabilityManager.GetLatestBlockData(fromBlock, toBlock, spectypes.NOT_APPLICABLE, false)
 				if err != nil {
 					return nil, utils.LavaFormatError("error getting range even without specific block", err, utils.Attribute{Key: "GUID", Value: ctx}, utils.Attribute{Key: "fromBlock", Value: fromBlock}, utils.Attribute{Key: "latestBlock", Value: latestBlock}, utils.Attribute{Key: "toBlock", Value: toBlock})
 				}
@@ -700,20 +773,34 @@ func (rpcps *RPCProviderServer) TryRelay(ctx context.Context, request *pairingty
 	// TODO: handle cache on fork for dataReliability = false
 	var reply *pairingtypes.RelayReply = nil
 	var err error = nil
-	if requestedBlockHash != nil || finalized {
-		reply, err = cache.GetEntry(ctx, request, rpcps.rpcProviderEndpoint.ApiInterface, requestedBlockHash, rpcps.rpcProviderEndpoint.ChainID, finalized)
+	if (requestedBlockHash != nil || finalized) && !partial {
+		reply, err = cache.GetEntry(ctx, chainlib.CanonicalRelayRequest(request), rpcps.rpcProviderEndpoint.ApiInterface, requestedBlockHash, rpcps.rpcProviderEndpoint.ChainID, finalized)
 	}
 	if err != nil || reply == nil {
 		if err != nil && performance.NotConnectedError.Is(err) {
 			utils.LavaFormatWarning("cache not connected", err, utils.Attribute{Key: "GUID", Value: ctx})
 		}
-		// cache miss or invalid
-		reply, _, _, err = rpcps.chainProxy.SendNodeMsg(ctx, nil, chainMsg)
-		if err != nil {
-			return nil, utils.LavaFormatError("Sending chainMsg failed", err, utils.Attribute{Key: "GUID", Value: ctx})
+		// cache miss or invalid, recent blocks may be retained by the chain tracker
+		reply = rpcps.replyFromBlockStore(ctx, chainMsg)
+		if reply == nil {
+			// wait for the node to have capacity, latest sensitive requests go first
+			priority := NodeRequestPriorityDefault
+			if chainMsg.RequestedBlock() == spectypes.LATEST_BLOCK {
+				priority = NodeRequestPriorityLatest
+			}
+			releaseNode, err := rpcps.nodeRequestScheduler.Acquire(ctx, priority)
+			if err != nil {
+				return nil, utils.LavaFormatWarning("node request scheduling failed", err, utils.Attribute{Key: "GUID", Value: ctx})
+			}
+			reply, _, _, err = rpcps.chainProxy.SendNodeMsg(ctx, nil, chainMsg)
+			releaseNode()
+			if err != nil {
+				return nil, utils.LavaFormatError("Sending chainMsg failed", err, utils.Attribute{Key: "GUID", Value: ctx})
+			}
+			rpcps.methodAvailability.ObserveReply(nodeKey, rpcps.rpcProviderEndpoint.ApiInterface, chainMsg.GetServiceApi().Name, reply.Data)
 		}
-		if requestedBlockHash != nil || finalized {
-			err := cache.SetEntry(ctx, request, rpcps.rpcProviderEndpoint.ApiInterface, requestedBlockHash, rpcps.rpcProviderEndpoint.ChainID, consumerAddr.String(), reply, finalized)
+		if (requestedBlockHash != nil || finalized) && !partial {
+			err := cache.SetEntry(ctx, chainlib.CanonicalRelayRequest(request), rpcps.rpcProviderEndpoint.ApiInterface, requestedBlockHash, rpcps.rpcProviderEndpoint.ChainID, consumerAddr.String(), reply, finalized)
 			if err != nil && !performance.NotInitialisedError.Is(err) && request.RelaySession.Epoch != spectypes.NOT_APPLICABLE {
 				utils.LavaFormatWarning("error updating cache with new entry", err, utils.Attribute{Key: "GUID", Value: ctx})
 			}
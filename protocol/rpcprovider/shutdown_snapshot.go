@@ -0,0 +1,131 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/utils"
+)
+
+const ShutdownSnapshotFlagName = "shutdown-snapshot"
+
+// ProviderShutdownSnapshot is written on graceful shutdown and restored on startup while the epoch hasn't rolled,
+// so consumers keep their sessions, unclaimed proofs aren't lost and the chain trackers don't refetch their window
+type ProviderShutdownSnapshot struct {
+	Provider      string                                           `json:"provider"`
+	Epoch         uint64                                           `json:"epoch"`
+	Time          time.Time                                        `json:"time"`
+	Rewards       *rewardserver.RewardServerSnapshot               `json:"rewards"`
+	Sessions      map[string]*lavasession.ProviderSessionsSnapshot `json:"sessions"`       // key is the endpoint key
+	ChainTrackers map[string]*chaintracker.ChainTrackerSnapshot    `json:"chain-trackers"` // key is the chain tracker key, chain and geolocation
+}
+
+// the snapshot is taken after the listeners shut down, so no relay changes the state while it's collected
+func collectShutdownSnapshot(provider string, epoch uint64, rewardServer *rewardserver.RewardServer, sessionManagers *sync.Map, chainTrackers *sync.Map) (*ProviderShutdownSnapshot, error) {
+	rewardsSnapshot, err := rewardServer.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &ProviderShutdownSnapshot{
+		Provider:      provider,
+		Epoch:         epoch,
+		Time:          time.Now(),
+		Rewards:       rewardsSnapshot,
+		Sessions:      map[string]*lavasession.ProviderSessionsSnapshot{},
+		ChainTrackers: map[string]*chaintracker.ChainTrackerSnapshot{},
+	}
+	sessionManagers.Range(func(key, value interface{}) bool {
+		if providerSessionManager, ok := value.(*lavasession.ProviderSessionManager); ok {
+			snapshot.Sessions[key.(string)] = providerSessionManager.Snapshot()
+		}
+		return true
+	})
+	chainTrackers.Range(func(key, value interface{}) bool {
+		if chainTracker, ok := value.(*chaintracker.ChainTracker); ok {
+			snapshot.ChainTrackers[key.(string)] = chainTracker.Snapshot()
+		}
+		return true
+	})
+	return snapshot, nil
+}
+
+// the snapshot is written to a temporary file and renamed, so a crash while writing doesn't leave a partial snapshot
+func writeShutdownSnapshot(path string, snapshot *ProviderShutdownSnapshot) error {
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, snapshotBytes, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// readShutdownSnapshot returns nil if there is no snapshot or it can't be restored, the file is removed so a snapshot is restored once
+func readShutdownSnapshot(ctx context.Context, path string, provider string, providerStateTracker ProviderStateTrackerInf) *ProviderShutdownSnapshot {
+	snapshotBytes, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LavaFormatWarning("failed reading shutdown snapshot, starting without it", err, utils.Attribute{Key: "path", Value: path})
+		}
+		return nil
+	}
+	defer func() {
+		err := os.Remove(path)
+		if err != nil {
+			utils.LavaFormatWarning("failed removing restored shutdown snapshot", err, utils.Attribute{Key: "path", Value: path})
+		}
+	}()
+	snapshot := &ProviderShutdownSnapshot{}
+	err = json.Unmarshal(snapshotBytes, snapshot)
+	if err != nil {
+		utils.LavaFormatWarning("invalid shutdown snapshot, starting without it", err, utils.Attribute{Key: "path", Value: path})
+		return nil
+	}
+	if snapshot.Provider != provider {
+		utils.LavaFormatWarning("shutdown snapshot of another provider, starting without it", nil, utils.Attribute{Key: "snapshotProvider", Value: snapshot.Provider}, utils.Attribute{Key: "provider", Value: provider})
+		return nil
+	}
+	currentEpoch, err := providerStateTracker.CurrentEpoch(ctx)
+	if err != nil {
+		utils.LavaFormatWarning("failed reading the current epoch, starting without the shutdown snapshot", err)
+		return nil
+	}
+	if currentEpoch != snapshot.Epoch {
+		utils.LavaFormatInfo("epoch rolled since the shutdown snapshot, starting without it", utils.Attribute{Key: "snapshotEpoch", Value: snapshot.Epoch}, utils.Attribute{Key: "currentEpoch", Value: currentEpoch})
+		return nil
+	}
+	utils.LavaFormatInfo("restoring shutdown snapshot", utils.Attribute{Key: "epoch", Value: snapshot.Epoch}, utils.Attribute{Key: "snapshotTime", Value: snapshot.Time},
+		utils.Attribute{Key: "endpoints", Value: len(snapshot.Sessions)}, utils.Attribute{Key: "chainTrackers", Value: len(snapshot.ChainTrackers)})
+	return snapshot
+}
+
+// nil safe accessors, so the startup code doesn't check if a snapshot was restored
+func (pss *ProviderShutdownSnapshot) rewards() *rewardserver.RewardServerSnapshot {
+	if pss == nil {
+		return nil
+	}
+	return pss.Rewards
+}
+
+func (pss *ProviderShutdownSnapshot) sessions(endpointKey string) *lavasession.ProviderSessionsSnapshot {
+	if pss == nil {
+		return nil
+	}
+	return pss.Sessions[endpointKey]
+}
+
+func (pss *ProviderShutdownSnapshot) chainTracker(chainTrackerKey string) *chaintracker.ChainTrackerSnapshot {
+	if pss == nil {
+		return nil
+	}
+	return pss.ChainTrackers[chainTrackerKey]
+}
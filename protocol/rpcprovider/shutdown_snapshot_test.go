@@ -0,0 +1,99 @@
+package rpcprovider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEpochStateTracker answers only the current epoch, the rest of the interface isn't used by the snapshot
+type fakeEpochStateTracker struct {
+	ProviderStateTrackerInf
+	epoch uint64
+	err   error
+}
+
+func (fest *fakeEpochStateTracker) CurrentEpoch(ctx context.Context) (uint64, error) {
+	return fest.epoch, fest.err
+}
+
+func writeTestShutdownSnapshot(t *testing.T, provider string, epoch uint64) string {
+	ctx := context.Background()
+	rewardServer := rewardserver.NewRewardServer(nil, rewardserver.ClaimThresholds{})
+	_, _, err := rewardServer.SendNewProof(ctx, &pairingtypes.RelaySession{SpecId: "LAV1", SessionId: 1, RelayNum: 1, CuSum: 10, Epoch: int64(epoch), Provider: provider, Sig: []byte("sig")}, epoch, "consumer", "rest")
+	require.NoError(t, err)
+	sessionManagers := &sync.Map{}
+	endpoint := &lavasession.RPCProviderEndpoint{ChainID: "LAV1", ApiInterface: "rest", Geolocation: 1, NodeUrls: []common.NodeUrl{{Url: "http://localhost:666"}}}
+	sessionManagers.Store(endpoint.Key(), lavasession.NewProviderSessionManager(endpoint, 20))
+
+	snapshot, err := collectShutdownSnapshot(provider, epoch, rewardServer, sessionManagers, &sync.Map{})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, writeShutdownSnapshot(path, snapshot))
+	_, err = os.Stat(path + ".tmp")
+	require.True(t, os.IsNotExist(err))
+	return path
+}
+
+func TestShutdownSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := writeTestShutdownSnapshot(t, "provider", 20)
+	snapshot := readShutdownSnapshot(ctx, path, "provider", &fakeEpochStateTracker{epoch: 20})
+	require.NotNil(t, snapshot)
+	require.Equal(t, uint64(20), snapshot.Epoch)
+	require.Len(t, snapshot.rewards().Rewards, 1)
+	require.Len(t, snapshot.rewards().Rewards[0].Proofs, 1)
+	endpointKey := (&lavasession.RPCProviderEndpoint{ChainID: "LAV1", ApiInterface: "rest"}).Key()
+	require.NotNil(t, snapshot.sessions(endpointKey))
+	require.Nil(t, snapshot.sessions("ETH1rest"))
+	require.Nil(t, snapshot.chainTracker("LAV1"))
+
+	// the file is removed so the snapshot is restored once
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	require.Nil(t, readShutdownSnapshot(ctx, path, "provider", &fakeEpochStateTracker{epoch: 20}))
+
+	// no snapshot was restored
+	var missing *ProviderShutdownSnapshot
+	require.Nil(t, missing.rewards())
+	require.Nil(t, missing.sessions(endpointKey))
+	require.Nil(t, missing.chainTracker("LAV1"))
+}
+
+func TestShutdownSnapshotRejected(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		provider     string
+		stateTracker *fakeEpochStateTracker
+	}{
+		{name: "another provider", provider: "provider2", stateTracker: &fakeEpochStateTracker{epoch: 20}},
+		{name: "epoch rolled", provider: "provider", stateTracker: &fakeEpochStateTracker{epoch: 40}},
+		{name: "epoch unknown", provider: "provider", stateTracker: &fakeEpochStateTracker{err: errors.New("no epoch")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestShutdownSnapshot(t, "provider", 20)
+			require.Nil(t, readShutdownSnapshot(ctx, path, tt.provider, tt.stateTracker))
+			_, err := os.Stat(path)
+			require.True(t, os.IsNotExist(err))
+		})
+	}
+
+	t.Run("invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.json")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+		require.Nil(t, readShutdownSnapshot(ctx, path, "provider", &fakeEpochStateTracker{epoch: 20}))
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	})
+}
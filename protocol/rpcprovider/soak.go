@@ -0,0 +1,443 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	btcSecp256k1 "github.com/btcsuite/btcd/btcec"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/version"
+	"github.com/lavanet/lava/app"
+	"github.com/lavanet/lava/protocol/common"
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils"
+	"github.com/lavanet/lava/utils/sigs"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	"github.com/spf13/cobra"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	SoakDurationFlag               = "duration"
+	SoakRPSFlag                    = "rps"
+	SoakOutputFlag                 = "output"
+	SoakVerifyFlag                 = "verify"
+	DefaultSoakDuration            = 4 * time.Hour
+	DefaultSoakRPS                 = 10
+	DefaultSoakOutput              = "soak_report.json"
+	soakRewardProofCheckInterval   = 10 * time.Second
+	soakProgressInterval           = time.Minute
+	soakMaxInFlightPerRPS          = 10 // requests that didn't return within this many intervals count as errors instead of piling up
+	soakRequestTimeout             = 5 * time.Second
+	soakLatencyPercentileP50       = 0.5
+	soakLatencyPercentileP90       = 0.9
+	soakLatencyPercentileP99       = 0.99
+	soakRewardProofCheckFailureMsg = "relay without signature wasn't refused"
+)
+
+// SoakReport is the performance certificate of a soak run, it is written signed by the provider key so it can be published and compared
+type SoakReport struct {
+	Provider    string               `json:"provider"`
+	LavaChainID string               `json:"lavaChainID"`
+	Version     string               `json:"version"`
+	StartTime   time.Time            `json:"startTime"`
+	EndTime     time.Time            `json:"endTime"`
+	Duration    string               `json:"duration"`
+	Completed   bool                 `json:"completed"` // false if the run was interrupted before the requested duration
+	TargetRPS   uint64               `json:"targetRPS"` // per endpoint
+	Endpoints   []SoakEndpointReport `json:"endpoints"`
+}
+
+type SoakEndpointReport struct {
+	ChainID             string  `json:"chainID"`
+	ApiInterface        string  `json:"apiInterface"`
+	NetworkAddress      string  `json:"networkAddress"`
+	Requests            uint64  `json:"requests"`
+	Errors              uint64  `json:"errors"`
+	ErrorRate           float64 `json:"errorRate"`
+	SustainedRPS        float64 `json:"sustainedRPS"` // successful requests per second over the whole run
+	LatencyP50Ms        float64 `json:"latencyP50Ms"`
+	LatencyP90Ms        float64 `json:"latencyP90Ms"`
+	LatencyP99Ms        float64 `json:"latencyP99Ms"`
+	LatencyMaxMs        float64 `json:"latencyMaxMs"`
+	RewardProofChecks   uint64  `json:"rewardProofChecks"`   // unsigned relays sent, they carry no proof for rewards and must be refused
+	RewardProofFailures uint64  `json:"rewardProofFailures"` // unsigned relays that weren't refused as expected
+}
+
+// SignedSoakReport keeps the report as signed, so verifying doesn't depend on how it is re-encoded
+type SignedSoakReport struct {
+	Report    json.RawMessage `json:"report"`
+	Signature []byte          `json:"signature"`
+}
+
+type soakEndpointStats struct {
+	lock                sync.Mutex
+	latencies           []time.Duration
+	requests            uint64 // atomic
+	errors              uint64 // atomic
+	rewardProofChecks   uint64
+	rewardProofFailures uint64
+}
+
+func (ses *soakEndpointStats) addResult(latency time.Duration, err error) {
+	atomic.AddUint64(&ses.requests, 1)
+	if err != nil {
+		atomic.AddUint64(&ses.errors, 1)
+		return
+	}
+	ses.lock.Lock()
+	defer ses.lock.Unlock()
+	ses.latencies = append(ses.latencies, latency)
+}
+
+// returns the latency at percentile of the sorted latencies in milliseconds
+func latencyPercentileMs(sorted []time.Duration, percentile float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(percentile*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return float64(sorted[idx]) / float64(time.Millisecond)
+}
+
+func (ses *soakEndpointStats) report(chainID string, endpoint epochstoragetypes.Endpoint, elapsed time.Duration) SoakEndpointReport {
+	ses.lock.Lock()
+	defer ses.lock.Unlock()
+	sorted := make([]time.Duration, len(ses.latencies))
+	copy(sorted, ses.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	requests := atomic.LoadUint64(&ses.requests)
+	errors := atomic.LoadUint64(&ses.errors)
+	endpointReport := SoakEndpointReport{
+		ChainID:             chainID,
+		ApiInterface:        endpoint.UseType,
+		NetworkAddress:      endpoint.IPPORT,
+		Requests:            requests,
+		Errors:              errors,
+		LatencyP50Ms:        latencyPercentileMs(sorted, soakLatencyPercentileP50),
+		LatencyP90Ms:        latencyPercentileMs(sorted, soakLatencyPercentileP90),
+		LatencyP99Ms:        latencyPercentileMs(sorted, soakLatencyPercentileP99),
+		LatencyMaxMs:        latencyPercentileMs(sorted, 1),
+		RewardProofChecks:   ses.rewardProofChecks,
+		RewardProofFailures: ses.rewardProofFailures,
+	}
+	if requests > 0 {
+		endpointReport.ErrorRate = float64(errors) / float64(requests)
+	}
+	if elapsed > 0 {
+		endpointReport.SustainedRPS = float64(requests-errors) / elapsed.Seconds()
+	}
+	return endpointReport
+}
+
+// soaks a single endpoint with probes at rps until ctx is done, and periodically checks unsigned relays are refused
+func soakEndpoint(ctx context.Context, chainID string, endpoint epochstoragetypes.Endpoint, rps uint64, stats *soakEndpointStats) {
+	cswp := lavasession.ConsumerSessionsWithProvider{}
+	relayerClientPt, conn, err := cswp.ConnectRawClientWithTimeout(ctx, endpoint.IPPORT)
+	if err != nil {
+		utils.LavaFormatError("failed connecting to provider endpoint, the soak counts every request as failed", err, utils.Attribute{Key: "apiInterface", Value: endpoint.UseType}, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "network address", Value: endpoint.IPPORT})
+	} else {
+		defer conn.Close()
+	}
+	inFlight := make(chan struct{}, rps*soakMaxInFlightPerRPS)
+	probe := func() {
+		defer func() { <-inFlight }()
+		if relayerClientPt == nil {
+			stats.addResult(0, lavasession.FailedToConnectToEndPointForDataReliabilityError)
+			return
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, soakRequestTimeout)
+		defer cancel()
+		guid := uint64(rand.Int63())
+		sentTime := time.Now()
+		returned, err := (*relayerClientPt).Probe(probeCtx, &wrapperspb.UInt64Value{Value: guid})
+		latency := time.Since(sentTime)
+		if ctx.Err() != nil {
+			return // the soak ended while the probe was in flight
+		}
+		if err == nil && returned.Value != guid {
+			err = utils.LavaFormatWarning("probe returned invalid value", nil, utils.Attribute{Key: "returnedGuid", Value: returned.Value}, utils.Attribute{Key: "guid", Value: guid})
+		}
+		stats.addResult(latency, err)
+	}
+	requestTicker := time.NewTicker(time.Second / time.Duration(rps))
+	defer requestTicker.Stop()
+	rewardProofTicker := time.NewTicker(soakRewardProofCheckInterval)
+	defer rewardProofTicker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-requestTicker.C:
+			select {
+			case inFlight <- struct{}{}:
+				go probe()
+			default:
+				stats.addResult(0, context.DeadlineExceeded) // the endpoint isn't keeping up
+			}
+		case <-rewardProofTicker.C:
+			if relayerClientPt == nil {
+				continue
+			}
+			checkCtx, cancel := context.WithTimeout(ctx, soakRequestTimeout)
+			err := checkUnsignedRelayRefused(checkCtx, *relayerClientPt, chainID, endpoint.UseType)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			stats.lock.Lock()
+			stats.rewardProofChecks++
+			if err != nil {
+				stats.rewardProofFailures++
+				utils.LavaFormatWarning(soakRewardProofCheckFailureMsg, err, utils.Attribute{Key: "apiInterface", Value: endpoint.UseType}, utils.Attribute{Key: "chainID", Value: chainID})
+			}
+			stats.lock.Unlock()
+		}
+	}
+}
+
+// runs the soak on all endpoints of the stake entries in parallel, returns early with Completed false on interrupt
+func runSoak(ctx context.Context, provider string, lavaChainID string, providerEntries []epochstoragetypes.StakeEntry, duration time.Duration, rps uint64) *SoakReport {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	defer func() {
+		signal.Stop(signalChan)
+		cancel()
+	}()
+	interrupted := uint32(0)
+	go func() {
+		select {
+		case <-signalChan:
+			atomic.StoreUint32(&interrupted, 1)
+			utils.LavaFormatWarning("soak interrupted, writing a partial report", nil)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	type soakedEndpoint struct {
+		chainID  string
+		endpoint epochstoragetypes.Endpoint
+		stats    *soakEndpointStats
+	}
+	soaked := []soakedEndpoint{}
+	var wg sync.WaitGroup
+	startTime := time.Now()
+	for _, providerEntry := range providerEntries {
+		for _, endpoint := range providerEntry.Endpoints {
+			entry := soakedEndpoint{chainID: providerEntry.Chain, endpoint: endpoint, stats: &soakEndpointStats{}}
+			soaked = append(soaked, entry)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				soakEndpoint(ctx, entry.chainID, entry.endpoint, rps, entry.stats)
+			}()
+		}
+	}
+	utils.LavaFormatInfo("soak started", utils.Attribute{Key: "endpoints", Value: len(soaked)}, utils.Attribute{Key: "duration", Value: duration}, utils.Attribute{Key: "rps", Value: rps})
+	progressTicker := time.NewTicker(soakProgressInterval)
+	defer progressTicker.Stop()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-progressTicker.C:
+			for _, entry := range soaked {
+				utils.LavaFormatInfo("soak progress", utils.Attribute{Key: "chainID", Value: entry.chainID}, utils.Attribute{Key: "apiInterface", Value: entry.endpoint.UseType},
+					utils.Attribute{Key: "requests", Value: atomic.LoadUint64(&entry.stats.requests)}, utils.Attribute{Key: "errors", Value: atomic.LoadUint64(&entry.stats.errors)}, utils.Attribute{Key: "elapsed", Value: time.Since(startTime)})
+			}
+		}
+	}
+	endTime := time.Now()
+	report := &SoakReport{
+		Provider:    provider,
+		LavaChainID: lavaChainID,
+		Version:     version.Version,
+		StartTime:   startTime.UTC(),
+		EndTime:     endTime.UTC(),
+		Duration:    endTime.Sub(startTime).String(),
+		Completed:   atomic.LoadUint32(&interrupted) == 0,
+		TargetRPS:   rps,
+		Endpoints:   []SoakEndpointReport{},
+	}
+	for _, entry := range soaked {
+		report.Endpoints = append(report.Endpoints, entry.stats.report(entry.chainID, entry.endpoint, endTime.Sub(startTime)))
+	}
+	return report
+}
+
+func signSoakReport(privKey *btcSecp256k1.PrivateKey, report *SoakReport) ([]byte, error) {
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sigs.SignSoakReport(privKey, reportBytes)
+	if err != nil {
+		return nil, err
+	}
+	// not indented, indenting would re-encode the signed report bytes
+	return json.Marshal(SignedSoakReport{Report: reportBytes, Signature: sig})
+}
+
+// VerifySoakReport checks the report was signed by the provider it names
+func VerifySoakReport(signed SignedSoakReport) (*SoakReport, error) {
+	report := &SoakReport{}
+	err := json.Unmarshal(signed.Report, report)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed parsing soak report", err)
+	}
+	pubKey, err := sigs.RecoverPubKeyFromSoakReport(signed.Report, signed.Signature)
+	if err != nil {
+		return nil, err
+	}
+	signer := sdk.AccAddress(pubKey.Address())
+	if signer.String() != report.Provider {
+		return nil, utils.LavaFormatWarning("soak report signer mismatch", nil, utils.Attribute{Key: "signer", Value: signer.String()}, utils.Attribute{Key: "provider", Value: report.Provider})
+	}
+	return report, nil
+}
+
+func printSoakReport(report *SoakReport) {
+	fmt.Printf("----------------------------------------SOAK REPORT----------------------------------------\n\nProvider: %s\nDuration: %s (completed: %t), target rps per endpoint: %d\n\n", report.Provider, report.Duration, report.Completed, report.TargetRPS)
+	for _, endpointReport := range report.Endpoints {
+		fmt.Printf("%s-%s %s: requests %d, error rate %.4f, sustained rps %.2f, latency p50 %.1fms p90 %.1fms p99 %.1fms max %.1fms, reward proof checks %d failed %d\n",
+			endpointReport.ChainID, endpointReport.ApiInterface, endpointReport.NetworkAddress, endpointReport.Requests, endpointReport.ErrorRate, endpointReport.SustainedRPS,
+			endpointReport.LatencyP50Ms, endpointReport.LatencyP90Ms, endpointReport.LatencyP99Ms, endpointReport.LatencyMaxMs, endpointReport.RewardProofChecks, endpointReport.RewardProofFailures)
+	}
+	fmt.Println()
+}
+
+func verifySoakReportFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	signed := SignedSoakReport{}
+	err = json.Unmarshal(data, &signed)
+	if err != nil {
+		return utils.LavaFormatError("failed parsing signed soak report", err, utils.Attribute{Key: "path", Value: path})
+	}
+	report, err := VerifySoakReport(signed)
+	if err != nil {
+		return err
+	}
+	utils.LavaFormatInfo("soak report signature is valid", utils.Attribute{Key: "provider", Value: report.Provider})
+	printSoakReport(report)
+	return nil
+}
+
+func CreateSoakTestRPCProviderCobraCommand() *cobra.Command {
+	cmdSoakTestRPCProvider := &cobra.Command{
+		Use:   `soak --from <provider wallet> [--duration 4h] [--rps 10] [--output soak_report.json] [--endpoints "provider-public-grpc:port,api-interface,spec-chain-id ..."]`,
+		Short: `run a long load test against an rpc provider's endpoints and write a signed performance report`,
+		Long: `probes every staked endpoint of the provider at a fixed rate for the whole duration, recording sustained rps, latency percentiles and error rates,
+		and periodically checks relays without a consumer signature are refused so no relay is served without a reward proof.
+		the report is signed with the provider key, so operators can publish it or compare it across hardware, verify one with --verify <report file>`,
+		Example: `soak --from providerWallet
+		soak --from providerWallet --duration 8h --rps 50 --output soak_report.json
+		soak --verify soak_report.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verifyPath, err := cmd.Flags().GetString(SoakVerifyFlag)
+			if err != nil {
+				return err
+			}
+			if verifyPath != "" {
+				return verifySoakReportFile(verifyPath)
+			}
+			clientCtx, err := client.GetClientTxContext(cmd)
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			networkChainId, err := cmd.Flags().GetString(flags.FlagChainID)
+			if err != nil {
+				return err
+			}
+			logLevel, err := cmd.Flags().GetString(flags.FlagLogLevel)
+			if err != nil {
+				utils.LavaFormatFatal("failed to read log level flag", err)
+			}
+			utils.LoggingLevel(logLevel)
+			duration, err := cmd.Flags().GetDuration(SoakDurationFlag)
+			if err != nil {
+				return err
+			}
+			rps, err := cmd.Flags().GetUint64(SoakRPSFlag)
+			if err != nil {
+				return err
+			}
+			if duration <= 0 || rps == 0 {
+				return utils.LavaFormatError("soak duration and rps must be positive", nil, utils.Attribute{Key: "duration", Value: duration}, utils.Attribute{Key: "rps", Value: rps})
+			}
+			output, err := cmd.Flags().GetString(SoakOutputFlag)
+			if err != nil {
+				return err
+			}
+			// the report is signed by the provider, so the key is needed and not only the address
+			keyName, err := sigs.GetKeyName(clientCtx)
+			if err != nil {
+				return utils.LavaFormatError("failed getting key name from clientCtx, the soak report is signed with the --from provider key", err)
+			}
+			privKey, err := sigs.GetPrivKey(clientCtx, keyName)
+			if err != nil {
+				return err
+			}
+			clientKey, err := clientCtx.Keyring.Key(keyName)
+			if err != nil {
+				return err
+			}
+			address := clientKey.GetAddress().String()
+			clientCtx = clientCtx.WithChainID(networkChainId)
+			utils.LavaFormatInfo("lavad Binary Version: " + version.Version)
+			rand.Seed(time.Now().UnixNano())
+			stakedProviderChains, err := getProviderStakeEntries(ctx, cmd, clientCtx, address)
+			if err != nil {
+				return err
+			}
+			if len(stakedProviderChains) == 0 {
+				return utils.LavaFormatError("no endpoints to soak", nil, utils.Attribute{Key: "address", Value: address})
+			}
+			report := runSoak(ctx, address, networkChainId, stakedProviderChains, duration, rps)
+			signed, err := signSoakReport(privKey, report)
+			if err != nil {
+				return utils.LavaFormatError("failed signing soak report", err)
+			}
+			err = os.WriteFile(output, signed, 0o644)
+			if err != nil {
+				return utils.LavaFormatError("failed writing soak report", err, utils.Attribute{Key: "output", Value: output})
+			}
+			printSoakReport(report)
+			utils.LavaFormatInfo("soak report written", utils.Attribute{Key: "output", Value: output})
+			return nil
+		},
+	}
+
+	flags.AddTxFlagsToCmd(cmdSoakTestRPCProvider)
+	cmdSoakTestRPCProvider.Flags().String(flags.FlagChainID, app.Name, "network chain id")
+	cmdSoakTestRPCProvider.Flags().String(common.EndpointsConfigName, "", "endpoints to soak, overwrites reading them from the blockchain")
+	cmdSoakTestRPCProvider.Flags().Duration(SoakDurationFlag, DefaultSoakDuration, "how long to soak the provider endpoints")
+	cmdSoakTestRPCProvider.Flags().Uint64(SoakRPSFlag, DefaultSoakRPS, "probes per second sent to every endpoint")
+	cmdSoakTestRPCProvider.Flags().String(SoakOutputFlag, DefaultSoakOutput, "path to write the signed soak report to")
+	cmdSoakTestRPCProvider.Flags().String(SoakVerifyFlag, "", "path of a signed soak report to verify and print instead of running a soak")
+	return cmdSoakTestRPCProvider
+}
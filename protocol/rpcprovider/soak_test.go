@@ -0,0 +1,169 @@
+package rpcprovider
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/utils/sigs"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeSoakRelayer echoes probes, and refuses unsigned relays unless it serves them
+type fakeSoakRelayer struct {
+	pairingtypes.UnimplementedRelayerServer
+	servesUnsigned bool
+	probeOffset    uint64 // added to the probe guid, a non zero offset returns invalid probes
+}
+
+func (fsr *fakeSoakRelayer) Relay(ctx context.Context, req *pairingtypes.RelayRequest) (*pairingtypes.RelayReply, error) {
+	if fsr.servesUnsigned {
+		return &pairingtypes.RelayReply{}, nil
+	}
+	return nil, status.Error(codes.Code(lavasession.EpochMismatchError.ABCICode()), "relay without a signature")
+}
+
+func (fsr *fakeSoakRelayer) Probe(ctx context.Context, guid *wrapperspb.UInt64Value) (*wrapperspb.UInt64Value, error) {
+	return &wrapperspb.UInt64Value{Value: guid.Value + fsr.probeOffset}, nil
+}
+
+func startFakeSoakRelayer(t *testing.T, relayer *fakeSoakRelayer) (pairingtypes.RelayerClient, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pairingtypes.RegisterRelayerServer(server, relayer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	cswp := lavasession.ConsumerSessionsWithProvider{}
+	relayerClient, conn, err := cswp.ConnectRawClientWithTimeout(context.Background(), listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return *relayerClient, listener.Addr().String()
+}
+
+func TestLatencyPercentileMs(t *testing.T) {
+	require.Zero(t, latencyPercentileMs(nil, soakLatencyPercentileP50))
+	sorted := []time.Duration{}
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, float64(50), latencyPercentileMs(sorted, soakLatencyPercentileP50))
+	require.Equal(t, float64(90), latencyPercentileMs(sorted, soakLatencyPercentileP90))
+	require.Equal(t, float64(99), latencyPercentileMs(sorted, soakLatencyPercentileP99))
+	require.Equal(t, float64(100), latencyPercentileMs(sorted, 1))
+	require.Equal(t, float64(1), latencyPercentileMs(sorted, 0))
+	require.Equal(t, 1.5, latencyPercentileMs([]time.Duration{1500 * time.Microsecond}, soakLatencyPercentileP99))
+}
+
+func TestSoakEndpointStatsReport(t *testing.T) {
+	stats := &soakEndpointStats{}
+	for _, latency := range []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond} {
+		stats.addResult(latency, nil)
+	}
+	stats.addResult(time.Second, context.DeadlineExceeded)
+	stats.rewardProofChecks = 2
+	stats.rewardProofFailures = 1
+	report := stats.report("SOAK1", epochstoragetypes.Endpoint{IPPORT: "127.0.0.1:2220", UseType: "jsonrpc"}, 2*time.Second)
+	require.Equal(t, SoakEndpointReport{
+		ChainID:             "SOAK1",
+		ApiInterface:        "jsonrpc",
+		NetworkAddress:      "127.0.0.1:2220",
+		Requests:            4,
+		Errors:              1,
+		ErrorRate:           0.25,
+		SustainedRPS:        1.5, // failed requests don't count
+		LatencyP50Ms:        20,
+		LatencyP90Ms:        30,
+		LatencyP99Ms:        30,
+		LatencyMaxMs:        30,
+		RewardProofChecks:   2,
+		RewardProofFailures: 1,
+	}, report)
+
+	require.Equal(t, SoakEndpointReport{ChainID: "SOAK1"}, (&soakEndpointStats{}).report("SOAK1", epochstoragetypes.Endpoint{}, 0))
+}
+
+func TestCheckUnsignedRelayRefused(t *testing.T) {
+	refusing, _ := startFakeSoakRelayer(t, &fakeSoakRelayer{})
+	require.NoError(t, checkUnsignedRelayRefused(context.Background(), refusing, "SOAK1", "jsonrpc"))
+	serving, _ := startFakeSoakRelayer(t, &fakeSoakRelayer{servesUnsigned: true})
+	require.Error(t, checkUnsignedRelayRefused(context.Background(), serving, "SOAK1", "jsonrpc"))
+}
+
+func TestRunSoak(t *testing.T) {
+	_, healthyAddress := startFakeSoakRelayer(t, &fakeSoakRelayer{})
+	_, invalidAddress := startFakeSoakRelayer(t, &fakeSoakRelayer{probeOffset: 1})
+	providerEntries := []epochstoragetypes.StakeEntry{
+		{Chain: "SOAK1", Endpoints: []epochstoragetypes.Endpoint{{IPPORT: healthyAddress, UseType: "jsonrpc"}}},
+		{Chain: "SOAK2", Endpoints: []epochstoragetypes.Endpoint{{IPPORT: invalidAddress, UseType: "rest"}}},
+	}
+	report := runSoak(context.Background(), "lava@provider", "lava-testnet", providerEntries, 500*time.Millisecond, 20)
+	require.True(t, report.Completed)
+	require.Equal(t, "lava@provider", report.Provider)
+	require.Equal(t, uint64(20), report.TargetRPS)
+	require.Len(t, report.Endpoints, 2)
+
+	healthy := report.Endpoints[0]
+	require.Equal(t, "SOAK1", healthy.ChainID)
+	require.Equal(t, healthyAddress, healthy.NetworkAddress)
+	require.Positive(t, healthy.Requests)
+	require.Zero(t, healthy.Errors)
+	require.Positive(t, healthy.SustainedRPS)
+	require.Positive(t, healthy.LatencyMaxMs)
+
+	// probes returning another guid are errors
+	invalid := report.Endpoints[1]
+	require.Positive(t, invalid.Requests)
+	require.Equal(t, invalid.Requests, invalid.Errors)
+	require.Equal(t, float64(1), invalid.ErrorRate)
+}
+
+func TestSoakReportSignature(t *testing.T) {
+	privKey, provider := sigs.GenerateFloatingKey()
+	report := &SoakReport{Provider: provider.String(), LavaChainID: "lava-testnet", Completed: true, TargetRPS: 10, Endpoints: []SoakEndpointReport{{ChainID: "SOAK1", Requests: 10}}}
+	signedBytes, err := signSoakReport(privKey, report)
+	require.NoError(t, err)
+	signed := SignedSoakReport{}
+	require.NoError(t, json.Unmarshal(signedBytes, &signed))
+	verified, err := VerifySoakReport(signed)
+	require.NoError(t, err)
+	require.Equal(t, report, verified)
+
+	// a report with modified results or signed by another key isn't valid
+	report.Endpoints[0].Requests = 20
+	reportBytes, err := json.Marshal(report)
+	require.NoError(t, err)
+	_, err = VerifySoakReport(SignedSoakReport{Report: reportBytes, Signature: signed.Signature})
+	require.Error(t, err)
+	otherKey, _ := sigs.GenerateFloatingKey()
+	otherSigned, err := signSoakReport(otherKey, report)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(otherSigned, &signed))
+	_, err = VerifySoakReport(signed)
+	require.Error(t, err)
+}
+
+func TestVerifySoakReportFile(t *testing.T) {
+	privKey, provider := sigs.GenerateFloatingKey()
+	signed, err := signSoakReport(privKey, &SoakReport{Provider: provider.String()})
+	require.NoError(t, err)
+	reportFile := filepath.Join(t.TempDir(), DefaultSoakOutput)
+	require.NoError(t, os.WriteFile(reportFile, signed, 0o600))
+	require.NoError(t, verifySoakReportFile(reportFile))
+
+	require.Error(t, verifySoakReportFile(filepath.Join(t.TempDir(), "missing.json")))
+	invalidFile := filepath.Join(t.TempDir(), "invalid.json")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("report"), 0o600))
+	require.Error(t, verifySoakReportFile(invalidFile))
+}
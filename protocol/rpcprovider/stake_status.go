@@ -0,0 +1,70 @@
+package rpcprovider
+
+import (
+	"context"
+
+	"github.com/lavanet/lava/protocol/statetracker"
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	AutoUnfreezeFlagName = "auto-unfreeze"
+)
+
+var stakeStatusGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lava_provider_stake_status",
+	Help: "1 for the current stake status of the provider on the chain, 0 for the other statuses",
+}, []string{"spec", "status"})
+
+func init() {
+	prometheus.MustRegister(stakeStatusGauge)
+}
+
+type providerUnfreezer interface {
+	TxUnfreezeProvider(ctx context.Context, chainIDs []string) error
+}
+
+// StakeStatusHandler alerts when the provider's stake on a chain is frozen or removed, and can unfreeze it automatically
+type StakeStatusHandler struct {
+	ctx          context.Context
+	chainID      string
+	autoUnfreeze bool
+	unfreezer    providerUnfreezer
+}
+
+func NewStakeStatusHandler(ctx context.Context, chainID string, autoUnfreeze bool, unfreezer providerUnfreezer) *StakeStatusHandler {
+	return &StakeStatusHandler{ctx: ctx, chainID: chainID, autoUnfreeze: autoUnfreeze, unfreezer: unfreezer}
+}
+
+func (ssh *StakeStatusHandler) StakeStatusChanged(event statetracker.StakeStatusEvent) {
+	for _, status := range []statetracker.StakeStatus{statetracker.StakeStatusActive, statetracker.StakeStatusPending, statetracker.StakeStatusFrozen, statetracker.StakeStatusUnstaked} {
+		value := float64(0)
+		if status == event.Status {
+			value = 1
+		}
+		stakeStatusGauge.WithLabelValues(event.ChainID, status.String()).Set(value)
+	}
+	switch event.Status {
+	case statetracker.StakeStatusFrozen:
+		utils.LavaFormatError("provider is frozen on the chain, it leaves the pairing on the next epoch and gets no new relays", nil, utils.Attribute{Key: "chainID", Value: event.ChainID},
+			utils.Attribute{Key: "provider", Value: event.Provider}, utils.Attribute{Key: "autoUnfreeze", Value: ssh.autoUnfreeze})
+		if ssh.autoUnfreeze {
+			// the transaction is sent outside of the state tracker update
+			go func() {
+				err := ssh.unfreezer.TxUnfreezeProvider(ssh.ctx, []string{ssh.chainID})
+				if err != nil {
+					utils.LavaFormatError("failed unfreezing the provider automatically", err, utils.Attribute{Key: "chainID", Value: ssh.chainID})
+					return
+				}
+				utils.LavaFormatInfo("sent an automatic unfreeze transaction", utils.Attribute{Key: "chainID", Value: ssh.chainID})
+			}()
+		}
+	case statetracker.StakeStatusUnstaked:
+		utils.LavaFormatError("provider isn't staked on the chain anymore, it was jailed or unstaked and its relays won't be paid", nil, utils.Attribute{Key: "chainID", Value: event.ChainID},
+			utils.Attribute{Key: "provider", Value: event.Provider}, utils.Attribute{Key: "previousStatus", Value: event.PreviousStatus.String()})
+	default:
+		utils.LavaFormatInfo("provider stake status on the chain changed", utils.Attribute{Key: "chainID", Value: event.ChainID}, utils.Attribute{Key: "status", Value: event.Status.String()},
+			utils.Attribute{Key: "previousStatus", Value: event.PreviousStatus.String()})
+	}
+}
@@ -60,18 +60,9 @@ func startTesting(ctx context.Context, clientCtx client.Context, txFactory tx.Fa
 					return 0, utils.LavaFormatError("probe returned invalid value", err, utils.Attribute{Key: "returnedGuid", Value: returned.Value}, utils.Attribute{Key: "guid", Value: guid}, utils.Attribute{Key: "apiInterface", Value: endpoint.UseType}, utils.Attribute{Key: "chainID", Value: providerEntry.Chain}, utils.Attribute{Key: "network address", Value: endpoint.IPPORT})
 				}
 
-				relayRequest := &pairingtypes.RelayRequest{
-					RelaySession:    &pairingtypes.RelaySession{SpecId: providerEntry.Chain},
-					RelayData:       &pairingtypes.RelayPrivateData{ApiInterface: endpoint.UseType},
-					DataReliability: nil,
-				}
-				_, err = relayerClient.Relay(ctx, relayRequest)
-				if err == nil {
-					return 0, utils.LavaFormatError("relay Without signature did not error, unexpected", nil, utils.Attribute{Key: "apiInterface", Value: endpoint.UseType}, utils.Attribute{Key: "chainID", Value: providerEntry.Chain}, utils.Attribute{Key: "network address", Value: endpoint.IPPORT})
-				}
-				code := status.Code(err)
-				if code != codes.Code(lavasession.EpochMismatchError.ABCICode()) {
-					return 0, utils.LavaFormatError("relay returned unexpected error", err, utils.Attribute{Key: "apiInterface", Value: endpoint.UseType}, utils.Attribute{Key: "chainID", Value: providerEntry.Chain}, utils.Attribute{Key: "network address", Value: endpoint.IPPORT})
+				err = checkUnsignedRelayRefused(ctx, relayerClient, providerEntry.Chain, endpoint.UseType)
+				if err != nil {
+					return 0, utils.LavaFormatError("relay without signature wasn't refused as expected", err, utils.Attribute{Key: "apiInterface", Value: endpoint.UseType}, utils.Attribute{Key: "chainID", Value: providerEntry.Chain}, utils.Attribute{Key: "network address", Value: endpoint.IPPORT})
 				}
 				return relayLatency, nil
 			}
@@ -88,6 +79,83 @@ func startTesting(ctx context.Context, clientCtx client.Context, txFactory tx.Fa
 	return nil
 }
 
+// a relay without a consumer signature carries no proof for rewards, the provider must refuse it before serving
+func checkUnsignedRelayRefused(ctx context.Context, relayerClient pairingtypes.RelayerClient, chainID string, apiInterface string) error {
+	relayRequest := &pairingtypes.RelayRequest{
+		RelaySession:    &pairingtypes.RelaySession{SpecId: chainID},
+		RelayData:       &pairingtypes.RelayPrivateData{ApiInterface: apiInterface},
+		DataReliability: nil,
+	}
+	_, err := relayerClient.Relay(ctx, relayRequest)
+	if err == nil {
+		return utils.LavaFormatWarning("relay Without signature did not error, unexpected", nil)
+	}
+	code := status.Code(err)
+	if code != codes.Code(lavasession.EpochMismatchError.ABCICode()) {
+		return utils.LavaFormatWarning("relay returned unexpected error", err)
+	}
+	return nil
+}
+
+// returns the stake entries of the provider from the --endpoints flag, or from the chain when it isn't set
+func getProviderStakeEntries(ctx context.Context, cmd *cobra.Command, clientCtx client.Context, address string) ([]epochstoragetypes.StakeEntry, error) {
+	resultStatus, err := clientCtx.Client.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currentBlock := resultStatus.SyncInfo.LatestBlockHeight
+	// get all chains provider is serving and their endpoints
+	specQuerier := spectypes.NewQueryClient(clientCtx)
+	allChains, err := specQuerier.ShowAllChains(ctx, &spectypes.QueryShowAllChainsRequest{})
+	if err != nil {
+		return nil, utils.LavaFormatError("failed getting key name from clientCtx, either provider the address in an argument or verify the --from wallet exists", err)
+	}
+	pairingQuerier := pairingtypes.NewQueryClient(clientCtx)
+	stakedProviderChains := []epochstoragetypes.StakeEntry{}
+	endpointConf, err := cmd.Flags().GetString(common.EndpointsConfigName)
+	if err != nil {
+		utils.LavaFormatFatal("failed to read endpoints flag", err)
+	}
+	if endpointConf != "" {
+		tmpArg := strings.Fields(endpointConf)
+		for _, endpointStr := range tmpArg {
+			splitted := strings.Split(endpointStr, ",")
+			if len(splitted) != 3 {
+				return nil, fmt.Errorf("invalid argument format in endpoints, must be: HOST:PORT,useType,chainid HOST:PORT,useType,chainid, received: %s", endpointStr)
+			}
+			endpoint := epochstoragetypes.Endpoint{IPPORT: splitted[0], UseType: splitted[1]}
+			providerEntry := epochstoragetypes.StakeEntry{
+				Endpoints: []epochstoragetypes.Endpoint{endpoint},
+				Chain:     splitted[2],
+			}
+			stakedProviderChains = append(stakedProviderChains, providerEntry)
+		}
+	} else {
+		for _, chainStructInfo := range allChains.ChainInfoList {
+			chainID := chainStructInfo.ChainID
+			response, err := pairingQuerier.Providers(ctx, &pairingtypes.QueryProvidersRequest{
+				ChainID:    chainID,
+				ShowFrozen: true,
+			})
+			if err == nil && len(response.StakeEntry) > 0 {
+				for _, provider := range response.StakeEntry {
+					if provider.Address == address {
+						if provider.StakeAppliedBlock > uint64(currentBlock+1) {
+							utils.LavaFormatWarning("provider is Frozen", nil, utils.Attribute{Key: "chainID", Value: provider.Chain})
+						}
+						stakedProviderChains = append(stakedProviderChains, provider)
+						break
+					}
+				}
+			}
+		}
+	}
+	if len(stakedProviderChains) == 0 {
+		utils.LavaFormatError("no active chains for provider", nil, utils.Attribute{Key: "address", Value: address})
+	}
+	return stakedProviderChains, nil
+}
+
 func CreateTestRPCProviderCobraCommand() *cobra.Command {
 	cmdTestRPCProvider := &cobra.Command{
 		Use:   `rpcprovider {provider_address | --from <wallet>} [--endpoints "listen-ip:listen-port,api-interface,spec-chain-id ..."]`,
@@ -134,60 +202,10 @@ func CreateTestRPCProviderCobraCommand() *cobra.Command {
 			txFactory := tx.NewFactoryCLI(clientCtx, cmd.Flags())
 			utils.LavaFormatInfo("lavad Binary Version: " + version.Version)
 			rand.Seed(time.Now().UnixNano())
-			resultStatus, err := clientCtx.Client.Status(ctx)
+			stakedProviderChains, err := getProviderStakeEntries(ctx, cmd, clientCtx, address)
 			if err != nil {
 				return err
 			}
-			currentBlock := resultStatus.SyncInfo.LatestBlockHeight
-			// get all chains provider is serving and their endpoints
-			specQuerier := spectypes.NewQueryClient(clientCtx)
-			allChains, err := specQuerier.ShowAllChains(ctx, &spectypes.QueryShowAllChainsRequest{})
-			if err != nil {
-				return utils.LavaFormatError("failed getting key name from clientCtx, either provider the address in an argument or verify the --from wallet exists", err)
-			}
-			pairingQuerier := pairingtypes.NewQueryClient(clientCtx)
-			stakedProviderChains := []epochstoragetypes.StakeEntry{}
-			endpointConf, err := cmd.Flags().GetString(common.EndpointsConfigName)
-			if err != nil {
-				utils.LavaFormatFatal("failed to read endpoints flag", err)
-			}
-			if endpointConf != "" {
-				tmpArg := strings.Fields(endpointConf)
-				for _, endpointStr := range tmpArg {
-					splitted := strings.Split(endpointStr, ",")
-					if len(splitted) != 3 {
-						return fmt.Errorf("invalid argument format in endpoints, must be: HOST:PORT,useType,chainid HOST:PORT,useType,chainid, received: %s", endpointStr)
-					}
-					endpoint := epochstoragetypes.Endpoint{IPPORT: splitted[0], UseType: splitted[1]}
-					providerEntry := epochstoragetypes.StakeEntry{
-						Endpoints: []epochstoragetypes.Endpoint{endpoint},
-						Chain:     splitted[2],
-					}
-					stakedProviderChains = append(stakedProviderChains, providerEntry)
-				}
-			} else {
-				for _, chainStructInfo := range allChains.ChainInfoList {
-					chainID := chainStructInfo.ChainID
-					response, err := pairingQuerier.Providers(ctx, &pairingtypes.QueryProvidersRequest{
-						ChainID:    chainID,
-						ShowFrozen: true,
-					})
-					if err == nil && len(response.StakeEntry) > 0 {
-						for _, provider := range response.StakeEntry {
-							if provider.Address == address {
-								if provider.StakeAppliedBlock > uint64(currentBlock+1) {
-									utils.LavaFormatWarning("provider is Frozen", nil, utils.Attribute{Key: "chainID", Value: provider.Chain})
-								}
-								stakedProviderChains = append(stakedProviderChains, provider)
-								break
-							}
-						}
-					}
-				}
-			}
-			if len(stakedProviderChains) == 0 {
-				utils.LavaFormatError("no active chains for provider", nil, utils.Attribute{Key: "address", Value: address})
-			}
 			return startTesting(ctx, clientCtx, txFactory, stakedProviderChains)
 		},
 	}
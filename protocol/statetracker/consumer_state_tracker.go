@@ -16,12 +16,17 @@ import (
 // ConsumerStateTracker CSTis a class for tracking consumer data from the lava blockchain, such as epoch changes.
 // it allows also to query specific data form the blockchain and acts as a single place to send transactions
 type ConsumerStateTracker struct {
-	stateQuery *ConsumerStateQuery
-	txSender   *ConsumerTxSender
+	stateQuery     *ConsumerStateQuery
+	txSender       *ConsumerTxSender
+	lavaNodeClient *LavaNodeClient
+	lavaChainID    string
+	prefetchBlocks uint64
 	*StateTracker
 }
 
+// NewConsumerStateTracker creates the tracker, to be able to switch lava nodes the chainFetcher should use a context returned from NewLavaNodeClient
 func NewConsumerStateTracker(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, chainFetcher chaintracker.ChainFetcher) (ret *ConsumerStateTracker, err error) {
+	lavaNodeClient, clientCtx := NewLavaNodeClient(clientCtx)
 	stateTrackerBase, err := NewStateTracker(ctx, txFactory, clientCtx, chainFetcher)
 	if err != nil {
 		return nil, err
@@ -30,13 +35,78 @@ func NewConsumerStateTracker(ctx context.Context, txFactory tx.Factory, clientCt
 	if err != nil {
 		return nil, err
 	}
-	cst := &ConsumerStateTracker{StateTracker: stateTrackerBase, stateQuery: NewConsumerStateQuery(ctx, clientCtx), txSender: txSender}
+	cst := &ConsumerStateTracker{StateTracker: stateTrackerBase, stateQuery: NewConsumerStateQuery(ctx, clientCtx), txSender: txSender, lavaNodeClient: lavaNodeClient, lavaChainID: clientCtx.ChainID, prefetchBlocks: DefaultPairingPrefetchBlocks}
+	cst.StateTracker.RegisterForUpdates(ctx, cst.stateQuery.queryBatcher)
+	cst.StateTracker.RegisterForUpdates(ctx, cst.stateQuery.ResponsesCache)
+	cst.StateTracker.RegisterForUpdates(ctx, txSender.TxConfirmations())
 	return cst, nil
 }
 
+// SwitchLavaNode moves all lava queries and transactions to a different lava node and revalidates the epoch and pairing state from it,
+// in-flight sessions are reconciled with the pairing returned by the new node instead of requiring a restart
+func (cst *ConsumerStateTracker) SwitchLavaNode(ctx context.Context, nodeURI string) error {
+	// block updaters while the state is revalidated so they don't act on a mix of both nodes
+	cst.registrationLock.Lock()
+	defer cst.registrationLock.Unlock()
+	latestBlock, err := cst.lavaNodeClient.Switch(ctx, nodeURI, cst.lavaChainID)
+	if err != nil {
+		return err
+	}
+	if trackedBlock := cst.chainTracker.GetLatestBlockNum(); latestBlock < trackedBlock {
+		utils.LavaFormatWarning("new lava node is behind the previous one, block updates will resume once it catches up", nil, utils.Attribute{Key: "nodeURI", Value: nodeURI}, utils.Attribute{Key: "height", Value: latestBlock}, utils.Attribute{Key: "trackedBlock", Value: trackedBlock})
+	}
+	// cached pairing and user entries were read from the previous node
+	cst.stateQuery.ClearCache()
+	updater, ok := cst.newLavaBlockUpdaters.Get(CallbackKeyForPairingUpdate)
+	if !ok {
+		return nil
+	}
+	pairingUpdater, ok := updater.(*PairingUpdater)
+	if !ok {
+		return utils.LavaFormatError("invalid updater type registered for pairing updates", nil, utils.Attribute{Key: "updater", Value: updater})
+	}
+	return pairingUpdater.Revalidate(ctx, latestBlock)
+}
+
+// StartLavaNodeFailover health checks the current lava node and backupNodeURIs, and switches to a backup when the current node fails
+func (cst *ConsumerStateTracker) StartLavaNodeFailover(ctx context.Context, backupNodeURIs []string) error {
+	failover, err := NewLavaNodeFailover(cst.lavaNodeClient, append([]string{cst.lavaNodeClient.NodeURI()}, backupNodeURIs...), cst.lavaChainID, cst.SwitchLavaNode)
+	if err != nil {
+		return err
+	}
+	failover.Start(ctx)
+	return nil
+}
+
+// EnforceProtocolVersion checks the binary version against the protocol version param of the lava chain, shutdown is called for the shutdown action
+func (cst *ConsumerStateTracker) EnforceProtocolVersion(ctx context.Context, binaryVersion string, action ProtocolVersionAction, shutdown func()) *ProtocolVersionUpdater {
+	protocolVersionUpdater := NewProtocolVersionUpdater(&cst.stateQuery.StateQuery, binaryVersion, ProtocolVersionRoleConsumer, action, shutdown)
+	protocolVersionUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, protocolVersionUpdater)
+	protocolVersionUpdater, ok := protocolVersionUpdaterRaw.(*ProtocolVersionUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: protocolVersionUpdaterRaw})
+	}
+	return protocolVersionUpdater
+}
+
+// SetSpecOverlays applies local spec modifications to specs queried from now on, used for devnets and forks
+func (cst *ConsumerStateTracker) SetSpecOverlays(specOverlays map[string]*SpecOverlay) {
+	cst.stateQuery.SetSpecOverlays(specOverlays)
+}
+
+// SetPairingPrefetchBlocks sets how many blocks before the epoch boundary the next pairing is queried, it applies to pairing updates registered later
+func (cst *ConsumerStateTracker) SetPairingPrefetchBlocks(prefetchBlocks uint64) {
+	cst.prefetchBlocks = prefetchBlocks
+}
+
+// SetTxGasAdjustment sets the multiplier of the simulated gas of the consumer's transactions
+func (cst *ConsumerStateTracker) SetTxGasAdjustment(gasAdjustment float64) error {
+	return cst.txSender.SetGasAdjustment(gasAdjustment)
+}
+
 func (cst *ConsumerStateTracker) RegisterConsumerSessionManagerForPairingUpdates(ctx context.Context, consumerSessionManager *lavasession.ConsumerSessionManager) {
 	// register this CSM to get the updated pairing list when a new epoch starts
-	pairingUpdater := NewPairingUpdater(cst.stateQuery)
+	pairingUpdater := NewPairingUpdater(cst.stateQuery, cst.prefetchBlocks)
 	pairingUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, pairingUpdater)
 	pairingUpdater, ok := pairingUpdaterRaw.(*PairingUpdater)
 	if !ok {
@@ -48,27 +118,105 @@ func (cst *ConsumerStateTracker) RegisterConsumerSessionManagerForPairingUpdates
 	}
 }
 
-func (cst *ConsumerStateTracker) RegisterFinalizationConsensusForUpdates(ctx context.Context, finalizationConsensus *lavaprotocol.FinalizationConsensus) {
+// RegisterFinalizationConsensusForUpdates also shares the finalized hashes of the consensus with the other endpoints of chainID
+func (cst *ConsumerStateTracker) RegisterFinalizationConsensusForUpdates(ctx context.Context, finalizationConsensus *lavaprotocol.FinalizationConsensus, chainID string) {
 	finalizationConsensusUpdater := NewFinalizationConsensusUpdater(cst.stateQuery)
 	finalizationConsensusUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, finalizationConsensusUpdater)
 	finalizationConsensusUpdater, ok := finalizationConsensusUpdaterRaw.(*FinalizationConsensusUpdater)
 	if !ok {
 		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: finalizationConsensusUpdaterRaw})
 	}
-	finalizationConsensusUpdater.RegisterFinalizationConsensus(finalizationConsensus)
+	finalizationConsensusUpdater.RegisterFinalizationConsensus(finalizationConsensus, chainID)
+}
+
+// GetFinalizedHashesDisagreements returns the finalized blocks the providers of the chain reported different hashes for in the current and previous epochs
+func (cst *ConsumerStateTracker) GetFinalizedHashesDisagreements(chainID string) []lavaprotocol.FinalizedHashesDisagreement {
+	finalizationConsensusUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForFinalizationConsensusUpdate).(*FinalizationConsensusUpdater)
+	if !ok {
+		return nil
+	}
+	chainFinalizedHashes := finalizationConsensusUpdater.FinalizedHashes(chainID)
+	if chainFinalizedHashes == nil {
+		return nil
+	}
+	return chainFinalizedHashes.Disagreements()
+}
+
+// RegisterForSubscriptionUpdates monitors the subscription of consumer, subscriptionUpdatable can be nil to only log and export metrics
+func (cst *ConsumerStateTracker) RegisterForSubscriptionUpdates(ctx context.Context, consumer string, thresholds SubscriptionThresholds, subscriptionUpdatable SubscriptionUpdatable) {
+	subscriptionUpdater := NewSubscriptionUpdater(cst.stateQuery, consumer, thresholds)
+	subscriptionUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, subscriptionUpdater)
+	subscriptionUpdater, ok := subscriptionUpdaterRaw.(*SubscriptionUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: subscriptionUpdaterRaw})
+	}
+	subscriptionUpdater.RegisterSubscriptionUpdatable(subscriptionUpdatable)
+}
+
+func (cst *ConsumerStateTracker) UnregisterForSubscriptionUpdates(subscriptionUpdatable SubscriptionUpdatable) bool {
+	subscriptionUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForSubscriptionUpdate).(*SubscriptionUpdater)
+	return ok && subscriptionUpdater.UnregisterSubscriptionUpdatable(subscriptionUpdatable)
+}
+
+// RegisterForPolicyUpdates passes the effective policy of the consumer's project to policyUpdatable whenever it changes
+func (cst *ConsumerStateTracker) RegisterForPolicyUpdates(ctx context.Context, consumer string, policyUpdatable PolicyUpdatable) {
+	policyUpdater := NewPolicyUpdater(cst.stateQuery, consumer)
+	policyUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, policyUpdater)
+	policyUpdater, ok := policyUpdaterRaw.(*PolicyUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: policyUpdaterRaw})
+	}
+	policyUpdater.RegisterPolicyUpdatable(policyUpdatable)
+}
+
+func (cst *ConsumerStateTracker) UnregisterForPolicyUpdates(policyUpdatable PolicyUpdatable) bool {
+	policyUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForPolicyUpdate).(*PolicyUpdater)
+	return ok && policyUpdater.UnregisterPolicyUpdatable(policyUpdatable)
 }
 
 func (cst *ConsumerStateTracker) RegisterChainParserForSpecUpdates(ctx context.Context, chainParser chainlib.ChainParser, chainID string) error {
-	// TODO: handle spec changes
-	spec, err := cst.stateQuery.GetSpec(ctx, chainID)
-	if err != nil {
-		return err
+	return cst.RegisterForSpecUpdates(ctx, chainParser, chainID)
+}
+
+// RegisterForSpecUpdates sets the current spec of the chain on specUpdatable, and sets it again whenever governance changes the spec
+func (cst *ConsumerStateTracker) RegisterForSpecUpdates(ctx context.Context, specUpdatable SpecUpdatable, chainID string) error {
+	specUpdater := NewSpecUpdater(chainID, &cst.stateQuery.StateQuery)
+	specUpdaterRaw := cst.StateTracker.RegisterForUpdates(ctx, specUpdater)
+	specUpdater, ok := specUpdaterRaw.(*SpecUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: specUpdaterRaw})
 	}
-	chainParser.SetSpec(*spec)
-	return nil
+	return specUpdater.RegisterSpecUpdatable(ctx, specUpdatable)
 }
 
 func (cst *ConsumerStateTracker) TxConflictDetection(ctx context.Context, finalizationConflict *conflicttypes.FinalizationConflict, responseConflict *conflicttypes.ResponseConflict, sameProviderConflict *conflicttypes.FinalizationConflict) error {
 	err := cst.txSender.TxConflictDetection(ctx, finalizationConflict, responseConflict, sameProviderConflict)
 	return err
 }
+
+func (cst *ConsumerStateTracker) UnregisterConsumerSessionManagerForPairingUpdates(consumerSessionManager *lavasession.ConsumerSessionManager) bool {
+	pairingUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForPairingUpdate).(*PairingUpdater)
+	return ok && pairingUpdater.UnregisterPairing(consumerSessionManager)
+}
+
+// ReplaceConsumerSessionManagerForPairingUpdates sets the current pairing on newConsumerSessionManager before it takes the place of oldConsumerSessionManager
+func (cst *ConsumerStateTracker) ReplaceConsumerSessionManagerForPairingUpdates(ctx context.Context, oldConsumerSessionManager *lavasession.ConsumerSessionManager, newConsumerSessionManager *lavasession.ConsumerSessionManager) error {
+	pairingUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForPairingUpdate).(*PairingUpdater)
+	if !ok {
+		return utils.LavaFormatError("no pairing updater registered", nil)
+	}
+	return pairingUpdater.ReplacePairing(ctx, oldConsumerSessionManager, newConsumerSessionManager)
+}
+
+func (cst *ConsumerStateTracker) UnregisterFinalizationConsensusForUpdates(finalizationConsensus *lavaprotocol.FinalizationConsensus) bool {
+	finalizationConsensusUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForFinalizationConsensusUpdate).(*FinalizationConsensusUpdater)
+	return ok && finalizationConsensusUpdater.UnregisterFinalizationConsensus(finalizationConsensus)
+}
+
+func (cst *ConsumerStateTracker) ReplaceFinalizationConsensusForUpdates(oldFinalizationConsensus *lavaprotocol.FinalizationConsensus, newFinalizationConsensus *lavaprotocol.FinalizationConsensus) error {
+	finalizationConsensusUpdater, ok := cst.StateTracker.registeredUpdater(CallbackKeyForFinalizationConsensusUpdate).(*FinalizationConsensusUpdater)
+	if !ok {
+		return utils.LavaFormatError("no finalization consensus updater registered", nil)
+	}
+	return finalizationConsensusUpdater.ReplaceFinalizationConsensus(oldFinalizationConsensus, newFinalizationConsensus)
+}
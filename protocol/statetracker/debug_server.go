@@ -0,0 +1,95 @@
+package statetracker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	StateTrackerDebugAddressFlag = "state-tracker-debug-address"
+	StateTrackerDebugPath        = "/lava/state-tracker"
+)
+
+// UpdaterDebugState is the listing of a single updater in the state tracker debug reply
+type UpdaterDebugState struct {
+	UpdaterKey       string   `json:"updaterKey"`
+	Updatables       []string `json:"updatables"` // keys or descriptions with their interest, empty for updaters that can't list them
+	LastBlock        int64    `json:"lastBlock"`
+	LastSuccessBlock int64    `json:"lastSuccessBlock"`
+	LastError        string   `json:"lastError,omitempty"`
+	Successes        uint64   `json:"successes"`
+	Failures         uint64   `json:"failures"`
+	LastDuration     string   `json:"lastDuration"`
+	MaxDuration      string   `json:"maxDuration"`
+}
+
+// StateTrackerDebugState is the reply of the state tracker debug server
+type StateTrackerDebugState struct {
+	LatestBlock        int64               `json:"latestBlock"`
+	ProcessedBlock     int64               `json:"processedBlock"` // the last block every updater finished
+	ReorgSafetyBlocks  int64               `json:"reorgSafetyBlocks"`
+	UpdaterParallelism int64               `json:"updaterParallelism"`
+	Updaters           []UpdaterDebugState `json:"updaters"` // in dispatch order
+}
+
+// DebugState lists every registered updater with its updatables and update results
+func (cst *StateTracker) DebugState() StateTrackerDebugState {
+	updaters := cst.newLavaBlockUpdaters.Updatables()
+	registered := cst.RegisteredUpdatables()
+	stats := cst.UpdaterStats()
+	debugState := StateTrackerDebugState{ProcessedBlock: cst.processingLag.getProcessedBlock(), LatestBlock: cst.chainTracker.GetLatestBlockNum(), ReorgSafetyBlocks: cst.getReorgSafetyBlocks(), UpdaterParallelism: atomic.LoadInt64(&cst.updaterParallelism), Updaters: make([]UpdaterDebugState, 0, len(updaters))}
+	for _, updater := range updaters {
+		updaterKey := updater.UpdaterKey()
+		updaterStats := stats[updaterKey]
+		debugState.Updaters = append(debugState.Updaters, UpdaterDebugState{
+			UpdaterKey:       updaterKey,
+			Updatables:       registered[updaterKey],
+			LastBlock:        updaterStats.LastBlock,
+			LastSuccessBlock: updaterStats.LastSuccessBlock,
+			LastError:        updaterStats.LastError,
+			Successes:        updaterStats.Successes,
+			Failures:         updaterStats.Failures,
+			LastDuration:     updaterStats.LastDuration.String(),
+			MaxDuration:      updaterStats.MaxDuration.String(),
+		})
+	}
+	return debugState
+}
+
+// StartDebugServer serves the state tracker debug state as json on addr until ctx is done, to diagnose updaters that don't react to chain changes
+func (cst *StateTracker) StartDebugServer(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(StateTrackerDebugPath, cst.serveDebugState)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			utils.LavaFormatError("state tracker debug server failed", err, utils.Attribute{Key: "address", Value: addr})
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	utils.LavaFormatInfo("started state tracker debug server", utils.Attribute{Key: "address", Value: addr}, utils.Attribute{Key: "path", Value: StateTrackerDebugPath})
+}
+
+func (cst *StateTracker) serveDebugState(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(resp).Encode(cst.DebugState())
+	if err != nil {
+		utils.LavaFormatWarning("failed writing state tracker debug reply", err)
+	}
+}
@@ -0,0 +1,50 @@
+package statetracker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateTrackerDebugState(t *testing.T) {
+	cst := newTestStateTracker()
+	cst.chainTracker = &chaintracker.ChainTracker{}
+	cst.reorgSafetyBlocks = 2
+	paymentUpdater, _ := newTestPaymentUpdater(NewFakeStateQuery())
+	cst.RegisterForUpdates(context.Background(), paymentUpdater)
+	cst.RegisterForUpdates(context.Background(), &scriptedUpdater{key: "scripted", failures: map[int64]bool{11: true}})
+	cst.newLavaBlock(10, "")
+	cst.newLavaBlock(11, "")
+
+	recorder := httptest.NewRecorder()
+	cst.serveDebugState(recorder, httptest.NewRequest(http.MethodGet, StateTrackerDebugPath, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	debugState := StateTrackerDebugState{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &debugState))
+	require.Equal(t, int64(11), debugState.ProcessedBlock)
+	require.Equal(t, int64(2), debugState.ReorgSafetyBlocks)
+	require.Equal(t, int64(DefaultUpdaterParallelism), debugState.UpdaterParallelism)
+
+	// updaters are listed in dispatch order, the payment updater is reorg safe so it's called the safety lag behind
+	require.Len(t, debugState.Updaters, 2)
+	scripted, payments := debugState.Updaters[0], debugState.Updaters[1]
+	require.Equal(t, "scripted", scripted.UpdaterKey)
+	require.Equal(t, int64(11), scripted.LastBlock)
+	require.Equal(t, int64(10), scripted.LastSuccessBlock)
+	require.Equal(t, errNodeUnavailable.Error(), scripted.LastError)
+	require.Equal(t, uint64(1), scripted.Failures)
+	require.Empty(t, scripted.Updatables)
+	require.Equal(t, CallbackKeyForPaymentUpdate, payments.UpdaterKey)
+	require.Equal(t, int64(9), payments.LastBlock)
+	require.Len(t, payments.Updatables, 1)
+
+	recorder = httptest.NewRecorder()
+	cst.serveDebugState(recorder, httptest.NewRequest(http.MethodPost, StateTrackerDebugPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
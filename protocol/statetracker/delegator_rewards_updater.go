@@ -0,0 +1,134 @@
+package statetracker
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	CallbackKeyForDelegatorRewardsUpdate = "delegator-rewards-update"
+	DelegatorRewardsQueryBlocks          = 100 // the delegations and their rewards are queried every this many blocks
+)
+
+var (
+	delegatorRewardsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_delegator_rewards",
+		Help: "The rewards the delegations of the provider's address accrued and can claim, by denom",
+	}, []string{"delegator", "denom"})
+	delegationsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_provider_delegations",
+		Help: "The number of validators the provider's address delegates to",
+	}, []string{"delegator"})
+)
+
+func init() {
+	prometheus.MustRegister(delegatorRewardsGauge, delegationsGauge)
+}
+
+// DelegatorRewards are the rewards the delegations of an address accrued, Rewards has an entry for every validator it delegates to
+type DelegatorRewards struct {
+	Delegator string
+	Rewards   map[string]sdk.DecCoins // key is the validator address
+	Total     sdk.DecCoins
+	Block     int64
+}
+
+// ClaimableValidators returns the validators the delegator has rewards to claim from, sorted
+func (dr *DelegatorRewards) ClaimableValidators() []string {
+	validators := []string{}
+	for validator, reward := range dr.Rewards {
+		if !reward.IsZero() {
+			validators = append(validators, validator)
+		}
+	}
+	sort.Strings(validators)
+	return validators
+}
+
+type DelegatorRewardsUpdatable interface {
+	DelegatorRewardsUpdated(rewards DelegatorRewards)
+}
+
+// DelegatorRewardsUpdater follows the delegations of the provider's address and aggregates the rewards it can claim. the delegation and
+// reward events don't carry the delegator, so the state is queried on an interval and the updatables are called on every query
+type DelegatorRewardsUpdater struct {
+	lock                       sync.RWMutex
+	delegatorRewardsUpdatables *UpdatableRegistry[DelegatorRewardsUpdatable]
+	stateQuery                 ProviderStateQueryInf
+	delegator                  string
+	nextBlockForUpdate         int64
+	rewards                    *DelegatorRewards // the last queried, nil before the first query
+}
+
+func NewDelegatorRewardsUpdater(stateQuery ProviderStateQueryInf, delegator string) *DelegatorRewardsUpdater {
+	return &DelegatorRewardsUpdater{delegatorRewardsUpdatables: NewUpdatableRegistry[DelegatorRewardsUpdatable](CallbackKeyForDelegatorRewardsUpdate), stateQuery: stateQuery, delegator: delegator}
+}
+
+func (dru *DelegatorRewardsUpdater) RegisterDelegatorRewardsUpdatable(delegatorRewardsUpdatable DelegatorRewardsUpdatable) {
+	dru.delegatorRewardsUpdatables.RegisterUnique(delegatorRewardsUpdatable, UpdateInterest{Kind: InterestEvent, EventType: CallbackKeyForDelegatorRewardsUpdate})
+}
+
+func (dru *DelegatorRewardsUpdater) UnregisterDelegatorRewardsUpdatable(delegatorRewardsUpdatable DelegatorRewardsUpdatable) bool {
+	return dru.delegatorRewardsUpdatables.UnregisterMatching(delegatorRewardsUpdatable)
+}
+
+func (dru *DelegatorRewardsUpdater) RegisteredUpdatables() []string {
+	return dru.delegatorRewardsUpdatables.Describe()
+}
+
+// DelegatorRewards returns the rewards of the last query, found is false before the first one succeeded
+func (dru *DelegatorRewardsUpdater) DelegatorRewards() (rewards DelegatorRewards, found bool) {
+	dru.lock.RLock()
+	defer dru.lock.RUnlock()
+	if dru.rewards == nil {
+		return DelegatorRewards{}, false
+	}
+	return *dru.rewards, true
+}
+
+func (dru *DelegatorRewardsUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
+}
+
+func (dru *DelegatorRewardsUpdater) UpdaterKey() string {
+	return CallbackKeyForDelegatorRewardsUpdate
+}
+
+func (dru *DelegatorRewardsUpdater) Update(latestBlock int64) error {
+	if latestBlock < dru.nextBlockForUpdate {
+		return nil
+	}
+	dru.nextBlockForUpdate = latestBlock + DelegatorRewardsQueryBlocks
+	rewards, err := dru.stateQuery.GetDelegatorRewards(context.Background(), dru.delegator)
+	if err != nil {
+		return utils.LavaFormatWarning("failed querying delegator rewards, trying again later", err, utils.Attribute{Key: "delegator", Value: dru.delegator})
+	}
+	rewards.Block = latestBlock
+	dru.lock.Lock()
+	previous := dru.rewards
+	dru.rewards = rewards
+	dru.lock.Unlock()
+	if previous == nil || len(previous.Rewards) != len(rewards.Rewards) {
+		utils.LavaFormatInfo("provider address delegations changed", utils.Attribute{Key: "delegator", Value: dru.delegator}, utils.Attribute{Key: "validators", Value: len(rewards.Rewards)}, utils.Attribute{Key: "rewards", Value: rewards.Total.String()})
+	}
+	if previous != nil {
+		// a claimed denom isn't in the total anymore
+		for _, coin := range previous.Total {
+			delegatorRewardsGauge.WithLabelValues(dru.delegator, coin.Denom).Set(0)
+		}
+	}
+	for _, coin := range rewards.Total {
+		amount, _ := coin.Amount.Float64()
+		delegatorRewardsGauge.WithLabelValues(dru.delegator, coin.Denom).Set(amount)
+	}
+	delegationsGauge.WithLabelValues(dru.delegator).Set(float64(len(rewards.Rewards)))
+	dru.delegatorRewardsUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, EventTypes: map[string]struct{}{CallbackKeyForDelegatorRewardsUpdate: {}}}, func(_ string, delegatorRewardsUpdatable DelegatorRewardsUpdatable) {
+		delegatorRewardsUpdatable.DelegatorRewardsUpdated(*rewards)
+	})
+	return nil
+}
@@ -0,0 +1,87 @@
+package statetracker
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const testDelegator = "lava@delegator"
+
+type recordingDelegatorRewardsUpdatable struct {
+	rewards []DelegatorRewards
+}
+
+func (rdru *recordingDelegatorRewardsUpdatable) DelegatorRewardsUpdated(rewards DelegatorRewards) {
+	rdru.rewards = append(rdru.rewards, rewards)
+}
+
+func TestDelegatorRewardsClaimableValidators(t *testing.T) {
+	rewards := DelegatorRewards{Rewards: map[string]sdk.DecCoins{
+		"lavavaloper@b":    sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(5))),
+		"lavavaloper@a":    sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(1))),
+		"lavavaloper@none": sdk.NewDecCoins(),
+	}}
+	require.Equal(t, []string{"lavavaloper@a", "lavavaloper@b"}, rewards.ClaimableValidators())
+}
+
+func TestDelegatorRewardsUpdater(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.DelegatorRewards[testDelegator] = &DelegatorRewards{
+		Delegator: testDelegator,
+		Rewards: map[string]sdk.DecCoins{
+			"lavavaloper@a": sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(7)), sdk.NewDecCoin("uatom", sdk.NewInt(2))),
+		},
+		Total: sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(7)), sdk.NewDecCoin("uatom", sdk.NewInt(2))),
+	}
+	updater := NewDelegatorRewardsUpdater(stateQuery, testDelegator)
+	recorder := &recordingDelegatorRewardsUpdatable{}
+	updater.RegisterDelegatorRewardsUpdatable(recorder)
+	_, found := updater.DelegatorRewards()
+	require.False(t, found)
+
+	require.NoError(t, updater.Update(10))
+	rewards, found := updater.DelegatorRewards()
+	require.True(t, found)
+	require.Equal(t, int64(10), rewards.Block)
+	require.Len(t, recorder.rewards, 1)
+	require.Equal(t, 7.0, testutil.ToFloat64(delegatorRewardsGauge.WithLabelValues(testDelegator, "ulava")))
+	require.Equal(t, 1.0, testutil.ToFloat64(delegationsGauge.WithLabelValues(testDelegator)))
+
+	// the rewards are queried once an interval
+	require.NoError(t, updater.Update(10+DelegatorRewardsQueryBlocks-1))
+	require.Equal(t, 1, stateQuery.Calls("GetDelegatorRewards"))
+
+	// the claimed denom is reset in the metrics
+	stateQuery.Lock()
+	stateQuery.DelegatorRewards[testDelegator] = &DelegatorRewards{
+		Delegator: testDelegator,
+		Rewards:   map[string]sdk.DecCoins{"lavavaloper@a": sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(3)))},
+		Total:     sdk.NewDecCoins(sdk.NewDecCoin("ulava", sdk.NewInt(3))),
+	}
+	stateQuery.Unlock()
+	require.NoError(t, updater.Update(10+DelegatorRewardsQueryBlocks))
+	require.Equal(t, 3.0, testutil.ToFloat64(delegatorRewardsGauge.WithLabelValues(testDelegator, "ulava")))
+	require.Zero(t, testutil.ToFloat64(delegatorRewardsGauge.WithLabelValues(testDelegator, "uatom")))
+	require.Len(t, recorder.rewards, 2)
+	require.Equal(t, int64(10+DelegatorRewardsQueryBlocks), recorder.rewards[1].Block)
+}
+
+func TestDelegatorRewardsUpdaterQueryFailure(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	updater := NewDelegatorRewardsUpdater(stateQuery, testDelegator)
+	recorder := &recordingDelegatorRewardsUpdatable{}
+	updater.RegisterDelegatorRewardsUpdatable(recorder)
+	stateQuery.FailNext("GetDelegatorRewards", errNodeUnavailable)
+	require.Error(t, updater.Update(10))
+	_, found := updater.DelegatorRewards()
+	require.False(t, found)
+	require.Empty(t, recorder.rewards)
+	// a failed query is retried on the next interval
+	require.NoError(t, updater.Update(11))
+	require.Equal(t, 1, stateQuery.Calls("GetDelegatorRewards"))
+	require.NoError(t, updater.Update(10+DelegatorRewardsQueryBlocks))
+	require.Len(t, recorder.rewards, 1)
+}
@@ -0,0 +1,159 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	CallbackKeyForDowntimeUpdate = "downtime-update"
+	DowntimeDurationFlagName     = "downtime-duration"
+	DefaultDowntimeDuration      = 5 * time.Minute
+	downtimeChecksPerWindow      = 10
+)
+
+// DowntimeUpdatable is called when lava stops producing blocks, virtualEpoch counts the epochs that passed in time since the last block.
+// consumers keep relaying during an outage, so the compute units allowed in epoch are extended by virtualEpoch epochs
+type DowntimeUpdatable interface {
+	UpdateVirtualEpoch(epoch uint64, virtualEpoch uint64)
+}
+
+// DowntimeTracker detects lava downtime, no new block within the downtime window, and computes virtual epochs from the time
+// an epoch takes at the observed block time. the virtual epoch is kept until the next real epoch starts
+type DowntimeTracker struct {
+	lock               sync.Mutex
+	downtimeUpdatables *UpdatableRegistry[DowntimeUpdatable]
+	stateQuery         EpochStateQueryInf
+	chainTracker       *chaintracker.ChainTracker
+	downtimeDuration   time.Duration
+	startOnce          sync.Once
+	lastBlockTime      time.Time
+	currentEpoch       uint64
+	epochSize          uint64
+	nextEpochStart     uint64
+	virtualEpoch       uint64
+	inDowntime         bool
+}
+
+func NewDowntimeTracker(stateQuery EpochStateQueryInf, chainTracker *chaintracker.ChainTracker, downtimeDuration time.Duration) *DowntimeTracker {
+	if downtimeDuration <= 0 {
+		downtimeDuration = DefaultDowntimeDuration
+	}
+	return &DowntimeTracker{downtimeUpdatables: NewUpdatableRegistry[DowntimeUpdatable](CallbackKeyForDowntimeUpdate), stateQuery: stateQuery, chainTracker: chainTracker, downtimeDuration: downtimeDuration}
+}
+
+// RegisterDowntimeUpdatable starts the downtime checks on the first registration, the updater returned from RegisterForUpdates is the one started
+func (dt *DowntimeTracker) RegisterDowntimeUpdatable(ctx context.Context, downtimeUpdatable DowntimeUpdatable) {
+	dt.downtimeUpdatables.RegisterUnique(downtimeUpdatable, UpdateInterest{Kind: InterestEveryBlock})
+	dt.startOnce.Do(func() {
+		go dt.checkDowntime(ctx)
+	})
+}
+
+func (dt *DowntimeTracker) UnregisterDowntimeUpdatable(downtimeUpdatable DowntimeUpdatable) bool {
+	return dt.downtimeUpdatables.UnregisterMatching(downtimeUpdatable)
+}
+
+func (dt *DowntimeTracker) RegisteredUpdatables() []string {
+	return dt.downtimeUpdatables.Describe()
+}
+
+// the epoch the virtual epochs are counted from is read after the epoch updater ran
+func (dt *DowntimeTracker) UpdatePriority() int {
+	return UpdatePriorityLow
+}
+
+func (dt *DowntimeTracker) UpdaterKey() string {
+	return CallbackKeyForDowntimeUpdate
+}
+
+// VirtualEpoch returns the current epoch and the virtual epochs added to it by downtime
+func (dt *DowntimeTracker) VirtualEpoch() (epoch uint64, virtualEpoch uint64) {
+	dt.lock.Lock()
+	defer dt.lock.Unlock()
+	return dt.currentEpoch, dt.virtualEpoch
+}
+
+func (dt *DowntimeTracker) Update(latestBlock int64) error {
+	dt.lock.Lock()
+	defer dt.lock.Unlock()
+	if dt.inDowntime {
+		utils.LavaFormatInfo("lava blocks resumed after downtime", utils.Attribute{Key: "block", Value: latestBlock}, utils.Attribute{Key: "downtime", Value: time.Since(dt.lastBlockTime)}, utils.Attribute{Key: "virtualEpoch", Value: dt.virtualEpoch})
+		dt.inDowntime = false
+	}
+	dt.lastBlockTime = time.Now()
+	if dt.nextEpochStart != 0 && uint64(latestBlock) < dt.nextEpochStart {
+		return nil
+	}
+	ctx := context.Background()
+	currentEpoch, err := dt.stateQuery.CurrentEpochStart(ctx)
+	if err != nil || currentEpoch <= dt.currentEpoch {
+		return err // queried again on the next block
+	}
+	epochSize, err := dt.stateQuery.GetEpochSize(ctx)
+	if err != nil {
+		return utils.LavaFormatWarning("failed reading the epoch size for downtime detection", err, utils.Attribute{Key: "epoch", Value: currentEpoch})
+	}
+	// a new epoch resets the virtual epochs, the extension was only for the epoch that was stuck
+	dt.currentEpoch = currentEpoch
+	dt.epochSize = epochSize
+	dt.nextEpochStart = currentEpoch + epochSize
+	dt.virtualEpoch = 0
+	return nil
+}
+
+func (dt *DowntimeTracker) checkDowntime(ctx context.Context) {
+	ticker := time.NewTicker(dt.downtimeDuration / downtimeChecksPerWindow)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			dt.checkDowntimeOnce(time.Now())
+		}
+	}
+}
+
+func (dt *DowntimeTracker) checkDowntimeOnce(now time.Time) {
+	dt.lock.Lock()
+	if dt.lastBlockTime.IsZero() || dt.currentEpoch == 0 {
+		dt.lock.Unlock()
+		return // no block was seen yet, a node that never responded isn't downtime
+	}
+	sinceLastBlock := now.Sub(dt.lastBlockTime)
+	if sinceLastBlock < dt.downtimeDuration {
+		dt.lock.Unlock()
+		return
+	}
+	if !dt.inDowntime {
+		utils.LavaFormatWarning("no new lava blocks within the downtime window, extending compute units with virtual epochs", nil, utils.Attribute{Key: "lastBlockTime", Value: dt.lastBlockTime}, utils.Attribute{Key: "downtimeDuration", Value: dt.downtimeDuration}, utils.Attribute{Key: "epoch", Value: dt.currentEpoch})
+		dt.inDowntime = true
+	}
+	virtualEpoch := dt.virtualEpochsUnsafe(sinceLastBlock)
+	if virtualEpoch <= dt.virtualEpoch {
+		dt.lock.Unlock()
+		return
+	}
+	dt.virtualEpoch = virtualEpoch
+	epoch := dt.currentEpoch
+	dt.lock.Unlock()
+	utils.LavaFormatInfo("lava downtime virtual epoch", utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "virtualEpoch", Value: virtualEpoch}, utils.Attribute{Key: "downtime", Value: sinceLastBlock})
+	dt.downtimeUpdatables.Dispatch(UpdateTrigger{}, func(_ string, downtimeUpdatable DowntimeUpdatable) {
+		downtimeUpdatable.UpdateVirtualEpoch(epoch, virtualEpoch)
+	})
+}
+
+// the first virtual epoch starts when the downtime window passes, another one is added every epoch duration after it
+func (dt *DowntimeTracker) virtualEpochsUnsafe(sinceLastBlock time.Duration) uint64 {
+	averageBlockTime, _ := dt.chainTracker.GetAverageBlockTime()
+	epochDuration := time.Duration(dt.epochSize) * averageBlockTime
+	if epochDuration <= 0 {
+		epochDuration = dt.downtimeDuration
+	}
+	return 1 + uint64((sinceLastBlock-dt.downtimeDuration)/epochDuration)
+}
@@ -0,0 +1,58 @@
+package statetracker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingDowntimeUpdatable struct {
+	virtualEpochs [][2]uint64 // epoch and virtual epoch
+}
+
+func (rdu *recordingDowntimeUpdatable) UpdateVirtualEpoch(epoch uint64, virtualEpoch uint64) {
+	rdu.virtualEpochs = append(rdu.virtualEpochs, [2]uint64{epoch, virtualEpoch})
+}
+
+func TestDowntimeTrackerVirtualEpochs(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.EpochStart, stateQuery.EpochSize = 20, 20
+	// without block time samples an epoch takes the downtime window
+	downtimeTracker := NewDowntimeTracker(stateQuery, &chaintracker.ChainTracker{}, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // the checks are run by the test
+	updatable := &recordingDowntimeUpdatable{}
+	downtimeTracker.RegisterDowntimeUpdatable(ctx, updatable)
+
+	// no block was seen yet
+	downtimeTracker.checkDowntimeOnce(time.Now().Add(time.Hour))
+	require.Empty(t, updatable.virtualEpochs)
+
+	require.NoError(t, downtimeTracker.Update(20))
+	lastBlockTime := downtimeTracker.lastBlockTime
+	downtimeTracker.checkDowntimeOnce(lastBlockTime.Add(30 * time.Second))
+	require.Empty(t, updatable.virtualEpochs)
+	downtimeTracker.checkDowntimeOnce(lastBlockTime.Add(61 * time.Second))
+	downtimeTracker.checkDowntimeOnce(lastBlockTime.Add(90 * time.Second))
+	require.Equal(t, [][2]uint64{{20, 1}}, updatable.virtualEpochs)
+	downtimeTracker.checkDowntimeOnce(lastBlockTime.Add(3*time.Minute + time.Second))
+	require.Equal(t, [][2]uint64{{20, 1}, {20, 3}}, updatable.virtualEpochs)
+
+	// the virtual epoch is kept when the blocks resume, until the next epoch starts
+	require.NoError(t, downtimeTracker.Update(21))
+	epoch, virtualEpoch := downtimeTracker.VirtualEpoch()
+	require.Equal(t, uint64(20), epoch)
+	require.Equal(t, uint64(3), virtualEpoch)
+	require.False(t, downtimeTracker.inDowntime)
+	stateQuery.EpochStart = 40
+	require.NoError(t, downtimeTracker.Update(40))
+	epoch, virtualEpoch = downtimeTracker.VirtualEpoch()
+	require.Equal(t, uint64(40), epoch)
+	require.Equal(t, uint64(0), virtualEpoch)
+	require.Equal(t, 2, stateQuery.Calls("CurrentEpochStart"))
+
+	require.True(t, downtimeTracker.UnregisterDowntimeUpdatable(updatable))
+}
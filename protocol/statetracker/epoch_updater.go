@@ -1,6 +1,7 @@
 package statetracker
 
 import (
+	"github.com/lavanet/lava/utils"
 	"golang.org/x/net/context"
 )
 
@@ -12,35 +13,102 @@ type EpochUpdatable interface {
 	UpdateEpoch(epoch uint64)
 }
 
+// EpochBoundaryUpdatable is called once per epoch with the epoch start block and its hash
+type EpochBoundaryUpdatable interface {
+	UpdateEpochBoundary(epoch uint64, epochHash string)
+}
+
+// EpochUpdater computes the next epoch start from the epoch size, so the epoch details are queried around epoch boundaries instead of on every block
 type EpochUpdater struct {
-	epochUpdatables []*EpochUpdatable
-	currentEpoch    uint64
-	stateQuery      *EpochStateQuery
+	epochUpdatables         *UpdatableRegistry[EpochUpdatable]
+	epochBoundaryUpdatables *UpdatableRegistry[EpochBoundaryUpdatable]
+	currentEpoch            uint64
+	nextEpochStart          uint64 // 0 until the epoch size is known, the epoch details are queried on every block until then
+	stateQuery              EpochStateQueryInf
 }
 
-func NewEpochUpdater(stateQuery *EpochStateQuery) *EpochUpdater {
-	return &EpochUpdater{epochUpdatables: []*EpochUpdatable{}, stateQuery: stateQuery}
+func NewEpochUpdater(stateQuery EpochStateQueryInf) *EpochUpdater {
+	return &EpochUpdater{
+		epochUpdatables:         NewUpdatableRegistry[EpochUpdatable](CallbackKeyForEpochUpdate),
+		epochBoundaryUpdatables: NewUpdatableRegistry[EpochBoundaryUpdatable](CallbackKeyForEpochUpdate + "-boundary"),
+		stateQuery:              stateQuery,
+	}
 }
 
 func (eu *EpochUpdater) RegisterEpochUpdatable(ctx context.Context, epochUpdatable EpochUpdatable) {
-	eu.epochUpdatables = append(eu.epochUpdatables, &epochUpdatable)
+	eu.epochUpdatables.RegisterUnique(epochUpdatable, UpdateInterest{Kind: InterestNewEpoch})
+}
+
+func (eu *EpochUpdater) RegisterEpochBoundaryUpdatable(ctx context.Context, epochBoundaryUpdatable EpochBoundaryUpdatable) {
+	eu.epochBoundaryUpdatables.RegisterUnique(epochBoundaryUpdatable, UpdateInterest{Kind: InterestNewEpoch})
+}
+
+func (eu *EpochUpdater) UnregisterEpochBoundaryUpdatable(epochBoundaryUpdatable EpochBoundaryUpdatable) bool {
+	return eu.epochBoundaryUpdatables.UnregisterMatching(epochBoundaryUpdatable)
+}
+
+// UnregisterEpochUpdatable returns false if epochUpdatable isn't registered, updatables are compared by value so they should be pointers
+func (eu *EpochUpdater) UnregisterEpochUpdatable(epochUpdatable EpochUpdatable) bool {
+	return eu.epochUpdatables.UnregisterMatching(epochUpdatable)
+}
+
+// ReplaceEpochUpdatable puts newEpochUpdatable in the place of oldEpochUpdatable, it gets the next epoch update like the old one would have
+func (eu *EpochUpdater) ReplaceEpochUpdatable(oldEpochUpdatable EpochUpdatable, newEpochUpdatable EpochUpdatable) error {
+	return eu.epochUpdatables.ReplaceMatching(oldEpochUpdatable, newEpochUpdatable)
+}
+
+func (eu *EpochUpdater) RegisteredUpdatables() []string {
+	return append(eu.epochUpdatables.Describe(), eu.epochBoundaryUpdatables.Describe()...)
+}
+
+// the epoch is updated before the updaters that depend on it
+func (eu *EpochUpdater) UpdatePriority() int {
+	return UpdatePriorityHigh
 }
 
 func (eu *EpochUpdater) UpdaterKey() string {
 	return CallbackKeyForEpochUpdate
 }
 
-func (eu *EpochUpdater) Update(latestBlock int64) {
-	ctx := context.Background()
-	currentEpoch, err := eu.stateQuery.CurrentEpochStart(ctx)
-	if err != nil {
-		return // failed to get the current epoch
+func (eu *EpochUpdater) Update(latestBlock int64) error {
+	// the epoch start and size are read at the same height
+	ctx := NewQuerySession(context.Background(), latestBlock)
+	newEpoch := false
+	if eu.nextEpochStart == 0 || uint64(latestBlock) >= eu.nextEpochStart {
+		currentEpoch, err := eu.stateQuery.CurrentEpochStart(ctx)
+		if err != nil {
+			return err // failed to get the current epoch, queried again on the next block
+		}
+		// the epoch might not have started yet on the expected block, the details are queried again on the next block
+		newEpoch = currentEpoch > eu.currentEpoch
+		if newEpoch {
+			eu.currentEpoch = currentEpoch
+			eu.nextEpochStart = 0
+			epochSize, err := eu.stateQuery.GetEpochSize(ctx)
+			if err != nil {
+				utils.LavaFormatWarning("failed reading the epoch size, querying the epoch on every block until it's known", err, utils.Attribute{Key: "epoch", Value: currentEpoch})
+			} else {
+				eu.nextEpochStart = currentEpoch + epochSize
+			}
+		}
 	}
-	if currentEpoch <= eu.currentEpoch {
-		return // still the same epoch
+	currentEpoch := eu.currentEpoch
+	if currentEpoch == 0 {
+		return nil // the epoch was never read
 	}
-	eu.currentEpoch = currentEpoch
-	for _, epochUpdatable := range eu.epochUpdatables {
-		(*epochUpdatable).UpdateEpoch(currentEpoch)
+	// updatables interested in every block get the current epoch on every block, the rest only when it changes
+	eu.epochUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, NewEpoch: newEpoch}, func(_ string, epochUpdatable EpochUpdatable) {
+		epochUpdatable.UpdateEpoch(currentEpoch)
+	})
+	if newEpoch && eu.epochBoundaryUpdatables.Len() > 0 {
+		epochHash, err := eu.stateQuery.BlockHash(ctx, int64(currentEpoch))
+		if err != nil {
+			// the boundary is still reported once, the hash can be read later by the updatables that need it
+			utils.LavaFormatWarning("failed reading the epoch start block hash", err, utils.Attribute{Key: "epoch", Value: currentEpoch})
+		}
+		eu.epochBoundaryUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, NewEpoch: true}, func(_ string, epochBoundaryUpdatable EpochBoundaryUpdatable) {
+			epochBoundaryUpdatable.UpdateEpochBoundary(currentEpoch, epochHash)
+		})
 	}
+	return nil
 }
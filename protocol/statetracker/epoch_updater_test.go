@@ -0,0 +1,103 @@
+package statetracker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEpochUpdatable struct {
+	epochs []uint64
+}
+
+func (reu *recordingEpochUpdatable) UpdateEpoch(epoch uint64) {
+	reu.epochs = append(reu.epochs, epoch)
+}
+
+func TestEpochUpdaterUnregisterReplace(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.EpochStart, stateQuery.EpochSize = 20, 20
+	epochUpdater := NewEpochUpdater(stateQuery)
+	first, second := &recordingEpochUpdatable{}, &recordingEpochUpdatable{}
+	epochUpdater.RegisterEpochUpdatable(context.Background(), first)
+	epochUpdater.RegisterEpochUpdatable(context.Background(), second)
+	require.Len(t, epochUpdater.RegisteredUpdatables(), 2)
+	require.NoError(t, epochUpdater.Update(20))
+
+	// the replacement gets the next epoch like the replaced updatable would have
+	replacement := &recordingEpochUpdatable{}
+	require.NoError(t, epochUpdater.ReplaceEpochUpdatable(first, replacement))
+	require.Error(t, epochUpdater.ReplaceEpochUpdatable(first, replacement))
+	require.True(t, epochUpdater.UnregisterEpochUpdatable(second))
+	require.False(t, epochUpdater.UnregisterEpochUpdatable(second))
+	stateQuery.EpochStart = 40
+	require.NoError(t, epochUpdater.Update(40))
+	require.Equal(t, []uint64{20}, first.epochs)
+	require.Equal(t, []uint64{20}, second.epochs)
+	require.Equal(t, []uint64{40}, replacement.epochs)
+	require.Len(t, epochUpdater.RegisteredUpdatables(), 1)
+}
+
+type recordingEpochBoundaryUpdatable struct {
+	epochs []uint64
+	hashes []string
+}
+
+func (rebu *recordingEpochBoundaryUpdatable) UpdateEpochBoundary(epoch uint64, epochHash string) {
+	rebu.epochs = append(rebu.epochs, epoch)
+	rebu.hashes = append(rebu.hashes, epochHash)
+}
+
+func TestEpochUpdaterBoundaries(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.EpochStart, stateQuery.EpochSize = 20, 20
+	stateQuery.BlockHashes[20], stateQuery.BlockHashes[40] = "hash20", "hash40"
+	epochUpdater := NewEpochUpdater(stateQuery)
+	updatable, boundaryUpdatable := &recordingEpochUpdatable{}, &recordingEpochBoundaryUpdatable{}
+	epochUpdater.RegisterEpochUpdatable(context.Background(), updatable)
+	epochUpdater.RegisterEpochBoundaryUpdatable(context.Background(), boundaryUpdatable)
+
+	require.NoError(t, epochUpdater.Update(25))
+	require.Equal(t, uint64(40), epochUpdater.nextEpochStart)
+	// the epoch isn't queried until the next epoch start
+	for block := int64(26); block < 40; block++ {
+		require.NoError(t, epochUpdater.Update(block))
+	}
+	require.Equal(t, 1, stateQuery.Calls("CurrentEpochStart"))
+	// the epoch didn't start on the expected block, it's queried again on the next one
+	require.NoError(t, epochUpdater.Update(40))
+	stateQuery.EpochStart = 40
+	require.NoError(t, epochUpdater.Update(41))
+	require.Equal(t, 3, stateQuery.Calls("CurrentEpochStart"))
+	require.Equal(t, []uint64{20, 40}, updatable.epochs)
+	require.Equal(t, []uint64{20, 40}, boundaryUpdatable.epochs)
+	require.Equal(t, []string{"hash20", "hash40"}, boundaryUpdatable.hashes)
+	require.Equal(t, uint64(60), epochUpdater.nextEpochStart)
+}
+
+func TestEpochUpdaterQueryFailures(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.EpochStart, stateQuery.EpochSize = 20, 20
+	epochUpdater := NewEpochUpdater(stateQuery)
+	updatable, boundaryUpdatable := &recordingEpochUpdatable{}, &recordingEpochBoundaryUpdatable{}
+	epochUpdater.RegisterEpochUpdatable(context.Background(), updatable)
+	epochUpdater.RegisterEpochBoundaryUpdatable(context.Background(), boundaryUpdatable)
+
+	// nothing is dispatched before the epoch is known
+	stateQuery.FailNext("CurrentEpochStart", errNodeUnavailable)
+	require.ErrorIs(t, epochUpdater.Update(20), errNodeUnavailable)
+	require.Empty(t, updatable.epochs)
+
+	// without the epoch size the epoch is queried on every block, the boundary is still reported once without its hash
+	stateQuery.FailNext("GetEpochSize", errNodeUnavailable)
+	stateQuery.FailNext("BlockHash", errNodeUnavailable)
+	require.NoError(t, epochUpdater.Update(21))
+	require.NoError(t, epochUpdater.Update(22))
+	require.NoError(t, epochUpdater.Update(23))
+	require.Equal(t, 4, stateQuery.Calls("CurrentEpochStart"))
+	require.Equal(t, []uint64{20}, updatable.epochs)
+	require.Equal(t, []uint64{20}, boundaryUpdatable.epochs)
+	require.Equal(t, []string{""}, boundaryUpdatable.hashes)
+	require.Equal(t, uint64(0), epochUpdater.nextEpochStart)
+}
@@ -0,0 +1,99 @@
+package statetracker
+
+import (
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	EventDedupWindowBlocks = 2 * MaxMissedPaymentBlocks // events are remembered for this many blocks, older events are rejected
+	BlockEventTxIndex      = -1                         // the tx index of begin and end block events
+)
+
+var duplicateEventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_state_tracker_duplicate_events_total",
+	Help: "The number of on chain events that were read again and not passed to the updatables, by updater and reason",
+}, []string{"updater", "reason"})
+
+func init() {
+	prometheus.MustRegister(duplicateEventsCounter)
+}
+
+// EventID is the position of an event on chain, it's the same whether the event was read from the block results or the tx index
+type EventID struct {
+	Block      int64
+	TxIndex    int // BlockEventTxIndex for events outside of transactions
+	EventIndex int // the index in the tx or block events
+}
+
+type eventPosition struct {
+	txIndex    int
+	eventIndex int
+}
+
+// eventDeduplicator remembers the events an updater handled in a sliding window of blocks, so reconnects, retries of pending blocks
+// and overlapping range queries never pass the same event twice
+type eventDeduplicator struct {
+	lock        sync.Mutex
+	updaterKey  string
+	window      int64
+	latestBlock int64
+	seen        map[int64]map[eventPosition]struct{} // key is the block
+}
+
+func newEventDeduplicator(updaterKey string, window int64) *eventDeduplicator {
+	return &eventDeduplicator{updaterKey: updaterKey, window: window, seen: map[int64]map[eventPosition]struct{}{}}
+}
+
+// firstSeen marks the event as seen and returns false if it was seen before or is older than the window
+func (ed *eventDeduplicator) firstSeen(eventID EventID) bool {
+	ed.lock.Lock()
+	defer ed.lock.Unlock()
+	if eventID.Block > ed.latestBlock {
+		ed.latestBlock = eventID.Block
+		ed.pruneUnsafe()
+	}
+	if eventID.Block <= ed.latestBlock-ed.window {
+		duplicateEventsCounter.WithLabelValues(ed.updaterKey, "outside_window").Inc()
+		utils.LavaFormatWarning("rejecting an event older than the dedup window", nil, utils.Attribute{Key: "updater", Value: ed.updaterKey},
+			utils.Attribute{Key: "event", Value: eventID}, utils.Attribute{Key: "latestBlock", Value: ed.latestBlock})
+		return false
+	}
+	position := eventPosition{txIndex: eventID.TxIndex, eventIndex: eventID.EventIndex}
+	blockEvents, ok := ed.seen[eventID.Block]
+	if !ok {
+		blockEvents = map[eventPosition]struct{}{}
+		ed.seen[eventID.Block] = blockEvents
+	}
+	if _, ok := blockEvents[position]; ok {
+		duplicateEventsCounter.WithLabelValues(ed.updaterKey, "duplicate").Inc()
+		utils.LavaFormatDebug("skipping an event that was already handled", utils.Attribute{Key: "updater", Value: ed.updaterKey}, utils.Attribute{Key: "event", Value: eventID})
+		return false
+	}
+	blockEvents[position] = struct{}{}
+	return true
+}
+
+// forget drops the events from fromBlock, after a reorg the blocks of the new fork have different events in the same positions
+func (ed *eventDeduplicator) forget(fromBlock int64) {
+	ed.lock.Lock()
+	defer ed.lock.Unlock()
+	for block := range ed.seen {
+		if block >= fromBlock {
+			delete(ed.seen, block)
+		}
+	}
+	if ed.latestBlock >= fromBlock {
+		ed.latestBlock = fromBlock - 1
+	}
+}
+
+func (ed *eventDeduplicator) pruneUnsafe() {
+	for block := range ed.seen {
+		if block <= ed.latestBlock-ed.window {
+			delete(ed.seen, block)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package statetracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventDeduplicator(t *testing.T) {
+	dedup := newEventDeduplicator("test", 10)
+	require.True(t, dedup.firstSeen(EventID{Block: 20, TxIndex: 0, EventIndex: 0}))
+	require.False(t, dedup.firstSeen(EventID{Block: 20, TxIndex: 0, EventIndex: 0}))
+	// the position is the tx and the event in it, block events don't collide with tx events
+	require.True(t, dedup.firstSeen(EventID{Block: 20, TxIndex: 0, EventIndex: 1}))
+	require.True(t, dedup.firstSeen(EventID{Block: 20, TxIndex: 1, EventIndex: 0}))
+	require.True(t, dedup.firstSeen(EventID{Block: 20, TxIndex: BlockEventTxIndex, EventIndex: 0}))
+	require.True(t, dedup.firstSeen(EventID{Block: 21, TxIndex: 0, EventIndex: 0}))
+
+	// events are remembered for the window, older events are rejected
+	require.True(t, dedup.firstSeen(EventID{Block: 30}))
+	require.Len(t, dedup.seen, 2)
+	require.False(t, dedup.firstSeen(EventID{Block: 20, EventIndex: 5}))
+	require.False(t, dedup.firstSeen(EventID{Block: 21}))
+	require.True(t, dedup.firstSeen(EventID{Block: 25}))
+}
+
+func TestEventDeduplicatorForget(t *testing.T) {
+	dedup := newEventDeduplicator("test", 10)
+	for block := int64(10); block <= 12; block++ {
+		require.True(t, dedup.firstSeen(EventID{Block: block}))
+	}
+	// a reorg from block 11 replaces the events in the positions of the reorged blocks
+	dedup.forget(11)
+	require.False(t, dedup.firstSeen(EventID{Block: 10}))
+	require.True(t, dedup.firstSeen(EventID{Block: 11}))
+	require.True(t, dedup.firstSeen(EventID{Block: 12}))
+	require.Equal(t, int64(12), dedup.latestBlock)
+}
+
+func TestVoteUpdaterDeduplicatesEvents(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	detection := testVoteEvent(10, 0, "voter", 0, testProviderAddress)
+	stateQuery.Votes[10] = []VoteEvent{detection}
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	// a reconnect reads the block again
+	voteUpdater.lastProcessed = 9
+	require.NoError(t, voteUpdater.Update(10))
+	require.Equal(t, []string{"voter/detection"}, recorder.handledVotes())
+	// a rewind reprocesses it
+	voteUpdater.Rewind(10)
+	require.NoError(t, voteUpdater.Update(10))
+	require.Equal(t, []string{"voter/detection", "voter/detection"}, recorder.handledVotes())
+}
+
+func TestPaymentUpdaterDeduplicatesEvents(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	for block := int64(11); block <= 13; block++ {
+		stateQuery.Payments[block] = []PaymentEvent{testPaymentEvent(block, 0, uint64(block))}
+	}
+	paymentUpdater, counter := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+	// a pending block that a later range query already covered isn't handled twice
+	paymentUpdater.pendingBlocks = []pendingPaymentBlock{{block: 12}}
+	paymentUpdater.lastProcessedBlock = 10
+	stateQuery.FailNext("PaymentEvents", errNodeUnavailable)
+	require.NoError(t, paymentUpdater.Update(13))
+	require.Len(t, paymentUpdater.pendingBlocks, 1)
+	require.NoError(t, paymentUpdater.Update(13))
+	require.Empty(t, paymentUpdater.pendingBlocks)
+	require.Equal(t, map[uint64]int{11: 1, 12: 1, 13: 1}, counter.handledPayments())
+}
@@ -0,0 +1,247 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/lavanet/lava/utils"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	subscriptiontypes "github.com/lavanet/lava/x/subscription/types"
+)
+
+// FakeStateQuery answers the state queries of the updaters from scripted responses instead of a lava node, for tests of the updaters
+// and the components registered to them. responses can be changed while the updaters run by setting them between Lock and Unlock
+type FakeStateQuery struct {
+	sync.Mutex
+	Specs            map[string]*spectypes.Spec  // key is the chain id
+	SpecChanges      map[int64]*SpecChangeEvents // key is the block
+	ProtocolVersion  *ProtocolVersion            // nil if the param isn't set
+	EpochStart       uint64
+	EpochSize        uint64
+	BlockHashes      map[int64]string
+	Pairings         map[string][]epochstoragetypes.StakeEntry // key is the chain id
+	PairingEpoch     uint64
+	NextPairingBlock uint64
+	MaxCU            map[string]uint64                          // key is the chain id
+	Subscriptions    map[string]*subscriptiontypes.Subscription // key is the consumer
+	Policies         map[string]*EffectivePolicy                // key is the consumer, a missing consumer fails like a consumer without a project
+	Payments         map[int64][]PaymentEvent                   // key is the block
+	Votes            map[int64][]VoteEvent                      // key is the block
+	StakeEntries     map[string]*epochstoragetypes.StakeEntry   // key is the chain id and the provider address
+	DelegatorRewards map[string]*DelegatorRewards               // key is the delegator, a missing delegator has no delegations
+	errors           map[string][]error                         // key is the method name
+	calls            map[string]int
+}
+
+func NewFakeStateQuery() *FakeStateQuery {
+	return &FakeStateQuery{
+		Specs: map[string]*spectypes.Spec{}, SpecChanges: map[int64]*SpecChangeEvents{}, BlockHashes: map[int64]string{}, Pairings: map[string][]epochstoragetypes.StakeEntry{},
+		MaxCU: map[string]uint64{}, Subscriptions: map[string]*subscriptiontypes.Subscription{}, Policies: map[string]*EffectivePolicy{}, Payments: map[int64][]PaymentEvent{},
+		Votes: map[int64][]VoteEvent{}, StakeEntries: map[string]*epochstoragetypes.StakeEntry{}, DelegatorRewards: map[string]*DelegatorRewards{}, errors: map[string][]error{}, calls: map[string]int{},
+	}
+}
+
+// FailNext makes the next calls of the method return errs, one per call, before it returns the responses again
+func (fsq *FakeStateQuery) FailNext(method string, errs ...error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	fsq.errors[method] = append(fsq.errors[method], errs...)
+}
+
+// Calls returns how many times the method was called
+func (fsq *FakeStateQuery) Calls(method string) int {
+	fsq.Lock()
+	defer fsq.Unlock()
+	return fsq.calls[method]
+}
+
+func (fsq *FakeStateQuery) SetStakeEntry(chainID string, stakeEntry *epochstoragetypes.StakeEntry) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	fsq.StakeEntries[chainID+stakeEntry.Address] = stakeEntry
+}
+
+// called locked, returns the scripted error of the call if there is one
+func (fsq *FakeStateQuery) callUnsafe(method string) error {
+	fsq.calls[method]++
+	if errs := fsq.errors[method]; len(errs) > 0 {
+		fsq.errors[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+func (fsq *FakeStateQuery) GetSpec(ctx context.Context, chainID string) (*spectypes.Spec, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetSpec"); err != nil {
+		return nil, err
+	}
+	spec, ok := fsq.Specs[chainID]
+	if !ok {
+		return nil, utils.LavaFormatWarning("fake state query has no spec for the chain", nil, utils.Attribute{Key: "chainID", Value: chainID})
+	}
+	return spec, nil
+}
+
+func (fsq *FakeStateQuery) SpecChangeEvents(ctx context.Context, block int64) (*SpecChangeEvents, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("SpecChangeEvents"); err != nil {
+		return nil, err
+	}
+	if specChanges, ok := fsq.SpecChanges[block]; ok {
+		return specChanges, nil
+	}
+	return &SpecChangeEvents{}, nil
+}
+
+func (fsq *FakeStateQuery) GetProtocolVersion(ctx context.Context) (protocolVersion *ProtocolVersion, found bool, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetProtocolVersion"); err != nil {
+		return nil, false, err
+	}
+	return fsq.ProtocolVersion, fsq.ProtocolVersion != nil, nil
+}
+
+func (fsq *FakeStateQuery) CurrentEpochStart(ctx context.Context) (uint64, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("CurrentEpochStart"); err != nil {
+		return 0, err
+	}
+	return fsq.EpochStart, nil
+}
+
+func (fsq *FakeStateQuery) GetEpochSize(ctx context.Context) (uint64, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetEpochSize"); err != nil {
+		return 0, err
+	}
+	return fsq.EpochSize, nil
+}
+
+func (fsq *FakeStateQuery) BlockHash(ctx context.Context, block int64) (string, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("BlockHash"); err != nil {
+		return "", err
+	}
+	return fsq.BlockHashes[block], nil
+}
+
+func (fsq *FakeStateQuery) GetPairing(ctx context.Context, chainID string, latestBlock int64) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetPairing"); err != nil {
+		return nil, 0, 0, err
+	}
+	return fsq.Pairings[chainID], fsq.PairingEpoch, fsq.NextPairingBlock, nil
+}
+
+func (fsq *FakeStateQuery) PrefetchPairing(ctx context.Context, chainID string) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("PrefetchPairing"); err != nil {
+		return nil, 0, 0, err
+	}
+	return fsq.Pairings[chainID], fsq.PairingEpoch, fsq.NextPairingBlock, nil
+}
+
+func (fsq *FakeStateQuery) GetMaxCUForUser(ctx context.Context, chainID string, epoch uint64) (maxCu uint64, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetMaxCUForUser"); err != nil {
+		return 0, err
+	}
+	return fsq.MaxCU[chainID], nil
+}
+
+func (fsq *FakeStateQuery) GetSubscription(ctx context.Context, consumer string) (subscription *subscriptiontypes.Subscription, found bool, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetSubscription"); err != nil {
+		return nil, false, err
+	}
+	subscription, found = fsq.Subscriptions[consumer]
+	return subscription, found, nil
+}
+
+func (fsq *FakeStateQuery) GetEffectivePolicy(ctx context.Context, consumer string) (*EffectivePolicy, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetEffectivePolicy"); err != nil {
+		return nil, err
+	}
+	policy, ok := fsq.Policies[consumer]
+	if !ok {
+		return nil, utils.LavaFormatWarning("fake state query has no project for the consumer", ConsumerWithoutProjectError, utils.Attribute{Key: "consumer", Value: consumer})
+	}
+	return policy, nil
+}
+
+func (fsq *FakeStateQuery) PaymentEvents(ctx context.Context, latestBlock int64) (payments []PaymentEvent, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("PaymentEvents"); err != nil {
+		return nil, err
+	}
+	return fsq.Payments[latestBlock], nil
+}
+
+func (fsq *FakeStateQuery) PaymentEventsInRange(ctx context.Context, fromBlock int64, toBlock int64) (payments []PaymentEvent, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("PaymentEventsInRange"); err != nil {
+		return nil, err
+	}
+	for block := fromBlock + 1; block <= toBlock; block++ {
+		payments = append(payments, fsq.Payments[block]...)
+	}
+	return payments, nil
+}
+
+func (fsq *FakeStateQuery) VoteEvents(ctx context.Context, latestBlock int64) (votes []VoteEvent, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("VoteEvents"); err != nil {
+		return nil, err
+	}
+	return fsq.Votes[latestBlock], nil
+}
+
+func (fsq *FakeStateQuery) GetProviderStakeEntry(ctx context.Context, chainID string, providerAddress string) (stakeEntry *epochstoragetypes.StakeEntry, found bool, err error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetProviderStakeEntry"); err != nil {
+		return nil, false, err
+	}
+	stakeEntry, found = fsq.StakeEntries[chainID+providerAddress]
+	return stakeEntry, found, nil
+}
+
+func (fsq *FakeStateQuery) GetDelegatorRewards(ctx context.Context, delegator string) (*DelegatorRewards, error) {
+	fsq.Lock()
+	defer fsq.Unlock()
+	if err := fsq.callUnsafe("GetDelegatorRewards"); err != nil {
+		return nil, err
+	}
+	rewards, ok := fsq.DelegatorRewards[delegator]
+	if !ok {
+		return &DelegatorRewards{Delegator: delegator, Rewards: map[string]sdk.DecCoins{}}, nil
+	}
+	// a copy, the updater sets the block on it
+	rewardsCopy := *rewards
+	return &rewardsCopy, nil
+}
+
+var (
+	_ StateQueryInf         = (*FakeStateQuery)(nil)
+	_ EpochStateQueryInf    = (*FakeStateQuery)(nil)
+	_ ConsumerStateQueryInf = (*FakeStateQuery)(nil)
+	_ ProviderStateQueryInf = (*FakeStateQuery)(nil)
+)
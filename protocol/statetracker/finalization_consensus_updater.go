@@ -2,6 +2,7 @@ package statetracker
 
 import (
 	"context"
+	"sync"
 
 	"github.com/lavanet/lava/protocol/lavaprotocol"
 	"github.com/lavanet/lava/utils"
@@ -12,37 +13,76 @@ const (
 )
 
 type FinalizationConsensusUpdater struct {
-	registeredFinalizationConsensuses []*lavaprotocol.FinalizationConsensus
+	registeredFinalizationConsensuses *UpdatableRegistry[*lavaprotocol.FinalizationConsensus]
 	nextBlockForUpdate                uint64
-	stateQuery                        *ConsumerStateQuery
+	stateQuery                        ConsumerStateQueryInf
+	lock                              sync.RWMutex
+	chainsFinalizedHashes             map[string]*lavaprotocol.ChainFinalizedHashes // key is the chainID, shared by all the endpoints of the chain
 }
 
-func NewFinalizationConsensusUpdater(stateQuery *ConsumerStateQuery) *FinalizationConsensusUpdater {
-	return &FinalizationConsensusUpdater{registeredFinalizationConsensuses: []*lavaprotocol.FinalizationConsensus{}, stateQuery: stateQuery}
+func NewFinalizationConsensusUpdater(stateQuery ConsumerStateQueryInf) *FinalizationConsensusUpdater {
+	return &FinalizationConsensusUpdater{registeredFinalizationConsensuses: NewUpdatableRegistry[*lavaprotocol.FinalizationConsensus](CallbackKeyForFinalizationConsensusUpdate), stateQuery: stateQuery, chainsFinalizedHashes: map[string]*lavaprotocol.ChainFinalizedHashes{}}
 }
 
-func (fcu *FinalizationConsensusUpdater) RegisterFinalizationConsensus(finalizationConsensus *lavaprotocol.FinalizationConsensus) {
+func (fcu *FinalizationConsensusUpdater) RegisterFinalizationConsensus(finalizationConsensus *lavaprotocol.FinalizationConsensus, chainID string) {
 	// TODO: also update here for the first time
-	fcu.registeredFinalizationConsensuses = append(fcu.registeredFinalizationConsensuses, finalizationConsensus)
+	finalizationConsensus.SetSharedHashes(fcu.chainFinalizedHashes(chainID))
+	fcu.registeredFinalizationConsensuses.RegisterUnique(finalizationConsensus, UpdateInterest{Kind: InterestNewEpoch})
+}
+
+func (fcu *FinalizationConsensusUpdater) UnregisterFinalizationConsensus(finalizationConsensus *lavaprotocol.FinalizationConsensus) bool {
+	return fcu.registeredFinalizationConsensuses.UnregisterMatching(finalizationConsensus)
+}
+
+func (fcu *FinalizationConsensusUpdater) ReplaceFinalizationConsensus(oldFinalizationConsensus *lavaprotocol.FinalizationConsensus, newFinalizationConsensus *lavaprotocol.FinalizationConsensus) error {
+	newFinalizationConsensus.SetSharedHashes(oldFinalizationConsensus.SharedHashes())
+	return fcu.registeredFinalizationConsensuses.ReplaceMatching(oldFinalizationConsensus, newFinalizationConsensus)
+}
+
+// FinalizedHashes returns the consolidated view of the finalized hashes reported by the providers of the chain, nil if no endpoint of the chain registered
+func (fcu *FinalizationConsensusUpdater) FinalizedHashes(chainID string) *lavaprotocol.ChainFinalizedHashes {
+	fcu.lock.RLock()
+	defer fcu.lock.RUnlock()
+	return fcu.chainsFinalizedHashes[chainID]
+}
+
+func (fcu *FinalizationConsensusUpdater) chainFinalizedHashes(chainID string) *lavaprotocol.ChainFinalizedHashes {
+	fcu.lock.Lock()
+	defer fcu.lock.Unlock()
+	chainFinalizedHashes, ok := fcu.chainsFinalizedHashes[chainID]
+	if !ok {
+		chainFinalizedHashes = lavaprotocol.NewChainFinalizedHashes(chainID)
+		fcu.chainsFinalizedHashes[chainID] = chainFinalizedHashes
+	}
+	return chainFinalizedHashes
+}
+
+func (fcu *FinalizationConsensusUpdater) RegisteredUpdatables() []string {
+	return fcu.registeredFinalizationConsensuses.Describe()
 }
 
 func (fcu *FinalizationConsensusUpdater) UpdaterKey() string {
 	return CallbackKeyForFinalizationConsensusUpdate
 }
 
-func (fcu *FinalizationConsensusUpdater) Update(latestBlock int64) {
+func (fcu *FinalizationConsensusUpdater) Update(latestBlock int64) error {
 	ctx := context.Background()
 	if int64(fcu.nextBlockForUpdate) > latestBlock {
-		return
+		return nil
 	}
 	_, epoch, nextBlockForUpdate, err := fcu.stateQuery.GetPairing(ctx, "", latestBlock)
 	if err != nil {
-		utils.LavaFormatError("could not get block stats for finzalizationConsensus, trying again later", err, utils.Attribute{Key: "latestBlock", Value: latestBlock})
 		fcu.nextBlockForUpdate += 1
-		return
+		return utils.LavaFormatError("could not get block stats for finzalizationConsensus, trying again later", err, utils.Attribute{Key: "latestBlock", Value: latestBlock})
 	}
 	fcu.nextBlockForUpdate = nextBlockForUpdate
-	for _, finalizationConsensus := range fcu.registeredFinalizationConsensuses {
-		finalizationConsensus.NewEpoch(epoch)
+	fcu.lock.RLock()
+	for _, chainFinalizedHashes := range fcu.chainsFinalizedHashes {
+		chainFinalizedHashes.NewEpoch(epoch)
 	}
+	fcu.lock.RUnlock()
+	fcu.registeredFinalizationConsensuses.Dispatch(UpdateTrigger{Block: latestBlock, NewEpoch: true}, func(_ string, finalizationConsensus *lavaprotocol.FinalizationConsensus) {
+		finalizationConsensus.NewEpoch(epoch)
+	})
+	return nil
 }
@@ -0,0 +1,245 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/lavanet/lava/utils"
+	"github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/log"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// LavaNodeClient is an rpc client whose lava node can be switched at runtime, every copy of a client.Context holding it
+// (query clients, tx senders, the lava chain fetcher) moves to the new node together
+type LavaNodeClient struct {
+	lock                sync.RWMutex
+	client              rpcclient.Client
+	nodeURI             string
+	consecutiveFailures uint64 // atomic, failed queries since the last successful one, read by the failover
+}
+
+// NewLavaNodeClient wraps the rpc client of clientCtx, the returned context must be used instead of the original one
+func NewLavaNodeClient(clientCtx client.Context) (*LavaNodeClient, client.Context) {
+	if lavaNodeClient, ok := clientCtx.Client.(*LavaNodeClient); ok {
+		return lavaNodeClient, clientCtx
+	}
+	lavaNodeClient := &LavaNodeClient{client: clientCtx.Client, nodeURI: clientCtx.NodeURI}
+	return lavaNodeClient, clientCtx.WithClient(lavaNodeClient)
+}
+
+func (lnc *LavaNodeClient) current() rpcclient.Client {
+	lnc.lock.RLock()
+	defer lnc.lock.RUnlock()
+	return lnc.client
+}
+
+func (lnc *LavaNodeClient) NodeURI() string {
+	lnc.lock.RLock()
+	defer lnc.lock.RUnlock()
+	return lnc.nodeURI
+}
+
+// Switch verifies the new node serves the expected lava chain and replaces the current node with it
+func (lnc *LavaNodeClient) Switch(ctx context.Context, nodeURI string, lavaChainID string) (latestBlock int64, err error) {
+	newClient, err := client.NewClientFromNode(nodeURI)
+	if err != nil {
+		return 0, utils.LavaFormatError("failed creating client for lava node", err, utils.Attribute{Key: "nodeURI", Value: nodeURI})
+	}
+	status, err := newClient.Status(ctx)
+	if err != nil {
+		return 0, utils.LavaFormatError("failed querying status of lava node", err, utils.Attribute{Key: "nodeURI", Value: nodeURI})
+	}
+	if lavaChainID != "" && status.NodeInfo.Network != lavaChainID {
+		return 0, utils.LavaFormatError("lava node serves a different chain", nil, utils.Attribute{Key: "nodeURI", Value: nodeURI}, utils.Attribute{Key: "expected", Value: lavaChainID}, utils.Attribute{Key: "network", Value: status.NodeInfo.Network})
+	}
+	if status.SyncInfo.CatchingUp {
+		return 0, utils.LavaFormatError("lava node is still catching up", nil, utils.Attribute{Key: "nodeURI", Value: nodeURI}, utils.Attribute{Key: "height", Value: status.SyncInfo.LatestBlockHeight})
+	}
+	lnc.lock.Lock()
+	previousURI := lnc.nodeURI
+	lnc.client = newClient
+	lnc.nodeURI = nodeURI
+	lnc.lock.Unlock()
+	atomic.StoreUint64(&lnc.consecutiveFailures, 0)
+	utils.LavaFormatInfo("switched lava node", utils.Attribute{Key: "from", Value: previousURI}, utils.Attribute{Key: "to", Value: nodeURI}, utils.Attribute{Key: "height", Value: status.SyncInfo.LatestBlockHeight})
+	return status.SyncInfo.LatestBlockHeight, nil
+}
+
+// ConsecutiveFailures returns how many queries to the current node failed in a row
+func (lnc *LavaNodeClient) ConsecutiveFailures() uint64 {
+	return atomic.LoadUint64(&lnc.consecutiveFailures)
+}
+
+func (lnc *LavaNodeClient) recordResult(err error) {
+	if err != nil {
+		atomic.AddUint64(&lnc.consecutiveFailures, 1)
+	} else {
+		atomic.StoreUint64(&lnc.consecutiveFailures, 0)
+	}
+}
+
+// NewQueryBatch batches queries to the current node, used by the query batcher
+func (lnc *LavaNodeClient) NewQueryBatch() (*rpchttp.BatchHTTP, bool) {
+	return newQueryBatch(lnc.current())
+}
+
+// service.Service
+
+func (lnc *LavaNodeClient) Start() error                { return lnc.current().Start() }
+func (lnc *LavaNodeClient) OnStart() error              { return lnc.current().OnStart() }
+func (lnc *LavaNodeClient) Stop() error                 { return lnc.current().Stop() }
+func (lnc *LavaNodeClient) OnStop()                     { lnc.current().OnStop() }
+func (lnc *LavaNodeClient) Reset() error                { return lnc.current().Reset() }
+func (lnc *LavaNodeClient) OnReset() error              { return lnc.current().OnReset() }
+func (lnc *LavaNodeClient) IsRunning() bool             { return lnc.current().IsRunning() }
+func (lnc *LavaNodeClient) Quit() <-chan struct{}       { return lnc.current().Quit() }
+func (lnc *LavaNodeClient) String() string              { return lnc.current().String() }
+func (lnc *LavaNodeClient) SetLogger(logger log.Logger) { lnc.current().SetLogger(logger) }
+
+// rpcclient.ABCIClient
+
+func (lnc *LavaNodeClient) ABCIInfo(ctx context.Context) (*ctypes.ResultABCIInfo, error) {
+	return lnc.current().ABCIInfo(ctx)
+}
+
+func (lnc *LavaNodeClient) ABCIQuery(ctx context.Context, path string, data bytes.HexBytes) (*ctypes.ResultABCIQuery, error) {
+	return lnc.current().ABCIQuery(ctx, path, data)
+}
+
+func (lnc *LavaNodeClient) ABCIQueryWithOptions(ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
+	result, err := lnc.current().ABCIQueryWithOptions(ctx, path, data, opts)
+	lnc.recordResult(err)
+	return result, err
+}
+
+func (lnc *LavaNodeClient) BroadcastTxCommit(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+	return lnc.current().BroadcastTxCommit(ctx, tx)
+}
+
+func (lnc *LavaNodeClient) BroadcastTxAsync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	return lnc.current().BroadcastTxAsync(ctx, tx)
+}
+
+func (lnc *LavaNodeClient) BroadcastTxSync(ctx context.Context, tx types.Tx) (*ctypes.ResultBroadcastTx, error) {
+	return lnc.current().BroadcastTxSync(ctx, tx)
+}
+
+// rpcclient.SignClient
+
+func (lnc *LavaNodeClient) Block(ctx context.Context, height *int64) (*ctypes.ResultBlock, error) {
+	result, err := lnc.current().Block(ctx, height)
+	lnc.recordResult(err)
+	return result, err
+}
+
+func (lnc *LavaNodeClient) BlockByHash(ctx context.Context, hash []byte) (*ctypes.ResultBlock, error) {
+	return lnc.current().BlockByHash(ctx, hash)
+}
+
+func (lnc *LavaNodeClient) BlockResults(ctx context.Context, height *int64) (*ctypes.ResultBlockResults, error) {
+	result, err := lnc.current().BlockResults(ctx, height)
+	lnc.recordResult(err)
+	return result, err
+}
+
+func (lnc *LavaNodeClient) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	return lnc.current().Commit(ctx, height)
+}
+
+func (lnc *LavaNodeClient) Validators(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+	return lnc.current().Validators(ctx, height, page, perPage)
+}
+
+func (lnc *LavaNodeClient) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	return lnc.current().Tx(ctx, hash, prove)
+}
+
+func (lnc *LavaNodeClient) TxSearch(ctx context.Context, query string, prove bool, page, perPage *int, orderBy string) (*ctypes.ResultTxSearch, error) {
+	return lnc.current().TxSearch(ctx, query, prove, page, perPage, orderBy)
+}
+
+func (lnc *LavaNodeClient) BlockSearch(ctx context.Context, query string, page, perPage *int, orderBy string) (*ctypes.ResultBlockSearch, error) {
+	return lnc.current().BlockSearch(ctx, query, page, perPage, orderBy)
+}
+
+// rpcclient.HistoryClient
+
+func (lnc *LavaNodeClient) Genesis(ctx context.Context) (*ctypes.ResultGenesis, error) {
+	return lnc.current().Genesis(ctx)
+}
+
+func (lnc *LavaNodeClient) GenesisChunked(ctx context.Context, id uint) (*ctypes.ResultGenesisChunk, error) {
+	return lnc.current().GenesisChunked(ctx, id)
+}
+
+func (lnc *LavaNodeClient) BlockchainInfo(ctx context.Context, minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error) {
+	return lnc.current().BlockchainInfo(ctx, minHeight, maxHeight)
+}
+
+// rpcclient.StatusClient
+
+func (lnc *LavaNodeClient) Status(ctx context.Context) (*ctypes.ResultStatus, error) {
+	return lnc.current().Status(ctx)
+}
+
+// rpcclient.NetworkClient
+
+func (lnc *LavaNodeClient) NetInfo(ctx context.Context) (*ctypes.ResultNetInfo, error) {
+	return lnc.current().NetInfo(ctx)
+}
+
+func (lnc *LavaNodeClient) DumpConsensusState(ctx context.Context) (*ctypes.ResultDumpConsensusState, error) {
+	return lnc.current().DumpConsensusState(ctx)
+}
+
+func (lnc *LavaNodeClient) ConsensusState(ctx context.Context) (*ctypes.ResultConsensusState, error) {
+	return lnc.current().ConsensusState(ctx)
+}
+
+func (lnc *LavaNodeClient) ConsensusParams(ctx context.Context, height *int64) (*ctypes.ResultConsensusParams, error) {
+	return lnc.current().ConsensusParams(ctx, height)
+}
+
+func (lnc *LavaNodeClient) Health(ctx context.Context) (*ctypes.ResultHealth, error) {
+	return lnc.current().Health(ctx)
+}
+
+// rpcclient.EventsClient, subscriptions stay on the node they were made on
+
+func (lnc *LavaNodeClient) Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan ctypes.ResultEvent, error) {
+	return lnc.current().Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+func (lnc *LavaNodeClient) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	return lnc.current().Unsubscribe(ctx, subscriber, query)
+}
+
+func (lnc *LavaNodeClient) UnsubscribeAll(ctx context.Context, subscriber string) error {
+	return lnc.current().UnsubscribeAll(ctx, subscriber)
+}
+
+// rpcclient.MempoolClient
+
+func (lnc *LavaNodeClient) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+	return lnc.current().UnconfirmedTxs(ctx, limit)
+}
+
+func (lnc *LavaNodeClient) NumUnconfirmedTxs(ctx context.Context) (*ctypes.ResultUnconfirmedTxs, error) {
+	return lnc.current().NumUnconfirmedTxs(ctx)
+}
+
+func (lnc *LavaNodeClient) CheckTx(ctx context.Context, tx types.Tx) (*ctypes.ResultCheckTx, error) {
+	return lnc.current().CheckTx(ctx, tx)
+}
+
+// rpcclient.EvidenceClient
+
+func (lnc *LavaNodeClient) BroadcastEvidence(ctx context.Context, evidence types.Evidence) (*ctypes.ResultBroadcastEvidence, error) {
+	return lnc.current().BroadcastEvidence(ctx, evidence)
+}
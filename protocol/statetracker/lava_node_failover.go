@@ -0,0 +1,146 @@
+package statetracker
+
+import (
+	"context"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/lavanet/lava/utils"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+const (
+	LavaNodeBackupsFlagName        = "lava-node-backups"
+	LavaNodeHealthCheckInterval    = 10 * time.Second
+	LavaNodeHealthCheckTimeout     = 5 * time.Second
+	LavaNodeMaxBlocksBehind        = 5 // a node further behind the best node is stale
+	LavaNodeMaxConsecutiveFailures = 3 // failed queries in a row before failing over without waiting for the health check
+	LavaNodeRecoveryProbes         = 3 // healthy probes in a row before moving back to a preferred node
+	LavaNodeFailoverStickiness     = 5 * time.Minute
+)
+
+type lavaNodeHealth struct {
+	nodeURI            string
+	client             rpcclient.Client // for probing, queries go through the LavaNodeClient
+	healthy            bool
+	height             int64
+	consecutiveHealthy uint64
+}
+
+// LavaNodeFailover health checks a list of lava nodes in order of preference and moves the lava node client to the first healthy
+// node when the current one fails queries, stops responding or falls behind. after failing over it sticks to the node it moved to,
+// and moves back to a preferred node only after it recovered for several probes and the stickiness period passed
+type LavaNodeFailover struct {
+	lavaNodeClient *LavaNodeClient
+	nodes          []*lavaNodeHealth
+	lavaChainID    string
+	switchNode     func(ctx context.Context, nodeURI string) error
+	switchedAt     time.Time
+}
+
+func NewLavaNodeFailover(lavaNodeClient *LavaNodeClient, nodeURIs []string, lavaChainID string, switchNode func(ctx context.Context, nodeURI string) error) (*LavaNodeFailover, error) {
+	lnf := &LavaNodeFailover{lavaNodeClient: lavaNodeClient, lavaChainID: lavaChainID, switchNode: switchNode}
+	seen := map[string]struct{}{}
+	for _, nodeURI := range nodeURIs {
+		if _, ok := seen[nodeURI]; ok || nodeURI == "" {
+			continue
+		}
+		seen[nodeURI] = struct{}{}
+		nodeClient, err := client.NewClientFromNode(nodeURI)
+		if err != nil {
+			return nil, utils.LavaFormatError("failed creating client for lava node", err, utils.Attribute{Key: "nodeURI", Value: nodeURI})
+		}
+		lnf.nodes = append(lnf.nodes, &lavaNodeHealth{nodeURI: nodeURI, client: nodeClient})
+	}
+	return lnf, nil
+}
+
+func (lnf *LavaNodeFailover) Start(ctx context.Context) {
+	if len(lnf.nodes) < 2 {
+		return // nothing to fail over to
+	}
+	go func() {
+		healthCheckTicker := time.NewTicker(LavaNodeHealthCheckInterval)
+		defer healthCheckTicker.Stop()
+		failuresTicker := time.NewTicker(time.Second)
+		defer failuresTicker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-healthCheckTicker.C:
+				lnf.checkNodes(ctx)
+			case <-failuresTicker.C:
+				// failing queries don't wait for the health check
+				if lnf.lavaNodeClient.ConsecutiveFailures() >= LavaNodeMaxConsecutiveFailures {
+					lnf.checkNodes(ctx)
+				}
+			}
+		}
+	}()
+}
+
+func (lnf *LavaNodeFailover) checkNodes(ctx context.Context) {
+	bestHeight := int64(0)
+	for _, node := range lnf.nodes {
+		lnf.probe(ctx, node)
+		if node.healthy && node.height > bestHeight {
+			bestHeight = node.height
+		}
+	}
+	currentURI := lnf.lavaNodeClient.NodeURI()
+	var current *lavaNodeHealth
+	for _, node := range lnf.nodes {
+		if node.nodeURI == currentURI {
+			current = node
+		}
+		// a node far behind the best one serves stale state even when it responds
+		if node.healthy && node.height+LavaNodeMaxBlocksBehind < bestHeight {
+			node.healthy = false
+			node.consecutiveHealthy = 0
+		}
+	}
+	currentFailed := current == nil || !current.healthy || lnf.lavaNodeClient.ConsecutiveFailures() >= LavaNodeMaxConsecutiveFailures
+	for _, node := range lnf.nodes {
+		if node == current {
+			if !currentFailed {
+				return // the current node is the most preferred healthy one
+			}
+			continue
+		}
+		if !node.healthy {
+			continue
+		}
+		if !currentFailed && (node.consecutiveHealthy < LavaNodeRecoveryProbes || time.Since(lnf.switchedAt) < LavaNodeFailoverStickiness) {
+			continue // the current node works, a preferred node has to prove it recovered before moving back
+		}
+		utils.LavaFormatWarning("lava node failover", nil, utils.Attribute{Key: "from", Value: currentURI}, utils.Attribute{Key: "to", Value: node.nodeURI},
+			utils.Attribute{Key: "currentFailed", Value: currentFailed}, utils.Attribute{Key: "consecutiveFailures", Value: lnf.lavaNodeClient.ConsecutiveFailures()})
+		err := lnf.switchNode(ctx, node.nodeURI)
+		if err != nil {
+			utils.LavaFormatError("failed switching lava node, trying the next one", err, utils.Attribute{Key: "nodeURI", Value: node.nodeURI})
+			continue
+		}
+		lnf.switchedAt = time.Now()
+		return
+	}
+	if currentFailed {
+		utils.LavaFormatError("current lava node is failing and no healthy lava node to fail over to", nil, utils.Attribute{Key: "nodeURI", Value: currentURI})
+	}
+}
+
+func (lnf *LavaNodeFailover) probe(ctx context.Context, node *lavaNodeHealth) {
+	ctx, cancel := context.WithTimeout(ctx, LavaNodeHealthCheckTimeout)
+	defer cancel()
+	status, err := node.client.Status(ctx)
+	node.healthy = err == nil && !status.SyncInfo.CatchingUp && (lnf.lavaChainID == "" || status.NodeInfo.Network == lnf.lavaChainID)
+	if !node.healthy {
+		if err != nil {
+			utils.LavaFormatDebug("lava node health check failed", utils.Attribute{Key: "nodeURI", Value: node.nodeURI}, utils.Attribute{Key: "error", Value: err})
+		}
+		node.consecutiveHealthy = 0
+		return
+	}
+	node.height = status.SyncInfo.LatestBlockHeight
+	node.consecutiveHealthy++
+}
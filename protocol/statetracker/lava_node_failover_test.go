@@ -0,0 +1,143 @@
+package statetracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/p2p"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const testLavaChainID = "lava-testnet"
+
+// fakeStatusClient answers the failover's health checks, only Status is implemented
+type fakeStatusClient struct {
+	rpcclient.Client
+	lock       sync.Mutex
+	height     int64
+	network    string
+	catchingUp bool
+	fail       bool
+}
+
+func (fsc *fakeStatusClient) Status(ctx context.Context) (*ctypes.ResultStatus, error) {
+	fsc.lock.Lock()
+	defer fsc.lock.Unlock()
+	if fsc.fail {
+		return nil, errNodeUnavailable
+	}
+	return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{Network: fsc.network}, SyncInfo: ctypes.SyncInfo{LatestBlockHeight: fsc.height, CatchingUp: fsc.catchingUp}}, nil
+}
+
+func (fsc *fakeStatusClient) set(update func(*fakeStatusClient)) {
+	fsc.lock.Lock()
+	defer fsc.lock.Unlock()
+	update(fsc)
+}
+
+// newTestLavaNodeFailover creates a failover over nodes named by their uri, starting on the first one
+func newTestLavaNodeFailover(nodeURIs ...string) (*LavaNodeFailover, map[string]*fakeStatusClient, *[]string) {
+	lavaNodeClient := &LavaNodeClient{nodeURI: nodeURIs[0]}
+	switches := []string{}
+	lnf := &LavaNodeFailover{lavaNodeClient: lavaNodeClient, lavaChainID: testLavaChainID, switchNode: func(ctx context.Context, nodeURI string) error {
+		switches = append(switches, nodeURI)
+		lavaNodeClient.lock.Lock()
+		lavaNodeClient.nodeURI = nodeURI
+		lavaNodeClient.lock.Unlock()
+		return nil
+	}}
+	clients := map[string]*fakeStatusClient{}
+	for _, nodeURI := range nodeURIs {
+		clients[nodeURI] = &fakeStatusClient{height: 100, network: testLavaChainID}
+		lnf.nodes = append(lnf.nodes, &lavaNodeHealth{nodeURI: nodeURI, client: clients[nodeURI]})
+	}
+	return lnf, clients, &switches
+}
+
+func TestLavaNodeFailoverUnhealthyNodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		fail  func(*fakeStatusClient)
+		moves bool
+	}{
+		{name: "healthy", fail: func(fsc *fakeStatusClient) {}, moves: false},
+		{name: "not responding", fail: func(fsc *fakeStatusClient) { fsc.fail = true }, moves: true},
+		{name: "catching up", fail: func(fsc *fakeStatusClient) { fsc.catchingUp = true }, moves: true},
+		{name: "another chain", fail: func(fsc *fakeStatusClient) { fsc.network = "lava-mainnet" }, moves: true},
+		{name: "within the blocks behind limit", fail: func(fsc *fakeStatusClient) { fsc.height -= LavaNodeMaxBlocksBehind }, moves: false},
+		{name: "behind the best node", fail: func(fsc *fakeStatusClient) { fsc.height -= LavaNodeMaxBlocksBehind + 1 }, moves: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lnf, clients, switches := newTestLavaNodeFailover("a", "b")
+			clients["a"].set(tt.fail)
+			lnf.checkNodes(context.Background())
+			if tt.moves {
+				require.Equal(t, []string{"b"}, *switches)
+				require.Equal(t, "b", lnf.lavaNodeClient.NodeURI())
+			} else {
+				require.Empty(t, *switches)
+			}
+		})
+	}
+}
+
+func TestLavaNodeFailoverConsecutiveFailures(t *testing.T) {
+	lnf, _, switches := newTestLavaNodeFailover("a", "b")
+	// the current node answers health checks but fails queries
+	for i := 0; i < LavaNodeMaxConsecutiveFailures; i++ {
+		lnf.lavaNodeClient.recordResult(errNodeUnavailable)
+	}
+	lnf.checkNodes(context.Background())
+	require.Equal(t, []string{"b"}, *switches)
+}
+
+func TestLavaNodeFailoverSkipsFailedSwitch(t *testing.T) {
+	lnf, clients, switches := newTestLavaNodeFailover("a", "b", "c")
+	failover := lnf.switchNode
+	lnf.switchNode = func(ctx context.Context, nodeURI string) error {
+		if nodeURI == "b" {
+			return errors.New("b serves another chain")
+		}
+		return failover(ctx, nodeURI)
+	}
+	clients["a"].set(func(fsc *fakeStatusClient) { fsc.fail = true })
+	lnf.checkNodes(context.Background())
+	require.Equal(t, []string{"c"}, *switches)
+
+	// no healthy node to move to keeps the current one
+	clients["c"].set(func(fsc *fakeStatusClient) { fsc.fail = true })
+	clients["b"].set(func(fsc *fakeStatusClient) { fsc.fail = true })
+	lnf.checkNodes(context.Background())
+	require.Equal(t, "c", lnf.lavaNodeClient.NodeURI())
+}
+
+func TestLavaNodeFailoverRecovery(t *testing.T) {
+	lnf, clients, switches := newTestLavaNodeFailover("a", "b")
+	clients["a"].set(func(fsc *fakeStatusClient) { fsc.fail = true })
+	lnf.checkNodes(context.Background())
+	require.Equal(t, []string{"b"}, *switches)
+
+	// the preferred node recovered, but it has to stay healthy for several probes and the stickiness period
+	clients["a"].set(func(fsc *fakeStatusClient) { fsc.fail = false })
+	for i := 0; i < LavaNodeRecoveryProbes; i++ {
+		lnf.checkNodes(context.Background())
+	}
+	require.Equal(t, []string{"b"}, *switches)
+	lnf.switchedAt = time.Now().Add(-LavaNodeFailoverStickiness)
+	lnf.checkNodes(context.Background())
+	require.Equal(t, []string{"b", "a"}, *switches)
+
+	// a failure resets the recovery probes
+	clients["a"].set(func(fsc *fakeStatusClient) { fsc.fail = true })
+	lnf.checkNodes(context.Background())
+	clients["a"].set(func(fsc *fakeStatusClient) { fsc.fail = false })
+	lnf.switchedAt = time.Now().Add(-LavaNodeFailoverStickiness)
+	lnf.checkNodes(context.Background())
+	require.Equal(t, []string{"b", "a", "b"}, *switches)
+}
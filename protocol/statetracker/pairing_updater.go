@@ -1,6 +1,8 @@
 package statetracker
 
 import (
+	"sync"
+
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/utils"
 	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
@@ -8,22 +10,35 @@ import (
 )
 
 const (
-	CallbackKeyForPairingUpdate = "pairing-update"
+	CallbackKeyForPairingUpdate  = "pairing-update"
+	PairingPrefetchBlocksFlag    = "pairing-prefetch-blocks"
+	DefaultPairingPrefetchBlocks = 2
 )
 
+// the pairing of the next epoch read before the boundary, with the pairing of every session manager of the chain already filtered
+type prefetchedPairing struct {
+	epoch              uint64
+	nextBlockForUpdate uint64
+	pairingList        []epochstoragetypes.StakeEntry
+	endpointPairings   map[string]map[uint64]*lavasession.ConsumerSessionsWithProvider // key is the endpoint key
+}
+
 type PairingUpdater struct {
-	consumerSessionManagersMap map[string][]*lavasession.ConsumerSessionManager // key is chainID so we don;t run getPairing more than once per chain
-	nextBlockForUpdate         uint64
-	stateQuery                 *ConsumerStateQuery
+	consumerSessionManagers *UpdatableRegistry[*lavasession.ConsumerSessionManager] // key is the endpoint key, grouped by chainID on updates so we don't run getPairing more than once per chain
+	nextBlockForUpdate      uint64
+	stateQuery              ConsumerStateQueryInf
+	prefetchBlocks          uint64 // blocks before the epoch boundary to start querying the next pairing, 0 disables prefetching
+	prefetchLock            sync.Mutex
+	prefetched              map[string]*prefetchedPairing // key is chainID
 }
 
-func NewPairingUpdater(stateQuery *ConsumerStateQuery) *PairingUpdater {
-	return &PairingUpdater{consumerSessionManagersMap: map[string][]*lavasession.ConsumerSessionManager{}, stateQuery: stateQuery}
+func NewPairingUpdater(stateQuery ConsumerStateQueryInf, prefetchBlocks uint64) *PairingUpdater {
+	return &PairingUpdater{consumerSessionManagers: NewUpdatableRegistry[*lavasession.ConsumerSessionManager](CallbackKeyForPairingUpdate), stateQuery: stateQuery, prefetchBlocks: prefetchBlocks, prefetched: map[string]*prefetchedPairing{}}
 }
 
 func (pu *PairingUpdater) RegisterPairing(ctx context.Context, consumerSessionManager *lavasession.ConsumerSessionManager) error {
-	chainID := consumerSessionManager.RPCEndpoint().ChainID
-	pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.GetPairing(context.Background(), chainID, -1)
+	rpcEndpoint := consumerSessionManager.RPCEndpoint()
+	pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.GetPairing(context.Background(), rpcEndpoint.ChainID, -1)
 	if err != nil {
 		return err
 	}
@@ -32,41 +47,103 @@ func (pu *PairingUpdater) RegisterPairing(ctx context.Context, consumerSessionMa
 		// make sure we don't update twice, this updates pu.nextBlockForUpdate
 		pu.Update(int64(nextBlockForUpdate))
 	}
-	consumerSessionsManagersList, ok := pu.consumerSessionManagersMap[chainID]
-	if !ok {
-		pu.consumerSessionManagersMap[chainID] = []*lavasession.ConsumerSessionManager{consumerSessionManager}
-		return nil
-	}
-	pu.consumerSessionManagersMap[chainID] = append(consumerSessionsManagersList, consumerSessionManager)
+	pu.consumerSessionManagers.Register(rpcEndpoint.Key(), consumerSessionManager, UpdateInterest{Kind: InterestNewEpoch})
 	return nil
 }
 
+// UnregisterPairing stops the pairing updates of consumerSessionManager, it returns false if it isn't registered
+func (pu *PairingUpdater) UnregisterPairing(consumerSessionManager *lavasession.ConsumerSessionManager) bool {
+	rpcEndpoint := consumerSessionManager.RPCEndpoint()
+	if registered, ok := pu.consumerSessionManagers.Get(rpcEndpoint.Key()); !ok || registered != consumerSessionManager {
+		return false
+	}
+	return pu.consumerSessionManagers.Unregister(rpcEndpoint.Key())
+}
+
+// ReplacePairing sets the current pairing on newConsumerSessionManager before it takes the place of oldConsumerSessionManager, both must be of the same chain
+func (pu *PairingUpdater) ReplacePairing(ctx context.Context, oldConsumerSessionManager *lavasession.ConsumerSessionManager, newConsumerSessionManager *lavasession.ConsumerSessionManager) error {
+	chainID := oldConsumerSessionManager.RPCEndpoint().ChainID
+	if newConsumerSessionManager.RPCEndpoint().ChainID != chainID {
+		return utils.LavaFormatError("can't replace a consumer session manager with one of another chain", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "newChainID", Value: newConsumerSessionManager.RPCEndpoint().ChainID})
+	}
+	pairingList, epoch, _, err := pu.stateQuery.GetPairing(ctx, chainID, -1)
+	if err != nil {
+		return err
+	}
+	err = pu.updateConsummerSessionManager(ctx, pairingList, newConsumerSessionManager, epoch)
+	if err != nil {
+		return err
+	}
+	oldEndpoint, newEndpoint := oldConsumerSessionManager.RPCEndpoint(), newConsumerSessionManager.RPCEndpoint()
+	if registered, ok := pu.consumerSessionManagers.Get(oldEndpoint.Key()); !ok || registered != oldConsumerSessionManager {
+		return utils.LavaFormatError("consumer session manager isn't registered for pairing updates", nil, utils.Attribute{Key: "endpoint", Value: oldEndpoint.Key()})
+	}
+	return pu.consumerSessionManagers.Replace(oldEndpoint.Key(), newEndpoint.Key(), newConsumerSessionManager)
+}
+
+func (pu *PairingUpdater) RegisteredUpdatables() []string {
+	return pu.consumerSessionManagers.Describe()
+}
+
+// the registered session managers grouped by chainID
+func (pu *PairingUpdater) registeredConsumerSessionManagers() map[string][]*lavasession.ConsumerSessionManager {
+	consumerSessionManagersMap := map[string][]*lavasession.ConsumerSessionManager{}
+	for _, consumerSessionManager := range pu.consumerSessionManagers.Updatables() {
+		chainID := consumerSessionManager.RPCEndpoint().ChainID
+		consumerSessionManagersMap[chainID] = append(consumerSessionManagersMap[chainID], consumerSessionManager)
+	}
+	return consumerSessionManagersMap
+}
+
 func (pu *PairingUpdater) UpdaterKey() string {
 	return CallbackKeyForPairingUpdate
 }
 
-func (pu *PairingUpdater) Update(latestBlock int64) {
+func (pu *PairingUpdater) Update(latestBlock int64) error {
 	ctx := context.Background()
 	if int64(pu.nextBlockForUpdate) > latestBlock {
-		return
+		pu.prefetchNextPairing(ctx, latestBlock)
+		return nil
 	}
+	// the pairing and the max cu of the providers are read at the same height, the prefetch above reads ahead of it on purpose
+	ctx = NewQuerySession(ctx, latestBlock)
+	var updateErr error // the first chain that failed, the others are still updated
 	nextBlockForUpdateList := []uint64{}
-	for chainID, consumerSessionManagerList := range pu.consumerSessionManagersMap {
-		pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.GetPairing(ctx, chainID, latestBlock)
+	for chainID, consumerSessionManagerList := range pu.registeredConsumerSessionManagers() {
+		prefetched := pu.takePrefetchedPairing(chainID)
+		var pairingList []epochstoragetypes.StakeEntry
+		var epoch, nextBlockForUpdate uint64
+		var err error
+		if prefetched != nil {
+			pairingList, epoch, nextBlockForUpdate = prefetched.pairingList, prefetched.epoch, prefetched.nextBlockForUpdate
+		} else {
+			pairingList, epoch, nextBlockForUpdate, err = pu.stateQuery.GetPairing(ctx, chainID, latestBlock)
+		}
 		if err != nil {
-			utils.LavaFormatError("could not update pairing for chain, trying again next block", err, utils.Attribute{Key: "chain", Value: chainID})
+			err = utils.LavaFormatError("could not update pairing for chain, trying again next block", err, utils.Attribute{Key: "chain", Value: chainID})
+			if updateErr == nil {
+				updateErr = err
+			}
 			nextBlockForUpdateList = append(nextBlockForUpdateList, pu.nextBlockForUpdate+1)
 			continue
 		} else {
 			nextBlockForUpdateList = append(nextBlockForUpdateList, nextBlockForUpdate)
 		}
 		for _, consumerSessionManager := range consumerSessionManagerList {
+			rpcEndpoint := consumerSessionManager.RPCEndpoint()
 			// same pairing for all apiInterfaces, they pick the right endpoints from inside using our filter function
-			err = pu.updateConsummerSessionManager(ctx, pairingList, consumerSessionManager, epoch)
-			if err != nil {
-				utils.LavaFormatError("failed updating consumer session manager", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: consumerSessionManager.RPCEndpoint().ApiInterface}, utils.Attribute{Key: "pairingListLen", Value: len(pairingList)})
-				continue
-			}
+			pu.consumerSessionManagers.Call(rpcEndpoint.Key(), consumerSessionManager, func(_ string, consumerSessionManager *lavasession.ConsumerSessionManager) {
+				var err error
+				if endpointPairing, ok := prefetched.endpointPairing(rpcEndpoint.Key()); ok {
+					// swapped in as is, the providers were filtered and their max cu read before the boundary
+					err = consumerSessionManager.UpdateAllProviders(epoch, endpointPairing)
+				} else {
+					err = pu.updateConsummerSessionManager(ctx, pairingList, consumerSessionManager, epoch)
+				}
+				if err != nil {
+					utils.LavaFormatError("failed updating consumer session manager", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "pairingListLen", Value: len(pairingList)})
+				}
+			})
 		}
 	}
 	nextBlockForUpdateMin := uint64(latestBlock) // in case the list is empty
@@ -76,6 +153,106 @@ func (pu *PairingUpdater) Update(latestBlock int64) {
 		}
 	}
 	pu.nextBlockForUpdate = nextBlockForUpdateMin
+	return updateErr
+}
+
+// prefetchNextPairing starts querying the pairing prefetchBlocks before the boundary. the next pairing depends on the hash of the
+// epoch start block so it can't be computed ahead, but the lava node commits that block before the lava block we track reaches it,
+// once it returns a newer epoch the session manager pairings are built so the boundary only swaps them in
+func (pu *PairingUpdater) prefetchNextPairing(ctx context.Context, latestBlock int64) {
+	if pu.prefetchBlocks == 0 || uint64(latestBlock)+pu.prefetchBlocks < pu.nextBlockForUpdate {
+		return
+	}
+	for chainID, consumerSessionManagerList := range pu.registeredConsumerSessionManagers() {
+		pu.prefetchLock.Lock()
+		_, alreadyPrefetched := pu.prefetched[chainID]
+		pu.prefetchLock.Unlock()
+		if alreadyPrefetched {
+			continue
+		}
+		pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.PrefetchPairing(ctx, chainID)
+		if err != nil {
+			utils.LavaFormatDebug("failed prefetching the next pairing, trying again next block", utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "error", Value: err})
+			continue
+		}
+		if epoch <= consumerSessionManagerList[0].CurrentEpoch() {
+			continue // the node didn't reach the boundary yet
+		}
+		prefetched := &prefetchedPairing{epoch: epoch, nextBlockForUpdate: nextBlockForUpdate, pairingList: pairingList, endpointPairings: map[string]map[uint64]*lavasession.ConsumerSessionsWithProvider{}}
+		for _, consumerSessionManager := range consumerSessionManagerList {
+			rpcEndpoint := consumerSessionManager.RPCEndpoint()
+			endpointPairing, err := pu.filterPairingListByEndpoint(ctx, pairingList, rpcEndpoint, epoch)
+			if err != nil {
+				continue // filtered again at the boundary
+			}
+			prefetched.endpointPairings[rpcEndpoint.Key()] = endpointPairing
+		}
+		pu.prefetchLock.Lock()
+		pu.prefetched[chainID] = prefetched
+		pu.prefetchLock.Unlock()
+		utils.LavaFormatDebug("prefetched next epoch pairing", utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "latestBlock", Value: latestBlock})
+	}
+}
+
+// takePrefetchedPairing returns nil if the pairing of chainID wasn't prefetched, a prefetched pairing is used once
+func (pu *PairingUpdater) takePrefetchedPairing(chainID string) *prefetchedPairing {
+	pu.prefetchLock.Lock()
+	defer pu.prefetchLock.Unlock()
+	prefetched := pu.prefetched[chainID]
+	delete(pu.prefetched, chainID)
+	return prefetched
+}
+
+func (pp *prefetchedPairing) endpointPairing(endpointKey string) (map[uint64]*lavasession.ConsumerSessionsWithProvider, bool) {
+	if pp == nil {
+		return nil, false
+	}
+	endpointPairing, ok := pp.endpointPairings[endpointKey]
+	return endpointPairing, ok
+}
+
+// Revalidate re-reads the pairing after switching lava nodes, newer epochs are applied as usual while the pairing of the current epoch
+// is reconciled so in-flight sessions are kept. a node returning an older epoch is behind and its pairing is ignored
+func (pu *PairingUpdater) Revalidate(ctx context.Context, latestBlock int64) error {
+	var lastErr error
+	nextBlockForUpdateMin := uint64(0)
+	// prefetched pairings were read from the previous node
+	pu.prefetchLock.Lock()
+	pu.prefetched = map[string]*prefetchedPairing{}
+	pu.prefetchLock.Unlock()
+	for chainID, consumerSessionManagerList := range pu.registeredConsumerSessionManagers() {
+		pairingList, epoch, nextBlockForUpdate, err := pu.stateQuery.GetPairing(ctx, chainID, latestBlock)
+		if err != nil {
+			lastErr = utils.LavaFormatError("could not revalidate pairing for chain", err, utils.Attribute{Key: "chain", Value: chainID})
+			continue
+		}
+		if nextBlockForUpdateMin == 0 || nextBlockForUpdate < nextBlockForUpdateMin {
+			nextBlockForUpdateMin = nextBlockForUpdate
+		}
+		for _, consumerSessionManager := range consumerSessionManagerList {
+			currentEpoch := consumerSessionManager.CurrentEpoch()
+			rpcEndpoint := consumerSessionManager.RPCEndpoint()
+			switch {
+			case epoch > currentEpoch:
+				err = pu.updateConsummerSessionManager(ctx, pairingList, consumerSessionManager, epoch)
+			case epoch == currentEpoch:
+				var pairingForThisCSM map[uint64]*lavasession.ConsumerSessionsWithProvider
+				pairingForThisCSM, err = pu.filterPairingListByEndpoint(ctx, pairingList, rpcEndpoint, epoch)
+				if err == nil {
+					_, err = consumerSessionManager.ReconcilePairing(epoch, pairingForThisCSM)
+				}
+			default:
+				utils.LavaFormatWarning("lava node returned an older epoch than the current one, keeping current pairing", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface}, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "currentEpoch", Value: currentEpoch})
+			}
+			if err != nil {
+				lastErr = utils.LavaFormatError("failed revalidating consumer session manager", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "apiInterface", Value: rpcEndpoint.ApiInterface})
+			}
+		}
+	}
+	if nextBlockForUpdateMin != 0 {
+		pu.nextBlockForUpdate = nextBlockForUpdateMin
+	}
+	return lastErr
 }
 
 func (pu *PairingUpdater) updateConsummerSessionManager(ctx context.Context, pairingList []epochstoragetypes.StakeEntry, consumerSessionManager *lavasession.ConsumerSessionManager, epoch uint64) (err error) {
@@ -90,6 +267,7 @@ func (pu *PairingUpdater) updateConsummerSessionManager(ctx context.Context, pai
 func (pu *PairingUpdater) filterPairingListByEndpoint(ctx context.Context, pairingList []epochstoragetypes.StakeEntry, rpcEndpoint lavasession.RPCEndpoint, epoch uint64) (filteredList map[uint64]*lavasession.ConsumerSessionsWithProvider, err error) {
 	// go over stake entries, and filter endpoints that match geolocation and api interface
 	pairing := map[uint64]*lavasession.ConsumerSessionsWithProvider{}
+	maxCuPerChain := map[string]uint64{} // the max cu is per consumer and chain, not per provider
 	for providerIdx, provider := range pairingList {
 		//
 		// Sanity
@@ -111,9 +289,13 @@ func (pu *PairingUpdater) filterPairingListByEndpoint(ctx context.Context, pairi
 			continue
 		}
 
-		maxcu, err := pu.stateQuery.GetMaxCUForUser(ctx, provider.Chain, epoch)
-		if err != nil {
-			return nil, err
+		maxcu, ok := maxCuPerChain[provider.Chain]
+		if !ok {
+			maxcu, err = pu.stateQuery.GetMaxCUForUser(ctx, provider.Chain, epoch)
+			if err != nil {
+				return nil, err
+			}
+			maxCuPerChain[provider.Chain] = maxcu
 		}
 		//
 		pairingEndpoints := make([]*lavasession.Endpoint, len(relevantEndpoints))
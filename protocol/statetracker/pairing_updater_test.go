@@ -0,0 +1,83 @@
+package statetracker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/provideroptimizer"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testStakeEntries(addresses ...string) []epochstoragetypes.StakeEntry {
+	stakeEntries := []epochstoragetypes.StakeEntry{}
+	for _, address := range addresses {
+		stakeEntries = append(stakeEntries, epochstoragetypes.StakeEntry{Address: address, Chain: "LAV1", Endpoints: []epochstoragetypes.Endpoint{{IPPORT: "127.0.0.1:0", UseType: "tendermintrpc", Geolocation: 1}}})
+	}
+	return stakeEntries
+}
+
+func newTestConsumerSessionManager() *lavasession.ConsumerSessionManager {
+	return lavasession.NewConsumerSessionManager(&lavasession.RPCEndpoint{NetworkAddress: "127.0.0.1:0", ChainID: "LAV1", ApiInterface: "tendermintrpc", Geolocation: 1}, provideroptimizer.NewProviderOptimizer(provideroptimizer.STRATEGY_QOS, 0))
+}
+
+func TestPairingUpdaterPrefetch(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Pairings["LAV1"] = testStakeEntries("provider1", "provider2")
+	stateQuery.PairingEpoch, stateQuery.NextPairingBlock = 20, 40
+	stateQuery.MaxCU["LAV1"] = 1000
+	pairingUpdater := NewPairingUpdater(stateQuery, 2)
+	consumerSessionManager := newTestConsumerSessionManager()
+	require.NoError(t, pairingUpdater.RegisterPairing(context.Background(), consumerSessionManager))
+	require.Equal(t, uint64(20), consumerSessionManager.CurrentEpoch())
+	require.Equal(t, uint64(40), pairingUpdater.nextBlockForUpdate)
+
+	// the next pairing isn't read before the prefetch window
+	require.NoError(t, pairingUpdater.Update(37))
+	require.Equal(t, 0, stateQuery.Calls("PrefetchPairing"))
+	// the node didn't reach the boundary yet, the pairing is read again on the next block
+	require.NoError(t, pairingUpdater.Update(38))
+	require.Empty(t, pairingUpdater.prefetched)
+	stateQuery.Lock()
+	stateQuery.Pairings["LAV1"] = testStakeEntries("provider3")
+	stateQuery.PairingEpoch, stateQuery.NextPairingBlock = 40, 60
+	stateQuery.Unlock()
+	require.NoError(t, pairingUpdater.Update(39))
+	require.Equal(t, 2, stateQuery.Calls("PrefetchPairing"))
+	require.Len(t, pairingUpdater.prefetched, 1)
+
+	// the boundary swaps in the prefetched pairing without querying it
+	pairingCalls := stateQuery.Calls("GetPairing")
+	require.NoError(t, pairingUpdater.Update(40))
+	require.Equal(t, pairingCalls, stateQuery.Calls("GetPairing"))
+	require.Equal(t, uint64(40), consumerSessionManager.CurrentEpoch())
+	require.Equal(t, uint64(1), consumerSessionManager.GetAtomicPairingAddressesLength())
+	require.Equal(t, uint64(60), pairingUpdater.nextBlockForUpdate)
+	require.Empty(t, pairingUpdater.prefetched)
+}
+
+func TestPairingUpdaterWithoutPrefetch(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Pairings["LAV1"] = testStakeEntries("provider1")
+	stateQuery.PairingEpoch, stateQuery.NextPairingBlock = 20, 40
+	pairingUpdater := NewPairingUpdater(stateQuery, 0)
+	consumerSessionManager := newTestConsumerSessionManager()
+	require.NoError(t, pairingUpdater.RegisterPairing(context.Background(), consumerSessionManager))
+
+	require.NoError(t, pairingUpdater.Update(39))
+	require.Equal(t, 0, stateQuery.Calls("PrefetchPairing"))
+	// a failed query is retried on the next block
+	stateQuery.FailNext("GetPairing", errNodeUnavailable)
+	require.Error(t, pairingUpdater.Update(40))
+	require.Equal(t, uint64(41), pairingUpdater.nextBlockForUpdate)
+	stateQuery.Lock()
+	stateQuery.PairingEpoch, stateQuery.NextPairingBlock = 40, 60
+	stateQuery.Unlock()
+	require.NoError(t, pairingUpdater.Update(41))
+	require.Equal(t, uint64(40), consumerSessionManager.CurrentEpoch())
+	require.Equal(t, uint64(60), pairingUpdater.nextBlockForUpdate)
+
+	require.True(t, pairingUpdater.UnregisterPairing(consumerSessionManager))
+	require.False(t, pairingUpdater.UnregisterPairing(consumerSessionManager))
+}
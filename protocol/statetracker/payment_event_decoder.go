@@ -0,0 +1,90 @@
+package statetracker
+
+import (
+	"sync"
+
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/utils"
+	terderminttypes "github.com/tendermint/tendermint/abci/types"
+)
+
+// PaymentEventDecoder decodes relay payment events with the schema of the protocol version the lava chain targets, before the
+// version is known and on chains without the param the latest schema is used
+type PaymentEventDecoder struct {
+	lock            sync.RWMutex
+	schemas         []rewardserver.PaymentEventSchema
+	schema          rewardserver.PaymentEventSchema
+	protocolVersion string
+	warnedUnknown   map[string]struct{} // unknown attributes already warned about
+}
+
+func NewPaymentEventDecoder(schemas []rewardserver.PaymentEventSchema) *PaymentEventDecoder {
+	return &PaymentEventDecoder{schemas: schemas, schema: schemas[len(schemas)-1], warnedUnknown: map[string]struct{}{}}
+}
+
+// ProtocolVersionUpdated selects the newest schema the provider target version supports
+func (ped *PaymentEventDecoder) ProtocolVersionUpdated(protocolVersion ProtocolVersion) {
+	version := protocolVersion.ProviderTarget
+	if version == "" {
+		return
+	}
+	schema, err := ped.schemaForVersion(version)
+	if err != nil {
+		utils.LavaFormatWarning("failed selecting the payment event schema, keeping the current one", err, utils.Attribute{Key: "protocolVersion", Value: version})
+		return
+	}
+	ped.lock.Lock()
+	changed := schema.MinProtocolVersion != ped.schema.MinProtocolVersion
+	ped.schema = schema
+	ped.protocolVersion = version
+	if changed {
+		ped.warnedUnknown = map[string]struct{}{}
+	}
+	ped.lock.Unlock()
+	if changed {
+		utils.LavaFormatInfo("payment event schema changed", utils.Attribute{Key: "protocolVersion", Value: version}, utils.Attribute{Key: "schema", Value: schema.MinProtocolVersion})
+	}
+}
+
+func (ped *PaymentEventDecoder) schemaForVersion(version string) (rewardserver.PaymentEventSchema, error) {
+	selected := ped.schemas[0]
+	for _, schema := range ped.schemas {
+		compared, err := compareVersions(version, schema.MinProtocolVersion)
+		if err != nil {
+			return rewardserver.PaymentEventSchema{}, err
+		}
+		if compared >= 0 {
+			selected = schema
+		}
+	}
+	return selected, nil
+}
+
+// Decode decodes the payment with the selected schema, attributes the schema doesn't name are warned about once each
+func (ped *PaymentEventDecoder) Decode(event terderminttypes.Event, block int64) (*rewardserver.PaymentRequest, error) {
+	ped.lock.RLock()
+	schema := ped.schema
+	protocolVersion := ped.protocolVersion
+	ped.lock.RUnlock()
+	payment, unknownAttributes, err := rewardserver.BuildPaymentFromRelayPaymentEventWithSchema(event, block, schema)
+	if len(unknownAttributes) > 0 {
+		ped.warnUnknown(unknownAttributes, schema, protocolVersion)
+	}
+	return payment, err
+}
+
+func (ped *PaymentEventDecoder) warnUnknown(unknownAttributes []string, schema rewardserver.PaymentEventSchema, protocolVersion string) {
+	ped.lock.Lock()
+	newUnknown := []string{}
+	for _, attribute := range unknownAttributes {
+		if _, ok := ped.warnedUnknown[attribute]; !ok {
+			ped.warnedUnknown[attribute] = struct{}{}
+			newUnknown = append(newUnknown, attribute)
+		}
+	}
+	ped.lock.Unlock()
+	if len(newUnknown) > 0 {
+		utils.LavaFormatWarning("relay payment event has attributes the schema doesn't know, the chain may emit a newer schema", nil, utils.Attribute{Key: "attributes", Value: newUnknown},
+			utils.Attribute{Key: "schema", Value: schema.MinProtocolVersion}, utils.Attribute{Key: "protocolVersion", Value: protocolVersion})
+	}
+}
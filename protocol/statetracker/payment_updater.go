@@ -1,12 +1,22 @@
 package statetracker
 
 import (
+	"time"
+
 	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/lavanet/lava/utils"
 	"golang.org/x/net/context"
 )
 
 const (
 	CallbackKeyForPaymentUpdate = "payment-update"
+	RelayPaymentEventType       = "lava_relay_payment"
+	PaymentEventsRetries        = 3
+	PaymentEventsRetryBackoff   = 100 * time.Millisecond // doubled on every retry
+	MaxPendingPaymentBlocks     = 100
+	MaxPendingPaymentAttempts   = 20   // updates a pending block is retried on before it's dropped
+	MaxMissedPaymentBlocks      = 1000 // a longer gap only has its latest blocks processed
+	PaymentEventsPageSize       = 100
 )
 
 type PaymentUpdatable interface {
@@ -14,33 +24,189 @@ type PaymentUpdatable interface {
 	Description() string
 }
 
+type pendingPaymentBlock struct {
+	block    int64
+	attempts int
+}
+
 type PaymentUpdater struct {
-	paymentUpdatable map[string]*PaymentUpdatable
-	stateQuery       *ProviderStateQuery
+	paymentUpdatables  *UpdatableRegistry[PaymentUpdatable] // key is the description
+	stateQuery         ProviderStateQueryInf
+	pendingBlocks      []pendingPaymentBlock // blocks whose payment events failed to fetch, retried on the next updates
+	lastProcessedBlock int64                 // the state tracker can skip blocks, the ones after it are processed together on the next update
+	handledEvents      *eventDeduplicator
+	retryBackoff       time.Duration // the wait before the first retry of a failed block, doubled on every retry
 }
 
-func NewPaymentUpdater(stateQuery *ProviderStateQuery) *PaymentUpdater {
-	return &PaymentUpdater{paymentUpdatable: map[string]*PaymentUpdatable{}, stateQuery: stateQuery}
+func NewPaymentUpdater(stateQuery ProviderStateQueryInf) *PaymentUpdater {
+	return &PaymentUpdater{
+		paymentUpdatables: NewUpdatableRegistry[PaymentUpdatable](CallbackKeyForPaymentUpdate),
+		stateQuery:        stateQuery,
+		handledEvents:     newEventDeduplicator(CallbackKeyForPaymentUpdate, EventDedupWindowBlocks),
+		retryBackoff:      PaymentEventsRetryBackoff,
+	}
 }
 
 func (pu *PaymentUpdater) RegisterPaymentUpdatable(ctx context.Context, paymentUpdatable *PaymentUpdatable) {
-	pu.paymentUpdatable[(*paymentUpdatable).Description()] = paymentUpdatable
+	pu.paymentUpdatables.Register((*paymentUpdatable).Description(), *paymentUpdatable, UpdateInterest{Kind: InterestEvent, EventType: RelayPaymentEventType})
+}
+
+// UnregisterPaymentUpdatable returns false if nothing was registered with the description
+func (pu *PaymentUpdater) UnregisterPaymentUpdatable(description string) bool {
+	return pu.paymentUpdatables.Unregister(description)
+}
+
+// ReplacePaymentUpdatable swaps the updatable registered with the description for paymentUpdatable, which can have another description
+func (pu *PaymentUpdater) ReplacePaymentUpdatable(description string, paymentUpdatable *PaymentUpdatable) error {
+	return pu.paymentUpdatables.Replace(description, (*paymentUpdatable).Description(), *paymentUpdatable)
+}
+
+func (pu *PaymentUpdater) RegisteredUpdatables() []string {
+	return pu.paymentUpdatables.Describe()
+}
+
+func (pu *PaymentUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
 }
 
 func (pu *PaymentUpdater) UpdaterKey() string {
 	return CallbackKeyForPaymentUpdate
 }
 
-func (pu *PaymentUpdater) Update(latestBlock int64) {
+func (pu *PaymentUpdater) Update(latestBlock int64) error {
 	ctx := context.Background()
-	payments, err := pu.stateQuery.PaymentEvents(ctx, latestBlock)
+	pu.retryPendingBlocks(ctx)
+	if latestBlock <= pu.lastProcessedBlock {
+		return nil // already processed, the node went back or the block was reported twice
+	}
+	fromBlock := latestBlock - 1 // exclusive, the first update only processes its own block
+	if pu.lastProcessedBlock != 0 {
+		fromBlock = pu.lastProcessedBlock
+	}
+	if latestBlock-fromBlock > MaxMissedPaymentBlocks {
+		utils.LavaFormatError("too many blocks missed for payment events, processing only the latest ones", nil, utils.Attribute{Key: "lastProcessedBlock", Value: fromBlock},
+			utils.Attribute{Key: "latestBlock", Value: latestBlock}, utils.Attribute{Key: "processed", Value: MaxMissedPaymentBlocks})
+		fromBlock = latestBlock - MaxMissedPaymentBlocks
+	}
+	pu.lastProcessedBlock = latestBlock
+	if latestBlock-fromBlock > 1 {
+		return pu.processMissedBlocks(ctx, fromBlock, latestBlock)
+	}
+	payments, err := pu.paymentEventsWithRetry(ctx, latestBlock)
 	if err != nil {
-		return
+		pu.addPendingBlock(latestBlock, err)
+		return err
 	}
-	for _, payment := range payments {
-		updatable, foundUpdatable := pu.paymentUpdatable[payment.Description]
+	pu.handlePayments(payments)
+	return nil
+}
+
+// Rewind drops the processed and pending blocks from forkBlock, so the next update fetches their payments from the new fork
+func (pu *PaymentUpdater) Rewind(forkBlock int64) {
+	if pu.lastProcessedBlock >= forkBlock {
+		pu.lastProcessedBlock = forkBlock - 1
+	}
+	stillPending := make([]pendingPaymentBlock, 0, len(pu.pendingBlocks))
+	for _, pending := range pu.pendingBlocks {
+		if pending.block < forkBlock {
+			stillPending = append(stillPending, pending)
+		}
+	}
+	pu.pendingBlocks = stillPending
+	pu.handledEvents.forget(forkBlock)
+}
+
+// processMissedBlocks handles the payments of (fromBlock, toBlock] with a single range query, if the node can't search its tx index
+// the blocks are queried one by one and the failing ones are left pending
+func (pu *PaymentUpdater) processMissedBlocks(ctx context.Context, fromBlock int64, toBlock int64) error {
+	utils.LavaFormatInfo("processing payment events of missed blocks", utils.Attribute{Key: "fromBlock", Value: fromBlock + 1}, utils.Attribute{Key: "toBlock", Value: toBlock})
+	payments, err := pu.stateQuery.PaymentEventsInRange(ctx, fromBlock, toBlock)
+	if err == nil {
+		pu.handlePayments(payments)
+		return nil
+	}
+	utils.LavaFormatWarning("failed searching payment events in range, querying the blocks one by one", err, utils.Attribute{Key: "fromBlock", Value: fromBlock + 1}, utils.Attribute{Key: "toBlock", Value: toBlock})
+	var firstErr error
+	for block := fromBlock + 1; block <= toBlock; block++ {
+		payments, err := pu.stateQuery.PaymentEvents(ctx, block)
+		if err != nil {
+			pu.addPendingBlock(block, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		pu.handlePayments(payments)
+	}
+	return firstErr
+}
+
+// payments are routed by description, so only the updatable of the payment's description is called. payment events that were already handled are skipped
+func (pu *PaymentUpdater) handlePayments(payments []PaymentEvent) {
+	for _, paymentEvent := range payments {
+		if !pu.handledEvents.firstSeen(paymentEvent.ID) {
+			continue
+		}
+		payment := paymentEvent.Payment
+		updatable, foundUpdatable := pu.paymentUpdatables.Get(payment.Description)
 		if foundUpdatable {
-			(*updatable).PaymentHandler(payment)
+			pu.paymentUpdatables.Call(payment.Description, updatable, func(_ string, paymentUpdatable PaymentUpdatable) {
+				paymentUpdatable.PaymentHandler(payment)
+			})
+		}
+	}
+}
+
+// paymentEventsWithRetry stops waiting for the next attempt when ctx is done and returns its error
+func (pu *PaymentUpdater) paymentEventsWithRetry(ctx context.Context, block int64) (payments []PaymentEvent, err error) {
+	backoff := pu.retryBackoff
+	for attempt := 0; attempt < PaymentEventsRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		payments, err = pu.stateQuery.PaymentEvents(ctx, block)
+		if err == nil {
+			return payments, nil
+		}
+	}
+	return nil, err
+}
+
+func (pu *PaymentUpdater) addPendingBlock(block int64, err error) {
+	if len(pu.pendingBlocks) >= MaxPendingPaymentBlocks {
+		utils.LavaFormatError("too many blocks pending payment events, dropping the oldest", nil, utils.Attribute{Key: "block", Value: pu.pendingBlocks[0].block})
+		pu.pendingBlocks = pu.pendingBlocks[1:]
+	}
+	utils.LavaFormatWarning("failed fetching payment events, will retry on the next update", err, utils.Attribute{Key: "block", Value: block}, utils.Attribute{Key: "pending", Value: len(pu.pendingBlocks) + 1})
+	pu.pendingBlocks = append(pu.pendingBlocks, pendingPaymentBlock{block: block})
+}
+
+// pending blocks get one attempt per update, oldest first. the retries stop at the first failure, so a node that is still failing doesn't delay the update further
+func (pu *PaymentUpdater) retryPendingBlocks(ctx context.Context) {
+	if len(pu.pendingBlocks) == 0 {
+		return
+	}
+	stillPending := make([]pendingPaymentBlock, 0, len(pu.pendingBlocks))
+	for idx, pending := range pu.pendingBlocks {
+		payments, err := pu.stateQuery.PaymentEvents(ctx, pending.block)
+		if err == nil {
+			utils.LavaFormatInfo("fetched payment events of a pending block", utils.Attribute{Key: "block", Value: pending.block}, utils.Attribute{Key: "payments", Value: len(payments)})
+			pu.handlePayments(payments)
+			continue
+		}
+		pending.attempts++
+		if pending.attempts >= MaxPendingPaymentAttempts {
+			utils.LavaFormatError("failed fetching payment events of a pending block, dropping it", err, utils.Attribute{Key: "block", Value: pending.block}, utils.Attribute{Key: "attempts", Value: pending.attempts})
+		} else {
+			stillPending = append(stillPending, pending)
 		}
+		stillPending = append(stillPending, pu.pendingBlocks[idx+1:]...)
+		break
 	}
+	pu.pendingBlocks = stillPending
 }
@@ -0,0 +1,246 @@
+package statetracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errNodeUnavailable = errors.New("node unavailable")
+
+// newTestPaymentUpdaterWithoutBackoff retries failed blocks right away, so the tests don't wait on the backoff
+func newTestPaymentUpdaterWithoutBackoff(stateQuery ProviderStateQueryInf) (*PaymentUpdater, *countingPaymentUpdatable) {
+	paymentUpdater, counter := newTestPaymentUpdater(stateQuery)
+	paymentUpdater.retryBackoff = 0
+	return paymentUpdater, counter
+}
+
+func TestPaymentEventsWithRetry(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		expectedCalls int
+		expectedErr   bool
+	}{
+		{name: "first attempt", failures: 0, expectedCalls: 1},
+		{name: "retried", failures: PaymentEventsRetries - 1, expectedCalls: PaymentEventsRetries},
+		{name: "every attempt failed", failures: PaymentEventsRetries, expectedCalls: PaymentEventsRetries, expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateQuery := NewFakeStateQuery()
+			stateQuery.Payments[10] = []PaymentEvent{testPaymentEvent(10, 0, 1)}
+			for i := 0; i < tt.failures; i++ {
+				stateQuery.FailNext("PaymentEvents", errNodeUnavailable)
+			}
+			paymentUpdater, _ := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+			payments, err := paymentUpdater.paymentEventsWithRetry(context.Background(), 10)
+			require.Equal(t, tt.expectedCalls, stateQuery.Calls("PaymentEvents"))
+			if tt.expectedErr {
+				require.ErrorIs(t, err, errNodeUnavailable)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, payments, 1)
+		})
+	}
+}
+
+func TestPaymentEventsRetryStopsOnContext(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.FailNext("PaymentEvents", errNodeUnavailable, errNodeUnavailable)
+	paymentUpdater, _ := newTestPaymentUpdater(stateQuery)
+	paymentUpdater.retryBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := paymentUpdater.paymentEventsWithRetry(ctx, 10)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, stateQuery.Calls("PaymentEvents"))
+}
+
+func TestRetryPendingBlocks(t *testing.T) {
+	tests := []struct {
+		name            string
+		pending         []pendingPaymentBlock
+		failures        int
+		expectedPending []pendingPaymentBlock
+		expectedHandled map[uint64]int
+	}{
+		{
+			name:            "all fetched",
+			pending:         []pendingPaymentBlock{{block: 10}, {block: 11}},
+			expectedPending: []pendingPaymentBlock{},
+			expectedHandled: map[uint64]int{10: 1, 11: 1},
+		},
+		{
+			name:            "stops at the first failure",
+			pending:         []pendingPaymentBlock{{block: 10}, {block: 11}, {block: 12}},
+			failures:        1,
+			expectedPending: []pendingPaymentBlock{{block: 10, attempts: 1}, {block: 11}, {block: 12}},
+			expectedHandled: map[uint64]int{},
+		},
+		{
+			name:            "dropped after the last attempt",
+			pending:         []pendingPaymentBlock{{block: 10, attempts: MaxPendingPaymentAttempts - 1}, {block: 11}},
+			failures:        1,
+			expectedPending: []pendingPaymentBlock{{block: 11}},
+			expectedHandled: map[uint64]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateQuery := NewFakeStateQuery()
+			for _, pending := range tt.pending {
+				stateQuery.Payments[pending.block] = []PaymentEvent{testPaymentEvent(pending.block, 0, uint64(pending.block))}
+			}
+			for i := 0; i < tt.failures; i++ {
+				stateQuery.FailNext("PaymentEvents", errNodeUnavailable)
+			}
+			paymentUpdater, counter := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+			paymentUpdater.pendingBlocks = append([]pendingPaymentBlock{}, tt.pending...)
+			paymentUpdater.retryPendingBlocks(context.Background())
+			require.Equal(t, tt.expectedPending, paymentUpdater.pendingBlocks)
+			require.Equal(t, tt.expectedHandled, counter.handledPayments())
+		})
+	}
+}
+
+func TestProcessMissedBlocks(t *testing.T) {
+	tests := []struct {
+		name            string
+		rangeFails      bool
+		blockFailures   int
+		expectedErr     bool
+		expectedPending []pendingPaymentBlock
+		expectedHandled map[uint64]int
+	}{
+		{
+			name:            "range query",
+			expectedHandled: map[uint64]int{11: 1, 12: 1, 13: 1},
+		},
+		{
+			name:            "blocks queried one by one",
+			rangeFails:      true,
+			expectedHandled: map[uint64]int{11: 1, 12: 1, 13: 1},
+		},
+		{
+			name:            "failed blocks left pending",
+			rangeFails:      true,
+			blockFailures:   1,
+			expectedErr:     true,
+			expectedPending: []pendingPaymentBlock{{block: 11}},
+			expectedHandled: map[uint64]int{12: 1, 13: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateQuery := NewFakeStateQuery()
+			for block := int64(11); block <= 13; block++ {
+				stateQuery.Payments[block] = []PaymentEvent{testPaymentEvent(block, 0, uint64(block))}
+			}
+			if tt.rangeFails {
+				stateQuery.FailNext("PaymentEventsInRange", errNodeUnavailable)
+			}
+			for i := 0; i < tt.blockFailures; i++ {
+				stateQuery.FailNext("PaymentEvents", errNodeUnavailable)
+			}
+			paymentUpdater, counter := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+			err := paymentUpdater.processMissedBlocks(context.Background(), 10, 13)
+			if tt.expectedErr {
+				require.ErrorIs(t, err, errNodeUnavailable)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedPending, paymentUpdater.pendingBlocks)
+			require.Equal(t, tt.expectedHandled, counter.handledPayments())
+		})
+	}
+}
+
+func TestPaymentUpdaterUpdate(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	for block := int64(10); block <= 15; block++ {
+		stateQuery.Payments[block] = []PaymentEvent{testPaymentEvent(block, 0, uint64(block))}
+	}
+	paymentUpdater, counter := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+
+	// the first update only processes its own block
+	require.NoError(t, paymentUpdater.Update(10))
+	require.Equal(t, map[uint64]int{10: 1}, counter.handledPayments())
+	// a failing block is left pending and fetched on the next update
+	stateQuery.FailNext("PaymentEvents", errNodeUnavailable, errNodeUnavailable, errNodeUnavailable)
+	require.ErrorIs(t, paymentUpdater.Update(11), errNodeUnavailable)
+	require.Equal(t, []pendingPaymentBlock{{block: 11}}, paymentUpdater.pendingBlocks)
+	require.NoError(t, paymentUpdater.Update(12))
+	require.Empty(t, paymentUpdater.pendingBlocks)
+	require.Equal(t, map[uint64]int{10: 1, 11: 1, 12: 1}, counter.handledPayments())
+	// skipped blocks are processed together, a block reported twice isn't
+	require.NoError(t, paymentUpdater.Update(15))
+	require.NoError(t, paymentUpdater.Update(15))
+	require.Equal(t, 1, stateQuery.Calls("PaymentEventsInRange"))
+	require.Equal(t, map[uint64]int{10: 1, 11: 1, 12: 1, 13: 1, 14: 1, 15: 1}, counter.handledPayments())
+}
+
+func TestPaymentUpdaterPendingBlocksLimit(t *testing.T) {
+	paymentUpdater, _ := newTestPaymentUpdaterWithoutBackoff(NewFakeStateQuery())
+	for block := int64(1); block <= MaxPendingPaymentBlocks+1; block++ {
+		paymentUpdater.addPendingBlock(block, errNodeUnavailable)
+	}
+	require.Len(t, paymentUpdater.pendingBlocks, MaxPendingPaymentBlocks)
+	require.Equal(t, int64(2), paymentUpdater.pendingBlocks[0].block)
+}
+
+type describedPaymentUpdatable struct {
+	countingPaymentUpdatable
+	description string
+}
+
+func (dpu *describedPaymentUpdatable) Description() string {
+	return dpu.description
+}
+
+func TestPaymentUpdaterUnregisterReplace(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	payment := testPaymentEvent(10, 0, 1)
+	stateQuery.Payments[10] = []PaymentEvent{payment}
+	stateQuery.Payments[11] = []PaymentEvent{testPaymentEvent(11, 0, 2)}
+	stateQuery.Payments[12] = []PaymentEvent{testPaymentEvent(12, 0, 3)}
+	paymentUpdater, counter := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+	require.Len(t, paymentUpdater.RegisteredUpdatables(), 1)
+
+	// the replacement gets the payments of the description it replaced
+	replacement := &describedPaymentUpdatable{countingPaymentUpdatable: *newCountingPaymentUpdatable(), description: testPaymentDescription}
+	var replacementUpdatable PaymentUpdatable = replacement
+	require.NoError(t, paymentUpdater.ReplacePaymentUpdatable(testPaymentDescription, &replacementUpdatable))
+	require.NoError(t, paymentUpdater.Update(10))
+	require.Empty(t, counter.handledPayments())
+	require.Equal(t, map[uint64]int{1: 1}, replacement.handledPayments())
+
+	// an unregistered updatable isn't called, its payments are dropped
+	require.True(t, paymentUpdater.UnregisterPaymentUpdatable(testPaymentDescription))
+	require.False(t, paymentUpdater.UnregisterPaymentUpdatable(testPaymentDescription))
+	require.Empty(t, paymentUpdater.RegisteredUpdatables())
+	require.NoError(t, paymentUpdater.Update(11))
+	require.Equal(t, map[uint64]int{1: 1}, replacement.handledPayments())
+	require.Error(t, paymentUpdater.ReplacePaymentUpdatable(testPaymentDescription, &replacementUpdatable))
+}
+
+func TestPaymentUpdaterMissedBlocksLimit(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	const latestBlock = 10 + MaxMissedPaymentBlocks + 5
+	for _, block := range []int64{11, latestBlock - MaxMissedPaymentBlocks, latestBlock - MaxMissedPaymentBlocks + 1, latestBlock} {
+		stateQuery.Payments[block] = []PaymentEvent{testPaymentEvent(block, 0, uint64(block))}
+	}
+	paymentUpdater, counter := newTestPaymentUpdaterWithoutBackoff(stateQuery)
+	require.NoError(t, paymentUpdater.Update(10))
+	// a gap longer than the limit only has its latest blocks processed
+	require.NoError(t, paymentUpdater.Update(latestBlock))
+	require.Equal(t, map[uint64]int{latestBlock - MaxMissedPaymentBlocks + 1: 1, latestBlock: 1}, counter.handledPayments())
+	require.Equal(t, int64(latestBlock), paymentUpdater.lastProcessedBlock)
+	// a block behind the processed one is ignored
+	require.NoError(t, paymentUpdater.Update(11))
+	require.Equal(t, 1, stateQuery.Calls("PaymentEvents"))
+}
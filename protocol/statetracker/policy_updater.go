@@ -0,0 +1,198 @@
+package statetracker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	projectstypes "github.com/lavanet/lava/x/projects/types"
+)
+
+const (
+	CallbackKeyForPolicyUpdate = "policy-update"
+	PolicyQueryBlocks          = 20 // the project, plan and subscription are queried every this many blocks
+)
+
+// ConsumerWithoutProjectError is returned when the consumer key isn't a developer key of any project
+var ConsumerWithoutProjectError = errors.New("consumer has no project")
+
+// EffectivePolicy combines the admin, subscription and plan policies of the consumer's project the way the pairing module does
+type EffectivePolicy struct {
+	Project            string
+	Plan               string
+	Policies           []projectstypes.Policy // the policies that are set, the plan policy is always last
+	Geolocation        uint64                 // the geolocations allowed by every policy
+	MaxProvidersToPair uint64
+	EpochCuLimit       uint64 // the compute units the project may use per epoch, limited by the project's total limit and the cu left in the subscription
+}
+
+func NewEffectivePolicy(project *projectstypes.Project, planPolicy projectstypes.Policy, planIndex string, monthCuLeft uint64) *EffectivePolicy {
+	effectivePolicy := &EffectivePolicy{Project: project.Index, Plan: planIndex, Geolocation: math.MaxUint64, MaxProvidersToPair: math.MaxUint64, EpochCuLimit: math.MaxUint64}
+	for _, policy := range []*projectstypes.Policy{project.AdminPolicy, project.SubscriptionPolicy, &planPolicy} {
+		if policy != nil {
+			effectivePolicy.Policies = append(effectivePolicy.Policies, *policy)
+		}
+	}
+	totalCuLimit := uint64(math.MaxUint64)
+	for _, policy := range effectivePolicy.Policies {
+		effectivePolicy.Geolocation &= policy.GeolocationProfile
+		effectivePolicy.MaxProvidersToPair = minUint64(effectivePolicy.MaxProvidersToPair, policy.MaxProvidersToPair)
+		effectivePolicy.EpochCuLimit = minUint64(effectivePolicy.EpochCuLimit, policy.EpochCuLimit)
+		totalCuLimit = minUint64(totalCuLimit, policy.TotalCuLimit)
+	}
+	cuLeftInProject := uint64(0)
+	if totalCuLimit > project.UsedCu {
+		cuLeftInProject = totalCuLimit - project.UsedCu
+	}
+	effectivePolicy.EpochCuLimit = minUint64(effectivePolicy.EpochCuLimit, minUint64(cuLeftInProject, monthCuLeft))
+	return effectivePolicy
+}
+
+// AllowsChain is true if any of the policies allows the chain, a policy without chain policies allows every chain
+func (ep *EffectivePolicy) AllowsChain(chainID string) bool {
+	for idx := range ep.Policies {
+		if ep.Policies[idx].ContainsChainID(chainID) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsApi is true if a policy that allows the chain allows the api, a chain policy without apis allows every api
+func (ep *EffectivePolicy) AllowsApi(chainID string, api string) bool {
+	for _, policy := range ep.Policies {
+		if len(policy.ChainPolicies) == 0 {
+			return true
+		}
+		for _, chainPolicy := range policy.ChainPolicies {
+			if chainPolicy.ChainId != chainID {
+				continue
+			}
+			if len(chainPolicy.Apis) == 0 {
+				return true
+			}
+			for _, allowedApi := range chainPolicy.Apis {
+				if allowedApi == api {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func minUint64(a uint64, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type PolicyUpdatable interface {
+	PolicyChanged(policy *EffectivePolicy)
+}
+
+// ProjectKeyRevokedUpdatable is optionally implemented by policy updatables that act when the consumer key is removed from its project
+type ProjectKeyRevokedUpdatable interface {
+	ProjectKeyRevoked(consumer string, project string)
+}
+
+// PolicyUpdater tracks the effective policy of the consumer's project, so policy changes apply before the next pairing.
+// consumers without a project keep running without a policy
+type PolicyUpdater struct {
+	lock               sync.RWMutex
+	policyUpdatables   *UpdatableRegistry[PolicyUpdatable]
+	stateQuery         ConsumerStateQueryInf
+	consumer           string
+	policy             *EffectivePolicy
+	nextBlockForUpdate int64
+}
+
+func NewPolicyUpdater(stateQuery ConsumerStateQueryInf, consumer string) *PolicyUpdater {
+	return &PolicyUpdater{policyUpdatables: NewUpdatableRegistry[PolicyUpdatable](CallbackKeyForPolicyUpdate), stateQuery: stateQuery, consumer: consumer}
+}
+
+// RegisterPolicyUpdatable calls policyUpdatable on every policy change, a known policy is passed to it right away
+func (pu *PolicyUpdater) RegisterPolicyUpdatable(policyUpdatable PolicyUpdatable) {
+	key := pu.policyUpdatables.RegisterUnique(policyUpdatable, UpdateInterest{Kind: InterestEvent, EventType: CallbackKeyForPolicyUpdate})
+	policy := pu.Policy()
+	if policy != nil {
+		pu.policyUpdatables.Call(key, policyUpdatable, func(_ string, policyUpdatable PolicyUpdatable) {
+			policyUpdatable.PolicyChanged(policy)
+		})
+	}
+}
+
+func (pu *PolicyUpdater) UnregisterPolicyUpdatable(policyUpdatable PolicyUpdatable) bool {
+	return pu.policyUpdatables.UnregisterMatching(policyUpdatable)
+}
+
+func (pu *PolicyUpdater) RegisteredUpdatables() []string {
+	return pu.policyUpdatables.Describe()
+}
+
+// Policy returns the last queried policy, nil if it wasn't queried yet
+func (pu *PolicyUpdater) Policy() *EffectivePolicy {
+	pu.lock.RLock()
+	defer pu.lock.RUnlock()
+	return pu.policy
+}
+
+func (pu *PolicyUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
+}
+
+func (pu *PolicyUpdater) UpdaterKey() string {
+	return CallbackKeyForPolicyUpdate
+}
+
+func (pu *PolicyUpdater) Update(latestBlock int64) error {
+	if latestBlock < pu.nextBlockForUpdate {
+		return nil
+	}
+	pu.nextBlockForUpdate = latestBlock + PolicyQueryBlocks
+	// the project, subscription and plan are read at the same height
+	policy, err := pu.stateQuery.GetEffectivePolicy(NewQuerySession(context.Background(), latestBlock), pu.consumer)
+	if errors.Is(err, ConsumerWithoutProjectError) {
+		pu.onProjectKeyRevoked(latestBlock)
+	}
+	if err != nil {
+		// the last policy stays in effect, consumers staked without a project fail here on every query
+		return utils.LavaFormatDebug("failed querying consumer policy", utils.Attribute{Key: "consumer", Value: pu.consumer}, utils.Attribute{Key: "error", Value: err})
+	}
+	pu.lock.Lock()
+	previous := pu.policy
+	pu.policy = policy
+	pu.lock.Unlock()
+	if reflect.DeepEqual(previous, policy) {
+		return nil
+	}
+	if previous == nil || !reflect.DeepEqual(previous.Policies, policy.Policies) || previous.Plan != policy.Plan {
+		utils.LavaFormatInfo("consumer policy changed", utils.Attribute{Key: "consumer", Value: pu.consumer}, utils.Attribute{Key: "project", Value: policy.Project}, utils.Attribute{Key: "plan", Value: policy.Plan},
+			utils.Attribute{Key: "geolocation", Value: policy.Geolocation}, utils.Attribute{Key: "epochCuLimit", Value: policy.EpochCuLimit}, utils.Attribute{Key: "block", Value: latestBlock})
+	}
+	pu.policyUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, EventTypes: map[string]struct{}{CallbackKeyForPolicyUpdate: {}}}, func(_ string, policyUpdatable PolicyUpdatable) {
+		policyUpdatable.PolicyChanged(policy)
+	})
+	return nil
+}
+
+// a consumer that had a project and no longer has one had its key removed from the project, relays signed with it are rejected
+func (pu *PolicyUpdater) onProjectKeyRevoked(latestBlock int64) {
+	pu.lock.Lock()
+	previous := pu.policy
+	pu.policy = nil // a key added back is dispatched as a new policy
+	pu.lock.Unlock()
+	if previous == nil {
+		return
+	}
+	utils.LavaFormatWarning("consumer key was removed from its project", nil, utils.Attribute{Key: "consumer", Value: pu.consumer}, utils.Attribute{Key: "project", Value: previous.Project}, utils.Attribute{Key: "block", Value: latestBlock})
+	pu.policyUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, EventTypes: map[string]struct{}{CallbackKeyForPolicyUpdate: {}}}, func(_ string, policyUpdatable PolicyUpdatable) {
+		if revokedUpdatable, ok := policyUpdatable.(ProjectKeyRevokedUpdatable); ok {
+			revokedUpdatable.ProjectKeyRevoked(pu.consumer, previous.Project)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package statetracker
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	projectstypes "github.com/lavanet/lava/x/projects/types"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPolicyUpdatable struct {
+	policies        []*EffectivePolicy
+	revokedProjects []string
+}
+
+func (rpu *recordingPolicyUpdatable) PolicyChanged(policy *EffectivePolicy) {
+	rpu.policies = append(rpu.policies, policy)
+}
+
+func (rpu *recordingPolicyUpdatable) ProjectKeyRevoked(consumer string, project string) {
+	rpu.revokedProjects = append(rpu.revokedProjects, project)
+}
+
+func TestNewEffectivePolicy(t *testing.T) {
+	project := &projectstypes.Project{
+		Index:       "project",
+		UsedCu:      900,
+		AdminPolicy: &projectstypes.Policy{GeolocationProfile: 3, TotalCuLimit: 1000, EpochCuLimit: 500, MaxProvidersToPair: 10},
+	}
+	planPolicy := projectstypes.Policy{GeolocationProfile: 6, TotalCuLimit: math.MaxUint64, EpochCuLimit: 200, MaxProvidersToPair: 5}
+	policy := NewEffectivePolicy(project, planPolicy, "basic", 1000)
+	require.Len(t, policy.Policies, 2)
+	require.Equal(t, uint64(2), policy.Geolocation)
+	require.Equal(t, uint64(5), policy.MaxProvidersToPair)
+	// the admin policy leaves 100 compute units in the project
+	require.Equal(t, uint64(100), policy.EpochCuLimit)
+	// the subscription's compute units limit it further
+	require.Equal(t, uint64(50), NewEffectivePolicy(project, planPolicy, "basic", 50).EpochCuLimit)
+}
+
+func TestEffectivePolicyAllows(t *testing.T) {
+	policy := &EffectivePolicy{Policies: []projectstypes.Policy{
+		{ChainPolicies: []projectstypes.ChainPolicy{{ChainId: "LAV1", Apis: []string{"status"}}, {ChainId: "ETH1"}}},
+	}}
+	require.True(t, policy.AllowsChain("LAV1"))
+	require.False(t, policy.AllowsChain("COS3"))
+	require.True(t, policy.AllowsApi("LAV1", "status"))
+	require.False(t, policy.AllowsApi("LAV1", "block"))
+	require.True(t, policy.AllowsApi("ETH1", "eth_blockNumber"))
+}
+
+func TestPolicyUpdater(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	updater := NewPolicyUpdater(stateQuery, "consumer")
+	updatable := &recordingPolicyUpdatable{}
+	updater.RegisterPolicyUpdatable(updatable)
+
+	// a consumer without a project keeps running without a policy
+	require.Error(t, updater.Update(1))
+	require.Nil(t, updater.Policy())
+	require.Empty(t, updatable.policies)
+
+	stateQuery.Policies["consumer"] = &EffectivePolicy{Project: "project", Plan: "basic", EpochCuLimit: 100}
+	require.NoError(t, updater.Update(1+PolicyQueryBlocks))
+	require.Len(t, updatable.policies, 1)
+	// an unchanged policy isn't dispatched
+	require.NoError(t, updater.Update(1+2*PolicyQueryBlocks))
+	require.Len(t, updatable.policies, 1)
+	// blocks between the queries don't query the project
+	require.NoError(t, updater.Update(2+2*PolicyQueryBlocks))
+	require.Equal(t, 3, stateQuery.Calls("GetEffectivePolicy"))
+
+	// a failed query keeps the last policy in effect
+	stateQuery.FailNext("GetEffectivePolicy", errors.New("node unavailable"))
+	require.Error(t, updater.Update(1+3*PolicyQueryBlocks))
+	require.Equal(t, uint64(100), updater.Policy().EpochCuLimit)
+
+	stateQuery.Policies["consumer"] = &EffectivePolicy{Project: "project", Plan: "basic", EpochCuLimit: 50}
+	require.NoError(t, updater.Update(1+4*PolicyQueryBlocks))
+	require.Len(t, updatable.policies, 2)
+	require.Equal(t, uint64(50), updatable.policies[1].EpochCuLimit)
+
+	// a late updatable gets the known policy right away
+	lateUpdatable := &recordingPolicyUpdatable{}
+	updater.RegisterPolicyUpdatable(lateUpdatable)
+	require.Len(t, lateUpdatable.policies, 1)
+	require.True(t, updater.UnregisterPolicyUpdatable(lateUpdatable))
+}
+
+func TestPolicyUpdaterProjectKeyRevoked(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	updater := NewPolicyUpdater(stateQuery, "consumer")
+	updatable := &recordingPolicyUpdatable{}
+	updater.RegisterPolicyUpdatable(updatable)
+
+	// a consumer that never had a project isn't revoked
+	require.Error(t, updater.Update(1))
+	require.Empty(t, updatable.revokedProjects)
+
+	stateQuery.Policies["consumer"] = &EffectivePolicy{Project: "project", Plan: "basic", EpochCuLimit: 100}
+	require.NoError(t, updater.Update(1+PolicyQueryBlocks))
+	// a failed query isn't a revocation
+	stateQuery.FailNext("GetEffectivePolicy", errors.New("node unavailable"))
+	require.Error(t, updater.Update(1+2*PolicyQueryBlocks))
+	require.Empty(t, updatable.revokedProjects)
+
+	delete(stateQuery.Policies, "consumer")
+	require.Error(t, updater.Update(1+3*PolicyQueryBlocks))
+	require.Equal(t, []string{"project"}, updatable.revokedProjects)
+	require.Nil(t, updater.Policy())
+	// the revocation is dispatched once
+	require.Error(t, updater.Update(1+4*PolicyQueryBlocks))
+	require.Len(t, updatable.revokedProjects, 1)
+
+	// a key added back gets its policy again
+	stateQuery.Policies["consumer"] = &EffectivePolicy{Project: "project", Plan: "basic", EpochCuLimit: 100}
+	require.NoError(t, updater.Update(1+5*PolicyQueryBlocks))
+	require.Len(t, updatable.policies, 2)
+}
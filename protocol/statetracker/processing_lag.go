@@ -0,0 +1,138 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ProcessingLagAlertFlag          = "state-tracker-lag-alert-blocks"
+	DefaultProcessingLagAlertBlocks = 10
+	processingLagFetchTimeout       = 5 * time.Second
+)
+
+var (
+	processingLagGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lava_state_tracker_processing_lag_blocks",
+		Help: "The lava blocks between the chain tip and the last block every updater finished processing",
+	})
+	lastProcessedBlockGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lava_state_tracker_last_processed_block",
+		Help: "The last lava block every updater finished processing",
+	})
+	skippedBlocksCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "lava_state_tracker_skipped_blocks_total",
+		Help: "Lava blocks the updaters weren't called with, as the lava node was unreachable or the chain tracker polled past them",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(processingLagGauge, lastProcessedBlockGauge, skippedBlocksCounter)
+}
+
+// ProcessingLagCallback is called when the state tracker starts or stops lagging behind the lava chain tip
+type ProcessingLagCallback func(lagging bool, processedBlock int64, latestBlock int64)
+
+// processingLagMonitor compares the last block the updaters finished with the chain tip, the tip is polled separately
+// since the chain tracker doesn't poll while the updaters are still processing a block
+type processingLagMonitor struct {
+	lock           sync.Mutex
+	chainFetcher   chaintracker.ChainFetcher
+	processedBlock int64
+	latestBlock    int64
+	threshold      int64 // 0 disables the alert
+	callback       ProcessingLagCallback
+	lagging        bool
+}
+
+func newProcessingLagMonitor(chainFetcher chaintracker.ChainFetcher) *processingLagMonitor {
+	return &processingLagMonitor{chainFetcher: chainFetcher, threshold: DefaultProcessingLagAlertBlocks}
+}
+
+func (plm *processingLagMonitor) start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fetchCtx, cancel := context.WithTimeout(ctx, processingLagFetchTimeout)
+				latestBlock, err := plm.chainFetcher.FetchLatestBlockNum(fetchCtx)
+				cancel()
+				if err != nil {
+					utils.LavaFormatDebug("failed fetching the lava chain tip for the processing lag", utils.Attribute{Key: "error", Value: err})
+					continue
+				}
+				plm.check(0, latestBlock)
+			}
+		}
+	}()
+}
+
+func (plm *processingLagMonitor) setAlert(threshold uint64, callback ProcessingLagCallback) {
+	plm.lock.Lock()
+	defer plm.lock.Unlock()
+	plm.threshold = int64(threshold)
+	plm.callback = callback
+}
+
+func (plm *processingLagMonitor) processed(block int64) {
+	previousBlock := plm.getProcessedBlock()
+	if previousBlock != 0 && block-previousBlock > 1 {
+		// the event updaters query the blocks they missed on their next update, the others only need the latest state
+		skippedBlocksCounter.Add(float64(block - previousBlock - 1))
+		utils.LavaFormatInfo("lava blocks were skipped, event updaters are backfilling them", utils.Attribute{Key: "fromBlock", Value: previousBlock + 1}, utils.Attribute{Key: "toBlock", Value: block - 1})
+	}
+	plm.check(block, block)
+}
+
+// check updates the processed block and the tip with the given blocks, 0 keeps the current one
+func (plm *processingLagMonitor) check(processedBlock int64, latestBlock int64) {
+	plm.lock.Lock()
+	if processedBlock > plm.processedBlock {
+		plm.processedBlock = processedBlock
+		lastProcessedBlockGauge.Set(float64(processedBlock))
+	}
+	if latestBlock > plm.latestBlock {
+		plm.latestBlock = latestBlock
+	}
+	if plm.processedBlock == 0 {
+		plm.lock.Unlock()
+		return // nothing was processed yet
+	}
+	lag := plm.latestBlock - plm.processedBlock
+	processingLagGauge.Set(float64(lag))
+	lagging := plm.threshold > 0 && lag > plm.threshold
+	changed := lagging != plm.lagging
+	plm.lagging = lagging
+	processed, latest, threshold, callback := plm.processedBlock, plm.latestBlock, plm.threshold, plm.callback
+	plm.lock.Unlock()
+	if !changed {
+		return
+	}
+	if lagging {
+		utils.LavaFormatWarning("state tracker is falling behind the lava chain, updaters are too slow", nil, utils.Attribute{Key: "processedBlock", Value: processed},
+			utils.Attribute{Key: "latestBlock", Value: latest}, utils.Attribute{Key: "threshold", Value: threshold})
+	} else {
+		utils.LavaFormatInfo("state tracker caught up with the lava chain", utils.Attribute{Key: "processedBlock", Value: processed}, utils.Attribute{Key: "latestBlock", Value: latest})
+	}
+	if callback != nil {
+		callback(lagging, processed, latest)
+	}
+}
+
+func (plm *processingLagMonitor) getProcessedBlock() int64 {
+	plm.lock.Lock()
+	defer plm.lock.Unlock()
+	return plm.processedBlock
+}
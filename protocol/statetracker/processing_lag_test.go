@@ -0,0 +1,96 @@
+package statetracker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// tipChainFetcher reports a settable chain tip
+type tipChainFetcher struct {
+	latestBlock int64 // atomic
+}
+
+func (tcf *tipChainFetcher) FetchLatestBlockNum(ctx context.Context) (int64, error) {
+	return atomic.LoadInt64(&tcf.latestBlock), nil
+}
+
+func (tcf *tipChainFetcher) FetchBlockHashByNum(ctx context.Context, blockNum int64) (string, error) {
+	return "", nil
+}
+
+func (tcf *tipChainFetcher) FetchEndpoint() lavasession.RPCProviderEndpoint {
+	return lavasession.RPCProviderEndpoint{}
+}
+
+type lagAlert struct {
+	lagging        bool
+	processedBlock int64
+	latestBlock    int64
+}
+
+func TestProcessingLagAlerts(t *testing.T) {
+	monitor := newProcessingLagMonitor(nil)
+	alerts := []lagAlert{}
+	monitor.setAlert(5, func(lagging bool, processedBlock int64, latestBlock int64) {
+		alerts = append(alerts, lagAlert{lagging: lagging, processedBlock: processedBlock, latestBlock: latestBlock})
+	})
+	// nothing was processed yet, a tip alone doesn't alert
+	monitor.check(0, 100)
+	require.Empty(t, alerts)
+
+	monitor.processed(10)
+	require.Equal(t, []lagAlert{{lagging: true, processedBlock: 10, latestBlock: 100}}, alerts)
+	// still lagging, the alert isn't repeated
+	monitor.processed(50)
+	require.Len(t, alerts, 1)
+	// a lag of the threshold isn't lagging
+	monitor.processed(95)
+	require.Equal(t, lagAlert{lagging: false, processedBlock: 95, latestBlock: 100}, alerts[1])
+	// older blocks don't move the processed block back
+	monitor.check(90, 0)
+	require.Equal(t, int64(95), monitor.getProcessedBlock())
+	require.Len(t, alerts, 2)
+
+	// a threshold of 0 disables the alert
+	monitor.setAlert(0, nil)
+	monitor.check(0, 1000)
+	require.Len(t, alerts, 2)
+}
+
+func TestProcessingLagPollsTip(t *testing.T) {
+	chainFetcher := &tipChainFetcher{latestBlock: 100}
+	monitor := newProcessingLagMonitor(chainFetcher)
+	lagging := make(chan bool, 1)
+	monitor.setAlert(5, func(isLagging bool, processedBlock int64, latestBlock int64) {
+		lagging <- isLagging
+	})
+	monitor.processed(99)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	monitor.start(ctx, time.Millisecond)
+	// the chain tracker doesn't report new blocks while the updaters are busy, the polled tip shows the lag
+	atomic.StoreInt64(&chainFetcher.latestBlock, 110)
+	select {
+	case isLagging := <-lagging:
+		require.True(t, isLagging)
+	case <-time.After(time.Second):
+		t.Fatal("no lag alert from the polled tip")
+	}
+}
+
+func TestProcessingLagSkippedBlocks(t *testing.T) {
+	monitor := newProcessingLagMonitor(nil)
+	skipped := testutil.ToFloat64(skippedBlocksCounter)
+	// the first block has nothing before it to skip
+	monitor.processed(10)
+	monitor.processed(11)
+	require.Equal(t, skipped, testutil.ToFloat64(skippedBlocksCounter))
+	monitor.processed(15)
+	require.Equal(t, skipped+3, testutil.ToFloat64(skippedBlocksCounter))
+}
@@ -0,0 +1,220 @@
+package statetracker
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	CallbackKeyForProtocolVersionUpdate = "protocol-version-update"
+	ProtocolVersionActionFlag           = "protocol-version-action"
+	ProtocolVersionParamSubspace        = "protocol"
+	ProtocolVersionParamKey             = "Version"
+	ProtocolVersionQueryBlocks          = 20 // the version param is queried every this many blocks
+	ProtocolVersionRoleProvider         = "provider"
+	ProtocolVersionRoleConsumer         = "consumer"
+)
+
+type ProtocolVersionUpdatable interface {
+	ProtocolVersionUpdated(protocolVersion ProtocolVersion)
+}
+
+// ProtocolVersionAction is what happens when the running binary is below the minimum protocol version
+type ProtocolVersionAction string
+
+const (
+	ProtocolVersionActionWarn      ProtocolVersionAction = "warn"      // log an error on every version change
+	ProtocolVersionActionUnhealthy ProtocolVersionAction = "unhealthy" // also report unhealthy, so load balancers move traffic away
+	ProtocolVersionActionShutdown  ProtocolVersionAction = "shutdown"  // also shut down gracefully
+)
+
+var protocolVersionSupportedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lava_protocol_version_supported",
+	Help: "1 if the running binary meets the minimum protocol version of the lava chain, 0 if it has to be upgraded",
+}, []string{"role", "binaryVersion"})
+
+func init() {
+	prometheus.MustRegister(protocolVersionSupportedGauge)
+}
+
+func ParseProtocolVersionAction(action string) (ProtocolVersionAction, error) {
+	switch ProtocolVersionAction(action) {
+	case ProtocolVersionActionWarn, ProtocolVersionActionUnhealthy, ProtocolVersionActionShutdown:
+		return ProtocolVersionAction(action), nil
+	}
+	return "", utils.LavaFormatError("invalid protocol version action", nil, utils.Attribute{Key: "action", Value: action},
+		utils.Attribute{Key: "options", Value: []ProtocolVersionAction{ProtocolVersionActionWarn, ProtocolVersionActionUnhealthy, ProtocolVersionActionShutdown}})
+}
+
+// ProtocolVersion is the json value of the protocol version param
+type ProtocolVersion struct {
+	ProviderTarget string `json:"provider_target"`
+	ProviderMin    string `json:"provider_min"`
+	ConsumerTarget string `json:"consumer_target"`
+	ConsumerMin    string `json:"consumer_min"`
+}
+
+func (pv *ProtocolVersion) forRole(role string) (target string, min string) {
+	if role == ProtocolVersionRoleConsumer {
+		return pv.ConsumerTarget, pv.ConsumerMin
+	}
+	return pv.ProviderTarget, pv.ProviderMin
+}
+
+// ProtocolVersionUpdater compares the binary version to the protocol version param and acts when the binary falls below the minimum.
+// chains without the param are not enforced
+type ProtocolVersionUpdater struct {
+	lock               sync.RWMutex
+	stateQuery         StateQueryInf
+	binaryVersion      string
+	role               string
+	action             ProtocolVersionAction
+	shutdown           func()
+	nextBlockForUpdate int64
+	lastVersion        ProtocolVersion
+	belowMin           bool
+	shutdownOnce       sync.Once
+	versionUpdatables  *UpdatableRegistry[ProtocolVersionUpdatable]
+}
+
+func NewProtocolVersionUpdater(stateQuery StateQueryInf, binaryVersion string, role string, action ProtocolVersionAction, shutdown func()) *ProtocolVersionUpdater {
+	protocolVersionSupportedGauge.WithLabelValues(role, binaryVersion).Set(1)
+	return &ProtocolVersionUpdater{stateQuery: stateQuery, binaryVersion: binaryVersion, role: role, action: action, shutdown: shutdown, versionUpdatables: NewUpdatableRegistry[ProtocolVersionUpdatable](CallbackKeyForProtocolVersionUpdate)}
+}
+
+// RegisterProtocolVersionUpdatable calls the updatable on every protocol version change, and right away if the version is known
+func (pvu *ProtocolVersionUpdater) RegisterProtocolVersionUpdatable(versionUpdatable ProtocolVersionUpdatable) {
+	pvu.versionUpdatables.RegisterUnique(versionUpdatable, UpdateInterest{Kind: InterestEvent, EventType: CallbackKeyForProtocolVersionUpdate})
+	pvu.lock.RLock()
+	lastVersion := pvu.lastVersion
+	pvu.lock.RUnlock()
+	if lastVersion != (ProtocolVersion{}) {
+		versionUpdatable.ProtocolVersionUpdated(lastVersion)
+	}
+}
+
+func (pvu *ProtocolVersionUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
+}
+
+func (pvu *ProtocolVersionUpdater) UpdaterKey() string {
+	return CallbackKeyForProtocolVersionUpdate
+}
+
+// Healthy is false while the binary is below the minimum version and the action reports it
+func (pvu *ProtocolVersionUpdater) Healthy() bool {
+	pvu.lock.RLock()
+	defer pvu.lock.RUnlock()
+	return !pvu.belowMin || pvu.action == ProtocolVersionActionWarn
+}
+
+func (pvu *ProtocolVersionUpdater) Update(latestBlock int64) error {
+	if latestBlock < pvu.nextBlockForUpdate {
+		return nil
+	}
+	pvu.nextBlockForUpdate = latestBlock + ProtocolVersionQueryBlocks
+	protocolVersion, found, err := pvu.stateQuery.GetProtocolVersion(context.Background())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	return pvu.checkVersion(protocolVersion)
+}
+
+func (pvu *ProtocolVersionUpdater) checkVersion(protocolVersion *ProtocolVersion) error {
+	pvu.lock.Lock()
+	changed := *protocolVersion != pvu.lastVersion
+	pvu.lastVersion = *protocolVersion
+	pvu.lock.Unlock()
+	if !changed {
+		return nil
+	}
+	pvu.versionUpdatables.Dispatch(UpdateTrigger{EventTypes: map[string]struct{}{CallbackKeyForProtocolVersionUpdate: {}}}, func(_ string, versionUpdatable ProtocolVersionUpdatable) {
+		versionUpdatable.ProtocolVersionUpdated(*protocolVersion)
+	})
+	target, min := protocolVersion.forRole(pvu.role)
+	belowMin := false
+	if min != "" {
+		compared, err := compareVersions(pvu.binaryVersion, min)
+		if err != nil {
+			return utils.LavaFormatWarning("failed comparing the binary version to the minimum protocol version", err, utils.Attribute{Key: "binaryVersion", Value: pvu.binaryVersion}, utils.Attribute{Key: "min", Value: min})
+		}
+		belowMin = compared < 0
+	}
+	pvu.lock.Lock()
+	pvu.belowMin = belowMin
+	pvu.lock.Unlock()
+	if belowMin {
+		protocolVersionSupportedGauge.WithLabelValues(pvu.role, pvu.binaryVersion).Set(0)
+		utils.LavaFormatError("binary version is below the minimum protocol version, upgrade is required", nil, utils.Attribute{Key: "binaryVersion", Value: pvu.binaryVersion},
+			utils.Attribute{Key: "min", Value: min}, utils.Attribute{Key: "target", Value: target}, utils.Attribute{Key: "role", Value: pvu.role}, utils.Attribute{Key: "action", Value: pvu.action})
+		if pvu.action == ProtocolVersionActionShutdown && pvu.shutdown != nil {
+			pvu.shutdownOnce.Do(pvu.shutdown)
+		}
+		return nil
+	}
+	protocolVersionSupportedGauge.WithLabelValues(pvu.role, pvu.binaryVersion).Set(1)
+	if target != "" {
+		if compared, err := compareVersions(pvu.binaryVersion, target); err == nil && compared < 0 {
+			utils.LavaFormatWarning("a newer protocol version is available, upgrade before it becomes the minimum", nil, utils.Attribute{Key: "binaryVersion", Value: pvu.binaryVersion},
+				utils.Attribute{Key: "target", Value: target}, utils.Attribute{Key: "role", Value: pvu.role})
+		}
+	}
+	return nil
+}
+
+// compareVersions compares semantic versions by major, minor and patch, a leading v and pre-release or build suffixes are ignored
+func compareVersions(version string, other string) (int, error) {
+	parsedVersion, err := parseVersion(version)
+	if err != nil {
+		return 0, err
+	}
+	parsedOther, err := parseVersion(other)
+	if err != nil {
+		return 0, err
+	}
+	for idx := range parsedVersion {
+		if parsedVersion[idx] != parsedOther[idx] {
+			if parsedVersion[idx] < parsedOther[idx] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(version string) (parsed [3]uint64, err error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) == 0 || len(parts) > 3 || parts[0] == "" {
+		return parsed, utils.LavaFormatWarning("invalid version", nil, utils.Attribute{Key: "version", Value: version})
+	}
+	for idx, part := range parts {
+		parsed[idx], err = strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return parsed, utils.LavaFormatWarning("invalid version", err, utils.Attribute{Key: "version", Value: version})
+		}
+	}
+	return parsed, nil
+}
+
+func parseProtocolVersion(value string) (*ProtocolVersion, error) {
+	protocolVersion := &ProtocolVersion{}
+	err := json.Unmarshal([]byte(value), protocolVersion)
+	if err != nil {
+		return nil, utils.LavaFormatWarning("failed parsing protocol version param", err, utils.Attribute{Key: "value", Value: value})
+	}
+	return protocolVersion, nil
+}
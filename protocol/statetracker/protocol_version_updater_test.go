@@ -0,0 +1,129 @@
+package statetracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingProtocolVersionUpdatable struct {
+	versions []ProtocolVersion
+}
+
+func (rpvu *recordingProtocolVersionUpdatable) ProtocolVersionUpdated(protocolVersion ProtocolVersion) {
+	rpvu.versions = append(rpvu.versions, protocolVersion)
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		version  string
+		other    string
+		expected int
+		err      bool
+	}{
+		{version: "0.21.1", other: "0.21.1", expected: 0},
+		{version: "v0.21.1", other: "0.21.1", expected: 0},
+		{version: "0.21.1-rc2", other: "0.21.1+build", expected: 0},
+		{version: "0.21", other: "0.21.0", expected: 0},
+		{version: "0.9.9", other: "0.10.0", expected: -1},
+		{version: "1.0.0", other: "0.99.99", expected: 1},
+		{version: "0.21.1", other: "0.21.2", expected: -1},
+		{version: "", other: "0.21.1", err: true},
+		{version: "0.21.x", other: "0.21.1", err: true},
+		{version: "0.21.1.4", other: "0.21.1", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.other, func(t *testing.T) {
+			compared, err := compareVersions(tt.version, tt.other)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, compared)
+		})
+	}
+}
+
+func TestParseProtocolVersion(t *testing.T) {
+	protocolVersion, err := parseProtocolVersion(`{"provider_target":"0.22.0","provider_min":"0.21.0","consumer_target":"0.22.1","consumer_min":"0.20.0"}`)
+	require.NoError(t, err)
+	require.Equal(t, &ProtocolVersion{ProviderTarget: "0.22.0", ProviderMin: "0.21.0", ConsumerTarget: "0.22.1", ConsumerMin: "0.20.0"}, protocolVersion)
+	_, err = parseProtocolVersion("0.21.0")
+	require.Error(t, err)
+
+	_, err = ParseProtocolVersionAction("restart")
+	require.Error(t, err)
+	action, err := ParseProtocolVersionAction("unhealthy")
+	require.NoError(t, err)
+	require.Equal(t, ProtocolVersionActionUnhealthy, action)
+}
+
+func TestProtocolVersionUpdaterActions(t *testing.T) {
+	tests := []struct {
+		name            string
+		role            string
+		action          ProtocolVersionAction
+		expectedHealthy bool
+		expectedStopped bool
+	}{
+		{name: "warn", role: ProtocolVersionRoleProvider, action: ProtocolVersionActionWarn, expectedHealthy: true},
+		{name: "unhealthy", role: ProtocolVersionRoleProvider, action: ProtocolVersionActionUnhealthy},
+		{name: "shutdown", role: ProtocolVersionRoleProvider, action: ProtocolVersionActionShutdown, expectedStopped: true},
+		{name: "consumer above its own minimum", role: ProtocolVersionRoleConsumer, action: ProtocolVersionActionShutdown, expectedHealthy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateQuery := NewFakeStateQuery()
+			stateQuery.ProtocolVersion = &ProtocolVersion{ProviderTarget: "0.22.0", ProviderMin: "0.21.0", ConsumerTarget: "0.21.0", ConsumerMin: "0.20.0"}
+			shutdowns := 0
+			updater := NewProtocolVersionUpdater(stateQuery, "0.20.5", tt.role, tt.action, func() { shutdowns++ })
+			require.NoError(t, updater.Update(1))
+			require.Equal(t, tt.expectedHealthy, updater.Healthy())
+			// shutting down happens once, even when the version changes again
+			stateQuery.ProtocolVersion = &ProtocolVersion{ProviderTarget: "0.23.0", ProviderMin: "0.21.0", ConsumerTarget: "0.21.0", ConsumerMin: "0.20.0"}
+			require.NoError(t, updater.Update(1+ProtocolVersionQueryBlocks))
+			if tt.expectedStopped {
+				require.Equal(t, 1, shutdowns)
+			} else {
+				require.Zero(t, shutdowns)
+			}
+		})
+	}
+}
+
+func TestProtocolVersionUpdaterUpdates(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	updater := NewProtocolVersionUpdater(stateQuery, "0.20.5", ProtocolVersionRoleProvider, ProtocolVersionActionUnhealthy, nil)
+	early := &recordingProtocolVersionUpdatable{}
+	updater.RegisterProtocolVersionUpdatable(early)
+	// a chain without the param isn't enforced
+	require.NoError(t, updater.Update(1))
+	require.True(t, updater.Healthy())
+	require.Empty(t, early.versions)
+
+	stateQuery.ProtocolVersion = &ProtocolVersion{ProviderMin: "0.21.0"}
+	require.NoError(t, updater.Update(1+ProtocolVersionQueryBlocks-1))
+	require.Equal(t, 1, stateQuery.Calls("GetProtocolVersion"))
+	require.NoError(t, updater.Update(1+ProtocolVersionQueryBlocks))
+	require.False(t, updater.Healthy())
+	require.Equal(t, []ProtocolVersion{{ProviderMin: "0.21.0"}}, early.versions)
+
+	// an updatable registered after the version is known gets it right away, an unchanged version isn't dispatched again
+	late := &recordingProtocolVersionUpdatable{}
+	updater.RegisterProtocolVersionUpdatable(late)
+	require.Len(t, late.versions, 1)
+	require.NoError(t, updater.Update(1+2*ProtocolVersionQueryBlocks))
+	require.Len(t, early.versions, 1)
+
+	// lowering the minimum makes the binary healthy again
+	stateQuery.ProtocolVersion = &ProtocolVersion{ProviderMin: "0.20.0"}
+	require.NoError(t, updater.Update(1+3*ProtocolVersionQueryBlocks))
+	require.True(t, updater.Healthy())
+	require.Len(t, late.versions, 2)
+
+	// an unparsable minimum is reported and leaves the health as it was
+	stateQuery.ProtocolVersion = &ProtocolVersion{ProviderMin: "latest"}
+	require.Error(t, updater.Update(1+4*ProtocolVersionQueryBlocks))
+	require.True(t, updater.Healthy())
+}
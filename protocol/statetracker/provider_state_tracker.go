@@ -2,13 +2,16 @@ package statetracker
 
 import (
 	"context"
+	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/lavanet/lava/protocol/chainlib"
 	"github.com/lavanet/lava/protocol/chaintracker"
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
 	"github.com/lavanet/lava/utils"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
 )
@@ -16,12 +19,17 @@ import (
 // ProviderStateTracker PST is a class for tracking provider data from the lava blockchain, such as epoch changes.
 // it allows also to query specific data form the blockchain and acts as a single place to send transactions
 type ProviderStateTracker struct {
-	stateQuery *ProviderStateQuery
-	txSender   *ProviderTxSender
+	stateQuery       *ProviderStateQuery
+	txSender         *ProviderTxSender
+	downtimeDuration time.Duration
+	lavaNodeClient   *LavaNodeClient
+	lavaChainID      string
 	*StateTracker
 }
 
+// NewProviderStateTracker creates the tracker, to be able to switch lava nodes the chainFetcher should use a context returned from NewLavaNodeClient
 func NewProviderStateTracker(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, chainFetcher chaintracker.ChainFetcher) (ret *ProviderStateTracker, err error) {
+	lavaNodeClient, clientCtx := NewLavaNodeClient(clientCtx)
 	stateTrackerBase, err := NewStateTracker(ctx, txFactory, clientCtx, chainFetcher)
 	if err != nil {
 		return nil, err
@@ -30,10 +38,117 @@ func NewProviderStateTracker(ctx context.Context, txFactory tx.Factory, clientCt
 	if err != nil {
 		return nil, err
 	}
-	pst := &ProviderStateTracker{StateTracker: stateTrackerBase, stateQuery: NewProviderStateQuery(ctx, clientCtx), txSender: txSender}
+	pst := &ProviderStateTracker{StateTracker: stateTrackerBase, stateQuery: NewProviderStateQuery(ctx, clientCtx), txSender: txSender, lavaNodeClient: lavaNodeClient, lavaChainID: clientCtx.ChainID}
+	pst.StateTracker.RegisterForUpdates(ctx, pst.stateQuery.StateQuery.queryBatcher)
+	pst.StateTracker.RegisterForUpdates(ctx, pst.stateQuery.StateQuery.ResponsesCache)
+	pst.StateTracker.RegisterForUpdates(ctx, txSender.TxConfirmations())
 	return pst, nil
 }
 
+// SetTxGasAdjustment sets the multiplier of the simulated gas of the provider's transactions
+func (pst *ProviderStateTracker) SetTxGasAdjustment(gasAdjustment float64) error {
+	return pst.txSender.SetGasAdjustment(gasAdjustment)
+}
+
+// SetTxMaxPerBlock sets how many of the provider's transactions are sent in a block, 0 is unlimited
+func (pst *ProviderStateTracker) SetTxMaxPerBlock(maxPerBlock int) error {
+	return pst.txSender.SetMaxTxsPerBlock(maxPerBlock)
+}
+
+// SetClaimFeeStrategy sets how the fee of the reward claim transactions is paid
+func (pst *ProviderStateTracker) SetClaimFeeStrategy(feeStrategy *TxFeeStrategy) {
+	pst.txSender.SetClaimFeeStrategy(feeStrategy)
+}
+
+// NewClaimTxSender creates a tx sender that signs with the keyName wallet, for the reward claims of a provider address other than
+// --from served by the same process. it pays fees like the claims of the provider
+func (pst *ProviderStateTracker) NewClaimTxSender(ctx context.Context, keyName string, address sdk.AccAddress) (*ProviderTxSender, error) {
+	clientCtx := pst.txSender.clientCtx.WithFromName(keyName).WithFromAddress(address)
+	txSender, err := NewProviderTxSender(ctx, clientCtx, pst.txSender.txFactory)
+	if err != nil {
+		return nil, err
+	}
+	pst.txSender.lock.RLock()
+	gasAdjustment := pst.txSender.gasAdjustment
+	maxPerBlock := pst.txSender.maxPerBlock
+	pst.txSender.lock.RUnlock()
+	err = txSender.SetGasAdjustment(gasAdjustment)
+	if err != nil {
+		return nil, err
+	}
+	err = txSender.SetMaxTxsPerBlock(maxPerBlock)
+	if err != nil {
+		return nil, err
+	}
+	txSender.SetClaimFeeStrategy(pst.txSender.claimFeeStrategy)
+	pst.StateTracker.RegisterForUpdates(ctx, txSender.TxConfirmations())
+	return txSender, nil
+}
+
+// SwitchLavaNode moves all lava queries and transactions to a different lava node, cached state read from the previous node is dropped
+func (pst *ProviderStateTracker) SwitchLavaNode(ctx context.Context, nodeURI string) error {
+	pst.registrationLock.Lock()
+	defer pst.registrationLock.Unlock()
+	latestBlock, err := pst.lavaNodeClient.Switch(ctx, nodeURI, pst.lavaChainID)
+	if err != nil {
+		return err
+	}
+	if trackedBlock := pst.chainTracker.GetLatestBlockNum(); latestBlock < trackedBlock {
+		utils.LavaFormatWarning("new lava node is behind the previous one, block updates will resume once it catches up", nil, utils.Attribute{Key: "nodeURI", Value: nodeURI}, utils.Attribute{Key: "height", Value: latestBlock}, utils.Attribute{Key: "trackedBlock", Value: trackedBlock})
+	}
+	pst.stateQuery.StateQuery.ClearCache()
+	return nil
+}
+
+// StartLavaNodeFailover health checks the current lava node and backupNodeURIs, and switches to a backup when the current node fails
+func (pst *ProviderStateTracker) StartLavaNodeFailover(ctx context.Context, backupNodeURIs []string) error {
+	failover, err := NewLavaNodeFailover(pst.lavaNodeClient, append([]string{pst.lavaNodeClient.NodeURI()}, backupNodeURIs...), pst.lavaChainID, pst.SwitchLavaNode)
+	if err != nil {
+		return err
+	}
+	failover.Start(ctx)
+	return nil
+}
+
+// EnforceProtocolVersion checks the binary version against the protocol version param of the lava chain, shutdown is called for the shutdown action
+func (pst *ProviderStateTracker) EnforceProtocolVersion(ctx context.Context, binaryVersion string, action ProtocolVersionAction, shutdown func()) *ProtocolVersionUpdater {
+	protocolVersionUpdater := NewProtocolVersionUpdater(&pst.stateQuery.StateQuery, binaryVersion, ProtocolVersionRoleProvider, action, shutdown)
+	protocolVersionUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, protocolVersionUpdater)
+	protocolVersionUpdater, ok := protocolVersionUpdaterRaw.(*ProtocolVersionUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: protocolVersionUpdaterRaw})
+	}
+	// payment events are decoded with the schema of the chain's protocol version
+	protocolVersionUpdater.RegisterProtocolVersionUpdatable(pst.stateQuery.PaymentEventDecoder)
+	return protocolVersionUpdater
+}
+
+// SetSpecOverlays applies local spec modifications to specs queried from now on, used for devnets and forks
+func (pst *ProviderStateTracker) SetSpecOverlays(specOverlays map[string]*SpecOverlay) {
+	pst.stateQuery.StateQuery.SetSpecOverlays(specOverlays)
+}
+
+// SetDowntimeDuration sets how long lava can go without a new block before it is considered down, it applies to downtime updates registered later
+func (pst *ProviderStateTracker) SetDowntimeDuration(downtimeDuration time.Duration) {
+	pst.downtimeDuration = downtimeDuration
+}
+
+// RegisterForDowntimeUpdates calls downtimeUpdatable with the virtual epochs that passed while lava produced no blocks
+func (pst *ProviderStateTracker) RegisterForDowntimeUpdates(ctx context.Context, downtimeUpdatable DowntimeUpdatable) {
+	downtimeTracker := NewDowntimeTracker(&pst.stateQuery.EpochStateQuery, pst.chainTracker, pst.downtimeDuration)
+	downtimeTrackerRaw := pst.StateTracker.RegisterForUpdates(ctx, downtimeTracker)
+	downtimeTracker, ok := downtimeTrackerRaw.(*DowntimeTracker)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: downtimeTrackerRaw})
+	}
+	downtimeTracker.RegisterDowntimeUpdatable(ctx, downtimeUpdatable)
+}
+
+func (pst *ProviderStateTracker) UnregisterForDowntimeUpdates(downtimeUpdatable DowntimeUpdatable) bool {
+	downtimeTracker, ok := pst.StateTracker.registeredUpdater(CallbackKeyForDowntimeUpdate).(*DowntimeTracker)
+	return ok && downtimeTracker.UnregisterDowntimeUpdatable(downtimeUpdatable)
+}
+
 func (pst *ProviderStateTracker) RegisterForEpochUpdates(ctx context.Context, epochUpdatable EpochUpdatable) {
 	epochUpdater := NewEpochUpdater(&pst.stateQuery.EpochStateQuery)
 	epochUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, epochUpdater)
@@ -44,17 +159,34 @@ func (pst *ProviderStateTracker) RegisterForEpochUpdates(ctx context.Context, ep
 	epochUpdater.RegisterEpochUpdatable(ctx, epochUpdatable)
 }
 
+// RegisterForEpochBoundaryUpdates calls epochBoundaryUpdatable once per epoch with the epoch start block and its hash
+func (pst *ProviderStateTracker) RegisterForEpochBoundaryUpdates(ctx context.Context, epochBoundaryUpdatable EpochBoundaryUpdatable) {
+	epochUpdater := NewEpochUpdater(&pst.stateQuery.EpochStateQuery)
+	epochUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, epochUpdater)
+	epochUpdater, ok := epochUpdaterRaw.(*EpochUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: epochUpdaterRaw})
+	}
+	epochUpdater.RegisterEpochBoundaryUpdatable(ctx, epochBoundaryUpdatable)
+}
+
 func (pst *ProviderStateTracker) RegisterChainParserForSpecUpdates(ctx context.Context, chainParser chainlib.ChainParser, chainID string) error {
-	spec, err := pst.stateQuery.GetSpec(ctx, chainID)
-	if err != nil {
-		return err
+	return pst.RegisterForSpecUpdates(ctx, chainParser, chainID)
+}
+
+// RegisterForSpecUpdates sets the current spec of the chain on specUpdatable, and sets it again whenever governance changes the spec
+func (pst *ProviderStateTracker) RegisterForSpecUpdates(ctx context.Context, specUpdatable SpecUpdatable, chainID string) error {
+	specUpdater := NewSpecUpdater(chainID, &pst.stateQuery.StateQuery)
+	specUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, specUpdater)
+	specUpdater, ok := specUpdaterRaw.(*SpecUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: specUpdaterRaw})
 	}
-	chainParser.SetSpec(*spec)
-	return nil
+	return specUpdater.RegisterSpecUpdatable(ctx, specUpdatable)
 }
 
 func (pst *ProviderStateTracker) RegisterReliabilityManagerForVoteUpdates(ctx context.Context, voteUpdatable VoteUpdatable, endpointP *lavasession.RPCProviderEndpoint) {
-	voteUpdater := NewVoteUpdater(pst.stateQuery)
+	voteUpdater := NewVoteUpdater(pst.stateQuery, pst.txSender.clientCtx.FromAddress.String())
 	voteUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, voteUpdater)
 	voteUpdater, ok := voteUpdaterRaw.(*VoteUpdater)
 	if !ok {
@@ -64,6 +196,38 @@ func (pst *ProviderStateTracker) RegisterReliabilityManagerForVoteUpdates(ctx co
 	voteUpdater.RegisterVoteUpdatable(ctx, &voteUpdatable, endpoint)
 }
 
+// RegisterForStakeStatusUpdates calls stakeStatusUpdatable when the provider's stake entry on the chain is frozen, unfrozen, unstaked or jailed
+func (pst *ProviderStateTracker) RegisterForStakeStatusUpdates(ctx context.Context, stakeStatusUpdatable StakeStatusUpdatable, chainID string) {
+	stakeStatusUpdater := NewStakeStatusUpdater(pst.stateQuery, pst.txSender.clientCtx.FromAddress.String())
+	stakeStatusUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, stakeStatusUpdater)
+	stakeStatusUpdater, ok := stakeStatusUpdaterRaw.(*StakeStatusUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: stakeStatusUpdaterRaw})
+	}
+	stakeStatusUpdater.RegisterStakeStatusUpdatable(ctx, stakeStatusUpdatable, chainID)
+}
+
+// RegisterForDelegatorRewardsUpdates calls delegatorRewardsUpdatable with the rewards of the provider's delegations every DelegatorRewardsQueryBlocks
+func (pst *ProviderStateTracker) RegisterForDelegatorRewardsUpdates(ctx context.Context, delegatorRewardsUpdatable DelegatorRewardsUpdatable) {
+	delegatorRewardsUpdater := NewDelegatorRewardsUpdater(pst.stateQuery, pst.txSender.clientCtx.FromAddress.String())
+	delegatorRewardsUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, delegatorRewardsUpdater)
+	delegatorRewardsUpdater, ok := delegatorRewardsUpdaterRaw.(*DelegatorRewardsUpdater)
+	if !ok {
+		utils.LavaFormatFatal("invalid updater type returned from RegisterForUpdates", nil, utils.Attribute{Key: "updater", Value: delegatorRewardsUpdaterRaw})
+	}
+	delegatorRewardsUpdater.RegisterDelegatorRewardsUpdatable(delegatorRewardsUpdatable)
+}
+
+func (pst *ProviderStateTracker) UnregisterForDelegatorRewardsUpdates(delegatorRewardsUpdatable DelegatorRewardsUpdatable) bool {
+	delegatorRewardsUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForDelegatorRewardsUpdate).(*DelegatorRewardsUpdater)
+	return ok && delegatorRewardsUpdater.UnregisterDelegatorRewardsUpdatable(delegatorRewardsUpdatable)
+}
+
+func (pst *ProviderStateTracker) UnregisterForStakeStatusUpdates(stakeStatusUpdatable StakeStatusUpdatable) bool {
+	stakeStatusUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForStakeStatusUpdate).(*StakeStatusUpdater)
+	return ok && stakeStatusUpdater.UnregisterStakeStatusUpdatable(stakeStatusUpdatable)
+}
+
 func (pst *ProviderStateTracker) RegisterPaymentUpdatableForPayments(ctx context.Context, paymentUpdatable PaymentUpdatable) {
 	payemntUpdater := NewPaymentUpdater(pst.stateQuery)
 	payemntUpdaterRaw := pst.StateTracker.RegisterForUpdates(ctx, payemntUpdater)
@@ -79,6 +243,14 @@ func (pst *ProviderStateTracker) TxRelayPayment(ctx context.Context, relayReques
 	return pst.txSender.TxRelayPayment(ctx, relayRequests, dataReliabilityProofs, description)
 }
 
+func (pst *ProviderStateTracker) TxUnfreezeProvider(ctx context.Context, chainIDs []string) error {
+	return pst.txSender.TxUnfreezeProvider(ctx, chainIDs)
+}
+
+func (pst *ProviderStateTracker) TxClaimDelegatorRewards(ctx context.Context, validators []string) error {
+	return pst.txSender.TxClaimDelegatorRewards(ctx, validators)
+}
+
 func (pst *ProviderStateTracker) SendVoteReveal(voteID string, vote *reliabilitymanager.VoteData) error {
 	return pst.txSender.SendVoteReveal(voteID, vote)
 }
@@ -103,6 +275,10 @@ func (pst *ProviderStateTracker) GetProvidersCountForConsumer(ctx context.Contex
 	return pst.stateQuery.GetProvidersCountForConsumer(ctx, consumerAddress, epoch, chainID)
 }
 
+func (pst *ProviderStateTracker) CurrentEpoch(ctx context.Context) (uint64, error) {
+	return pst.stateQuery.CurrentEpochStart(ctx)
+}
+
 func (pst *ProviderStateTracker) GetEpochSize(ctx context.Context) (uint64, error) {
 	return pst.stateQuery.GetEpochSize(ctx)
 }
@@ -115,6 +291,91 @@ func (pst *ProviderStateTracker) GetRecommendedEpochNumToCollectPayment(ctx cont
 	return pst.stateQuery.GetRecommendedEpochNumToCollectPayment(ctx)
 }
 
+// ProviderPaymentEvents returns the payments of provider's claims in the blocks in (fromBlock, toBlock], from the lava node's tx index
+func (pst *ProviderStateTracker) ProviderPaymentEvents(ctx context.Context, provider string, fromBlock int64, toBlock int64) ([]*rewardserver.PaymentRequest, error) {
+	events, err := pst.stateQuery.ProviderPaymentEventsInRange(ctx, provider, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	payments := make([]*rewardserver.PaymentRequest, 0, len(events))
+	for _, event := range events {
+		payments = append(payments, event.Payment)
+	}
+	return payments, nil
+}
+
+func (pst *ProviderStateTracker) GetPairingParams(ctx context.Context) (*pairingtypes.Params, error) {
+	return pst.stateQuery.GetPairingParams(ctx)
+}
+
+// GetBlockMaxBytes returns the max bytes of a lava block, a transaction can't be bigger
+func (pst *ProviderStateTracker) GetBlockMaxBytes(ctx context.Context) (int64, error) {
+	res, err := pst.lavaNodeClient.ConsensusParams(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return res.ConsensusParams.Block.MaxBytes, nil
+}
+
 func (pst *ProviderStateTracker) GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error) {
 	return pst.stateQuery.GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx)
 }
+
+func (pst *ProviderStateTracker) UnregisterForEpochUpdates(epochUpdatable EpochUpdatable) bool {
+	epochUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForEpochUpdate).(*EpochUpdater)
+	return ok && epochUpdater.UnregisterEpochUpdatable(epochUpdatable)
+}
+
+func (pst *ProviderStateTracker) UnregisterForEpochBoundaryUpdates(epochBoundaryUpdatable EpochBoundaryUpdatable) bool {
+	epochUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForEpochUpdate).(*EpochUpdater)
+	return ok && epochUpdater.UnregisterEpochBoundaryUpdatable(epochBoundaryUpdatable)
+}
+
+func (pst *ProviderStateTracker) ReplaceForEpochUpdates(oldEpochUpdatable EpochUpdatable, newEpochUpdatable EpochUpdatable) error {
+	epochUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForEpochUpdate).(*EpochUpdater)
+	if !ok {
+		return utils.LavaFormatError("no epoch updater registered", nil)
+	}
+	return epochUpdater.ReplaceEpochUpdatable(oldEpochUpdatable, newEpochUpdatable)
+}
+
+func (pst *ProviderStateTracker) UnregisterForSpecUpdates(specUpdatable SpecUpdatable, chainID string) bool {
+	specUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForSpecUpdate + chainID).(*SpecUpdater)
+	return ok && specUpdater.UnregisterSpecUpdatable(specUpdatable)
+}
+
+// ReplaceForSpecUpdates sets the current spec of the chain on newSpecUpdatable before it takes the place of oldSpecUpdatable
+func (pst *ProviderStateTracker) ReplaceForSpecUpdates(ctx context.Context, oldSpecUpdatable SpecUpdatable, newSpecUpdatable SpecUpdatable, chainID string) error {
+	specUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForSpecUpdate + chainID).(*SpecUpdater)
+	if !ok {
+		return utils.LavaFormatError("no spec updater registered for chain", nil, utils.Attribute{Key: "chainID", Value: chainID})
+	}
+	return specUpdater.ReplaceSpecUpdatable(ctx, oldSpecUpdatable, newSpecUpdatable)
+}
+
+func (pst *ProviderStateTracker) UnregisterReliabilityManagerForVoteUpdates(endpointP *lavasession.RPCProviderEndpoint) bool {
+	voteUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForVoteUpdate).(*VoteUpdater)
+	return ok && voteUpdater.UnregisterVoteUpdatable(lavasession.RPCEndpoint{ChainID: endpointP.ChainID, ApiInterface: endpointP.ApiInterface})
+}
+
+func (pst *ProviderStateTracker) ReplaceReliabilityManagerForVoteUpdates(voteUpdatable VoteUpdatable, endpointP *lavasession.RPCProviderEndpoint) error {
+	voteUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForVoteUpdate).(*VoteUpdater)
+	if !ok {
+		return utils.LavaFormatError("no vote updater registered", nil)
+	}
+	return voteUpdater.ReplaceVoteUpdatable(&voteUpdatable, lavasession.RPCEndpoint{ChainID: endpointP.ChainID, ApiInterface: endpointP.ApiInterface})
+}
+
+// UnregisterPaymentUpdatableForPayments detaches the payment updatable with the description, payments for it are ignored from then on
+func (pst *ProviderStateTracker) UnregisterPaymentUpdatableForPayments(description string) bool {
+	paymentUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForPaymentUpdate).(*PaymentUpdater)
+	return ok && paymentUpdater.UnregisterPaymentUpdatable(description)
+}
+
+func (pst *ProviderStateTracker) ReplacePaymentUpdatableForPayments(description string, paymentUpdatable PaymentUpdatable) error {
+	paymentUpdater, ok := pst.StateTracker.registeredUpdater(CallbackKeyForPaymentUpdate).(*PaymentUpdater)
+	if !ok {
+		return utils.LavaFormatError("no payment updater registered", nil)
+	}
+	return paymentUpdater.ReplacePaymentUpdatable(description, &paymentUpdatable)
+}
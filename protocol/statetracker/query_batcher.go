@@ -0,0 +1,185 @@
+package statetracker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/lavanet/lava/utils"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"google.golang.org/grpc"
+)
+
+const (
+	CallbackKeyForQueryBatcher = "query-batcher"
+	DefaultQueryBatchWindow    = 2 * time.Millisecond
+	QueryResultMaxAge          = 5 * time.Second // results are shared within a lava block, but not for longer if blocks stop arriving
+)
+
+// queryBatchClient is implemented by rpc clients that can send several abci queries in one request
+type queryBatchClient interface {
+	NewQueryBatch() (*rpchttp.BatchHTTP, bool)
+}
+
+type sharedQueryResult struct {
+	done      chan struct{}
+	value     []byte
+	err       error
+	createdAt time.Time
+}
+
+type pendingQuery struct {
+	key    string
+	method string
+	data   []byte
+	height int64 // 0 queries the latest state
+	result *sharedQueryResult
+}
+
+// QueryBatcher is the grpc connection of the state query clients. identical queries issued during one lava block share a single
+// result, and queries issued together within the batch window are sent to the lava node as one batched rpc request
+type QueryBatcher struct {
+	lock        sync.Mutex
+	clientCtx   client.Context
+	batchWindow time.Duration
+	block       int64
+	results     map[string]*sharedQueryResult // key is the method, the marshaled request and the pinned height, reset on every lava block
+	pending     []*pendingQuery
+}
+
+func NewQueryBatcher(clientCtx client.Context, batchWindow time.Duration) *QueryBatcher {
+	return &QueryBatcher{clientCtx: clientCtx, batchWindow: batchWindow, results: map[string]*sharedQueryResult{}}
+}
+
+func (qb *QueryBatcher) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	request, ok := args.(codec.ProtoMarshaler)
+	replyMsg, okReply := reply.(codec.ProtoMarshaler)
+	if !ok || !okReply {
+		return qb.clientCtx.Invoke(ctx, method, args, reply, opts...)
+	}
+	data, err := request.Marshal()
+	if err != nil {
+		return err
+	}
+	height, _ := QuerySessionHeight(ctx)
+	key := method + string(data) + "@" + strconv.FormatInt(height, 10)
+	qb.lock.Lock()
+	result, found := qb.results[key]
+	if !found || time.Since(result.createdAt) > QueryResultMaxAge {
+		result = &sharedQueryResult{done: make(chan struct{}), createdAt: time.Now()}
+		qb.results[key] = result
+		qb.pending = append(qb.pending, &pendingQuery{key: key, method: method, data: data, height: height, result: result})
+		if len(qb.pending) == 1 {
+			time.AfterFunc(qb.batchWindow, qb.flush)
+		}
+	}
+	qb.lock.Unlock()
+	select {
+	case <-result.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if result.err != nil {
+		return result.err
+	}
+	return replyMsg.Unmarshal(result.value)
+}
+
+func (qb *QueryBatcher) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return qb.clientCtx.NewStream(ctx, desc, method, opts...)
+}
+
+// the shared results are from the previous block, queries run before the other updaters
+func (qb *QueryBatcher) UpdatePriority() int {
+	return UpdatePriorityHigh - 1
+}
+
+func (qb *QueryBatcher) UpdaterKey() string {
+	return CallbackKeyForQueryBatcher
+}
+
+func (qb *QueryBatcher) Update(latestBlock int64) error {
+	qb.lock.Lock()
+	defer qb.lock.Unlock()
+	if latestBlock == qb.block {
+		return nil
+	}
+	qb.block = latestBlock
+	qb.results = map[string]*sharedQueryResult{} // in flight queries still complete for their waiters
+	return nil
+}
+
+// Reset drops the shared results, used when they might not match the lava node we query from now on
+func (qb *QueryBatcher) Reset() {
+	qb.lock.Lock()
+	defer qb.lock.Unlock()
+	qb.results = map[string]*sharedQueryResult{}
+}
+
+func (qb *QueryBatcher) flush() {
+	qb.lock.Lock()
+	pending := qb.pending
+	qb.pending = nil
+	qb.lock.Unlock()
+	ctx := context.Background()
+	if len(pending) > 1 {
+		if batch, ok := newQueryBatch(qb.clientCtx.Client); ok {
+			qb.sendBatch(ctx, batch, pending)
+			return
+		}
+	}
+	for _, query := range pending {
+		response, err := qb.clientCtx.Client.ABCIQueryWithOptions(ctx, query.method, query.data, rpcclient.ABCIQueryOptions{Height: query.height})
+		qb.complete(query, response, err)
+	}
+}
+
+// only the http rpc client supports batching, others query one by one
+func newQueryBatch(rpcClient rpcclient.Client) (*rpchttp.BatchHTTP, bool) {
+	switch batchClient := rpcClient.(type) {
+	case queryBatchClient:
+		return batchClient.NewQueryBatch()
+	case *rpchttp.HTTP:
+		return batchClient.NewBatch(), true
+	}
+	return nil, false
+}
+
+func (qb *QueryBatcher) sendBatch(ctx context.Context, batch *rpchttp.BatchHTTP, pending []*pendingQuery) {
+	responses := make([]*ctypes.ResultABCIQuery, len(pending))
+	for idx, query := range pending {
+		// the batch fills the returned results on send
+		responses[idx], _ = batch.ABCIQueryWithOptions(ctx, query.method, query.data, rpcclient.ABCIQueryOptions{Height: query.height})
+	}
+	_, err := batch.Send(ctx)
+	if err != nil {
+		utils.LavaFormatDebug("batched lava queries failed", utils.Attribute{Key: "queries", Value: len(pending)}, utils.Attribute{Key: "error", Value: err})
+	}
+	for idx, query := range pending {
+		qb.complete(query, responses[idx], err)
+	}
+}
+
+func (qb *QueryBatcher) complete(query *pendingQuery, response *ctypes.ResultABCIQuery, err error) {
+	if err == nil && !response.Response.IsOK() {
+		err = sdkerrors.ABCIError(response.Response.Codespace, response.Response.Code, response.Response.Log)
+	}
+	if err != nil {
+		query.result.err = err
+		// failed queries aren't shared, the next caller queries again
+		qb.lock.Lock()
+		if qb.results[query.key] == query.result {
+			delete(qb.results, query.key)
+		}
+		qb.lock.Unlock()
+	} else {
+		query.result.value = response.Response.Value
+	}
+	close(query.result.done)
+}
@@ -0,0 +1,117 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// fakeABCIClient answers abci queries with the spec of the queried chain, only the methods the query batcher uses are implemented
+type fakeABCIClient struct {
+	rpcclient.Client
+	lock    sync.Mutex
+	queries []rpcclient.ABCIQueryOptions
+	fail    bool
+}
+
+func (fac *fakeABCIClient) ABCIQueryWithOptions(ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
+	fac.lock.Lock()
+	defer fac.lock.Unlock()
+	fac.queries = append(fac.queries, opts)
+	if fac.fail {
+		return &ctypes.ResultABCIQuery{Response: abcitypes.ResponseQuery{Code: 1, Codespace: "spec", Log: "not found"}}, nil
+	}
+	request := spectypes.QueryGetSpecRequest{}
+	if err := request.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	response := spectypes.QueryGetSpecResponse{Spec: spectypes.Spec{Index: request.ChainID, BlockLastUpdated: uint64(opts.Height)}}
+	value, err := response.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultABCIQuery{Response: abcitypes.ResponseQuery{Value: value}}, nil
+}
+
+func (fac *fakeABCIClient) queried() []rpcclient.ABCIQueryOptions {
+	fac.lock.Lock()
+	defer fac.lock.Unlock()
+	return append([]rpcclient.ABCIQueryOptions{}, fac.queries...)
+}
+
+func querySpec(ctx context.Context, queryBatcher *QueryBatcher, chainID string) (*spectypes.QueryGetSpecResponse, error) {
+	response := &spectypes.QueryGetSpecResponse{}
+	err := queryBatcher.Invoke(ctx, "/lavanet.lava.spec.Query/Spec", &spectypes.QueryGetSpecRequest{ChainID: chainID}, response)
+	return response, err
+}
+
+func TestQueryBatcherSharesResults(t *testing.T) {
+	abciClient := &fakeABCIClient{}
+	queryBatcher := NewQueryBatcher(client.Context{}.WithClient(abciClient), time.Millisecond)
+	require.NoError(t, queryBatcher.Update(10))
+
+	// identical queries of the block share a single query to the node
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := querySpec(context.Background(), queryBatcher, "LAV1")
+			require.NoError(t, err)
+			require.Equal(t, "LAV1", response.Spec.Index)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, abciClient.queried(), 1)
+	// queries pinned to a height are shared separately
+	response, err := querySpec(NewQuerySession(context.Background(), 9), queryBatcher, "LAV1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), response.Spec.BlockLastUpdated)
+	_, err = querySpec(context.Background(), queryBatcher, "ETH1")
+	require.NoError(t, err)
+	require.Len(t, abciClient.queried(), 3)
+
+	// a new block drops the shared results, the same block reported again doesn't
+	require.NoError(t, queryBatcher.Update(10))
+	_, err = querySpec(context.Background(), queryBatcher, "LAV1")
+	require.NoError(t, err)
+	require.Len(t, abciClient.queried(), 3)
+	require.NoError(t, queryBatcher.Update(11))
+	_, err = querySpec(context.Background(), queryBatcher, "LAV1")
+	require.NoError(t, err)
+	require.Len(t, abciClient.queried(), 4)
+	queryBatcher.Reset()
+	_, err = querySpec(context.Background(), queryBatcher, "LAV1")
+	require.NoError(t, err)
+	require.Len(t, abciClient.queried(), 5)
+}
+
+func TestQueryBatcherDoesNotShareFailures(t *testing.T) {
+	abciClient := &fakeABCIClient{fail: true}
+	queryBatcher := NewQueryBatcher(client.Context{}.WithClient(abciClient), time.Millisecond)
+	_, err := querySpec(context.Background(), queryBatcher, "LAV1")
+	require.Error(t, err)
+	abciClient.lock.Lock()
+	abciClient.fail = false
+	abciClient.lock.Unlock()
+	// the failed query is sent again
+	response, err := querySpec(context.Background(), queryBatcher, "LAV1")
+	require.NoError(t, err)
+	require.Equal(t, "LAV1", response.Spec.Index)
+	require.Len(t, abciClient.queried(), 2)
+
+	// a waiter whose context is done stops waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = querySpec(ctx, NewQueryBatcher(client.Context{}.WithClient(abciClient), time.Hour), "ETH1")
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,136 @@
+package statetracker
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	CallbackKeyForQueryCache     = "query-cache"
+	DefaultQueryCacheMaxEntries  = 10000
+	DefaultQueryCacheExpiration  = 30 * time.Minute
+	queryCacheLatestHeight       = 0 // the height of queries on the latest state
+	queryCacheHitLabel           = "hit"
+	queryCacheMissLabel          = "miss"
+	queryCacheInvalidationsLabel = "invalidated"
+)
+
+var queryCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lava_state_query_cache_total",
+	Help: "State query cache lookups by query and result, and entries invalidated by new lava blocks",
+}, []string{"query", "result"})
+
+func init() {
+	prometheus.MustRegister(queryCacheLookups)
+}
+
+type queryCacheKey struct {
+	query   string
+	request string
+	height  int64
+}
+
+type queryCacheEntry struct {
+	key             queryCacheKey
+	value           interface{}
+	expiry          time.Time
+	heightSensitive bool
+}
+
+// QueryCache is an lru cache with expiration for state query responses keyed by query, request and height.
+// responses of a specific height never change and only expire, height sensitive responses read the latest state and are dropped on every new lava block
+type QueryCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	expiration time.Duration
+	entries    map[queryCacheKey]*list.Element
+	lru        *list.List // front is the most recently used
+	block      int64
+}
+
+func NewQueryCache(maxEntries int, expiration time.Duration) *QueryCache {
+	return &QueryCache{maxEntries: maxEntries, expiration: expiration, entries: map[queryCacheKey]*list.Element{}, lru: list.New()}
+}
+
+func (qc *QueryCache) Get(query string, request string, height int64) (value interface{}, found bool) {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	key := queryCacheKey{query: query, request: request, height: height}
+	element, ok := qc.entries[key]
+	if ok && time.Now().After(element.Value.(*queryCacheEntry).expiry) {
+		qc.removeUnsafe(element)
+		ok = false
+	}
+	if !ok {
+		queryCacheLookups.WithLabelValues(query, queryCacheMissLabel).Inc()
+		return nil, false
+	}
+	queryCacheLookups.WithLabelValues(query, queryCacheHitLabel).Inc()
+	qc.lru.MoveToFront(element)
+	return element.Value.(*queryCacheEntry).value, true
+}
+
+// Set caches value, heightSensitive values are dropped on the next lava block
+func (qc *QueryCache) Set(query string, request string, height int64, value interface{}, heightSensitive bool) {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	key := queryCacheKey{query: query, request: request, height: height}
+	entry := &queryCacheEntry{key: key, value: value, expiry: time.Now().Add(qc.expiration), heightSensitive: heightSensitive}
+	if element, ok := qc.entries[key]; ok {
+		element.Value = entry
+		qc.lru.MoveToFront(element)
+		return
+	}
+	qc.entries[key] = qc.lru.PushFront(entry)
+	for qc.lru.Len() > qc.maxEntries {
+		qc.removeUnsafe(qc.lru.Back())
+	}
+}
+
+func (qc *QueryCache) Clear() {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	qc.entries = map[queryCacheKey]*list.Element{}
+	qc.lru.Init()
+}
+
+func (qc *QueryCache) Len() int {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	return qc.lru.Len()
+}
+
+// height sensitive entries are dropped before the updaters query this block
+func (qc *QueryCache) UpdatePriority() int {
+	return UpdatePriorityHigh - 1
+}
+
+func (qc *QueryCache) UpdaterKey() string {
+	return CallbackKeyForQueryCache
+}
+
+func (qc *QueryCache) Update(latestBlock int64) error {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+	if latestBlock == qc.block {
+		return nil
+	}
+	qc.block = latestBlock
+	for element := qc.lru.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(*queryCacheEntry); entry.heightSensitive {
+			queryCacheLookups.WithLabelValues(entry.key.query, queryCacheInvalidationsLabel).Inc()
+			qc.removeUnsafe(element)
+		}
+		element = next
+	}
+	return nil
+}
+
+func (qc *QueryCache) removeUnsafe(element *list.Element) {
+	delete(qc.entries, element.Value.(*queryCacheEntry).key)
+	qc.lru.Remove(element)
+}
@@ -0,0 +1,69 @@
+package statetracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCacheHeights(t *testing.T) {
+	queryCache := NewQueryCache(DefaultQueryCacheMaxEntries, DefaultQueryCacheExpiration)
+	queryCache.Set("spec", "LAV1", 10, "spec at 10", false)
+	queryCache.Set("spec", "LAV1", queryCacheLatestHeight, "latest spec", true)
+
+	value, found := queryCache.Get("spec", "LAV1", 10)
+	require.True(t, found)
+	require.Equal(t, "spec at 10", value)
+	_, found = queryCache.Get("spec", "LAV1", 11)
+	require.False(t, found)
+	_, found = queryCache.Get("spec", "ETH1", 10)
+	require.False(t, found)
+
+	// a new block drops the responses of the latest state, the responses of a height stay
+	require.NoError(t, queryCache.Update(20))
+	_, found = queryCache.Get("spec", "LAV1", queryCacheLatestHeight)
+	require.False(t, found)
+	_, found = queryCache.Get("spec", "LAV1", 10)
+	require.True(t, found)
+
+	// the same block reported again doesn't drop them
+	queryCache.Set("spec", "LAV1", queryCacheLatestHeight, "latest spec", true)
+	require.NoError(t, queryCache.Update(20))
+	_, found = queryCache.Get("spec", "LAV1", queryCacheLatestHeight)
+	require.True(t, found)
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	queryCache := NewQueryCache(2, DefaultQueryCacheExpiration)
+	queryCache.Set("spec", "a", 1, "a", false)
+	queryCache.Set("spec", "b", 1, "b", false)
+	// a is used, so b is the least recently used entry when c is added
+	_, found := queryCache.Get("spec", "a", 1)
+	require.True(t, found)
+	queryCache.Set("spec", "c", 1, "c", false)
+	require.Equal(t, 2, queryCache.Len())
+	_, found = queryCache.Get("spec", "b", 1)
+	require.False(t, found)
+	_, found = queryCache.Get("spec", "a", 1)
+	require.True(t, found)
+
+	// setting a cached key again replaces its value without adding an entry
+	queryCache.Set("spec", "c", 1, "c2", false)
+	value, found := queryCache.Get("spec", "c", 1)
+	require.True(t, found)
+	require.Equal(t, "c2", value)
+	require.Equal(t, 2, queryCache.Len())
+
+	queryCache.Clear()
+	require.Equal(t, 0, queryCache.Len())
+}
+
+func TestQueryCacheExpiration(t *testing.T) {
+	queryCache := NewQueryCache(DefaultQueryCacheMaxEntries, time.Millisecond)
+	queryCache.Set("spec", "LAV1", 10, "spec at 10", false)
+	time.Sleep(5 * time.Millisecond)
+	_, found := queryCache.Get("spec", "LAV1", 10)
+	require.False(t, found)
+	require.Equal(t, 0, queryCache.Len())
+}
@@ -0,0 +1,51 @@
+package statetracker
+
+import (
+	"context"
+	"strconv"
+
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// NewQuerySession returns a context that pins the state queries made with it to the lava block height, an updater opens one per Update
+// so the queries of the cycle read the same state even if the lava node commits a block in between
+func NewQuerySession(ctx context.Context, height int64) context.Context {
+	if height <= 0 {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	// the same header the cosmos client context reads, so queries that aren't batched are pinned too
+	md.Set(grpctypes.GRPCBlockHeightHeader, strconv.FormatInt(height, 10))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// QuerySessionHeight returns the height the context is pinned to, pinned is false for queries on the latest state
+func QuerySessionHeight(ctx context.Context) (height int64, pinned bool) {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	heights := md.Get(grpctypes.GRPCBlockHeightHeader)
+	if len(heights) == 0 {
+		return 0, false
+	}
+	height, err := strconv.ParseInt(heights[0], 10, 64)
+	if err != nil || height <= 0 {
+		return 0, false
+	}
+	return height, true
+}
+
+// queryCacheHeight is the height to cache a response of the latest state at, responses of a pinned session never change
+func queryCacheHeight(ctx context.Context) (height int64, heightSensitive bool) {
+	if height, pinned := QuerySessionHeight(ctx); pinned {
+		return height, false
+	}
+	return queryCacheLatestHeight, true
+}
@@ -0,0 +1,66 @@
+package statetracker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestQuerySessionHeight(t *testing.T) {
+	_, pinned := QuerySessionHeight(context.Background())
+	require.False(t, pinned)
+	// a session of no height queries the latest state
+	_, pinned = QuerySessionHeight(NewQuerySession(context.Background(), 0))
+	require.False(t, pinned)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "other", "header")
+	session := NewQuerySession(ctx, 10)
+	height, pinned := QuerySessionHeight(session)
+	require.True(t, pinned)
+	require.Equal(t, int64(10), height)
+	// the other headers are kept, and the context the session was opened from isn't changed
+	md, _ := metadata.FromOutgoingContext(session)
+	require.Equal(t, []string{"header"}, md.Get("other"))
+	require.Equal(t, []string{"10"}, md.Get(grpctypes.GRPCBlockHeightHeader))
+	_, pinned = QuerySessionHeight(ctx)
+	require.False(t, pinned)
+	// a session opened from a session moves to the new height
+	height, _ = QuerySessionHeight(NewQuerySession(session, 11))
+	require.Equal(t, int64(11), height)
+
+	cacheHeight, heightSensitive := queryCacheHeight(session)
+	require.Equal(t, int64(10), cacheHeight)
+	require.False(t, heightSensitive)
+	cacheHeight, heightSensitive = queryCacheHeight(context.Background())
+	require.Equal(t, int64(queryCacheLatestHeight), cacheHeight)
+	require.True(t, heightSensitive)
+}
+
+func TestStateQueryPinnedSession(t *testing.T) {
+	abciClient := &fakeABCIClient{}
+	stateQuery := NewStateQuery(context.Background(), client.Context{}.WithClient(abciClient))
+	spec, err := stateQuery.GetSpec(NewQuerySession(context.Background(), 9), "LAV1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), spec.BlockLastUpdated)
+	spec, err = stateQuery.GetSpec(context.Background(), "LAV1")
+	require.NoError(t, err)
+	require.Zero(t, spec.BlockLastUpdated)
+	queried := abciClient.queried()
+	require.Len(t, queried, 2)
+	require.Equal(t, int64(9), queried[0].Height)
+	require.Zero(t, queried[1].Height)
+
+	// a new block drops the latest state response, the pinned one never changes
+	require.NoError(t, stateQuery.ResponsesCache.Update(10))
+	require.NoError(t, stateQuery.queryBatcher.Update(10))
+	_, err = stateQuery.GetSpec(NewQuerySession(context.Background(), 9), "LAV1")
+	require.NoError(t, err)
+	require.Len(t, abciClient.queried(), 2)
+	_, err = stateQuery.GetSpec(context.Background(), "LAV1")
+	require.NoError(t, err)
+	require.Len(t, abciClient.queried(), 3)
+}
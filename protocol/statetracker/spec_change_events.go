@@ -0,0 +1,75 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	abcitypes "github.com/tendermint/tendermint/abci/types"
+)
+
+var specChangeEventTypes = map[string]struct{}{
+	utils.EventPrefix + spectypes.SpecAddEventName:     {},
+	utils.EventPrefix + spectypes.SpecModifyEventName:  {},
+	utils.EventPrefix + spectypes.ParamChangeEventName: {},
+}
+
+// SpecChangeEvents are the spec proposals and spec param changes accepted in a block
+type SpecChangeEvents struct {
+	Block         int64
+	ChainIDs      map[string]struct{}
+	ParamsChanged bool
+}
+
+// any change refreshes every spec, a spec can import the changed one and param changes aren't per chain
+func (sce *SpecChangeEvents) SpecsChanged() bool {
+	return sce.ParamsChanged || len(sce.ChainIDs) > 0
+}
+
+// specChangeEventsReader reads the block results once per block for the spec updaters of all chains
+type specChangeEventsReader struct {
+	lock       sync.Mutex
+	clientCtx  client.Context
+	lastEvents *SpecChangeEvents
+}
+
+func (scer *specChangeEventsReader) read(ctx context.Context, block int64) (*SpecChangeEvents, error) {
+	scer.lock.Lock()
+	defer scer.lock.Unlock()
+	if scer.lastEvents != nil && scer.lastEvents.Block == block {
+		return scer.lastEvents, nil
+	}
+	blockResults, err := scer.clientCtx.Client.BlockResults(ctx, &block)
+	if err != nil {
+		return nil, err
+	}
+	specChangeEvents := &SpecChangeEvents{Block: block, ChainIDs: map[string]struct{}{}}
+	// proposals are executed in the gov end blocker, transactions are read as well
+	events := append([]abcitypes.Event{}, blockResults.EndBlockEvents...)
+	for _, tx := range blockResults.TxsResults {
+		events = append(events, tx.Events...)
+	}
+	for _, event := range events {
+		if _, ok := specChangeEventTypes[event.Type]; !ok {
+			continue
+		}
+		if event.Type == utils.EventPrefix+spectypes.ParamChangeEventName {
+			specChangeEvents.ParamsChanged = true
+			continue
+		}
+		for _, attribute := range event.Attributes {
+			if string(attribute.Key) == "chainID" {
+				specChangeEvents.ChainIDs[string(attribute.Value)] = struct{}{}
+			}
+		}
+	}
+	scer.lastEvents = specChangeEvents
+	return specChangeEvents, nil
+}
+
+// SpecChangeEvents returns the spec changes of block, spec updaters query the spec only when there are
+func (csq *StateQuery) SpecChangeEvents(ctx context.Context, block int64) (*SpecChangeEvents, error) {
+	return csq.specChangeEvents.read(ctx, block)
+}
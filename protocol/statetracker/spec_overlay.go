@@ -0,0 +1,131 @@
+package statetracker
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	SpecOverlayFlagName = "spec-overlay"
+)
+
+var specOverlayActiveGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lava_spec_overlay_active",
+	Help: "Set to 1 for chains served with a locally modified spec",
+}, []string{"spec", "baseSpec"})
+
+func init() {
+	prometheus.MustRegister(specOverlayActiveGauge)
+}
+
+// SpecOverlay modifies an on chain spec locally, it is meant for devnets and forks testing and is refused on mainnet lava networks.
+// the file format follows the cookbook specs:
+//
+//	{"overlays": [{"chain_id": "ETH1-DEVNET", "base_spec": "ETH1", "block_distance_for_finalized_data": 1, "apis": [...]}]}
+type SpecOverlay struct {
+	ChainID                       string                 `json:"chain_id"`            // the chain id this overlay is served as
+	BaseSpec                      string                 `json:"base_spec,omitempty"` // the on chain spec to modify, defaults to ChainID
+	DataReliabilityEnabled        *bool                  `json:"data_reliability_enabled,omitempty"`
+	BlockDistanceForFinalizedData *uint32                `json:"block_distance_for_finalized_data,omitempty"`
+	BlocksInFinalizationProof     *uint32                `json:"blocks_in_finalization_proof,omitempty"`
+	AverageBlockTime              *int64                 `json:"average_block_time,omitempty"`
+	AllowedBlockLagForQosSync     *int64                 `json:"allowed_block_lag_for_qos_sync,omitempty"`
+	Apis                          []spectypes.ServiceApi `json:"apis,omitempty"` // added to the spec, replacing apis with the same name
+}
+
+type SpecOverlaysJSON struct {
+	Overlays []SpecOverlay `json:"overlays"`
+}
+
+func (so *SpecOverlay) baseSpec() string {
+	if so.BaseSpec == "" {
+		return so.ChainID
+	}
+	return so.BaseSpec
+}
+
+// Apply returns a modified copy of the spec
+func (so *SpecOverlay) Apply(baseSpec spectypes.Spec) spectypes.Spec {
+	spec := baseSpec
+	spec.Index = so.ChainID
+	if so.DataReliabilityEnabled != nil {
+		spec.DataReliabilityEnabled = *so.DataReliabilityEnabled
+	}
+	if so.BlockDistanceForFinalizedData != nil {
+		spec.BlockDistanceForFinalizedData = *so.BlockDistanceForFinalizedData
+	}
+	if so.BlocksInFinalizationProof != nil {
+		spec.BlocksInFinalizationProof = *so.BlocksInFinalizationProof
+	}
+	if so.AverageBlockTime != nil {
+		spec.AverageBlockTime = *so.AverageBlockTime
+	}
+	if so.AllowedBlockLagForQosSync != nil {
+		spec.AllowedBlockLagForQosSync = *so.AllowedBlockLagForQosSync
+	}
+	if len(so.Apis) > 0 {
+		apis := make([]spectypes.ServiceApi, 0, len(baseSpec.Apis)+len(so.Apis))
+		overlayApis := map[string]struct{}{}
+		for _, api := range so.Apis {
+			overlayApis[api.Name] = struct{}{}
+		}
+		for _, api := range baseSpec.Apis {
+			if _, ok := overlayApis[api.Name]; !ok {
+				apis = append(apis, api)
+			}
+		}
+		spec.Apis = append(apis, so.Apis...)
+	}
+	return spec
+}
+
+// MainnetChainIDs are the lava networks spec overlays are refused on
+var MainnetChainIDs = map[string]struct{}{
+	"lava-mainnet-1": {},
+}
+
+func IsMainnetChainID(lavaChainID string) bool {
+	_, ok := MainnetChainIDs[lavaChainID]
+	return ok
+}
+
+func parseSpecOverlays(contents []byte) ([]SpecOverlay, error) {
+	overlaysJSON := SpecOverlaysJSON{}
+	err := json.Unmarshal(contents, &overlaysJSON)
+	if err != nil {
+		return nil, err
+	}
+	return overlaysJSON.Overlays, nil
+}
+
+// ReadSpecOverlays loads overlays from a json file, overlays are refused when connected to a mainnet lava chain
+func ReadSpecOverlays(overlayFile string, lavaChainID string) (map[string]*SpecOverlay, error) {
+	if overlayFile == "" {
+		return nil, nil
+	}
+	if IsMainnetChainID(lavaChainID) {
+		return nil, utils.LavaFormatError("spec overlays are disabled on mainnet", nil, utils.Attribute{Key: "lavaChainID", Value: lavaChainID}, utils.Attribute{Key: "file", Value: overlayFile})
+	}
+	contents, err := os.ReadFile(overlayFile)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed reading spec overlay file", err, utils.Attribute{Key: "file", Value: overlayFile})
+	}
+	parsedOverlays, err := parseSpecOverlays(contents)
+	if err != nil {
+		return nil, utils.LavaFormatError("failed parsing spec overlay file", err, utils.Attribute{Key: "file", Value: overlayFile})
+	}
+	overlays := make(map[string]*SpecOverlay, len(parsedOverlays))
+	for idx := range parsedOverlays {
+		overlay := &parsedOverlays[idx]
+		if overlay.ChainID == "" {
+			return nil, utils.LavaFormatError("spec overlay is missing a chain id", nil, utils.Attribute{Key: "file", Value: overlayFile}, utils.Attribute{Key: "index", Value: idx})
+		}
+		overlays[overlay.ChainID] = overlay
+		utils.LavaFormatWarning("loaded spec overlay, chain will be served with a locally modified spec", nil, utils.Attribute{Key: "chainID", Value: overlay.ChainID}, utils.Attribute{Key: "baseSpec", Value: overlay.baseSpec()})
+	}
+	return overlays, nil
+}
@@ -0,0 +1,91 @@
+package statetracker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpecOverlayJSON = `{"overlays": [{
+	"chain_id": "ETH1-DEVNET",
+	"base_spec": "ETH1",
+	"block_distance_for_finalized_data": 1,
+	"average_block_time": 2000,
+	"apis": [{"name": "eth_blockNumber", "compute_units": 20, "enabled": true,
+		"block_parsing": {"parser_arg": ["latest"], "parser_func": "DEFAULT"},
+		"api_interfaces": [{"interface": "jsonrpc", "type": "POST"}]}]
+}]}`
+
+func writeSpecOverlayFile(t *testing.T, contents string) string {
+	overlayFile := filepath.Join(t.TempDir(), "overlays.json")
+	require.NoError(t, os.WriteFile(overlayFile, []byte(contents), 0o600))
+	return overlayFile
+}
+
+func TestParseSpecOverlays(t *testing.T) {
+	overlays, err := parseSpecOverlays([]byte(testSpecOverlayJSON))
+	require.NoError(t, err)
+	require.Len(t, overlays, 1)
+	overlay := overlays[0]
+	require.Equal(t, "ETH1-DEVNET", overlay.ChainID)
+	require.Equal(t, "ETH1", overlay.baseSpec())
+	require.Equal(t, uint32(1), *overlay.BlockDistanceForFinalizedData)
+	require.Equal(t, int64(2000), *overlay.AverageBlockTime)
+	require.Nil(t, overlay.DataReliabilityEnabled)
+	// apis use the cookbook format, enums are names
+	require.Len(t, overlay.Apis, 1)
+	require.Equal(t, uint64(20), overlay.Apis[0].ComputeUnits)
+	require.Equal(t, spectypes.PARSER_FUNC_DEFAULT, overlay.Apis[0].BlockParsing.ParserFunc)
+	require.Equal(t, "jsonrpc", overlay.Apis[0].ApiInterfaces[0].Interface)
+
+	_, err = parseSpecOverlays([]byte(`{"overlays": [{"chain_id": 1}]}`))
+	require.Error(t, err)
+}
+
+func TestSpecOverlayApply(t *testing.T) {
+	overlays, err := parseSpecOverlays([]byte(testSpecOverlayJSON))
+	require.NoError(t, err)
+	baseSpec := spectypes.Spec{Index: "ETH1", BlockDistanceForFinalizedData: 7, BlocksInFinalizationProof: 3, Apis: []spectypes.ServiceApi{
+		{Name: "eth_blockNumber", ComputeUnits: 10},
+		{Name: "eth_getBalance", ComputeUnits: 10},
+	}}
+	spec := overlays[0].Apply(baseSpec)
+	require.Equal(t, "ETH1-DEVNET", spec.Index)
+	require.Equal(t, uint32(1), spec.BlockDistanceForFinalizedData)
+	require.Equal(t, uint32(3), spec.BlocksInFinalizationProof)
+	require.Len(t, spec.Apis, 2)
+	require.Equal(t, "eth_getBalance", spec.Apis[0].Name)
+	require.Equal(t, uint64(20), spec.Apis[1].ComputeUnits)
+	// the base spec isn't modified
+	require.Equal(t, "ETH1", baseSpec.Index)
+	require.Equal(t, uint64(10), baseSpec.Apis[0].ComputeUnits)
+}
+
+func TestReadSpecOverlaysMainnetGuard(t *testing.T) {
+	overlayFile := writeSpecOverlayFile(t, testSpecOverlayJSON)
+	_, err := ReadSpecOverlays(overlayFile, "lava-mainnet-1")
+	require.Error(t, err)
+
+	// only the listed chain ids are guarded, not every chain id mentioning mainnet
+	for _, lavaChainID := range []string{"lava-testnet-2", "my-mainnet-fork"} {
+		overlays, err := ReadSpecOverlays(overlayFile, lavaChainID)
+		require.NoError(t, err)
+		require.Contains(t, overlays, "ETH1-DEVNET")
+	}
+
+	overlays, err := ReadSpecOverlays("", "lava-mainnet-1")
+	require.NoError(t, err)
+	require.Nil(t, overlays)
+}
+
+func TestReadSpecOverlaysErrors(t *testing.T) {
+	_, err := ReadSpecOverlays(filepath.Join(t.TempDir(), "missing.json"), "lava-testnet-2")
+	require.Error(t, err)
+	_, err = ReadSpecOverlays(writeSpecOverlayFile(t, "overlays"), "lava-testnet-2")
+	require.Error(t, err)
+	_, err = ReadSpecOverlays(writeSpecOverlayFile(t, `{"overlays": [{"base_spec": "ETH1"}]}`), "lava-testnet-2")
+	require.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package statetracker
+
+import (
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	CallbackKeyForSpecUpdate  = "spec-update-"
+	SpecModifyEventType       = utils.EventPrefix + spectypes.SpecModifyEventName
+	SpecRefreshFallbackBlocks = 100 // the spec is queried at least this often, in case a change event was missed
+)
+
+type SpecUpdatable interface {
+	SetSpec(spec spectypes.Spec)
+}
+
+// SpecUpdater passes the spec of a chain to the registered updatables when governance changed it, so parsers and trackers follow spec proposals without a restart.
+// the spec is queried on blocks with spec proposal or param change events, and every SpecRefreshFallbackBlocks
+type SpecUpdater struct {
+	lock             sync.RWMutex
+	chainID          string
+	blockLastUpdated uint64
+	lastQueriedBlock int64
+	refreshPending   bool // a change was seen but the spec query failed
+	specUpdatables   *UpdatableRegistry[SpecUpdatable]
+	stateQuery       StateQueryInf
+}
+
+func NewSpecUpdater(chainID string, stateQuery StateQueryInf) *SpecUpdater {
+	return &SpecUpdater{chainID: chainID, specUpdatables: NewUpdatableRegistry[SpecUpdatable](CallbackKeyForSpecUpdate + chainID), stateQuery: stateQuery}
+}
+
+// RegisterSpecUpdatable sets the current spec on specUpdatable before registering it
+func (su *SpecUpdater) RegisterSpecUpdatable(ctx context.Context, specUpdatable SpecUpdatable) error {
+	err := su.setCurrentSpec(ctx, specUpdatable)
+	if err != nil {
+		return err
+	}
+	su.specUpdatables.RegisterUnique(specUpdatable, UpdateInterest{Kind: InterestEvent, EventType: SpecModifyEventType})
+	return nil
+}
+
+// UnregisterSpecUpdatable returns false if specUpdatable isn't registered, updatables are compared by value so they should be pointers
+func (su *SpecUpdater) UnregisterSpecUpdatable(specUpdatable SpecUpdatable) bool {
+	return su.specUpdatables.UnregisterMatching(specUpdatable)
+}
+
+// ReplaceSpecUpdatable sets the current spec on newSpecUpdatable before it takes the place of oldSpecUpdatable
+func (su *SpecUpdater) ReplaceSpecUpdatable(ctx context.Context, oldSpecUpdatable SpecUpdatable, newSpecUpdatable SpecUpdatable) error {
+	err := su.setCurrentSpec(ctx, newSpecUpdatable)
+	if err != nil {
+		return err
+	}
+	return su.specUpdatables.ReplaceMatching(oldSpecUpdatable, newSpecUpdatable)
+}
+
+func (su *SpecUpdater) setCurrentSpec(ctx context.Context, specUpdatable SpecUpdatable) error {
+	spec, err := su.stateQuery.GetSpec(ctx, su.chainID)
+	if err != nil {
+		return err
+	}
+	su.lock.Lock()
+	defer su.lock.Unlock()
+	if spec.BlockLastUpdated > su.blockLastUpdated {
+		su.blockLastUpdated = spec.BlockLastUpdated
+	}
+	specUpdatable.SetSpec(*spec)
+	return nil
+}
+
+func (su *SpecUpdater) RegisteredUpdatables() []string {
+	return su.specUpdatables.Describe()
+}
+
+// the spec is updated before the updaters that depend on it
+func (su *SpecUpdater) UpdatePriority() int {
+	return UpdatePriorityHigh
+}
+
+func (su *SpecUpdater) UpdaterKey() string {
+	return CallbackKeyForSpecUpdate + su.chainID
+}
+
+func (su *SpecUpdater) Update(latestBlock int64) error {
+	ctx := context.Background()
+	if !su.shouldQuerySpec(ctx, latestBlock) {
+		return nil
+	}
+	spec, err := su.stateQuery.GetSpec(ctx, su.chainID)
+	su.lock.Lock()
+	defer su.lock.Unlock()
+	if err != nil {
+		su.refreshPending = true
+		return err // failed to get the spec, trying again on the next block
+	}
+	su.refreshPending = false
+	su.lastQueriedBlock = latestBlock
+	if spec.BlockLastUpdated <= su.blockLastUpdated {
+		return nil // the spec didn't change
+	}
+	utils.LavaFormatInfo("spec updated, applying the new spec", utils.Attribute{Key: "chainID", Value: su.chainID}, utils.Attribute{Key: "blockLastUpdated", Value: spec.BlockLastUpdated}, utils.Attribute{Key: "latestBlock", Value: latestBlock})
+	if !spec.Enabled {
+		utils.LavaFormatWarning("spec was disabled by governance, relays for the chain won't be paid", nil, utils.Attribute{Key: "chainID", Value: su.chainID})
+	}
+	su.blockLastUpdated = spec.BlockLastUpdated
+	su.specUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, EventTypes: map[string]struct{}{SpecModifyEventType: {}}}, func(_ string, specUpdatable SpecUpdatable) {
+		specUpdatable.SetSpec(*spec)
+	})
+	return nil
+}
+
+// every change refreshes the spec since a spec can import the changed one. when the block events can't be read the spec is queried like on a change
+func (su *SpecUpdater) shouldQuerySpec(ctx context.Context, latestBlock int64) bool {
+	su.lock.RLock()
+	refreshPending, lastQueriedBlock := su.refreshPending, su.lastQueriedBlock
+	su.lock.RUnlock()
+	if refreshPending || latestBlock-lastQueriedBlock >= SpecRefreshFallbackBlocks {
+		return true
+	}
+	specChangeEvents, err := su.stateQuery.SpecChangeEvents(ctx, latestBlock)
+	if err != nil {
+		utils.LavaFormatDebug("failed reading spec change events, querying the spec", utils.Attribute{Key: "chainID", Value: su.chainID}, utils.Attribute{Key: "block", Value: latestBlock}, utils.Attribute{Key: "error", Value: err})
+		return true
+	}
+	return specChangeEvents.SpecsChanged()
+}
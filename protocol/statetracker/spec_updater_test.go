@@ -0,0 +1,94 @@
+package statetracker
+
+import (
+	"context"
+	"testing"
+
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSpecUpdatable struct {
+	specs []spectypes.Spec
+}
+
+func (rsu *recordingSpecUpdatable) SetSpec(spec spectypes.Spec) {
+	rsu.specs = append(rsu.specs, spec)
+}
+
+func TestSpecUpdaterRefreshesOnChanges(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Specs["LAV1"] = &spectypes.Spec{Index: "LAV1", BlockLastUpdated: 5, Enabled: true}
+	specUpdater := NewSpecUpdater("LAV1", stateQuery)
+	updatable := &recordingSpecUpdatable{}
+	// the current spec is set on registration
+	require.NoError(t, specUpdater.RegisterSpecUpdatable(context.Background(), updatable))
+	require.Len(t, updatable.specs, 1)
+
+	// blocks without spec changes don't query the spec
+	require.NoError(t, specUpdater.Update(8))
+	require.NoError(t, specUpdater.Update(9))
+	require.Equal(t, 1, stateQuery.Calls("GetSpec"))
+
+	// a proposal of another chain refreshes the spec, it can be imported
+	stateQuery.Specs["LAV1"] = &spectypes.Spec{Index: "LAV1", BlockLastUpdated: 10, Enabled: true}
+	stateQuery.SpecChanges[10] = &SpecChangeEvents{Block: 10, ChainIDs: map[string]struct{}{"ETH1": {}}}
+	require.NoError(t, specUpdater.Update(10))
+	require.Len(t, updatable.specs, 2)
+	require.Equal(t, uint64(10), updatable.specs[1].BlockLastUpdated)
+
+	// a param change the spec didn't change in isn't dispatched
+	stateQuery.SpecChanges[11] = &SpecChangeEvents{Block: 11, ParamsChanged: true}
+	require.NoError(t, specUpdater.Update(11))
+	require.Equal(t, 3, stateQuery.Calls("GetSpec"))
+	require.Len(t, updatable.specs, 2)
+}
+
+func TestSpecUpdaterRetriesFailedRefresh(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Specs["LAV1"] = &spectypes.Spec{Index: "LAV1", BlockLastUpdated: 5}
+	specUpdater := NewSpecUpdater("LAV1", stateQuery)
+	updatable := &recordingSpecUpdatable{}
+	require.NoError(t, specUpdater.RegisterSpecUpdatable(context.Background(), updatable))
+
+	stateQuery.Specs["LAV1"] = &spectypes.Spec{Index: "LAV1", BlockLastUpdated: 10}
+	stateQuery.SpecChanges[10] = &SpecChangeEvents{Block: 10, ChainIDs: map[string]struct{}{"LAV1": {}}}
+	stateQuery.FailNext("GetSpec", errNodeUnavailable)
+	require.ErrorIs(t, specUpdater.Update(10), errNodeUnavailable)
+	// the failed refresh is retried on the next block without a change event
+	require.NoError(t, specUpdater.Update(11))
+	require.Len(t, updatable.specs, 2)
+
+	// block events that can't be read are treated as a change
+	stateQuery.FailNext("SpecChangeEvents", errNodeUnavailable)
+	calls := stateQuery.Calls("GetSpec")
+	require.NoError(t, specUpdater.Update(12))
+	require.Equal(t, calls+1, stateQuery.Calls("GetSpec"))
+
+	// the spec is queried every SpecRefreshFallbackBlocks in case a change was missed
+	require.NoError(t, specUpdater.Update(12+SpecRefreshFallbackBlocks-1))
+	require.Equal(t, calls+1, stateQuery.Calls("GetSpec"))
+	require.NoError(t, specUpdater.Update(12+SpecRefreshFallbackBlocks))
+	require.Equal(t, calls+2, stateQuery.Calls("GetSpec"))
+}
+
+func TestSpecUpdaterReplace(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Specs["LAV1"] = &spectypes.Spec{Index: "LAV1", BlockLastUpdated: 5}
+	specUpdater := NewSpecUpdater("LAV1", stateQuery)
+	old, replacement := &recordingSpecUpdatable{}, &recordingSpecUpdatable{}
+	require.NoError(t, specUpdater.RegisterSpecUpdatable(context.Background(), old))
+	// the replacement gets the current spec before it's registered
+	require.NoError(t, specUpdater.ReplaceSpecUpdatable(context.Background(), old, replacement))
+	require.Len(t, replacement.specs, 1)
+	require.False(t, specUpdater.UnregisterSpecUpdatable(old))
+	require.True(t, specUpdater.UnregisterSpecUpdatable(replacement))
+	// a spec that can't be queried isn't registered
+	require.Error(t, NewSpecUpdater("ETH1", stateQuery).RegisterSpecUpdatable(context.Background(), &recordingSpecUpdatable{}))
+}
+
+func TestSpecChangeEvents(t *testing.T) {
+	require.False(t, (&SpecChangeEvents{ChainIDs: map[string]struct{}{}}).SpecsChanged())
+	require.True(t, (&SpecChangeEvents{ParamsChanged: true}).SpecsChanged())
+	require.True(t, (&SpecChangeEvents{ChainIDs: map[string]struct{}{"LAV1": {}}}).SpecsChanged())
+}
@@ -0,0 +1,166 @@
+package statetracker
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+)
+
+const (
+	CallbackKeyForStakeStatusUpdate = "stake-status-update"
+	StakeStatusQueryBlocks          = 10 // the stake entries are queried every this many blocks
+)
+
+// StakeStatus is the state of the provider's stake entry on a chain
+type StakeStatus int
+
+const (
+	StakeStatusUnknown  StakeStatus = iota // not queried yet
+	StakeStatusActive                      // staked and in the pairing
+	StakeStatusPending                     // staked, enters the pairing once the stake applies
+	StakeStatusFrozen                      // frozen, out of the pairing until unfrozen
+	StakeStatusUnstaked                    // not staked, jailed providers are unstaked
+)
+
+func (ss StakeStatus) String() string {
+	switch ss {
+	case StakeStatusActive:
+		return "active"
+	case StakeStatusPending:
+		return "pending"
+	case StakeStatusFrozen:
+		return "frozen"
+	case StakeStatusUnstaked:
+		return "unstaked"
+	default:
+		return "unknown"
+	}
+}
+
+// StakeStatusEvent is a change of the provider's stake status on a chain
+type StakeStatusEvent struct {
+	ChainID           string
+	Provider          string
+	Status            StakeStatus
+	PreviousStatus    StakeStatus // unknown on the first status, which is only dispatched when it isn't active
+	StakeAppliedBlock uint64
+	Block             int64
+}
+
+type StakeStatusUpdatable interface {
+	StakeStatusChanged(event StakeStatusEvent)
+}
+
+// StakeStatusUpdater monitors the provider's own stake entries on the registered chains, so freezing and jailing are handled when
+// they happen instead of being discovered by failing relays
+type StakeStatusUpdater struct {
+	lock                  sync.RWMutex
+	stakeStatusUpdatables *UpdatableRegistry[StakeStatusUpdatable] // the interest event type is the chain id
+	stateQuery            ProviderStateQueryInf
+	providerAddress       string
+	chainIDs              map[string]struct{}
+	statuses              map[string]StakeStatus // key is the chain id
+	nextBlockForUpdate    int64
+}
+
+func NewStakeStatusUpdater(stateQuery ProviderStateQueryInf, providerAddress string) *StakeStatusUpdater {
+	return &StakeStatusUpdater{stakeStatusUpdatables: NewUpdatableRegistry[StakeStatusUpdatable](CallbackKeyForStakeStatusUpdate), stateQuery: stateQuery, providerAddress: providerAddress, chainIDs: map[string]struct{}{}, statuses: map[string]StakeStatus{}}
+}
+
+// RegisterStakeStatusUpdatable calls stakeStatusUpdatable on every stake status change of the chain, a known status that isn't active is passed to it right away
+func (ssu *StakeStatusUpdater) RegisterStakeStatusUpdatable(ctx context.Context, stakeStatusUpdatable StakeStatusUpdatable, chainID string) {
+	ssu.lock.Lock()
+	_, knownChain := ssu.chainIDs[chainID]
+	if !knownChain {
+		ssu.chainIDs[chainID] = struct{}{}
+		ssu.nextBlockForUpdate = 0 // query the new chain on the next block
+	}
+	status := ssu.statuses[chainID]
+	ssu.lock.Unlock()
+	key := ssu.stakeStatusUpdatables.RegisterUnique(stakeStatusUpdatable, UpdateInterest{Kind: InterestEvent, EventType: chainID})
+	if status != StakeStatusUnknown && status != StakeStatusActive {
+		ssu.stakeStatusUpdatables.Call(key, stakeStatusUpdatable, func(_ string, stakeStatusUpdatable StakeStatusUpdatable) {
+			stakeStatusUpdatable.StakeStatusChanged(StakeStatusEvent{ChainID: chainID, Provider: ssu.providerAddress, Status: status})
+		})
+	}
+}
+
+func (ssu *StakeStatusUpdater) UnregisterStakeStatusUpdatable(stakeStatusUpdatable StakeStatusUpdatable) bool {
+	return ssu.stakeStatusUpdatables.UnregisterMatching(stakeStatusUpdatable)
+}
+
+func (ssu *StakeStatusUpdater) RegisteredUpdatables() []string {
+	return ssu.stakeStatusUpdatables.Describe()
+}
+
+// StakeStatus returns the last queried status of the chain, unknown if it wasn't queried yet
+func (ssu *StakeStatusUpdater) StakeStatus(chainID string) StakeStatus {
+	ssu.lock.RLock()
+	defer ssu.lock.RUnlock()
+	return ssu.statuses[chainID]
+}
+
+func (ssu *StakeStatusUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
+}
+
+func (ssu *StakeStatusUpdater) UpdaterKey() string {
+	return CallbackKeyForStakeStatusUpdate
+}
+
+func (ssu *StakeStatusUpdater) Update(latestBlock int64) error {
+	ssu.lock.Lock()
+	if latestBlock < ssu.nextBlockForUpdate {
+		ssu.lock.Unlock()
+		return nil
+	}
+	ssu.nextBlockForUpdate = latestBlock + StakeStatusQueryBlocks
+	chainIDs := make([]string, 0, len(ssu.chainIDs))
+	for chainID := range ssu.chainIDs {
+		chainIDs = append(chainIDs, chainID)
+	}
+	ssu.lock.Unlock()
+	var firstErr error
+	for _, chainID := range chainIDs {
+		stakeEntry, found, err := ssu.stateQuery.GetProviderStakeEntry(context.Background(), chainID, ssu.providerAddress)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		event := StakeStatusEvent{ChainID: chainID, Provider: ssu.providerAddress, Status: StakeStatusUnstaked, Block: latestBlock}
+		if found {
+			event.StakeAppliedBlock = stakeEntry.StakeAppliedBlock
+			event.Status = stakeStatus(stakeEntry, latestBlock)
+		}
+		ssu.lock.Lock()
+		event.PreviousStatus = ssu.statuses[chainID]
+		ssu.statuses[chainID] = event.Status
+		ssu.lock.Unlock()
+		if event.Status == event.PreviousStatus || (event.PreviousStatus == StakeStatusUnknown && event.Status == StakeStatusActive) {
+			continue
+		}
+		utils.LavaFormatWarning("provider stake status changed", nil, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "status", Value: event.Status.String()},
+			utils.Attribute{Key: "previousStatus", Value: event.PreviousStatus.String()}, utils.Attribute{Key: "stakeAppliedBlock", Value: event.StakeAppliedBlock}, utils.Attribute{Key: "block", Value: latestBlock})
+		ssu.stakeStatusUpdatables.Dispatch(UpdateTrigger{Block: latestBlock, EventTypes: map[string]struct{}{chainID: {}}}, func(_ string, stakeStatusUpdatable StakeStatusUpdatable) {
+			stakeStatusUpdatable.StakeStatusChanged(event)
+		})
+	}
+	return firstErr
+}
+
+// freezing sets the stake applied block to the max int64, other future stake applied blocks are stakes that apply on the next epoch
+func stakeStatus(stakeEntry *epochstoragetypes.StakeEntry, latestBlock int64) StakeStatus {
+	switch {
+	case stakeEntry.StakeAppliedBlock >= math.MaxInt64:
+		return StakeStatusFrozen
+	case stakeEntry.StakeAppliedBlock > uint64(latestBlock):
+		return StakeStatusPending
+	default:
+		return StakeStatusActive
+	}
+}
@@ -0,0 +1,93 @@
+package statetracker
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingStakeStatusUpdatable struct {
+	events []StakeStatusEvent
+}
+
+func (rssu *recordingStakeStatusUpdatable) StakeStatusChanged(event StakeStatusEvent) {
+	rssu.events = append(rssu.events, event)
+}
+
+func (rssu *recordingStakeStatusUpdatable) statuses() []StakeStatus {
+	statuses := []StakeStatus{}
+	for _, event := range rssu.events {
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+func TestStakeStatus(t *testing.T) {
+	require.Equal(t, StakeStatusActive, stakeStatus(&epochstoragetypes.StakeEntry{StakeAppliedBlock: 100}, 100))
+	require.Equal(t, StakeStatusPending, stakeStatus(&epochstoragetypes.StakeEntry{StakeAppliedBlock: 101}, 100))
+	require.Equal(t, StakeStatusFrozen, stakeStatus(&epochstoragetypes.StakeEntry{StakeAppliedBlock: math.MaxInt64}, 100))
+	require.Equal(t, StakeStatusFrozen, stakeStatus(&epochstoragetypes.StakeEntry{StakeAppliedBlock: math.MaxUint64}, 100))
+}
+
+func TestStakeStatusUpdaterChanges(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stakeEntry := &epochstoragetypes.StakeEntry{Address: testProviderAddress, StakeAppliedBlock: 5}
+	stateQuery.SetStakeEntry("LAV1", stakeEntry)
+	updater := NewStakeStatusUpdater(stateQuery, testProviderAddress)
+	lav1, eth1 := &recordingStakeStatusUpdatable{}, &recordingStakeStatusUpdatable{}
+	updater.RegisterStakeStatusUpdatable(context.Background(), lav1, "LAV1")
+	updater.RegisterStakeStatusUpdatable(context.Background(), eth1, "ETH1")
+
+	// a first active status isn't a change, an unstaked chain is
+	require.NoError(t, updater.Update(10))
+	require.Empty(t, lav1.events)
+	require.Equal(t, []StakeStatus{StakeStatusUnstaked}, eth1.statuses())
+	require.Equal(t, StakeStatusActive, updater.StakeStatus("LAV1"))
+
+	// frozen, then unfrozen with a stake that applies on the next epoch
+	stateQuery.SetStakeEntry("LAV1", &epochstoragetypes.StakeEntry{Address: testProviderAddress, StakeAppliedBlock: math.MaxInt64})
+	require.NoError(t, updater.Update(10+StakeStatusQueryBlocks-1))
+	require.Empty(t, lav1.events)
+	require.NoError(t, updater.Update(10+StakeStatusQueryBlocks))
+	stateQuery.SetStakeEntry("LAV1", &epochstoragetypes.StakeEntry{Address: testProviderAddress, StakeAppliedBlock: 35})
+	require.NoError(t, updater.Update(10+2*StakeStatusQueryBlocks))
+	require.NoError(t, updater.Update(10+3*StakeStatusQueryBlocks))
+	require.Equal(t, []StakeStatus{StakeStatusFrozen, StakeStatusPending, StakeStatusActive}, lav1.statuses())
+	require.Equal(t, StakeStatusFrozen, lav1.events[1].PreviousStatus)
+	require.Equal(t, uint64(35), lav1.events[2].StakeAppliedBlock)
+	require.Len(t, eth1.events, 1)
+
+	// jailed providers are unstaked
+	stateQuery.Lock()
+	delete(stateQuery.StakeEntries, "LAV1"+testProviderAddress)
+	stateQuery.Unlock()
+	require.NoError(t, updater.Update(10+4*StakeStatusQueryBlocks))
+	require.Equal(t, StakeStatusUnstaked, lav1.events[3].Status)
+}
+
+func TestStakeStatusUpdaterRegistration(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	updater := NewStakeStatusUpdater(stateQuery, testProviderAddress)
+	first := &recordingStakeStatusUpdatable{}
+	updater.RegisterStakeStatusUpdatable(context.Background(), first, "LAV1")
+	require.NoError(t, updater.Update(10))
+	require.Len(t, first.events, 1)
+
+	// a new chain is queried on the next block, a late updatable gets the known status that isn't active right away
+	late := &recordingStakeStatusUpdatable{}
+	updater.RegisterStakeStatusUpdatable(context.Background(), late, "LAV1")
+	require.Equal(t, []StakeStatus{StakeStatusUnstaked}, late.statuses())
+	updater.RegisterStakeStatusUpdatable(context.Background(), &recordingStakeStatusUpdatable{}, "ETH1")
+	require.NoError(t, updater.Update(11))
+	require.Equal(t, 3, stateQuery.Calls("GetProviderStakeEntry"))
+
+	// a failed query is returned and leaves the status as it was
+	stateQuery.FailNext("GetProviderStakeEntry", errNodeUnavailable)
+	require.ErrorIs(t, updater.Update(11+StakeStatusQueryBlocks), errNodeUnavailable)
+	require.True(t, updater.UnregisterStakeStatusUpdatable(late))
+	require.False(t, updater.UnregisterStakeStatusUpdatable(late))
+	require.Len(t, updater.RegisteredUpdatables(), 2)
+}
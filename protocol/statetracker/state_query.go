@@ -4,69 +4,173 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/client"
-	"github.com/dgraph-io/ristretto"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	paramproposal "github.com/cosmos/cosmos-sdk/x/params/types/proposal"
 	reliabilitymanager "github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
 	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
 	"github.com/lavanet/lava/utils"
 	conflicttypes "github.com/lavanet/lava/x/conflict/types"
 	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	planstypes "github.com/lavanet/lava/x/plans/types"
+	projectstypes "github.com/lavanet/lava/x/projects/types"
 	spectypes "github.com/lavanet/lava/x/spec/types"
+	subscriptiontypes "github.com/lavanet/lava/x/subscription/types"
+	abci "github.com/tendermint/tendermint/abci/types"
 )
 
 const (
-	CacheMaxCost                = 10 * 1024 // 10K cost
-	CacheNumCounters            = 100000    // expect 10K items
-	DefaultTimeToLiveExpiration = 30 * time.Minute
-	PairingRespKey              = "pairing-resp"
-	VerifyPairingRespKey        = "verify-pairing-resp"
-	VrfPkAndMaxCuResponseKey    = "vrf-and-max-cu-resp"
+	PairingRespKey            = "pairing-resp"
+	VerifyPairingRespKey      = "verify-pairing-resp"
+	VrfPkAndMaxCuResponseKey  = "vrf-and-max-cu-resp"
+	MaxCuForUserRespKey       = "max-cu-for-user-resp"
+	SpecRespKey               = "spec-resp"
+	EpochDetailsRespKey       = "epoch-details-resp"
+	EpochStorageParamsRespKey = "epoch-storage-params-resp"
+	PairingParamsRespKey      = "pairing-params-resp"
+	projectDeveloperNotFound  = "GetProjectIDForDeveloper_invalid_key" // the projects module error for a key without a project
 )
 
 type StateQuery struct {
 	SpecQueryClient         spectypes.QueryClient
 	PairingQueryClient      pairingtypes.QueryClient
 	EpochStorageQueryClient epochstoragetypes.QueryClient
-	ResponsesCache          *ristretto.Cache
+	ResponsesCache          *QueryCache             // registered as an updater by the state trackers so height sensitive responses are dropped every block
+	specOverlays            map[string]*SpecOverlay // key is the overlaid chain id, set before querying specs
+	specChangeEvents        *specChangeEventsReader
+	lavaClientCtx           client.Context
+	queryBatcher            *QueryBatcher // registered as an updater by the state trackers so shared results are dropped every block
+	paramsQueryClient       paramproposal.QueryClient
 }
 
 func NewStateQuery(ctx context.Context, clientCtx client.Context) *StateQuery {
 	sq := &StateQuery{}
-	sq.SpecQueryClient = spectypes.NewQueryClient(clientCtx)
-	sq.PairingQueryClient = pairingtypes.NewQueryClient(clientCtx)
-	sq.EpochStorageQueryClient = epochstoragetypes.NewQueryClient(clientCtx)
-	cache, err := ristretto.NewCache(&ristretto.Config{NumCounters: CacheNumCounters, MaxCost: CacheMaxCost, BufferItems: 64})
+	sq.queryBatcher = NewQueryBatcher(clientCtx, DefaultQueryBatchWindow)
+	sq.SpecQueryClient = spectypes.NewQueryClient(sq.queryBatcher)
+	sq.PairingQueryClient = pairingtypes.NewQueryClient(sq.queryBatcher)
+	sq.EpochStorageQueryClient = epochstoragetypes.NewQueryClient(sq.queryBatcher)
+	sq.paramsQueryClient = paramproposal.NewQueryClient(sq.queryBatcher)
+	sq.ResponsesCache = NewQueryCache(DefaultQueryCacheMaxEntries, DefaultQueryCacheExpiration)
+	sq.specChangeEvents = &specChangeEventsReader{clientCtx: clientCtx}
+	sq.lavaClientCtx = clientCtx
+	return sq
+}
+
+// ClearCache drops cached responses, used when they might not match the lava node we query from now on
+func (csq *StateQuery) ClearCache() {
+	csq.ResponsesCache.Clear()
+	csq.queryBatcher.Reset()
+}
+
+// GetProtocolVersion returns found false if the chain has no protocol version param
+func (csq *StateQuery) GetProtocolVersion(ctx context.Context) (protocolVersion *ProtocolVersion, found bool, err error) {
+	res, err := csq.paramsQueryClient.Params(ctx, &paramproposal.QueryParamsRequest{Subspace: ProtocolVersionParamSubspace, Key: ProtocolVersionParamKey})
 	if err != nil {
-		utils.LavaFormatFatal("failed setting up cache for queries", err)
+		if paramproposal.ErrUnknownSubspace.Is(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
 	}
-	sq.ResponsesCache = cache
-	return sq
+	if res.Param.Value == "" {
+		return nil, false, nil
+	}
+	protocolVersion, err = parseProtocolVersion(res.Param.Value)
+	return protocolVersion, err == nil, err
+}
+
+func (csq *StateQuery) SetSpecOverlays(specOverlays map[string]*SpecOverlay) {
+	csq.specOverlays = specOverlays
 }
 
 func (csq *StateQuery) GetSpec(ctx context.Context, chainID string) (*spectypes.Spec, error) {
-	spec, err := csq.SpecQueryClient.Spec(ctx, &spectypes.QueryGetSpecRequest{
-		ChainID: chainID,
-	})
-	if err != nil {
-		return nil, utils.LavaFormatError("Failed Querying spec for chain", err, utils.Attribute{Key: "ChainID", Value: chainID})
+	overlay, hasOverlay := csq.specOverlays[chainID]
+	queriedChainID := chainID
+	if hasOverlay {
+		queriedChainID = overlay.baseSpec()
+	}
+	var spec *spectypes.QueryGetSpecResponse
+	cacheHeight, heightSensitive := queryCacheHeight(ctx)
+	if cached, found := csq.ResponsesCache.Get(SpecRespKey, queriedChainID, cacheHeight); found {
+		spec, _ = cached.(*spectypes.QueryGetSpecResponse)
+	}
+	if spec == nil {
+		var err error
+		spec, err = csq.SpecQueryClient.Spec(ctx, &spectypes.QueryGetSpecRequest{
+			ChainID: queriedChainID,
+		})
+		if err != nil {
+			return nil, utils.LavaFormatError("Failed Querying spec for chain", err, utils.Attribute{Key: "ChainID", Value: queriedChainID})
+		}
+		// a spec proposal can change it on any block
+		csq.ResponsesCache.Set(SpecRespKey, queriedChainID, cacheHeight, spec, heightSensitive)
+	}
+	if hasOverlay {
+		overlaidSpec := overlay.Apply(spec.Spec)
+		utils.LavaFormatWarning("using a locally modified spec from a spec overlay", nil, utils.Attribute{Key: "ChainID", Value: chainID}, utils.Attribute{Key: "baseSpec", Value: queriedChainID})
+		specOverlayActiveGauge.WithLabelValues(chainID, queriedChainID).Set(1)
+		return &overlaidSpec, nil
 	}
 	return &spec.Spec, nil
 }
 
 type ConsumerStateQuery struct {
 	StateQuery
-	clientCtx   client.Context
-	lastChainID string
+	clientCtx               client.Context
+	lastChainID             string
+	SubscriptionQueryClient subscriptiontypes.QueryClient
+	ProjectsQueryClient     projectstypes.QueryClient
+	PlansQueryClient        planstypes.QueryClient
 }
 
 func NewConsumerStateQuery(ctx context.Context, clientCtx client.Context) *ConsumerStateQuery {
 	csq := &ConsumerStateQuery{StateQuery: *NewStateQuery(ctx, clientCtx), clientCtx: clientCtx, lastChainID: ""}
+	csq.SubscriptionQueryClient = subscriptiontypes.NewQueryClient(csq.queryBatcher)
+	csq.ProjectsQueryClient = projectstypes.NewQueryClient(csq.queryBatcher)
+	csq.PlansQueryClient = planstypes.NewQueryClient(csq.queryBatcher)
 	return csq
 }
 
+// GetSubscription returns found false if the consumer has no subscription
+func (csq *ConsumerStateQuery) GetSubscription(ctx context.Context, consumer string) (subscription *subscriptiontypes.Subscription, found bool, err error) {
+	res, err := csq.SubscriptionQueryClient.Current(ctx, &subscriptiontypes.QueryCurrentRequest{Consumer: consumer})
+	if err != nil {
+		return nil, false, err
+	}
+	return &res.Sub, res.Sub.Consumer != "", nil
+}
+
+// GetEffectivePolicy returns the policy of the project the consumer is a developer of, fails if the consumer has no project
+func (csq *ConsumerStateQuery) GetEffectivePolicy(ctx context.Context, consumer string) (*EffectivePolicy, error) {
+	developerRes, err := csq.ProjectsQueryClient.Developer(ctx, &projectstypes.QueryDeveloperRequest{Developer: consumer})
+	if err != nil {
+		// the projects module fails the query with a plain error when the key isn't registered to a project
+		if strings.Contains(err.Error(), projectDeveloperNotFound) {
+			return nil, utils.LavaFormatWarning("consumer has no project", ConsumerWithoutProjectError, utils.Attribute{Key: "consumer", Value: consumer})
+		}
+		return nil, err
+	}
+	project := developerRes.Project
+	if project == nil {
+		return nil, utils.LavaFormatWarning("consumer has no project", ConsumerWithoutProjectError, utils.Attribute{Key: "consumer", Value: consumer})
+	}
+	subscription, found, err := csq.GetSubscription(ctx, project.Subscription)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, utils.LavaFormatWarning("project subscription not found", nil, utils.Attribute{Key: "project", Value: project.Index}, utils.Attribute{Key: "subscription", Value: project.Subscription})
+	}
+	planRes, err := csq.PlansQueryClient.Info(ctx, &planstypes.QueryInfoRequest{PlanIndex: subscription.PlanIndex})
+	if err != nil {
+		return nil, err
+	}
+	return NewEffectivePolicy(project, planRes.PlanInfo.PlanPolicy, subscription.PlanIndex, subscription.MonthCuLeft), nil
+}
+
 func (csq *ConsumerStateQuery) GetPairing(ctx context.Context, chainID string, latestBlock int64) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error) {
 	if chainID == "" {
 		if csq.lastChainID != "" {
@@ -78,7 +182,7 @@ func (csq *ConsumerStateQuery) GetPairing(ctx context.Context, chainID string, l
 		}
 	}
 
-	cachedInterface, found := csq.ResponsesCache.Get(PairingRespKey + chainID)
+	cachedInterface, found := csq.ResponsesCache.Get(PairingRespKey, chainID, queryCacheLatestHeight)
 	if found && cachedInterface != nil {
 		if cachedResp, ok := cachedInterface.(*pairingtypes.QueryGetPairingResponse); ok {
 			if cachedResp.BlockOfNextPairing > uint64(latestBlock) {
@@ -89,6 +193,15 @@ func (csq *ConsumerStateQuery) GetPairing(ctx context.Context, chainID string, l
 		}
 	}
 
+	return csq.queryPairing(ctx, chainID)
+}
+
+// PrefetchPairing queries the pairing skipping the cache, the lava node can be past the epoch boundary before the latest block we track is
+func (csq *ConsumerStateQuery) PrefetchPairing(ctx context.Context, chainID string) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error) {
+	return csq.queryPairing(ctx, chainID)
+}
+
+func (csq *ConsumerStateQuery) queryPairing(ctx context.Context, chainID string) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error) {
 	pairingResp, err := csq.PairingQueryClient.GetPairing(ctx, &pairingtypes.QueryGetPairingRequest{
 		ChainID: chainID,
 		Client:  csq.clientCtx.FromAddress.String(),
@@ -97,16 +210,23 @@ func (csq *ConsumerStateQuery) GetPairing(ctx context.Context, chainID string, l
 		return nil, 0, 0, utils.LavaFormatError("Failed in get pairing query", err, utils.Attribute{})
 	}
 	csq.lastChainID = chainID
-	csq.ResponsesCache.SetWithTTL(PairingRespKey+chainID, pairingResp, 1, DefaultTimeToLiveExpiration)
+	// the pairing holds for the epoch, it is checked against BlockOfNextPairing on use
+	csq.ResponsesCache.Set(PairingRespKey, chainID, queryCacheLatestHeight, pairingResp, false)
 	return pairingResp.Providers, pairingResp.CurrentEpoch, pairingResp.BlockOfNextPairing, nil
 }
 
 func (csq *ConsumerStateQuery) GetMaxCUForUser(ctx context.Context, chainID string, epoch uint64) (maxCu uint64, err error) {
 	address := csq.clientCtx.FromAddress.String()
+	if cached, found := csq.ResponsesCache.Get(MaxCuForUserRespKey, chainID+address, int64(epoch)); found {
+		if UserEntryRes, ok := cached.(*pairingtypes.QueryUserEntryResponse); ok {
+			return UserEntryRes.GetMaxCU(), nil
+		}
+	}
 	UserEntryRes, err := csq.PairingQueryClient.UserEntry(ctx, &pairingtypes.QueryUserEntryRequest{ChainID: chainID, Address: address, Block: epoch})
 	if err != nil {
 		return 0, utils.LavaFormatError("failed querying StakeEntry for consumer", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "address", Value: address}, utils.Attribute{Key: "block", Value: epoch})
 	}
+	csq.ResponsesCache.Set(MaxCuForUserRespKey, chainID+address, int64(epoch), UserEntryRes, false)
 	return UserEntryRes.GetMaxCU(), nil
 }
 
@@ -115,7 +235,7 @@ type EpochStateQuery struct {
 }
 
 func (esq *EpochStateQuery) CurrentEpochStart(ctx context.Context) (uint64, error) {
-	epochDetails, err := esq.EpochStorageQueryClient.EpochDetails(ctx, &epochstoragetypes.QueryGetEpochDetailsRequest{})
+	epochDetails, err := esq.epochDetails(ctx)
 	if err != nil {
 		return 0, utils.LavaFormatError("Failed Querying EpochDetails", err)
 	}
@@ -123,6 +243,61 @@ func (esq *EpochStateQuery) CurrentEpochStart(ctx context.Context) (uint64, erro
 	return details.StartBlock, nil
 }
 
+func (esq *EpochStateQuery) GetEpochSize(ctx context.Context) (uint64, error) {
+	cacheHeight, heightSensitive := queryCacheHeight(ctx)
+	if cached, found := esq.ResponsesCache.Get(EpochStorageParamsRespKey, "", cacheHeight); found {
+		if res, ok := cached.(*epochstoragetypes.QueryParamsResponse); ok {
+			return res.Params.EpochBlocks, nil
+		}
+	}
+	res, err := esq.EpochStorageQueryClient.Params(ctx, &epochstoragetypes.QueryParamsRequest{})
+	if err != nil {
+		return 0, err
+	}
+	esq.ResponsesCache.Set(EpochStorageParamsRespKey, "", cacheHeight, res, heightSensitive)
+	return res.Params.EpochBlocks, nil
+}
+
+// the epoch details change on every block, the cache shares them between the updaters of a block
+func (sq *StateQuery) epochDetails(ctx context.Context) (*epochstoragetypes.QueryGetEpochDetailsResponse, error) {
+	cacheHeight, heightSensitive := queryCacheHeight(ctx)
+	if cached, found := sq.ResponsesCache.Get(EpochDetailsRespKey, "", cacheHeight); found {
+		if res, ok := cached.(*epochstoragetypes.QueryGetEpochDetailsResponse); ok {
+			return res, nil
+		}
+	}
+	res, err := sq.EpochStorageQueryClient.EpochDetails(ctx, &epochstoragetypes.QueryGetEpochDetailsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	sq.ResponsesCache.Set(EpochDetailsRespKey, "", cacheHeight, res, heightSensitive)
+	return res, nil
+}
+
+func (sq *StateQuery) pairingParams(ctx context.Context) (*pairingtypes.QueryParamsResponse, error) {
+	cacheHeight, heightSensitive := queryCacheHeight(ctx)
+	if cached, found := sq.ResponsesCache.Get(PairingParamsRespKey, "", cacheHeight); found {
+		if res, ok := cached.(*pairingtypes.QueryParamsResponse); ok {
+			return res, nil
+		}
+	}
+	res, err := sq.PairingQueryClient.Params(ctx, &pairingtypes.QueryParamsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	sq.ResponsesCache.Set(PairingParamsRespKey, "", cacheHeight, res, heightSensitive)
+	return res, nil
+}
+
+// BlockHash returns the hash of a lava block, used to identify an epoch by the hash of its start block
+func (esq *EpochStateQuery) BlockHash(ctx context.Context, block int64) (string, error) {
+	blockResult, err := esq.lavaClientCtx.Client.Block(ctx, &block)
+	if err != nil {
+		return "", err
+	}
+	return blockResult.BlockID.Hash.String(), nil
+}
+
 func NewEpochStateQuery(stateQuery *StateQuery) *EpochStateQuery {
 	return &EpochStateQuery{StateQuery: *stateQuery}
 }
@@ -130,19 +305,23 @@ func NewEpochStateQuery(stateQuery *StateQuery) *EpochStateQuery {
 type ProviderStateQuery struct {
 	StateQuery
 	EpochStateQuery
-	clientCtx client.Context
+	clientCtx               client.Context
+	DistributionQueryClient distributiontypes.QueryClient
+	PaymentEventDecoder     *PaymentEventDecoder
 }
 
 func NewProviderStateQuery(ctx context.Context, clientCtx client.Context) *ProviderStateQuery {
 	sq := NewStateQuery(ctx, clientCtx)
 	esq := NewEpochStateQuery(sq)
 	csq := &ProviderStateQuery{StateQuery: *sq, EpochStateQuery: *esq, clientCtx: clientCtx}
+	csq.DistributionQueryClient = distributiontypes.NewQueryClient(sq.queryBatcher)
+	csq.PaymentEventDecoder = NewPaymentEventDecoder(rewardserver.PaymentEventSchemas)
 	return csq
 }
 
 func (psq *ProviderStateQuery) GetVrfPkAndMaxCuForUser(ctx context.Context, consumerAddress string, chainID string, epoch uint64) (vrfPk *utils.VrfPubKey, maxCu uint64, err error) {
 	key := psq.entryKey(consumerAddress, chainID, epoch, "")
-	cachedInterface, found := psq.ResponsesCache.Get(VrfPkAndMaxCuResponseKey + key)
+	cachedInterface, found := psq.ResponsesCache.Get(VrfPkAndMaxCuResponseKey, key, int64(epoch))
 	var userEntryRes *pairingtypes.QueryUserEntryResponse = nil
 	if found && cachedInterface != nil {
 		if cachedResp, ok := cachedInterface.(*pairingtypes.QueryUserEntryResponse); ok {
@@ -156,7 +335,7 @@ func (psq *ProviderStateQuery) GetVrfPkAndMaxCuForUser(ctx context.Context, cons
 		if err != nil {
 			return nil, 0, utils.LavaFormatError("StakeEntry querying for consumer failed", err, utils.Attribute{Key: "chainID", Value: chainID}, utils.Attribute{Key: "address", Value: consumerAddress}, utils.Attribute{Key: "block", Value: epoch})
 		}
-		psq.ResponsesCache.SetWithTTL(VrfPkAndMaxCuResponseKey+key, userEntryRes, 1, DefaultTimeToLiveExpiration)
+		psq.ResponsesCache.Set(VrfPkAndMaxCuResponseKey, key, int64(epoch), userEntryRes, false)
 	}
 	vrfPk = &utils.VrfPubKey{}
 	vrfPk, err = vrfPk.DecodeFromBech32(userEntryRes.GetConsumer().Vrfpk)
@@ -170,50 +349,101 @@ func (psq *ProviderStateQuery) entryKey(consumerAddress string, chainID string,
 	return consumerAddress + chainID + strconv.FormatUint(epoch, 10) + providerAddress
 }
 
-func (psq *ProviderStateQuery) PaymentEvents(ctx context.Context, latestBlock int64) (payments []*rewardserver.PaymentRequest, err error) {
+// PaymentEvent is a payment with the position of the event it was read from
+type PaymentEvent struct {
+	ID      EventID
+	Payment *rewardserver.PaymentRequest
+}
+
+// VoteEvent is a vote with the position of the event it was read from
+type VoteEvent struct {
+	ID   EventID
+	Vote *reliabilitymanager.VoteParams
+}
+
+func (psq *ProviderStateQuery) PaymentEvents(ctx context.Context, latestBlock int64) (payments []PaymentEvent, err error) {
 	blockResults, err := psq.clientCtx.Client.BlockResults(ctx, &latestBlock)
 	if err != nil {
 		return nil, err
 	}
 	transactionResults := blockResults.TxsResults
-	for _, tx := range transactionResults {
-		events := tx.Events
-		for _, event := range events {
-			if event.Type == "lava_relay_payment" {
-				payment, err := rewardserver.BuildPaymentFromRelayPaymentEvent(event, latestBlock)
-				if err != nil {
-					return nil, utils.LavaFormatError("failed relay_payment_event parsing", err, utils.Attribute{Key: "event", Value: event})
-				}
-				utils.LavaFormatDebug("relay_payment_event", utils.Attribute{Key: "payment", Value: payment})
-				payments = append(payments, payment)
+	for txIndex, tx := range transactionResults {
+		txPayments, err := psq.paymentsFromEvents(tx.Events, latestBlock, txIndex)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, txPayments...)
+	}
+	return payments, nil
+}
+
+// PaymentEventsInRange returns the payment events of the blocks in (fromBlock, toBlock] from the node's tx index, a page at a time
+func (psq *ProviderStateQuery) PaymentEventsInRange(ctx context.Context, fromBlock int64, toBlock int64) (payments []PaymentEvent, err error) {
+	return psq.searchPaymentEvents(ctx, fmt.Sprintf("%s.chainID EXISTS AND tx.height > %d AND tx.height <= %d", RelayPaymentEventType, fromBlock, toBlock))
+}
+
+// ProviderPaymentEventsInRange returns the payment events of provider's claims in the blocks in (fromBlock, toBlock]
+func (psq *ProviderStateQuery) ProviderPaymentEventsInRange(ctx context.Context, provider string, fromBlock int64, toBlock int64) (payments []PaymentEvent, err error) {
+	return psq.searchPaymentEvents(ctx, fmt.Sprintf("%s.provider = '%s' AND tx.height > %d AND tx.height <= %d", RelayPaymentEventType, provider, fromBlock, toBlock))
+}
+
+func (psq *ProviderStateQuery) searchPaymentEvents(ctx context.Context, query string) (payments []PaymentEvent, err error) {
+	perPage := PaymentEventsPageSize
+	for page := 1; ; page++ {
+		result, err := psq.clientCtx.Client.TxSearch(ctx, query, false, &page, &perPage, "asc")
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range result.Txs {
+			txPayments, err := psq.paymentsFromEvents(tx.TxResult.Events, tx.Height, int(tx.Index))
+			if err != nil {
+				return nil, err
 			}
+			payments = append(payments, txPayments...)
+		}
+		if len(result.Txs) == 0 || page*perPage >= result.TotalCount {
+			return payments, nil
+		}
+	}
+}
+
+func (psq *ProviderStateQuery) paymentsFromEvents(events []abci.Event, block int64, txIndex int) (payments []PaymentEvent, err error) {
+	for eventIndex, event := range events {
+		if event.Type == RelayPaymentEventType {
+			payment, err := psq.PaymentEventDecoder.Decode(event, block)
+			if err != nil {
+				return nil, utils.LavaFormatError("failed relay_payment_event parsing", err, utils.Attribute{Key: "event", Value: event})
+			}
+			utils.LavaFormatDebug("relay_payment_event", utils.Attribute{Key: "payment", Value: payment})
+			payments = append(payments, PaymentEvent{ID: EventID{Block: block, TxIndex: txIndex, EventIndex: eventIndex}, Payment: payment})
 		}
 	}
 	return payments, nil
 }
 
-func (psq *ProviderStateQuery) VoteEvents(ctx context.Context, latestBlock int64) (votes []*reliabilitymanager.VoteParams, err error) {
+func (psq *ProviderStateQuery) VoteEvents(ctx context.Context, latestBlock int64) (votes []VoteEvent, err error) {
 	blockResults, err := psq.clientCtx.Client.BlockResults(ctx, &latestBlock)
 	if err != nil {
 		return nil, err
 	}
 	transactionResults := blockResults.TxsResults
-	for _, tx := range transactionResults {
+	for txIndex, tx := range transactionResults {
 		events := tx.Events
-		for _, event := range events {
+		for eventIndex, event := range events {
 			if event.Type == utils.EventPrefix+conflicttypes.ConflictVoteDetectionEventName {
 				vote, err := reliabilitymanager.BuildVoteParamsFromDetectionEvent(event)
 				if err != nil {
 					return nil, utils.LavaFormatError("failed conflict_vote_detection_event parsing", err, utils.Attribute{Key: "event", Value: event})
 				}
 				utils.LavaFormatDebug("conflict_vote_detection_event", utils.Attribute{Key: "voteID", Value: vote.VoteID})
-				votes = append(votes, vote)
+				votes = append(votes, VoteEvent{ID: EventID{Block: latestBlock, TxIndex: txIndex, EventIndex: eventIndex}, Vote: vote})
 			}
 		}
 	}
 
 	beginBlockEvents := blockResults.BeginBlockEvents
-	for _, event := range beginBlockEvents {
+	for eventIndex, event := range beginBlockEvents {
+		eventID := EventID{Block: latestBlock, TxIndex: BlockEventTxIndex, EventIndex: eventIndex}
 		if event.Type == utils.EventPrefix+conflicttypes.ConflictVoteRevealEventName {
 			voteID, voteDeadline, err := reliabilitymanager.BuildBaseVoteDataFromEvent(event)
 			if err != nil {
@@ -221,9 +451,10 @@ func (psq *ProviderStateQuery) VoteEvents(ctx context.Context, latestBlock int64
 			}
 			vote_reveal := &reliabilitymanager.VoteParams{VoteID: voteID, VoteDeadline: voteDeadline, ParamsType: reliabilitymanager.RevealVoteType}
 			utils.LavaFormatDebug("conflict_vote_reveal_event", utils.Attribute{Key: "voteID", Value: voteID})
-			votes = append(votes, vote_reveal)
+			votes = append(votes, VoteEvent{ID: eventID, Vote: vote_reveal})
 		}
-		if event.Type == utils.EventPrefix+conflicttypes.ConflictVoteResolvedEventName {
+		// a vote without a majority closes as unresolved, both end the vote
+		if event.Type == utils.EventPrefix+conflicttypes.ConflictVoteResolvedEventName || event.Type == utils.EventPrefix+conflicttypes.ConflictVoteUnresolvedEventName {
 			voteID, _, err := reliabilitymanager.BuildBaseVoteDataFromEvent(event)
 			if err != nil {
 				if !reliabilitymanager.NoVoteDeadline.Is(err) {
@@ -231,17 +462,44 @@ func (psq *ProviderStateQuery) VoteEvents(ctx context.Context, latestBlock int64
 				}
 			}
 			vote_resolved := &reliabilitymanager.VoteParams{VoteID: voteID, VoteDeadline: 0, ParamsType: reliabilitymanager.CloseVoteType, CloseVote: true}
-			votes = append(votes, vote_resolved)
+			votes = append(votes, VoteEvent{ID: eventID, Vote: vote_resolved})
 			utils.LavaFormatDebug("conflict_vote_resolved_event", utils.Attribute{Key: "voteID", Value: voteID})
 		}
 	}
 	return votes, err
 }
 
+// GetProviderStakeEntry returns the current stake entry of the provider on the chain including frozen entries, found is false if it isn't staked
+func (psq *ProviderStateQuery) GetProviderStakeEntry(ctx context.Context, chainID string, providerAddress string) (stakeEntry *epochstoragetypes.StakeEntry, found bool, err error) {
+	providersResp, err := psq.PairingQueryClient.Providers(ctx, &pairingtypes.QueryProvidersRequest{ChainID: chainID, ShowFrozen: true})
+	if err != nil {
+		return nil, false, utils.LavaFormatWarning("failed querying providers for the stake entry", err, utils.Attribute{Key: "chainID", Value: chainID})
+	}
+	for idx := range providersResp.StakeEntry {
+		if providersResp.StakeEntry[idx].Address == providerAddress {
+			return &providersResp.StakeEntry[idx], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// GetDelegatorRewards returns the rewards of every delegation of the delegator
+func (psq *ProviderStateQuery) GetDelegatorRewards(ctx context.Context, delegator string) (*DelegatorRewards, error) {
+	res, err := psq.DistributionQueryClient.DelegationTotalRewards(ctx, &distributiontypes.QueryDelegationTotalRewardsRequest{DelegatorAddress: delegator})
+	if err != nil {
+		return nil, err
+	}
+	rewards := &DelegatorRewards{Delegator: delegator, Rewards: make(map[string]sdk.DecCoins, len(res.Rewards)), Total: res.Total}
+	for _, reward := range res.Rewards {
+		rewards.Rewards[reward.ValidatorAddress] = reward.Reward
+	}
+	return rewards, nil
+}
+
 func (psq *ProviderStateQuery) VerifyPairing(ctx context.Context, consumerAddress string, providerAddress string, epoch uint64, chainID string) (valid bool, index, total int64, err error) {
 	key := psq.entryKey(consumerAddress, chainID, epoch, providerAddress)
 	extractedResultFromCache := false
-	cachedInterface, found := psq.ResponsesCache.Get(VerifyPairingRespKey + key)
+	cachedInterface, found := psq.ResponsesCache.Get(VerifyPairingRespKey, key, int64(epoch))
 	var verifyResponse *pairingtypes.QueryVerifyPairingResponse = nil
 	if found && cachedInterface != nil {
 		if cachedResp, ok := cachedInterface.(*pairingtypes.QueryVerifyPairingResponse); ok {
@@ -261,7 +519,7 @@ func (psq *ProviderStateQuery) VerifyPairing(ctx context.Context, consumerAddres
 		if err != nil {
 			return false, 0, 0, err
 		}
-		psq.ResponsesCache.SetWithTTL(VerifyPairingRespKey+key, verifyResponse, 1, DefaultTimeToLiveExpiration)
+		psq.ResponsesCache.Set(VerifyPairingRespKey, key, int64(epoch), verifyResponse, false)
 	}
 	if !verifyResponse.Valid {
 		return false, 0, 0, utils.LavaFormatError("invalid self pairing with consumer", nil, utils.Attribute{Key: "provider", Value: providerAddress}, utils.Attribute{Key: "consumer address", Value: consumerAddress}, utils.Attribute{Key: "epoch", Value: epoch}, utils.Attribute{Key: "from_cache", Value: extractedResultFromCache})
@@ -270,23 +528,15 @@ func (psq *ProviderStateQuery) VerifyPairing(ctx context.Context, consumerAddres
 }
 
 func (psq *ProviderStateQuery) GetProvidersCountForConsumer(ctx context.Context, consumerAddress string, epoch uint64, chainID string) (uint32, error) {
-	res, err := psq.PairingQueryClient.Params(ctx, &pairingtypes.QueryParamsRequest{})
+	res, err := psq.pairingParams(ctx)
 	if err != nil {
 		return 0, err
 	}
 	return uint32(res.GetParams().ServicersToPairCount), nil
 }
 
-func (psq *ProviderStateQuery) GetEpochSize(ctx context.Context) (uint64, error) {
-	res, err := psq.EpochStorageQueryClient.Params(ctx, &epochstoragetypes.QueryParamsRequest{})
-	if err != nil {
-		return 0, err
-	}
-	return res.Params.EpochBlocks, nil
-}
-
 func (psq *ProviderStateQuery) EarliestBlockInMemory(ctx context.Context) (uint64, error) {
-	res, err := psq.EpochStorageQueryClient.EpochDetails(ctx, &epochstoragetypes.QueryGetEpochDetailsRequest{})
+	res, err := psq.StateQuery.epochDetails(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -294,13 +544,22 @@ func (psq *ProviderStateQuery) EarliestBlockInMemory(ctx context.Context) (uint6
 }
 
 func (psq *ProviderStateQuery) GetRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error) {
-	res, err := psq.PairingQueryClient.Params(ctx, &pairingtypes.QueryParamsRequest{})
+	res, err := psq.StateQuery.pairingParams(ctx)
 	if err != nil {
 		return 0, err
 	}
 	return res.GetParams().RecommendedEpochNumToCollectPayment, nil
 }
 
+func (psq *ProviderStateQuery) GetPairingParams(ctx context.Context) (*pairingtypes.Params, error) {
+	res, err := psq.StateQuery.pairingParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params := res.GetParams()
+	return &params, nil
+}
+
 func (psq *ProviderStateQuery) GetEpochSizeMultipliedByRecommendedEpochNumToCollectPayment(ctx context.Context) (uint64, error) {
 	epochSize, err := psq.GetEpochSize(ctx)
 	if err != nil {
@@ -0,0 +1,50 @@
+package statetracker
+
+import (
+	"context"
+
+	epochstoragetypes "github.com/lavanet/lava/x/epochstorage/types"
+	spectypes "github.com/lavanet/lava/x/spec/types"
+	subscriptiontypes "github.com/lavanet/lava/x/subscription/types"
+)
+
+// the updaters read the lava state through these interfaces, so they can run on a FakeStateQuery without a lava node
+
+// StateQueryInf is the state read by the spec and protocol version updaters
+type StateQueryInf interface {
+	GetSpec(ctx context.Context, chainID string) (*spectypes.Spec, error)
+	SpecChangeEvents(ctx context.Context, block int64) (*SpecChangeEvents, error)
+	GetProtocolVersion(ctx context.Context) (protocolVersion *ProtocolVersion, found bool, err error)
+}
+
+// EpochStateQueryInf is the state read by the epoch updater and the downtime tracker
+type EpochStateQueryInf interface {
+	CurrentEpochStart(ctx context.Context) (uint64, error)
+	GetEpochSize(ctx context.Context) (uint64, error)
+	BlockHash(ctx context.Context, block int64) (string, error)
+}
+
+// ConsumerStateQueryInf is the state read by the consumer updaters
+type ConsumerStateQueryInf interface {
+	GetPairing(ctx context.Context, chainID string, latestBlock int64) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error)
+	PrefetchPairing(ctx context.Context, chainID string) (pairingList []epochstoragetypes.StakeEntry, epoch uint64, nextBlockForUpdate uint64, errRet error)
+	GetMaxCUForUser(ctx context.Context, chainID string, epoch uint64) (maxCu uint64, err error)
+	GetSubscription(ctx context.Context, consumer string) (subscription *subscriptiontypes.Subscription, found bool, err error)
+	GetEffectivePolicy(ctx context.Context, consumer string) (*EffectivePolicy, error)
+}
+
+// ProviderStateQueryInf is the state read by the provider updaters
+type ProviderStateQueryInf interface {
+	PaymentEvents(ctx context.Context, latestBlock int64) (payments []PaymentEvent, err error)
+	PaymentEventsInRange(ctx context.Context, fromBlock int64, toBlock int64) (payments []PaymentEvent, err error)
+	VoteEvents(ctx context.Context, latestBlock int64) (votes []VoteEvent, err error)
+	GetProviderStakeEntry(ctx context.Context, chainID string, providerAddress string) (stakeEntry *epochstoragetypes.StakeEntry, found bool, err error)
+	GetDelegatorRewards(ctx context.Context, delegator string) (*DelegatorRewards, error)
+}
+
+var (
+	_ StateQueryInf         = (*StateQuery)(nil)
+	_ EpochStateQueryInf    = (*EpochStateQuery)(nil)
+	_ ConsumerStateQueryInf = (*ConsumerStateQuery)(nil)
+	_ ProviderStateQueryInf = (*ProviderStateQuery)(nil)
+)
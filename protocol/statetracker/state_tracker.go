@@ -3,16 +3,21 @@ package statetracker
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/tx"
 	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/utils"
 )
 
 const (
-	BlocksToSaveLavaChainTracker   = 1 // we only need the latest block
+	BlocksToSaveLavaChainTracker   = 1 // we only need the latest block, unless there's a reorg safety lag
 	TendermintConsensusParamsQuery = "consensus_params"
+	ReorgSafetyBlocksFlag          = "reorg-safety-blocks"
+	UpdaterParallelismFlag         = "state-tracker-parallelism"
+	DefaultUpdaterParallelism      = 1 // updaters run one after the other unless configured
 )
 
 // ConsumerStateTracker CSTis a class for tracking consumer data from the lava blockchain, such as epoch changes.
@@ -20,46 +25,186 @@ const (
 type StateTracker struct {
 	chainTracker         *chaintracker.ChainTracker
 	registrationLock     sync.RWMutex
-	newLavaBlockUpdaters map[string]Updater
+	newLavaBlockUpdaters *UpdatableRegistry[Updater] // key is the updater key
+	updaterStats         *updaterStats
+	reorgSafetyBlocks    int64 // atomic, how far behind the latest block reorg safe updaters are called
+	updaterParallelism   int64 // atomic, how many updaters of the same priority run at once
+	processingLag        *processingLagMonitor
+	reorgLock            sync.Mutex
+	lastHandledReorg     *chaintracker.ReorgEvent // nil until the first reorg, fork callbacks that bring no newer reorg don't rewind again
 }
 
+// Updater can implement PrioritizedUpdatable to run before or after the other updaters.
+// an error returned from Update is counted in the updater stats, the updater still runs on the next block
 type Updater interface {
-	Update(int64)
+	Update(int64) error
 	UpdaterKey() string
 }
 
+// ReorgSafeUpdater is implemented by updaters acting on the events of the block they're called with, with a reorg safety lag
+// they're called with the block that many blocks behind the latest. when a reorg reaches a block they already processed
+// Rewind is called with the lowest reorged block, and the next Update has to process the blocks from it again
+type ReorgSafeUpdater interface {
+	Updater
+	Rewind(forkBlock int64)
+}
+
 func NewStateTracker(ctx context.Context, txFactory tx.Factory, clientCtx client.Context, chainFetcher chaintracker.ChainFetcher) (ret *StateTracker, err error) {
-	cst := &StateTracker{newLavaBlockUpdaters: map[string]Updater{}}
+	cst := &StateTracker{newLavaBlockUpdaters: NewUpdatableRegistry[Updater]("lava-block-updaters"), updaterStats: newUpdaterStats(), updaterParallelism: DefaultUpdaterParallelism, processingLag: newProcessingLagMonitor(chainFetcher)}
 	resultConsensusParams, err := clientCtx.Client.ConsensusParams(ctx, nil) // nil returns latest
 	if err != nil {
 		return nil, err
 	}
 	chainTrackerConfig := chaintracker.ChainTrackerConfig{
 		NewLatestCallback: cst.newLavaBlock,
+		ForkCallback:      cst.lavaFork,
 		BlocksToSave:      BlocksToSaveLavaChainTracker,
 		AverageBlockTime:  time.Duration(resultConsensusParams.ConsensusParams.Block.TimeIotaMs) * time.Millisecond,
 		ServerBlockMemory: BlocksToSaveLavaChainTracker,
 	}
 	cst.chainTracker, err = chaintracker.NewChainTracker(ctx, chainFetcher, chainTrackerConfig)
-	return cst, err
+	if err != nil {
+		return nil, err
+	}
+	cst.processingLag.start(ctx, chainTrackerConfig.AverageBlockTime)
+	return cst, nil
 }
 
 func (cst *StateTracker) newLavaBlock(latestBlock int64, hash string) {
-	// go over the registered updaters and trigger update
+	// go over the registered updaters in priority order and trigger update, a panicking updater doesn't stop the others.
+	// updaters of the same priority can run concurrently, all of them are done before the next block is dispatched
 	cst.registrationLock.RLock()
 	defer cst.registrationLock.RUnlock()
-	for _, updater := range cst.newLavaBlockUpdaters {
-		updater.Update(latestBlock)
+	cst.newLavaBlockUpdaters.DispatchConcurrently(UpdateTrigger{Block: latestBlock}, int(atomic.LoadInt64(&cst.updaterParallelism)), func(updaterKey string, updater Updater) {
+		updateBlock := cst.updateBlock(updater, latestBlock)
+		if updateBlock <= 0 {
+			return // the chain is younger than the reorg safety lag
+		}
+		start := time.Now()
+		err := errUpdaterPanicked // recorded as a failure if Update doesn't return
+		defer func() {
+			cst.updaterStats.record(updaterKey, updateBlock, time.Since(start), err)
+		}()
+		err = updater.Update(updateBlock)
+	})
+	cst.processingLag.processed(latestBlock)
+}
+
+// updateBlock is the block the updater is called with, reorg safe updaters aren't called before the lag passes
+func (cst *StateTracker) updateBlock(updater Updater, latestBlock int64) int64 {
+	if _, ok := updater.(ReorgSafeUpdater); !ok {
+		return latestBlock
+	}
+	return latestBlock - cst.getReorgSafetyBlocks()
+}
+
+// lavaFork rewinds the reorg safe updaters that processed reorged blocks and reprocesses them right away.
+// the fork block is the lowest block of the latest reorg the chain tracker detected
+func (cst *StateTracker) lavaFork(latestBlock int64) {
+	reorgs := cst.chainTracker.GetReorgHistory(1)
+	if len(reorgs) == 0 {
+		return
+	}
+	cst.handleReorg(latestBlock, reorgs[0])
+}
+
+// handleReorg rewinds the updaters once per reorg, a fork callback can come without a new reorg being recorded and rewinding
+// for the last reorg again would make the updaters handle the events of its blocks twice
+func (cst *StateTracker) handleReorg(latestBlock int64, reorg *chaintracker.ReorgEvent) {
+	cst.reorgLock.Lock()
+	defer cst.reorgLock.Unlock()
+	if lastReorg := cst.lastHandledReorg; lastReorg != nil && lastReorg.Height == reorg.Height && lastReorg.DetectionTime == reorg.DetectionTime && lastReorg.NewHash == reorg.NewHash {
+		utils.LavaFormatDebug("fork callback without a new reorg, not rewinding", utils.Attribute{Key: "forkBlock", Value: reorg.Height}, utils.Attribute{Key: "latestBlock", Value: latestBlock})
+		return
+	}
+	cst.lastHandledReorg = reorg
+	forkBlock := reorg.Height
+	cst.registrationLock.RLock()
+	defer cst.registrationLock.RUnlock()
+	cst.newLavaBlockUpdaters.Dispatch(UpdateTrigger{Block: latestBlock}, func(updaterKey string, updater Updater) {
+		reorgSafeUpdater, ok := updater.(ReorgSafeUpdater)
+		if !ok {
+			return
+		}
+		updateBlock := cst.updateBlock(updater, latestBlock)
+		if forkBlock > updateBlock || updateBlock <= 0 {
+			return // the lag kept the reorged blocks from the updater
+		}
+		utils.LavaFormatWarning("lava reorg reached processed blocks, reprocessing them", nil, utils.Attribute{Key: "updater", Value: updaterKey},
+			utils.Attribute{Key: "forkBlock", Value: forkBlock}, utils.Attribute{Key: "depth", Value: reorg.Depth}, utils.Attribute{Key: "reorgSafetyBlocks", Value: cst.getReorgSafetyBlocks()})
+		reorgSafeUpdater.Rewind(forkBlock)
+		start := time.Now()
+		err := errUpdaterPanicked
+		defer func() {
+			cst.updaterStats.record(updaterKey, updateBlock, time.Since(start), err)
+		}()
+		err = reorgSafeUpdater.Update(updateBlock)
+	})
+}
+
+// SetProcessingLagAlert calls callback when the blocks between the lava chain tip and the last block every updater processed go over threshold,
+// and again when they're back under it. a zero threshold disables the alert, the lag metric is exported regardless
+func (cst *StateTracker) SetProcessingLagAlert(threshold uint64, callback ProcessingLagCallback) {
+	cst.processingLag.setAlert(threshold, callback)
+}
+
+// SetUpdaterParallelism sets how many updaters of the same priority run at once on a new block, so a slow state query doesn't delay the others.
+// 0 and 1 run them one after the other
+func (cst *StateTracker) SetUpdaterParallelism(parallelism uint64) {
+	atomic.StoreInt64(&cst.updaterParallelism, int64(parallelism))
+}
+
+func (cst *StateTracker) getReorgSafetyBlocks() int64 {
+	return atomic.LoadInt64(&cst.reorgSafetyBlocks)
+}
+
+// SetReorgSafetyBlocks makes reorg safe updaters, such as payments and conflict votes, act only on blocks that many blocks behind the latest.
+// the lava chain tracker saves the blocks in the lag so reorgs in it are detected
+func (cst *StateTracker) SetReorgSafetyBlocks(reorgSafetyBlocks uint64) error {
+	err := cst.chainTracker.UpdateBlocksToSave(BlocksToSaveLavaChainTracker+reorgSafetyBlocks, BlocksToSaveLavaChainTracker+reorgSafetyBlocks, 0)
+	if err != nil {
+		return err
 	}
+	atomic.StoreInt64(&cst.reorgSafetyBlocks, int64(reorgSafetyBlocks))
+	return nil
 }
 
 func (cst *StateTracker) RegisterForUpdates(ctx context.Context, updater Updater) Updater {
 	cst.registrationLock.Lock()
 	defer cst.registrationLock.Unlock()
-	existingUpdater, ok := cst.newLavaBlockUpdaters[updater.UpdaterKey()]
+	existingUpdater, ok := cst.newLavaBlockUpdaters.Get(updater.UpdaterKey())
 	if !ok {
-		cst.newLavaBlockUpdaters[updater.UpdaterKey()] = updater
+		cst.newLavaBlockUpdaters.Register(updater.UpdaterKey(), updater, UpdateInterest{Kind: InterestEveryBlock})
 		existingUpdater = updater
 	}
 	return existingUpdater
 }
+
+// UpdatablesLister is implemented by updaters that can list the updatables registered on them
+type UpdatablesLister interface {
+	RegisteredUpdatables() []string
+}
+
+// RegisteredUpdatables lists the updatables registered on every updater, the key is the updater key
+func (cst *StateTracker) RegisteredUpdatables() map[string][]string {
+	registered := map[string][]string{}
+	for _, updater := range cst.newLavaBlockUpdaters.Updatables() {
+		if lister, ok := updater.(UpdatablesLister); ok {
+			registered[updater.UpdaterKey()] = lister.RegisteredUpdatables()
+		} else {
+			registered[updater.UpdaterKey()] = nil
+		}
+	}
+	return registered
+}
+
+// UpdaterStats returns the update results of every updater since the state tracker started, the key is the updater key
+func (cst *StateTracker) UpdaterStats() map[string]UpdaterStats {
+	return cst.updaterStats.snapshot()
+}
+
+// registeredUpdater returns nil if no updater was registered with the key, so unregistering before registering is a no-op
+func (cst *StateTracker) registeredUpdater(updaterKey string) Updater {
+	updater, _ := cst.newLavaBlockUpdaters.Get(updaterKey)
+	return updater
+}
@@ -0,0 +1,123 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/chaintracker"
+	"github.com/lavanet/lava/protocol/rpcprovider/rewardserver"
+	"github.com/stretchr/testify/require"
+)
+
+const testPaymentDescription = "test-provider"
+
+func newTestStateTracker() *StateTracker {
+	return &StateTracker{newLavaBlockUpdaters: NewUpdatableRegistry[Updater]("lava-block-updaters"), updaterStats: newUpdaterStats(), updaterParallelism: DefaultUpdaterParallelism, processingLag: newProcessingLagMonitor(nil)}
+}
+
+// countingPaymentUpdatable counts the payments it was called with by session id
+type countingPaymentUpdatable struct {
+	lock    sync.Mutex
+	handled map[uint64]int
+}
+
+func newCountingPaymentUpdatable() *countingPaymentUpdatable {
+	return &countingPaymentUpdatable{handled: map[uint64]int{}}
+}
+
+func (cpu *countingPaymentUpdatable) PaymentHandler(payment *rewardserver.PaymentRequest) {
+	cpu.lock.Lock()
+	defer cpu.lock.Unlock()
+	cpu.handled[payment.UniqueIdentifier]++
+}
+
+func (cpu *countingPaymentUpdatable) Description() string {
+	return testPaymentDescription
+}
+
+func (cpu *countingPaymentUpdatable) handledPayments() map[uint64]int {
+	cpu.lock.Lock()
+	defer cpu.lock.Unlock()
+	handled := map[uint64]int{}
+	for sessionID, count := range cpu.handled {
+		handled[sessionID] = count
+	}
+	return handled
+}
+
+func testPaymentEvent(block int64, eventIndex int, sessionID uint64) PaymentEvent {
+	return PaymentEvent{ID: EventID{Block: block, EventIndex: eventIndex}, Payment: &rewardserver.PaymentRequest{UniqueIdentifier: sessionID, Description: testPaymentDescription, BlockHeightDeadline: block}}
+}
+
+func newTestPaymentUpdater(stateQuery ProviderStateQueryInf) (*PaymentUpdater, *countingPaymentUpdatable) {
+	paymentUpdater := NewPaymentUpdater(stateQuery)
+	counter := newCountingPaymentUpdatable()
+	var updatable PaymentUpdatable = counter
+	paymentUpdater.RegisterPaymentUpdatable(context.Background(), &updatable)
+	return paymentUpdater, counter
+}
+
+func TestStateTrackerForkHandlesPaymentsOnce(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Payments[10] = []PaymentEvent{testPaymentEvent(10, 0, 1)}
+	stateQuery.Payments[11] = []PaymentEvent{testPaymentEvent(11, 0, 2)}
+	stateQuery.Payments[12] = []PaymentEvent{testPaymentEvent(12, 0, 4)}
+	cst := newTestStateTracker()
+	paymentUpdater, counter := newTestPaymentUpdater(stateQuery)
+	cst.RegisterForUpdates(context.Background(), paymentUpdater)
+
+	cst.newLavaBlock(10, "hash10")
+	cst.newLavaBlock(11, "hash11")
+	// block 11 was reorged, the new fork has another payment in the same position
+	stateQuery.Lock()
+	stateQuery.Payments[11] = []PaymentEvent{testPaymentEvent(11, 0, 3)}
+	stateQuery.Unlock()
+	reorg := &chaintracker.ReorgEvent{Height: 11, OldHash: "hash11", NewHash: "hash11b", Depth: 1, DetectionTime: 1000}
+	cst.handleReorg(11, reorg)
+	// a second fork callback without a new reorg doesn't replay the blocks of the last one
+	reorgCopy := *reorg
+	cst.handleReorg(11, &reorgCopy)
+	cst.newLavaBlock(12, "hash12")
+	require.Equal(t, map[uint64]int{1: 1, 2: 1, 3: 1, 4: 1}, counter.handledPayments())
+
+	// a new reorg rewinds again
+	stateQuery.Lock()
+	stateQuery.Payments[12] = []PaymentEvent{testPaymentEvent(12, 0, 5)}
+	stateQuery.Unlock()
+	cst.handleReorg(12, &chaintracker.ReorgEvent{Height: 12, OldHash: "hash12", NewHash: "hash12b", Depth: 1, DetectionTime: 2000})
+	require.Equal(t, map[uint64]int{1: 1, 2: 1, 3: 1, 4: 1, 5: 1}, counter.handledPayments())
+	require.Equal(t, uint64(0), cst.UpdaterStats()[CallbackKeyForPaymentUpdate].Failures)
+}
+
+func TestStateTrackerForkBehindReorgSafetyLag(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Payments[10] = []PaymentEvent{testPaymentEvent(10, 0, 1)}
+	cst := newTestStateTracker()
+	cst.reorgSafetyBlocks = 2
+	paymentUpdater, counter := newTestPaymentUpdater(stateQuery)
+	cst.RegisterForUpdates(context.Background(), paymentUpdater)
+
+	cst.newLavaBlock(12, "hash12")
+	require.Equal(t, map[uint64]int{1: 1}, counter.handledPayments())
+	// the reorg is within the lag, the updater didn't process its blocks yet
+	cst.handleReorg(12, &chaintracker.ReorgEvent{Height: 11, NewHash: "hash11b", Depth: 2, DetectionTime: 1000})
+	require.Equal(t, 1, stateQuery.Calls("PaymentEvents"))
+	require.Equal(t, int64(10), paymentUpdater.lastProcessedBlock)
+}
+
+func TestStateTrackerRegisteredUpdatables(t *testing.T) {
+	cst := newTestStateTracker()
+	paymentUpdater, _ := newTestPaymentUpdater(NewFakeStateQuery())
+	require.Same(t, paymentUpdater, cst.RegisterForUpdates(context.Background(), paymentUpdater))
+	// an updater of a registered key isn't registered twice, the registered one is returned
+	otherPaymentUpdater, _ := newTestPaymentUpdater(NewFakeStateQuery())
+	require.Same(t, paymentUpdater, cst.RegisterForUpdates(context.Background(), otherPaymentUpdater))
+	cst.RegisterForUpdates(context.Background(), NewEpochUpdater(NewFakeStateQuery()))
+
+	registered := cst.RegisteredUpdatables()
+	require.Len(t, registered, 2)
+	require.Equal(t, []string{testPaymentDescription + " (event:" + RelayPaymentEventType + ", priority 0)"}, registered[CallbackKeyForPaymentUpdate])
+	require.Empty(t, registered[CallbackKeyForEpochUpdate])
+	require.Nil(t, cst.registeredUpdater("missing"))
+}
@@ -0,0 +1,185 @@
+package statetracker
+
+import (
+	"context"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	subscriptiontypes "github.com/lavanet/lava/x/subscription/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	CallbackKeyForSubscriptionUpdate = "subscription-update"
+	SubscriptionCuThresholdsFlag     = "subscription-cu-thresholds"
+	SubscriptionExpiryDaysFlag       = "subscription-expiry-days"
+	SubscriptionEventCuUsed          = "subscription-cu-used"
+	SubscriptionEventExpiry          = "subscription-expiry"
+	SubscriptionQueryBlocks          = 20 // the subscription is queried every this many blocks
+)
+
+var (
+	DefaultSubscriptionCuThresholds = []float64{0.8, 0.95}
+	DefaultSubscriptionExpiryDays   = []uint64{7, 1}
+)
+
+var (
+	subscriptionCuUsedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_subscription_cu_used_ratio",
+		Help: "The part of the subscription's monthly compute units the consumer used",
+	}, []string{"consumer", "plan"})
+	subscriptionExpiryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_consumer_subscription_expiry_seconds",
+		Help: "The time left until the consumer's subscription expires",
+	}, []string{"consumer", "plan"})
+	subscriptionThresholdsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_consumer_subscription_thresholds_total",
+		Help: "The number of subscription thresholds the consumer crossed by event",
+	}, []string{"consumer", "event"})
+)
+
+func init() {
+	prometheus.MustRegister(subscriptionCuUsedGauge, subscriptionExpiryGauge, subscriptionThresholdsCounter)
+}
+
+// SubscriptionThresholds are the subscription states that fire an event, every threshold fires once per month or subscription period
+type SubscriptionThresholds struct {
+	CuUsed       []float64 // parts of the monthly compute units
+	DaysToExpiry []uint64
+}
+
+// SubscriptionEvent is the subscription state when a threshold was crossed, Threshold is the cu part or the days to expiry by Event
+type SubscriptionEvent struct {
+	Event        string
+	Consumer     string
+	Plan         string
+	Threshold    float64
+	MonthCuTotal uint64
+	MonthCuLeft  uint64
+	ExpiryTime   time.Time
+}
+
+type SubscriptionUpdatable interface {
+	SubscriptionThresholdCrossed(event SubscriptionEvent)
+}
+
+// SubscriptionUpdater monitors the subscription of the consumer and warns when its compute units run low or it's about to expire
+type SubscriptionUpdater struct {
+	subscriptionUpdatables *UpdatableRegistry[SubscriptionUpdatable]
+	stateQuery             ConsumerStateQueryInf
+	consumer               string
+	thresholds             SubscriptionThresholds
+	nextBlockForUpdate     int64
+	monthExpiryTime        uint64  // the cu thresholds fire again when a new month refills the compute units
+	expiryTime             int64   // the expiry thresholds fire again when the subscription is extended
+	crossedCuUsed          float64 // the highest cu threshold crossed this month
+	crossedDaysToExpiry    uint64  // the lowest expiry threshold crossed this subscription period
+	crossedExpiry          bool
+}
+
+func NewSubscriptionUpdater(stateQuery ConsumerStateQueryInf, consumer string, thresholds SubscriptionThresholds) *SubscriptionUpdater {
+	return &SubscriptionUpdater{subscriptionUpdatables: NewUpdatableRegistry[SubscriptionUpdatable](CallbackKeyForSubscriptionUpdate), stateQuery: stateQuery, consumer: consumer, thresholds: thresholds}
+}
+
+// RegisterSubscriptionUpdatable is a no-op for a nil updatable, the updater still logs and exports the subscription state
+func (su *SubscriptionUpdater) RegisterSubscriptionUpdatable(subscriptionUpdatable SubscriptionUpdatable) {
+	if subscriptionUpdatable == nil {
+		return
+	}
+	su.subscriptionUpdatables.RegisterUnique(subscriptionUpdatable, UpdateInterest{Kind: InterestEvent, EventType: CallbackKeyForSubscriptionUpdate})
+}
+
+func (su *SubscriptionUpdater) UnregisterSubscriptionUpdatable(subscriptionUpdatable SubscriptionUpdatable) bool {
+	return su.subscriptionUpdatables.UnregisterMatching(subscriptionUpdatable)
+}
+
+func (su *SubscriptionUpdater) RegisteredUpdatables() []string {
+	return su.subscriptionUpdatables.Describe()
+}
+
+func (su *SubscriptionUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
+}
+
+func (su *SubscriptionUpdater) UpdaterKey() string {
+	return CallbackKeyForSubscriptionUpdate
+}
+
+func (su *SubscriptionUpdater) Update(latestBlock int64) error {
+	if latestBlock < su.nextBlockForUpdate {
+		return nil
+	}
+	su.nextBlockForUpdate = latestBlock + SubscriptionQueryBlocks
+	subscription, found, err := su.stateQuery.GetSubscription(context.Background(), su.consumer)
+	if err != nil {
+		return utils.LavaFormatWarning("failed querying consumer subscription", err, utils.Attribute{Key: "consumer", Value: su.consumer})
+	}
+	if !found {
+		return nil // consumers staked without a subscription have nothing to monitor
+	}
+	su.checkThresholds(subscription, time.Now())
+	return nil
+}
+
+func (su *SubscriptionUpdater) checkThresholds(subscription *subscriptiontypes.Subscription, now time.Time) {
+	expiryTime := SubscriptionExpiryTime(subscription)
+	cuUsed := float64(0)
+	if subscription.MonthCuTotal > 0 {
+		cuUsed = float64(subscription.MonthCuTotal-subscription.MonthCuLeft) / float64(subscription.MonthCuTotal)
+	}
+	subscriptionCuUsedGauge.WithLabelValues(su.consumer, subscription.PlanIndex).Set(cuUsed)
+	subscriptionExpiryGauge.WithLabelValues(su.consumer, subscription.PlanIndex).Set(expiryTime.Sub(now).Seconds())
+	if subscription.MonthExpiryTime != su.monthExpiryTime {
+		su.monthExpiryTime = subscription.MonthExpiryTime
+		su.crossedCuUsed = 0
+	}
+	if expiryTime.Unix() != su.expiryTime {
+		su.expiryTime = expiryTime.Unix()
+		su.crossedExpiry = false
+	}
+	event := SubscriptionEvent{Consumer: su.consumer, Plan: subscription.PlanIndex, MonthCuTotal: subscription.MonthCuTotal, MonthCuLeft: subscription.MonthCuLeft, ExpiryTime: expiryTime}
+	// only the tightest crossed threshold fires, the looser ones it passed since the last query are skipped
+	crossedCuUsed := su.crossedCuUsed
+	for _, threshold := range su.thresholds.CuUsed {
+		if cuUsed >= threshold && threshold > crossedCuUsed {
+			crossedCuUsed = threshold
+		}
+	}
+	if crossedCuUsed > su.crossedCuUsed {
+		su.crossedCuUsed = crossedCuUsed
+		event.Event, event.Threshold = SubscriptionEventCuUsed, crossedCuUsed
+		utils.LavaFormatWarning("subscription compute units are running low", nil, utils.Attribute{Key: "consumer", Value: su.consumer}, utils.Attribute{Key: "plan", Value: subscription.PlanIndex},
+			utils.Attribute{Key: "threshold", Value: crossedCuUsed}, utils.Attribute{Key: "monthCuLeft", Value: subscription.MonthCuLeft}, utils.Attribute{Key: "monthCuTotal", Value: subscription.MonthCuTotal})
+		su.dispatch(event)
+	}
+	daysToExpiry := expiryTime.Sub(now).Hours() / 24
+	crossedDaysToExpiry, crossedExpiry := su.crossedDaysToExpiry, false
+	for _, threshold := range su.thresholds.DaysToExpiry {
+		if daysToExpiry <= float64(threshold) && (!su.crossedExpiry || threshold < su.crossedDaysToExpiry) && (!crossedExpiry || threshold < crossedDaysToExpiry) {
+			crossedDaysToExpiry, crossedExpiry = threshold, true
+		}
+	}
+	if crossedExpiry {
+		su.crossedDaysToExpiry, su.crossedExpiry = crossedDaysToExpiry, true
+		event.Event, event.Threshold = SubscriptionEventExpiry, float64(crossedDaysToExpiry)
+		utils.LavaFormatWarning("subscription is about to expire", nil, utils.Attribute{Key: "consumer", Value: su.consumer}, utils.Attribute{Key: "plan", Value: subscription.PlanIndex},
+			utils.Attribute{Key: "daysToExpiry", Value: crossedDaysToExpiry}, utils.Attribute{Key: "expiryTime", Value: expiryTime})
+		su.dispatch(event)
+	}
+}
+
+func (su *SubscriptionUpdater) dispatch(event SubscriptionEvent) {
+	subscriptionThresholdsCounter.WithLabelValues(su.consumer, event.Event).Inc()
+	su.subscriptionUpdatables.Dispatch(UpdateTrigger{EventTypes: map[string]struct{}{CallbackKeyForSubscriptionUpdate: {}}}, func(_ string, subscriptionUpdatable SubscriptionUpdatable) {
+		subscriptionUpdatable.SubscriptionThresholdCrossed(event)
+	})
+}
+
+// SubscriptionExpiryTime is the end of the subscription, the current month expires first and every month left after it extends it
+func SubscriptionExpiryTime(subscription *subscriptiontypes.Subscription) time.Time {
+	expiryTime := time.Unix(int64(subscription.MonthExpiryTime), 0)
+	if subscription.DurationLeft > 1 {
+		expiryTime = expiryTime.AddDate(0, int(subscription.DurationLeft-1), 0)
+	}
+	return expiryTime
+}
@@ -0,0 +1,85 @@
+package statetracker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	subscriptiontypes "github.com/lavanet/lava/x/subscription/types"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSubscriptionUpdatable struct {
+	events []SubscriptionEvent
+}
+
+func (rsu *recordingSubscriptionUpdatable) SubscriptionThresholdCrossed(event SubscriptionEvent) {
+	rsu.events = append(rsu.events, event)
+}
+
+func TestSubscriptionUpdaterThresholds(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	subscription := &subscriptiontypes.Subscription{PlanIndex: "basic", MonthCuTotal: 100, MonthCuLeft: 50, DurationLeft: 1, MonthExpiryTime: uint64(time.Now().AddDate(0, 0, 30).Unix())}
+	stateQuery.Subscriptions["consumer"] = subscription
+	updater := NewSubscriptionUpdater(stateQuery, "consumer", SubscriptionThresholds{CuUsed: DefaultSubscriptionCuThresholds, DaysToExpiry: DefaultSubscriptionExpiryDays})
+	updatable := &recordingSubscriptionUpdatable{}
+	updater.RegisterSubscriptionUpdatable(updatable)
+	updater.RegisterSubscriptionUpdatable(nil)
+	require.Len(t, updater.RegisteredUpdatables(), 1)
+
+	require.NoError(t, updater.Update(1))
+	require.Empty(t, updatable.events)
+	// the subscription isn't queried again before SubscriptionQueryBlocks pass
+	subscription.MonthCuLeft = 10
+	require.NoError(t, updater.Update(1+SubscriptionQueryBlocks-1))
+	require.Equal(t, 1, stateQuery.Calls("GetSubscription"))
+	require.NoError(t, updater.Update(1+SubscriptionQueryBlocks))
+	require.Len(t, updatable.events, 1)
+	require.Equal(t, SubscriptionEventCuUsed, updatable.events[0].Event)
+	require.Equal(t, 0.8, updatable.events[0].Threshold)
+
+	// a failed query is returned and retried on the next interval
+	stateQuery.FailNext("GetSubscription", errors.New("node unavailable"))
+	require.Error(t, updater.Update(1+2*SubscriptionQueryBlocks))
+	// only the tightest threshold fires, and only once
+	subscription.MonthCuLeft = 0
+	require.NoError(t, updater.Update(1+3*SubscriptionQueryBlocks))
+	require.NoError(t, updater.Update(1+4*SubscriptionQueryBlocks))
+	require.Len(t, updatable.events, 2)
+	require.Equal(t, 0.95, updatable.events[1].Threshold)
+
+	// a new month refills the compute units and the thresholds fire again
+	subscription.MonthCuLeft = 15
+	subscription.MonthExpiryTime = uint64(time.Now().AddDate(0, 0, 20).Unix())
+	require.NoError(t, updater.Update(1+5*SubscriptionQueryBlocks))
+	require.Len(t, updatable.events, 3)
+	require.Equal(t, 0.8, updatable.events[2].Threshold)
+
+	// the subscription is about to expire
+	subscription.MonthExpiryTime = uint64(time.Now().AddDate(0, 0, 2).Unix())
+	subscription.MonthCuLeft = 100
+	require.NoError(t, updater.Update(1+6*SubscriptionQueryBlocks))
+	require.Len(t, updatable.events, 4)
+	require.Equal(t, SubscriptionEventExpiry, updatable.events[3].Event)
+	require.Equal(t, float64(7), updatable.events[3].Threshold)
+
+	require.True(t, updater.UnregisterSubscriptionUpdatable(updatable))
+	require.False(t, updater.UnregisterSubscriptionUpdatable(updatable))
+}
+
+func TestSubscriptionUpdaterWithoutSubscription(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	updater := NewSubscriptionUpdater(stateQuery, "consumer", SubscriptionThresholds{CuUsed: DefaultSubscriptionCuThresholds})
+	updatable := &recordingSubscriptionUpdatable{}
+	updater.RegisterSubscriptionUpdatable(updatable)
+	require.NoError(t, updater.Update(1))
+	require.Empty(t, updatable.events)
+}
+
+func TestSubscriptionExpiryTime(t *testing.T) {
+	monthExpiry := time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC)
+	subscription := &subscriptiontypes.Subscription{MonthExpiryTime: uint64(monthExpiry.Unix()), DurationLeft: 3}
+	require.Equal(t, monthExpiry.AddDate(0, 2, 0).Unix(), SubscriptionExpiryTime(subscription).Unix())
+	subscription.DurationLeft = 1
+	require.Equal(t, monthExpiry.Unix(), SubscriptionExpiryTime(subscription).Unix())
+}
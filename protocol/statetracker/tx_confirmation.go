@@ -0,0 +1,142 @@
+package statetracker
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/lavanet/lava/utils"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+const (
+	CallbackKeyForTxConfirmationUpdate = "tx-confirmation-update"
+	DefaultTxRebroadcastBlocks         = 5 // blocks a transaction can stay out of the chain before it's broadcast again
+	MaxTxRebroadcasts                  = 3
+)
+
+// TxConfirmation is the result of a transaction sent with confirmation, Height is 0 if it was never included
+type TxConfirmation struct {
+	TxHash       string
+	Height       int64
+	Success      bool
+	Code         uint32
+	Log          string
+	Events       []abci.Event
+	Rebroadcasts int
+}
+
+type TxConfirmationCallback func(confirmation TxConfirmation)
+
+type pendingTx struct {
+	txBytes      []byte
+	sentBlock    int64
+	rebroadcasts int
+	callback     TxConfirmationCallback
+}
+
+// TxConfirmationTracker looks for sent transactions in the blocks after them, and broadcasts the same signed transaction again
+// if it isn't included in time. rebroadcasting the same bytes can't include the transaction twice
+type TxConfirmationTracker struct {
+	lock              sync.Mutex
+	clientCtx         client.Context
+	rebroadcastBlocks int64
+	pending           map[string]*pendingTx // key is the tx hash
+	latestBlock       int64
+}
+
+func NewTxConfirmationTracker(clientCtx client.Context, rebroadcastBlocks int64) *TxConfirmationTracker {
+	return &TxConfirmationTracker{clientCtx: clientCtx, rebroadcastBlocks: rebroadcastBlocks, pending: map[string]*pendingTx{}}
+}
+
+// Track calls callback once the transaction is found in a block or given up on
+func (tct *TxConfirmationTracker) Track(txHash string, txBytes []byte, callback TxConfirmationCallback) {
+	tct.lock.Lock()
+	defer tct.lock.Unlock()
+	tct.pending[txHash] = &pendingTx{txBytes: txBytes, sentBlock: tct.latestBlock, callback: callback}
+}
+
+func (tct *TxConfirmationTracker) PendingTxs() int {
+	tct.lock.Lock()
+	defer tct.lock.Unlock()
+	return len(tct.pending)
+}
+
+// LatestBlock is the block of the last update, 0 before the first one
+func (tct *TxConfirmationTracker) LatestBlock() int64 {
+	tct.lock.Lock()
+	defer tct.lock.Unlock()
+	return tct.latestBlock
+}
+
+func (tct *TxConfirmationTracker) UpdaterKey() string {
+	return CallbackKeyForTxConfirmationUpdate
+}
+
+func (tct *TxConfirmationTracker) Update(latestBlock int64) error {
+	tct.lock.Lock()
+	tct.latestBlock = latestBlock
+	pending := make(map[string]*pendingTx, len(tct.pending))
+	for txHash, pendingTx := range tct.pending {
+		pending[txHash] = pendingTx
+	}
+	tct.lock.Unlock()
+	ctx := context.Background()
+	for txHash, pendingTx := range pending {
+		confirmation, done := tct.checkTx(ctx, txHash, pendingTx, latestBlock)
+		if !done {
+			continue
+		}
+		tct.lock.Lock()
+		delete(tct.pending, txHash)
+		tct.lock.Unlock()
+		if pendingTx.callback != nil {
+			pendingTx.callback(confirmation)
+		}
+	}
+	return nil
+}
+
+func (tct *TxConfirmationTracker) checkTx(ctx context.Context, txHash string, pendingTx *pendingTx, latestBlock int64) (confirmation TxConfirmation, done bool) {
+	confirmation = TxConfirmation{TxHash: txHash, Rebroadcasts: pendingTx.rebroadcasts}
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		confirmation.Log = "invalid tx hash"
+		return confirmation, true
+	}
+	res, err := tct.clientCtx.Client.Tx(ctx, hash, false)
+	if err == nil {
+		confirmation.Height = res.Height
+		confirmation.Code = res.TxResult.Code
+		confirmation.Success = res.TxResult.Code == 0
+		confirmation.Log = res.TxResult.Log
+		confirmation.Events = res.TxResult.Events
+		if !confirmation.Success {
+			utils.LavaFormatWarning("transaction was included and failed", nil, utils.Attribute{Key: "txHash", Value: txHash}, utils.Attribute{Key: "code", Value: confirmation.Code},
+				utils.Attribute{Key: "log", Value: confirmation.Log}, utils.Attribute{Key: "height", Value: confirmation.Height})
+		}
+		return confirmation, true
+	}
+	if pendingTx.sentBlock == 0 {
+		pendingTx.sentBlock = latestBlock // sent before the first block update
+	}
+	if latestBlock-pendingTx.sentBlock < tct.rebroadcastBlocks {
+		return confirmation, false
+	}
+	if pendingTx.rebroadcasts >= MaxTxRebroadcasts {
+		confirmation.Log = "transaction was not included in a block"
+		utils.LavaFormatError("transaction was not included after rebroadcasting it, giving up", err, utils.Attribute{Key: "txHash", Value: txHash}, utils.Attribute{Key: "rebroadcasts", Value: pendingTx.rebroadcasts})
+		return confirmation, true
+	}
+	pendingTx.rebroadcasts++
+	pendingTx.sentBlock = latestBlock
+	broadcastRes, err := tct.clientCtx.BroadcastTx(pendingTx.txBytes)
+	if err != nil {
+		utils.LavaFormatWarning("failed rebroadcasting transaction", err, utils.Attribute{Key: "txHash", Value: txHash}, utils.Attribute{Key: "rebroadcast", Value: pendingTx.rebroadcasts})
+		return confirmation, false
+	}
+	utils.LavaFormatInfo("rebroadcast a transaction that wasn't included", utils.Attribute{Key: "txHash", Value: txHash}, utils.Attribute{Key: "code", Value: broadcastRes.Code},
+		utils.Attribute{Key: "rebroadcast", Value: pendingTx.rebroadcasts}, utils.Attribute{Key: "block", Value: latestBlock})
+	return confirmation, false
+}
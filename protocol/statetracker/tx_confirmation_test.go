@@ -0,0 +1,116 @@
+package statetracker
+
+import (
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConfirmations collects the confirmations of the transactions sent with confirmation
+type recordingConfirmations struct {
+	lock          sync.Mutex
+	confirmations []TxConfirmation
+}
+
+func (rc *recordingConfirmations) callback(confirmation TxConfirmation) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	rc.confirmations = append(rc.confirmations, confirmation)
+}
+
+func (rc *recordingConfirmations) received() []TxConfirmation {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	return append([]TxConfirmation{}, rc.confirmations...)
+}
+
+func (ftc *fakeTxChain) acceptedHashes() []string {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	hashes := make([]string, 0, len(ftc.accepted))
+	for txHash := range ftc.accepted {
+		hashes = append(hashes, txHash)
+	}
+	return hashes
+}
+
+func TestTxConfirmationIncluded(t *testing.T) {
+	for _, code := range []uint32{0, 5} {
+		txSender, chain := newTestTxSender(t)
+		chain.autoInclude = false
+		recorder := &recordingConfirmations{}
+		confirmations := txSender.TxConfirmations()
+		require.NoError(t, confirmations.Update(100))
+		require.NoError(t, txSender.SendTxWithConfirmation(testTxMsg(txSender), false, recorder.callback))
+		require.Equal(t, 1, confirmations.PendingTxs())
+		require.NoError(t, confirmations.Update(101))
+		require.Empty(t, recorder.received())
+
+		txHash := chain.acceptedHashes()[0]
+		chain.include(txHash, code)
+		require.NoError(t, confirmations.Update(102))
+		require.Zero(t, confirmations.PendingTxs())
+		require.Len(t, recorder.received(), 1)
+		confirmation := recorder.received()[0]
+		require.Equal(t, txHash, confirmation.TxHash)
+		require.Equal(t, int64(101), confirmation.Height)
+		require.Equal(t, code == 0, confirmation.Success)
+		require.Equal(t, code, confirmation.Code)
+		require.Zero(t, confirmation.Rebroadcasts)
+	}
+}
+
+func TestTxConfirmationRebroadcast(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	chain.autoInclude = false
+	recorder := &recordingConfirmations{}
+	confirmations := txSender.TxConfirmations()
+	// sent before the first block update, the block it was sent in is the first update
+	require.NoError(t, txSender.SendTxWithConfirmation(testTxMsg(txSender), false, recorder.callback))
+	require.NoError(t, confirmations.Update(100))
+	require.NoError(t, confirmations.Update(100+DefaultTxRebroadcastBlocks-1))
+	require.Equal(t, 1, chain.broadcastCount())
+
+	// the same signed transaction is broadcast again every DefaultTxRebroadcastBlocks, it isn't sent twice
+	block := int64(100)
+	for rebroadcast := 1; rebroadcast <= MaxTxRebroadcasts; rebroadcast++ {
+		block += DefaultTxRebroadcastBlocks
+		require.NoError(t, confirmations.Update(block))
+		require.Equal(t, 1+rebroadcast, chain.broadcastCount())
+	}
+	require.Len(t, chain.sentTxs(), 1)
+	require.Empty(t, recorder.received())
+
+	// given up on after the last rebroadcast
+	require.NoError(t, confirmations.Update(block+DefaultTxRebroadcastBlocks))
+	require.Equal(t, []TxConfirmation{{TxHash: chain.acceptedHashes()[0], Rebroadcasts: MaxTxRebroadcasts, Log: "transaction was not included in a block"}}, recorder.received())
+	require.Zero(t, confirmations.PendingTxs())
+}
+
+func TestTxConfirmationIncludedAfterRebroadcast(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	chain.autoInclude = false
+	recorder := &recordingConfirmations{}
+	confirmations := txSender.TxConfirmations()
+	require.NoError(t, confirmations.Update(100))
+	require.NoError(t, txSender.SendTxWithConfirmation(testTxMsg(txSender), false, recorder.callback))
+	require.NoError(t, confirmations.Update(100+DefaultTxRebroadcastBlocks))
+	chain.include(chain.acceptedHashes()[0], 0)
+	require.NoError(t, confirmations.Update(101+DefaultTxRebroadcastBlocks))
+	require.Len(t, recorder.received(), 1)
+	require.True(t, recorder.received()[0].Success)
+	require.Equal(t, 1, recorder.received()[0].Rebroadcasts)
+
+	// a failed send isn't tracked
+	chain.requiredGas = 10 * testTxSimulatedGas
+	require.Error(t, txSender.SendTxWithConfirmation(testTxMsg(txSender), false, recorder.callback))
+	require.Zero(t, confirmations.PendingTxs())
+	// an invalid hash is given up on right away
+	confirmations.Track("not-hex", nil, recorder.callback)
+	require.NoError(t, confirmations.Update(102+DefaultTxRebroadcastBlocks))
+	require.Equal(t, "invalid tx hash", recorder.received()[1].Log)
+	_, err := hex.DecodeString(recorder.received()[0].TxHash)
+	require.NoError(t, err)
+}
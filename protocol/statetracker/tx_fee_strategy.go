@@ -0,0 +1,85 @@
+package statetracker
+
+import (
+	"math"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/lavanet/lava/utils"
+)
+
+const (
+	ClaimGasPricesFlag       = "claim-gas-prices"
+	ClaimGasAdjustmentFlag   = "claim-gas-adjustment"
+	ClaimFeeGranterFlag      = "claim-fee-granter"
+	ClaimMaxFeeFlag          = "claim-max-fee"
+	ClaimOutOfGasRetriesFlag = "claim-out-of-gas-retries"
+	DefaultOutOfGasRetries   = 2
+	outOfGasGasMultiplier    = 1.5 // the gas of a retry after an out of gas error
+)
+
+// TxFeeStrategy sets how the fee of a transaction is paid, the zero values keep the tx sender defaults
+type TxFeeStrategy struct {
+	GasPrices       string         // empty uses the default gas price
+	GasAdjustment   float64        // multiplier of the simulated gas, 0 uses the tx sender's
+	FeeGranter      sdk.AccAddress // pays the fee through a fee grant, empty pays from the account
+	MaxFee          sdk.Coins      // the transaction isn't sent if its fee is higher, empty is unlimited
+	OutOfGasRetries int            // sends with more gas after an out of gas error
+}
+
+func ParseTxFeeStrategy(gasPrices string, gasAdjustment float64, feeGranter string, maxFee string, outOfGasRetries int) (*TxFeeStrategy, error) {
+	strategy := &TxFeeStrategy{GasPrices: gasPrices, GasAdjustment: gasAdjustment, OutOfGasRetries: outOfGasRetries}
+	if gasPrices != "" {
+		if _, err := sdk.ParseDecCoins(gasPrices); err != nil {
+			return nil, utils.LavaFormatError("invalid gas prices", err, utils.Attribute{Key: "gasPrices", Value: gasPrices})
+		}
+	}
+	if gasAdjustment < 0 {
+		return nil, utils.LavaFormatError("invalid gas adjustment, can't be negative", nil, utils.Attribute{Key: "gasAdjustment", Value: gasAdjustment})
+	}
+	if outOfGasRetries < 0 {
+		return nil, utils.LavaFormatError("invalid out of gas retries, can't be negative", nil, utils.Attribute{Key: "outOfGasRetries", Value: outOfGasRetries})
+	}
+	if feeGranter != "" {
+		granter, err := sdk.AccAddressFromBech32(feeGranter)
+		if err != nil {
+			return nil, utils.LavaFormatError("invalid fee granter address", err, utils.Attribute{Key: "feeGranter", Value: feeGranter})
+		}
+		strategy.FeeGranter = granter
+	}
+	if maxFee != "" {
+		fee, err := sdk.ParseCoinsNormalized(maxFee)
+		if err != nil {
+			return nil, utils.LavaFormatError("invalid max fee", err, utils.Attribute{Key: "maxFee", Value: maxFee})
+		}
+		strategy.MaxFee = fee
+	}
+	return strategy, nil
+}
+
+// checkMaxFee fails if the fee of gas at gasPrices is above the max fee
+func (tfs *TxFeeStrategy) checkMaxFee(gasPrices string, gas uint64) error {
+	if tfs == nil || tfs.MaxFee.Empty() {
+		return nil
+	}
+	prices, err := sdk.ParseDecCoins(gasPrices)
+	if err != nil {
+		return err
+	}
+	fee := sdk.Coins{}
+	for _, price := range prices {
+		fee = fee.Add(sdk.NewCoin(price.Denom, price.Amount.MulInt64(int64(gas)).Ceil().RoundInt()))
+	}
+	if !fee.IsAllLTE(tfs.MaxFee) {
+		return utils.LavaFormatWarning("transaction fee is above the max fee, not sending it", nil, utils.Attribute{Key: "fee", Value: fee.String()}, utils.Attribute{Key: "maxFee", Value: tfs.MaxFee.String()}, utils.Attribute{Key: "gas", Value: gas})
+	}
+	return nil
+}
+
+func isOutOfGas(res *sdk.TxResponse) bool {
+	return res != nil && res.Codespace == sdkerrors.ErrOutOfGas.Codespace() && res.Code == sdkerrors.ErrOutOfGas.ABCICode()
+}
+
+func increasedGas(gas uint64) uint64 {
+	return uint64(math.Ceil(float64(gas) * outOfGasGasMultiplier))
+}
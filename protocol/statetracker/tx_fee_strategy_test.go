@@ -0,0 +1,102 @@
+package statetracker
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTxFeeStrategy(t *testing.T) {
+	granter := sdk.AccAddress([]byte("granter_____________"))
+	strategy, err := ParseTxFeeStrategy("0.5ulava", 1.2, granter.String(), "100ulava", 3)
+	require.NoError(t, err)
+	require.Equal(t, &TxFeeStrategy{GasPrices: "0.5ulava", GasAdjustment: 1.2, FeeGranter: granter, MaxFee: sdk.NewCoins(sdk.NewInt64Coin("ulava", 100)), OutOfGasRetries: 3}, strategy)
+	strategy, err = ParseTxFeeStrategy("", 0, "", "", 0)
+	require.NoError(t, err)
+	require.Equal(t, &TxFeeStrategy{}, strategy)
+
+	for name, parse := range map[string]func() (*TxFeeStrategy, error){
+		"gas prices":         func() (*TxFeeStrategy, error) { return ParseTxFeeStrategy("ulava", 0, "", "", 0) },
+		"gas adjustment":     func() (*TxFeeStrategy, error) { return ParseTxFeeStrategy("", -1, "", "", 0) },
+		"fee granter":        func() (*TxFeeStrategy, error) { return ParseTxFeeStrategy("", 0, "granter", "", 0) },
+		"max fee":            func() (*TxFeeStrategy, error) { return ParseTxFeeStrategy("", 0, "", "100", 0) },
+		"out of gas retries": func() (*TxFeeStrategy, error) { return ParseTxFeeStrategy("", 0, "", "", -1) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parse()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTxFeeStrategyMaxFee(t *testing.T) {
+	var noStrategy *TxFeeStrategy
+	require.NoError(t, noStrategy.checkMaxFee("1ulava", 1000))
+	require.NoError(t, (&TxFeeStrategy{}).checkMaxFee("1ulava", 1000))
+
+	strategy := &TxFeeStrategy{MaxFee: sdk.NewCoins(sdk.NewInt64Coin("ulava", 100))}
+	require.NoError(t, strategy.checkMaxFee("0.5ulava", 200))
+	// a fraction of a coin is rounded up
+	require.Error(t, strategy.checkMaxFee("0.5ulava", 201))
+	// a denom the max fee doesn't have is above it
+	require.Error(t, strategy.checkMaxFee("1uatom,0.5ulava", 10))
+	require.Error(t, strategy.checkMaxFee("invalid", 10))
+
+	require.Equal(t, uint64(150), increasedGas(100))
+	require.Equal(t, uint64(2), increasedGas(1))
+}
+
+func TestTxSenderOutOfGasRetries(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	strategy := &TxFeeStrategy{GasAdjustment: 1, OutOfGasRetries: DefaultOutOfGasRetries}
+	// the simulated gas runs out, the first retry has enough
+	chain.requiredGas = testTxSimulatedGas + 1
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithFeeStrategy(testTxMsg(txSender), false, strategy))
+	require.Equal(t, 2, chain.broadcastCount())
+	sent := chain.sentTxs()
+	require.Len(t, sent, 1)
+	require.Equal(t, increasedGas(testTxSimulatedGas), sent[0].gas)
+
+	// more gas than the retries reach
+	chain.requiredGas = increasedGas(increasedGas(testTxSimulatedGas)) + 1
+	require.Error(t, txSender.SimulateAndBroadCastTxWithFeeStrategy(testTxMsg(txSender), false, strategy))
+	require.Equal(t, 2+1+DefaultOutOfGasRetries, chain.broadcastCount())
+}
+
+func TestTxSenderOutOfGasWithoutRetries(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	chain.requiredGas = 10 * testTxSimulatedGas
+	// the same gas would run out again, it isn't sent again
+	require.Error(t, txSender.SimulateAndBroadCastTxWithFeeStrategy(testTxMsg(txSender), false, &TxFeeStrategy{GasAdjustment: 1}))
+	require.Equal(t, 1, chain.broadcastCount())
+	require.Error(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	require.Equal(t, 2, chain.broadcastCount())
+}
+
+func TestTxSenderMaxFee(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	// the simulated gas at 1ulava costs more than the max fee, nothing is sent
+	strategy := &TxFeeStrategy{GasPrices: "1ulava", GasAdjustment: 1, MaxFee: sdk.NewCoins(sdk.NewInt64Coin("ulava", testTxSimulatedGas-1)), OutOfGasRetries: DefaultOutOfGasRetries}
+	require.Error(t, txSender.SimulateAndBroadCastTxWithFeeStrategy(testTxMsg(txSender), false, strategy))
+	require.Zero(t, chain.broadcastCount())
+
+	// an out of gas retry above the max fee isn't sent
+	strategy.MaxFee = sdk.NewCoins(sdk.NewInt64Coin("ulava", testTxSimulatedGas))
+	chain.requiredGas = testTxSimulatedGas + 1
+	require.Error(t, txSender.SimulateAndBroadCastTxWithFeeStrategy(testTxMsg(txSender), false, strategy))
+	require.Equal(t, 1, chain.broadcastCount())
+}
+
+func TestTxSenderFeeGranter(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	granter := sdk.AccAddress([]byte("granter_____________"))
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithFeeStrategy(testTxMsg(txSender), false, &TxFeeStrategy{FeeGranter: granter}))
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	granters := map[string]bool{}
+	for _, sent := range chain.sentTxs() {
+		granters[sent.feeGranter.String()] = true
+	}
+	// only the transaction of the strategy has the granter pay its fee
+	require.Equal(t, map[string]bool{granter.String(): true, "": true}, granters)
+}
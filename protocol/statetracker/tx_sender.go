@@ -1,44 +1,138 @@
 package statetracker
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/tx"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	typestx "github.com/cosmos/cosmos-sdk/types/tx"
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	"github.com/lavanet/lava/protocol/common"
 	"github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
 	"github.com/lavanet/lava/utils"
 	conflicttypes "github.com/lavanet/lava/x/conflict/types"
 	pairingtypes "github.com/lavanet/lava/x/pairing/types"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
-	defaultGasPrice      = "0.000000001ulava"
-	defaultGasAdjustment = 1.5
+	defaultGasPrice        = "0.000000001ulava"
+	DefaultTxGasAdjustment = 1.5
+	TxGasAdjustmentFlag    = "tx-gas-adjustment"
+	TxMaxPerBlockFlag      = "tx-max-per-block"
+	txBlockCapacityPoll    = time.Second // how often a throttled queue checks for a new block
+	TxQueueSize            = 100         // transactions waiting to be sent, callers block while the queue is full
 	// same account can continue failing the more providers you have under the same account
 	// for example if you have a provider staked at 20 chains you will ask for 20 payments per epoch.
 	// therefore currently our best solution is to continue retrying increasing sequence number until successful
 	RETRY_INCORRECT_SEQUENCE = 100
 )
 
+var (
+	txQueueLengthGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lava_tx_sender_queue_length",
+		Help: "The number of lava transactions waiting to be sent",
+	})
+	txSentCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_tx_sender_transactions_total",
+		Help: "The number of lava transactions sent by message type and result",
+	}, []string{"msg", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(txQueueLengthGauge, txSentCounter)
+}
+
+type txRequest struct {
+	msg                sdk.Msg
+	checkProfitability bool
+	feeStrategy        *TxFeeStrategy // nil for the defaults
+	result             chan txResult
+}
+
+type txResult struct {
+	txHash  string
+	txBytes []byte
+	err     error
+}
+
+// TxSender sends the lava transactions of all the components one at a time from a queue, so they don't race on the account sequence.
+// the sequence is tracked locally between transactions and queried again after a failure
 type TxSender struct {
-	txFactory tx.Factory
-	clientCtx client.Context
+	lock              sync.RWMutex
+	txFactory         tx.Factory
+	clientCtx         client.Context
+	gasAdjustment     float64
+	queue             chan txRequest
+	done              <-chan struct{}
+	sequence          uint64 // the sequence of the next transaction, 0 if it has to be queried. only accessed by the queue
+	confirmations     *TxConfirmationTracker
+	maxPerBlock       int           // 0 is unlimited
+	blockTxs          int           // transactions sent since blockTxsHeight, only accessed by the queue
+	blockTxsHeight    int64         // the latest block when they were sent, only accessed by the queue
+	blockCapacityPoll time.Duration // how often a throttled queue checks for a new block
 }
 
 func NewTxSender(ctx context.Context, clientCtx client.Context, txFactory tx.Factory) (ret *TxSender, err error) {
 	// set up the rpcClient, and factory necessary to make queries
 	clientCtx.SkipConfirm = true
-	ts := &TxSender{txFactory: txFactory, clientCtx: clientCtx}
+	ts := &TxSender{txFactory: txFactory, clientCtx: clientCtx, gasAdjustment: DefaultTxGasAdjustment, blockCapacityPoll: txBlockCapacityPoll, queue: make(chan txRequest, TxQueueSize), done: ctx.Done()}
+	ts.confirmations = NewTxConfirmationTracker(clientCtx, DefaultTxRebroadcastBlocks)
+	go ts.processQueue(ctx)
 	return ts, nil
 }
 
+// SetGasAdjustment sets the multiplier of the simulated gas of every transaction
+func (ts *TxSender) SetGasAdjustment(gasAdjustment float64) error {
+	if gasAdjustment <= 0 {
+		return utils.LavaFormatError("invalid gas adjustment, must be positive", nil, utils.Attribute{Key: "gasAdjustment", Value: gasAdjustment})
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	ts.gasAdjustment = gasAdjustment
+	return nil
+}
+
+// SetMaxTxsPerBlock sets how many transactions are sent while the latest block doesn't change, the rest wait for the next block
+func (ts *TxSender) SetMaxTxsPerBlock(maxPerBlock int) error {
+	if maxPerBlock < 0 {
+		return utils.LavaFormatError("invalid max transactions per block, can't be negative", nil, utils.Attribute{Key: "maxPerBlock", Value: maxPerBlock})
+	}
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	ts.maxPerBlock = maxPerBlock
+	return nil
+}
+
+func (ts *TxSender) processQueue(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case request := <-ts.queue:
+			txQueueLengthGauge.Set(float64(len(ts.queue)))
+			if !ts.waitForBlockCapacity(ctx) {
+				return
+			}
+			txHash, txBytes, err := ts.sendTx(request.msg, request.checkProfitability, request.feeStrategy)
+			result := "success"
+			if err != nil {
+				result = "failure"
+			} else {
+				ts.blockTxs++
+			}
+			txSentCounter.WithLabelValues(sdk.MsgTypeURL(request.msg), result).Inc()
+			request.result <- txResult{txHash: txHash, txBytes: txBytes, err: err}
+		}
+	}
+}
+
 func (ts *TxSender) checkProfitability(simResult *typestx.SimulateResponse, gasUsed uint64, txFactory tx.Factory) error {
 	txEvents := simResult.GetResult().Events
 	lavaReward := sdk.NewCoin("ulava", sdk.NewInt(0))
@@ -69,32 +163,144 @@ func (ts *TxSender) checkProfitability(simResult *typestx.SimulateResponse, gasU
 	return nil
 }
 
+// SimulateAndBroadCastTxWithRetryOnSeqMismatch queues the transaction and waits until it was sent
 func (ts *TxSender) SimulateAndBroadCastTxWithRetryOnSeqMismatch(msg sdk.Msg, checkProfitability bool) error {
-	txfactory := ts.txFactory.WithGasPrices(defaultGasPrice)
-	txfactory = txfactory.WithGasAdjustment(defaultGasAdjustment)
+	result := ts.enqueue(msg, checkProfitability, nil)
+	return result.err
+}
+
+// SimulateAndBroadCastTxWithFeeStrategy queues the transaction to be sent with the fee strategy and waits until it was sent
+func (ts *TxSender) SimulateAndBroadCastTxWithFeeStrategy(msg sdk.Msg, checkProfitability bool, feeStrategy *TxFeeStrategy) error {
+	result := ts.enqueue(msg, checkProfitability, feeStrategy)
+	return result.err
+}
+
+// SendTxWithConfirmation queues the transaction and calls onConfirmation once it was included in a block,
+// or when it wasn't included after all the rebroadcasts. onConfirmation isn't called if sending failed
+func (ts *TxSender) SendTxWithConfirmation(msg sdk.Msg, checkProfitability bool, onConfirmation TxConfirmationCallback) error {
+	result := ts.enqueue(msg, checkProfitability, nil)
+	if result.err != nil {
+		return result.err
+	}
+	ts.confirmations.Track(result.txHash, result.txBytes, onConfirmation)
+	return nil
+}
+
+// TxConfirmations is the updater that watches the blocks for the transactions sent with confirmation
+func (ts *TxSender) TxConfirmations() *TxConfirmationTracker {
+	return ts.confirmations
+}
+
+// waitForBlockCapacity waits for a new block once the transactions sent in the latest one reached the max, false if ctx is done.
+// before the first block update the latest block isn't known and nothing is throttled
+func (ts *TxSender) waitForBlockCapacity(ctx context.Context) bool {
+	ts.lock.RLock()
+	maxPerBlock := ts.maxPerBlock
+	ts.lock.RUnlock()
+	if maxPerBlock <= 0 {
+		return true
+	}
+	logged := false
+	for {
+		latestBlock := ts.confirmations.LatestBlock()
+		if latestBlock == 0 || latestBlock != ts.blockTxsHeight {
+			ts.blockTxsHeight = latestBlock
+			ts.blockTxs = 0
+			return true
+		}
+		if ts.blockTxs < maxPerBlock {
+			return true
+		}
+		if !logged {
+			utils.LavaFormatDebug("max transactions per block sent, waiting for the next block", utils.Attribute{Key: "block", Value: latestBlock}, utils.Attribute{Key: "maxPerBlock", Value: maxPerBlock}, utils.Attribute{Key: "queued", Value: len(ts.queue)})
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(ts.blockCapacityPoll):
+		}
+	}
+}
+
+func (ts *TxSender) enqueue(msg sdk.Msg, checkProfitability bool, feeStrategy *TxFeeStrategy) txResult {
 	if err := msg.ValidateBasic(); err != nil {
-		return err
+		return txResult{err: err}
 	}
+	request := txRequest{msg: msg, checkProfitability: checkProfitability, feeStrategy: feeStrategy, result: make(chan txResult, 1)}
+	select {
+	case ts.queue <- request:
+		txQueueLengthGauge.Set(float64(len(ts.queue)))
+	case <-ts.done:
+		return txResult{err: utils.LavaFormatWarning("tx sender stopped, transaction was not sent", nil, utils.Attribute{Key: "msg", Value: sdk.MsgTypeURL(msg)})}
+	}
+	select {
+	case result := <-request.result:
+		return result
+	case <-ts.done:
+		return txResult{err: utils.LavaFormatWarning("tx sender stopped, transaction was not sent", nil, utils.Attribute{Key: "msg", Value: sdk.MsgTypeURL(msg)})}
+	}
+}
+
+func (ts *TxSender) sendTx(msg sdk.Msg, checkProfitability bool, feeStrategy *TxFeeStrategy) (txHash string, txBytes []byte, err error) {
+	ts.lock.RLock()
+	gasAdjustment := ts.gasAdjustment
+	ts.lock.RUnlock()
+	gasPrices := defaultGasPrice
 	clientCtx := ts.clientCtx
-	txfactory, err := ts.prepareFactory(txfactory)
+	outOfGasRetries := 0
+	if feeStrategy != nil {
+		if feeStrategy.GasPrices != "" {
+			gasPrices = feeStrategy.GasPrices
+		}
+		if feeStrategy.GasAdjustment > 0 {
+			gasAdjustment = feeStrategy.GasAdjustment
+		}
+		if !feeStrategy.FeeGranter.Empty() {
+			clientCtx = clientCtx.WithFeeGranterAddress(feeStrategy.FeeGranter)
+		}
+		outOfGasRetries = feeStrategy.OutOfGasRetries
+	}
+	txfactory := ts.txFactory.WithGasPrices(gasPrices)
+	txfactory = txfactory.WithGasAdjustment(gasAdjustment)
+	txfactory = txfactory.WithSequence(ts.sequence)
+	txfactory, err = ts.prepareFactory(txfactory)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	simResult, gasUsed, err := tx.CalculateGas(clientCtx, txfactory, msg)
+	if err != nil && strings.Contains(err.Error(), "account sequence") {
+		// the tracked sequence is stale, another client sent from the account
+		sequenceNumberParsed, parseErr := common.FindSequenceNumber(err.Error())
+		if parseErr != nil {
+			_, seq, seqErr := clientCtx.AccountRetriever.GetAccountNumberSequence(clientCtx, clientCtx.GetFromAddress())
+			if seqErr != nil {
+				ts.sequence = 0
+				return "", nil, seqErr
+			}
+			sequenceNumberParsed = int(seq)
+		}
+		txfactory = txfactory.WithSequence(uint64(sequenceNumberParsed))
+		simResult, gasUsed, err = tx.CalculateGas(clientCtx, txfactory, msg)
+	}
 	if err != nil {
-		return err
+		ts.sequence = 0
+		return "", nil, err
 	}
 
 	if checkProfitability {
 		err := ts.checkProfitability(simResult, gasUsed, txfactory)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 	}
 
+	err = feeStrategy.checkMaxFee(gasPrices, gasUsed)
+	if err != nil {
+		return "", nil, err
+	}
 	txfactory = txfactory.WithGas(gasUsed)
-	myWriter := bytes.Buffer{}
 	hasSequenceError := false
 	success := false
 	idx := -1
@@ -118,23 +324,36 @@ func (ts *TxSender) SimulateAndBroadCastTxWithRetryOnSeqMismatch(msg sdk.Msg, ch
 				}
 			}
 			txfactory = txfactory.WithSequence(seq)
-			myWriter.Reset()
 			utils.LavaFormatInfo("Retrying with sequence number:", utils.Attribute{Key: "SeqNum", Value: seq})
 		}
 		var transactionResult string
-		clientCtx.Output = &myWriter
-		err = tx.GenerateOrBroadcastTxWithFactory(clientCtx, txfactory, msg)
+		returnCode := uint32(1) // not zero
+		var res *sdk.TxResponse
+		txBytes, res, err = ts.signAndBroadcast(clientCtx, txfactory, msg)
 		if err != nil {
 			utils.LavaFormatWarning("Sending CheckProfitabilityAndBroadCastTx failed", err, utils.Attribute{Key: "msg", Value: msg})
 			transactionResult = err.Error() // incase we got an error the tx result is basically the error
+			summarizedTransactionResult = transactionResult
 		} else {
-			transactionResult = myWriter.String()
+			transactionResult = res.RawLog
+			returnCode = res.Code
+			txHash = res.TxHash
+			summarizedTransactionResult = fmt.Sprintf("txhash:%s, code:%d, raw_log:%s", res.TxHash, res.Code, res.RawLog)
 		}
-		var returnCode int
-		summarizedTransactionResult, returnCode = common.ParseTransactionResult(transactionResult)
-		// utils.LavaFormatDebug("parsed transaction code", utils.Attribute{"code",  strconv.Itoa(returnCode)}, "transactionResult": transactionResult})
 		if returnCode == 0 { // if we get some other code which isn't 0 then keep retrying
 			success = true
+		} else if isOutOfGas(res) {
+			if outOfGasRetries == 0 {
+				break // the same gas would run out again
+			}
+			outOfGasRetries--
+			gasUsed = increasedGas(gasUsed)
+			if err := feeStrategy.checkMaxFee(gasPrices, gasUsed); err != nil {
+				break
+			}
+			utils.LavaFormatInfo("transaction ran out of gas, retrying with more gas", utils.Attribute{Key: "gas", Value: gasUsed}, utils.Attribute{Key: "msg", Value: sdk.MsgTypeURL(msg)})
+			txfactory = txfactory.WithGas(gasUsed)
+			hasSequenceError = false
 		} else if strings.Contains(transactionResult, "account sequence") {
 			hasSequenceError = true
 			sequenceNumberParsed, err = common.FindSequenceNumber(transactionResult)
@@ -145,10 +364,34 @@ func (ts *TxSender) SimulateAndBroadCastTxWithRetryOnSeqMismatch(msg sdk.Msg, ch
 		}
 	}
 	if !success {
-		return utils.LavaFormatError(fmt.Sprintf("failed sending transaction %s", summarizedTransactionResult), nil)
+		ts.sequence = 0
+		return "", nil, utils.LavaFormatError(fmt.Sprintf("failed sending transaction %s", summarizedTransactionResult), nil)
 	}
+	ts.sequence = txfactory.Sequence() + 1
 	utils.LavaFormatInfo(fmt.Sprintf("succeeded sending transaction %s", summarizedTransactionResult))
-	return nil
+	return txHash, txBytes, nil
+}
+
+// signAndBroadcast is the broadcast part of tx.BroadcastTx, the encoded tx is returned so it can be broadcast again
+func (ts *TxSender) signAndBroadcast(clientCtx client.Context, txf tx.Factory, msg sdk.Msg) (txBytes []byte, res *sdk.TxResponse, err error) {
+	unsignedTx, err := tx.BuildUnsignedTx(txf, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	unsignedTx.SetFeeGranter(clientCtx.GetFeeGranterAddress())
+	err = tx.Sign(txf, clientCtx.GetFromName(), unsignedTx, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	txBytes, err = clientCtx.TxConfig.TxEncoder()(unsignedTx.GetTx())
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err = clientCtx.BroadcastTx(txBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return txBytes, res, nil
 }
 
 // this function is extracted from the tx package so that we can use it locally to set the tx factory correctly
@@ -205,6 +448,7 @@ func (ts *ConsumerTxSender) TxConflictDetection(ctx context.Context, finalizatio
 
 type ProviderTxSender struct {
 	*TxSender
+	claimFeeStrategy *TxFeeStrategy
 }
 
 func NewProviderTxSender(ctx context.Context, clientCtx client.Context, txFactory tx.Factory) (ret *ProviderTxSender, err error) {
@@ -216,15 +460,60 @@ func NewProviderTxSender(ctx context.Context, clientCtx client.Context, txFactor
 	return ts, nil
 }
 
+// SetClaimFeeStrategy sets how the fee of the reward claims is paid, it isn't safe to call while claims are sent
+func (pts *ProviderTxSender) SetClaimFeeStrategy(feeStrategy *TxFeeStrategy) {
+	pts.claimFeeStrategy = feeStrategy
+}
+
 func (pts *ProviderTxSender) TxRelayPayment(ctx context.Context, relayRequests []*pairingtypes.RelaySession, dataReliabilityProofs []*pairingtypes.VRFData, description string) error {
 	msg := pairingtypes.NewMsgRelayPayment(pts.clientCtx.FromAddress.String(), relayRequests, dataReliabilityProofs, description)
-	err := pts.SimulateAndBroadCastTxWithRetryOnSeqMismatch(msg, true)
+	err := pts.SimulateAndBroadCastTxWithFeeStrategy(msg, true, pts.claimFeeStrategy)
 	if err != nil {
 		return utils.LavaFormatError("relay_payment - sending Tx Failed", err)
 	}
 	return nil
 }
 
+// TxUnfreezeProvider unfreezes the provider on the chains, it's back in the pairing from the next epoch
+func (pts *ProviderTxSender) TxUnfreezeProvider(ctx context.Context, chainIDs []string) error {
+	msg := pairingtypes.NewMsgUnfreeze(pts.clientCtx.FromAddress.String(), chainIDs)
+	err := pts.SendTxWithConfirmation(msg, false, func(confirmation TxConfirmation) {
+		if !confirmation.Success {
+			utils.LavaFormatError("unfreeze transaction failed", nil, utils.Attribute{Key: "chainIDs", Value: chainIDs}, utils.Attribute{Key: "txHash", Value: confirmation.TxHash}, utils.Attribute{Key: "log", Value: confirmation.Log})
+			return
+		}
+		utils.LavaFormatInfo("unfreeze transaction was included", utils.Attribute{Key: "chainIDs", Value: chainIDs}, utils.Attribute{Key: "height", Value: confirmation.Height})
+	})
+	if err != nil {
+		return utils.LavaFormatError("TxUnfreezeProvider - SimulateAndBroadCastTx Failed", err, utils.Attribute{Key: "chainIDs", Value: chainIDs})
+	}
+	return nil
+}
+
+// TxClaimDelegatorRewards withdraws the rewards of the provider's delegations to the validators, a transaction per validator
+func (pts *ProviderTxSender) TxClaimDelegatorRewards(ctx context.Context, validators []string) error {
+	delegator := pts.clientCtx.FromAddress
+	var firstErr error
+	for _, validator := range validators {
+		validatorAddress, err := sdk.ValAddressFromBech32(validator)
+		if err != nil {
+			return utils.LavaFormatError("invalid validator address to claim rewards from", err, utils.Attribute{Key: "validator", Value: validator})
+		}
+		msg := distributiontypes.NewMsgWithdrawDelegatorReward(delegator, validatorAddress)
+		err = pts.SendTxWithConfirmation(msg, false, func(confirmation TxConfirmation) {
+			if !confirmation.Success {
+				utils.LavaFormatError("delegator rewards claim transaction failed", nil, utils.Attribute{Key: "validator", Value: validator}, utils.Attribute{Key: "txHash", Value: confirmation.TxHash}, utils.Attribute{Key: "log", Value: confirmation.Log})
+				return
+			}
+			utils.LavaFormatInfo("delegator rewards claim transaction was included", utils.Attribute{Key: "validator", Value: validator}, utils.Attribute{Key: "height", Value: confirmation.Height})
+		})
+		if err != nil && firstErr == nil {
+			firstErr = utils.LavaFormatError("TxClaimDelegatorRewards - SimulateAndBroadCastTx Failed", err, utils.Attribute{Key: "validator", Value: validator})
+		}
+	}
+	return firstErr
+}
+
 func (pts *ProviderTxSender) SendVoteReveal(voteID string, vote *reliabilitymanager.VoteData) error {
 	msg := conflicttypes.NewMsgConflictVoteReveal(pts.clientCtx.FromAddress.String(), voteID, vote.Nonce, vote.RelayDataHash)
 	err := pts.SimulateAndBroadCastTxWithRetryOnSeqMismatch(msg, false)
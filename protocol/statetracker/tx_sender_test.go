@@ -0,0 +1,345 @@
+package statetracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	typestx "github.com/cosmos/cosmos-sdk/types/tx"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/lavanet/lava/app"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	testTxChainID       = "lava-testnet"
+	testTxKeyName       = "provider"
+	testTxAccountNumber = 7
+	testTxSimulatedGas  = 100000
+)
+
+var errTxNotFound = errors.New("tx not found")
+
+// sentTx is a transaction the fake chain accepted
+type sentTx struct {
+	sequence   uint64
+	gas        uint64
+	feeGranter sdk.AccAddress
+}
+
+// fakeTxChain is a lava node for the tx sender, it simulates, checks the sequence and gas of broadcast transactions and includes them.
+// only the methods the tx sender and the confirmation tracker use are implemented
+type fakeTxChain struct {
+	rpcclient.Client
+	lock        sync.Mutex
+	txConfig    client.TxConfig
+	account     *authtypes.BaseAccount // the sequence is of the accepted transactions, as in the mempool
+	committed   uint64                 // the sequence of the included transactions, the account query returns it
+	height      int64
+	requiredGas uint64 // a transaction with less gas runs out of it, 0 if any gas is enough
+	autoInclude bool   // accepted transactions are included right away
+	accepted    map[string]sentTx
+	included    map[string]*ctypes.ResultTx // key is the upper case hex tx hash, as tendermint prints it
+	broadcasts  int
+}
+
+func (ftc *fakeTxChain) ABCIQueryWithOptions(ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	var response interface{ Marshal() ([]byte, error) }
+	switch path {
+	case "/cosmos.auth.v1beta1.Query/Account":
+		committedAccount := *ftc.account
+		committedAccount.Sequence = ftc.committed
+		account, err := codectypes.NewAnyWithValue(&committedAccount)
+		if err != nil {
+			return nil, err
+		}
+		response = &authtypes.QueryAccountResponse{Account: account}
+	case "/cosmos.tx.v1beta1.Service/Simulate":
+		request := typestx.SimulateRequest{}
+		if err := request.Unmarshal(data); err != nil {
+			return nil, err
+		}
+		sent, err := ftc.decodeUnsafe(request.TxBytes)
+		if err != nil {
+			return nil, err
+		}
+		if sent.sequence != ftc.account.Sequence {
+			return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Code: sdkerrors.ErrWrongSequence.ABCICode(), Codespace: sdkerrors.ErrWrongSequence.Codespace(), Log: ftc.sequenceMismatchUnsafe(sent.sequence)}}, nil
+		}
+		response = &typestx.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: testTxSimulatedGas}, Result: &sdk.Result{}}
+	default:
+		return nil, fmt.Errorf("unexpected query %s", path)
+	}
+	value, err := response.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{Value: value, Height: ftc.height}}, nil
+}
+
+func (ftc *fakeTxChain) BroadcastTxSync(ctx context.Context, txBytes tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	ftc.broadcasts++
+	result := &ctypes.ResultBroadcastTx{Hash: txBytes.Hash()}
+	sent, err := ftc.decodeUnsafe(txBytes)
+	if err != nil {
+		return nil, err
+	}
+	txHash := fmt.Sprintf("%X", txBytes.Hash())
+	if _, ok := ftc.accepted[txHash]; ok {
+		return result, nil // a rebroadcast of an accepted transaction
+	}
+	switch {
+	case sent.sequence != ftc.account.Sequence:
+		result.Code, result.Codespace, result.Log = sdkerrors.ErrWrongSequence.ABCICode(), sdkerrors.ErrWrongSequence.Codespace(), ftc.sequenceMismatchUnsafe(sent.sequence)
+	case sent.gas < ftc.requiredGas:
+		result.Code, result.Codespace, result.Log = sdkerrors.ErrOutOfGas.ABCICode(), sdkerrors.ErrOutOfGas.Codespace(), "out of gas"
+	default:
+		ftc.account.Sequence++
+		ftc.accepted[txHash] = sent
+		if ftc.autoInclude {
+			ftc.includeUnsafe(txHash, 0)
+		}
+	}
+	return result, nil
+}
+
+func (ftc *fakeTxChain) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	result, ok := ftc.included[fmt.Sprintf("%X", hash)]
+	if !ok {
+		return nil, errTxNotFound
+	}
+	return result, nil
+}
+
+func (ftc *fakeTxChain) decodeUnsafe(txBytes []byte) (sentTx, error) {
+	decoded, err := ftc.txConfig.TxDecoder()(txBytes)
+	if err != nil {
+		return sentTx{}, err
+	}
+	signatures, err := decoded.(authsigning.SigVerifiableTx).GetSignaturesV2()
+	if err != nil || len(signatures) != 1 {
+		return sentTx{}, fmt.Errorf("expected a single signature: %w", err)
+	}
+	feeTx := decoded.(sdk.FeeTx)
+	return sentTx{sequence: signatures[0].Sequence, gas: feeTx.GetGas(), feeGranter: feeTx.FeeGranter()}, nil
+}
+
+func (ftc *fakeTxChain) sequenceMismatchUnsafe(sequence uint64) string {
+	return fmt.Sprintf("account sequence mismatch, expected %d, got %d: incorrect account sequence", ftc.account.Sequence, sequence)
+}
+
+func (ftc *fakeTxChain) includeUnsafe(txHash string, code uint32) {
+	if sent := ftc.accepted[txHash]; sent.sequence >= ftc.committed {
+		ftc.committed = sent.sequence + 1
+	}
+	ftc.included[txHash] = &ctypes.ResultTx{Height: ftc.height + 1, TxResult: abci.ResponseDeliverTx{Code: code}}
+}
+
+// include adds an accepted transaction to the next block
+func (ftc *fakeTxChain) include(txHash string, code uint32) {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	ftc.includeUnsafe(txHash, code)
+}
+
+// sendFromAnotherClient uses a sequence of the account, as another client sending from the same key
+func (ftc *fakeTxChain) sendFromAnotherClient() {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	ftc.account.Sequence++
+	ftc.committed = ftc.account.Sequence
+}
+
+func (ftc *fakeTxChain) sentTxs() []sentTx {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	sent := make([]sentTx, 0, len(ftc.accepted))
+	for _, accepted := range ftc.accepted {
+		sent = append(sent, accepted)
+	}
+	return sent
+}
+
+func (ftc *fakeTxChain) sentSequences() map[uint64]bool {
+	sequences := map[uint64]bool{}
+	for _, sent := range ftc.sentTxs() {
+		sequences[sent.sequence] = true
+	}
+	return sequences
+}
+
+func (ftc *fakeTxChain) broadcastCount() int {
+	ftc.lock.Lock()
+	defer ftc.lock.Unlock()
+	return ftc.broadcasts
+}
+
+// newTestTxSender creates a tx sender signing with an in memory key and sending to a fake chain, it stops when the test ends
+func newTestTxSender(t *testing.T) (*TxSender, *fakeTxChain) {
+	encodingConfig := app.MakeEncodingConfig()
+	kr := keyring.NewInMemory()
+	info, _, err := kr.NewMnemonic(testTxKeyName, keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+	require.NoError(t, err)
+	chain := &fakeTxChain{
+		txConfig:    encodingConfig.TxConfig,
+		account:     authtypes.NewBaseAccount(info.GetAddress(), info.GetPubKey(), testTxAccountNumber, 3),
+		committed:   3,
+		height:      100,
+		autoInclude: true,
+		accepted:    map[string]sentTx{},
+		included:    map[string]*ctypes.ResultTx{},
+	}
+	clientCtx := client.Context{}.WithClient(chain).WithTxConfig(encodingConfig.TxConfig).WithInterfaceRegistry(encodingConfig.InterfaceRegistry).WithCodec(encodingConfig.Marshaler).
+		WithAccountRetriever(authtypes.AccountRetriever{}).WithFromAddress(info.GetAddress()).WithFromName(testTxKeyName).WithKeyring(kr).WithBroadcastMode(flags.BroadcastSync).WithChainID(testTxChainID)
+	txFactory := tx.Factory{}.WithTxConfig(encodingConfig.TxConfig).WithKeybase(kr).WithChainID(testTxChainID).WithAccountRetriever(authtypes.AccountRetriever{})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	txSender, err := NewTxSender(ctx, clientCtx, txFactory)
+	require.NoError(t, err)
+	return txSender, chain
+}
+
+func testTxMsg(txSender *TxSender) sdk.Msg {
+	return banktypes.NewMsgSend(txSender.clientCtx.FromAddress, txSender.clientCtx.FromAddress, sdk.NewCoins(sdk.NewInt64Coin("ulava", 1)))
+}
+
+func TestTxSenderTracksSequence(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	chain.autoInclude = false
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	// the first transaction isn't included yet so the account still has its sequence, the tracked sequence is used
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	require.Equal(t, uint64(5), txSender.sequence)
+	require.Equal(t, 2, chain.broadcastCount())
+
+	// another client sent from the account, the stale sequence is corrected from the simulation error
+	chain.sendFromAnotherClient()
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	require.Equal(t, uint64(7), txSender.sequence)
+	require.Equal(t, 3, chain.broadcastCount())
+	require.Equal(t, map[uint64]bool{3: true, 4: true, 6: true}, chain.sentSequences())
+
+	// a failed transaction makes the next one query the sequence
+	chain.requiredGas = 10 * testTxSimulatedGas
+	require.Error(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	require.Zero(t, txSender.sequence)
+}
+
+func TestTxSenderSerializesTransactions(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	const senders = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, senders)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	// concurrent components don't race on the sequence, every transaction was accepted on its first broadcast
+	require.Len(t, chain.sentTxs(), senders)
+	require.Equal(t, senders, chain.broadcastCount())
+}
+
+func TestTxSenderRejectsInvalidMsg(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	msg := banktypes.NewMsgSend(txSender.clientCtx.FromAddress, txSender.clientCtx.FromAddress, sdk.Coins{})
+	require.Error(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(msg, false))
+	require.Zero(t, chain.broadcastCount())
+	require.Error(t, txSender.SetGasAdjustment(0))
+}
+
+func TestTxSenderStopped(t *testing.T) {
+	txSender, _ := newTestTxSender(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stopped, err := NewTxSender(ctx, txSender.clientCtx, txSender.txFactory)
+	require.NoError(t, err)
+	require.Error(t, stopped.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+}
+
+func TestTxSenderMaxTxsPerBlock(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	txSender.blockCapacityPoll = time.Millisecond
+	require.Error(t, txSender.SetMaxTxsPerBlock(-1))
+	require.NoError(t, txSender.SetMaxTxsPerBlock(1))
+	// before the first block the latest block isn't known, nothing is throttled
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+
+	require.NoError(t, txSender.TxConfirmations().Update(101))
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	sent := make(chan error, 1)
+	go func() {
+		sent <- txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false)
+	}()
+	// the second transaction of the block waits for the next one
+	require.Never(t, func() bool { return chain.broadcastCount() > 3 }, 50*time.Millisecond, time.Millisecond)
+	require.NoError(t, txSender.TxConfirmations().Update(102))
+	require.NoError(t, <-sent)
+	require.Equal(t, 4, chain.broadcastCount())
+}
+
+func TestTxSenderMaxTxsPerBlockFailedTx(t *testing.T) {
+	txSender, chain := newTestTxSender(t)
+	txSender.blockCapacityPoll = time.Millisecond
+	require.NoError(t, txSender.SetMaxTxsPerBlock(1))
+	require.NoError(t, txSender.TxConfirmations().Update(101))
+	// a transaction that wasn't sent doesn't take the block's capacity
+	chain.requiredGas = 10 * testTxSimulatedGas
+	require.Error(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	chain.lock.Lock()
+	chain.requiredGas = 0
+	chain.lock.Unlock()
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+}
+
+func TestTxSenderMaxTxsPerBlockStopped(t *testing.T) {
+	testTxSender, chain := newTestTxSender(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	txSender, err := NewTxSender(ctx, testTxSender.clientCtx, testTxSender.txFactory)
+	require.NoError(t, err)
+	txSender.blockCapacityPoll = time.Millisecond
+	require.NoError(t, txSender.SetMaxTxsPerBlock(1))
+	require.NoError(t, txSender.TxConfirmations().Update(101))
+	require.NoError(t, txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false))
+	sent := make(chan error, 1)
+	go func() {
+		sent <- txSender.SimulateAndBroadCastTxWithRetryOnSeqMismatch(testTxMsg(txSender), false)
+	}()
+	require.Never(t, func() bool { return len(sent) > 0 }, 20*time.Millisecond, time.Millisecond)
+	// a throttled transaction isn't sent once the tx sender stops
+	cancel()
+	require.Error(t, <-sent)
+	require.Equal(t, 1, chain.broadcastCount())
+}
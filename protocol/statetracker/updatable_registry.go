@@ -0,0 +1,334 @@
+package statetracker
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/lavanet/lava/utils"
+)
+
+// updatables and updaters run in ascending priority, the same priority runs in registration order
+const (
+	UpdatePriorityHigh    = -10
+	UpdatePriorityDefault = 0
+	UpdatePriorityLow     = 10
+)
+
+type UpdateInterestKind int
+
+const (
+	InterestEveryBlock UpdateInterestKind = iota
+	InterestNewEpoch
+	InterestEvent
+)
+
+// UpdateInterest declares what an updatable is called on, an event interest is called on blocks holding events of EventType
+type UpdateInterest struct {
+	Kind      UpdateInterestKind
+	EventType string
+}
+
+func (ui UpdateInterest) String() string {
+	switch ui.Kind {
+	case InterestEveryBlock:
+		return "every-block"
+	case InterestNewEpoch:
+		return "new-epoch"
+	case InterestEvent:
+		return "event:" + ui.EventType
+	}
+	return "unknown"
+}
+
+// UpdateTrigger is what happened on the block an updater dispatches for
+type UpdateTrigger struct {
+	Block      int64
+	NewEpoch   bool
+	EventTypes map[string]struct{}
+}
+
+func (ut UpdateTrigger) matches(interest UpdateInterest) bool {
+	switch interest.Kind {
+	case InterestEveryBlock:
+		return true
+	case InterestNewEpoch:
+		return ut.NewEpoch
+	case InterestEvent:
+		_, ok := ut.EventTypes[interest.EventType]
+		return ok
+	}
+	return false
+}
+
+// PrioritizedUpdatable is implemented by updatables and updaters that need to run before or after the others
+type PrioritizedUpdatable interface {
+	UpdatePriority() int
+}
+
+// InterestedUpdatable is implemented by updatables that override the interest their updater registers them with
+type InterestedUpdatable interface {
+	UpdateInterest() UpdateInterest
+}
+
+func updatePriority(updatable interface{}) int {
+	if prioritized, ok := updatable.(PrioritizedUpdatable); ok {
+		return prioritized.UpdatePriority()
+	}
+	return UpdatePriorityDefault
+}
+
+type registryEntry[T any] struct {
+	key       string
+	priority  int
+	sequence  uint64
+	interest  UpdateInterest
+	updatable T
+}
+
+// UpdatableRegistry holds the updatables of an updater by key in dispatch order, it replaces the map and slice each updater kept.
+// updatables are called without the lock, so they can register and unregister from inside their handler
+type UpdatableRegistry[T any] struct {
+	lock     sync.RWMutex
+	name     string // for logs
+	entries  []*registryEntry[T]
+	sequence uint64
+}
+
+func NewUpdatableRegistry[T any](name string) *UpdatableRegistry[T] {
+	return &UpdatableRegistry[T]{name: name}
+}
+
+// Register adds updatable under key, an updatable already registered with the key is replaced
+func (ur *UpdatableRegistry[T]) Register(key string, updatable T, interest UpdateInterest) {
+	ur.lock.Lock()
+	defer ur.lock.Unlock()
+	ur.removeUnsafe(key)
+	ur.addUnsafe(key, updatable, interest)
+}
+
+// RegisterUnique is for updatables without a key of their own, it returns the generated key. the key of a pointer is derived from its address,
+// so registering it again replaces the earlier registration and UnregisterMatching and ReplaceMatching find it
+func (ur *UpdatableRegistry[T]) RegisterUnique(updatable T, interest UpdateInterest) string {
+	ur.lock.Lock()
+	defer ur.lock.Unlock()
+	key := ur.uniqueKeyUnsafe(updatable)
+	ur.removeUnsafe(key)
+	ur.addUnsafe(key, updatable, interest)
+	return key
+}
+
+func (ur *UpdatableRegistry[T]) Unregister(key string) bool {
+	ur.lock.Lock()
+	defer ur.lock.Unlock()
+	return ur.removeUnsafe(key)
+}
+
+// UnregisterMatching removes updatable registered with RegisterUnique, it's matched by its registration key so only pointers can be matched.
+// updatables registered with a key of their own are unregistered by the key
+func (ur *UpdatableRegistry[T]) UnregisterMatching(updatable T) bool {
+	key, ok := identityKey(updatable)
+	if !ok {
+		return false
+	}
+	return ur.Unregister(key)
+}
+
+// Replace puts updatable under newKey in the place of the updatable registered with key. the replacement keeps the place of the replaced
+// updatable among the updatables of its priority, and its interest unless the replacement declares its own
+func (ur *UpdatableRegistry[T]) Replace(key string, newKey string, updatable T) error {
+	ur.lock.Lock()
+	defer ur.lock.Unlock()
+	return ur.replaceUnsafe(key, newKey, updatable)
+}
+
+// ReplaceMatching puts newUpdatable in the place of oldUpdatable registered with RegisterUnique, matched like UnregisterMatching
+func (ur *UpdatableRegistry[T]) ReplaceMatching(oldUpdatable T, newUpdatable T) error {
+	key, ok := identityKey(oldUpdatable)
+	if !ok {
+		return utils.LavaFormatError("updatable can't be matched, it's not a pointer", nil, utils.Attribute{Key: "registry", Value: ur.name}, utils.Attribute{Key: "updatable", Value: fmt.Sprintf("%T", oldUpdatable)})
+	}
+	ur.lock.Lock()
+	defer ur.lock.Unlock()
+	return ur.replaceUnsafe(key, ur.uniqueKeyUnsafe(newUpdatable), newUpdatable)
+}
+
+func (ur *UpdatableRegistry[T]) replaceUnsafe(key string, newKey string, updatable T) error {
+	for _, entry := range ur.entries {
+		if entry.key == key {
+			if newKey != key {
+				ur.removeUnsafe(newKey)
+			}
+			entry.key = newKey
+			entry.updatable = updatable
+			entry.priority = updatePriority(updatable)
+			if interested, ok := interface{}(updatable).(InterestedUpdatable); ok {
+				entry.interest = interested.UpdateInterest()
+			}
+			ur.sortUnsafe()
+			return nil
+		}
+	}
+	return utils.LavaFormatError("no updatable registered to replace", nil, utils.Attribute{Key: "registry", Value: ur.name}, utils.Attribute{Key: "key", Value: key})
+}
+
+func (ur *UpdatableRegistry[T]) Get(key string) (updatable T, found bool) {
+	ur.lock.RLock()
+	defer ur.lock.RUnlock()
+	for _, entry := range ur.entries {
+		if entry.key == key {
+			return entry.updatable, true
+		}
+	}
+	return updatable, false
+}
+
+// Keys returns the registered keys in dispatch order
+func (ur *UpdatableRegistry[T]) Keys() []string {
+	ur.lock.RLock()
+	defer ur.lock.RUnlock()
+	keys := make([]string, len(ur.entries))
+	for idx, entry := range ur.entries {
+		keys[idx] = entry.key
+	}
+	return keys
+}
+
+// Updatables returns the registered updatables in dispatch order, for updaters that group them before calling
+func (ur *UpdatableRegistry[T]) Updatables() []T {
+	ur.lock.RLock()
+	defer ur.lock.RUnlock()
+	updatables := make([]T, len(ur.entries))
+	for idx, entry := range ur.entries {
+		updatables[idx] = entry.updatable
+	}
+	return updatables
+}
+
+func (ur *UpdatableRegistry[T]) Len() int {
+	ur.lock.RLock()
+	defer ur.lock.RUnlock()
+	return len(ur.entries)
+}
+
+// Dispatch calls call for every updatable interested in trigger in priority order, a panicking updatable is logged and skipped
+func (ur *UpdatableRegistry[T]) Dispatch(trigger UpdateTrigger, call func(key string, updatable T)) {
+	for _, entry := range ur.interested(trigger) {
+		ur.Call(entry.key, entry.updatable, call)
+	}
+}
+
+// DispatchConcurrently is Dispatch with up to parallelism updatables of the same priority called at once, a priority starts after the lower ones are done.
+// it returns once every call is done, so every updatable is still called in trigger order
+func (ur *UpdatableRegistry[T]) DispatchConcurrently(trigger UpdateTrigger, parallelism int, call func(key string, updatable T)) {
+	if parallelism <= 1 {
+		ur.Dispatch(trigger, call)
+		return
+	}
+	entries := ur.interested(trigger)
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for idx, entry := range entries {
+		if idx > 0 && entry.priority != entries[idx-1].priority {
+			wg.Wait()
+		}
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(entry registryEntry[T]) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			ur.Call(entry.key, entry.updatable, call)
+		}(entry)
+	}
+	wg.Wait()
+}
+
+// interested returns copies of the entries interested in trigger in dispatch order, so they're called without the lock
+func (ur *UpdatableRegistry[T]) interested(trigger UpdateTrigger) []registryEntry[T] {
+	ur.lock.RLock()
+	defer ur.lock.RUnlock()
+	entries := make([]registryEntry[T], 0, len(ur.entries))
+	for _, entry := range ur.entries {
+		if trigger.matches(entry.interest) {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// Call calls call on a single updatable with the panic isolation of Dispatch, for updaters that route by key
+func (ur *UpdatableRegistry[T]) Call(key string, updatable T, call func(key string, updatable T)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			utils.LavaFormatError("updatable panicked during update, skipping it", fmt.Errorf("%v", recovered), utils.Attribute{Key: "registry", Value: ur.name},
+				utils.Attribute{Key: "key", Value: key}, utils.Attribute{Key: "stack", Value: string(debug.Stack())})
+		}
+	}()
+	call(key, updatable)
+}
+
+// Describe lists the registered keys with their interest, for the state tracker listing
+func (ur *UpdatableRegistry[T]) Describe() []string {
+	ur.lock.RLock()
+	defer ur.lock.RUnlock()
+	descriptions := make([]string, len(ur.entries))
+	for idx, entry := range ur.entries {
+		descriptions[idx] = entry.key + " (" + entry.interest.String() + ", priority " + strconv.Itoa(entry.priority) + ")"
+	}
+	return descriptions
+}
+
+func (ur *UpdatableRegistry[T]) addUnsafe(key string, updatable T, interest UpdateInterest) {
+	if interested, ok := interface{}(updatable).(InterestedUpdatable); ok {
+		interest = interested.UpdateInterest()
+	}
+	ur.entries = append(ur.entries, &registryEntry[T]{key: key, priority: updatePriority(updatable), sequence: ur.sequence, interest: interest, updatable: updatable})
+	ur.sequence++
+	ur.sortUnsafe()
+}
+
+func (ur *UpdatableRegistry[T]) sortUnsafe() {
+	sort.SliceStable(ur.entries, func(i, j int) bool {
+		if ur.entries[i].priority != ur.entries[j].priority {
+			return ur.entries[i].priority < ur.entries[j].priority
+		}
+		return ur.entries[i].sequence < ur.entries[j].sequence
+	})
+}
+
+func (ur *UpdatableRegistry[T]) removeUnsafe(key string) bool {
+	for idx, entry := range ur.entries {
+		if entry.key == key {
+			ur.entries = append(ur.entries[:idx:idx], ur.entries[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueKeyUnsafe is the identity key of a pointer, updatables without an identity get a key of the registration sequence
+func (ur *UpdatableRegistry[T]) uniqueKeyUnsafe(updatable T) string {
+	if key, ok := identityKey(updatable); ok {
+		return key
+	}
+	return fmt.Sprintf("%T-%s", updatable, strconv.FormatUint(ur.sequence, 10))
+}
+
+// identityKey derives a key from the address of pointer updatables, the updatables themselves are never compared since values
+// of types like maps and slices panic on comparison
+func identityKey(updatable interface{}) (string, bool) {
+	value := reflect.ValueOf(updatable)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		if value.IsNil() {
+			return "", false
+		}
+		return fmt.Sprintf("%T-%x", updatable, value.Pointer()), true
+	}
+	return "", false
+}
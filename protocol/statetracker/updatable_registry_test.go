@@ -0,0 +1,186 @@
+package statetracker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testUpdatable struct {
+	name     string
+	priority int
+	interest *UpdateInterest
+}
+
+func (tu *testUpdatable) UpdatePriority() int {
+	return tu.priority
+}
+
+type testInterestedUpdatable struct {
+	testUpdatable
+}
+
+func (tiu *testInterestedUpdatable) UpdateInterest() UpdateInterest {
+	return *tiu.interest
+}
+
+// a value type that panics when compared with ==
+type nonComparableUpdatable struct {
+	names []string
+}
+
+func dispatchedNames(registry *UpdatableRegistry[*testUpdatable], trigger UpdateTrigger) []string {
+	names := []string{}
+	registry.Dispatch(trigger, func(_ string, updatable *testUpdatable) {
+		names = append(names, updatable.name)
+	})
+	return names
+}
+
+func TestUpdatableRegistryOrder(t *testing.T) {
+	registry := NewUpdatableRegistry[*testUpdatable]("test")
+	registry.Register("low", &testUpdatable{name: "low", priority: UpdatePriorityLow}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("first", &testUpdatable{name: "first"}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("high", &testUpdatable{name: "high", priority: UpdatePriorityHigh}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("second", &testUpdatable{name: "second"}, UpdateInterest{Kind: InterestNewEpoch})
+	registry.Register("event", &testUpdatable{name: "event"}, UpdateInterest{Kind: InterestEvent, EventType: "payment"})
+	require.Equal(t, []string{"high", "first", "low"}, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+	require.Equal(t, []string{"high", "first", "second", "low"}, dispatchedNames(registry, UpdateTrigger{Block: 1, NewEpoch: true}))
+	require.Equal(t, []string{"high", "first", "event", "low"}, dispatchedNames(registry, UpdateTrigger{Block: 1, EventTypes: map[string]struct{}{"payment": {}}}))
+
+	// registering a key again replaces the updatable
+	registry.Register("first", &testUpdatable{name: "first again"}, UpdateInterest{Kind: InterestEveryBlock})
+	require.Equal(t, []string{"high", "first again", "low"}, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+	require.True(t, registry.Unregister("first"))
+	require.False(t, registry.Unregister("first"))
+	require.Equal(t, 4, registry.Len())
+}
+
+func TestUpdatableRegistryReplace(t *testing.T) {
+	registry := NewUpdatableRegistry[*testUpdatable]("test")
+	registry.Register("a", &testUpdatable{name: "a"}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("b", &testUpdatable{name: "b"}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("c", &testUpdatable{name: "c"}, UpdateInterest{Kind: InterestEveryBlock})
+
+	// a replacement of the same priority keeps the place of the replaced updatable
+	require.NoError(t, registry.Replace("a", "a2", &testUpdatable{name: "a2"}))
+	require.Equal(t, []string{"a2", "b", "c"}, registry.Keys())
+	// the replacement's priority is used
+	require.NoError(t, registry.Replace("b", "b2", &testUpdatable{name: "b2", priority: UpdatePriorityLow}))
+	require.Equal(t, []string{"a2", "c", "b2"}, registry.Keys())
+	// replacing under a registered key drops the updatable registered with it
+	require.NoError(t, registry.Replace("a2", "c", &testUpdatable{name: "c2"}))
+	require.Equal(t, []string{"c", "b2"}, registry.Keys())
+	require.Equal(t, []string{"c2", "b2"}, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+	require.Error(t, registry.Replace("missing", "d", &testUpdatable{name: "d"}))
+}
+
+func TestUpdatableRegistryReplaceInterest(t *testing.T) {
+	registry := NewUpdatableRegistry[*testUpdatable]("test")
+	registry.Register("a", &testUpdatable{name: "a"}, UpdateInterest{Kind: InterestNewEpoch})
+	// a replacement without an interest of its own keeps the interest it was registered with
+	require.NoError(t, registry.Replace("a", "a", &testUpdatable{name: "a2"}))
+	require.Empty(t, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+	require.Equal(t, []string{"a2"}, dispatchedNames(registry, UpdateTrigger{Block: 1, NewEpoch: true}))
+
+	// the interest the replacement declares is used
+	interestedRegistry := NewUpdatableRegistry[interface{}]("test")
+	interestedRegistry.Register("a", &testUpdatable{name: "a"}, UpdateInterest{Kind: InterestNewEpoch})
+	interested := &testInterestedUpdatable{testUpdatable{name: "interested", interest: &UpdateInterest{Kind: InterestEveryBlock}}}
+	require.NoError(t, interestedRegistry.Replace("a", "a", interested))
+	require.Equal(t, []string{"a (every-block, priority 0)"}, interestedRegistry.Describe())
+}
+
+func TestUpdatableRegistryMatching(t *testing.T) {
+	registry := NewUpdatableRegistry[*testUpdatable]("test")
+	first, second := &testUpdatable{name: "first"}, &testUpdatable{name: "second"}
+	registry.RegisterUnique(first, UpdateInterest{Kind: InterestEveryBlock})
+	// registering the same updatable again doesn't call it twice
+	registry.RegisterUnique(first, UpdateInterest{Kind: InterestEveryBlock})
+	registry.RegisterUnique(second, UpdateInterest{Kind: InterestEveryBlock})
+	require.Equal(t, []string{"first", "second"}, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+
+	replacement := &testUpdatable{name: "replacement"}
+	require.NoError(t, registry.ReplaceMatching(first, replacement))
+	require.Equal(t, []string{"replacement", "second"}, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+	require.Error(t, registry.ReplaceMatching(first, &testUpdatable{}))
+	require.True(t, registry.UnregisterMatching(replacement))
+	require.False(t, registry.UnregisterMatching(replacement))
+	require.False(t, registry.UnregisterMatching(nil))
+	require.Equal(t, []string{"second"}, dispatchedNames(registry, UpdateTrigger{Block: 1}))
+}
+
+func TestUpdatableRegistryNonComparable(t *testing.T) {
+	registry := NewUpdatableRegistry[interface{}]("test")
+	key := registry.RegisterUnique(nonComparableUpdatable{names: []string{"a"}}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.RegisterUnique(nonComparableUpdatable{names: []string{"b"}}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.RegisterUnique(map[string]int{}, UpdateInterest{Kind: InterestEveryBlock})
+	require.Equal(t, 3, registry.Len())
+	// values can't be matched, they're unregistered by the key RegisterUnique returned
+	require.NotPanics(t, func() {
+		require.False(t, registry.UnregisterMatching(nonComparableUpdatable{names: []string{"a"}}))
+		require.Error(t, registry.ReplaceMatching(nonComparableUpdatable{names: []string{"a"}}, nonComparableUpdatable{}))
+	})
+	require.Equal(t, 3, registry.Len())
+	require.True(t, registry.Unregister(key))
+	require.Equal(t, 2, registry.Len())
+}
+
+func TestUpdatableRegistryPanicIsolation(t *testing.T) {
+	registry := NewUpdatableRegistry[*testUpdatable]("test")
+	registry.Register("panics", &testUpdatable{name: "panics"}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("after", &testUpdatable{name: "after"}, UpdateInterest{Kind: InterestEveryBlock})
+	called := []string{}
+	registry.Dispatch(UpdateTrigger{Block: 1}, func(_ string, updatable *testUpdatable) {
+		if updatable.name == "panics" {
+			panic("updatable failed")
+		}
+		called = append(called, updatable.name)
+	})
+	require.Equal(t, []string{"after"}, called)
+}
+
+func TestUpdatableRegistryDispatchConcurrently(t *testing.T) {
+	registry := NewUpdatableRegistry[*testUpdatable]("test")
+	for _, name := range []string{"high1", "high2", "high3", "high4"} {
+		registry.Register(name, &testUpdatable{name: name, priority: UpdatePriorityHigh}, UpdateInterest{Kind: InterestEveryBlock})
+	}
+	registry.Register("panics", &testUpdatable{name: "panics"}, UpdateInterest{Kind: InterestEveryBlock})
+	registry.Register("low", &testUpdatable{name: "low", priority: UpdatePriorityLow}, UpdateInterest{Kind: InterestEveryBlock})
+
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	finished := []string{}
+	registry.DispatchConcurrently(UpdateTrigger{Block: 1}, 2, func(_ string, updatable *testUpdatable) {
+		if updatable.name == "panics" {
+			panic("updatable failed")
+		}
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		running--
+		finished = append(finished, updatable.name)
+		lock.Unlock()
+	})
+	// updatables of the same priority run together up to the parallelism, a priority starts after the higher one is done
+	require.Equal(t, 2, maxRunning)
+	require.Len(t, finished, 5)
+	require.ElementsMatch(t, []string{"high1", "high2", "high3", "high4"}, finished[:4])
+	require.Equal(t, "low", finished[4])
+
+	// a parallelism of 1 dispatches in order
+	require.Equal(t, []string{"high1", "high2", "high3", "high4", "panics", "low"}, func() []string {
+		names := []string{}
+		registry.DispatchConcurrently(UpdateTrigger{Block: 1}, 1, func(_ string, updatable *testUpdatable) {
+			names = append(names, updatable.name)
+		})
+		return names
+	}())
+}
@@ -0,0 +1,96 @@
+package statetracker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lavanet/lava/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	updaterSuccessLabel = "success"
+	updaterFailureLabel = "failure"
+)
+
+var errUpdaterPanicked = errors.New("updater panicked")
+
+var (
+	updaterRunsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lava_state_tracker_updates_total",
+		Help: "The number of lava block updates by updater and result",
+	}, []string{"updater", "result"})
+	updaterLastSuccessGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lava_state_tracker_updater_last_success_block",
+		Help: "The last lava block the updater handled successfully, an updater falling behind the latest block keeps failing",
+	}, []string{"updater"})
+	updaterDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lava_state_tracker_update_duration_seconds",
+		Help:    "The time an updater took to handle a lava block",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"updater"})
+)
+
+func init() {
+	prometheus.MustRegister(updaterRunsCounter, updaterLastSuccessGauge, updaterDurationHistogram)
+}
+
+// UpdaterStats are the results of an updater since the state tracker started
+type UpdaterStats struct {
+	Successes        uint64
+	Failures         uint64
+	LastBlock        int64 // the last block the updater was called with
+	LastSuccessBlock int64
+	LastError        string
+	LastDuration     time.Duration
+	MaxDuration      time.Duration
+}
+
+// updaterStats aggregates the update results of every updater, and exports them as metrics
+type updaterStats struct {
+	lock  sync.Mutex
+	stats map[string]*UpdaterStats // key is the updater key
+}
+
+func newUpdaterStats() *updaterStats {
+	return &updaterStats{stats: map[string]*UpdaterStats{}}
+}
+
+func (us *updaterStats) record(updaterKey string, latestBlock int64, duration time.Duration, err error) {
+	updaterDurationHistogram.WithLabelValues(updaterKey).Observe(duration.Seconds())
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	stats, ok := us.stats[updaterKey]
+	if !ok {
+		stats = &UpdaterStats{}
+		us.stats[updaterKey] = stats
+	}
+	stats.LastBlock = latestBlock
+	stats.LastDuration = duration
+	if duration > stats.MaxDuration {
+		stats.MaxDuration = duration
+	}
+	if err != nil {
+		updaterRunsCounter.WithLabelValues(updaterKey, updaterFailureLabel).Inc()
+		stats.Failures++
+		stats.LastError = err.Error()
+		utils.LavaFormatDebug("updater failed on lava block", utils.Attribute{Key: "updater", Value: updaterKey}, utils.Attribute{Key: "block", Value: latestBlock},
+			utils.Attribute{Key: "failures", Value: stats.Failures}, utils.Attribute{Key: "error", Value: err})
+		return
+	}
+	updaterRunsCounter.WithLabelValues(updaterKey, updaterSuccessLabel).Inc()
+	updaterLastSuccessGauge.WithLabelValues(updaterKey).Set(float64(latestBlock))
+	stats.Successes++
+	stats.LastSuccessBlock = latestBlock
+}
+
+func (us *updaterStats) snapshot() map[string]UpdaterStats {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+	snapshot := make(map[string]UpdaterStats, len(us.stats))
+	for updaterKey, stats := range us.stats {
+		snapshot[updaterKey] = *stats
+	}
+	return snapshot
+}
@@ -0,0 +1,66 @@
+package statetracker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedUpdater fails on the blocks in failures and panics on the blocks in panics
+type scriptedUpdater struct {
+	key      string
+	failures map[int64]bool
+	panics   map[int64]bool
+}
+
+func (su *scriptedUpdater) Update(latestBlock int64) error {
+	if su.panics[latestBlock] {
+		panic("updater failed")
+	}
+	if su.failures[latestBlock] {
+		return errNodeUnavailable
+	}
+	return nil
+}
+
+func (su *scriptedUpdater) UpdaterKey() string {
+	return su.key
+}
+
+func TestUpdaterStats(t *testing.T) {
+	cst := newTestStateTracker()
+	cst.RegisterForUpdates(context.Background(), &scriptedUpdater{key: "scripted", failures: map[int64]bool{11: true}, panics: map[int64]bool{12: true}})
+	cst.RegisterForUpdates(context.Background(), &scriptedUpdater{key: "healthy"})
+	for block := int64(10); block <= 13; block++ {
+		cst.newLavaBlock(block, "")
+	}
+
+	stats := cst.UpdaterStats()
+	require.Len(t, stats, 2)
+	scripted := stats["scripted"]
+	require.Equal(t, uint64(2), scripted.Successes)
+	// a panic is recorded as a failure and doesn't stop the other updaters
+	require.Equal(t, uint64(2), scripted.Failures)
+	require.Equal(t, errUpdaterPanicked.Error(), scripted.LastError)
+	require.Equal(t, int64(13), scripted.LastBlock)
+	require.Equal(t, int64(13), scripted.LastSuccessBlock)
+	require.GreaterOrEqual(t, scripted.MaxDuration, scripted.LastDuration)
+	require.Equal(t, UpdaterStats{Successes: 4, LastBlock: 13, LastSuccessBlock: 13, LastDuration: stats["healthy"].LastDuration, MaxDuration: stats["healthy"].MaxDuration}, stats["healthy"])
+
+	// the snapshot is a copy
+	scripted.Failures = 0
+	require.Equal(t, uint64(2), cst.UpdaterStats()["scripted"].Failures)
+}
+
+func TestUpdaterStatsLastError(t *testing.T) {
+	cst := newTestStateTracker()
+	cst.RegisterForUpdates(context.Background(), &scriptedUpdater{key: "scripted", failures: map[int64]bool{10: true}})
+	cst.newLavaBlock(10, "")
+	cst.newLavaBlock(11, "")
+	// the last error is kept after the updater recovers, the last success block shows it did
+	stats := cst.UpdaterStats()["scripted"]
+	require.Equal(t, errNodeUnavailable.Error(), stats.LastError)
+	require.Equal(t, int64(11), stats.LastSuccessBlock)
+	require.Equal(t, uint64(1), stats.Failures)
+}
@@ -3,43 +3,166 @@ package statetracker
 import (
 	"github.com/lavanet/lava/protocol/lavasession"
 	"github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
+	"github.com/lavanet/lava/utils"
+	conflicttypes "github.com/lavanet/lava/x/conflict/types"
+	"golang.org/x/exp/slices"
 	"golang.org/x/net/context"
 )
 
 const (
 	CallbackKeyForVoteUpdate = "vote-update"
+	MaxVoteTrackingBlocks    = 1000 // a vote whose close event was missed is dropped this many blocks after its deadline
 )
 
 type VoteUpdatable interface {
 	VoteHandler(*reliabilitymanager.VoteParams, uint64)
 }
 
+// trackedVote is a conflict vote the provider is a voter in, reveal and close events only carry the vote id so they are routed by it
+type trackedVote struct {
+	endpointKey string
+	deadline    uint64 // of the current phase
+	revealing   bool
+}
+
+// VoteUpdater follows the conflict votes the provider was chosen for from detection through reveal to close,
+// and calls the endpoint's updatable on every phase so it commits and reveals before the deadlines
 type VoteUpdater struct {
-	voteUpdatables map[string]*VoteUpdatable
-	stateQuery     *ProviderStateQuery
+	voteUpdatables  *UpdatableRegistry[VoteUpdatable] // key is the endpoint key
+	stateQuery      ProviderStateQueryInf
+	providerAddress string
+	trackedVotes    map[string]*trackedVote // key is the vote id, only accessed from Update
+	lastProcessed   int64                   // the next update processes the blocks after it, blocks are skipped when the lava node is unreachable and reprocessed after a reorg
+	handledEvents   *eventDeduplicator
 }
 
-func NewVoteUpdater(stateQuery *ProviderStateQuery) *VoteUpdater {
-	return &VoteUpdater{voteUpdatables: map[string]*VoteUpdatable{}, stateQuery: stateQuery}
+func NewVoteUpdater(stateQuery ProviderStateQueryInf, providerAddress string) *VoteUpdater {
+	return &VoteUpdater{voteUpdatables: NewUpdatableRegistry[VoteUpdatable](CallbackKeyForVoteUpdate), stateQuery: stateQuery, providerAddress: providerAddress, trackedVotes: map[string]*trackedVote{}, handledEvents: newEventDeduplicator(CallbackKeyForVoteUpdate, EventDedupWindowBlocks)}
 }
 
 func (vu *VoteUpdater) RegisterVoteUpdatable(ctx context.Context, voteUpdatable *VoteUpdatable, endpoint lavasession.RPCEndpoint) {
-	vu.voteUpdatables[endpoint.Key()] = voteUpdatable
+	vu.voteUpdatables.Register(endpoint.Key(), *voteUpdatable, UpdateInterest{Kind: InterestEvent, EventType: utils.EventPrefix + conflicttypes.ConflictVoteDetectionEventName})
+}
+
+// UnregisterVoteUpdatable returns false if nothing was registered for the endpoint
+func (vu *VoteUpdater) UnregisterVoteUpdatable(endpoint lavasession.RPCEndpoint) bool {
+	return vu.voteUpdatables.Unregister(endpoint.Key())
+}
+
+func (vu *VoteUpdater) ReplaceVoteUpdatable(voteUpdatable *VoteUpdatable, endpoint lavasession.RPCEndpoint) error {
+	return vu.voteUpdatables.Replace(endpoint.Key(), endpoint.Key(), *voteUpdatable)
+}
+
+func (vu *VoteUpdater) RegisteredUpdatables() []string {
+	return vu.voteUpdatables.Describe()
+}
+
+func (vu *VoteUpdater) UpdatePriority() int {
+	return UpdatePriorityLow
 }
 
 func (vu *VoteUpdater) UpdaterKey() string {
 	return CallbackKeyForVoteUpdate
 }
 
-func (vu *VoteUpdater) Update(latestBlock int64) {
+// Rewind makes the next update process the vote events from forkBlock again, as they were reorged
+func (vu *VoteUpdater) Rewind(forkBlock int64) {
+	if vu.lastProcessed >= forkBlock {
+		vu.lastProcessed = forkBlock - 1
+	}
+	vu.handledEvents.forget(forkBlock)
+}
+
+func (vu *VoteUpdater) Update(latestBlock int64) error {
 	ctx := context.Background()
+	if vu.lastProcessed != 0 && vu.lastProcessed+1 < latestBlock {
+		// a gap the lava node was unreachable in or a reorg, the missed blocks are processed before the latest so no event is skipped
+		fromBlock := vu.lastProcessed + 1
+		if latestBlock-fromBlock > MaxVoteTrackingBlocks {
+			utils.LavaFormatError("too many blocks missed for vote events, processing only the latest ones", nil, utils.Attribute{Key: "fromBlock", Value: fromBlock},
+				utils.Attribute{Key: "latestBlock", Value: latestBlock}, utils.Attribute{Key: "processed", Value: MaxVoteTrackingBlocks})
+			fromBlock = latestBlock - MaxVoteTrackingBlocks
+		}
+		for block := fromBlock; block < latestBlock; block++ {
+			err := vu.processBlock(ctx, block)
+			if err != nil {
+				return err // continue from the failed block on the next update
+			}
+			vu.lastProcessed = block
+		}
+	}
+	err := vu.processBlock(ctx, latestBlock)
+	if err != nil {
+		return err
+	}
+	vu.lastProcessed = latestBlock
+	vu.dropExpiredVotes(latestBlock)
+	return nil
+}
+
+func (vu *VoteUpdater) processBlock(ctx context.Context, latestBlock int64) error {
 	votes, err := vu.stateQuery.VoteEvents(ctx, latestBlock)
 	if err != nil {
-		return
+		return err
 	}
-	for _, vote := range votes {
+	for _, voteEvent := range votes {
+		if !vu.handledEvents.firstSeen(voteEvent.ID) {
+			continue
+		}
+		vote := voteEvent.Vote
+		endpointKey, ok := vu.trackVote(vote, latestBlock)
+		if !ok {
+			continue // a vote the provider isn't a voter in
+		}
+		updatable, ok := vu.voteUpdatables.Get(endpointKey)
+		if !ok {
+			continue // no updatable for the endpoint, it was never registered or was unregistered
+		}
+		vu.voteUpdatables.Call(endpointKey, updatable, func(_ string, voteUpdatable VoteUpdatable) {
+			voteUpdatable.VoteHandler(vote, uint64(latestBlock))
+		})
+	}
+	return nil
+}
+
+// trackVote moves the vote to the phase of the event and returns the endpoint key the event is routed to
+func (vu *VoteUpdater) trackVote(vote *reliabilitymanager.VoteParams, latestBlock int64) (endpointKey string, ok bool) {
+	switch vote.ParamsType {
+	case reliabilitymanager.DetectionVoteType:
+		if !slices.Contains(vote.Voters, vu.providerAddress) {
+			return "", false
+		}
 		endpoint := lavasession.RPCEndpoint{ChainID: vote.ChainID, ApiInterface: vote.ApiInterface}
-		updatable := vu.voteUpdatables[endpoint.Key()]
-		(*updatable).VoteHandler(vote, uint64(latestBlock))
+		vu.trackedVotes[vote.VoteID] = &trackedVote{endpointKey: endpoint.Key(), deadline: vote.VoteDeadline}
+		utils.LavaFormatInfo("chosen as a voter in a conflict vote, committing before the deadline", utils.Attribute{Key: "voteID", Value: vote.VoteID},
+			utils.Attribute{Key: "chainID", Value: vote.ChainID}, utils.Attribute{Key: "deadline", Value: vote.VoteDeadline}, utils.Attribute{Key: "block", Value: latestBlock})
+		return endpoint.Key(), true
+	case reliabilitymanager.RevealVoteType:
+		tracked, found := vu.trackedVotes[vote.VoteID]
+		if !found {
+			return "", false
+		}
+		tracked.deadline = vote.VoteDeadline
+		tracked.revealing = true
+		utils.LavaFormatInfo("conflict vote moved to reveal, revealing before the deadline", utils.Attribute{Key: "voteID", Value: vote.VoteID}, utils.Attribute{Key: "deadline", Value: vote.VoteDeadline})
+		return tracked.endpointKey, true
+	case reliabilitymanager.CloseVoteType:
+		tracked, found := vu.trackedVotes[vote.VoteID]
+		if !found {
+			return "", false
+		}
+		delete(vu.trackedVotes, vote.VoteID)
+		return tracked.endpointKey, true
+	}
+	return "", false
+}
+
+func (vu *VoteUpdater) dropExpiredVotes(latestBlock int64) {
+	for voteID, tracked := range vu.trackedVotes {
+		if tracked.deadline+MaxVoteTrackingBlocks < uint64(latestBlock) {
+			utils.LavaFormatWarning("conflict vote passed its deadline without closing, no longer tracking it", nil, utils.Attribute{Key: "voteID", Value: voteID},
+				utils.Attribute{Key: "deadline", Value: tracked.deadline}, utils.Attribute{Key: "revealing", Value: tracked.revealing})
+			delete(vu.trackedVotes, voteID)
+		}
 	}
 }
@@ -0,0 +1,165 @@
+package statetracker
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lavanet/lava/protocol/lavasession"
+	"github.com/lavanet/lava/protocol/rpcprovider/reliabilitymanager"
+	"github.com/stretchr/testify/require"
+)
+
+const testProviderAddress = "lava@provider"
+
+var testVoteEndpoint = lavasession.RPCEndpoint{ChainID: "LAV1", ApiInterface: "tendermintrpc"}
+
+// recordingVoteUpdatable records the vote ids and phases it was called with
+type recordingVoteUpdatable struct {
+	lock  sync.Mutex
+	votes []string
+}
+
+func (rvu *recordingVoteUpdatable) VoteHandler(vote *reliabilitymanager.VoteParams, block uint64) {
+	rvu.lock.Lock()
+	defer rvu.lock.Unlock()
+	rvu.votes = append(rvu.votes, vote.VoteID+"/"+[]string{"detection", "reveal", "close"}[vote.ParamsType])
+}
+
+func (rvu *recordingVoteUpdatable) handledVotes() []string {
+	rvu.lock.Lock()
+	defer rvu.lock.Unlock()
+	return append([]string{}, rvu.votes...)
+}
+
+func testVoteEvent(block int64, eventIndex int, voteID string, paramsType uint, voters ...string) VoteEvent {
+	return VoteEvent{ID: EventID{Block: block, EventIndex: eventIndex}, Vote: &reliabilitymanager.VoteParams{
+		ChainID: testVoteEndpoint.ChainID, ApiInterface: testVoteEndpoint.ApiInterface, VoteID: voteID, ParamsType: paramsType, Voters: voters, VoteDeadline: uint64(block) + 10,
+	}}
+}
+
+func newTestVoteUpdater(stateQuery ProviderStateQueryInf) (*VoteUpdater, *recordingVoteUpdatable) {
+	voteUpdater := NewVoteUpdater(stateQuery, testProviderAddress)
+	recorder := &recordingVoteUpdatable{}
+	var updatable VoteUpdatable = recorder
+	voteUpdater.RegisterVoteUpdatable(context.Background(), &updatable, testVoteEndpoint)
+	return voteUpdater, recorder
+}
+
+func TestVoteUpdaterPhases(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Votes[10] = []VoteEvent{
+		testVoteEvent(10, 0, "voter", reliabilitymanager.DetectionVoteType, "lava@other", testProviderAddress),
+		testVoteEvent(10, 1, "not-voter", reliabilitymanager.DetectionVoteType, "lava@other"),
+	}
+	// reveal and close events don't carry the voters, they're routed by the vote id
+	stateQuery.Votes[11] = []VoteEvent{
+		testVoteEvent(11, 0, "voter", reliabilitymanager.RevealVoteType),
+		testVoteEvent(11, 1, "not-voter", reliabilitymanager.RevealVoteType),
+	}
+	stateQuery.Votes[12] = []VoteEvent{
+		testVoteEvent(12, 0, "voter", reliabilitymanager.CloseVoteType),
+		testVoteEvent(12, 1, "not-voter", reliabilitymanager.CloseVoteType),
+	}
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	require.Len(t, voteUpdater.trackedVotes, 1)
+	require.NoError(t, voteUpdater.Update(11))
+	require.True(t, voteUpdater.trackedVotes["voter"].revealing)
+	require.Equal(t, uint64(21), voteUpdater.trackedVotes["voter"].deadline)
+	require.NoError(t, voteUpdater.Update(12))
+	require.Empty(t, voteUpdater.trackedVotes)
+	require.Equal(t, []string{"voter/detection", "voter/reveal", "voter/close"}, recorder.handledVotes())
+}
+
+func TestVoteUpdaterUnregisteredEndpoint(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Votes[10] = []VoteEvent{testVoteEvent(10, 0, "voter", reliabilitymanager.DetectionVoteType, testProviderAddress)}
+	stateQuery.Votes[11] = []VoteEvent{testVoteEvent(11, 0, "voter", reliabilitymanager.RevealVoteType)}
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	require.True(t, voteUpdater.UnregisterVoteUpdatable(testVoteEndpoint))
+	require.False(t, voteUpdater.UnregisterVoteUpdatable(testVoteEndpoint))
+	// the vote is still tracked, its events are dropped while the endpoint has no updatable
+	require.NoError(t, voteUpdater.Update(11))
+	require.Equal(t, []string{"voter/detection"}, recorder.handledVotes())
+	require.True(t, voteUpdater.trackedVotes["voter"].revealing)
+}
+
+func TestVoteUpdaterDropsExpiredVotes(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Votes[10] = []VoteEvent{testVoteEvent(10, 0, "voter", reliabilitymanager.DetectionVoteType, testProviderAddress)}
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	deadline := int64(voteUpdater.trackedVotes["voter"].deadline)
+	// updates far apart skip the missed blocks past MaxVoteTrackingBlocks
+	require.NoError(t, voteUpdater.Update(deadline+MaxVoteTrackingBlocks))
+	require.Len(t, voteUpdater.trackedVotes, 1)
+	require.NoError(t, voteUpdater.Update(deadline+MaxVoteTrackingBlocks+1))
+	require.Empty(t, voteUpdater.trackedVotes)
+
+	// the close event of a dropped vote isn't routed
+	stateQuery.Lock()
+	stateQuery.Votes[deadline+MaxVoteTrackingBlocks+2] = []VoteEvent{testVoteEvent(deadline+MaxVoteTrackingBlocks+2, 0, "voter", reliabilitymanager.CloseVoteType)}
+	stateQuery.Unlock()
+	require.NoError(t, voteUpdater.Update(deadline+MaxVoteTrackingBlocks+2))
+	require.Equal(t, []string{"voter/detection"}, recorder.handledVotes())
+}
+
+func TestVoteUpdaterBackfillsSkippedBlocks(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Votes[11] = []VoteEvent{testVoteEvent(11, 0, "voter", reliabilitymanager.DetectionVoteType, testProviderAddress)}
+	stateQuery.Votes[12] = []VoteEvent{testVoteEvent(12, 0, "voter", reliabilitymanager.RevealVoteType)}
+	stateQuery.Votes[14] = []VoteEvent{testVoteEvent(14, 0, "voter", reliabilitymanager.CloseVoteType)}
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	// blocks 11 to 13 were skipped, their events are handled in order before the latest block's
+	require.NoError(t, voteUpdater.Update(14))
+	require.Equal(t, 5, stateQuery.Calls("VoteEvents"))
+	require.Equal(t, []string{"voter/detection", "voter/reveal", "voter/close"}, recorder.handledVotes())
+	require.Equal(t, int64(14), voteUpdater.lastProcessed)
+}
+
+func TestVoteUpdaterBackfillContinuesFromFailedBlock(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	stateQuery.Votes[11] = []VoteEvent{testVoteEvent(11, 0, "voter", reliabilitymanager.DetectionVoteType, testProviderAddress)}
+	stateQuery.Votes[12] = []VoteEvent{testVoteEvent(12, 0, "voter", reliabilitymanager.RevealVoteType)}
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	// block 11 is processed, block 12 fails
+	stateQuery.FailNext("VoteEvents", nil, errNodeUnavailable)
+	require.ErrorIs(t, voteUpdater.Update(13), errNodeUnavailable)
+	require.Equal(t, int64(11), voteUpdater.lastProcessed)
+	require.Equal(t, []string{"voter/detection"}, recorder.handledVotes())
+
+	require.NoError(t, voteUpdater.Update(13))
+	require.Equal(t, int64(13), voteUpdater.lastProcessed)
+	require.Equal(t, []string{"voter/detection", "voter/reveal"}, recorder.handledVotes())
+}
+
+func TestVoteUpdaterBackfillLimit(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	voteUpdater, _ := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	// only the last MaxVoteTrackingBlocks blocks of a long gap are queried
+	require.NoError(t, voteUpdater.Update(10+2*MaxVoteTrackingBlocks))
+	require.Equal(t, 1+MaxVoteTrackingBlocks+1, stateQuery.Calls("VoteEvents"))
+}
+
+func TestVoteUpdaterRewind(t *testing.T) {
+	stateQuery := NewFakeStateQuery()
+	voteUpdater, recorder := newTestVoteUpdater(stateQuery)
+	require.NoError(t, voteUpdater.Update(10))
+	require.NoError(t, voteUpdater.Update(11))
+	// the reorged block 11 had no events, the new block 11 has one
+	stateQuery.Lock()
+	stateQuery.Votes[11] = []VoteEvent{testVoteEvent(11, 0, "voter", reliabilitymanager.DetectionVoteType, testProviderAddress)}
+	stateQuery.Unlock()
+	voteUpdater.Rewind(11)
+	require.Equal(t, int64(10), voteUpdater.lastProcessed)
+	require.NoError(t, voteUpdater.Update(12))
+	require.Equal(t, []string{"voter/detection"}, recorder.handledVotes())
+	// a rewind past the processed blocks changes nothing
+	voteUpdater.Rewind(20)
+	require.Equal(t, int64(12), voteUpdater.lastProcessed)
+}
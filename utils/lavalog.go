@@ -7,6 +7,7 @@ import (
 	"os"
 	"runtime/debug"
 	"strconv"
+	"sync"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -72,13 +73,20 @@ func LoggingLevel(logLevel string) {
 	LavaFormatInfo("setting log level", Attribute{Key: "loglevel", Value: logLevel})
 }
 
-func LavaFormatLog(description string, err error, attributes []Attribute, severity uint) error {
+// the zerolog globals are set once, setting them on every log races with concurrent logs
+var setupLoggerOnce sync.Once
+
+func setupLogger() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	// os.Getenv("LAVA_DISABLE_COLORS") == "true"
 	NoColor := true
 	if os.Getenv("LAVA_OUTPUT") != "json" {
 		zerologlog.Logger = zerologlog.Output(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: NoColor, TimeFormat: time.Stamp})
 	}
+}
+
+func LavaFormatLog(description string, err error, attributes []Attribute, severity uint) error {
+	setupLoggerOnce.Do(setupLogger)
 
 	var logEvent *zerolog.Event
 	switch severity {
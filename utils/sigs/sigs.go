@@ -271,3 +271,19 @@ func CalculateContentHashForRelayData(relayRequestData *pairingtypes.RelayPrivat
 	msgData := bytes.Join([][]byte{[]byte(relayRequestData.ApiInterface), []byte(relayRequestData.ConnectionType), []byte(relayRequestData.ApiUrl), relayRequestData.Data, requestBlockBytes, relayRequestData.Salt}, nil)
 	return HashMsg(msgData)
 }
+
+func SignProviderManifest(pkey *btcSecp256k1.PrivateKey, manifest []byte) ([]byte, error) {
+	return btcSecp256k1.SignCompact(btcSecp256k1.S256(), pkey, HashMsg(manifest), false)
+}
+
+func RecoverPubKeyFromProviderManifest(manifest []byte, sig []byte) (secp256k1.PubKey, error) {
+	return RecoverPubKey(sig, HashMsg(manifest))
+}
+
+func SignSoakReport(pkey *btcSecp256k1.PrivateKey, report []byte) ([]byte, error) {
+	return btcSecp256k1.SignCompact(btcSecp256k1.S256(), pkey, HashMsg(report), false)
+}
+
+func RecoverPubKeyFromSoakReport(report []byte, sig []byte) (secp256k1.PubKey, error) {
+	return RecoverPubKey(sig, HashMsg(report))
+}
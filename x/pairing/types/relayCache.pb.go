@@ -165,6 +165,7 @@ type RelayCacheSet struct {
 	BucketID     string        `protobuf:"bytes,5,opt,name=bucketID,proto3" json:"bucketID,omitempty"`
 	Response     *RelayReply   `protobuf:"bytes,6,opt,name=response,proto3" json:"response,omitempty"`
 	Finalized    bool          `protobuf:"varint,7,opt,name=finalized,proto3" json:"finalized,omitempty"`
+	ExpirationMs uint64        `protobuf:"varint,8,opt,name=expirationMs,proto3" json:"expirationMs,omitempty"`
 }
 
 func (m *RelayCacheSet) Reset()         { *m = RelayCacheSet{} }
@@ -249,6 +250,13 @@ func (m *RelayCacheSet) GetFinalized() bool {
 	return false
 }
 
+func (m *RelayCacheSet) GetExpirationMs() uint64 {
+	if m != nil {
+		return m.ExpirationMs
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*CacheUsage)(nil), "lavanet.lava.pairing.CacheUsage")
 	proto.RegisterType((*RelayCacheGet)(nil), "lavanet.lava.pairing.RelayCacheGet")
@@ -258,35 +266,36 @@ func init() {
 func init() { proto.RegisterFile("pairing/relayCache.proto", fileDescriptor_2cd8c815c0cb2c9f) }
 
 var fileDescriptor_2cd8c815c0cb2c9f = []byte{
-	// 446 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x53, 0xc1, 0x8e, 0xd3, 0x30,
-	0x10, 0x8d, 0x97, 0xa5, 0x4d, 0xbd, 0xe5, 0x62, 0x56, 0x28, 0x0a, 0x28, 0x8a, 0xc2, 0x81, 0x9e,
-	0x1c, 0x69, 0xb9, 0xee, 0x05, 0x28, 0xda, 0x56, 0x82, 0x8b, 0x2b, 0x2e, 0xdc, 0x9c, 0x30, 0x4d,
-	0xac, 0xcd, 0xc6, 0x21, 0x76, 0x11, 0xe5, 0x2b, 0xf8, 0x05, 0xbe, 0x06, 0x8e, 0x7b, 0xe4, 0x88,
-	0xda, 0x5f, 0xe0, 0x03, 0x50, 0xa6, 0x4d, 0xb3, 0x45, 0x6c, 0xe1, 0xc8, 0xc9, 0x9e, 0xe7, 0x79,
-	0x33, 0xef, 0x8d, 0xc6, 0xd4, 0xab, 0xa4, 0xaa, 0x55, 0x99, 0xc5, 0x35, 0x14, 0x72, 0xf9, 0x42,
-	0xa6, 0x39, 0xf0, 0xaa, 0xd6, 0x56, 0xb3, 0xd3, 0x42, 0x7e, 0x90, 0x25, 0x58, 0xde, 0x9c, 0x7c,
-	0x9b, 0xe6, 0x9f, 0x66, 0x3a, 0xd3, 0x98, 0x10, 0x37, 0xb7, 0x4d, 0xae, 0x7f, 0x7f, 0xaf, 0xca,
-	0x16, 0x7c, 0x98, 0x69, 0x9d, 0x15, 0x10, 0x63, 0x94, 0x2c, 0xe6, 0x31, 0x5c, 0x55, 0x76, 0xfb,
-	0x18, 0xbd, 0xa2, 0x14, 0x9b, 0xbd, 0x31, 0x32, 0x03, 0xf6, 0x88, 0x0e, 0x30, 0x9a, 0x28, 0x6b,
-	0x3c, 0x12, 0x92, 0xd1, 0xb1, 0xe8, 0x00, 0x16, 0xd2, 0x13, 0x0c, 0x5e, 0x2b, 0x63, 0xc0, 0x78,
-	0x47, 0xf8, 0x7e, 0x13, 0x8a, 0xbe, 0x12, 0x7a, 0x4f, 0xec, 0x0c, 0x5c, 0x80, 0x65, 0xe7, 0xb4,
-	0x5f, 0xc3, 0xfb, 0x05, 0x18, 0x8b, 0xf5, 0x4e, 0xce, 0x22, 0xfe, 0x27, 0x3f, 0x1c, 0x59, 0x62,
-	0x93, 0x29, 0x5a, 0x0a, 0x8b, 0xe8, 0x50, 0x56, 0x6a, 0x5a, 0x5a, 0xa8, 0xe7, 0x32, 0x05, 0x6c,
-	0x39, 0x10, 0x7b, 0x58, 0xa3, 0x39, 0x29, 0x74, 0x7a, 0x39, 0x91, 0x26, 0xf7, 0xee, 0x84, 0x64,
-	0x34, 0x14, 0x1d, 0xc0, 0x3c, 0xda, 0x4f, 0x73, 0xa9, 0xca, 0xe9, 0xd8, 0x3b, 0x46, 0x72, 0x1b,
-	0x36, 0xbc, 0xb9, 0x2a, 0x65, 0xa1, 0x3e, 0xc1, 0x3b, 0xef, 0x6e, 0x48, 0x46, 0xae, 0xe8, 0x80,
-	0xe8, 0xcb, 0xd1, 0x4d, 0x27, 0xb3, 0xff, 0xda, 0x89, 0x4f, 0xdd, 0x64, 0x91, 0x5e, 0x82, 0x9d,
-	0x8e, 0xd1, 0xc8, 0x40, 0xec, 0x62, 0x76, 0x4e, 0xdd, 0x1a, 0x4c, 0xa5, 0x4b, 0x03, 0x5e, 0x0f,
-	0x65, 0x87, 0x07, 0x65, 0x57, 0xc5, 0x52, 0xec, 0x18, 0xfb, 0x33, 0xea, 0xff, 0x36, 0xa3, 0xb3,
-	0x9f, 0x84, 0x0e, 0x91, 0x06, 0x35, 0x4e, 0x89, 0xcd, 0xa8, 0x7b, 0x01, 0x16, 0x21, 0xf6, 0xf8,
-	0x40, 0x9b, 0x76, 0x3b, 0xfc, 0xbf, 0x6a, 0x89, 0x1c, 0x36, 0xa5, 0xee, 0xec, 0x9f, 0x8b, 0xce,
-	0xc0, 0xfa, 0x0f, 0xf8, 0x66, 0xe1, 0x79, 0xbb, 0xf0, 0xfc, 0x65, 0xb3, 0xf0, 0x91, 0xc3, 0xc6,
-	0xb4, 0x37, 0x01, 0x59, 0xd8, 0x9c, 0xdd, 0x92, 0x73, 0x9b, 0xa0, 0xee, 0x8b, 0x44, 0xce, 0xf3,
-	0x67, 0xdf, 0x56, 0x01, 0xb9, 0x5e, 0x05, 0xe4, 0xc7, 0x2a, 0x20, 0x9f, 0xd7, 0x81, 0x73, 0xbd,
-	0x0e, 0x9c, 0xef, 0xeb, 0xc0, 0x79, 0xfb, 0x24, 0x53, 0x36, 0x5f, 0x24, 0x3c, 0xd5, 0x57, 0xf1,
-	0xb6, 0x0e, 0x9e, 0xf1, 0xc7, 0xb8, 0xfd, 0x98, 0x76, 0x59, 0x81, 0x49, 0x7a, 0xd8, 0xf6, 0xe9,
-	0xaf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x44, 0xb5, 0x17, 0xd4, 0xf6, 0x03, 0x00, 0x00,
+	// 458 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x53, 0x4f, 0x6f, 0xd3, 0x30,
+	0x14, 0x8f, 0xc7, 0x68, 0x53, 0xaf, 0x5c, 0xcc, 0x84, 0xac, 0x82, 0xa2, 0x28, 0x1c, 0xe8, 0xc9,
+	0x91, 0xc6, 0x75, 0x17, 0xa0, 0x68, 0xad, 0xc4, 0x2e, 0xae, 0xb8, 0x70, 0x73, 0xc2, 0x6b, 0x62,
+	0x2d, 0x8b, 0x43, 0xec, 0xa2, 0x95, 0x4f, 0xc1, 0x97, 0x42, 0x70, 0xdc, 0x91, 0x23, 0x6a, 0xbf,
+	0x02, 0x1f, 0x00, 0xe5, 0xb5, 0x69, 0x57, 0xc4, 0x06, 0x47, 0x4e, 0xf6, 0xfb, 0xf9, 0xfd, 0xf9,
+	0xfd, 0x9e, 0xfc, 0xa3, 0xbc, 0x52, 0xba, 0xd6, 0x65, 0x16, 0xd7, 0x50, 0xa8, 0xc5, 0x2b, 0x95,
+	0xe6, 0x20, 0xaa, 0xda, 0x38, 0xc3, 0x8e, 0x0b, 0xf5, 0x51, 0x95, 0xe0, 0x44, 0x73, 0x8a, 0x4d,
+	0xda, 0xe0, 0x38, 0x33, 0x99, 0xc1, 0x84, 0xb8, 0xb9, 0xad, 0x73, 0x07, 0x0f, 0xf7, 0xba, 0x6c,
+	0xc0, 0xc7, 0x99, 0x31, 0x59, 0x01, 0x31, 0x46, 0xc9, 0x7c, 0x16, 0xc3, 0x65, 0xe5, 0x36, 0x8f,
+	0xd1, 0x1b, 0x4a, 0x71, 0xd8, 0x5b, 0xab, 0x32, 0x60, 0x4f, 0x68, 0x0f, 0xa3, 0xb1, 0x76, 0x96,
+	0x93, 0x90, 0x0c, 0x0f, 0xe5, 0x0e, 0x60, 0x21, 0x3d, 0xc2, 0xe0, 0x5c, 0x5b, 0x0b, 0x96, 0x1f,
+	0xe0, 0xfb, 0x4d, 0x28, 0xfa, 0x4a, 0xe8, 0x03, 0xb9, 0x15, 0x70, 0x06, 0x8e, 0x9d, 0xd2, 0x6e,
+	0x0d, 0x1f, 0xe6, 0x60, 0x1d, 0xf6, 0x3b, 0x3a, 0x89, 0xc4, 0x9f, 0xf4, 0x08, 0xac, 0x92, 0xeb,
+	0x4c, 0xd9, 0x96, 0xb0, 0x88, 0xf6, 0x55, 0xa5, 0x27, 0xa5, 0x83, 0x7a, 0xa6, 0x52, 0xc0, 0x91,
+	0x3d, 0xb9, 0x87, 0x35, 0x9c, 0x93, 0xc2, 0xa4, 0x17, 0x63, 0x65, 0x73, 0x7e, 0x2f, 0x24, 0xc3,
+	0xbe, 0xdc, 0x01, 0x8c, 0xd3, 0x6e, 0x9a, 0x2b, 0x5d, 0x4e, 0x46, 0xfc, 0x10, 0x8b, 0xdb, 0xb0,
+	0xa9, 0x9b, 0xe9, 0x52, 0x15, 0xfa, 0x13, 0xbc, 0xe7, 0xf7, 0x43, 0x32, 0xf4, 0xe5, 0x0e, 0x88,
+	0xbe, 0x1c, 0xdc, 0x54, 0x32, 0xfd, 0xaf, 0x95, 0x0c, 0xa8, 0x9f, 0xcc, 0xd3, 0x0b, 0x70, 0x93,
+	0x11, 0x0a, 0xe9, 0xc9, 0x6d, 0xcc, 0x4e, 0xa9, 0x5f, 0x83, 0xad, 0x4c, 0x69, 0x81, 0x77, 0x90,
+	0x76, 0x78, 0x27, 0xed, 0xaa, 0x58, 0xc8, 0x6d, 0xc5, 0xfe, 0x8e, 0xba, 0xbf, 0xed, 0xa8, 0xd1,
+	0x04, 0x57, 0x95, 0xae, 0x95, 0xd3, 0xa6, 0x3c, 0xb7, 0xdc, 0xc7, 0x0f, 0xb1, 0x87, 0x9d, 0xfc,
+	0x24, 0xb4, 0x8f, 0xad, 0xa1, 0xc6, 0x4d, 0xb2, 0x29, 0xf5, 0xcf, 0xc0, 0x21, 0xc4, 0x9e, 0xde,
+	0x41, 0xa5, 0xfd, 0x41, 0x83, 0xbf, 0xf2, 0x8d, 0x3c, 0x36, 0xa1, 0xfe, 0xf4, 0x9f, 0x9b, 0x4e,
+	0xc1, 0x0d, 0x1e, 0x89, 0xb5, 0x29, 0x44, 0x6b, 0x0a, 0xf1, 0xba, 0x31, 0x45, 0xe4, 0xb1, 0x11,
+	0xed, 0x8c, 0x41, 0x15, 0x2e, 0x67, 0xb7, 0xe4, 0xdc, 0x46, 0x68, 0x67, 0xa3, 0xc8, 0x7b, 0xf9,
+	0xe2, 0xdb, 0x32, 0x20, 0xd7, 0xcb, 0x80, 0xfc, 0x58, 0x06, 0xe4, 0xf3, 0x2a, 0xf0, 0xae, 0x57,
+	0x81, 0xf7, 0x7d, 0x15, 0x78, 0xef, 0x9e, 0x65, 0xda, 0xe5, 0xf3, 0x44, 0xa4, 0xe6, 0x32, 0xde,
+	0xf4, 0xc1, 0x33, 0xbe, 0x8a, 0x5b, 0xf3, 0xba, 0x45, 0x05, 0x36, 0xe9, 0xe0, 0xd8, 0xe7, 0xbf,
+	0x06, 0x00, 0xac, 0x47, 0xea, 0x22, 0x1a, 0x04, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -560,6 +569,11 @@ func (m *RelayCacheSet) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ExpirationMs != 0 {
+		i = encodeVarintRelayCache(dAtA, i, uint64(m.ExpirationMs))
+		i--
+		dAtA[i] = 0x40
+	}
 	if m.Finalized {
 		i--
 		if m.Finalized {
@@ -712,6 +726,9 @@ func (m *RelayCacheSet) Size() (n int) {
 	if m.Finalized {
 		n += 2
 	}
+	if m.ExpirationMs != 0 {
+		n += 1 + sovRelayCache(uint64(m.ExpirationMs))
+	}
 	return n
 }
 
@@ -1264,6 +1281,25 @@ func (m *RelayCacheSet) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Finalized = bool(v != 0)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpirationMs", wireType)
+			}
+			m.ExpirationMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRelayCache
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExpirationMs |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRelayCache(dAtA[iNdEx:])